@@ -0,0 +1,146 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// The orders bundle writes a chain in.
+const (
+	orderLeafFirst = "leaf-first"
+	orderRootFirst = "root-first"
+)
+
+// checkOrder refuses an --order that is neither of the orders.
+func checkOrder(order string) error {
+	if order != orderLeafFirst && order != orderRootFirst {
+		return fmt.Errorf("unknown order %q (one of %s, %s)", order, orderLeafFirst, orderRootFirst)
+	}
+	return nil
+}
+
+// inOrder is chain, which runs leaf first, in order: a reversed copy for
+// root-first.
+func inOrder(chain []*x509.Certificate, order string) []*x509.Certificate {
+	if order != orderRootFirst {
+		return chain
+	}
+	chain = slices.Clone(chain)
+	slices.Reverse(chain)
+	return chain
+}
+
+// bundleCmd joins certificates from several files into one chain file,
+// checking they make a chain rather than concatenating them blind.
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <file>...",
+	Short: "Join certificates into one chain file, checked",
+	Long: `Join the certificates of the files given into one PEM chain file, as
+'cat leaf.pem intermediate.pem root.pem > fullchain.pem' would, but in the
+chain's order whatever order the files were given in, and only once they are
+checked to make one chain: each certificate issued by the next, by name, key
+identifier and signature.
+
+Nothing is written when the certificates make more than one chain, or when
+one of them is not part of the chain built up from the leaf. A chain that
+stops short of a root is written, with a note, since most servers are meant
+to leave the root out; so is one holding an expired certificate.
+
+--order root-first writes the root end first, as some Java and appliance
+tooling wants. Without -o the chain goes to stdout; a file already there is
+left alone unless --force is given.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeCertFiles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		order, err := cmd.Flags().GetString("order")
+		if err != nil {
+			return err
+		}
+		if err := checkOrder(order); err != nil {
+			return err
+		}
+		outFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		if err := refuseOverwrite(outFile, force); err != nil {
+			return err
+		}
+
+		var certs []*x509.Certificate
+		for _, file := range args {
+			infos, err := loadCertificates(cmd, file)
+			if err != nil {
+				logger.Log.Error("Failed to load certificates", zap.String("file", file), zap.Error(err))
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			for _, info := range infos {
+				certs = append(certs, info.Certificate)
+			}
+		}
+
+		chain, err := certificate.AssembleChain(certs)
+		if err != nil {
+			return err
+		}
+		for _, note := range bundleNotes(chain, time.Now()) {
+			fmt.Fprintln(os.Stderr, "Note: "+note)
+		}
+		chain = inOrder(chain, order)
+
+		data, err := certificate.EncodeChain(chain, "pem")
+		if err != nil {
+			return err
+		}
+		if outFile == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := writeFile(outFile, data, 0o644); err != nil {
+			logger.Log.Error("Failed to write bundle", zap.Error(err))
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s, %s: %s\n", outFile, order, certificate.FormatChainNames(chain))
+		return nil
+	},
+}
+
+// bundleNotes are what is worth knowing about a chain that still makes a
+// bundle: that it stops short of a root, or holds a certificate out of date.
+func bundleNotes(chain []*x509.Certificate, now time.Time) []string {
+	var notes []string
+	if top := chain[len(chain)-1]; !certificate.IsSelfSigned(top) {
+		notes = append(notes, fmt.Sprintf("the chain stops at '%s'; its issuer '%s' is not included",
+			top.Subject.CommonName, top.Issuer.CommonName))
+	}
+	for _, cert := range chain {
+		switch {
+		case now.After(cert.NotAfter):
+			notes = append(notes, fmt.Sprintf("'%s' expired on %s", cert.Subject.CommonName, certificate.FormatDate(cert.NotAfter)))
+		case now.Before(cert.NotBefore):
+			notes = append(notes, fmt.Sprintf("'%s' is not valid until %s", cert.Subject.CommonName, certificate.FormatDate(cert.NotBefore)))
+		}
+	}
+	return notes
+}
+
+func init() {
+	bundleCmd.Flags().String("order", orderLeafFirst, "Order to write the chain in: "+orderLeafFirst+" or "+orderRootFirst)
+	bundleCmd.Flags().StringP("output", "o", "", "File to write (default: stdout)")
+	bundleCmd.Flags().BoolP("force", "f", false, "Overwrite the file if it already exists")
+	_ = bundleCmd.RegisterFlagCompletionFunc("order", cobra.FixedCompletions([]string{orderLeafFirst, orderRootFirst}, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.AddCommand(bundleCmd)
+}
@@ -2,10 +2,28 @@ package cmd
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"text/template"
+	"time"
 
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/pkg/certificate"
 	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
 )
 
 func TestRootCommandHelp(t *testing.T) {
@@ -29,7 +47,7 @@ func TestRootCommandHelp(t *testing.T) {
 }
 
 func TestCommandStructure(t *testing.T) {
-	subcommands := []string{"validate", "export", "version", "completion"}
+	subcommands := []string{"validate", "export", "inspect", "list", "convert", "split", "bundle", "verify", "csr", "gen", "key", "ocsp", "crl", "diff", "watch", "fingerprint", "pin", "dane", "expiry", "match", "report", "lint", "config", "version", "completion"}
 
 	for _, name := range subcommands {
 		found := false
@@ -46,6 +64,83 @@ func TestCommandStructure(t *testing.T) {
 	}
 }
 
+// TestRootTakesFiles checks files given to y509 itself are not taken for
+// subcommands.
+func TestRootTakesFiles(t *testing.T) {
+	cmd, args, err := RootCmd.Find([]string{"old.pem", "new.pem"})
+	if err != nil || cmd != RootCmd || len(args) != 2 {
+		t.Errorf("Find = %s, %v, %v", cmd.Name(), args, err)
+	}
+	if err := RootCmd.ValidateArgs(args); err != nil {
+		t.Errorf("ValidateArgs: %v", err)
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	dir := t.TempDir()
+	pemData, err := certificate.EncodeChain([]*x509.Certificate{newTestCert(t, "a.example.com").Certificate, newTestCert(t, "b.example.com").Certificate}, "pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "chain.pem")
+	if err := os.WriteFile(file, pemData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, directive := completeIndex(inspectCmd, []string{file}, "")
+	if len(got) != 2 || got[1] != "1\tb.example.com" || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("completeIndex = %q, %v", got, directive)
+	}
+	// Nothing is read from stdin, or a server, to complete an index.
+	if got, _ := completeIndex(inspectCmd, []string{"example.com:443"}, ""); len(got) != 0 {
+		t.Errorf("completeIndex for a server = %q", got)
+	}
+
+	complete := completeArgs(completeCertFiles, completeFiles(keyFileExtensions...))
+	if got, directive := complete(matchCmd, nil, ""); directive != cobra.ShellCompDirectiveFilterFileExt || !slices.Contains(got, "pem") {
+		t.Errorf("first argument = %q, %v", got, directive)
+	}
+	if got, _ := complete(matchCmd, []string{"cert.pem"}, ""); !slices.Contains(got, "key") {
+		t.Errorf("second argument = %q", got)
+	}
+	if got, directive := complete(matchCmd, []string{"cert.pem", "key.pem"}, ""); len(got) != 0 || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("past the last argument = %q, %v", got, directive)
+	}
+
+	// While a subcommand could still be meant, its name must not be lost
+	// to a filter by extension.
+	if _, directive := completeRootArgs(RootCmd, nil, "li"); directive != cobra.ShellCompDirectiveDefault {
+		t.Errorf("root, subcommand prefix: %v", directive)
+	}
+	if _, directive := completeRootArgs(RootCmd, nil, "chain"); directive != cobra.ShellCompDirectiveFilterFileExt {
+		t.Errorf("root, file prefix: %v", directive)
+	}
+}
+
+// TestLintProfileFlag checks that lint's --profile, which hides the global
+// one, points a configuration profile given to it at Y509_PROFILE.
+func TestLintProfileFlag(t *testing.T) {
+	old := loadedConfig
+	defer func() { loadedConfig = old }()
+	loadedConfig = &config.Config{ProfileNames: []string{"work"}}
+
+	lint := func(profile string) (*certificate.LintProfile, error) {
+		cmd := &cobra.Command{Use: "lint"}
+		cmd.Flags().String("profile", "cabf-br", "")
+		_ = cmd.Flags().Set("profile", profile)
+		return lintProfileFlag(cmd)
+	}
+	if profile, err := lint("mozilla"); err != nil || profile.Name != "mozilla" {
+		t.Errorf("--profile mozilla = %v, %v", profile, err)
+	}
+	if _, err := lint("work"); err == nil || !strings.Contains(err.Error(), "Y509_PROFILE=work") {
+		t.Errorf("--profile work = %v, want it pointed at Y509_PROFILE", err)
+	}
+	if _, err := lint("nosuch"); err == nil || !strings.Contains(err.Error(), "unknown lint profile") {
+		t.Errorf("--profile nosuch = %v", err)
+	}
+}
+
 func TestLooksLikeHost(t *testing.T) {
 	tests := []struct {
 		in   string
@@ -93,3 +188,960 @@ func TestLoadInputRejectsConnectPlusArg(t *testing.T) {
 		t.Errorf("error = %q, want it to explain the conflict", err)
 	}
 }
+
+func TestValidateExitCode(t *testing.T) {
+	cert := &x509.Certificate{}
+	tests := []struct {
+		name   string
+		result *certificate.VerifyResult
+		want   int
+	}{
+		{"self-anchored", &certificate.VerifyResult{Level: certificate.TrustSelfAnchored}, exitSelfAnchored},
+		{"expired", &certificate.VerifyResult{Level: certificate.TrustBroken, Expired: cert}, exitExpired},
+		{"not yet valid", &certificate.VerifyResult{Level: certificate.TrustBroken, NotYetValid: cert}, exitNotYetValid},
+		{"broken", &certificate.VerifyResult{Level: certificate.TrustBroken}, exitBroken},
+	}
+	for _, tt := range tests {
+		if got := validateExitCode(tt.result); got != tt.want {
+			t.Errorf("%s: validateExitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestValidateRevocation checks that validate --check-revocation fails a
+// chain with a revoked certificate, with exit status 5, and leaves the root
+// alone.
+func TestValidateRevocation(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Revoking CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:                    big.NewInt(1),
+			ThisUpdate:                time.Now().Add(-time.Minute),
+			NextUpdate:                time.Now().Add(time.Hour),
+			RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: big.NewInt(2), RevocationTime: time.Now().Add(-time.Minute)}},
+		}, ca, key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(crl)
+	}))
+	defer server.Close()
+
+	leaf := func(serial int64, cn string) *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().AddDate(0, 1, 0),
+			CRLDistributionPoints: []string{server.URL},
+		}, ca, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	checks := checkChainRevocation(t.Context(), []*x509.Certificate{leaf(2, "revoked.example.com"), ca}, certificate.VerifyOptions{})
+	if len(checks) != 1 || checks[0].result.Status != certificate.RevocationRevoked {
+		t.Fatalf("checks = %+v, want the leaf alone, revoked", checks)
+	}
+	var exit *exitError
+	if err := revocationVerdict(checks); !errors.As(err, &exit) || exit.code != exitRevoked {
+		t.Errorf("verdict on a revoked leaf = %v, want exit %d", err, exitRevoked)
+	}
+	var b bytes.Buffer
+	if err := writeRevocation(&b, checks); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "❌ revoked.example.com: revoked on") {
+		t.Errorf("output:\n%s", b.String())
+	}
+	if out := newRevocationJSON(checks); out[0].Status != "revoked" || out[0].RevokedAt == "" {
+		t.Errorf("JSON = %+v", out)
+	}
+
+	// The CA is given as a root rather than in the chain.
+	checks = checkChainRevocation(t.Context(), []*x509.Certificate{leaf(3, "good.example.com")}, certificate.VerifyOptions{ExtraRoots: []*x509.Certificate{ca}})
+	if len(checks) != 1 || checks[0].result.Status != certificate.RevocationGood {
+		t.Fatalf("checks = %+v, want the leaf good", checks)
+	}
+	if err := revocationVerdict(checks); err != nil {
+		t.Errorf("verdict on a good leaf = %v", err)
+	}
+}
+
+// newTestCert issues a self-signed ECDSA certificate for cn, valid for a
+// year, with cn and an address as its SANs.
+func newTestCert(t *testing.T, cn string) *certificate.Info {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Example"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		DNSNames:     []string{cn},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &certificate.Info{Certificate: cert, Index: 0, Label: cn}
+}
+
+// TestWriteDetails checks inspect prints a section for each part of the
+// certificate the TUI shows.
+func TestWriteDetails(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeDetails(&b, newTestCert(t, "www.example.com"), 1, 3); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"Certificate 2 of 3\n",
+		"\nSubject:\n", "www.example.com",
+		"\nSubject Alternative Names:\n",
+		"\nPublic Key:\n", "ECDSA",
+		"\nKey Usage:\n", "digitalSignature",
+		"\nSHA-256 Fingerprint:\n",
+		"\nExtensions:\n", "Subject Alternative Name (2.5.29.17)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("details lack %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestWriteList checks list lines its rows up under the header, with the
+// wide columns only when asked for.
+func TestWriteList(t *testing.T) {
+	certs := []*certificate.Info{newTestCert(t, "www.example.com"), newTestCert(t, "api.example.com")}
+	certificate.ValidateChainLinks(certs)
+
+	var b bytes.Buffer
+	if err := writeList(&b, certs, false, 30); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want a header and 2 rows:\n%s", len(lines), b.String())
+	}
+	if col := strings.Index(lines[0], "STATUS"); col < 0 || !strings.HasPrefix(lines[2][col:], "valid") {
+		t.Errorf("status not aligned under its header:\n%s", b.String())
+	}
+	if !strings.HasPrefix(lines[2], "1  api.example.com") || strings.Contains(b.String(), "SANS") {
+		t.Errorf("unexpected table:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := writeList(&b, certs, true, 30); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "SANS") || !strings.Contains(b.String(), "ECDSA-P256") {
+		t.Errorf("wide table lacks its extra columns:\n%s", b.String())
+	}
+}
+
+// TestCertificatesJSON checks the JSON description keeps its documented
+// field names, and gives empty lists rather than nulls.
+// TestWriteListCSV checks list --output csv and tsv write a header of the
+// columns asked for and a row per certificate, quoting what needs it.
+func TestWriteListCSV(t *testing.T) {
+	certs := []*certificate.Info{newTestCert(t, "www.example.com"), newTestCert(t, "Acme, Inc.")}
+	certs[1].Source = "fleet.pem"
+	certificate.ValidateChainLinks(certs)
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringSlice("columns", nil, "")
+	columns, err := listColumnsFromFlags(cmd, outputCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := writeListCSV(&b, certs, columns, false, 30); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 || lines[0] != strings.Join(defaultListColumns, ",") {
+		t.Fatalf("CSV is not a header and two rows:\n%s", b.String())
+	}
+	if !strings.HasPrefix(lines[2], `1,"Acme, Inc.",`) {
+		t.Errorf("a comma in a name was not quoted: %s", lines[2])
+	}
+
+	_ = cmd.Flags().Set("columns", "common_name,dns_names,source")
+	if columns, err = listColumnsFromFlags(cmd, outputTSV); err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := writeListCSV(&b, certs, columns, true, 30); err != nil {
+		t.Fatal(err)
+	}
+	if want := "common_name\tdns_names\tsource\nwww.example.com\twww.example.com\t\nAcme, Inc.\tAcme, Inc.\tfleet.pem\n"; b.String() != want {
+		t.Errorf("TSV = %q, want %q", b.String(), want)
+	}
+
+	if _, err := listColumnsFromFlags(cmd, outputText); err == nil {
+		t.Error("--columns was taken with the table")
+	}
+	_ = cmd.Flags().Set("columns", "nonsense")
+	if _, err := listColumnsFromFlags(cmd, outputCSV); err == nil {
+		t.Error("an unknown column was taken")
+	}
+}
+
+func TestCertificatesJSON(t *testing.T) {
+	certs := []*certificate.Info{newTestCert(t, "www.example.com")}
+	certificate.ValidateChainLinks(certs)
+
+	var b bytes.Buffer
+	if err := writeJSON(&b, certificatesJSON(certs, 30)); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, b.String())
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d certificates, want 1", len(got))
+	}
+	c := got[0]
+	for field, want := range map[string]any{
+		"index":       0.0,
+		"common_name": "www.example.com",
+		"status":      "valid",
+		"key_type":    "ECDSA-P256",
+		"serial":      "2a",
+	} {
+		if c[field] != want {
+			t.Errorf("%s = %v, want %v", field, c[field], want)
+		}
+	}
+	if ips, ok := c["ip_addresses"].([]any); !ok || len(ips) != 0 {
+		t.Errorf("ip_addresses = %v, want an empty list", c["ip_addresses"])
+	}
+	if sans, ok := c["dns_names"].([]any); !ok || len(sans) != 1 {
+		t.Errorf("dns_names = %v, want the one SAN", c["dns_names"])
+	}
+}
+
+// TestCertificatesYAML checks the YAML output uses the JSON's field names,
+// the verdict of validate included.
+func TestCertificatesYAML(t *testing.T) {
+	certs := []*certificate.Info{newTestCert(t, "www.example.com")}
+	certificate.ValidateChainLinks(certs)
+
+	var b bytes.Buffer
+	if err := writeOutput(&b, outputYAML, certificatesJSON(certs, 30)); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := yaml.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("output is not YAML: %v\n%s", err, b.String())
+	}
+	if len(got) != 1 || got[0]["common_name"] != "www.example.com" || got[0]["key_type"] != "ECDSA-P256" {
+		t.Errorf("unexpected YAML:\n%s", b.String())
+	}
+
+	b.Reset()
+	verdict := validateJSON{verdictJSON: verdictJSON{Level: "trusted"}}
+	if err := writeOutput(&b, outputYAML, verdict); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "\nlevel: trusted\n") {
+		t.Errorf("verdict not inlined at the top level:\n%s", b.String())
+	}
+}
+
+// TestWriteFormatted checks --format prints a line per certificate from the
+// template, with the x509 fields and y509's own.
+func TestWriteFormatted(t *testing.T) {
+	certs := []*certificate.Info{newTestCert(t, "www.example.com"), newTestCert(t, "api.example.com")}
+	certificate.ValidateChainLinks(certs)
+
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(`{{.Index}},{{.Subject.CommonName}},{{upper .Status}},{{join .DNSNames ";"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := writeFormatted(&b, tmpl, certs[1:], 1, 30); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "1,api.example.com,VALID,api.example.com\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// TestWriteFingerprint checks fingerprint prints the digest both ways.
+func TestWriteFingerprint(t *testing.T) {
+	c := newTestCert(t, "www.example.com")
+	var b bytes.Buffer
+	if err := writeFingerprint(&b, c, "sha256", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	hexSum := certificate.FormatFingerprint(c.Certificate)
+	out := b.String()
+	if !strings.Contains(out, "  sha256  "+hexSum+"\n") {
+		t.Errorf("no bare hex fingerprint:\n%s", out)
+	}
+	if !strings.Contains(out, "  sha256  "+strings.ToUpper(hexSum[:2])+":") {
+		t.Errorf("no colon-separated fingerprint:\n%s", out)
+	}
+	if err := writeFingerprint(&b, c, "crc32", 0, 1); err == nil {
+		t.Error("an unknown algorithm was accepted")
+	}
+}
+
+func TestWritePin(t *testing.T) {
+	a, b := newTestCert(t, "a.example.com"), newTestCert(t, "b.example.com")
+	var out bytes.Buffer
+	if err := writePin(&out, a, 0, 2); err != nil {
+		t.Fatal(err)
+	}
+	pin := certificate.SPKIPin(a.Certificate)
+	if !strings.Contains(out.String(), `  pin-sha256="`+pin+`"`) || !strings.Contains(out.String(), "  sha256//"+pin+"\n") {
+		t.Errorf("no HPKP or curl pin:\n%s", out.String())
+	}
+	if got, want := curlPins([]*certificate.Info{a, b}), "sha256//"+pin+";sha256//"+certificate.SPKIPin(b.Certificate); got != want {
+		t.Errorf("curlPins = %q, want %q", got, want)
+	}
+}
+
+func TestWriteTLSA(t *testing.T) {
+	c := newTestCert(t, "mail.example.com")
+	record, err := certificate.NewTLSA(c.Certificate, 3, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := writeTLSA(&b, record, "mail.example.com", 25, "tcp"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "_25._tcp.mail.example.com. IN TLSA " + record.String() + "\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+
+	// Without a host there is no owner name, only the record data.
+	b.Reset()
+	if err := writeTLSA(&b, record, "", 443, "tcp"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "3 1 1 ") {
+		t.Errorf("got %q, want the record data alone", b.String())
+	}
+	if err := writeTLSA(&b, record, "mail.example.com", 25, "quic"); err == nil {
+		t.Error("an unknown protocol was accepted")
+	}
+}
+
+func TestSettleThresholds(t *testing.T) {
+	day := 24 * time.Hour
+	for _, tc := range []struct {
+		name                 string
+		warn, crit           time.Duration
+		warnGiven, critGiven bool
+		wantWarn, wantCrit   time.Duration
+		wantOK               bool
+	}{
+		{"in order", 30 * day, 7 * day, true, true, 30 * day, 7 * day, true},
+		{"default crit over a short warn", 5 * day, 7 * day, false, false, 5 * day, 5 * day, true},
+		{"default crit over --warn", 5 * day, 7 * day, true, false, 5 * day, 5 * day, true},
+		{"--crit over the default warn", 5 * day, 10 * day, false, true, 10 * day, 10 * day, true},
+		{"both given", 5 * day, 10 * day, true, true, 5 * day, 10 * day, false},
+	} {
+		warn, crit, ok := settleThresholds(tc.warn, tc.crit, tc.warnGiven, tc.critGiven)
+		if warn != tc.wantWarn || crit != tc.wantCrit || ok != tc.wantOK {
+			t.Errorf("%s: got %v, %v, %v; want %v, %v, %v", tc.name, warn, crit, ok, tc.wantWarn, tc.wantCrit, tc.wantOK)
+		}
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	day := 24 * time.Hour
+	for in, want := range map[string]time.Duration{
+		"30d": 30 * day,
+		"30":  30 * day,
+		"2w":  14 * day,
+		"72h": 72 * time.Hour,
+		" 7D": 7 * day,
+	} {
+		if got, err := parseThreshold(in); err != nil || got != want {
+			t.Errorf("parseThreshold(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "soon", "-3d", "-1h"} {
+		if _, err := parseThreshold(in); err == nil {
+			t.Errorf("parseThreshold(%q) succeeded", in)
+		}
+	}
+}
+
+// TestExpiryStates checks each certificate is put in the state its time
+// left calls for, and the report in the worst, with a plugin's exit status.
+func TestExpiryStates(t *testing.T) {
+	day := 24 * time.Hour
+	certs := []*certificate.Info{newTestCert(t, "a.example"), newTestCert(t, "b.example")}
+	// Issued a moment apart, so within a minute of each other.
+	notAfter := certs[0].Certificate.NotAfter
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		state string
+		code  int
+	}{
+		{"ok", notAfter.Add(-60 * day), expiryOK, 0},
+		{"warning", notAfter.Add(-20 * day), expiryWarning, exitExpiryWarning},
+		{"critical", notAfter.Add(-3 * day), expiryCritical, exitExpiryCritical},
+		{"expired", notAfter.Add(time.Hour), expiryExpired, exitExpiryCritical},
+	}
+	for _, tt := range tests {
+		report := newExpiryJSON(certs, 30*day, 7*day, tt.now)
+		if report.State != tt.state || report.ExitCode != tt.code {
+			t.Errorf("%s: state %s, exit %d; want %s, %d", tt.name, report.State, report.ExitCode, tt.state, tt.code)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := writeExpiry(&b, newExpiryJSON(certs, 30*day, 7*day, notAfter.Add(-20*day))); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "WARNING: 0 expired, 0 within 7 days, 2 within 30 days") {
+		t.Errorf("unexpected report:\n%s", b.String())
+	}
+}
+
+// TestConvertFormat checks --to wins over the output file's extension, and
+// the extension is enough without it.
+func TestConvertFormat(t *testing.T) {
+	tests := []struct {
+		to, file, want string
+	}{
+		{"", "out.pem", "pem"},
+		{"", "chain.P7B", "pkcs7"},
+		{"", "site.pfx", "pkcs12"},
+		{"", "leaf.cer", "der"},
+		{"der", "out.pem", "der"},
+		{"p12", "", "pkcs12"},
+		{"", "out.txt", ""},
+		{"", "", ""},
+		{"jks", "out.jks", ""},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("to", tt.to, "")
+		got, err := convertFormat(cmd, tt.file)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("convertFormat(--to %q, %q) = %q, want an error", tt.to, tt.file, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("convertFormat(--to %q, %q) = %q, %v; want %q", tt.to, tt.file, got, err, tt.want)
+		}
+	}
+}
+
+// TestWriteReport checks the report in both formats carries the summary,
+// the checks and the lint findings, and that neither format lets a name in
+// a certificate turn into markup.
+func TestWriteReport(t *testing.T) {
+	profile, err := certificate.LoadLintProfile("cabf-br")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &input{Certs: []*certificate.Info{newTestCert(t, "<b>a|b</b>")}}
+	data := newReport(source, certificate.VerifyOptions{ExpiryWarningDays: 30}, profile)
+	if data.Verdict.Level != "self-anchored" || data.Linted != 1 || data.LintFailed != 1 {
+		t.Fatalf("report on a self-signed leaf: %+v", data)
+	}
+
+	var md bytes.Buffer
+	if err := writeReport(&md, "markdown", data); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# Certificate report: stdin\n",
+		"The chain is **self-anchored**",
+		"| 0 | \\<b\\>a\\|b\\</b\\> | ",
+		"| Signature | pass |",
+		"Lint (cabf-br):",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown report lacks %q:\n%s", want, md.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := writeReport(&html, "html", data); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>Certificate report: stdin</title>",
+		`<strong class="self-anchored">self-anchored</strong>`,
+		"&lt;b&gt;a|b&lt;/b&gt;",
+		`<td class="pass">pass</td>`,
+	} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("HTML report lacks %q:\n%s", want, html.String())
+		}
+	}
+	if strings.Contains(html.String(), "<b>a") {
+		t.Error("a common name went into the HTML unescaped")
+	}
+}
+
+// TestReportFormat checks the report format comes from --to, or the
+// extension of -o, and is Markdown on stdout.
+func TestReportFormat(t *testing.T) {
+	tests := []struct {
+		to, file, want string
+	}{
+		{"", "", "markdown"},
+		{"", "report.HTML", "html"},
+		{"", "report.md", "markdown"},
+		{"md", "report.html", "markdown"},
+		{"", "report.txt", ""},
+		{"pdf", "", ""},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("to", tt.to, "")
+		got, err := reportFormat(cmd, tt.file)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("reportFormat(--to %q, %q) = %q, want an error", tt.to, tt.file, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("reportFormat(--to %q, %q) = %q, %v; want %q", tt.to, tt.file, got, err, tt.want)
+		}
+	}
+}
+
+// TestSplitNames checks split's file names, by default and from --name, and
+// that names clashing or leaving the output directory are refused.
+func TestSplitNames(t *testing.T) {
+	certs := []*certificate.Info{newTestCert(t, "a.example"), newTestCert(t, "Issuing CA / R3")}
+
+	names, err := splitNames(certs, nil, "pem")
+	if err != nil || strings.Join(names, " ") != "01-a.example.pem 02-Issuing_CA___R3.pem" {
+		t.Errorf("default names = %q, %v", names, err)
+	}
+
+	tmpl := template.Must(template.New("name").Funcs(templateFuncs).Parse("{{.Index}}-{{.CN}}-{{.Serial}}.{{.Ext}}"))
+	names, err = splitNames(certs, tmpl, "der")
+	if err != nil || strings.Join(names, " ") != "0-a.example-2a.der 1-Issuing_CA___R3-2a.der" {
+		t.Errorf("templated names = %q, %v", names, err)
+	}
+
+	for _, text := range []string{"{{.Serial}}.pem", "../{{.N}}.pem", "/tmp/{{.N}}.pem", ""} {
+		tmpl := template.Must(template.New("name").Parse(text))
+		if names, err := splitNames(certs, tmpl, "pem"); err == nil {
+			t.Errorf("--name %q gave %q, want an error", text, names)
+		}
+	}
+}
+
+// TestBundleNotes checks bundle notes a chain short of its root and a
+// certificate out of date, and says nothing of a complete, current one.
+func TestBundleNotes(t *testing.T) {
+	cert := newTestCert(t, "self.example").Certificate
+	if notes := bundleNotes([]*x509.Certificate{cert}, time.Now()); len(notes) != 0 {
+		t.Errorf("a self-signed, current certificate got notes %q", notes)
+	}
+
+	notes := bundleNotes([]*x509.Certificate{cert}, cert.NotAfter.Add(time.Hour))
+	if len(notes) != 1 || !strings.Contains(notes[0], "expired") {
+		t.Errorf("notes after expiry = %q", notes)
+	}
+
+	issued := *cert
+	issued.RawSubject, issued.RawIssuer = []byte("leaf"), []byte("issuer")
+	issued.Issuer.CommonName = "Issuing CA"
+	notes = bundleNotes([]*x509.Certificate{&issued}, time.Now())
+	if len(notes) != 1 || !strings.Contains(notes[0], "'Issuing CA' is not included") {
+		t.Errorf("notes on a chain short of its root = %q", notes)
+	}
+}
+
+func TestLooksLikeHostName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"api.example.com":   true,
+		"*.example.com":     true,
+		"localhost":         false,
+		"My Service":        false,
+		"ops@example.com":   false,
+		"":                  false,
+		"xn--bcher-kva.org": true,
+	} {
+		if got := looksLikeHostName(name); got != want {
+			t.Errorf("looksLikeHostName(%q) = %v", name, got)
+		}
+	}
+}
+
+func TestGenChainFiles(t *testing.T) {
+	got := strings.Join(genChainFiles([]string{"leaf", "intermediate", "root"}), " ")
+	if want := "leaf.pem leaf.key intermediate.pem intermediate.key root.pem root.key chain.pem fullchain.pem"; got != want {
+		t.Errorf("files = %s", got)
+	}
+	if got := strings.Join(genChainFiles([]string{"leaf", "root"}), " "); strings.Contains(got, "chain.pem ") || !strings.HasSuffix(got, "fullchain.pem") {
+		t.Errorf("files without intermediates = %s", got)
+	}
+}
+
+func TestWriteOCSP(t *testing.T) {
+	c := newTestCert(t, "ocsp.example.com")
+	var b bytes.Buffer
+	report := newOCSPJSON(c.Certificate, 0, certificate.OCSPResult{
+		RevocationResult: certificate.RevocationResult{Status: certificate.RevocationRevoked, RevokedAt: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Reason: 1},
+		Responder:        "http://ocsp.example.com",
+		Elapsed:          120 * time.Millisecond,
+	})
+	if err := writeOCSP(&b, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"❌ Certificate 0: ocsp.example.com is revoked", "Serial:", "2a", "Revoked at:", "2026-05-01 00:00:00 UTC",
+		"Reason:", "keyCompromise", "Time:", "120 ms"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "Next update") {
+		t.Errorf("output has a next update the responder did not give:\n%s", b.String())
+	}
+	if report.RevokedAt != "2026-05-01T00:00:00Z" {
+		t.Errorf("--output revoked_at = %q, want RFC 3339", report.RevokedAt)
+	}
+}
+
+func TestNewCRLJSON(t *testing.T) {
+	chain, err := certificate.GenerateChain("ecdsa-p256",
+		certificate.GenerateOptions{Subject: pkix.Name{CommonName: "CRL CA"}, PathLen: -1}, nil,
+		certificate.GenerateOptions{Subject: pkix.Name{CommonName: "crl.example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, ca := chain[0].Certificate, chain[1]
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(7),
+		ThisUpdate:                time.Now().Add(-48 * time.Hour),
+		NextUpdate:                time.Now().Add(-24 * time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: leaf.SerialNumber, RevocationTime: time.Now().Add(-72 * time.Hour), ReasonCode: 1}},
+	}, ca.Certificate, ca.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := certificate.ParseCRL(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := newCRLJSON("crl.der", crl, leaf, ca.Certificate)
+	if report.Status != "revoked" || report.Reason != "keyCompromise" || report.Number != "7" || report.Entries != 1 || !report.Stale {
+		t.Errorf("report = %+v", report)
+	}
+	var exit *exitError
+	if err := crlVerdict(leaf, []crlJSON{report}); !errors.As(err, &exit) || exit.code != exitRevocationRevoked {
+		t.Errorf("verdict on a revoked certificate = %v", err)
+	}
+	var b bytes.Buffer
+	if err := writeCRL(&b, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "❌ crl.der: revoked") || !strings.Contains(b.String(), "the CRL is stale") {
+		t.Errorf("output:\n%s", b.String())
+	}
+
+	// Not signed by the issuer given, the CRL says nothing of the leaf.
+	impostor, err := certificate.GenerateSelfSignedCert(chain[0].Key, certificate.GenerateOptions{Subject: pkix.Name{CommonName: "CRL CA"}, IsCA: true, PathLen: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report = newCRLJSON("crl.der", crl, leaf, impostor)
+	if report.Status != "unknown" || report.Error == "" || report.Signature != "not verified" {
+		t.Errorf("report against the wrong issuer = %+v", report)
+	}
+	if err := crlVerdict(leaf, []crlJSON{report}); !errors.As(err, &exit) || exit.code != exitRevocationUnknown {
+		t.Errorf("verdict on an unchecked CRL = %v", err)
+	}
+
+	if crlFileName("http://crl.example.com/ca/latest.crl", 0) != "latest.crl" || crlFileName("http://crl.example.com/..", 2) != "crl-3.crl" {
+		t.Error("crlFileName")
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	lines := diffLines([]string{"DNS:a.example.com", "DNS:b.example.com"}, []string{"DNS:a.example.com", "DNS:c.example.com", "DNS:b.example.com"})
+	var got []string
+	for _, l := range lines {
+		got = append(got, string(l.op)+l.text)
+	}
+	want := []string{" DNS:a.example.com", "+DNS:c.example.com", " DNS:b.example.com"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("diffLines = %q, want %q", got, want)
+	}
+
+	// A changed value comes out as its old line, then its new one.
+	lines = diffLines([]string{"old"}, []string{"new"})
+	if len(lines) != 2 || lines[0] != (diffLine{'-', "old"}) || lines[1] != (diffLine{'+', "new"}) {
+		t.Errorf("diffLines on a replaced line = %v", lines)
+	}
+	if len(diffLines(splitValue(""), splitValue("x"))) != 1 {
+		t.Error("an absent field should diff as no lines")
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	a, b := newTestCert(t, "old.example.com"), newTestCert(t, "new.example.com")
+	diffs := certificate.DiffCertificates(a.Certificate, b.Certificate)
+
+	var out bytes.Buffer
+	w, err := colorWriter(&out, "never")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDiff(w, []string{"old.pem", "new.pem"}, diffs, false); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{"--- old.pem", "+++ new.pem", "- CN=old.example.com", "+ CN=new.example.com", "Unchanged: ", "Serial"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("--color never left escape sequences:\n%q", got)
+	}
+
+	out.Reset()
+	if w, err = colorWriter(&out, "always"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDiff(w, []string{"old.pem", "new.pem"}, diffs, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), sgrRed+"- ") || strings.Contains(out.String(), "Unchanged:") {
+		t.Errorf("--color always --all output:\n%q", out.String())
+	}
+	if _, err := colorWriter(&out, "sometimes"); err == nil {
+		t.Error("an unknown --color should be an error")
+	}
+}
+
+func TestParseWatchTargets(t *testing.T) {
+	targets, err := parseWatchTargets([]byte("warn: 14d\ntargets:\n  - address: api.example.com:443\n  - name: bundle\n    file: bundle.pem\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets.Warn != "14d" || len(targets.Targets) != 2 || targets.Targets[0].Name != "api.example.com:443" || targets.Targets[1].Name != "bundle" {
+		t.Errorf("targets = %+v", targets)
+	}
+
+	for _, bad := range []string{
+		"targets: []",
+		"targets:\n  - name: nothing\n",
+		"targets:\n  - file: a.pem\n    address: a.example.com:443\n",
+		"targets:\n  - file: a.pem\n    starttls: smtp\n",
+		"targets:\n  - address: a.example.com:25\n    starttls: gopher\n",
+		"targets: {",
+	} {
+		if _, err := parseWatchTargets([]byte(bad)); err == nil {
+			t.Errorf("parseWatchTargets(%q) should fail", bad)
+		}
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	for in, want := range map[string]time.Duration{"6h": 6 * time.Hour, "1d": 24 * time.Hour, "90m": 90 * time.Minute} {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("interval", "6h", "")
+		_ = cmd.Flags().Set("interval", in)
+		if got, err := watchInterval(cmd); err != nil || got != want {
+			t.Errorf("--interval %s = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"0", "0d", "soon"} {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("interval", "6h", "")
+		_ = cmd.Flags().Set("interval", bad)
+		if _, err := watchInterval(cmd); err == nil {
+			t.Errorf("--interval %s should fail", bad)
+		}
+	}
+}
+
+func TestCheckWatchTarget(t *testing.T) {
+	dir := t.TempDir()
+	cert := newTestCert(t, "watch.example.com")
+	pemData, err := certificate.EncodeChain([]*x509.Certificate{cert.Certificate}, "pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(file, pemData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	day := 24 * time.Hour
+
+	trusted := checkWatchTarget(t.Context(), watchTarget{Name: "cert", File: file, Roots: file}, 30*day, 7*day, time.Second, now)
+	if trusted.State != expiryOK || trusted.Trust != "trusted" || trusted.CommonName != "watch.example.com" || trusted.DaysLeft < 360 {
+		t.Errorf("with its root = %+v", trusted)
+	}
+
+	// Without roots the certificate anchors itself, which is worth a warning.
+	selfAnchored := checkWatchTarget(t.Context(), watchTarget{Name: "cert", File: file}, 30*day, 7*day, time.Second, now)
+	if selfAnchored.State != expiryWarning || selfAnchored.Trust != "self-anchored" {
+		t.Errorf("without roots = %+v", selfAnchored)
+	}
+
+	// A year out, the certificate is close to expiry whatever the trust.
+	expiring := checkWatchTarget(t.Context(), watchTarget{Name: "cert", File: file, Roots: file}, 400*day, 7*day, time.Second, now)
+	if expiring.State != expiryWarning {
+		t.Errorf("within --warn = %+v", expiring)
+	}
+
+	missing := checkWatchTarget(t.Context(), watchTarget{Name: "gone", File: filepath.Join(dir, "gone.pem")}, 30*day, 7*day, time.Second, now)
+	if missing.State != watchUnreachable || missing.Error == "" {
+		t.Errorf("a missing file = %+v", missing)
+	}
+
+	var b bytes.Buffer
+	if err := writeWatchResult(&b, outputText, trusted); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "✅ ok") || !strings.Contains(b.String(), "the chain is trusted") {
+		t.Errorf("text line: %s", b.String())
+	}
+	b.Reset()
+	if err := writeWatchResult(&b, outputJSON, missing); err != nil {
+		t.Fatal(err)
+	}
+	var line watchResultJSON
+	if err := json.Unmarshal(b.Bytes(), &line); err != nil || line.State != watchUnreachable || strings.Count(b.String(), "\n") != 1 {
+		t.Errorf("JSON line %q: %v", b.String(), err)
+	}
+}
+
+func TestExportedChain(t *testing.T) {
+	generated, err := certificate.GenerateChain("ecdsa-p256",
+		certificate.GenerateOptions{Subject: pkix.Name{CommonName: "Order Root"}, PathLen: -1},
+		[]certificate.GenerateOptions{{Subject: pkix.Name{CommonName: "Order Intermediate"}, PathLen: -1}},
+		certificate.GenerateOptions{Subject: pkix.Name{CommonName: "order.example.com"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, intermediate, root := generated[0].Certificate, generated[1].Certificate, generated[2].Certificate
+	pool := []*x509.Certificate{root, leaf, intermediate}
+
+	var notes bytes.Buffer
+	export := func(pool []*x509.Certificate, order string) []*x509.Certificate {
+		t.Helper()
+		chain, err := exportedChain(&notes, leaf, pool, order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chain
+	}
+	if chain := export(pool, orderLeafFirst); !slices.Equal(chain, []*x509.Certificate{leaf, intermediate, root}) {
+		t.Errorf("leaf-first chain = %s", certificate.FormatChainNames(chain))
+	}
+	if chain := export(pool, orderRootFirst); !slices.Equal(chain, []*x509.Certificate{root, intermediate, leaf}) {
+		t.Errorf("root-first chain = %s", certificate.FormatChainNames(chain))
+	}
+	if notes.Len() != 0 {
+		t.Errorf("notes on a whole chain: %s", notes.String())
+	}
+
+	// Without the root, the chain is written with a note saying so.
+	notes.Reset()
+	if chain := export([]*x509.Certificate{intermediate, leaf}, orderRootFirst); !slices.Equal(chain, []*x509.Certificate{intermediate, leaf}) {
+		t.Errorf("root-first chain without the root = %s", certificate.FormatChainNames(chain))
+	}
+	if !strings.Contains(notes.String(), "Note: ") {
+		t.Error("no note on a chain without its root")
+	}
+
+	for _, order := range []string{orderLeafFirst, orderRootFirst} {
+		if err := checkOrder(order); err != nil {
+			t.Errorf("checkOrder(%q) = %v", order, err)
+		}
+	}
+	if err := checkOrder("sideways"); err == nil || !strings.Contains(err.Error(), "root-first") {
+		t.Errorf("checkOrder(sideways) = %v, want the orders listed", err)
+	}
+}
+
+func TestExportEach(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	certs := []*x509.Certificate{newTestCert(t, "a.example.com").Certificate, newTestCert(t, "b.example.com").Certificate}
+	if err := exportEach(certs, "der", dir, false); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"01-a.example.com.der", "02-b.example.com.der"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, certs[i].Raw) {
+			t.Errorf("%s does not hold certificate %d", name, i)
+		}
+	}
+
+	// Nothing is overwritten without force, and nothing at all is written
+	// when one file is in the way.
+	if err := os.Remove(filepath.Join(dir, "02-b.example.com.der")); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportEach(certs, "der", dir, false); err == nil {
+		t.Error("exportEach overwrote a file without force")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "02-b.example.com.der")); err == nil {
+		t.Error("exportEach wrote some files before refusing")
+	}
+	if err := exportEach(certs, "der", dir, true); err != nil {
+		t.Errorf("with force: %v", err)
+	}
+	if err := exportEach(certs, "gif", dir, true); err == nil {
+		t.Error("an unknown format should be an error")
+	}
+}
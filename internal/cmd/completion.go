@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/pkg/certificate"
 	"github.com/spf13/cobra"
 )
 
@@ -59,6 +63,98 @@ PowerShell:
 	},
 }
 
+// The extensions file arguments are completed with, by what the file holds.
+// Directories are offered too, to complete paths through them.
+var (
+	certFileExtensions = func() []string {
+		exts := make([]string, len(certExtensions))
+		for i, ext := range certExtensions {
+			exts[i] = strings.TrimPrefix(ext, ".")
+		}
+		return exts
+	}()
+	keyFileExtensions  = []string{"key", "pem", "der"}
+	csrFileExtensions  = []string{"csr", "pem", "der"}
+	crlFileExtensions  = []string{"crl", "pem", "der"}
+	yamlFileExtensions = []string{"yaml", "yml"}
+)
+
+// completeFiles completes an argument with the paths of files with one of
+// exts.
+func completeFiles(exts ...string) cobra.CompletionFunc {
+	return func(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return exts, cobra.ShellCompDirectiveFilterFileExt
+	}
+}
+
+// completeCertFiles completes every argument with certificate files. An
+// argument can be a host:port too, but there is nothing to offer for that.
+var completeCertFiles = completeFiles(certFileExtensions...)
+
+// completeRootArgs completes y509's own arguments with certificate files.
+// The first could also be a subcommand, whose names cobra adds to these; as
+// a filter by extension would swallow them, the shell's own completion is
+// left to it while a subcommand still could be meant.
+func completeRootArgs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() && strings.HasPrefix(sub.Name(), toComplete) {
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+		}
+	}
+	return completeCertFiles(cmd, args, toComplete)
+}
+
+// completeArgs completes each positional argument with the function at its
+// position, and none past the last.
+func completeArgs(funcs ...cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) >= len(funcs) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return funcs[len(args)](cmd, args, toComplete)
+	}
+}
+
+// completeIndex completes --index with the indexes of the certificates in
+// the input, each described by its common name: the first argument when it
+// is a file, else --input. A completion neither dials a server nor waits on
+// stdin, so for those there is nothing to offer.
+func completeIndex(cmd *cobra.Command, args []string, _ string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		if info, err := os.Stat(args[0]); err == nil && info.Mode().IsRegular() {
+			return indexCompletions(args[0]), cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	input, _ := cmd.Flags().GetString("input")
+	return indexCompletions(input), cobra.ShellCompDirectiveNoFileComp
+}
+
+// indexCompletions are the indexes of the certificates in file, or none
+// when it cannot be read.
+func indexCompletions(file string) []cobra.Completion {
+	if file == "" {
+		return nil
+	}
+	certs, err := certificate.LoadCertificates(file)
+	if err != nil {
+		return nil
+	}
+	completions := make([]cobra.Completion, len(certs))
+	for i, c := range certs {
+		completions[i] = cobra.CompletionWithDesc(strconv.Itoa(i), orNone(c.Certificate.Subject.CommonName))
+	}
+	return completions
+}
+
+// completeConfigProfile completes --profile with the profiles of the
+// configuration file.
+func completeConfigProfile(*cobra.Command, []string, string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	cfg, _ := config.LoadConfig()
+	return cfg.ProfileNames, cobra.ShellCompDirectiveNoFileComp
+}
+
 func init() {
 	RootCmd.AddCommand(completionCmd)
 }
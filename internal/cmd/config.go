@@ -0,0 +1,115 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/internal/model"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// configCmd groups the commands that write and show the configuration.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Write or show the configuration",
+	Long: `Write a configuration file to start from, or show the configuration in force.
+
+y509 reads ~/.config/y509/` + config.FileName + `, or ` + config.FileName + ` under $XDG_CONFIG_HOME/y509
+when that is set. Failing that it reads ` + config.LegacyFileName + ` from the home directory,
+or else the working one, where earlier versions kept it. Y509_<SETTING> in the
+environment overrides the file.`,
+}
+
+// configInitCmd writes a commented configuration file.
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a commented configuration file",
+	Long: `Write a configuration file with every setting at its default, each explained:
+the behaviour settings set, and the theme's colours and the key bindings
+commented out, so that the preset and the built-in keys show through until
+one is uncommented.
+
+It goes to ~/.config/y509/` + config.FileName + ` (under $XDG_CONFIG_HOME if set), or with
+-o to another file. A file already there is left alone unless --force is
+given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		path, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			if path, err = config.DefaultPath(); err != nil {
+				return err
+			}
+		}
+		if err := refuseOverwrite(path, force); err != nil {
+			return err
+		}
+		previous := config.Find()
+
+		keys, err := model.KeyBindings(nil)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(path, config.Template(keys), 0o644); err != nil {
+			logger.Log.Error("Failed to write configuration", zap.String("filename", path), zap.Error(err))
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote the configuration to %s\n", path)
+		if previous != "" && previous != path && config.Find() == path {
+			fmt.Fprintf(os.Stderr, "Note: it is read in place of %s, which is now ignored\n", previous)
+		}
+		return nil
+	},
+}
+
+// configShowCmd prints the configuration in force.
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the configuration in force",
+	Long: `Print the configuration in force, as a configuration file would set it: the
+file and the environment merged over the defaults, the theme's colours laid
+over its preset, and every key binding, rebound or not. A first comment names
+the file read, if any.`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			logger.Log.Error("Failed to load configuration", zap.Error(err))
+			return err
+		}
+		keys, err := model.KeyBindings(cfg.Keys)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Note: "+err.Error())
+		}
+		data, err := cfg.YAML(keys)
+		if err != nil {
+			return err
+		}
+
+		if cfg.File != "" {
+			fmt.Printf("# Read from %s\n", cfg.File)
+		} else {
+			fmt.Println("# No configuration file; these are the defaults")
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+func init() {
+	configInitCmd.Flags().StringP("output", "o", "", "Write to this file (default: ~/.config/y509/"+config.FileName+")")
+	configInitCmd.Flags().BoolP("force", "f", false, "Overwrite the file if it already exists")
+	_ = configInitCmd.MarkFlagFilename("output", "yaml", "yml")
+	configCmd.AddCommand(configInitCmd, configShowCmd)
+	RootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,176 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// convertFormats are the formats convert writes.
+var convertFormats = []string{"pem", "der", "pkcs7", "pkcs12"}
+
+// convertExtensions names the format a file extension stands for, when
+// --to is not given.
+var convertExtensions = map[string]string{
+	".pem": "pem", ".crt": "pem", ".cert": "pem",
+	".der": "der", ".cer": "der",
+	".p7b": "pkcs7", ".p7c": "pkcs7",
+	".p12": "pkcs12", ".pfx": "pkcs12",
+}
+
+// convertCmd rewrites the input in another format, in place of the openssl
+// x509, crl2pkcs7 and pkcs12 commands it would otherwise take.
+var convertCmd = &cobra.Command{
+	Use:   "convert [file | host:port]",
+	Short: "Convert certificates between PEM, DER, PKCS#7 and PKCS#12",
+	Long: `Write the certificates of the input in another format: pem, der, pkcs7
+(a certs-only .p7b bundle) or pkcs12. The input can be any of the first three,
+so 'y509 convert in.p7b --to pem -o out.pem' unpacks a bundle a CA sent. der
+holds a single certificate, so a chain goes to pem or pkcs7.
+
+--to is taken from the extension of -o when not given: .pem, .crt, .der, .cer,
+.p7b, .p7c, .p12 or .pfx. Without -o the result goes to stdout.
+
+pkcs12 needs the private key, with --key. The certificate the key belongs to
+goes first, then the chain built up from it through the rest of the input. The
+key is encrypted with --password (PBES2 with AES-256), and the file carries a
+SHA-256 MAC, as OpenSSL 3 writes them.
+
+A file already there is left alone unless --force is given.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		to, err := convertFormat(cmd, outFile)
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		if err := refuseOverwrite(outFile, force); err != nil {
+			return err
+		}
+
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+		certs := make([]*x509.Certificate, len(source.Certs))
+		for i, c := range source.Certs {
+			certs[i] = c.Certificate
+		}
+
+		data, written, err := convertCertificates(cmd, certs, to)
+		if err != nil {
+			return err
+		}
+
+		if outFile == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		// A PKCS#12 file holds the private key, so only its owner reads it.
+		perm := os.FileMode(0o644)
+		if to == "pkcs12" {
+			perm = 0o600
+		}
+		if err := writeFile(outFile, data, perm); err != nil {
+			logger.Log.Error("Failed to write converted certificates", zap.Error(err))
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d certificate(s) as %s to %s\n", len(written), to, outFile)
+		return nil
+	},
+}
+
+// convertFormat is the format to write: --to, or else the one the output
+// file's extension stands for.
+func convertFormat(cmd *cobra.Command, outFile string) (string, error) {
+	to, err := cmd.Flags().GetString("to")
+	if err != nil {
+		return "", err
+	}
+	to = strings.ToLower(to)
+	if to == "" {
+		to = convertExtensions[strings.ToLower(filepath.Ext(outFile))]
+		if to == "" {
+			return "", fmt.Errorf("give --to (one of %s): it cannot be told from the output file name", strings.Join(convertFormats, ", "))
+		}
+	}
+	switch to {
+	case "p7b", "p7c":
+		to = "pkcs7"
+	case "p12", "pfx":
+		to = "pkcs12"
+	}
+	if !slices.Contains(convertFormats, to) {
+		return "", fmt.Errorf("unknown format %q (one of %s)", to, strings.Join(convertFormats, ", "))
+	}
+	return to, nil
+}
+
+// convertCertificates encodes the certificates in the format to, and says
+// which went in: all of them, but for pkcs12 only the certificate --key
+// belongs to and the chain above it.
+func convertCertificates(cmd *cobra.Command, certs []*x509.Certificate, to string) (data []byte, written []*x509.Certificate, err error) {
+	keyFile, err := cmd.Flags().GetString("key")
+	if err != nil {
+		return nil, nil, err
+	}
+	if to != "pkcs12" {
+		if keyFile != "" {
+			return nil, nil, fmt.Errorf("--key goes only with --to pkcs12")
+		}
+		data, err := certificate.EncodeChain(certs, to)
+		return data, certs, err
+	}
+
+	if keyFile == "" {
+		return nil, nil, fmt.Errorf("--to pkcs12 needs the private key: give --key")
+	}
+	key, err := certificate.LoadPrivateKey(keyFile)
+	if err != nil {
+		logger.Log.Error("Failed to load private key", zap.Error(err))
+		return nil, nil, err
+	}
+	password, err := cmd.Flags().GetString("password")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, cert := range certs {
+		if certificate.KeyMatches(cert, key) {
+			chain := certificate.BuildChain(cert, certs, time.Time{})
+			data, err := certificate.EncodePKCS12(key, chain, password)
+			return data, chain, err
+		}
+	}
+	return nil, nil, fmt.Errorf("the %s private key matches none of the %d certificate(s) in the input",
+		certificate.DescribePrivateKey(key), len(certs))
+}
+
+func init() {
+	convertCmd.Flags().String("to", "", "Format to write: "+strings.Join(convertFormats, ", ")+" (default: from the -o extension)")
+	convertCmd.Flags().StringP("output", "o", "", "File to write (default: stdout)")
+	convertCmd.Flags().String("key", "", "Private key to put in a pkcs12 file")
+	convertCmd.Flags().String("password", "", "Password to encrypt a pkcs12 file with")
+	convertCmd.Flags().BoolP("force", "f", false, "Overwrite the file if it already exists")
+	_ = convertCmd.MarkFlagFilename("key", keyFileExtensions...)
+	_ = convertCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions(convertFormats, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.AddCommand(convertCmd)
+}
@@ -0,0 +1,331 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// crlCmd groups the commands that work with certificate revocation lists.
+var crlCmd = &cobra.Command{
+	Use:   "crl",
+	Short: "Fetch and check certificate revocation lists",
+}
+
+// crlFetchCmd downloads the CRLs a certificate names and sums them up.
+var crlFetchCmd = &cobra.Command{
+	Use:   "fetch [file | host:port]",
+	Short: "Download and summarize the CRLs a certificate names",
+	Long: `Download each CRL named in the CRL distribution points of the leaf, or of
+the certificate at --index, counting from 0, and sum it up: its issuer and
+number, the period it covers, how many certificates it lists, how big it is
+and how long it took to download, and whether the certificate is on it.
+
+The CRL's signature is checked when the certificate's issuer is in the input
+or given with --issuer. --save writes each CRL, as downloaded, to a
+directory, for crl check to use offline later.
+
+Exit status, as ocsp's: 0 when the certificate is on none of the CRLs, 1
+when a CRL could not be had or checked, 2 when the certificate is revoked.
+With --output json or yaml, the CRLs come as a list of objects.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		saveDir, err := cmd.Flags().GetString("save")
+		if err != nil {
+			return err
+		}
+		cert, issuer, err := revocationSubject(cmd, args)
+		if err != nil {
+			return err
+		}
+		if len(cert.CRLDistributionPoints) == 0 {
+			return fmt.Errorf("'%s' names no CRL distribution point", orNone(cert.Subject.CommonName))
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var reports []crlJSON
+		for _, url := range cert.CRLDistributionPoints {
+			start := time.Now()
+			crl, err := certificate.FetchCRL(ctx, url)
+			elapsed := time.Since(start)
+			if err != nil {
+				reports = append(reports, crlJSON{Source: url, Status: certificate.RevocationUnknown.String(), Error: err.Error()})
+				continue
+			}
+			report := newCRLJSON(url, crl, cert, issuer)
+			report.ElapsedMS = elapsed.Milliseconds()
+			if saveDir != "" {
+				file := filepath.Join(saveDir, crlFileName(url, len(reports)))
+				if err := writeFile(file, crl.Raw, 0o644); err != nil {
+					logger.Log.Error("Failed to save CRL", zap.String("filename", file), zap.Error(err))
+					return err
+				}
+				report.Saved = file
+			}
+			reports = append(reports, report)
+		}
+
+		if format != outputText {
+			err = writeOutput(os.Stdout, format, reports)
+		} else {
+			for i, report := range reports {
+				if i > 0 {
+					fmt.Println()
+				}
+				if err = writeCRL(os.Stdout, report); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return crlVerdict(cert, reports)
+	},
+}
+
+// crlCheckCmd looks a certificate up in a CRL on disk.
+var crlCheckCmd = &cobra.Command{
+	Use:   "check <file | host:port> <crl>",
+	Short: "Check a certificate against a CRL file, offline",
+	Long: `Look the leaf, or the certificate at --index, counting from 0, up in a CRL
+file, PEM or DER, without going to the network: to test revocation where the
+distribution point cannot be reached, or against a CRL saved earlier with
+crl fetch --save.
+
+The CRL must be issued under the name of the certificate's issuer, and its
+signature is checked when the issuer is in the input or given with
+--issuer. A CRL past its next update is still read, with a note.
+
+Exit status, as ocsp's: 0 when the certificate is not on the CRL, 1 when the
+CRL says nothing about it, 2 when the certificate is revoked. With --output
+json or yaml, the CRL comes as one object.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeArgs(completeCertFiles, completeFiles(crlFileExtensions...)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		cert, issuer, err := revocationSubject(cmd, args[:1])
+		if err != nil {
+			return err
+		}
+		crl, err := certificate.LoadCRL(args[1])
+		if err != nil {
+			logger.Log.Error("Failed to load CRL", zap.Error(err))
+			return err
+		}
+
+		report := newCRLJSON(args[1], crl, cert, issuer)
+		if format != outputText {
+			err = writeOutput(os.Stdout, format, report)
+		} else {
+			err = writeCRL(os.Stdout, report)
+		}
+		if err != nil {
+			return err
+		}
+		return crlVerdict(cert, []crlJSON{report})
+	},
+}
+
+// revocationSubject loads the input and picks the certificate at --index,
+// and its issuer from the input and --issuer, nil when it is in neither.
+func revocationSubject(cmd *cobra.Command, args []string) (cert, issuer *x509.Certificate, err error) {
+	index, err := cmd.Flags().GetInt("index")
+	if err != nil {
+		return nil, nil, err
+	}
+	issuerFile, err := cmd.Flags().GetString("issuer")
+	if err != nil {
+		return nil, nil, err
+	}
+	source, err := loadInput(cmd, args)
+	if err != nil {
+		logger.Log.Error("Error loading certificates", zap.Error(err))
+		return nil, nil, err
+	}
+	if index < 0 || index >= len(source.Certs) {
+		return nil, nil, fmt.Errorf("certificate index %d out of range: the input has %d", index, len(source.Certs))
+	}
+	pool := make([]*x509.Certificate, len(source.Certs))
+	for i, c := range source.Certs {
+		pool[i] = c.Certificate
+	}
+	if issuerFile != "" {
+		issuers, err := certificate.LoadCertificates(issuerFile)
+		if err != nil {
+			logger.Log.Error("Failed to load issuer", zap.Error(err))
+			return nil, nil, fmt.Errorf("--issuer: %w", err)
+		}
+		for _, c := range issuers {
+			pool = append(pool, c.Certificate)
+		}
+	}
+	cert = pool[index]
+	return cert, certificate.IssuerOf(cert, pool), nil
+}
+
+// crlJSON is a CRL as crl fetch and crl check sum it up, with what it says
+// of the certificate. The times are RFC 3339, and empty when not known.
+type crlJSON struct {
+	Source     string `json:"source" yaml:"source"`
+	Issuer     string `json:"issuer" yaml:"issuer"`
+	Number     string `json:"number" yaml:"number"`
+	ThisUpdate string `json:"this_update" yaml:"this_update"`
+	NextUpdate string `json:"next_update" yaml:"next_update"`
+	Stale      bool   `json:"stale" yaml:"stale"`
+	Entries    int    `json:"entries" yaml:"entries"`
+	Size       int    `json:"size" yaml:"size"`
+	ElapsedMS  int64  `json:"elapsed_ms" yaml:"elapsed_ms"`
+	Signature  string `json:"signature" yaml:"signature"`
+	Status     string `json:"status" yaml:"status"`
+	RevokedAt  string `json:"revoked_at" yaml:"revoked_at"`
+	Reason     string `json:"reason" yaml:"reason"`
+	Saved      string `json:"saved" yaml:"saved"`
+	Error      string `json:"error" yaml:"error"`
+}
+
+// newCRLJSON sums up crl, read from source, and looks cert up in it. The
+// signature is checked only when issuer is known.
+func newCRLJSON(source string, crl *x509.RevocationList, cert, issuer *x509.Certificate) crlJSON {
+	out := crlJSON{
+		Source:     source,
+		Issuer:     crl.Issuer.String(),
+		ThisUpdate: rfc3339(crl.ThisUpdate),
+		NextUpdate: rfc3339(crl.NextUpdate),
+		Stale:      !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate),
+		Entries:    len(crl.RevokedCertificateEntries),
+		Size:       len(crl.Raw),
+		Signature:  "not checked: the issuer is not in the input",
+	}
+	if crl.Number != nil {
+		out.Number = crl.Number.String()
+	}
+	if issuer != nil {
+		out.Signature = fmt.Sprintf("verified with '%s'", orNone(issuer.Subject.CommonName))
+	}
+
+	result, err := certificate.LookupCRL(crl, cert, issuer)
+	out.Status = result.Status.String()
+	if err != nil {
+		out.Error = err.Error()
+		if issuer != nil {
+			out.Signature = "not verified"
+		}
+		return out
+	}
+	if result.Err != nil {
+		out.Error = result.Err.Error()
+	}
+	if result.Status == certificate.RevocationRevoked {
+		out.RevokedAt = rfc3339(result.RevokedAt)
+		out.Reason = certificate.RevocationReasonName(result.Reason)
+	}
+	return out
+}
+
+// crlFileName names a CRL saved from url: the last element of its path,
+// or one made of n when that is no name at all.
+func crlFileName(url string, n int) string {
+	name := certificate.SafeFileName(path.Base(url))
+	if name == "" {
+		name = fmt.Sprintf("crl-%d.crl", n+1)
+	}
+	return name
+}
+
+// crlVerdict is the exit of crl fetch and crl check: revoked when any CRL
+// lists the certificate, unknown when one could not be had or checked.
+func crlVerdict(cert *x509.Certificate, reports []crlJSON) error {
+	unknown := 0
+	for _, report := range reports {
+		switch report.Status {
+		case certificate.RevocationRevoked.String():
+			return &exitError{code: exitRevocationRevoked, err: fmt.Errorf("'%s' is revoked", orNone(cert.Subject.CommonName))}
+		case certificate.RevocationUnknown.String():
+			unknown++
+		}
+	}
+	if unknown > 0 {
+		return &exitError{code: exitRevocationUnknown, err: fmt.Errorf("%d of %d CRL(s) could not be checked", unknown, len(reports))}
+	}
+	return nil
+}
+
+// writeCRL writes a CRL's summary a field to a line.
+func writeCRL(w io.Writer, r crlJSON) error {
+	mark := map[string]string{"good": "✅", "revoked": "❌"}[r.Status]
+	if mark == "" {
+		mark = "⚠️"
+	}
+	verdict := map[string]string{"good": "not on it", "revoked": "revoked"}[r.Status]
+	if verdict == "" {
+		verdict = "unknown"
+	}
+	var rows strings.Builder
+	fmt.Fprintf(&rows, "%s %s: %s\n", mark, r.Source, verdict)
+	nextUpdate := r.NextUpdate
+	if r.Stale {
+		nextUpdate += " (passed; the CRL is stale)"
+	}
+	fields := []struct{ name, value string }{
+		{"Issuer", r.Issuer},
+		{"Number", r.Number},
+		{"This update", r.ThisUpdate},
+		{"Next update", nextUpdate},
+		{"Signature", r.Signature},
+		{"Revoked at", r.RevokedAt},
+		{"Reason", r.Reason},
+		{"Saved to", r.Saved},
+		{"Error", r.Error},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			fmt.Fprintf(&rows, "  %s:\t%s\n", f.name, f.value)
+		}
+	}
+	if r.Issuer != "" {
+		fmt.Fprintf(&rows, "  Entries:\t%d\n", r.Entries)
+		fmt.Fprintf(&rows, "  Size:\t%d bytes\n", r.Size)
+	}
+	if r.ElapsedMS > 0 {
+		fmt.Fprintf(&rows, "  Time:\t%d ms\n", r.ElapsedMS)
+	}
+	return writeTable(w, rows.String())
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{crlFetchCmd, crlCheckCmd} {
+		cmd.Flags().Int("index", 0, "Check the certificate at this index, counting from 0")
+		cmd.Flags().String("issuer", "", "PEM file holding the issuer, when it is not in the input")
+		addOutputFlag(cmd)
+		_ = cmd.RegisterFlagCompletionFunc("index", completeIndex)
+		_ = cmd.MarkFlagFilename("issuer", certFileExtensions...)
+		crlCmd.AddCommand(cmd)
+	}
+	crlFetchCmd.Flags().String("save", "", "Directory to save each CRL to, as downloaded")
+	_ = crlFetchCmd.MarkFlagDirname("save")
+	RootCmd.AddCommand(crlCmd)
+}
@@ -0,0 +1,223 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto"
+	"crypto/x509/pkix"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// csrCmd makes a key and a certificate signing request for it.
+var csrCmd = &cobra.Command{
+	Use:   "csr",
+	Short: "Generate a key and a certificate signing request",
+	Long: `Generate a private key and a PKCS#10 certificate signing request for it, to
+send to a CA, in place of an openssl req command and its config file.
+
+The subject is built from --cn, --org, --org-unit, --country, --state and
+--locality, each but --cn repeatable. --san takes subject alternative names,
+several separated by commas or in several flags: dns:api.example.com,
+ip:10.0.0.1, email:ops@example.com or uri:spiffe://example.org/api; a name
+without a prefix is an IP address, an email address or a DNS name by its
+look. Without --san, a --cn that looks like a host name is put in as a DNS
+name too, since CAs and browsers go by the SANs alone.
+
+The key is made of --key-type (ecdsa-p256 by default; ecdsa-p384, ed25519,
+rsa-2048, rsa-3072 or rsa-4096) and written to --key-out as unencrypted
+PKCS#8, readable by its owner only. --key signs the request with a key you
+already have instead. The request goes to -o, or to stdout.
+
+A file already there is left alone unless --force is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		subject, err := subjectFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		sans, err := sansFromFlags(cmd, subject.CommonName)
+		if err != nil {
+			return err
+		}
+		if subject.CommonName == "" && sans.Empty() {
+			return fmt.Errorf("give the request a name: --cn, --san or both")
+		}
+		outFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		if err := refuseOverwrite(outFile, force); err != nil {
+			return err
+		}
+
+		key, keyOut, err := keyFromFlags(cmd, force)
+		if err != nil {
+			return err
+		}
+		csr, err := certificate.CreateCSR(key, subject, sans)
+		if err != nil {
+			return err
+		}
+
+		if keyOut != "" {
+			if err := writeKey(keyOut, key); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Wrote the %s private key to %s\n", certificate.DescribePrivateKey(key), keyOut)
+		}
+		if outFile == "" {
+			_, err := os.Stdout.Write(csr)
+			return err
+		}
+		if err := writeFile(outFile, csr, 0o644); err != nil {
+			logger.Log.Error("Failed to write certificate request", zap.Error(err))
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote the certificate request to %s\n", outFile)
+		return nil
+	},
+}
+
+// addSubjectFlags gives a command the flags for a subject and its SANs.
+func addSubjectFlags(cmd *cobra.Command) {
+	cmd.Flags().String("cn", "", "Subject common name")
+	cmd.Flags().StringArray("org", nil, "Subject organization")
+	cmd.Flags().StringArray("org-unit", nil, "Subject organizational unit")
+	cmd.Flags().StringArray("country", nil, "Subject country, as a two-letter code")
+	cmd.Flags().StringArray("state", nil, "Subject state or province")
+	cmd.Flags().StringArray("locality", nil, "Subject locality or city")
+	cmd.Flags().StringSlice("san", nil, "Subject alternative names: dns:NAME, ip:ADDR, email:ADDR or uri:URI")
+}
+
+// subjectFromFlags is the subject the flags of addSubjectFlags describe.
+func subjectFromFlags(cmd *cobra.Command) (pkix.Name, error) {
+	var subject pkix.Name
+	var err error
+	if subject.CommonName, err = cmd.Flags().GetString("cn"); err != nil {
+		return subject, err
+	}
+	for flag, field := range map[string]*[]string{
+		"org":      &subject.Organization,
+		"org-unit": &subject.OrganizationalUnit,
+		"country":  &subject.Country,
+		"state":    &subject.Province,
+		"locality": &subject.Locality,
+	} {
+		if *field, err = cmd.Flags().GetStringArray(flag); err != nil {
+			return subject, err
+		}
+	}
+	for _, country := range subject.Country {
+		if len(country) != 2 {
+			return subject, fmt.Errorf("--country %q: use the two-letter code, as US or JP", country)
+		}
+	}
+	return subject, nil
+}
+
+// sansFromFlags reads --san. Without it, a common name that looks like a
+// host name stands in as the one DNS name.
+func sansFromFlags(cmd *cobra.Command, commonName string) (certificate.SANs, error) {
+	names, err := cmd.Flags().GetStringSlice("san")
+	if err != nil {
+		return certificate.SANs{}, err
+	}
+	if len(names) == 0 && looksLikeHostName(commonName) {
+		names = []string{"dns:" + commonName}
+	}
+	sans, err := certificate.ParseSANs(names)
+	if err != nil {
+		return sans, fmt.Errorf("--san: %w", err)
+	}
+	return sans, nil
+}
+
+// looksLikeHostName reports whether name could be a DNS name: dotted, and
+// only letters, digits, hyphens and a leading wildcard.
+func looksLikeHostName(name string) bool {
+	name = strings.TrimPrefix(name, "*.")
+	if !strings.Contains(name, ".") {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '.' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// addKeyFlags gives a command the flags for a key to make or reuse.
+func addKeyFlags(cmd *cobra.Command) {
+	cmd.Flags().String("key-type", certificate.KeyTypes[0], "Type of key to generate: "+strings.Join(certificate.KeyTypes, ", "))
+	cmd.Flags().String("key-out", "", "File to write the generated private key to")
+	cmd.Flags().String("key", "", "Use this private key instead of generating one")
+	_ = cmd.RegisterFlagCompletionFunc("key-type", cobra.FixedCompletions(certificate.KeyTypes, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.MarkFlagFilename("key", keyFileExtensions...)
+}
+
+// keyFromFlags loads --key, or generates a key of --key-type to be written
+// to keyOut, the --key-out file, which it must have.
+func keyFromFlags(cmd *cobra.Command, force bool) (key crypto.Signer, keyOut string, err error) {
+	keyFile, err := cmd.Flags().GetString("key")
+	if err != nil {
+		return nil, "", err
+	}
+	if keyOut, err = cmd.Flags().GetString("key-out"); err != nil {
+		return nil, "", err
+	}
+	if keyFile != "" {
+		if keyOut != "" {
+			return nil, "", fmt.Errorf("--key and --key-out do not go together: the key is already in %s", keyFile)
+		}
+		key, err := certificate.LoadPrivateKey(keyFile)
+		if err != nil {
+			logger.Log.Error("Failed to load private key", zap.Error(err))
+		}
+		return key, "", err
+	}
+
+	if keyOut == "" {
+		return nil, "", fmt.Errorf("give --key-out to keep the key that is generated, or --key to use one you have")
+	}
+	if err := refuseOverwrite(keyOut, force); err != nil {
+		return nil, "", err
+	}
+	keyType, err := cmd.Flags().GetString("key-type")
+	if err != nil {
+		return nil, "", err
+	}
+	key, err = certificate.GenerateKey(keyType)
+	return key, keyOut, err
+}
+
+// writeKey writes a private key as PKCS#8 PEM, for its owner's eyes only.
+func writeKey(path string, key crypto.Signer) error {
+	data, err := certificate.EncodePrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(path, data, 0o600); err != nil {
+		logger.Log.Error("Failed to write private key", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func init() {
+	addSubjectFlags(csrCmd)
+	addKeyFlags(csrCmd)
+	csrCmd.Flags().StringP("output", "o", "", "File to write the request to (default: stdout)")
+	csrCmd.Flags().BoolP("force", "f", false, "Overwrite files that already exist")
+	RootCmd.AddCommand(csrCmd)
+}
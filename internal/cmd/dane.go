@@ -0,0 +1,136 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// daneCmd prints the TLSA record that pins a certificate for DANE.
+var daneCmd = &cobra.Command{
+	Use:   "dane [file | host:port]",
+	Short: "Print a DANE TLSA record for a certificate",
+	Long: `Print the TLSA record (RFC 6698) that pins a certificate for DANE, ready to go
+into a zone file.
+
+--usage says how the certificate is used: 0 PKIX-TA, 1 PKIX-EE, 2 DANE-TA or 3
+DANE-EE. --selector picks what is matched: 0 the whole certificate, 1 its public
+key alone, which survives a renewal with the same key. --match picks how: 0 the
+data itself, 1 its SHA-256, 2 its SHA-512. Each takes its number or its name.
+The default, 3 1 1, is the record most servers want.
+
+An end-entity usage pins the leaf, the first certificate; a trust-anchor usage
+pins the last, the top of the chain as it was given. --index picks another,
+counting from 0.
+
+With --host, or when the certificates came from a server, the record is printed
+under its owner name, _<port>._<proto>.<host>., the port being the one connected
+to unless --port says otherwise; without a host, only its data.`,
+	Example: `  y509 dane chain.pem --host mail.example.com --port 25
+  y509 dane example.com:443 --usage dane-ta --selector cert`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var fields [3]uint8
+		for i, flag := range []struct {
+			name  string
+			names []string
+		}{{"usage", certificate.TLSAUsages}, {"selector", certificate.TLSASelectors}, {"match", certificate.TLSAMatchingTypes}} {
+			value, err := cmd.Flags().GetString(flag.name)
+			if err != nil {
+				return err
+			}
+			if fields[i], err = certificate.ParseTLSAField(value, flag.names); err != nil {
+				return fmt.Errorf("--%s: %w", flag.name, err)
+			}
+		}
+		port, err := cmd.Flags().GetInt("port")
+		if err != nil {
+			return err
+		}
+		proto, err := cmd.Flags().GetString("proto")
+		if err != nil {
+			return err
+		}
+		host, err := cmd.Flags().GetString("host")
+		if err != nil {
+			return err
+		}
+
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+		if len(source.Certs) == 0 {
+			return fmt.Errorf("no certificates in the input")
+		}
+
+		record, err := daneRecord(cmd, source.Certs, fields)
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			host = source.Host
+		}
+		if _, p, err := net.SplitHostPort(source.Address); err == nil && !cmd.Flags().Changed("port") {
+			port, _ = strconv.Atoi(p)
+		}
+		return writeTLSA(os.Stdout, record, host, port, proto)
+	},
+}
+
+// daneRecord builds the record for the certificate --index names, or else
+// the one the usage is about: the leaf for an end-entity usage, the top of
+// the chain for a trust anchor.
+func daneRecord(cmd *cobra.Command, certs []*certificate.Info, fields [3]uint8) (*certificate.TLSA, error) {
+	selected, _, err := selectIndex(cmd, certs)
+	if err != nil {
+		return nil, err
+	}
+	target := selected[0]
+	if !cmd.Flags().Changed("index") && (fields[0] == 0 || fields[0] == 2) {
+		target = selected[len(selected)-1]
+	}
+	return certificate.NewTLSA(target.Certificate, fields[0], fields[1], fields[2])
+}
+
+// writeTLSA writes the record as a zone file line under its owner name, or
+// only its data when there is no host to name it for.
+func writeTLSA(w io.Writer, record *certificate.TLSA, host string, port int, proto string) error {
+	if host == "" {
+		_, err := fmt.Fprintln(w, record)
+		return err
+	}
+	owner, err := certificate.TLSAOwner(host, port, proto)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s IN TLSA %s\n", owner, record)
+	return err
+}
+
+func init() {
+	daneCmd.Flags().String("usage", "3", "Certificate usage: 0-3 or "+strings.Join(certificate.TLSAUsages, ", "))
+	daneCmd.Flags().String("selector", "1", "What is matched: 0-1 or "+strings.Join(certificate.TLSASelectors, ", "))
+	daneCmd.Flags().String("match", "1", "How it is matched: 0-2 or "+strings.Join(certificate.TLSAMatchingTypes, ", "))
+	daneCmd.Flags().Int("port", 443, "Port the record is for, when not the one connected to")
+	daneCmd.Flags().String("proto", "tcp", "Protocol the record is for: "+strings.Join(certificate.TLSAProtocols, ", "))
+	daneCmd.Flags().String("host", "", "Host the record is for (default: the server connected to)")
+	daneCmd.Flags().Int("index", 0, "Pin the certificate at this index, counting from 0")
+	_ = daneCmd.RegisterFlagCompletionFunc("index", completeIndex)
+	_ = daneCmd.RegisterFlagCompletionFunc("usage", cobra.FixedCompletions(certificate.TLSAUsages, cobra.ShellCompDirectiveNoFileComp))
+	_ = daneCmd.RegisterFlagCompletionFunc("selector", cobra.FixedCompletions(certificate.TLSASelectors, cobra.ShellCompDirectiveNoFileComp))
+	_ = daneCmd.RegisterFlagCompletionFunc("match", cobra.FixedCompletions(certificate.TLSAMatchingTypes, cobra.ShellCompDirectiveNoFileComp))
+	_ = daneCmd.RegisterFlagCompletionFunc("proto", cobra.FixedCompletions(certificate.TLSAProtocols, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.AddCommand(daneCmd)
+}
@@ -0,0 +1,247 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// The SGR sequences diff colours its output with. The writer they go
+// through drops them when stdout is not a terminal or NO_COLOR is set.
+const (
+	sgrReset   = "\x1b[0m"
+	sgrBold    = "\x1b[1m"
+	sgrDim     = "\x1b[2m"
+	sgrRed     = "\x1b[31m"
+	sgrGreen   = "\x1b[32m"
+	sgrHeading = "\x1b[1;36m"
+)
+
+// diffCmd compares two certificates field by field, for reviewing a renewal.
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare two certificates field by field",
+	Long: `Compare two certificates field by field, as the TUI's diff view does, and
+print what changed as a diff: the old value in red with -, the new in green
+with +. Multi-line fields, the SANs and the extensions, are compared line by
+line, so a renewal that adds one name shows one + line.
+
+The fields are the subject, issuer, serial, validity and lifetime, SANs,
+public key and its SHA-256, signature algorithm, every extension either
+certificate carries, and the fingerprint. Unchanged fields are named at the
+end; --all prints them in full.
+
+Either side can be a file or a host:port, so a certificate about to be
+deployed can be compared with the one being served. A file or server with
+a chain gives its first certificate, or the one at --index.
+
+--color is auto, always or never; auto colours a terminal unless NO_COLOR is
+set. Like git diff, exits 0 whether or not anything changed, unless
+--exit-code is given, when it exits 1 on a difference. With --output json
+or yaml, every field comes as an object with field, old, new and changed.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeArgs(completeCertFiles, completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		index, err := cmd.Flags().GetInt("index")
+		if err != nil {
+			return err
+		}
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+		exitCode, err := cmd.Flags().GetBool("exit-code")
+		if err != nil {
+			return err
+		}
+		color, err := cmd.Flags().GetString("color")
+		if err != nil {
+			return err
+		}
+		out, err := colorWriter(os.Stdout, color)
+		if err != nil {
+			return err
+		}
+
+		var infos [2]*certificate.Info
+		for i, arg := range args {
+			source, err := loadInput(cmd, []string{arg})
+			if err != nil {
+				logger.Log.Error("Error loading certificates", zap.String("source", arg), zap.Error(err))
+				return fmt.Errorf("%s: %w", arg, err)
+			}
+			if index < 0 || index >= len(source.Certs) {
+				return fmt.Errorf("%s: certificate index %d out of range: it has %d", arg, index, len(source.Certs))
+			}
+			infos[i] = source.Certs[index]
+		}
+
+		diffs := certificate.DiffCertificates(infos[0].Certificate, infos[1].Certificate)
+		if format != outputText {
+			err = writeOutput(os.Stdout, format, newDiffJSON(diffs))
+		} else {
+			err = writeDiff(out, args, diffs, all)
+		}
+		if err != nil {
+			return err
+		}
+		if exitCode {
+			for _, d := range diffs {
+				if d.Changed() {
+					return &exitError{code: 1, err: fmt.Errorf("the certificates differ")}
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// colorWriter wraps w so the SGR sequences written to it come out as
+// --color asks: auto leaves it to the terminal and NO_COLOR.
+func colorWriter(w io.Writer, color string) (io.Writer, error) {
+	cw := colorprofile.NewWriter(w, os.Environ())
+	switch strings.ToLower(color) {
+	case "auto":
+	case "always":
+		if cw.Profile < colorprofile.ANSI {
+			cw.Profile = colorprofile.ANSI
+		}
+	case "never":
+		cw.Profile = colorprofile.NoTTY
+	default:
+		return nil, fmt.Errorf("unknown --color %q (one of auto, always, never)", color)
+	}
+	return cw, nil
+}
+
+// diffFieldJSON is a field of diff, as --output gives it.
+type diffFieldJSON struct {
+	Field   string `json:"field" yaml:"field"`
+	Old     string `json:"old" yaml:"old"`
+	New     string `json:"new" yaml:"new"`
+	Changed bool   `json:"changed" yaml:"changed"`
+}
+
+func newDiffJSON(diffs []certificate.FieldDiff) []diffFieldJSON {
+	out := make([]diffFieldJSON, len(diffs))
+	for i, d := range diffs {
+		out[i] = diffFieldJSON{Field: d.Field, Old: d.A, New: d.B, Changed: d.Changed()}
+	}
+	return out
+}
+
+// writeDiff writes the changed fields as a diff under the names of the two
+// sides, then the unchanged ones: by name, or in full with all.
+func writeDiff(w io.Writer, names []string, diffs []certificate.FieldDiff, all bool) error {
+	changed := 0
+	for _, d := range diffs {
+		if d.Changed() {
+			changed++
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s--- %s%s\n", sgrRed, names[0], sgrReset)
+	fmt.Fprintf(&b, "%s+++ %s%s\n", sgrGreen, names[1], sgrReset)
+	fmt.Fprintf(&b, "%s%d of %d fields differ%s\n", sgrDim, changed, len(diffs), sgrReset)
+
+	var unchanged []string
+	for _, d := range diffs {
+		if !d.Changed() && !all {
+			unchanged = append(unchanged, d.Field)
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s%s%s\n", sgrHeading, d.Field, sgrReset)
+		for _, line := range diffLines(splitValue(d.A), splitValue(d.B)) {
+			switch line.op {
+			case '-':
+				fmt.Fprintf(&b, "%s- %s%s\n", sgrRed, line.text, sgrReset)
+			case '+':
+				fmt.Fprintf(&b, "%s+ %s%s\n", sgrGreen, line.text, sgrReset)
+			default:
+				fmt.Fprintf(&b, "  %s\n", line.text)
+			}
+		}
+	}
+	if len(unchanged) > 0 {
+		fmt.Fprintf(&b, "\n%sUnchanged:%s %s%s%s\n", sgrBold, sgrReset, sgrDim, strings.Join(unchanged, ", "), sgrReset)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// splitValue splits a field's value into lines; a field the certificate
+// does not have has none.
+func splitValue(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
+
+// diffLine is a line of a field's diff: '-' only in the old value, '+'
+// only in the new, ' ' in both.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines diffs two lists of lines by their longest common subsequence,
+// putting each run of removed lines before the lines that replace them.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]. Fields are a handful of lines, so the table stays small.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+func init() {
+	diffCmd.Flags().Int("index", 0, "Compare the certificates at this index of each side, counting from 0")
+	diffCmd.Flags().Bool("all", false, "Print unchanged fields in full too")
+	diffCmd.Flags().Bool("exit-code", false, "Exit 1 when the certificates differ")
+	diffCmd.Flags().String("color", "auto", "Colour the diff: auto, always or never")
+	addOutputFlag(diffCmd)
+	_ = diffCmd.RegisterFlagCompletionFunc("index", completeIndex)
+	_ = diffCmd.RegisterFlagCompletionFunc("color", cobra.FixedCompletions([]string{"auto", "always", "never"}, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.AddCommand(diffCmd)
+}
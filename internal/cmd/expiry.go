@@ -0,0 +1,248 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// Exit statuses of expiry, those of a monitoring plugin: 0 is OK.
+const (
+	exitExpiryWarning  = 1
+	exitExpiryCritical = 2
+)
+
+// The states expiry puts a certificate in.
+const (
+	expiryOK       = "ok"
+	expiryWarning  = "warning"
+	expiryCritical = "critical"
+	expiryExpired  = "expired"
+)
+
+// expiryStates are the states, worst last; the report takes the worst.
+var expiryStates = []string{expiryOK, expiryWarning, expiryCritical, expiryExpired}
+
+// expiryCmd checks how long the certificates have left, for cron and CI.
+var expiryCmd = &cobra.Command{
+	Use:   "expiry [file | host:port]",
+	Short: "Check how long each certificate has left",
+	Long: `Print the days each certificate in the input has left, and exit non-zero when
+any is close to expiry, so it can gate a cron job or a CI pipeline.
+
+--warn and --crit take days as 30d, weeks as 2w, a Go duration as 72h, or a
+bare number of days. --warn defaults to expiry_warning_days of the
+configuration, 30 days unless it says otherwise; --crit to 7 days.
+
+Exit status, as a monitoring plugin's:
+  0  every certificate has more than --warn left
+  1  one expires within --warn
+  2  one expires within --crit, or has expired
+
+Pass --output json or yaml for the thresholds, the overall state and a row
+per certificate.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		warn, err := thresholdFlag(cmd, "warn")
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("warn") {
+			warn = time.Duration(expiryWarningDays()) * 24 * time.Hour
+		}
+		crit, err := thresholdFlag(cmd, "crit")
+		if err != nil {
+			return err
+		}
+		warn, crit, ok := settleThresholds(warn, crit, cmd.Flags().Changed("warn"), cmd.Flags().Changed("crit"))
+		if !ok {
+			return fmt.Errorf("--crit (%s) is longer than --warn (%s)", formatThreshold(crit), formatThreshold(warn))
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+
+		report := newExpiryJSON(source.Certs, warn, crit, time.Now())
+		if format != outputText {
+			err = writeOutput(os.Stdout, format, report)
+		} else {
+			err = writeExpiry(os.Stdout, report)
+		}
+		if err != nil {
+			return err
+		}
+
+		if report.ExitCode != 0 {
+			return &exitError{code: report.ExitCode, err: fmt.Errorf("certificate expiry is %s", report.State)}
+		}
+		return nil
+	},
+}
+
+// expiryJSON is the expiry report, as expiry --output prints it.
+type expiryJSON struct {
+	WarnDays     int               `json:"warn_days" yaml:"warn_days"`
+	CritDays     int               `json:"crit_days" yaml:"crit_days"`
+	State        string            `json:"state" yaml:"state"`
+	ExitCode     int               `json:"exit_code" yaml:"exit_code"`
+	Certificates []expiryEntryJSON `json:"certificates" yaml:"certificates"`
+}
+
+// expiryEntryJSON is a certificate's row of the expiry report.
+type expiryEntryJSON struct {
+	Index      int       `json:"index" yaml:"index"`
+	CommonName string    `json:"common_name" yaml:"common_name"`
+	NotAfter   time.Time `json:"not_after" yaml:"not_after"`
+	DaysLeft   int       `json:"days_left" yaml:"days_left"`
+	State      string    `json:"state" yaml:"state"`
+}
+
+// newExpiryJSON puts each certificate in a state by the time it has left
+// at now, and the report in the worst of them.
+func newExpiryJSON(certs []*certificate.Info, warn, crit time.Duration, now time.Time) expiryJSON {
+	report := expiryJSON{
+		WarnDays:     int(warn.Hours() / 24),
+		CritDays:     int(crit.Hours() / 24),
+		State:        expiryOK,
+		Certificates: []expiryEntryJSON{},
+	}
+	for i, c := range certs {
+		left := c.Certificate.NotAfter.Sub(now)
+		state := expiryOK
+		switch {
+		case left <= 0:
+			state = expiryExpired
+		case left <= crit:
+			state = expiryCritical
+		case left <= warn:
+			state = expiryWarning
+		}
+		if slices.Index(expiryStates, state) > slices.Index(expiryStates, report.State) {
+			report.State = state
+		}
+		report.Certificates = append(report.Certificates, expiryEntryJSON{
+			Index:      i,
+			CommonName: c.Certificate.Subject.CommonName,
+			NotAfter:   c.Certificate.NotAfter.UTC(),
+			DaysLeft:   int(left.Hours() / 24),
+			State:      state,
+		})
+	}
+	switch report.State {
+	case expiryWarning:
+		report.ExitCode = exitExpiryWarning
+	case expiryCritical, expiryExpired:
+		report.ExitCode = exitExpiryCritical
+	}
+	return report
+}
+
+// writeExpiry writes the report as a table, then a line with the verdict.
+func writeExpiry(w io.Writer, report expiryJSON) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "#\tCOMMON NAME\tNOT AFTER\tDAYS LEFT\tSTATE"); err != nil {
+		return err
+	}
+	for _, e := range report.Certificates {
+		if _, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\n", e.Index, orNone(e.CommonName), certificate.FormatDate(e.NotAfter), e.DaysLeft, e.State); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, e := range report.Certificates {
+		counts[e.State]++
+	}
+	_, err := fmt.Fprintf(w, "\n%s: %d expired, %d within %d days, %d within %d days\n", strings.ToUpper(report.State),
+		counts[expiryExpired], counts[expiryCritical], report.CritDays, counts[expiryWarning], report.WarnDays)
+	return err
+}
+
+// settleThresholds keeps crit within warn. A threshold left at its default
+// gives way to the other; ok is false when both were given and crit is the
+// longer.
+func settleThresholds(warn, crit time.Duration, warnGiven, critGiven bool) (time.Duration, time.Duration, bool) {
+	switch {
+	case crit <= warn:
+		return warn, crit, true
+	case warnGiven && critGiven:
+		return warn, crit, false
+	case critGiven:
+		return crit, crit, true
+	default:
+		return warn, warn, true
+	}
+}
+
+// thresholdFlag reads a threshold flag.
+func thresholdFlag(cmd *cobra.Command, name string) (time.Duration, error) {
+	value, err := cmd.Flags().GetString(name)
+	if err != nil {
+		return 0, err
+	}
+	d, err := parseThreshold(value)
+	if err != nil {
+		return 0, fmt.Errorf("--%s: %w", name, err)
+	}
+	return d, nil
+}
+
+// parseThreshold reads a time before expiry: days as 30d or a bare 30,
+// weeks as 2w, or anything time.ParseDuration takes, as 72h.
+func parseThreshold(value string) (time.Duration, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	unit := 24 * time.Hour
+	number := value
+	switch {
+	case strings.HasSuffix(value, "d"):
+		number = strings.TrimSuffix(value, "d")
+	case strings.HasSuffix(value, "w"):
+		number, unit = strings.TrimSuffix(value, "w"), 7*24*time.Hour
+	}
+	if n, err := strconv.Atoi(number); err == nil && n >= 0 {
+		return time.Duration(n) * unit, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%q is not a time such as 30d, 2w or 72h", value)
+	}
+	return d, nil
+}
+
+// formatThreshold shows a threshold in days, or as a duration when it is
+// not a whole number of them.
+func formatThreshold(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+	return d.String()
+}
+
+func init() {
+	expiryCmd.Flags().String("warn", "30d", "Warn when a certificate expires within this long (default: expiry_warning_days)")
+	expiryCmd.Flags().String("crit", "7d", "Critical when a certificate expires within this long")
+	addOutputFlag(expiryCmd)
+	RootCmd.AddCommand(expiryCmd)
+}
@@ -2,9 +2,15 @@
 package cmd
 
 import (
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/kanywst/y509/internal/logger"
 	"github.com/kanywst/y509/pkg/certificate"
@@ -16,11 +22,88 @@ var exportCmd = &cobra.Command{
 	Use:   "export [index] [format] [filename]",
 	Short: "Export a certificate to a file",
 	Long: `Export a certificate to a file in the specified format.
-Format can be 'pem', 'der', 'crt', or 'cert' (crt and cert are written as PEM).
+Format can be 'pem', 'der', 'crt', 'cert' (crt and cert are written as PEM),
+or 'p7b' for a certs-only PKCS#7 bundle.
 If no index is provided, the currently selected certificate will be exported.
 If no format is provided, 'pem' will be used.
-If no filename is provided, a default name will be generated.`,
+If no filename is provided, a default name will be generated.
+A file already there is left alone unless --force is given.
+
+With --chain, the chain built up from the certificate through the rest of the
+input is exported instead, leaf first, whatever order the input was in:
+a fullchain.pem as nginx and haproxy take it. --order root-first writes it
+the other way up, as bundle does; notes on a chain that stops short of a
+root or holds a certificate out of date go to stderr. With --all, every
+certificate of the input is, in the order it came in; there is no index
+then, so the arguments are [format] [filename].
+
+Either goes to one file, as pem or a pkcs7 bundle (der holds only one
+certificate), or with --out-dir to a file each in that directory, named as
+split names them: "01-example.com.pem".
+The files written to a directory are printed, one per line.
+
+With --pubkey, the certificate's public key is written instead, as its
+subjectPublicKeyInfo, for key pinning or a JWT verifier: format 'pem' gives
+a PUBLIC KEY block, as openssl x509 -pubkey prints, and 'der' the bare DER.
+
+With --jwk, the certificate is written as a JSON Web Key, for JWT and JOSE
+validation: its public key, with the certificate, or with --chain the chain
+leaf first, in x5c and its SHA-256 thumbprint in x5t#S256. The kid is the
+key's RFC 7638 thumbprint. There is no format then, so the arguments are
+[index] [filename].`,
+	Args:              cobra.MaximumNArgs(3),
+	ValidArgsFunction: completeExportArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		exportAll, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return err
+		}
+		exportChain, err := cmd.Flags().GetBool("chain")
+		if err != nil {
+			return err
+		}
+		outDir, err := cmd.Flags().GetString("out-dir")
+		if err != nil {
+			return err
+		}
+		exportPubkey, err := cmd.Flags().GetBool("pubkey")
+		if err != nil {
+			return err
+		}
+		order, err := cmd.Flags().GetString("order")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		exportJWK, err := cmd.Flags().GetBool("jwk")
+		if err != nil {
+			return err
+		}
+		if err := checkOrder(order); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("order") && !exportChain {
+			return fmt.Errorf("--order is the order of a built chain; give it with --chain")
+		}
+		if exportAll && exportChain {
+			return fmt.Errorf("give --all or --chain, not both")
+		}
+		if exportPubkey && (exportAll || exportChain || outDir != "") {
+			return fmt.Errorf("--pubkey exports one certificate's key; it does not go with --all, --chain or --out-dir")
+		}
+		if exportJWK && (exportAll || exportPubkey || outDir != "") {
+			return fmt.Errorf("--jwk writes one key; it does not go with --all, --pubkey or --out-dir")
+		}
+		if exportJWK && order == orderRootFirst {
+			return fmt.Errorf("a JWK's x5c is leaf first; --order %s does not go with --jwk", orderRootFirst)
+		}
+		if outDir != "" && !exportAll && !exportChain {
+			return fmt.Errorf("--out-dir writes a file per certificate; give it with --all or --chain")
+		}
+
 		// Get input file from flag or use stdin
 		inputFile := ""
 		if cmd.Flags().Changed("input") {
@@ -28,7 +111,7 @@ If no filename is provided, a default name will be generated.`,
 		}
 
 		// Load certificates
-		certs, err := certificate.LoadCertificates(inputFile)
+		certs, err := loadCertificates(cmd, inputFile)
 		if err != nil {
 			logger.Log.Error("Failed to load certificates", zap.Error(err))
 			return err
@@ -39,9 +122,13 @@ If no filename is provided, a default name will be generated.`,
 			return fmt.Errorf("no certificates available")
 		}
 
-		// Get certificate index
+		// Get certificate index; --all has none, so the rest come first.
 		index := 0
-		if len(args) > 0 {
+		if exportAll {
+			if len(args) > 2 {
+				return fmt.Errorf("--all takes no index: give [format] [filename]")
+			}
+		} else if len(args) > 0 {
 			_, err := fmt.Sscanf(args[0], "%d", &index)
 			if err != nil {
 				logger.Log.Error("Invalid certificate index", zap.Error(err))
@@ -51,18 +138,76 @@ If no filename is provided, a default name will be generated.`,
 				logger.Log.Error("Certificate index out of range")
 				return fmt.Errorf("certificate index out of range")
 			}
+			args = args[1:]
 		}
 
-		// Get format
+		// Get format; a JWK has none, so it stands in for one ahead of the
+		// file name.
 		format := "pem"
-		if len(args) > 1 {
-			format = args[1]
+		if exportJWK {
+			if len(args) > 1 {
+				return fmt.Errorf("--jwk takes no format: give [index] [filename]")
+			}
+			format = "jwk"
+			args = slices.Insert(args, 0, format)
+		} else if len(args) > 0 {
+			format = args[0]
+		}
+
+		pool := make([]*x509.Certificate, len(certs))
+		for i, c := range certs {
+			pool[i] = c.Certificate
+		}
+		selected := []*x509.Certificate{certs[index].Certificate}
+		switch {
+		case exportAll:
+			selected = pool
+		case exportChain:
+			if selected, err = exportedChain(os.Stderr, certs[index].Certificate, pool, order); err != nil {
+				return err
+			}
+		}
+
+		if outDir != "" {
+			if len(args) > 1 {
+				return fmt.Errorf("give a filename or --out-dir, not both")
+			}
+			return exportEach(selected, format, outDir, force)
+		}
+
+		// The key is encoded first, so an unsupported format writes nothing.
+		var key []byte
+		switch {
+		case exportPubkey:
+			if key, err = certificate.EncodePublicKey(certs[index].Certificate, format); err != nil {
+				return err
+			}
+		case exportJWK:
+			jwk, err := certificate.NewJWK(selected)
+			if err != nil {
+				return err
+			}
+			if key, err = json.MarshalIndent(jwk, "", "  "); err != nil {
+				return err
+			}
+			key = append(key, '\n')
 		}
 
 		// Get filename
 		filename := fmt.Sprintf("certificate_%d.%s", index, format)
-		if len(args) > 2 {
-			filename = args[2]
+		switch {
+		case exportAll:
+			filename = "certificates." + format
+		case exportPubkey:
+			filename = fmt.Sprintf("pubkey_%d.%s", index, format)
+		}
+		if len(args) > 1 {
+			filename = args[1]
+		}
+
+		if _, err := os.Stat(filename); err == nil && !force {
+			logger.Log.Error("Export target exists", zap.String("filename", filename))
+			return fmt.Errorf("%s already exists; --force overwrites it", filename)
 		}
 
 		// Create directory if it doesn't exist
@@ -74,8 +219,17 @@ If no filename is provided, a default name will be generated.`,
 			}
 		}
 
+		if exportPubkey || exportJWK {
+			if err := os.WriteFile(filename, key, 0o644); err != nil {
+				logger.Log.Error("Failed to export public key", zap.Error(err))
+				return fmt.Errorf("failed to export public key: %v", err)
+			}
+			logger.Log.Info("Public key exported successfully", zap.String("filename", filename))
+			return nil
+		}
+
 		// Export certificate
-		if err := certificate.ExportCertificate(certs[index].Certificate, format, filename); err != nil {
+		if err := certificate.ExportChain(selected, format, filename); err != nil {
 			logger.Log.Error("Failed to export certificate", zap.Error(err))
 			return fmt.Errorf("failed to export certificate: %v", err)
 		}
@@ -85,6 +239,77 @@ If no filename is provided, a default name will be generated.`,
 	},
 }
 
+// completeExportArgs completes the index, the format and the file name, or
+// with --all, which takes no index, the last two. --pubkey has formats of
+// its own, and --jwk none.
+func completeExportArgs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	formats := cobra.FixedCompletions(certificate.ExportFormats, cobra.ShellCompDirectiveNoFileComp)
+	if pubkey, _ := cmd.Flags().GetBool("pubkey"); pubkey {
+		formats = cobra.FixedCompletions(certificate.PublicKeyFormats, cobra.ShellCompDirectiveNoFileComp)
+	}
+	if jwk, _ := cmd.Flags().GetBool("jwk"); jwk {
+		return completeArgs(completeIndex, completeFiles())(cmd, args, toComplete)
+	}
+	if all, _ := cmd.Flags().GetBool("all"); all {
+		return completeArgs(formats, completeFiles())(cmd, args, toComplete)
+	}
+	return completeArgs(completeIndex, formats, completeFiles())(cmd, args, toComplete)
+}
+
+// exportedChain is the chain built up from cert through pool, in order,
+// with notes on it written to w.
+func exportedChain(w io.Writer, cert *x509.Certificate, pool []*x509.Certificate, order string) ([]*x509.Certificate, error) {
+	chain := certificate.BuildChain(cert, pool, time.Time{})
+	for _, note := range bundleNotes(chain, time.Now()) {
+		if _, err := fmt.Fprintln(w, "Note: "+note); err != nil {
+			return nil, err
+		}
+	}
+	return inOrder(chain, order), nil
+}
+
+// exportEach writes each certificate to a file of its own in dir, named as
+// split names them, and prints the paths. Every file is encoded, and
+// checked not to be there already, before any is written.
+func exportEach(certs []*x509.Certificate, format, dir string, force bool) error {
+	paths := make([]string, len(certs))
+	files := make([][]byte, len(certs))
+	for i, cert := range certs {
+		paths[i] = filepath.Join(dir, certificate.FileName(cert, i+1, strings.ToLower(format)))
+		if err := refuseOverwrite(paths[i], force); err != nil {
+			logger.Log.Error("Export target exists", zap.String("filename", paths[i]))
+			return err
+		}
+		data, err := certificate.EncodeChain([]*x509.Certificate{cert}, format)
+		if err != nil {
+			return err
+		}
+		files[i] = data
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	for i, path := range paths {
+		if err := os.WriteFile(path, files[i], 0o644); err != nil {
+			logger.Log.Error("Failed to export certificate", zap.String("filename", path), zap.Error(err))
+			return err
+		}
+		fmt.Println(path)
+	}
+	logger.Log.Info("Certificates exported successfully", zap.String("directory", dir), zap.Int("count", len(paths)))
+	return nil
+}
+
 func init() {
+	exportCmd.Flags().Bool("chain", false, "Export the chain built up from the certificate, not just the certificate")
+	exportCmd.Flags().String("order", orderLeafFirst, "With --chain, order to write the chain in: "+orderLeafFirst+" or "+orderRootFirst)
+	exportCmd.Flags().Bool("all", false, "Export every certificate of the input")
+	exportCmd.Flags().Bool("pubkey", false, "Export the certificate's public key (SPKI) instead of the certificate")
+	exportCmd.Flags().Bool("jwk", false, "Export the certificate as a JWK, with x5c and x5t#S256")
+	exportCmd.Flags().String("out-dir", "", "With --all or --chain, write a file per certificate to this directory")
+	exportCmd.Flags().BoolP("force", "f", false, "Overwrite the file if it already exists")
+	_ = exportCmd.MarkFlagDirname("out-dir")
+	_ = exportCmd.RegisterFlagCompletionFunc("order", cobra.FixedCompletions([]string{orderLeafFirst, orderRootFirst}, cobra.ShellCompDirectiveNoFileComp))
 	RootCmd.AddCommand(exportCmd)
 }
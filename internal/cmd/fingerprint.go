@@ -0,0 +1,76 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// fingerprintCmd prints certificate fingerprints.
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint [file | host:port]",
+	Short: "Print certificate fingerprints",
+	Long: `Print the fingerprint of every certificate in the input, in the colon-separated
+form openssl and browsers show and as bare hex.
+
+--algo picks the digest: ` + strings.Join(certificate.FingerprintAlgorithms, ", ") + `. sha256, sha1 and md5
+hash the whole certificate; spki-sha256 hashes its public key alone, the hash
+key pinning uses, which stays the same when a certificate is renewed with the
+same key. Pass --index to print only one certificate, counting from 0.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		algo, err := cmd.Flags().GetString("algo")
+		if err != nil {
+			return err
+		}
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+
+		certs, first, err := selectIndex(cmd, source.Certs)
+		if err != nil {
+			return err
+		}
+		for i, c := range certs {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := writeFingerprint(os.Stdout, c, algo, first+i, len(source.Certs)); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// writeFingerprint writes the fingerprint of the certificate at index of
+// total, colon-separated and as bare hex.
+func writeFingerprint(w io.Writer, c *certificate.Info, algo string, index, total int) error {
+	digest, err := certificate.Fingerprint(c.Certificate, algo)
+	if err != nil {
+		return err
+	}
+	algo = strings.ToLower(algo)
+	_, err = fmt.Fprintf(w, "Certificate %d of %d: %s\n  %s  %s\n  %s  %s\n", index+1, total, orNone(c.Certificate.Subject.CommonName),
+		algo, certificate.ColonHex(digest), algo, hex.EncodeToString(digest))
+	return err
+}
+
+func init() {
+	fingerprintCmd.Flags().String("algo", "sha256", "Digest: "+strings.Join(certificate.FingerprintAlgorithms, ", "))
+	fingerprintCmd.Flags().Int("index", 0, "Print only the certificate at this index, counting from 0")
+	_ = fingerprintCmd.RegisterFlagCompletionFunc("index", completeIndex)
+	_ = fingerprintCmd.RegisterFlagCompletionFunc("algo", cobra.FixedCompletions(certificate.FingerprintAlgorithms, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.AddCommand(fingerprintCmd)
+}
@@ -0,0 +1,412 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// genCmd groups the commands that make certificates for testing.
+var genCmd = &cobra.Command{
+	Use:   "gen",
+	Short: "Generate certificates for development and testing",
+}
+
+// genSelfSignedCmd makes a self-signed certificate and its key.
+var genSelfSignedCmd = &cobra.Command{
+	Use:   "selfsigned",
+	Short: "Generate a self-signed certificate and its key",
+	Long: `Generate a private key and a certificate for it signed with the key itself,
+for a development server or a test, in place of an openssl req -x509 command.
+
+--host names what the certificate is for, several separated by commas or in
+several flags: each is a DNS name or an IP address, and the first is the
+common name unless --cn gives one. The subject flags and --san are those of
+the csr command, as are the key flags: --key-type (ecdsa-p256 by default),
+--key-out for the generated key, or --key for a key you already have.
+
+The certificate is valid for --days from now, or from --not-before. It is a
+TLS server certificate (digitalSignature, serverAuth) unless --key-usage and
+--ext-key-usage say otherwise, or a CA with --ca, with --path-len as its
+path length constraint. --serial gives the serial number in hex; a random
+one is drawn otherwise.
+
+The certificate goes to -o, or to stdout. A file already there is left
+alone unless --force is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		opts, err := certificateOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		outFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		if err := refuseOverwrite(outFile, force); err != nil {
+			return err
+		}
+
+		key, keyOut, err := keyFromFlags(cmd, force)
+		if err != nil {
+			return err
+		}
+		cert, err := certificate.GenerateSelfSignedCert(key, opts)
+		if err != nil {
+			return err
+		}
+		return writeGenerated(cert, key, outFile, keyOut)
+	},
+}
+
+// genChainCmd makes a root, intermediates and a leaf, for a test PKI.
+var genChainCmd = &cobra.Command{
+	Use:   "chain",
+	Short: "Generate a test PKI: root CA, intermediate and leaf",
+	Long: `Generate a root CA, an intermediate CA and a leaf issued by it, each with a
+key of its own, as a realistic chain for tests and development servers.
+
+The leaf takes --cn and --san, as the csr command does: a --cn that looks
+like a host name is a DNS name of the leaf too. It is a TLS server
+certificate valid for --days, unless --ext-key-usage says otherwise, say
+clientAuth. --org, --org-unit, --country, --state and --locality go in every
+subject. The CAs are valid for --ca-days and named by --root-cn and
+--intermediate-cn; --intermediates sets how many intermediates there are,
+numbered when there are several, or none with 0. Every key is of --key-type.
+
+The files go to -o, a directory, made if need be:
+
+  root.pem, root.key                  the root CA
+  intermediate.pem, intermediate.key  the intermediate CA (intermediate-1,
+                                      intermediate-2... when several)
+  leaf.pem, leaf.key                  the leaf
+  chain.pem                           the intermediates, for a server's chain
+  fullchain.pem                       the leaf and the intermediates
+
+Keys are unencrypted PKCS#8, readable by their owner only. Nothing is
+written when a file is already there, unless --force is given. The files
+written are printed, one per line.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		subject, err := subjectFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		sans, err := sansFromFlags(cmd, subject.CommonName)
+		if err != nil {
+			return err
+		}
+		if subject.CommonName == "" && sans.Empty() {
+			return fmt.Errorf("give the leaf a name: --cn, --san or both")
+		}
+		flags := cmd.Flags()
+		rootCN, err := flags.GetString("root-cn")
+		if err != nil {
+			return err
+		}
+		intermediateCN, err := flags.GetString("intermediate-cn")
+		if err != nil {
+			return err
+		}
+		count, err := flags.GetInt("intermediates")
+		if err != nil {
+			return err
+		}
+		if count < 0 {
+			return fmt.Errorf("--intermediates must be 0 or more")
+		}
+		days, err := flags.GetInt("days")
+		if err != nil {
+			return err
+		}
+		caDays, err := flags.GetInt("ca-days")
+		if err != nil {
+			return err
+		}
+		if days <= 0 || caDays <= 0 {
+			return fmt.Errorf("--days and --ca-days must be at least 1")
+		}
+		keyType, err := flags.GetString("key-type")
+		if err != nil {
+			return err
+		}
+		extKeyUsageNames, err := flags.GetStringSlice("ext-key-usage")
+		if err != nil {
+			return err
+		}
+		var extKeyUsages []x509.ExtKeyUsage
+		for _, name := range extKeyUsageNames {
+			usage, err := certificate.ParseExtKeyUsage(name)
+			if err != nil {
+				return fmt.Errorf("--ext-key-usage: %w", err)
+			}
+			extKeyUsages = append(extKeyUsages, usage)
+		}
+		outDir, err := flags.GetString("out-dir")
+		if err != nil {
+			return err
+		}
+		force, err := flags.GetBool("force")
+		if err != nil {
+			return err
+		}
+
+		caSubject := func(cn string) pkix.Name {
+			name := subject
+			name.CommonName = cn
+			return name
+		}
+		root := certificate.GenerateOptions{Subject: caSubject(rootCN), Days: caDays, PathLen: -1}
+		// The intermediates are numbered from the root down; the names go
+		// leaf first, as the chain does.
+		intermediates := make([]certificate.GenerateOptions, count)
+		names := make([]string, count+2)
+		names[0], names[count+1] = "leaf", "root"
+		for i := range intermediates {
+			cn, name := intermediateCN, "intermediate"
+			if count > 1 {
+				cn, name = fmt.Sprintf("%s %d", intermediateCN, i+1), fmt.Sprintf("intermediate-%d", i+1)
+			}
+			intermediates[i] = certificate.GenerateOptions{Subject: caSubject(cn), Days: caDays, PathLen: count - 1 - i}
+			names[count-i] = name
+		}
+		leaf := certificate.GenerateOptions{Subject: subject, SANs: sans, Days: days, ExtKeyUsage: extKeyUsages}
+
+		files := genChainFiles(names)
+		for _, file := range files {
+			if err := refuseOverwrite(filepath.Join(outDir, file), force); err != nil {
+				return err
+			}
+		}
+		chain, err := certificate.GenerateChain(keyType, root, intermediates, leaf)
+		if err != nil {
+			return err
+		}
+		return writeGeneratedChain(outDir, names, chain)
+	},
+}
+
+// genChainFiles are the files gen chain writes for the certificates names,
+// leaf first.
+func genChainFiles(names []string) []string {
+	var files []string
+	for _, name := range names {
+		files = append(files, name+".pem", name+".key")
+	}
+	if len(names) > 2 {
+		files = append(files, "chain.pem")
+	}
+	return append(files, "fullchain.pem")
+}
+
+// writeGeneratedChain writes each certificate and key of a chain under its
+// name, then the chain and full-chain files, printing each path.
+func writeGeneratedChain(outDir string, names []string, chain []certificate.GeneratedCert) error {
+	certs := make([]*x509.Certificate, len(chain))
+	for i, generated := range chain {
+		certs[i] = generated.Certificate
+	}
+	write := func(file string, data []byte, perm os.FileMode) error {
+		path := filepath.Join(outDir, file)
+		if err := writeFile(path, data, perm); err != nil {
+			logger.Log.Error("Failed to write file", zap.String("filename", path), zap.Error(err))
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	}
+	pemOf := func(certs []*x509.Certificate) []byte {
+		data, _ := certificate.EncodeChain(certs, "pem")
+		return data
+	}
+
+	for i, generated := range chain {
+		key, err := certificate.EncodePrivateKey(generated.Key)
+		if err != nil {
+			return err
+		}
+		if err := write(names[i]+".pem", pemOf(certs[i:i+1]), 0o644); err != nil {
+			return err
+		}
+		if err := write(names[i]+".key", key, 0o600); err != nil {
+			return err
+		}
+	}
+	// The root is left out of both chain files, as a server sends them.
+	if len(certs) > 2 {
+		if err := write("chain.pem", pemOf(certs[1:len(certs)-1]), 0o644); err != nil {
+			return err
+		}
+	}
+	return write("fullchain.pem", pemOf(certs[:len(certs)-1]), 0o644)
+}
+
+// certificateOptionsFromFlags reads the certificate fields of
+// addCertificateFlags and addSubjectFlags.
+func certificateOptionsFromFlags(cmd *cobra.Command) (certificate.GenerateOptions, error) {
+	var opts certificate.GenerateOptions
+	var err error
+	if opts.Subject, err = subjectFromFlags(cmd); err != nil {
+		return opts, err
+	}
+	hosts, err := cmd.Flags().GetStringSlice("host")
+	if err != nil {
+		return opts, err
+	}
+	// The hosts, when given, are the names: the common name is not added
+	// to them as well.
+	commonName := opts.Subject.CommonName
+	if len(hosts) > 0 {
+		commonName = ""
+	}
+	if opts.SANs, err = sansFromFlags(cmd, commonName); err != nil {
+		return opts, err
+	}
+	if len(hosts) > 0 {
+		if opts.Subject.CommonName == "" {
+			opts.Subject.CommonName = hosts[0]
+		}
+		hostSANs, err := certificate.ParseSANs(hosts)
+		if err != nil {
+			return opts, fmt.Errorf("--host: %w", err)
+		}
+		opts.SANs.DNSNames = append(opts.SANs.DNSNames, hostSANs.DNSNames...)
+		opts.SANs.IPAddresses = append(opts.SANs.IPAddresses, hostSANs.IPAddresses...)
+		opts.SANs.EmailAddresses = append(opts.SANs.EmailAddresses, hostSANs.EmailAddresses...)
+		opts.SANs.URIs = append(opts.SANs.URIs, hostSANs.URIs...)
+	}
+	if opts.Subject.CommonName == "" && opts.SANs.Empty() {
+		return opts, fmt.Errorf("give the certificate a name: --host, --cn or --san")
+	}
+
+	if opts.Days, err = cmd.Flags().GetInt("days"); err != nil {
+		return opts, err
+	}
+	if opts.Days <= 0 {
+		return opts, fmt.Errorf("--days must be at least 1")
+	}
+	if notBefore, err := cmd.Flags().GetString("not-before"); err != nil {
+		return opts, err
+	} else if notBefore != "" {
+		if opts.NotBefore, err = certificate.ParseVerifyTime(notBefore); err != nil {
+			return opts, fmt.Errorf("--not-before: %w", err)
+		}
+	}
+	if serial, err := cmd.Flags().GetString("serial"); err != nil {
+		return opts, err
+	} else if serial != "" {
+		n, ok := new(big.Int).SetString(strings.NewReplacer(":", "", "0x", "").Replace(serial), 16)
+		if !ok || n.Sign() <= 0 {
+			return opts, fmt.Errorf("--serial %q: give a positive number in hex", serial)
+		}
+		opts.SerialNumber = n
+	}
+
+	if opts.IsCA, err = cmd.Flags().GetBool("ca"); err != nil {
+		return opts, err
+	}
+	if opts.PathLen, err = cmd.Flags().GetInt("path-len"); err != nil {
+		return opts, err
+	}
+	keyUsages, err := cmd.Flags().GetStringSlice("key-usage")
+	if err != nil {
+		return opts, err
+	}
+	for _, name := range keyUsages {
+		usage, err := certificate.ParseKeyUsage(name)
+		if err != nil {
+			return opts, fmt.Errorf("--key-usage: %w", err)
+		}
+		opts.KeyUsage |= usage
+	}
+	extKeyUsages, err := cmd.Flags().GetStringSlice("ext-key-usage")
+	if err != nil {
+		return opts, err
+	}
+	for _, name := range extKeyUsages {
+		usage, err := certificate.ParseExtKeyUsage(name)
+		if err != nil {
+			return opts, fmt.Errorf("--ext-key-usage: %w", err)
+		}
+		opts.ExtKeyUsage = append(opts.ExtKeyUsage, usage)
+	}
+	return opts, nil
+}
+
+// writeGenerated writes the key, when it was generated, then the
+// certificate, to outFile or stdout.
+func writeGenerated(cert *x509.Certificate, key crypto.Signer, outFile, keyOut string) error {
+	if keyOut != "" {
+		if err := writeKey(keyOut, key); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote the %s private key to %s\n", certificate.DescribePrivateKey(key), keyOut)
+	}
+	data, err := certificate.EncodeChain([]*x509.Certificate{cert}, "pem")
+	if err != nil {
+		return err
+	}
+	if outFile == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := writeFile(outFile, data, 0o644); err != nil {
+		logger.Log.Error("Failed to write certificate", zap.Error(err))
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote '%s', valid until %s, to %s\n",
+		cert.Subject.CommonName, certificate.FormatDate(cert.NotAfter), outFile)
+	return nil
+}
+
+// addCertificateFlags gives a command the flags for the fields of a
+// certificate, its subject among them.
+func addCertificateFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("host", nil, "DNS names and IP addresses the certificate is for")
+	addSubjectFlags(cmd)
+	cmd.Flags().Int("days", 365, "Days the certificate is valid for")
+	cmd.Flags().String("not-before", "", "Start of the validity period instead of now (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().String("serial", "", "Serial number in hex (default: random)")
+	cmd.Flags().Bool("ca", false, "Make a CA certificate")
+	cmd.Flags().Int("path-len", -1, "Path length constraint of a CA certificate (default: none)")
+	cmd.Flags().StringSlice("key-usage", nil, "Key usages, as digitalSignature or keyCertSign")
+	cmd.Flags().StringSlice("ext-key-usage", nil, "Extended key usages, as serverAuth or clientAuth")
+}
+
+func init() {
+	addCertificateFlags(genSelfSignedCmd)
+	addKeyFlags(genSelfSignedCmd)
+	genSelfSignedCmd.Flags().StringP("output", "o", "", "File to write the certificate to (default: stdout)")
+	genSelfSignedCmd.Flags().BoolP("force", "f", false, "Overwrite files that already exist")
+	genCmd.AddCommand(genSelfSignedCmd)
+
+	addSubjectFlags(genChainCmd)
+	genChainCmd.Flags().String("root-cn", "y509 Test Root CA", "Common name of the root CA")
+	genChainCmd.Flags().String("intermediate-cn", "y509 Test Intermediate CA", "Common name of the intermediate CA")
+	genChainCmd.Flags().Int("intermediates", 1, "Number of intermediate CAs")
+	genChainCmd.Flags().Int("days", 90, "Days the leaf is valid for")
+	genChainCmd.Flags().Int("ca-days", 3650, "Days the CAs are valid for")
+	genChainCmd.Flags().StringSlice("ext-key-usage", nil, "Extended key usages of the leaf, as serverAuth or clientAuth")
+	genChainCmd.Flags().String("key-type", certificate.KeyTypes[0], "Type of the keys: "+strings.Join(certificate.KeyTypes, ", "))
+	genChainCmd.Flags().StringP("out-dir", "o", ".", "Directory to write the files to")
+	genChainCmd.Flags().BoolP("force", "f", false, "Overwrite files that already exist")
+	_ = genChainCmd.MarkFlagDirname("out-dir")
+	_ = genChainCmd.RegisterFlagCompletionFunc("key-type", cobra.FixedCompletions(certificate.KeyTypes, cobra.ShellCompDirectiveNoFileComp))
+	genCmd.AddCommand(genChainCmd)
+	RootCmd.AddCommand(genCmd)
+}
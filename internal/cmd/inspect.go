@@ -0,0 +1,171 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// inspectCmd prints certificate details without the TUI.
+var inspectCmd = &cobra.Command{
+	Use:   "inspect [file | host:port]",
+	Short: "Print certificate details",
+	Long: `Print the details of every certificate in the input to stdout: subject,
+issuer, validity, SANs, public key, key usage, fingerprint and extensions, as
+the TUI shows them, for scripts and CI logs.
+
+Pass --index to print only one certificate, counting from 0 as export does.
+Pass --text for the layout of openssl x509 -text instead, so the output can be
+diffed against existing tooling or pasted into a ticket, or --output json or
+yaml for a list of objects with the same details, for jq and other tools.
+--format prints a line per certificate from a Go template, as list --format
+does.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+		text, err := cmd.Flags().GetBool("text")
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if text && format != outputText {
+			return fmt.Errorf("--text and --output %s do not go together", format)
+		}
+		tmpl, err := formatTemplate(cmd, format)
+		if err != nil {
+			return err
+		}
+		if text && tmpl != nil {
+			return fmt.Errorf("--text and --format do not go together")
+		}
+
+		certs, first, err := selectIndex(cmd, source.Certs)
+		if err != nil {
+			return err
+		}
+
+		if tmpl != nil {
+			certificate.ValidateChainLinks(source.Certs)
+			return writeFormatted(os.Stdout, tmpl, certs, first, expiryWarningDays())
+		}
+		if format != outputText {
+			certificate.ValidateChainLinks(source.Certs)
+			described := certificatesJSON(source.Certs, expiryWarningDays())
+			return writeOutput(os.Stdout, format, described[first:first+len(certs)])
+		}
+
+		for i, c := range certs {
+			if i > 0 {
+				fmt.Println()
+			}
+			if text {
+				fmt.Print(certificate.FormatText(c.Certificate))
+				continue
+			}
+			if err := writeDetails(os.Stdout, c, first+i, len(source.Certs)); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// selectIndex narrows the input to the certificate --index names, if it
+// names one. first is the index of the first certificate left.
+func selectIndex(cmd *cobra.Command, certs []*certificate.Info) (selected []*certificate.Info, first int, err error) {
+	if !cmd.Flags().Changed("index") {
+		return certs, 0, nil
+	}
+	index, err := cmd.Flags().GetInt("index")
+	if err != nil {
+		return nil, 0, err
+	}
+	if index < 0 || index >= len(certs) {
+		return nil, 0, fmt.Errorf("certificate index %d out of range: the input has %d", index, len(certs))
+	}
+	return certs[index : index+1], index, nil
+}
+
+// writeDetails writes the details of the certificate at index of total, a
+// section for each part the TUI has a tab for.
+func writeDetails(w io.Writer, c *certificate.Info, index, total int) error {
+	cert := c.Certificate
+	var b strings.Builder
+	fmt.Fprintf(&b, "Certificate %d of %d\n", index+1, total)
+	writeSection(&b, "Subject", certificate.FormatSubject(cert))
+	writeSection(&b, "Issuer", certificate.FormatIssuer(cert))
+	writeSection(&b, "Validity", certificate.FormatValidity(cert))
+	writeSection(&b, "Subject Alternative Names", certificate.FormatSAN(cert))
+	writeSection(&b, "Public Key", certificate.FormatPublicKey(cert))
+	writeSection(&b, "Key Usage", fmt.Sprintf("Usage: %s\nExtended: %s",
+		orNone(certificate.FormatKeyUsage(cert)), orNone(certificate.FormatExtKeyUsage(cert))))
+	writeSection(&b, "Signature", fmt.Sprintf("Serial: %s\nAlgorithm: %s", cert.SerialNumber, cert.SignatureAlgorithm))
+	writeSection(&b, "SHA-256 Fingerprint", certificate.FormatFingerprint(cert))
+
+	var extensions []string
+	for _, ext := range certificate.DecodeExtensions(cert) {
+		name := ext.Name
+		if name == "" {
+			name = "Unknown extension"
+		}
+		header := fmt.Sprintf("%s (%s)", name, ext.OID)
+		if ext.Critical {
+			header += ", critical"
+		}
+		extensions = append(extensions, header)
+		for _, value := range ext.Values {
+			extensions = append(extensions, "  "+value)
+		}
+	}
+	if len(extensions) == 0 {
+		extensions = []string{"No extensions present"}
+	}
+	writeSection(&b, "Extensions", strings.Join(extensions, "\n"))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSection writes a titled block with its lines indented under the
+// title. Blank lines stay blank.
+func writeSection(b *strings.Builder, title, body string) {
+	fmt.Fprintf(b, "\n%s:\n", title)
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+}
+
+// orNone stands in for an empty value.
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func init() {
+	inspectCmd.Flags().Bool("text", false, "Print in the layout of openssl x509 -text")
+	inspectCmd.Flags().Int("index", 0, "Print only the certificate at this index, counting from 0")
+	addOutputFlag(inspectCmd)
+	addFormatFlag(inspectCmd)
+	_ = inspectCmd.RegisterFlagCompletionFunc("index", completeIndex)
+	RootCmd.AddCommand(inspectCmd)
+}
@@ -0,0 +1,159 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// keyCmd describes a private key, and finds its certificate in a bundle.
+var keyCmd = &cobra.Command{
+	Use:   "key <key> [bundle]",
+	Short: "Describe a private key and find its certificate",
+	Long: `Describe a private key: its type and size or curve, the encoding it is stored
+in, whether it is encrypted and with what, and the SHA-256 fingerprint of its
+public key, the spki-sha256 fingerprint of any certificate for the key.
+
+The key can be RSA, ECDSA or Ed25519, in PKCS#8, PKCS#1 or SEC 1 form, PEM or
+DER. An encrypted key is described as far as it can be without the
+passphrase: the type of an encrypted PKCS#8 key is encrypted with it.
+
+Given a bundle as well, the certificates in it that belong to the key are
+listed, and the command exits non-zero when none does. With --output json or
+yaml, the key comes as one object, with the indexes of the matching
+certificates in matches.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeArgs(completeFiles(keyFileExtensions...), completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			logger.Log.Error("Failed to read private key", zap.Error(err))
+			return fmt.Errorf("failed to read private key: %w", err)
+		}
+		info, err := certificate.InspectPrivateKey(data)
+		if err != nil {
+			return err
+		}
+
+		report := newKeyJSON(info)
+		var certs []*certificate.Info
+		if len(args) == 2 {
+			if info.Encrypted {
+				return fmt.Errorf("%s is encrypted, so its certificate cannot be looked up; decrypt it first (e.g. openssl pkey -in %s)", args[0], args[0])
+			}
+			if certs, err = loadCertificates(cmd, args[1]); err != nil {
+				logger.Log.Error("Failed to load certificates", zap.Error(err))
+				return err
+			}
+			report.Matches = []int{}
+			for i, c := range certs {
+				if certificate.KeyMatches(c.Certificate, info.Key) {
+					report.Matches = append(report.Matches, i)
+				}
+			}
+		}
+
+		if format != outputText {
+			err = writeOutput(os.Stdout, format, report)
+		} else {
+			err = writeKeyInfo(os.Stdout, report, certs)
+		}
+		if err != nil {
+			return err
+		}
+		if certs != nil && len(report.Matches) == 0 {
+			return &exitError{code: 1, err: fmt.Errorf("no certificate in %s matches the key", args[1])}
+		}
+		return nil
+	},
+}
+
+// keyJSON is a private key as key describes it. Matches is left out when
+// no bundle was given.
+type keyJSON struct {
+	Algorithm  string `json:"algorithm" yaml:"algorithm"`
+	Bits       int    `json:"bits" yaml:"bits"`
+	Curve      string `json:"curve" yaml:"curve"`
+	Format     string `json:"format" yaml:"format"`
+	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
+	Encryption string `json:"encryption" yaml:"encryption"`
+	SPKISHA256 string `json:"spki_sha256" yaml:"spki_sha256"`
+	Matches    []int  `json:"matches,omitempty" yaml:"matches,omitempty"`
+}
+
+func newKeyJSON(info *certificate.KeyInfo) keyJSON {
+	return keyJSON{
+		Algorithm:  info.Algorithm,
+		Bits:       info.Bits,
+		Curve:      info.Curve,
+		Format:     info.Format,
+		Encrypted:  info.Encrypted,
+		Encryption: info.Encryption,
+		SPKISHA256: hex.EncodeToString(info.SPKISHA256),
+	}
+}
+
+// writeKeyInfo writes the key's description, then the certificates of the
+// bundle that match it, when a bundle was given.
+func writeKeyInfo(w io.Writer, key keyJSON, certs []*certificate.Info) error {
+	keyType := orNone(key.Algorithm)
+	switch {
+	case key.Algorithm == "" && key.Encrypted:
+		keyType = "unknown until decrypted"
+	case key.Curve != "":
+		keyType += " " + key.Curve
+	case key.Bits > 0 && key.Algorithm == "RSA":
+		keyType += fmt.Sprintf(" %d bits", key.Bits)
+	}
+	var rows strings.Builder
+	fmt.Fprintf(&rows, "Type:\t%s\n", keyType)
+	fmt.Fprintf(&rows, "Format:\t%s\n", key.Format)
+	encrypted := "no"
+	if key.Encrypted {
+		encrypted = "yes"
+		if key.Encryption != "" {
+			encrypted += ", " + key.Encryption
+		}
+	}
+	fmt.Fprintf(&rows, "Encrypted:\t%s\n", encrypted)
+	if key.SPKISHA256 != "" {
+		digest, _ := hex.DecodeString(key.SPKISHA256)
+		fmt.Fprintf(&rows, "SPKI SHA-256:\t%s\n", certificate.ColonHex(digest))
+	}
+	if err := writeTable(w, rows.String()); err != nil {
+		return err
+	}
+
+	if certs == nil {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("\n")
+	if len(key.Matches) == 0 {
+		fmt.Fprintf(&b, "❌ The key matches none of the %d certificate(s)\n", len(certs))
+	}
+	for _, i := range key.Matches {
+		cert := certs[i].Certificate
+		fmt.Fprintf(&b, "✅ The key matches certificate %d, '%s', valid until %s\n",
+			i, orNone(cert.Subject.CommonName), certificate.FormatDate(cert.NotAfter))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func init() {
+	addOutputFlag(keyCmd)
+	RootCmd.AddCommand(keyCmd)
+}
@@ -0,0 +1,97 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// lintCmd checks end-entity certificates against a compliance profile.
+var lintCmd = &cobra.Command{
+	Use:   "lint [file | host:port]",
+	Short: "Check leaf certificates against a compliance profile",
+	Long: `Check every end-entity certificate in the input against a compliance
+profile: validity period, SAN presence, extended key usages, key types and
+sizes, signature hash, serial number entropy and AIA.
+
+Built-in profiles: ` + strings.Join(certificate.LintProfiles(), ", ") + `.
+--profile also accepts the path of a YAML profile in the same format, so the
+requirements can be tightened or updated without a new release. Being the
+lint profile here, it hides the global --profile; pick a configuration
+profile for lint with Y509_PROFILE.
+
+CA certificates are skipped; the profiles describe subscriber certificates.
+Exits non-zero when any certificate falls short.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, err := lintProfileFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+
+		fmt.Printf("Profile: %s\n\n", profile.Description)
+		checked, failed := 0, 0
+		for _, c := range source.Certs {
+			if c.Certificate.IsCA {
+				continue
+			}
+			findings := certificate.Lint(c.Certificate, profile)
+			fmt.Println(certificate.FormatLintFindings(c.Certificate, findings))
+			checked++
+			if len(findings) > 0 {
+				failed++
+			}
+		}
+
+		if checked == 0 {
+			return fmt.Errorf("no end-entity certificates to lint")
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d certificate(s) do not meet the %s profile", failed, checked, profile.Name)
+		}
+		return nil
+	},
+}
+
+// lintProfileFlag loads the lint profile --profile names, for lint and
+// report. A configuration profile given there is pointed at Y509_PROFILE
+// rather than reported as an unknown lint profile.
+func lintProfileFlag(cmd *cobra.Command) (*certificate.LintProfile, error) {
+	name, err := cmd.Flags().GetString("profile")
+	if err != nil {
+		return nil, err
+	}
+	profile, err := certificate.LoadLintProfile(name)
+	if err != nil && loadedConfig != nil && slices.Contains(loadedConfig.ProfileNames, name) {
+		return nil, fmt.Errorf("%q is a configuration profile, but --profile is the lint profile for %s: use Y509_PROFILE=%s", name, cmd.Name(), name)
+	}
+	return profile, err
+}
+
+// completeLintProfile completes --profile with the built-in profiles, or,
+// once what is typed looks like a path, with YAML files.
+func completeLintProfile(_ *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if strings.ContainsAny(toComplete, `./\`) {
+		return yamlFileExtensions, cobra.ShellCompDirectiveFilterFileExt
+	}
+	return certificate.LintProfiles(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	lintCmd.Flags().String("profile", "cabf-br", "Profile name ("+strings.Join(certificate.LintProfiles(), ", ")+") or YAML file")
+	_ = lintCmd.RegisterFlagCompletionFunc("profile", completeLintProfile)
+	RootCmd.AddCommand(lintCmd)
+}
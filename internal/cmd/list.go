@@ -0,0 +1,305 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// The formats list --output takes besides those of every command: a row
+// per certificate, for a spreadsheet or a CMDB import.
+const (
+	outputCSV = "csv"
+	outputTSV = "tsv"
+)
+
+// listOutputFormats lists the formats list --output takes.
+var listOutputFormats = append(slices.Clone(outputFormats), outputCSV, outputTSV)
+
+// listColumn is a column of list --output csv: its name, as --columns
+// takes it and the header row gives it, and its value for a certificate.
+type listColumn struct {
+	name  string
+	value func(c *certificate.Info, j certificateJSON) string
+}
+
+// listColumns are the columns --columns picks from. They are named as the
+// JSON fields are, with source, the file or server, besides. A list goes in
+// one cell, its items separated by semicolons.
+var listColumns = []listColumn{
+	{"index", func(_ *certificate.Info, j certificateJSON) string { return strconv.Itoa(j.Index) }},
+	{"subject", func(_ *certificate.Info, j certificateJSON) string { return j.Subject }},
+	{"common_name", func(_ *certificate.Info, j certificateJSON) string { return j.CommonName }},
+	{"issuer", func(_ *certificate.Info, j certificateJSON) string { return j.Issuer }},
+	{"issuer_common_name", func(_ *certificate.Info, j certificateJSON) string { return j.IssuerCommonName }},
+	{"serial", func(_ *certificate.Info, j certificateJSON) string { return j.Serial }},
+	{"not_before", func(_ *certificate.Info, j certificateJSON) string { return j.NotBefore.Format(time.RFC3339) }},
+	{"not_after", func(_ *certificate.Info, j certificateJSON) string { return j.NotAfter.Format(time.RFC3339) }},
+	{"days_left", func(_ *certificate.Info, j certificateJSON) string { return strconv.Itoa(j.DaysLeft) }},
+	{"status", func(_ *certificate.Info, j certificateJSON) string { return j.Status }},
+	{"is_ca", func(_ *certificate.Info, j certificateJSON) string { return strconv.FormatBool(j.IsCA) }},
+	{"dns_names", func(_ *certificate.Info, j certificateJSON) string { return strings.Join(j.DNSNames, ";") }},
+	{"ip_addresses", func(_ *certificate.Info, j certificateJSON) string { return strings.Join(j.IPAddresses, ";") }},
+	{"email_addresses", func(_ *certificate.Info, j certificateJSON) string { return strings.Join(j.EmailAddresses, ";") }},
+	{"key_type", func(_ *certificate.Info, j certificateJSON) string { return j.KeyType }},
+	{"key_bits", func(_ *certificate.Info, j certificateJSON) string { return strconv.Itoa(j.KeyBits) }},
+	{"signature_algorithm", func(_ *certificate.Info, j certificateJSON) string { return j.SignatureAlgorithm }},
+	{"key_usage", func(_ *certificate.Info, j certificateJSON) string { return strings.Join(j.KeyUsage, ";") }},
+	{"ext_key_usage", func(_ *certificate.Info, j certificateJSON) string { return strings.Join(j.ExtKeyUsage, ";") }},
+	{"sha256", func(_ *certificate.Info, j certificateJSON) string { return j.SHA256 }},
+	{"source", func(c *certificate.Info, _ certificateJSON) string { return c.Source }},
+}
+
+// defaultListColumns are the columns of list --output csv without
+// --columns: those of the table, with the whole fingerprint.
+var defaultListColumns = []string{"index", "common_name", "issuer_common_name", "not_after", "status", "sha256"}
+
+// listColumnNames lists the names --columns takes, in order.
+func listColumnNames() []string {
+	names := make([]string, len(listColumns))
+	for i, column := range listColumns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// listCmd prints the certificate list as a table, as the TUI's left pane
+// shows it.
+var listCmd = &cobra.Command{
+	Use:   "list [file | host:port]",
+	Short: "Print the certificates as a table",
+	Long: `Print one row per certificate in the input: its index, common name, issuer,
+expiry date, status and the start of its SHA-256 fingerprint, aligned in
+columns. The index counts from 0, as export and inspect --index take it.
+
+The status is that of the TUI: valid, expiring (within expiry_warning_days
+of the configuration), expired, not yet valid, issuer missing, bad signature
+or constraint violation, each certificate checked against the rest of the
+input.
+
+Pass --wide for the start date, serial, key type, signature algorithm and
+SANs too, and the whole fingerprint. With --output json or yaml, every
+certificate is described in full instead, as inspect --output does.
+
+With --output csv or tsv, a header row and then a row per certificate, for a
+spreadsheet or a CMDB import. --columns picks the columns, in order, by the
+names the JSON fields have, with source besides:
+
+  y509 list fleet.pem -o csv --columns common_name,dns_names,not_after,days_left
+
+A list, such as dns_names, goes in one cell, separated by semicolons. Dates
+are RFC 3339, in UTC. The columns are index, subject, common_name, issuer,
+issuer_common_name, serial, not_before, not_after, days_left, status, is_ca,
+dns_names, ip_addresses, email_addresses, key_type, key_bits,
+signature_algorithm, key_usage, ext_key_usage, sha256 and source.
+
+Pass --format for a line per certificate from a Go template instead of the
+table, as docker and kubectl take one: '{{.Subject.CommonName}},{{.NotAfter}}'.
+The template sees the fields of Go's x509.Certificate, with .Index, .Status,
+.DaysLeft, .SHA256 and .KeyType besides, and can call join, date, upper, lower
+and json.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+		wide, err := cmd.Flags().GetBool("wide")
+		if err != nil {
+			return err
+		}
+		format, err := listOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		columns, err := listColumnsFromFlags(cmd, format)
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := formatTemplate(cmd, format)
+		if err != nil {
+			return err
+		}
+
+		certificate.ValidateChainLinks(source.Certs)
+		if tmpl != nil {
+			return writeFormatted(os.Stdout, tmpl, source.Certs, 0, expiryWarningDays())
+		}
+		if format == outputCSV || format == outputTSV {
+			return writeListCSV(os.Stdout, source.Certs, columns, format == outputTSV, expiryWarningDays())
+		}
+		if format != outputText {
+			return writeOutput(os.Stdout, format, certificatesJSON(source.Certs, expiryWarningDays()))
+		}
+		return writeList(os.Stdout, source.Certs, wide, expiryWarningDays())
+	},
+}
+
+// listOutputFormat is the format list --output asks for, checked.
+func listOutputFormat(cmd *cobra.Command) (string, error) {
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+	format = strings.ToLower(format)
+	if !slices.Contains(listOutputFormats, format) {
+		return "", fmt.Errorf("unknown output format %q (one of %s)", format, strings.Join(listOutputFormats, ", "))
+	}
+	return format, nil
+}
+
+// listColumnsFromFlags is the columns --columns asks for, checked, or the
+// default ones. They only go with --output csv or tsv.
+func listColumnsFromFlags(cmd *cobra.Command, format string) ([]listColumn, error) {
+	names, err := cmd.Flags().GetStringSlice("columns")
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Flags().Changed("columns") && format != outputCSV && format != outputTSV {
+		return nil, fmt.Errorf("--columns goes with --output %s or %s", outputCSV, outputTSV)
+	}
+	if len(names) == 0 {
+		names = defaultListColumns
+	}
+	columns := make([]listColumn, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(listColumns, func(c listColumn) bool { return c.name == strings.ToLower(strings.TrimSpace(name)) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column %q (one of %s)", name, strings.Join(listColumnNames(), ", "))
+		}
+		columns = append(columns, listColumns[i])
+	}
+	return columns, nil
+}
+
+// writeListCSV writes a header row of the column names, then a row per
+// certificate, as CSV or, with tabs set, TSV.
+func writeListCSV(w io.Writer, certs []*certificate.Info, columns []listColumn, tabs bool, warnDays int) error {
+	cw := csv.NewWriter(w)
+	if tabs {
+		cw.Comma = '\t'
+	}
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = column.name
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+	for i, c := range certs {
+		j := newCertificateJSON(c, i, warnDays)
+		for k, column := range columns {
+			row[k] = column.value(c, j)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// expiryWarningDays is how close to expiry a certificate is called
+// expiring, from the configuration as the TUI takes it.
+func expiryWarningDays() int {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.Log.Warn("Failed to load configuration", zap.Error(err))
+	}
+	return cfg.ExpiryWarningDays
+}
+
+// writeList writes the certificates as a table, a row each under a header.
+func writeList(w io.Writer, certs []*certificate.Info, wide bool, warnDays int) error {
+	header := []string{"#", "COMMON NAME", "ISSUER", "NOT AFTER", "STATUS", "SHA-256"}
+	if wide {
+		header = append(header, "NOT BEFORE", "SERIAL", "KEY", "SIGNATURE", "SANS")
+	}
+	var rows strings.Builder
+	rows.WriteString(strings.Join(header, "\t") + "\n")
+	for i, c := range certs {
+		cert := c.Certificate
+		fingerprint := certificate.FormatFingerprint(cert)
+		if !wide {
+			fingerprint = fingerprint[:16]
+		}
+		row := []string{
+			fmt.Sprint(i),
+			orNone(cert.Subject.CommonName),
+			orNone(cert.Issuer.CommonName),
+			certificate.FormatDate(cert.NotAfter),
+			statusOf(c, warnDays),
+			fingerprint,
+		}
+		if wide {
+			key, _ := certificate.KeyType(cert)
+			row = append(row,
+				certificate.FormatDate(cert.NotBefore),
+				cert.SerialNumber.Text(16),
+				key,
+				cert.SignatureAlgorithm.String(),
+				orNone(strings.Join(sanNames(c), ",")))
+		}
+		rows.WriteString(strings.Join(row, "\t") + "\n")
+	}
+	return writeTable(w, rows.String())
+}
+
+// statusOf names a certificate's status in a word or two, as the TUI's
+// status icons show it.
+func statusOf(c *certificate.Info, warnDays int) string {
+	switch c.ValidationStatus {
+	case certificate.StatusExpired:
+		return "expired"
+	case certificate.StatusInvalidSignature:
+		return "bad signature"
+	case certificate.StatusConstraintViolation:
+		return "constraint violation"
+	case certificate.StatusMismatchedIssuer:
+		return "issuer missing"
+	case certificate.StatusWarning:
+		return "not yet valid"
+	}
+	if certificate.IsExpired(c.Certificate) {
+		return "expired"
+	}
+	if certificate.IsExpiringSoonWithin(c.Certificate, warnDays) {
+		return "expiring"
+	}
+	return "valid"
+}
+
+// sanNames lists a certificate's DNS, IP and email SANs.
+func sanNames(c *certificate.Info) []string {
+	cert := c.Certificate
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return append(names, cert.EmailAddresses...)
+}
+
+func init() {
+	listCmd.Flags().Bool("wide", false, "Add the start date, serial, key, signature algorithm and SANs")
+	listCmd.Flags().StringP("output", "o", outputText, "Output format: "+strings.Join(listOutputFormats, ", "))
+	listCmd.Flags().StringSlice("columns", nil, "Columns of --output csv or tsv, comma-separated (default: "+strings.Join(defaultListColumns, ",")+")")
+	_ = listCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(listOutputFormats, cobra.ShellCompDirectiveNoFileComp))
+	_ = listCmd.RegisterFlagCompletionFunc("columns", cobra.FixedCompletions(listColumnNames(), cobra.ShellCompDirectiveNoFileComp))
+	addFormatFlag(listCmd)
+	RootCmd.AddCommand(listCmd)
+}
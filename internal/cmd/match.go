@@ -0,0 +1,151 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// matchCmd checks that a certificate, a private key and a request share a
+// public key.
+var matchCmd = &cobra.Command{
+	Use:   "match [<cert> <key>]",
+	Short: "Check that a certificate, key and request belong together",
+	Long: `Check that a certificate, a private key and a certificate signing request
+hold the same public key, before deploying them together: give any two of
+--cert, --key and --csr, or all three. 'y509 match cert.pem key.pem' is
+short for --cert and --key.
+
+The key can be RSA, ECDSA or Ed25519, in PKCS#8, PKCS#1 or SEC 1 form, PEM or
+DER; the request PEM or DER, and its own signature is checked too. When the
+certificate file holds a chain, every certificate in it is tried, so a
+full-chain file works as well as a lone leaf.
+
+Exits non-zero on any mismatch.`,
+	Args: cobra.MatchAll(cobra.MaximumNArgs(2), func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			return fmt.Errorf("give both a certificate and a key, or use --cert, --key and --csr")
+		}
+		if len(args) == 2 && (cmd.Flags().Changed("cert") || cmd.Flags().Changed("key")) {
+			return fmt.Errorf("give the certificate and key as arguments or with --cert and --key, not both")
+		}
+		return nil
+	}),
+	ValidArgsFunction: completeArgs(completeCertFiles, completeFiles(keyFileExtensions...)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		certFile, err := cmd.Flags().GetString("cert")
+		if err != nil {
+			return err
+		}
+		keyFile, err := cmd.Flags().GetString("key")
+		if err != nil {
+			return err
+		}
+		csrFile, err := cmd.Flags().GetString("csr")
+		if err != nil {
+			return err
+		}
+		if len(args) == 2 {
+			certFile, keyFile = args[0], args[1]
+		}
+		given := 0
+		for _, file := range []string{certFile, keyFile, csrFile} {
+			if file != "" {
+				given++
+			}
+		}
+		if given < 2 {
+			return fmt.Errorf("give at least two of --cert, --key and --csr to match")
+		}
+
+		var certs []*certificate.Info
+		if certFile != "" {
+			if certs, err = loadCertificates(cmd, certFile); err != nil {
+				logger.Log.Error("Failed to load certificates", zap.Error(err))
+				return err
+			}
+		}
+		var key crypto.Signer
+		if keyFile != "" {
+			if key, err = certificate.LoadPrivateKey(keyFile); err != nil {
+				logger.Log.Error("Failed to load private key", zap.Error(err))
+				return err
+			}
+		}
+		var csr *x509.CertificateRequest
+		if csrFile != "" {
+			if csr, err = certificate.LoadCertificateRequest(csrFile); err != nil {
+				logger.Log.Error("Failed to load certificate request", zap.Error(err))
+				return err
+			}
+		}
+
+		if !reportMatches(certs, key, csr) {
+			return fmt.Errorf("public keys do not match")
+		}
+		return nil
+	},
+}
+
+// reportMatches prints whether each pair of what was given shares a public
+// key, and reports whether they all do. The private key, or else the
+// request, is what the certificates are matched against.
+func reportMatches(certs []*certificate.Info, key crypto.Signer, csr *x509.CertificateRequest) bool {
+	ok := true
+	var keyName, csrName string
+	if key != nil {
+		keyName = fmt.Sprintf("The %s private key", certificate.DescribePrivateKey(key))
+	}
+	if csr != nil {
+		csrName = fmt.Sprintf("The request for '%s'", orNone(csr.Subject.CommonName))
+	}
+
+	if key != nil && csr != nil {
+		if certificate.SamePublicKey(key.Public(), csr.PublicKey) {
+			fmt.Printf("✅ %s matches the private key\n", csrName)
+		} else {
+			fmt.Printf("❌ %s does not match the private key\n", csrName)
+			ok = false
+		}
+	}
+
+	if certs == nil {
+		return ok
+	}
+	name, pub := keyName, crypto.PublicKey(nil)
+	if key != nil {
+		pub = key.Public()
+	} else {
+		name, pub = csrName, csr.PublicKey
+	}
+	for _, c := range certs {
+		if certificate.SamePublicKey(pub, c.Certificate.PublicKey) {
+			fmt.Printf("✅ %s matches '%s'\n", name, c.Certificate.Subject.CommonName)
+			return ok
+		}
+	}
+	fmt.Printf("❌ %s does not match ", name)
+	if len(certs) == 1 {
+		fmt.Printf("'%s' (%s)\n", certs[0].Certificate.Subject.CommonName,
+			certs[0].Certificate.PublicKeyAlgorithm)
+	} else {
+		fmt.Printf("any of the %d certificates\n", len(certs))
+	}
+	return false
+}
+
+func init() {
+	matchCmd.Flags().String("cert", "", "Certificate, or chain, to match")
+	matchCmd.Flags().String("key", "", "Private key to match")
+	matchCmd.Flags().String("csr", "", "Certificate signing request to match")
+	_ = matchCmd.MarkFlagFilename("cert", certFileExtensions...)
+	_ = matchCmd.MarkFlagFilename("key", keyFileExtensions...)
+	_ = matchCmd.MarkFlagFilename("csr", csrFileExtensions...)
+	RootCmd.AddCommand(matchCmd)
+}
@@ -0,0 +1,213 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of ocsp and crl besides 0, for a certificate not revoked.
+const (
+	exitRevocationUnknown = 1
+	exitRevocationRevoked = 2
+)
+
+// ocspCmd asks a certificate's OCSP responder about it.
+var ocspCmd = &cobra.Command{
+	Use:   "ocsp [file | host:port]",
+	Short: "Check a certificate with its OCSP responder",
+	Long: `Ask the OCSP responder of a certificate whether it has been revoked, and
+print the answer with when it was produced, the period it is good for, and
+how long the responder took, as the TUI's revocation check does.
+
+The certificate is the leaf, or the one at --index, counting from 0; its
+issuer must be in the input too, or be given with --issuer, to build the
+request and check the signature on the answer. The responders the
+certificate names are asked in turn until one answers, or --responder alone.
+
+Exit status, as a monitoring plugin's:
+  0  the certificate is good
+  1  no answer could be had, or the responder does not know the certificate
+  2  the certificate is revoked
+
+With --output json or yaml, the answer comes as one object.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		responder, err := cmd.Flags().GetString("responder")
+		if err != nil {
+			return err
+		}
+		cert, issuer, err := revocationSubject(cmd, args)
+		if err != nil {
+			return err
+		}
+		if issuer == nil {
+			return fmt.Errorf("the issuer of '%s', '%s', is not in the input; give it with --issuer",
+				orNone(cert.Subject.CommonName), orNone(cert.Issuer.CommonName))
+		}
+		responders := cert.OCSPServer
+		if responder != "" {
+			responders = []string{responder}
+		}
+		if len(responders) == 0 {
+			return fmt.Errorf("'%s' names no OCSP responder; give one with --responder", orNone(cert.Subject.CommonName))
+		}
+
+		index, err := cmd.Flags().GetInt("index")
+		if err != nil {
+			return err
+		}
+		result := queryOCSP(cmd.Context(), responders, cert, issuer)
+		report := newOCSPJSON(cert, index, result)
+		if format != outputText {
+			err = writeOutput(os.Stdout, format, report)
+		} else {
+			err = writeOCSP(os.Stdout, report)
+		}
+		if err != nil {
+			return err
+		}
+		switch result.Status {
+		case certificate.RevocationGood:
+			return nil
+		case certificate.RevocationRevoked:
+			return &exitError{code: exitRevocationRevoked, err: fmt.Errorf("'%s' is revoked", orNone(cert.Subject.CommonName))}
+		default:
+			return &exitError{code: exitRevocationUnknown, err: fmt.Errorf("OCSP status unknown: %w", result.Err)}
+		}
+	},
+}
+
+// queryOCSP asks each responder in turn until one gives a good or revoked
+// answer. Failing that, it returns the last answer, its Err saying what
+// went wrong with each.
+func queryOCSP(ctx context.Context, responders []string, cert, issuer *x509.Certificate) certificate.OCSPResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var result certificate.OCSPResult
+	var errs []error
+	for _, url := range responders {
+		var err error
+		result, err = certificate.QueryOCSP(ctx, url, cert, issuer)
+		if err == nil && result.Status != certificate.RevocationUnknown {
+			return result
+		}
+		if err == nil {
+			err = fmt.Errorf("%s: %w", url, result.Err)
+		}
+		errs = append(errs, err)
+	}
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// ocspJSON is the answer of ocsp, as --output gives it. The times are
+// RFC 3339, and empty when the responder did not give them.
+type ocspJSON struct {
+	Index      int    `json:"index" yaml:"index"`
+	CommonName string `json:"common_name" yaml:"common_name"`
+	Serial     string `json:"serial" yaml:"serial"`
+	Responder  string `json:"responder" yaml:"responder"`
+	Status     string `json:"status" yaml:"status"`
+	RevokedAt  string `json:"revoked_at" yaml:"revoked_at"`
+	Reason     string `json:"reason" yaml:"reason"`
+	ProducedAt string `json:"produced_at" yaml:"produced_at"`
+	ThisUpdate string `json:"this_update" yaml:"this_update"`
+	NextUpdate string `json:"next_update" yaml:"next_update"`
+	ElapsedMS  int64  `json:"elapsed_ms" yaml:"elapsed_ms"`
+	Error      string `json:"error" yaml:"error"`
+}
+
+func newOCSPJSON(cert *x509.Certificate, index int, result certificate.OCSPResult) ocspJSON {
+	out := ocspJSON{
+		Index:      index,
+		CommonName: cert.Subject.CommonName,
+		Serial:     cert.SerialNumber.Text(16),
+		Responder:  result.Responder,
+		Status:     result.Status.String(),
+		ProducedAt: rfc3339(result.ProducedAt),
+		ThisUpdate: rfc3339(result.ThisUpdate),
+		NextUpdate: rfc3339(result.NextUpdate),
+		ElapsedMS:  result.Elapsed.Milliseconds(),
+	}
+	if result.Status == certificate.RevocationRevoked {
+		out.RevokedAt = rfc3339(result.RevokedAt)
+		out.Reason = certificate.RevocationReasonName(result.Reason)
+	}
+	if result.Err != nil {
+		out.Error = result.Err.Error()
+	}
+	return out
+}
+
+// rfc3339 writes t for --output, or nothing for a time not given.
+func rfc3339(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// displayTime writes a time of the --output fields in the configured format,
+// for the text output.
+func displayTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return certificate.FormatTime(t)
+}
+
+// writeOCSP writes the answer a field to a line. The verdict is the exit
+// status, and the error when there is no good answer.
+func writeOCSP(w io.Writer, r ocspJSON) error {
+	mark := map[string]string{"good": "✅", "revoked": "❌"}[r.Status]
+	if mark == "" {
+		mark = "⚠️"
+	}
+	var rows strings.Builder
+	fmt.Fprintf(&rows, "%s Certificate %d: %s is %s\n", mark, r.Index, orNone(r.CommonName), r.Status)
+	fields := []struct{ name, value string }{
+		{"Serial", r.Serial},
+		{"Responder", r.Responder},
+		{"Revoked at", displayTime(r.RevokedAt)},
+		{"Reason", r.Reason},
+		{"Produced at", displayTime(r.ProducedAt)},
+		{"This update", displayTime(r.ThisUpdate)},
+		{"Next update", displayTime(r.NextUpdate)},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			fmt.Fprintf(&rows, "  %s:\t%s\n", f.name, f.value)
+		}
+	}
+	if r.Responder != "" {
+		fmt.Fprintf(&rows, "  Time:\t%d ms\n", r.ElapsedMS)
+	}
+	return writeTable(w, rows.String())
+}
+
+func init() {
+	ocspCmd.Flags().Int("index", 0, "Check the certificate at this index, counting from 0")
+	ocspCmd.Flags().String("responder", "", "Ask this OCSP responder instead of those the certificate names")
+	ocspCmd.Flags().String("issuer", "", "PEM file holding the issuer, when it is not in the input")
+	addOutputFlag(ocspCmd)
+	_ = ocspCmd.RegisterFlagCompletionFunc("index", completeIndex)
+	_ = ocspCmd.MarkFlagFilename("issuer", certFileExtensions...)
+	RootCmd.AddCommand(ocspCmd)
+}
@@ -0,0 +1,284 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// The formats --output takes. Text is for people; the others are for
+// scripts, and keep their field names from one release to the next.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputFormats lists the formats --output takes, for its help and its
+// completion.
+var outputFormats = []string{outputText, outputJSON, outputYAML}
+
+// addOutputFlag gives a subcommand --output.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", outputText, "Output format: "+strings.Join(outputFormats, ", "))
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// outputFormat is the format --output asks for, checked.
+func outputFormat(cmd *cobra.Command) (string, error) {
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+	format = strings.ToLower(format)
+	if !slices.Contains(outputFormats, format) {
+		return "", fmt.Errorf("unknown output format %q (one of %s)", format, strings.Join(outputFormats, ", "))
+	}
+	return format, nil
+}
+
+// writeOutput writes v in one of the formats for scripts.
+func writeOutput(w io.Writer, format string, v any) error {
+	if format == outputYAML {
+		return writeYAML(w, v)
+	}
+	return writeJSON(w, v)
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeYAML writes v as YAML, with the same field names as the JSON.
+func writeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// writeTable writes rows, their columns separated by tabs, with the columns
+// lined up.
+func writeTable(w io.Writer, rows string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := io.WriteString(tw, rows); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// templateFuncs are the functions a --format template can call, besides
+// the built-in ones: {{join .DNSNames ","}}, {{date .NotAfter "2006-01-02"}}
+// (in the configured time zone), {{upper .Status}} and {{json .Extensions}}.
+var templateFuncs = template.FuncMap{
+	"join":  func(list []string, sep string) string { return strings.Join(list, sep) },
+	"date":  func(t time.Time, layout string) string { return certificate.CurrentTimeFormat().In(t).Format(layout) },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// addFormatFlag gives a subcommand --format, a template applied to each
+// certificate as docker and kubectl apply theirs.
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().String("format", "", "Print each certificate with a Go template, as '{{.Subject.CommonName}},{{.NotAfter}}'")
+}
+
+// formatTemplate is the template --format gives, nil without one. It does
+// not go with an --output other than text.
+func formatTemplate(cmd *cobra.Command, format string) (*template.Template, error) {
+	text, err := cmd.Flags().GetString("format")
+	if err != nil || text == "" {
+		return nil, err
+	}
+	if format != outputText {
+		return nil, fmt.Errorf("--format and --output %s do not go together", format)
+	}
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("--format: %w", err)
+	}
+	return tmpl, nil
+}
+
+// templateData is what a --format template sees of a certificate: the
+// fields of Go's x509.Certificate, so .Subject.CommonName, .NotAfter and
+// .DNSNames, and a few of y509's own.
+type templateData struct {
+	*x509.Certificate
+	// Index is the certificate's position in the input, counting from 0.
+	Index int
+	// Status is its status as list shows it.
+	Status string
+	// DaysLeft is the whole days until NotAfter, negative once expired.
+	DaysLeft int
+	// SHA256 is the fingerprint in hex, and KeyType the key as
+	// "ECDSA-P256".
+	SHA256  string
+	KeyType string
+}
+
+// writeFormatted writes a line for each certificate, from the template,
+// the first at index first of the input. ValidateChainLinks should have
+// been run over the input for the status.
+func writeFormatted(w io.Writer, tmpl *template.Template, certs []*certificate.Info, first, warnDays int) error {
+	for i, c := range certs {
+		keyType, _ := certificate.KeyType(c.Certificate)
+		data := templateData{
+			Certificate: c.Certificate,
+			Index:       first + i,
+			Status:      statusOf(c, warnDays),
+			DaysLeft:    int(time.Until(c.Certificate.NotAfter).Hours() / 24),
+			SHA256:      certificate.FormatFingerprint(c.Certificate),
+			KeyType:     keyType,
+		}
+		if err := tmpl.Execute(w, data); err != nil {
+			return fmt.Errorf("--format: %w", err)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// certificateJSON is a certificate as the JSON and YAML output describe
+// it. Lists are empty rather than null when there is nothing in them, so a
+// jq filter need not guard against both.
+type certificateJSON struct {
+	Index              int             `json:"index" yaml:"index"`
+	Subject            string          `json:"subject" yaml:"subject"`
+	CommonName         string          `json:"common_name" yaml:"common_name"`
+	Issuer             string          `json:"issuer" yaml:"issuer"`
+	IssuerCommonName   string          `json:"issuer_common_name" yaml:"issuer_common_name"`
+	Serial             string          `json:"serial" yaml:"serial"`
+	NotBefore          time.Time       `json:"not_before" yaml:"not_before"`
+	NotAfter           time.Time       `json:"not_after" yaml:"not_after"`
+	DaysLeft           int             `json:"days_left" yaml:"days_left"`
+	Status             string          `json:"status" yaml:"status"`
+	IsCA               bool            `json:"is_ca" yaml:"is_ca"`
+	DNSNames           []string        `json:"dns_names" yaml:"dns_names"`
+	IPAddresses        []string        `json:"ip_addresses" yaml:"ip_addresses"`
+	EmailAddresses     []string        `json:"email_addresses" yaml:"email_addresses"`
+	KeyType            string          `json:"key_type" yaml:"key_type"`
+	KeyBits            int             `json:"key_bits" yaml:"key_bits"`
+	SignatureAlgorithm string          `json:"signature_algorithm" yaml:"signature_algorithm"`
+	KeyUsage           []string        `json:"key_usage" yaml:"key_usage"`
+	ExtKeyUsage        []string        `json:"ext_key_usage" yaml:"ext_key_usage"`
+	SHA256             string          `json:"sha256" yaml:"sha256"`
+	Extensions         []extensionJSON `json:"extensions" yaml:"extensions"`
+}
+
+// extensionJSON is an extension, decoded as the Extensions tab shows it.
+type extensionJSON struct {
+	OID      string   `json:"oid" yaml:"oid"`
+	Name     string   `json:"name" yaml:"name"`
+	Critical bool     `json:"critical" yaml:"critical"`
+	Values   []string `json:"values" yaml:"values"`
+}
+
+// newCertificateJSON describes the certificate at index. Its status is
+// that of list, so ValidateChainLinks should have been run over the input.
+func newCertificateJSON(c *certificate.Info, index, warnDays int) certificateJSON {
+	cert := c.Certificate
+	keyType, keyBits := certificate.KeyType(cert)
+	out := certificateJSON{
+		Index:              index,
+		Subject:            cert.Subject.String(),
+		CommonName:         cert.Subject.CommonName,
+		Issuer:             cert.Issuer.String(),
+		IssuerCommonName:   cert.Issuer.CommonName,
+		Serial:             cert.SerialNumber.Text(16),
+		NotBefore:          cert.NotBefore.UTC(),
+		NotAfter:           cert.NotAfter.UTC(),
+		DaysLeft:           int(time.Until(cert.NotAfter).Hours() / 24),
+		Status:             statusOf(c, warnDays),
+		IsCA:               cert.IsCA,
+		DNSNames:           nonNil(cert.DNSNames),
+		IPAddresses:        []string{},
+		EmailAddresses:     nonNil(cert.EmailAddresses),
+		KeyType:            keyType,
+		KeyBits:            keyBits,
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		KeyUsage:           splitList(certificate.FormatKeyUsage(cert)),
+		ExtKeyUsage:        splitList(certificate.FormatExtKeyUsage(cert)),
+		SHA256:             certificate.FormatFingerprint(cert),
+		Extensions:         []extensionJSON{},
+	}
+	for _, ip := range cert.IPAddresses {
+		out.IPAddresses = append(out.IPAddresses, ip.String())
+	}
+	for _, ext := range certificate.DecodeExtensions(cert) {
+		out.Extensions = append(out.Extensions, extensionJSON{OID: ext.OID, Name: ext.Name, Critical: ext.Critical, Values: nonNil(ext.Values)})
+	}
+	return out
+}
+
+// certificatesJSON describes every certificate of the input.
+func certificatesJSON(certs []*certificate.Info, warnDays int) []certificateJSON {
+	out := make([]certificateJSON, len(certs))
+	for i, c := range certs {
+		out[i] = newCertificateJSON(c, i, warnDays)
+	}
+	return out
+}
+
+// nonNil is s, or an empty list in place of nil.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// splitList splits a comma-separated list as the Format functions join
+// them.
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ", ")
+}
+
+// refuseOverwrite fails when path is already there, unless force is set,
+// as export does.
+func refuseOverwrite(path string, force bool) error {
+	if path == "" || force {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists; --force overwrites it", path)
+	}
+	return nil
+}
+
+// writeFile writes data to path, making its directory first.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %v", err)
+		}
+	}
+	return os.WriteFile(path, data, perm)
+}
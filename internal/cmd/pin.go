@@ -0,0 +1,86 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// pinCmd prints the SPKI pins of certificates.
+var pinCmd = &cobra.Command{
+	Use:   "pin [file | host:port]",
+	Short: "Print SPKI pin hashes for key pinning",
+	Long: `Print the pin of every certificate in the input: the base64 SHA-256 of its
+public key, as HPKP's pin-sha256="..." and curl's --pinnedpubkey sha256//...
+take it. The pin stays the same when a certificate is renewed with the same
+key.
+
+--curl prints only the pins of the whole input, joined by ';' as one
+--pinnedpubkey argument, so that curl accepts a connection whose chain has any
+of them. Pass --index to print only one certificate, counting from 0.`,
+	Example: `  y509 pin chain.pem
+  curl --pinnedpubkey "$(y509 pin --curl chain.pem)" https://example.com/`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		curl, err := cmd.Flags().GetBool("curl")
+		if err != nil {
+			return err
+		}
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+
+		certs, first, err := selectIndex(cmd, source.Certs)
+		if err != nil {
+			return err
+		}
+		if curl {
+			fmt.Println(curlPins(certs))
+			return nil
+		}
+		for i, c := range certs {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := writePin(os.Stdout, c, first+i, len(source.Certs)); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// writePin writes the pin of the certificate at index of total, as HPKP
+// and as curl write it.
+func writePin(w io.Writer, c *certificate.Info, index, total int) error {
+	pin := certificate.SPKIPin(c.Certificate)
+	_, err := fmt.Fprintf(w, "Certificate %d of %d: %s\n  pin-sha256=%q\n  sha256//%s\n",
+		index+1, total, orNone(c.Certificate.Subject.CommonName), pin, pin)
+	return err
+}
+
+// curlPins joins the pins of certs into one --pinnedpubkey argument.
+func curlPins(certs []*certificate.Info) string {
+	pins := make([]string, len(certs))
+	for i, c := range certs {
+		pins[i] = "sha256//" + certificate.SPKIPin(c.Certificate)
+	}
+	return strings.Join(pins, ";")
+}
+
+func init() {
+	pinCmd.Flags().Bool("curl", false, "Print only the pins, joined by ';' for curl --pinnedpubkey")
+	pinCmd.Flags().Int("index", 0, "Print only the certificate at this index, counting from 0")
+	_ = pinCmd.RegisterFlagCompletionFunc("index", completeIndex)
+	RootCmd.AddCommand(pinCmd)
+}
@@ -0,0 +1,562 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/internal/version"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// reportFormats are the formats report writes.
+var reportFormats = []string{"markdown", "html"}
+
+// reportExtensions names the format a file extension stands for, when --to
+// is not given.
+var reportExtensions = map[string]string{
+	".md": "markdown", ".markdown": "markdown",
+	".html": "html", ".htm": "html",
+}
+
+// reportCmd writes a report on a chain for audits and change tickets, and
+// groups the summary reports over a whole bundle.
+var reportCmd = &cobra.Command{
+	Use:   "report [file | host:port]",
+	Short: "Write a Markdown or HTML report on a chain, or summarize a bundle",
+	Long: `Write a report on the certificates of the input, to attach to an audit or a
+change ticket: a summary table, the verdict on the chain, how it was presented
+and how its key usages agree, and for each certificate its details, every
+check the Validation tab runs and, for end-entity certificates, the findings
+of a lint profile.
+
+  y509 report chain.pem -o report.html
+  y509 report example.com:443 --to markdown > report.md
+
+--to is markdown or html, taken from the extension of -o when not given: .md,
+.markdown, .html or .htm. Without -o the report goes to stdout, as Markdown
+unless --to says otherwise. The HTML is one file, its styles inline.
+
+The chain is verified as validate verifies it, with the same --roots,
+--no-system-roots, --host and --at; --profile picks the lint profile, as lint
+takes it, so a configuration profile is picked with Y509_PROFILE. The report
+is written whatever it finds: validate and lint are the commands to gate on.
+A file already there is left alone unless --force is given.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		to, err := reportFormat(cmd, outFile)
+		if err != nil {
+			return err
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		if err := refuseOverwrite(outFile, force); err != nil {
+			return err
+		}
+		profile, err := lintProfileFlag(cmd)
+		if err != nil {
+			return err
+		}
+		opts, err := verifyOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+		if opts.DNSName == "" {
+			opts.DNSName = source.Host
+		}
+		opts.ExpiryWarningDays = expiryWarningDays()
+
+		var buf bytes.Buffer
+		if err := writeReport(&buf, to, newReport(source, opts, profile)); err != nil {
+			return err
+		}
+		if outFile == "" {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		if err := writeFile(outFile, buf.Bytes(), 0o644); err != nil {
+			logger.Log.Error("Failed to write the report", zap.Error(err))
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote a report on %d certificate(s), as %s, to %s\n", len(source.Certs), to, outFile)
+		return nil
+	},
+}
+
+// reportAlgosCmd tallies algorithms and checks them against a policy.
+var reportAlgosCmd = &cobra.Command{
+	Use:   "algos [file | host:port]",
+	Short: "Summarize signature algorithms and key types against a policy",
+	Long: `Summarize the signature algorithms, key types and key sizes across a bundle,
+and flag every certificate outside an algorithm policy.
+
+Without --policy the built-in policy flags MD5 and SHA-1 signatures, RSA keys
+under 2048 bits, and RSA-2048 certificates valid past 2030. A policy file is
+YAML:
+
+  rules:
+    - match: "RSA-2048"     # glob against the key type or signature algorithm
+      after: 2030-01-01     # only certificates still valid after this date
+      reason: migrate to RSA-3072 or ECDSA
+    - match: "RSA-*"
+      min_bits: 3072        # only keys smaller than this
+    - match: "*SHA1*"
+
+Exits non-zero when anything is outside the policy.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+
+		policyFile, err := cmd.Flags().GetString("policy")
+		if err != nil {
+			return err
+		}
+		var policy *certificate.AlgorithmPolicy
+		if policyFile != "" {
+			if policy, err = certificate.LoadAlgorithmPolicy(policyFile); err != nil {
+				return err
+			}
+		}
+
+		certs := make([]*x509.Certificate, len(source.Certs))
+		for i, c := range source.Certs {
+			certs[i] = c.Certificate
+		}
+		report := certificate.ReportAlgorithms(certs, policy)
+		fmt.Println(certificate.FormatAlgorithmReport(report))
+
+		if len(report.Findings) > 0 {
+			return fmt.Errorf("%d certificate algorithm(s) outside the policy", len(report.Findings))
+		}
+		return nil
+	},
+}
+
+// reportFormat is the format to write: --to, or else the one the output
+// file's extension stands for, or Markdown on stdout.
+func reportFormat(cmd *cobra.Command, outFile string) (string, error) {
+	to, err := cmd.Flags().GetString("to")
+	if err != nil {
+		return "", err
+	}
+	to = strings.ToLower(to)
+	if to == "" {
+		if outFile == "" {
+			return "markdown", nil
+		}
+		to = reportExtensions[strings.ToLower(filepath.Ext(outFile))]
+		if to == "" {
+			return "", fmt.Errorf("give --to (one of %s): it cannot be told from the output file name", strings.Join(reportFormats, ", "))
+		}
+	}
+	if to == "md" {
+		to = "markdown"
+	}
+	if !slices.Contains(reportFormats, to) {
+		return "", fmt.Errorf("unknown format %q (one of %s)", to, strings.Join(reportFormats, ", "))
+	}
+	return to, nil
+}
+
+// reportData is what the report templates are given.
+type reportData struct {
+	// Source names the input: its file or server, or stdin.
+	Source    string
+	Version   string
+	Generated time.Time
+	// AsOf is the time the chain was verified for, nil for now.
+	AsOf    *time.Time
+	Verdict reportVerdict
+	// Presentation and Usage are the findings on how the chain was sent
+	// and on its key usages.
+	Presentation []reportFinding
+	Usage        []reportFinding
+	// Profile is the lint profile's name and Description what it stands
+	// for; Linted counts the end-entity certificates checked against it.
+	Profile, ProfileDescription string
+	Linted, LintFailed          int
+	Expired, Expiring           int
+	Certificates                []reportCertificate
+}
+
+// reportVerdict is the verdict on the chain as a whole.
+type reportVerdict struct {
+	// Level is trusted, self-anchored or broken.
+	Level      string
+	Anchor     string
+	Error      string
+	Violations []string
+	Warnings   []string
+}
+
+// reportFinding is a finding about one certificate, by its common name.
+type reportFinding struct {
+	Subject, Detail string
+}
+
+// reportCertificate is one certificate of the report, in input order.
+type reportCertificate struct {
+	Index              int
+	CommonName         string
+	Subject, Issuer    string
+	Serial             string
+	NotBefore          time.Time
+	NotAfter           time.Time
+	DaysLeft           int
+	Status             string
+	IsCA               bool
+	Key                string
+	SignatureAlgorithm string
+	SANs               []string
+	KeyUsage           []string
+	ExtKeyUsage        []string
+	SHA256             string
+	Checks             []certificate.Check
+	// Linted is false for CA certificates, which the profiles do not cover.
+	Linted bool
+	Lint   []certificate.LintFinding
+}
+
+// newReport runs everything the report shows over the input: the chain
+// verified and analysed as validate does, each certificate checked, and
+// each end-entity certificate linted.
+func newReport(source *input, opts certificate.VerifyOptions, profile *certificate.LintProfile) *reportData {
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	certs := make([]*x509.Certificate, len(source.Certs))
+	for i, c := range source.Certs {
+		certs[i] = c.Certificate
+	}
+	certificate.ValidateChainLinks(source.Certs)
+
+	data := &reportData{
+		Source:             reportSource(source),
+		Version:            version.GetVersion(),
+		Generated:          time.Now().UTC(),
+		AsOf:               asOf(opts),
+		Profile:            profile.Name,
+		ProfileDescription: profile.Description,
+	}
+
+	// A pile that does not sort into one chain is still worth a report; it
+	// is verified as it came, and says why.
+	analysis := certificate.AnalyzeChain(certs)
+	chain := analysis.Sorted
+	if analysis.SortErr != nil {
+		chain = certs
+	}
+	for _, finding := range analysis.Findings {
+		data.Presentation = append(data.Presentation, reportFinding{Subject: finding.Subject, Detail: finding.Detail})
+	}
+	for _, finding := range certificate.AnalyzeUsage(chain) {
+		data.Usage = append(data.Usage, reportFinding{Subject: finding.Subject, Detail: finding.Detail})
+	}
+	result, err := certificate.VerifyChain(chain, opts)
+	switch {
+	case err != nil:
+		data.Verdict = reportVerdict{Level: certificate.TrustBroken.String(), Error: err.Error()}
+	default:
+		data.Verdict = reportVerdict{
+			Level:    result.Level.String(),
+			Anchor:   result.Anchor,
+			Error:    errorText(result.Err),
+			Warnings: result.Warnings,
+		}
+		for _, violation := range result.Violations {
+			data.Verdict.Violations = append(data.Verdict.Violations, violation.Error())
+		}
+	}
+	if analysis.SortErr != nil {
+		data.Verdict.Warnings = append(data.Verdict.Warnings, "the input does not sort into one chain: "+analysis.SortErr.Error())
+	}
+
+	warnDays := opts.ExpiryWarningDays
+	for i, c := range source.Certs {
+		cert := c.Certificate
+		keyType, _ := certificate.KeyType(cert)
+		rc := reportCertificate{
+			Index:              i,
+			CommonName:         cert.Subject.CommonName,
+			Subject:            cert.Subject.String(),
+			Issuer:             cert.Issuer.String(),
+			Serial:             cert.SerialNumber.Text(16),
+			NotBefore:          cert.NotBefore.UTC(),
+			NotAfter:           cert.NotAfter.UTC(),
+			DaysLeft:           int(cert.NotAfter.Sub(now).Hours() / 24),
+			Status:             statusOf(c, warnDays),
+			IsCA:               cert.IsCA,
+			Key:                keyType,
+			SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+			SANs:               sanNames(c),
+			KeyUsage:           splitList(certificate.FormatKeyUsage(cert)),
+			ExtKeyUsage:        splitList(certificate.FormatExtKeyUsage(cert)),
+			SHA256:             certificate.FormatFingerprint(cert),
+			Checks:             certificate.CheckCertificate(cert, certs, opts),
+		}
+		if !cert.IsCA {
+			rc.Linted = true
+			rc.Lint = certificate.Lint(cert, profile)
+			data.Linted++
+			if len(rc.Lint) > 0 {
+				data.LintFailed++
+			}
+		}
+		switch rc.Status {
+		case "expired":
+			data.Expired++
+		case "expiring":
+			data.Expiring++
+		}
+		data.Certificates = append(data.Certificates, rc)
+	}
+	return data
+}
+
+// reportSource names the input for the report's title.
+func reportSource(source *input) string {
+	if source.Host != "" {
+		return source.Host
+	}
+	if len(source.Certs) > 0 && source.Certs[0].Source != "" {
+		return source.Certs[0].Source
+	}
+	return "stdin"
+}
+
+// reportFuncs are the functions both report templates call.
+var reportFuncs = map[string]any{
+	"date": certificate.FormatTime,
+	"join": strings.Join,
+	// class makes a status a CSS class: "issuer missing" is issuer-missing.
+	"class": func(status any) string { return strings.ReplaceAll(fmt.Sprint(status), " ", "-") },
+	"orNone": func(s string) string {
+		if s == "" {
+			return "(none)"
+		}
+		return s
+	},
+	// md escapes what Markdown would take for markup, the pipes that end a
+	// table cell among it, and keeps the text on one line.
+	"md": func(s string) string {
+		var b strings.Builder
+		for _, r := range strings.Join(strings.Fields(s), " ") {
+			if strings.ContainsRune("\\`*_[]<>|#", r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	},
+}
+
+// writeReport renders the report in format, markdown or html.
+func writeReport(w io.Writer, format string, data *reportData) error {
+	if format == "html" {
+		tmpl, err := htmltemplate.New("report").Funcs(reportFuncs).Parse(reportHTML)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, data)
+	}
+	tmpl, err := template.New("report").Funcs(reportFuncs).Parse(reportMarkdown)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// reportMarkdown lays the report out as GitHub-flavoured Markdown.
+const reportMarkdown = `# Certificate report: {{md .Source}}
+
+Generated {{date .Generated}} by y509 {{.Version}}{{with .AsOf}}, verified as of {{date .}}{{end}}.
+
+## Summary
+
+The chain is **{{.Verdict.Level}}**{{with .Verdict.Anchor}}, anchored at {{md .}}{{end}}.
+{{len .Certificates}} certificate(s): {{.Expired}} expired, {{.Expiring}} expiring.
+{{if .Linted}}{{.LintFailed}} of {{.Linted}} end-entity certificate(s) fall short of the {{md .Profile}} profile ({{md .ProfileDescription}}).{{else}}No end-entity certificates to lint.{{end}}
+
+| # | Common name | Issuer | Not after | Days left | Status | Key | Signature |
+| ---: | :--- | :--- | :--- | ---: | :--- | :--- | :--- |
+{{range .Certificates}}| {{.Index}} | {{md (orNone .CommonName)}} | {{md .Issuer}} | {{date .NotAfter}} | {{.DaysLeft}} | {{.Status}} | {{.Key}} | {{.SignatureAlgorithm}} |
+{{end}}
+## Chain validation
+
+**{{.Verdict.Level}}**{{with .Verdict.Anchor}}; trust anchor: {{md .}}{{end}}
+{{with .Verdict.Error}}
+Error: {{md .}}
+{{end}}{{with .Verdict.Violations}}
+Basic constraints violated:
+
+{{range .}}- {{md .}}
+{{end}}{{end}}{{with .Verdict.Warnings}}
+Warnings:
+
+{{range .}}- {{md .}}
+{{end}}{{end}}{{with .Presentation}}
+Chain as presented:
+
+{{range .}}- {{md .Subject}}: {{md .Detail}}
+{{end}}{{end}}{{with .Usage}}
+Key usage:
+
+{{range .}}- {{md .Subject}}: {{md .Detail}}
+{{end}}{{end}}
+## Certificates
+{{range .Certificates}}
+### {{.Index}}. {{md (orNone .CommonName)}}
+
+| Field | Value |
+| :--- | :--- |
+| Subject | {{md .Subject}} |
+| Issuer | {{md .Issuer}} |
+| Serial | {{.Serial}} |
+| Not before | {{date .NotBefore}} |
+| Not after | {{date .NotAfter}} ({{.DaysLeft}} days left) |
+| Status | {{.Status}} |
+| CA | {{.IsCA}} |
+| SANs | {{md (orNone (join .SANs ", "))}} |
+| Key | {{.Key}} |
+| Signature | {{.SignatureAlgorithm}} |
+| Key usage | {{md (orNone (join .KeyUsage ", "))}} |
+| Extended key usage | {{md (orNone (join .ExtKeyUsage ", "))}} |
+| SHA-256 | {{.SHA256}} |
+
+| Check | Result | Detail |
+| :--- | :--- | :--- |
+{{range .Checks}}| {{.Name}} | {{.Status}} | {{md .Detail}} |
+{{end}}
+{{if .Linted}}{{if .Lint}}Lint ({{md $.Profile}}):
+
+{{range .Lint}}- {{.Rule}}: {{md .Detail}}
+{{end}}{{else}}Meets the {{md $.Profile}} profile.
+{{end}}{{else}}Not linted: a CA certificate.
+{{end}}{{end}}`
+
+// reportHTML lays the report out as one HTML page, its styles inline.
+const reportHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Certificate report: {{.Source}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 72rem; margin: 2rem auto; padding: 0 1rem; color: #1e1e2e; }
+table { border-collapse: collapse; margin: 1rem 0; width: 100%; }
+th, td { border: 1px solid #ccd0da; padding: 0.3rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #eff1f5; }
+td.num { text-align: right; }
+.mono { font-family: ui-monospace, monospace; word-break: break-all; }
+.trusted, .valid, .pass { color: #40a02b; }
+.self-anchored, .expiring, .warn { color: #df8e1d; }
+.broken, .expired, .fail, .not-yet-valid, .issuer-missing, .bad-signature, .constraint-violation { color: #d20f39; }
+.skipped, .meta { color: #6c6f85; }
+section.cert { page-break-inside: avoid; }
+</style>
+</head>
+<body>
+<h1>Certificate report: {{.Source}}</h1>
+<p class="meta">Generated {{date .Generated}} by y509 {{.Version}}{{with .AsOf}}, verified as of {{date .}}{{end}}.</p>
+
+<h2>Summary</h2>
+<p>The chain is <strong class="{{.Verdict.Level}}">{{.Verdict.Level}}</strong>{{with .Verdict.Anchor}}, anchored at {{.}}{{end}}.
+{{len .Certificates}} certificate(s): {{.Expired}} expired, {{.Expiring}} expiring.
+{{if .Linted}}{{.LintFailed}} of {{.Linted}} end-entity certificate(s) fall short of the {{.Profile}} profile ({{.ProfileDescription}}).{{else}}No end-entity certificates to lint.{{end}}</p>
+<table>
+<tr><th>#</th><th>Common name</th><th>Issuer</th><th>Not after</th><th>Days left</th><th>Status</th><th>Key</th><th>Signature</th></tr>
+{{range .Certificates}}<tr><td class="num">{{.Index}}</td><td><a href="#cert-{{.Index}}">{{orNone .CommonName}}</a></td><td>{{.Issuer}}</td><td>{{date .NotAfter}}</td><td class="num">{{.DaysLeft}}</td><td class="{{class .Status}}">{{.Status}}</td><td>{{.Key}}</td><td>{{.SignatureAlgorithm}}</td></tr>
+{{end}}</table>
+
+<h2>Chain validation</h2>
+<p><strong class="{{.Verdict.Level}}">{{.Verdict.Level}}</strong>{{with .Verdict.Anchor}}; trust anchor: {{.}}{{end}}</p>
+{{with .Verdict.Error}}<p>Error: {{.}}</p>
+{{end}}{{with .Verdict.Violations}}<p>Basic constraints violated:</p>
+<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>
+{{end}}{{with .Verdict.Warnings}}<p>Warnings:</p>
+<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>
+{{end}}{{with .Presentation}}<p>Chain as presented:</p>
+<ul>{{range .}}<li>{{.Subject}}: {{.Detail}}</li>{{end}}</ul>
+{{end}}{{with .Usage}}<p>Key usage:</p>
+<ul>{{range .}}<li>{{.Subject}}: {{.Detail}}</li>{{end}}</ul>
+{{end}}
+<h2>Certificates</h2>
+{{range .Certificates}}<section class="cert" id="cert-{{.Index}}">
+<h3>{{.Index}}. {{orNone .CommonName}}</h3>
+<table>
+<tr><th>Subject</th><td>{{.Subject}}</td></tr>
+<tr><th>Issuer</th><td>{{.Issuer}}</td></tr>
+<tr><th>Serial</th><td class="mono">{{.Serial}}</td></tr>
+<tr><th>Not before</th><td>{{date .NotBefore}}</td></tr>
+<tr><th>Not after</th><td>{{date .NotAfter}} ({{.DaysLeft}} days left)</td></tr>
+<tr><th>Status</th><td class="{{class .Status}}">{{.Status}}</td></tr>
+<tr><th>CA</th><td>{{.IsCA}}</td></tr>
+<tr><th>SANs</th><td>{{orNone (join .SANs ", ")}}</td></tr>
+<tr><th>Key</th><td>{{.Key}}</td></tr>
+<tr><th>Signature</th><td>{{.SignatureAlgorithm}}</td></tr>
+<tr><th>Key usage</th><td>{{orNone (join .KeyUsage ", ")}}</td></tr>
+<tr><th>Extended key usage</th><td>{{orNone (join .ExtKeyUsage ", ")}}</td></tr>
+<tr><th>SHA-256</th><td class="mono">{{.SHA256}}</td></tr>
+</table>
+<table>
+<tr><th>Check</th><th>Result</th><th>Detail</th></tr>
+{{range .Checks}}<tr><td>{{.Name}}</td><td class="{{class .Status}}">{{.Status}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{if .Linted}}{{if .Lint}}<p>Lint ({{$.Profile}}):</p>
+<ul>{{range .Lint}}<li class="fail">{{.Rule}}: {{.Detail}}</li>{{end}}</ul>
+{{else}}<p class="pass">Meets the {{$.Profile}} profile.</p>
+{{end}}{{else}}<p class="skipped">Not linted: a CA certificate.</p>
+{{end}}</section>
+{{end}}</body>
+</html>
+`
+
+func init() {
+	reportCmd.Flags().StringP("output", "o", "", "File to write (default: stdout)")
+	reportCmd.Flags().String("to", "", "Format: "+strings.Join(reportFormats, ", ")+" (default: from the -o extension, else markdown)")
+	reportCmd.Flags().BoolP("force", "f", false, "Overwrite the file if it already exists")
+	reportCmd.Flags().String("profile", "cabf-br", "Lint profile name ("+strings.Join(certificate.LintProfiles(), ", ")+") or YAML file")
+	reportCmd.Flags().String("roots", "", "PEM file of additional trust anchors")
+	reportCmd.Flags().Bool("no-system-roots", false, "Do not trust the system store; use only --roots")
+	reportCmd.Flags().String("host", "", "Also check that the leaf is valid for this hostname")
+	reportCmd.Flags().String("at", "", "Verify as of this time instead of now (YYYY-MM-DD or RFC 3339)")
+	_ = reportCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions(reportFormats, cobra.ShellCompDirectiveNoFileComp))
+	_ = reportCmd.RegisterFlagCompletionFunc("profile", completeLintProfile)
+	_ = reportCmd.MarkFlagFilename("roots", certFileExtensions...)
+	_ = reportCmd.MarkFlagFilename("output", "md", "markdown", "html", "htm")
+
+	reportAlgosCmd.Flags().String("policy", "", "YAML algorithm policy (default: the built-in policy)")
+	_ = reportAlgosCmd.MarkFlagFilename("policy", yamlFileExtensions...)
+	reportCmd.AddCommand(reportAlgosCmd)
+	RootCmd.AddCommand(reportCmd)
+}
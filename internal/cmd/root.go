@@ -2,25 +2,31 @@
 package cmd
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/colorprofile"
 	"github.com/kanywst/y509/internal/config"
 	"github.com/kanywst/y509/internal/logger"
 	"github.com/kanywst/y509/internal/model"
 	"github.com/kanywst/y509/internal/version"
 	"github.com/kanywst/y509/pkg/certificate"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/zap"
 )
 
 var (
 	// RootCmd represents the base command when called without any subcommands
 	RootCmd = &cobra.Command{
-		Use:   "y509 [file | host:port]",
+		Use:   "y509 [file | host:port]...",
 		Short: "A TUI for X.509 certificate chains",
 		Long: `y509 opens a certificate chain in a terminal UI.
 
@@ -32,28 +38,80 @@ The chain can come from a file, from stdin, or from a live server:
   openssl s_client -connect example.com:443 -showcerts | y509
 
 An argument that names an existing file is always read as a file. Otherwise it
-is treated as an address; pass --connect to force that.`,
-		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+is treated as an address; pass --connect to force that.
+
+Several files (or servers) can be opened at once. Each gets its own tab above
+the list, next to an "All" tab that shows them together:
+
+  y509 old-bundle.pem new-bundle.pem
+
+--cmd runs a command of the ':' command line as the TUI opens, and may be
+given again for more, in order. With --batch there is no TUI: the commands
+run against the chain and what each shows is printed, so that anything the
+command line does can go in a script:
+
+  y509 chain.pem --cmd "filter expired" --cmd "select all" --cmd "export pem out/" --batch`,
+		ValidArgsFunction: completeRootArgs,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			// Initialize logger
 			logFile, err := cmd.Flags().GetString("log-file")
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error getting log-file flag: %v\n", err)
 				os.Exit(1)
 			}
+			logLevel, err := cmd.Flags().GetString("log-level")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error getting log-level flag: %v\n", err)
+				os.Exit(1)
+			}
 			debug, err := cmd.Flags().GetBool("debug")
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error getting debug flag: %v\n", err)
 				os.Exit(1)
 			}
-			if err := logger.Init(logFile, debug); err != nil {
+			if debug {
+				logLevel = "debug"
+			}
+			if err := logger.Init(logFile, logLevel); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 				os.Exit(1)
 			}
 			certificate.SetLogger(logger.Log)
+			logCommandStarted(cmd, args)
+
+			// lint and report have a --profile of their own, for the lint
+			// profile, which hides this one; Y509_PROFILE still picks.
+			profile, err := cmd.Root().PersistentFlags().GetString("profile")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error getting profile flag: %v\n", err)
+				os.Exit(1)
+			}
+			if profile != "" {
+				config.SetProfile(profile)
+			}
+			cfg, err := config.LoadConfig()
+			if errors.Is(err, config.ErrNoProfile) {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if err != nil {
+				// The defaults stand in for what could not be read.
+				logger.Log.Error("Failed to load configuration", zap.Error(err))
+			}
+			loadedConfig = cfg
+			certificate.SetTimeFormat(cfg.TimeFormat)
+			if err := applyConfigFlags(cmd, cfg); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
 		},
 	}
 )
 
+// loadedConfig is the configuration PersistentPreRun loaded, its flags
+// already laid over the command's, for the command to run with.
+var loadedConfig *config.Config
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -71,17 +129,98 @@ func Execute() {
 	RootCmd.SilenceErrors = true
 	RootCmd.SilenceUsage = true
 
-	if err := RootCmd.Execute(); err != nil {
+	start := time.Now()
+	cmd, err := RootCmd.ExecuteC()
+	code := 0
+	if err != nil {
+		code = 1
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.code
+		}
+	}
+	logger.Log.Info("command finished",
+		zap.String("command", cmd.CommandPath()),
+		zap.Int("exitCode", code),
+		zap.Duration("elapsed", time.Since(start)),
+		zap.Error(err))
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(code)
+	}
+}
+
+// logCommandStarted records the command being run, its arguments, and the
+// names of the flags given: not their values, which may be passwords.
+func logCommandStarted(cmd *cobra.Command, args []string) {
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, f.Name)
+	})
+	logger.Log.Info("command started",
+		zap.String("command", cmd.CommandPath()),
+		zap.Strings("args", args),
+		zap.Strings("flags", flags),
+		zap.String("version", version.GetVersion()))
+}
+
+// applyConfigFlags gives the command's flags the defaults the configuration
+// has for them, roots among them, where they were not given.
+func applyConfigFlags(cmd *cobra.Command, cfg *config.Config) error {
+	defaults := maps.Clone(cfg.Flags)
+	if defaults == nil {
+		defaults = make(map[string]any)
 	}
+	if cfg.Roots != "" {
+		for _, name := range []string{"roots", "ca-file"} {
+			if _, ok := defaults[name]; !ok {
+				defaults[name] = cfg.Roots
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(defaults)) {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		values, ok := defaults[name].([]any)
+		if !ok {
+			values = []any{defaults[name]}
+		}
+		for _, value := range values {
+			if err := cmd.Flags().Set(name, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("flags: %s in the configuration: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// exitError is an error that asks for a particular exit status, for commands
+// whose callers are scripts that branch on it. Anything else exits 1.
+type exitError struct {
+	code int
+	err  error
 }
 
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
 func init() {
 	// Add flags
 	RootCmd.PersistentFlags().StringP("input", "i", "", "Input file containing certificates (default: stdin)")
-	RootCmd.PersistentFlags().String("log-file", "", "Path to the log file")
-	RootCmd.PersistentFlags().Bool("debug", false, "Enable debug logging")
+	_ = RootCmd.MarkPersistentFlagFilename("input", certFileExtensions...)
+	RootCmd.PersistentFlags().String("in-format", "auto", "Read the input as "+strings.Join(certificate.InputFormats, ", ")+" rather than detect it")
+	RootCmd.PersistentFlags().String("in-password", "", "Password of a PKCS#12 input")
+	_ = RootCmd.RegisterFlagCompletionFunc("in-format", cobra.FixedCompletions(certificate.InputFormats, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.PersistentFlags().String("log-file", "", "Path to the log file, or stderr (default: ~/.local/state/y509/y509.log, or under $XDG_STATE_HOME)")
+	RootCmd.PersistentFlags().String("log-level", "info", "Log this level and above: "+strings.Join(logger.Levels, ", "))
+	RootCmd.PersistentFlags().Bool("debug", false, "Enable debug logging (--log-level debug)")
+	_ = RootCmd.MarkPersistentFlagFilename("log-file", "log")
+	_ = RootCmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions(logger.Levels, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.PersistentFlags().String("profile", "", "Configuration profile to lay over the rest of the file (also Y509_PROFILE)")
+	_ = RootCmd.RegisterFlagCompletionFunc("profile", completeConfigProfile)
 
 	// Persistent, so `validate` and `export` can read from a live server too.
 	RootCmd.PersistentFlags().String("connect", "", "Fetch the chain from a live server (host[:port])")
@@ -90,33 +229,70 @@ func init() {
 		strings.Join(certificate.StartTLSProtocols, ", "))
 	RootCmd.PersistentFlags().Duration("timeout", certificate.DefaultConnectTimeout, "Timeout for a live connection")
 
+	RootCmd.Flags().Bool("check-revocation", false, "Check each certificate with its OCSP responder or CRL")
+	RootCmd.Flags().Bool("no-color", false, "Draw the TUI without colour (also set by NO_COLOR)")
+	RootCmd.Flags().Bool("ascii", false, "Draw the TUI in plain ASCII, without emoji or box drawing")
+	RootCmd.Flags().Bool("no-splash", false, "Start on the certificates, without the splash screen")
+	RootCmd.Flags().String("theme", "", "Theme preset to lay the configured colours over: "+strings.Join(config.PresetNames, ", "))
+	_ = RootCmd.RegisterFlagCompletionFunc("theme", cobra.FixedCompletions(config.PresetNames, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.Flags().Bool("screen-reader", false, "Suit the TUI to a screen reader (implies --ascii, --no-color and --no-splash)")
+	RootCmd.Flags().StringArray("cmd", nil, "Run a ':' command as the TUI opens (repeatable, in order)")
+	RootCmd.Flags().Bool("batch", false, "Run the --cmd commands without the TUI and print what they show")
+
 	// Subcommands register themselves in their own init().
 
-	// Handle arguments
-	RootCmd.Args = func(_ *cobra.Command, args []string) error {
-		if len(args) > 1 {
-			return fmt.Errorf("too many arguments")
-		}
-		return nil
-	}
+	// Any number of files or servers. Left unset, cobra would take the first
+	// for the name of a subcommand and refuse it as unknown.
+	RootCmd.Args = cobra.ArbitraryArgs
+
 	// Set default behavior for no arguments
 	RootCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		// Load configuration
-		cfg, err := config.LoadConfig()
+		cfg := loadedConfig
+		if err := applyTUIFlags(cmd, cfg); err != nil {
+			return err
+		}
+
+		commands, err := cmd.Flags().GetStringArray("cmd")
+		if err != nil {
+			return err
+		}
+		batch, err := cmd.Flags().GetBool("batch")
 		if err != nil {
-			logger.Log.Error("Failed to load configuration", zap.Error(err))
-			// We don't exit here, as we can run with default settings
+			return err
+		}
+		if batch && len(commands) == 0 {
+			return errors.New("--batch runs the commands given with --cmd; give at least one")
 		}
 
-		source, err := loadInput(cmd, args)
+		certs, err := loadSources(cmd, args)
 		if err != nil {
 			logger.Log.Error("Failed to load certificates", zap.Error(err))
 			return err
 		}
 
+		if batch {
+			if err := model.RunBatch(certs, cfg, commands, os.Stdout); err != nil {
+				logger.Log.Error("Batch command failed", zap.Error(err))
+				return err
+			}
+			return nil
+		}
+
 		// Create and run the TUI
-		model := model.NewModel(source.Certs, cfg)
-		p := tea.NewProgram(model)
+		model := model.NewModel(certs, cfg)
+		model.SetStartupCommands(commands)
+		model.SetConfigLoader(func() (*config.Config, error) {
+			cfg, err := config.LoadConfig()
+			if flagErr := applyTUIFlags(cmd, cfg); flagErr != nil {
+				return cfg, flagErr
+			}
+			return cfg, err
+		})
+		var opts []tea.ProgramOption
+		if cfg.NoColor {
+			opts = append(opts, tea.WithColorProfile(colorprofile.ASCII))
+		}
+		p := tea.NewProgram(model, opts...)
 
 		if _, err := p.Run(); err != nil {
 			logger.Log.Error("Failed to run TUI", zap.Error(err))
@@ -127,6 +303,56 @@ func init() {
 	}
 }
 
+// applyTUIFlags lays the flags that change how the TUI looks and what it
+// checks over the configuration, at start and again on each reload.
+func applyTUIFlags(cmd *cobra.Command, cfg *config.Config) error {
+	checkRevocation, err := cmd.Flags().GetBool("check-revocation")
+	if err != nil {
+		return err
+	}
+	if checkRevocation {
+		cfg.CheckRevocation = true
+	}
+	noColor, err := cmd.Flags().GetBool("no-color")
+	if err != nil {
+		return err
+	}
+	if noColor {
+		cfg.NoColor = true
+	}
+	ascii, err := cmd.Flags().GetBool("ascii")
+	if err != nil {
+		return err
+	}
+	if ascii {
+		cfg.ASCII = true
+	}
+	noSplash, err := cmd.Flags().GetBool("no-splash")
+	if err != nil {
+		return err
+	}
+	if noSplash {
+		cfg.NoSplash = true
+	}
+	if cmd.Flags().Changed("theme") {
+		preset, err := cmd.Flags().GetString("theme")
+		if err != nil {
+			return err
+		}
+		if err := cfg.UsePreset(preset); err != nil {
+			return err
+		}
+	}
+	screenReader, err := cmd.Flags().GetBool("screen-reader")
+	if err != nil {
+		return err
+	}
+	if screenReader {
+		cfg.UseScreenReader()
+	}
+	return nil
+}
+
 // input is where a command's certificates came from.
 type input struct {
 	// Certs are the certificates, leaf first. When they came from a server this
@@ -136,6 +362,8 @@ type input struct {
 	// gives validate a hostname to check the leaf against, which is the whole
 	// question when you are looking at a live endpoint.
 	Host string
+	// Address is the host:port that was dialled, empty for a file or stdin.
+	Address string
 }
 
 // loadInput decides where the certificates come from: a live server, a file, or
@@ -157,12 +385,14 @@ func loadInput(cmd *cobra.Command, args []string) (*input, error) {
 		target = args[0]
 	}
 
+	start := time.Now()
 	if explicitConnect || looksLikeHost(target) {
 		result, err := connectFromFlags(cmd, target)
 		if err != nil {
 			return nil, err
 		}
-		return &input{Certs: result.Certificates, Host: result.ServerName}, nil
+		logLoaded(result.Address, len(result.Certificates), start)
+		return &input{Certs: result.Certificates, Host: result.ServerName, Address: result.Address}, nil
 	}
 
 	if target == "" {
@@ -173,13 +403,63 @@ func loadInput(cmd *cobra.Command, args []string) (*input, error) {
 		}
 	}
 
-	certs, err := certificate.LoadCertificates(target)
+	certs, err := loadCertificates(cmd, target)
 	if err != nil {
 		return nil, err
 	}
+	logLoaded(cmp.Or(target, "stdin"), len(certs), start)
 	return &input{Certs: certs}, nil
 }
 
+// logLoaded records how long the certificates of a source took to load.
+func logLoaded(source string, certs int, start time.Time) {
+	logger.Log.Info("certificates loaded",
+		zap.String("source", source),
+		zap.Int("certificates", certs),
+		zap.Duration("elapsed", time.Since(start)))
+}
+
+// loadCertificates loads the certificates of a file, or stdin when filename
+// is empty, in the format --in-format forces and with --in-password. Files
+// beside the input -- roots, issuers -- are read with detection alone.
+func loadCertificates(cmd *cobra.Command, filename string) ([]*certificate.Info, error) {
+	var opts certificate.LoadOptions
+	var err error
+	if opts.Format, err = cmd.Flags().GetString("in-format"); err != nil {
+		return nil, err
+	}
+	if opts.Password, err = cmd.Flags().GetString("in-password"); err != nil {
+		return nil, err
+	}
+	certs, err := certificate.LoadCertificatesWith(filename, opts)
+	if errors.Is(err, certificate.ErrPKCS12Password) && opts.Password == "" {
+		return nil, fmt.Errorf("%w: give its password with --in-password", err)
+	}
+	return certs, err
+}
+
+// loadSources loads each argument as a source of its own, for the TUI to
+// tab between. A single argument, or none, is just loadInput.
+func loadSources(cmd *cobra.Command, args []string) ([]*certificate.Info, error) {
+	if len(args) <= 1 {
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			return nil, err
+		}
+		return source.Certs, nil
+	}
+
+	var certs []*certificate.Info
+	for _, arg := range args {
+		source, err := loadInput(cmd, []string{arg})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", arg, err)
+		}
+		certs = append(certs, source.Certs...)
+	}
+	return certs, nil
+}
+
 // connectFromFlags fetches a chain from a live server.
 func connectFromFlags(cmd *cobra.Command, target string) (*certificate.ConnectResult, error) {
 	var opts certificate.ConnectOptions
@@ -0,0 +1,174 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// splitCmd writes each certificate of a bundle to a file of its own.
+var splitCmd = &cobra.Command{
+	Use:   "split [file | host:port]",
+	Short: "Write each certificate to its own file",
+	Long: `Write each certificate of the input to a file of its own in --out-dir, to
+untangle a bundle of certificates concatenated together.
+
+The files are named as the TUI's export of several certificates names them,
+from the position and the common name (the serial when there is none):
+"01-example.com.pem", "02-Issuing_CA.pem". --name gives a Go template for
+the name instead, seeing the fields of Go's x509.Certificate and:
+
+  .N       the position, counting from 1
+  .Index   the position, counting from 0
+  .Name    the common name, or the serial, fit for a file name
+  .CN      the common name alone, fit for a file name
+  .Serial  the serial number in hex
+  .Ext     the extension --to calls for, pem or der
+
+as in --name '{{.Serial}}.{{.Ext}}' or --name '{{.CN}}-{{date .NotAfter "2006"}}.pem'.
+
+Nothing is written when two certificates would go to the same file, or when
+a file is already there, unless --force is given. The files written are
+printed, one per line.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outDir, err := cmd.Flags().GetString("out-dir")
+		if err != nil {
+			return err
+		}
+		to, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return err
+		}
+		to = strings.ToLower(to)
+		if to != "pem" && to != "der" {
+			return fmt.Errorf("unknown format %q (one of pem, der)", to)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		var tmpl *template.Template
+		if text, err := cmd.Flags().GetString("name"); err != nil {
+			return err
+		} else if text != "" {
+			if tmpl, err = template.New("name").Funcs(templateFuncs).Parse(text); err != nil {
+				return fmt.Errorf("--name: %w", err)
+			}
+		}
+
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+
+		names, err := splitNames(source.Certs, tmpl, to)
+		if err != nil {
+			return err
+		}
+		paths := make([]string, len(names))
+		for i, name := range names {
+			paths[i] = filepath.Join(outDir, name)
+			if err := refuseOverwrite(paths[i], force); err != nil {
+				return err
+			}
+		}
+
+		if err := os.MkdirAll(outDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %v", err)
+		}
+		for i, c := range source.Certs {
+			data, err := certificate.EncodeChain([]*x509.Certificate{c.Certificate}, to)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(paths[i], data, 0o644); err != nil {
+				logger.Log.Error("Failed to write certificate", zap.String("filename", paths[i]), zap.Error(err))
+				return err
+			}
+			fmt.Println(paths[i])
+		}
+		return nil
+	},
+}
+
+// splitName is what a --name template sees of a certificate.
+type splitName struct {
+	*x509.Certificate
+	N      int
+	Index  int
+	Name   string
+	CN     string
+	Serial string
+	Ext    string
+}
+
+// splitNames names a file for each certificate, from the template or else
+// as FileName does. Each name must stay inside the output directory, and
+// no two may be the same.
+func splitNames(certs []*certificate.Info, tmpl *template.Template, ext string) ([]string, error) {
+	names := make([]string, len(certs))
+	seen := make(map[string]int, len(certs))
+	for i, c := range certs {
+		name := certificate.FileName(c.Certificate, i+1, ext)
+		if tmpl != nil {
+			var b strings.Builder
+			data := splitName{
+				Certificate: c.Certificate,
+				N:           i + 1,
+				Index:       i,
+				Name:        splitDisplayName(c.Certificate),
+				CN:          certificate.SafeFileName(c.Certificate.Subject.CommonName),
+				Serial:      c.Certificate.SerialNumber.Text(16),
+				Ext:         ext,
+			}
+			if err := tmpl.Execute(&b, data); err != nil {
+				return nil, fmt.Errorf("--name: %w", err)
+			}
+			name = b.String()
+			if name == "" || !filepath.IsLocal(name) {
+				return nil, fmt.Errorf("--name gives %q for certificate %d, which is not a file name inside the output directory", name, i)
+			}
+		}
+		if j, ok := seen[name]; ok {
+			return nil, fmt.Errorf("certificates %d and %d would both be written to %s; give a --name that tells them apart", j, i, name)
+		}
+		seen[name] = i
+		names[i] = name
+	}
+	return names, nil
+}
+
+// splitDisplayName is the common name, or the serial when there is none,
+// fit for a file name, as FileName puts it after the position.
+func splitDisplayName(cert *x509.Certificate) string {
+	name := cert.Subject.CommonName
+	if name == "" {
+		name = "serial " + cert.SerialNumber.String()
+	}
+	if name = certificate.SafeFileName(name); name == "" {
+		return "certificate"
+	}
+	return name
+}
+
+func init() {
+	splitCmd.Flags().String("out-dir", ".", "Directory to write the files to")
+	splitCmd.Flags().String("name", "", "Go template for each file name, as '{{.Serial}}.{{.Ext}}'")
+	splitCmd.Flags().String("to", "pem", "Format of each file: pem or der")
+	splitCmd.Flags().BoolP("force", "f", false, "Overwrite files that already exist")
+	_ = splitCmd.MarkFlagDirname("out-dir")
+	_ = splitCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions([]string{"pem", "der"}, cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.AddCommand(splitCmd)
+}
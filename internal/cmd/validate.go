@@ -2,8 +2,14 @@
 package cmd
 
 import (
+	"context"
 	"crypto/x509"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/kanywst/y509/internal/logger"
 	"github.com/kanywst/y509/pkg/certificate"
@@ -20,8 +26,41 @@ var validateCmd = &cobra.Command{
 The chain is verified against the system trust store. A chain that links up but
 terminates at a root which is not trusted -- an internal PKI, or a bundle that
 is simply missing its root -- is reported as self-anchored rather than valid,
-and exits non-zero. Pass --roots to supply your own trust anchors.`,
-	Args: cobra.MaximumNArgs(1),
+and exits non-zero. Pass --roots to supply your own trust anchors.
+
+Pass --at to verify at another point in time, e.g. to check whether a chain
+still verifies after an old root expires, without touching the system clock.
+
+When the input can be followed to more than one root -- a cross-signed
+intermediate, say -- every path is listed with its own verdict.
+
+A chain that stops because an intermediate was never sent names the missing
+issuer. Pass --fetch-missing to download it from the AIA URL and verify again.
+
+Pass --build when the input is an unordered pile of certificates rather than
+one chain: a chain is built for every leaf in it, and each is verified in turn.
+The exit status is then that of the worst chain.
+
+Pass --check-revocation to ask the OCSP responder, or failing that the CRL
+distribution point, of each certificate but the root whether it has been
+revoked. A revoked certificate fails the chain however it verified; a status
+that could not be had is only reported. The CAs answer for now, so it does
+not go with --at.
+
+Pass --output json or yaml for the verdict as one object -- the trust level,
+exit status, anchor, error, warnings and the chain's certificates, with the
+paths and presentation findings -- for scripts that want more than the exit
+status.
+
+Exit status:
+  0  trusted
+  1  self-anchored (links up to a root that is not trusted), or any other error
+  2  a certificate in the chain has expired
+  3  a certificate in the chain is not yet valid
+  4  broken: bad signature, incomplete chain, constraint or hostname failure
+  5  a certificate in the chain is revoked (with --check-revocation)`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		source, err := loadInput(cmd, args)
 		if err != nil {
@@ -45,6 +84,34 @@ and exits non-zero. Pass --roots to supply your own trust anchors.`,
 			opts.DNSName = source.Host
 		}
 
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		// Progress goes to stderr when stdout is for a machine.
+		progress := io.Writer(os.Stdout)
+		if format != outputText {
+			progress = os.Stderr
+		}
+
+		build, err := cmd.Flags().GetBool("build")
+		if err != nil {
+			return err
+		}
+		checkRevocation, err := cmd.Flags().GetBool("check-revocation")
+		if err != nil {
+			return err
+		}
+		if checkRevocation && !opts.CurrentTime.IsZero() {
+			return fmt.Errorf("--check-revocation and --at do not go together: a CA says whether a certificate is revoked now, not then")
+		}
+		if build {
+			if checkRevocation {
+				return fmt.Errorf("--check-revocation and --build do not go together")
+			}
+			return validateBuiltChains(inputCerts, opts, format)
+		}
+
 		// Look at the chain as it was presented, before sorting it: sorting is
 		// what destroys the evidence. AnalyzeChain sorts it on the way through,
 		// so take its result rather than sorting a second time.
@@ -61,14 +128,47 @@ and exits non-zero. Pass --roots to supply your own trust anchors.`,
 			return err
 		}
 
-		fmt.Println(certificate.FormatVerifyResult(result))
+		fetchMissing, err := cmd.Flags().GetBool("fetch-missing")
+		if err != nil {
+			return err
+		}
+		if fetchMissing {
+			chain, result, err = fetchMissingIssuers(cmd.Context(), progress, chain, result, opts)
+			if err != nil {
+				return err
+			}
+		}
 
-		// How the chain was presented is a separate question from whether it
-		// verifies, and a chain can be perfectly trusted while still being
-		// mis-served. Report it either way.
-		if presentation := certificate.FormatChainReport(report); presentation != "" {
-			fmt.Println()
-			fmt.Println(presentation)
+		// A cross-signed bundle can be read more than one way, and which way a
+		// client goes decides whether it works. Show every path when there is
+		// a choice.
+		paths, err := certificate.EnumeratePaths(chain, opts)
+		if err != nil {
+			return err
+		}
+		scts, err := sctChecks(cmd, chain)
+		if err != nil {
+			return err
+		}
+		var revocations []revocationCheck
+		if checkRevocation {
+			revocations = checkChainRevocation(cmd.Context(), chain, opts)
+		}
+
+		if format != outputText {
+			out := newValidateJSON(chain, result, paths, report, scts, opts)
+			out.Revocation = newRevocationJSON(revocations)
+			if err := writeOutput(os.Stdout, format, out); err != nil {
+				return err
+			}
+		} else {
+			printValidation(chain, result, paths, report, scts, opts)
+			if len(revocations) > 0 {
+				fmt.Println()
+				if err := writeRevocation(os.Stdout, revocations); err != nil {
+					return err
+				}
+			}
 		}
 
 		logger.Log.Info("Certificate chain validation result",
@@ -76,18 +176,281 @@ and exits non-zero. Pass --roots to supply your own trust anchors.`,
 			zap.String("anchor", result.Anchor),
 			zap.Int("presentationFindings", len(report.Findings)))
 
+		// A revoked certificate is the worst news there is, whatever the
+		// verifier made of the chain.
+		if err := revocationVerdict(revocations); err != nil {
+			return err
+		}
 		// Only a chain that reaches a real trust anchor is a success. A
 		// self-anchored chain gets reported, but a TLS client would not accept
 		// it, so it must not exit 0 and quietly pass CI.
 		if result.Level != certificate.TrustAnchored {
-			return fmt.Errorf("certificate chain is %s", result.Level)
+			return &exitError{code: validateExitCode(result), err: fmt.Errorf("certificate chain is %s", result.Level)}
 		}
 		return nil
 	},
 }
 
+// printValidation prints the verdict on a chain for people: the result, then
+// the paths, presentation findings, key usage warnings and SCTs, whichever
+// have anything to say.
+func printValidation(chain []*x509.Certificate, result *certificate.VerifyResult, paths []certificate.TrustPath,
+	report *certificate.ChainReport, scts []certificate.SCTCheck, opts certificate.VerifyOptions) {
+	if !opts.CurrentTime.IsZero() {
+		fmt.Printf("Verifying as of %s\n\n", certificate.FormatTime(opts.CurrentTime))
+	}
+	fmt.Println(certificate.FormatVerifyResult(result))
+
+	if formatted := certificate.FormatTrustPaths(paths); formatted != "" {
+		fmt.Println()
+		fmt.Println(formatted)
+	}
+
+	// How the chain was presented is a separate question from whether it
+	// verifies, and a chain can be perfectly trusted while still being
+	// mis-served. Report it either way.
+	if presentation := certificate.FormatChainReport(report); presentation != "" {
+		fmt.Println()
+		fmt.Println(presentation)
+	}
+
+	// Key usage problems are warnings, not verdicts: the verifier already
+	// failed the ones a client would refuse, and the rest are worth seeing
+	// before they bite.
+	if usage := certificate.FormatUsageFindings(certificate.AnalyzeUsage(chain)); usage != "" {
+		fmt.Println()
+		fmt.Println(usage)
+	}
+
+	if formatted := certificate.FormatSCTChecks(scts); formatted != "" {
+		fmt.Println()
+		fmt.Println(formatted)
+	}
+}
+
+// Exit statuses of validate, documented in its help.
+const (
+	exitSelfAnchored = 1
+	exitExpired      = 2
+	exitNotYetValid  = 3
+	exitBroken       = 4
+	exitRevoked      = 5
+)
+
+// validateExitCode picks the exit status for a chain that is not trusted.
+func validateExitCode(result *certificate.VerifyResult) int {
+	switch {
+	case result.Level == certificate.TrustSelfAnchored:
+		return exitSelfAnchored
+	case result.Expired != nil:
+		return exitExpired
+	case result.NotYetValid != nil:
+		return exitNotYetValid
+	default:
+		return exitBroken
+	}
+}
+
+// revocationCheck is what a CA said of one certificate of a chain.
+type revocationCheck struct {
+	cert   *x509.Certificate
+	result certificate.RevocationResult
+}
+
+// checkChainRevocation asks about every certificate of chain but a
+// self-signed root, which has no one to revoke it. Each issuer is looked for
+// in the chain and among the extra roots.
+func checkChainRevocation(ctx context.Context, chain []*x509.Certificate, opts certificate.VerifyOptions) []revocationCheck {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pool := append(slices.Clone(chain), opts.ExtraRoots...)
+	var checks []revocationCheck
+	for _, cert := range chain {
+		if certificate.IsSelfSigned(cert) {
+			continue
+		}
+		issuer := certificate.IssuerOf(cert, pool)
+		checks = append(checks, revocationCheck{cert: cert, result: certificate.CheckRevocation(ctx, cert, issuer)})
+	}
+	return checks
+}
+
+// revocationVerdict is the exit for the first revoked certificate of
+// checks, or nil when none is. An unknown status does not fail the chain.
+func revocationVerdict(checks []revocationCheck) error {
+	for _, check := range checks {
+		if check.result.Status == certificate.RevocationRevoked {
+			return &exitError{code: exitRevoked, err: fmt.Errorf("'%s' is revoked", orNone(check.cert.Subject.CommonName))}
+		}
+	}
+	return nil
+}
+
+// writeRevocation writes what the CAs said, a certificate to a line.
+func writeRevocation(w io.Writer, checks []revocationCheck) error {
+	var b strings.Builder
+	b.WriteString("Revocation:\n")
+	for _, check := range checks {
+		mark := map[certificate.RevocationStatus]string{certificate.RevocationGood: "✅", certificate.RevocationRevoked: "❌"}[check.result.Status]
+		if mark == "" {
+			mark = "⚠️"
+		}
+		fmt.Fprintf(&b, "  %s %s: %s\n", mark, orNone(check.cert.Subject.CommonName), check.result.Check().Detail)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// revocationJSON is what a CA said of one certificate, as validate
+// --check-revocation --output gives it.
+type revocationJSON struct {
+	CommonName string `json:"common_name" yaml:"common_name"`
+	Status     string `json:"status" yaml:"status"`
+	Source     string `json:"source" yaml:"source"`
+	RevokedAt  string `json:"revoked_at" yaml:"revoked_at"`
+	Reason     string `json:"reason" yaml:"reason"`
+	Error      string `json:"error" yaml:"error"`
+}
+
+// newRevocationJSON describes checks; an empty list when revocation was
+// not checked.
+func newRevocationJSON(checks []revocationCheck) []revocationJSON {
+	out := []revocationJSON{}
+	for _, check := range checks {
+		r := revocationJSON{
+			CommonName: check.cert.Subject.CommonName,
+			Status:     check.result.Status.String(),
+			Source:     check.result.Source,
+			Error:      errorText(check.result.Err),
+		}
+		if check.result.Status == certificate.RevocationRevoked {
+			r.RevokedAt = rfc3339(check.result.RevokedAt)
+			r.Reason = certificate.RevocationReasonName(check.result.Reason)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// validateBuiltChains builds a chain for every leaf in an unordered pool and
+// verifies each. How the pool was presented says nothing, so only the
+// verdicts are reported.
+func validateBuiltChains(pool []*x509.Certificate, opts certificate.VerifyOptions, format string) error {
+	chains := certificate.BuildChains(pool, opts.CurrentTime)
+
+	if !opts.CurrentTime.IsZero() && format == outputText {
+		fmt.Printf("Verifying as of %s\n\n", certificate.FormatTime(opts.CurrentTime))
+	}
+
+	worst := 0
+	var worstLevel certificate.TrustLevel
+	built := builtChainsJSON{AsOf: asOf(opts), Chains: []verdictJSON{}}
+	for i, chain := range chains {
+		result, err := certificate.VerifyChain(chain, opts)
+		if err != nil {
+			return err
+		}
+		if result.Level != certificate.TrustAnchored {
+			if code := validateExitCode(result); code > worst {
+				worst, worstLevel = code, result.Level
+			}
+		}
+		if format != outputText {
+			built.Chains = append(built.Chains, newVerdictJSON(chain, result))
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Chain %d of %d: %s\n", i+1, len(chains), certificate.FormatChainNames(chain))
+		fmt.Println(certificate.FormatVerifyResult(result))
+	}
+
+	if format != outputText {
+		built.ExitCode = worst
+		if err := writeOutput(os.Stdout, format, built); err != nil {
+			return err
+		}
+	}
+	if worst != 0 {
+		return &exitError{code: worst, err: fmt.Errorf("a built certificate chain is %s", worstLevel)}
+	}
+	return nil
+}
+
+// maxAIAFetches bounds how far --fetch-missing climbs. A real chain needs one
+// fetch, occasionally two; anything more is a loop or a misbehaving CA.
+const maxAIAFetches = 3
+
+// fetchMissingIssuers chases the AIA URL of a chain that is broken because an
+// issuer was never sent, appends what it finds, and verifies again.
+func fetchMissingIssuers(ctx context.Context, w io.Writer, chain []*x509.Certificate, result *certificate.VerifyResult,
+	opts certificate.VerifyOptions) ([]*x509.Certificate, *certificate.VerifyResult, error) {
+	for range maxAIAFetches {
+		missing := result.MissingIssuer
+		if missing == nil || len(missing.URLs) == 0 {
+			break
+		}
+
+		var fetched []*x509.Certificate
+		var fetchErr error
+		for _, url := range missing.URLs {
+			if fetched, fetchErr = certificate.FetchIssuer(ctx, url); fetchErr == nil {
+				if _, err := fmt.Fprintf(w, "Fetched issuer '%s' from %s\n", missing.Issuer, url); err != nil {
+					return nil, nil, err
+				}
+				break
+			}
+			logger.Log.Warn("AIA fetch failed", zap.String("url", url), zap.Error(fetchErr))
+		}
+		if fetchErr != nil {
+			if _, err := fmt.Fprintf(w, "Could not fetch issuer '%s': %v\n", missing.Issuer, fetchErr); err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+
+		chain = append(chain, fetched...)
+		var err error
+		if result, err = certificate.VerifyChain(chain, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+	return chain, result, nil
+}
+
+// sctChecks decodes the leaf's embedded SCTs and, given --ct-logs, verifies
+// them. Like key usage, this is reported rather than judged: SCTs that
+// cannot be decoded are logged and left out.
+func sctChecks(cmd *cobra.Command, chain []*x509.Certificate) ([]certificate.SCTCheck, error) {
+	logListFile, err := cmd.Flags().GetString("ct-logs")
+	if err != nil {
+		return nil, err
+	}
+	var logs certificate.CTLogList
+	if logListFile != "" {
+		if logs, err = certificate.LoadCTLogList(logListFile); err != nil {
+			return nil, err
+		}
+	}
+
+	checks, err := certificate.CheckSCTs(chain[0], chain, logs)
+	if err != nil {
+		logger.Log.Warn("Failed to decode SCTs", zap.Error(err))
+		return nil, nil
+	}
+	return checks, nil
+}
+
 // verifyOptionsFromFlags builds the verification options from the trust flags.
 func verifyOptionsFromFlags(cmd *cobra.Command) (certificate.VerifyOptions, error) {
+	return verifyOptionsFromNamedFlags(cmd, "host", "roots")
+}
+
+// verifyOptionsFromNamedFlags builds the verification options from the trust
+// flags, the hostname and roots flags going by the names given.
+func verifyOptionsFromNamedFlags(cmd *cobra.Command, hostFlag, rootsFlag string) (certificate.VerifyOptions, error) {
 	var opts certificate.VerifyOptions
 
 	skipSystem, err := cmd.Flags().GetBool("no-system-roots")
@@ -96,13 +459,13 @@ func verifyOptionsFromFlags(cmd *cobra.Command) (certificate.VerifyOptions, erro
 	}
 	opts.SkipSystemRoots = skipSystem
 
-	hostname, err := cmd.Flags().GetString("host")
+	hostname, err := cmd.Flags().GetString(hostFlag)
 	if err != nil {
 		return opts, err
 	}
 	opts.DNSName = hostname
 
-	rootsFile, err := cmd.Flags().GetString("roots")
+	rootsFile, err := cmd.Flags().GetString(rootsFlag)
 	if err != nil {
 		return opts, err
 	}
@@ -116,16 +479,172 @@ func verifyOptionsFromFlags(cmd *cobra.Command) (certificate.VerifyOptions, erro
 		}
 	}
 
+	at, err := cmd.Flags().GetString("at")
+	if err != nil {
+		return opts, err
+	}
+	if at != "" {
+		if opts.CurrentTime, err = certificate.ParseVerifyTime(at); err != nil {
+			return opts, fmt.Errorf("--at: %w", err)
+		}
+	}
+
 	if opts.SkipSystemRoots && len(opts.ExtraRoots) == 0 {
-		return opts, fmt.Errorf("--no-system-roots leaves no trust anchors; pass --roots as well")
+		return opts, fmt.Errorf("--no-system-roots leaves no trust anchors; pass --%s as well", rootsFlag)
 	}
 
 	return opts, nil
 }
 
+// verdictJSON is the verdict on one chain, as validate --output gives it. Expired and NotYetValid name the certificate concerned by its common
+// name; an empty string, like a null missing_issuer, means there is none.
+type verdictJSON struct {
+	Level         string             `json:"level" yaml:"level"`
+	ExitCode      int                `json:"exit_code" yaml:"exit_code"`
+	Anchor        string             `json:"anchor" yaml:"anchor"`
+	Error         string             `json:"error" yaml:"error"`
+	Violations    []string           `json:"violations" yaml:"violations"`
+	MissingIssuer *missingIssuerJSON `json:"missing_issuer" yaml:"missing_issuer"`
+	Expired       string             `json:"expired" yaml:"expired"`
+	NotYetValid   string             `json:"not_yet_valid" yaml:"not_yet_valid"`
+	Warnings      []string           `json:"warnings" yaml:"warnings"`
+	Chain         []certificateJSON  `json:"chain" yaml:"chain"`
+}
+
+// missingIssuerJSON is the issuer a broken chain stops short of.
+type missingIssuerJSON struct {
+	Issuer string   `json:"issuer" yaml:"issuer"`
+	URLs   []string `json:"urls" yaml:"urls"`
+}
+
+// validateJSON is what validate --output prints: the verdict, with
+// the time it was reached for (null for now) and the rest of the text
+// report.
+type validateJSON struct {
+	AsOf         *time.Time `json:"as_of" yaml:"as_of"`
+	verdictJSON  `yaml:",inline"`
+	Paths        []pathJSON       `json:"paths" yaml:"paths"`
+	Presentation []findingJSON    `json:"presentation" yaml:"presentation"`
+	Usage        []findingJSON    `json:"usage" yaml:"usage"`
+	SCTs         []string         `json:"scts" yaml:"scts"`
+	Revocation   []revocationJSON `json:"revocation" yaml:"revocation"`
+}
+
+// builtChainsJSON is what validate --build --output prints: a verdict
+// for each chain built, and the exit status of the worst.
+type builtChainsJSON struct {
+	AsOf     *time.Time    `json:"as_of" yaml:"as_of"`
+	ExitCode int           `json:"exit_code" yaml:"exit_code"`
+	Chains   []verdictJSON `json:"chains" yaml:"chains"`
+}
+
+// pathJSON is one path to a root, named leaf first.
+type pathJSON struct {
+	Level string   `json:"level" yaml:"level"`
+	Error string   `json:"error" yaml:"error"`
+	Chain []string `json:"chain" yaml:"chain"`
+}
+
+// findingJSON is a presentation or key usage finding.
+type findingJSON struct {
+	Problem string `json:"problem,omitempty" yaml:"problem,omitempty"`
+	Subject string `json:"subject" yaml:"subject"`
+	Detail  string `json:"detail" yaml:"detail"`
+}
+
+// newVerdictJSON describes the verdict on chain.
+func newVerdictJSON(chain []*x509.Certificate, result *certificate.VerifyResult) verdictJSON {
+	out := verdictJSON{
+		Level:      result.Level.String(),
+		Anchor:     result.Anchor,
+		Error:      errorText(result.Err),
+		Violations: []string{},
+		Warnings:   nonNil(result.Warnings),
+	}
+	if result.Level != certificate.TrustAnchored {
+		out.ExitCode = validateExitCode(result)
+	}
+	for _, violation := range result.Violations {
+		out.Violations = append(out.Violations, violation.Error())
+	}
+	if missing := result.MissingIssuer; missing != nil {
+		out.MissingIssuer = &missingIssuerJSON{Issuer: missing.Issuer, URLs: nonNil(missing.URLs)}
+	}
+	if result.Expired != nil {
+		out.Expired = result.Expired.Subject.CommonName
+	}
+	if result.NotYetValid != nil {
+		out.NotYetValid = result.NotYetValid.Subject.CommonName
+	}
+
+	infos := make([]*certificate.Info, len(chain))
+	for i, cert := range chain {
+		infos[i] = &certificate.Info{Certificate: cert, Index: i}
+	}
+	certificate.ValidateChainLinks(infos)
+	out.Chain = certificatesJSON(infos, expiryWarningDays())
+	return out
+}
+
+// newValidateJSON describes everything validate reports on a chain.
+func newValidateJSON(chain []*x509.Certificate, result *certificate.VerifyResult, paths []certificate.TrustPath,
+	report *certificate.ChainReport, scts []certificate.SCTCheck, opts certificate.VerifyOptions) validateJSON {
+	out := validateJSON{
+		AsOf:         asOf(opts),
+		verdictJSON:  newVerdictJSON(chain, result),
+		Paths:        []pathJSON{},
+		Presentation: []findingJSON{},
+		Usage:        []findingJSON{},
+		SCTs:         []string{},
+		Revocation:   []revocationJSON{},
+	}
+	for _, path := range paths {
+		names := make([]string, len(path.Certificates))
+		for i, cert := range path.Certificates {
+			names[i] = cert.Subject.CommonName
+		}
+		out.Paths = append(out.Paths, pathJSON{Level: path.Level.String(), Error: errorText(path.Err), Chain: names})
+	}
+	for _, finding := range report.Findings {
+		out.Presentation = append(out.Presentation, findingJSON{Problem: finding.Problem.String(), Subject: finding.Subject, Detail: finding.Detail})
+	}
+	for _, finding := range certificate.AnalyzeUsage(chain) {
+		out.Usage = append(out.Usage, findingJSON{Subject: finding.Subject, Detail: finding.Detail})
+	}
+	for _, check := range scts {
+		out.SCTs = append(out.SCTs, certificate.FormatSCT(check))
+	}
+	return out
+}
+
+// asOf is the time a verdict was reached for, nil when it was now.
+func asOf(opts certificate.VerifyOptions) *time.Time {
+	if opts.CurrentTime.IsZero() {
+		return nil
+	}
+	at := opts.CurrentTime.UTC()
+	return &at
+}
+
+// errorText is the text of err, empty for nil.
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
 func init() {
 	validateCmd.Flags().String("roots", "", "PEM file of additional trust anchors")
 	validateCmd.Flags().Bool("no-system-roots", false, "Do not trust the system store; use only --roots")
 	validateCmd.Flags().String("host", "", "Also check that the leaf is valid for this hostname")
+	validateCmd.Flags().String("at", "", "Verify as of this time instead of now (YYYY-MM-DD or RFC 3339)")
+	validateCmd.Flags().String("ct-logs", "", "CT log list (v3 JSON) to verify embedded SCTs against")
+	validateCmd.Flags().Bool("fetch-missing", false, "Fetch a missing issuer from its AIA URL and verify again")
+	validateCmd.Flags().Bool("build", false, "Treat the input as an unordered pool and verify a chain built for each leaf")
+	validateCmd.Flags().Bool("check-revocation", false, "Ask each certificate's OCSP responder or CRL whether it is revoked")
+	addOutputFlag(validateCmd)
+	_ = validateCmd.MarkFlagFilename("roots", certFileExtensions...)
+	_ = validateCmd.MarkFlagFilename("ct-logs", "json")
 	RootCmd.AddCommand(validateCmd)
 }
@@ -0,0 +1,147 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// verifyCmd checks a chain step by step, for gating a deploy on it.
+var verifyCmd = &cobra.Command{
+	Use:   "verify [file | host:port]",
+	Short: "Verify a chain step by step, for CI gates",
+	Long: `Verify the chain in the input as a TLS client would, and report each step
+with its own verdict rather than the first error the verifier runs into:
+
+  validity     every certificate is within its validity period
+  signatures   each certificate is signed by the next up the chain
+  constraints  every issuer is a CA, within its path length
+  trust        the chain leads to a trust anchor
+  hostname     the leaf is valid for --hostname, when given
+  usage        the chain allows each --usage, when given
+
+Trust anchors are the system store, plus --ca-file, or --ca-file alone with
+--no-system-roots. --usage takes serverAuth, clientAuth, codeSigning,
+emailProtection, timeStamping, OCSPSigning or any, in any case, several
+separated by commas. A chain read from a live server is checked against
+that server's name unless --hostname says otherwise.
+
+Exits 0 when every step passes, 1 otherwise. With --output json or yaml, the
+steps come as a list of objects with name, passed and detail.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeArgs(completeCertFiles),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		opts, err := verifyOptionsFromNamedFlags(cmd, "hostname", "ca-file")
+		if err != nil {
+			return err
+		}
+		usageNames, err := cmd.Flags().GetStringSlice("usage")
+		if err != nil {
+			return err
+		}
+		var usages []x509.ExtKeyUsage
+		for _, name := range usageNames {
+			usage, err := certificate.ParseExtKeyUsage(name)
+			if err != nil {
+				return fmt.Errorf("--usage: %w", err)
+			}
+			usages = append(usages, usage)
+		}
+
+		source, err := loadInput(cmd, args)
+		if err != nil {
+			logger.Log.Error("Error loading certificates", zap.Error(err))
+			return err
+		}
+		if opts.DNSName == "" {
+			opts.DNSName = source.Host
+		}
+		certs := make([]*x509.Certificate, len(source.Certs))
+		for i, c := range source.Certs {
+			certs[i] = c.Certificate
+		}
+
+		steps, err := certificate.VerifySteps(certs, opts, usages)
+		if err != nil {
+			return err
+		}
+		report := newVerifyJSON(steps)
+		if format != outputText {
+			if err := writeOutput(os.Stdout, format, report); err != nil {
+				return err
+			}
+		} else if err := writeVerify(os.Stdout, steps); err != nil {
+			return err
+		}
+		if !report.Passed {
+			return &exitError{code: 1, err: fmt.Errorf("verification failed: %s", strings.Join(report.Failed, ", "))}
+		}
+		return nil
+	},
+}
+
+// verifyJSON is the outcome of verify, as --output gives it.
+type verifyJSON struct {
+	Passed bool             `json:"passed" yaml:"passed"`
+	Failed []string         `json:"failed" yaml:"failed"`
+	Steps  []verifyStepJSON `json:"steps" yaml:"steps"`
+}
+
+type verifyStepJSON struct {
+	Name   string `json:"name" yaml:"name"`
+	Passed bool   `json:"passed" yaml:"passed"`
+	Detail string `json:"detail" yaml:"detail"`
+}
+
+// newVerifyJSON describes the steps, naming those that failed.
+func newVerifyJSON(steps []certificate.VerifyStep) verifyJSON {
+	out := verifyJSON{Passed: true, Failed: []string{}, Steps: make([]verifyStepJSON, len(steps))}
+	for i, step := range steps {
+		out.Steps[i] = verifyStepJSON{Name: step.Name, Passed: step.Passed, Detail: step.Detail}
+		if !step.Passed {
+			out.Passed = false
+			out.Failed = append(out.Failed, step.Name)
+		}
+	}
+	return out
+}
+
+// writeVerify writes a line per step. The verdict is the exit status, and
+// the error naming the steps that failed.
+func writeVerify(w io.Writer, steps []certificate.VerifyStep) error {
+	var rows strings.Builder
+	for _, step := range steps {
+		mark := "✅"
+		if !step.Passed {
+			mark = "❌"
+		}
+		fmt.Fprintf(&rows, "%s %s\t%s\n", mark, step.Name, step.Detail)
+	}
+	return writeTable(w, rows.String())
+}
+
+func init() {
+	verifyCmd.Flags().String("hostname", "", "Check that the leaf is valid for this hostname")
+	verifyCmd.Flags().StringSlice("usage", nil, "Check that the chain allows these extended key usages, as serverAuth")
+	verifyCmd.Flags().String("ca-file", "", "PEM file of additional trust anchors")
+	verifyCmd.Flags().Bool("no-system-roots", false, "Do not trust the system store; use only --ca-file")
+	verifyCmd.Flags().String("at", "", "Verify as of this time instead of now (YYYY-MM-DD or RFC 3339)")
+	addOutputFlag(verifyCmd)
+	_ = verifyCmd.MarkFlagFilename("ca-file", certFileExtensions...)
+	_ = verifyCmd.RegisterFlagCompletionFunc("usage", cobra.FixedCompletions(
+		[]string{"serverAuth", "clientAuth", "codeSigning", "emailProtection", "timeStamping", "OCSPSigning", "any"},
+		cobra.ShellCompDirectiveNoFileComp))
+	RootCmd.AddCommand(verifyCmd)
+}
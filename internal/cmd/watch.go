@@ -0,0 +1,404 @@
+// Package cmd contains the command line interface for y509
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// The states watch puts a target in besides those of expiry: a chain that
+// does not verify, and a target that could not be read at all.
+const (
+	watchBroken      = "broken"
+	watchUnreachable = "unreachable"
+)
+
+// watchStates are the states of a target, worst last.
+var watchStates = append(slices.Clone(expiryStates), watchBroken, watchUnreachable)
+
+// watchCmd checks a list of files and servers over and over.
+var watchCmd = &cobra.Command{
+	Use:   "watch --targets <file>",
+	Short: "Check files and servers for expiry and broken chains, repeatedly",
+	Long: `Check every target of a targets file for certificates close to expiry and for
+chains that no longer verify, then again every --interval (as 6h or 1d),
+printing a line per target each round and logging it, until interrupted: a
+small certificate monitor to leave running.
+
+The targets file is YAML:
+
+  warn: 30d                 # optional; --warn and --crit win over these
+  crit: 7d
+  targets:
+    - name: api             # optional; the address or file by default
+      address: api.example.com:443
+    - address: mail.example.com:587
+      starttls: smtp
+      servername: mx.example.com
+    - file: /etc/ssl/internal/bundle.pem
+      roots: /etc/ssl/internal/root.pem
+
+A target is a file or an address, not both. A server's chain is verified for
+the name it was reached by; roots adds trust anchors, for an internal PKI
+whose root is not in the system store. Without it such a chain is
+self-anchored, which is a warning.
+
+Each target is in the worst of these states, in order: ok, warning or
+critical when a certificate expires within --warn or --crit, expired,
+broken when the chain does not verify, and unreachable when it could not be
+read. With --output json every line is a JSON object, for a log shipper;
+with yaml, a YAML document.
+
+--once checks each target once and exits, as expiry does: 0 when all are
+ok, 1 when the worst is a warning, 2 otherwise.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		targetsFile, err := cmd.Flags().GetString("targets")
+		if err != nil {
+			return err
+		}
+		interval, err := watchInterval(cmd)
+		if err != nil {
+			return err
+		}
+		once, err := cmd.Flags().GetBool("once")
+		if err != nil {
+			return err
+		}
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			return err
+		}
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		targets, err := loadWatchTargets(targetsFile)
+		if err != nil {
+			logger.Log.Error("Failed to load watch targets", zap.Error(err))
+			return err
+		}
+		warn, crit, err := watchThresholds(cmd, targets)
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		logger.Log.Info("Watching certificates",
+			zap.String("targets", targetsFile),
+			zap.Int("count", len(targets.Targets)),
+			zap.Duration("interval", interval))
+		if !once {
+			fmt.Fprintf(os.Stderr, "Watching %d target(s) every %s; interrupt to stop\n", len(targets.Targets), interval)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			worst := expiryOK
+			for _, target := range targets.Targets {
+				if ctx.Err() != nil {
+					logger.Log.Info("Stopped watching certificates")
+					return nil
+				}
+				result := checkWatchTarget(ctx, target, warn, crit, timeout, time.Now())
+				logWatchResult(result)
+				if err := writeWatchResult(os.Stdout, format, result); err != nil {
+					return err
+				}
+				if slices.Index(watchStates, result.State) > slices.Index(watchStates, worst) {
+					worst = result.State
+				}
+			}
+			if once {
+				switch worst {
+				case expiryOK:
+					return nil
+				case expiryWarning:
+					return &exitError{code: exitExpiryWarning, err: fmt.Errorf("the worst target is %s", worst)}
+				default:
+					return &exitError{code: exitExpiryCritical, err: fmt.Errorf("the worst target is %s", worst)}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				logger.Log.Info("Stopped watching certificates")
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// watchTargets is a targets file.
+type watchTargets struct {
+	Warn    string        `yaml:"warn"`
+	Crit    string        `yaml:"crit"`
+	Targets []watchTarget `yaml:"targets"`
+}
+
+// watchTarget is a file or a server for watch to check.
+type watchTarget struct {
+	Name       string `yaml:"name"`
+	File       string `yaml:"file"`
+	Address    string `yaml:"address"`
+	ServerName string `yaml:"servername"`
+	StartTLS   string `yaml:"starttls"`
+	Roots      string `yaml:"roots"`
+}
+
+// loadWatchTargets reads a targets file, naming each target that has no
+// name, so a mistake is reported before the first round rather than in it.
+func loadWatchTargets(filename string) (*watchTargets, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("give a targets file with --targets")
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+	return parseWatchTargets(data)
+}
+
+func parseWatchTargets(data []byte) (*watchTargets, error) {
+	var targets watchTargets
+	if err := yaml.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("malformed targets file: %w", err)
+	}
+	if len(targets.Targets) == 0 {
+		return nil, fmt.Errorf("the targets file lists no targets")
+	}
+	for i := range targets.Targets {
+		t := &targets.Targets[i]
+		switch {
+		case t.File == "" && t.Address == "":
+			return nil, fmt.Errorf("target %d has neither a file nor an address", i+1)
+		case t.File != "" && t.Address != "":
+			return nil, fmt.Errorf("target %d has both a file and an address", i+1)
+		case t.File != "" && (t.ServerName != "" || t.StartTLS != ""):
+			return nil, fmt.Errorf("target %d is a file, so servername and starttls mean nothing to it", i+1)
+		case t.StartTLS != "" && !slices.Contains(certificate.StartTLSProtocols, t.StartTLS):
+			return nil, fmt.Errorf("target %d: unknown starttls %q", i+1, t.StartTLS)
+		}
+		if t.Name == "" {
+			t.Name = t.Address + t.File
+		}
+	}
+	return &targets, nil
+}
+
+// watchInterval is --interval, in the units --warn and --crit take.
+func watchInterval(cmd *cobra.Command) (time.Duration, error) {
+	interval, err := thresholdFlag(cmd, "interval")
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("--interval must be positive, not %s", formatThreshold(interval))
+	}
+	return interval, nil
+}
+
+// watchThresholds are --warn and --crit when given, else those of the
+// targets file, else expiry's defaults, which give way as expiry's do.
+func watchThresholds(cmd *cobra.Command, targets *watchTargets) (warn, crit time.Duration, err error) {
+	warn = time.Duration(expiryWarningDays()) * 24 * time.Hour
+	crit = 7 * 24 * time.Hour
+	given := make(map[string]bool)
+	for _, th := range []struct {
+		name, fromFile string
+		d              *time.Duration
+	}{{"warn", targets.Warn, &warn}, {"crit", targets.Crit, &crit}} {
+		given[th.name] = cmd.Flags().Changed(th.name) || th.fromFile != ""
+		switch {
+		case cmd.Flags().Changed(th.name):
+			if *th.d, err = thresholdFlag(cmd, th.name); err != nil {
+				return 0, 0, err
+			}
+		case th.fromFile != "":
+			if *th.d, err = parseThreshold(th.fromFile); err != nil {
+				return 0, 0, fmt.Errorf("%s in the targets file: %w", th.name, err)
+			}
+		}
+	}
+	warn, crit, ok := settleThresholds(warn, crit, given["warn"], given["crit"])
+	if !ok {
+		return 0, 0, fmt.Errorf("crit (%s) is longer than warn (%s)", formatThreshold(crit), formatThreshold(warn))
+	}
+	return warn, crit, nil
+}
+
+// watchResultJSON is a target's line of a watch round. The certificate is
+// the one of the chain to expire first.
+type watchResultJSON struct {
+	Time       time.Time `json:"time" yaml:"time"`
+	Target     string    `json:"target" yaml:"target"`
+	State      string    `json:"state" yaml:"state"`
+	CommonName string    `json:"common_name" yaml:"common_name"`
+	NotAfter   time.Time `json:"not_after" yaml:"not_after"`
+	DaysLeft   int       `json:"days_left" yaml:"days_left"`
+	Trust      string    `json:"trust" yaml:"trust"`
+	Error      string    `json:"error" yaml:"error"`
+}
+
+// checkWatchTarget reads a target's chain and puts it in a state by how
+// long its certificates have left at now and whether it verifies.
+func checkWatchTarget(ctx context.Context, target watchTarget, warn, crit, timeout time.Duration, now time.Time) watchResultJSON {
+	result := watchResultJSON{Time: now.UTC().Truncate(time.Second), Target: target.Name, State: watchUnreachable}
+
+	var certs []*certificate.Info
+	var opts certificate.VerifyOptions
+	var err error
+	if target.Address != "" {
+		var conn *certificate.ConnectResult
+		conn, err = certificate.FetchChain(ctx, target.Address, certificate.ConnectOptions{
+			ServerName: target.ServerName,
+			StartTLS:   target.StartTLS,
+			Timeout:    timeout,
+		})
+		if conn != nil {
+			certs, opts.DNSName = conn.Certificates, conn.ServerName
+		}
+	} else {
+		certs, err = certificate.LoadCertificates(target.File)
+	}
+	if err == nil && len(certs) == 0 {
+		err = errors.New("no certificates")
+	}
+	if err == nil && target.Roots != "" {
+		var roots []*certificate.Info
+		if roots, err = certificate.LoadCertificates(target.Roots); err != nil {
+			err = fmt.Errorf("failed to load roots: %w", err)
+		}
+		for _, r := range roots {
+			opts.ExtraRoots = append(opts.ExtraRoots, r.Certificate)
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	expiry := newExpiryJSON(certs, warn, crit, now)
+	result.State = expiry.State
+	soonest := expiry.Certificates[0]
+	for _, e := range expiry.Certificates {
+		if e.NotAfter.Before(soonest.NotAfter) {
+			soonest = e
+		}
+	}
+	result.CommonName, result.NotAfter, result.DaysLeft = soonest.CommonName, soonest.NotAfter, soonest.DaysLeft
+
+	chain := make([]*x509.Certificate, len(certs))
+	for i, c := range certs {
+		chain[i] = c.Certificate
+	}
+	report := certificate.AnalyzeChain(chain)
+	if report.SortErr != nil {
+		result.State, result.Trust, result.Error = watchBroken, certificate.TrustBroken.String(), report.SortErr.Error()
+		return result
+	}
+	opts.CurrentTime = now
+	verified, err := certificate.VerifyChain(report.Sorted, opts)
+	if err != nil {
+		result.State, result.Error = watchBroken, err.Error()
+		return result
+	}
+	result.Trust = verified.Level.String()
+	switch verified.Level {
+	case certificate.TrustBroken:
+		if verified.Err != nil {
+			result.Error = verified.Err.Error()
+		}
+		// An expired certificate breaks the chain too; expired says more.
+		if result.State != expiryExpired {
+			result.State = watchBroken
+		}
+	case certificate.TrustSelfAnchored:
+		if result.State == expiryOK {
+			result.State = expiryWarning
+		}
+	}
+	return result
+}
+
+// logWatchResult logs a target's result, as a warning when it is not ok.
+func logWatchResult(r watchResultJSON) {
+	fields := []zap.Field{
+		zap.String("target", r.Target),
+		zap.String("state", r.State),
+		zap.String("trust", r.Trust),
+		zap.Int("daysLeft", r.DaysLeft),
+	}
+	if r.Error != "" {
+		fields = append(fields, zap.String("error", r.Error))
+	}
+	if r.State == expiryOK {
+		logger.Log.Info("Watch target checked", fields...)
+	} else {
+		logger.Log.Warn("Watch target checked", fields...)
+	}
+}
+
+// writeWatchResult writes a target's result as a line of text, a line of
+// JSON, or a YAML document, so the output of a long run stays a stream.
+func writeWatchResult(w io.Writer, format string, r watchResultJSON) error {
+	switch format {
+	case outputJSON:
+		return json.NewEncoder(w).Encode(r)
+	case outputYAML:
+		if _, err := fmt.Fprintln(w, "---"); err != nil {
+			return err
+		}
+		return writeYAML(w, r)
+	}
+
+	mark := map[string]string{expiryOK: "✅", expiryWarning: "⚠️"}[r.State]
+	if mark == "" {
+		mark = "❌"
+	}
+	line := fmt.Sprintf("%s %s %-11s %s", certificate.FormatTime(r.Time), mark, r.State, r.Target)
+	if r.Trust != "" {
+		line += fmt.Sprintf(": '%s' expires %s, in %d days; the chain is %s",
+			orNone(r.CommonName), certificate.FormatDate(r.NotAfter), r.DaysLeft, r.Trust)
+	}
+	if r.Error != "" {
+		line += fmt.Sprintf(" (%s)", r.Error)
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+func init() {
+	watchCmd.Flags().String("targets", "", "YAML file listing the files and servers to watch")
+	watchCmd.Flags().String("interval", "6h", "Time between rounds of checks, as 6h or 1d")
+	watchCmd.Flags().Bool("once", false, "Check each target once and exit with the worst state")
+	watchCmd.Flags().String("warn", "30d", "Warn when a certificate expires within this long (default: the targets file's, then expiry_warning_days)")
+	watchCmd.Flags().String("crit", "7d", "Critical when a certificate expires within this long (default: the targets file's)")
+	addOutputFlag(watchCmd)
+	_ = watchCmd.MarkFlagFilename("targets", "yaml", "yml")
+	RootCmd.AddCommand(watchCmd)
+}
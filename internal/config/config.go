@@ -2,10 +2,22 @@
 package config
 
 import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/kanywst/y509/pkg/certificate"
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
 )
 
 // Theme holds the color configuration for the application.
@@ -39,11 +51,121 @@ type Config struct {
 	// lifetimes shrink (200 days in 2026, 47 by 2029) a 30-day default becomes
 	// a large slice of a cert's life, so this is configurable.
 	ExpiryWarningDays int `mapstructure:"expiry_warning_days"`
+	// CTLogList is the path to a Certificate Transparency log list in the v3
+	// JSON schema. When set, embedded SCTs are verified against it; otherwise
+	// they are only decoded.
+	CTLogList string `mapstructure:"ct_log_list"`
+	// SearchMode is how search matches: SearchFuzzy ranks certificates by an
+	// fzf-style score, SearchExact keeps those containing the query as typed.
+	SearchMode string `mapstructure:"search_mode"`
+	// Columns are the columns of the certificate list, left to right: any
+	// of status, cn, issuer, expiry, key and source.
+	Columns []string `mapstructure:"columns"`
+	// CheckRevocation asks each certificate's OCSP responder, or failing
+	// that its CRL distribution point, whether it has been revoked. It goes
+	// to the network, so it is off unless asked for.
+	CheckRevocation bool `mapstructure:"check_revocation"`
+	// Themes are named alternatives to Theme, switched between at runtime
+	// with :theme. Colours a theme leaves out are taken from Theme, which
+	// is itself always on offer as "default".
+	Themes map[string]Theme `mapstructure:"themes"`
+	// ThemeName is the theme in use: "default", or a name from Themes.
+	ThemeName string `mapstructure:"theme_name"`
+	// Preset is the shipped theme, one of PresetNames, that the colours
+	// under theme are laid over; theme.preset in the file.
+	Preset string `mapstructure:"-"`
+	// NoColor draws the TUI without colour, marking the selection and
+	// focus with reverse video and underlines instead. It is also set by
+	// a non-empty NO_COLOR in the environment (https://no-color.org).
+	NoColor bool `mapstructure:"no_color"`
+	// ASCII replaces the emoji, status symbols and box drawing of the TUI
+	// with plain ASCII, for terminals, logs and screen readers that cannot
+	// show them.
+	ASCII bool `mapstructure:"ascii"`
+	// NoSplash starts the TUI on the certificates, without the splash
+	// screen first.
+	NoSplash bool `mapstructure:"no_splash"`
+	// ScreenReader suits the TUI to a terminal screen reader: it implies
+	// ASCII, NoColor and NoSplash, says in words what icons and colours
+	// show, and makes the status bar a plain line announcing each change,
+	// with the terminal cursor left on it.
+	ScreenReader bool `mapstructure:"screen_reader"`
+	// Keys rebinds actions of the TUI, each to the keys listed for it:
+	// quit: [x, ctrl+c], next_pane: [tab]. Actions left out keep their
+	// default keys.
+	Keys map[string][]string `mapstructure:"keys"`
+	// ToastTimeout is how long a notification such as "Exported to
+	// leaf.pem" stays on screen.
+	ToastTimeout time.Duration `mapstructure:"toast_timeout"`
+	// DateFormat is how times are written in the TUI and the output of
+	// the commands: "default" (2025-06-01 12:00:00 UTC), "rfc3339",
+	// "epoch", or a Go layout such as "02 Jan 2006 15:04".
+	DateFormat string `mapstructure:"date_format"`
+	// Timezone is the zone times are written in: "utc", "local", or an
+	// IANA name such as "Europe/Paris".
+	Timezone string `mapstructure:"timezone"`
+	// TimeFormat is DateFormat and Timezone, parsed.
+	TimeFormat certificate.TimeFormat `mapstructure:"-"`
+	// Roots is a PEM file of trust anchors to verify against besides the
+	// system store, as --roots gives them: the default of that flag, and of
+	// verify's --ca-file, and what the TUI validates with.
+	Roots string `mapstructure:"roots"`
+	// Flags are defaults for command line flags, by name, for each command
+	// that has the flag: timeout: 10s, crit: 14d. A flag given on the
+	// command line wins; a list sets one that repeats once for each item.
+	Flags map[string]any `mapstructure:"flags"`
+	// Profile is the profile in use, whose settings are laid over the rest
+	// of the file: picked with --profile, Y509_PROFILE, or profile in the
+	// file itself. Empty for none.
+	Profile string `mapstructure:"profile"`
+	// ProfileNames are the profiles the file has under profiles, sorted.
+	ProfileNames []string `mapstructure:"-"`
+	// File is the configuration file that was read; empty when there was
+	// none.
+	File string `mapstructure:"-"`
+
+	// overrides and configuredThemes are Theme and Themes as the file has
+	// them, before the preset is laid under them.
+	overrides        Theme
+	configuredThemes map[string]Theme
 }
 
+// ErrNoProfile is returned by LoadConfig, wrapped, when the profile asked
+// for is not in the file. The rest of the configuration is still loaded.
+var ErrNoProfile = errors.New("no profile")
+
+// profileOverride is the profile SetProfile picked.
+var profileOverride string
+
+// SetProfile has LoadConfig use the named profile, over any Y509_PROFILE or
+// profile in the file, as --profile does.
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// DefaultThemeName names Theme as configured, before any switch.
+const DefaultThemeName = "default"
+
 // DefaultExpiryWarningDays is the fallback "expiring soon" window in days.
 const DefaultExpiryWarningDays = 30
 
+// DefaultToastTimeout is how long notifications stay up unless configured
+// otherwise.
+const DefaultToastTimeout = 3 * time.Second
+
+// DefaultTimezone is the zone times are written in unless configured
+// otherwise: that of the certificates themselves.
+const DefaultTimezone = "utc"
+
+// DefaultColumns are the list columns shown unless configured otherwise.
+var DefaultColumns = []string{"status", "cn", "expiry"}
+
+// Search modes.
+const (
+	SearchFuzzy = "fuzzy"
+	SearchExact = "exact"
+)
+
 // newDefaultTheme returns a Theme struct with all default values.
 func newDefaultTheme() Theme {
 	return Theme{
@@ -73,37 +195,34 @@ func newDefaultTheme() Theme {
 // It always returns a valid Config object, falling back to defaults if necessary.
 func LoadConfig() (*Config, error) {
 	v := viper.New()
-	defaultTheme := newDefaultTheme()
-
-	// Set default values using the default theme struct
-	v.SetDefault("theme.text", defaultTheme.Text)
-	v.SetDefault("theme.border", defaultTheme.Border)
-	v.SetDefault("theme.border_focus", defaultTheme.BorderFocus)
-	v.SetDefault("theme.background", defaultTheme.Background)
-	v.SetDefault("theme.status_bar", defaultTheme.StatusBar)
-	v.SetDefault("theme.status_bar_text", defaultTheme.StatusBarText)
-	v.SetDefault("theme.command_bar", defaultTheme.CommandBar)
-	v.SetDefault("theme.command_bar_text", defaultTheme.CommandBarText)
-	v.SetDefault("theme.error", defaultTheme.Error)
-	v.SetDefault("theme.highlight", defaultTheme.Highlight)
-	v.SetDefault("theme.highlight_text", defaultTheme.HighlightText)
-	v.SetDefault("theme.highlight_dim", defaultTheme.HighlightDim)
-	v.SetDefault("theme.status_valid", defaultTheme.StatusValid)
-	v.SetDefault("theme.status_warning", defaultTheme.StatusWarning)
-	v.SetDefault("theme.status_expired", defaultTheme.StatusExpired)
-	v.SetDefault("theme.title", defaultTheme.Title)
-	v.SetDefault("theme.section_title", defaultTheme.SectionTitle)
-	v.SetDefault("theme.detail_key", defaultTheme.DetailKey)
-	v.SetDefault("theme.list_row_alt", defaultTheme.ListRowAlt)
+
+	// The colours default to empty, so that those the file or the
+	// environment leave out can be told apart and come from the preset.
+	// They are still set, for viper to look for them in the environment.
+	themeType := reflect.TypeFor[Theme]()
+	for i := range themeType.NumField() {
+		v.SetDefault("theme."+themeType.Field(i).Tag.Get("mapstructure"), "")
+	}
+	v.SetDefault("theme.preset", DefaultThemeName)
 	v.SetDefault("expiry_warning_days", DefaultExpiryWarningDays)
+	v.SetDefault("ct_log_list", "")
+	v.SetDefault("search_mode", SearchFuzzy)
+	v.SetDefault("columns", DefaultColumns)
+	v.SetDefault("check_revocation", false)
+	v.SetDefault("theme_name", DefaultThemeName)
+	v.SetDefault("no_color", false)
+	v.SetDefault("ascii", false)
+	v.SetDefault("no_splash", false)
+	v.SetDefault("screen_reader", false)
+	v.SetDefault("toast_timeout", DefaultToastTimeout)
+	v.SetDefault("date_format", certificate.TimeLayoutDefault)
+	v.SetDefault("timezone", DefaultTimezone)
 
 	// Set config file
-	v.SetConfigName(".y509")
 	v.SetConfigType("yaml")
-	if home, err := os.UserHomeDir(); err == nil {
-		v.AddConfigPath(home)
+	if path := Find(); path != "" {
+		v.SetConfigFile(path)
 	}
-	v.AddConfigPath(".")
 
 	// Env variables
 	v.SetEnvPrefix("Y509")
@@ -112,26 +231,175 @@ func LoadConfig() (*Config, error) {
 
 	// Read config file
 	var readErr error
-	if err := v.ReadInConfig(); err != nil {
+	if v.ConfigFileUsed() != "" {
 		// We acknowledge the error but don't return nil here to ensure
 		// default values are still available.
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		if err := v.ReadInConfig(); err != nil {
 			readErr = err
 		}
 	}
 
+	// Lay the profile's settings over the file's, where the environment
+	// still overrides them.
+	profiles := slices.Sorted(maps.Keys(v.GetStringMap("profiles")))
+	if profile := strings.ToLower(cmp.Or(profileOverride, v.GetString("profile"))); profile != "" {
+		if !slices.Contains(profiles, profile) {
+			readErr = errors.Join(readErr, fmt.Errorf("%w %q (profiles: %s)", ErrNoProfile, profile, orNone(strings.Join(profiles, ", "))))
+			profile = ""
+		} else if err := v.MergeConfigMap(v.GetStringMap("profiles." + profile)); err != nil {
+			readErr = errors.Join(readErr, err)
+		}
+		v.Set("profile", profile)
+	}
+
 	// Unmarshal config
 	var config Config
 	if err := v.Unmarshal(&config); err != nil {
 		// If unmarshal fails entirely, we still want to return a config object with hardcoded defaults
 		// as a last resort, though viper defaults should have been enough.
-		return &Config{Theme: defaultTheme, ExpiryWarningDays: DefaultExpiryWarningDays}, err
+		return &Config{Theme: newDefaultTheme(), Preset: DefaultThemeName, ExpiryWarningDays: DefaultExpiryWarningDays, SearchMode: SearchFuzzy, Columns: DefaultColumns, ToastTimeout: DefaultToastTimeout, DateFormat: certificate.TimeLayoutDefault, Timezone: DefaultTimezone}, err
 	}
 
 	// Guard against non-positive values from a malformed config file.
 	if config.ExpiryWarningDays <= 0 {
 		config.ExpiryWarningDays = DefaultExpiryWarningDays
 	}
+	if config.ToastTimeout <= 0 {
+		config.ToastTimeout = DefaultToastTimeout
+	}
+	config.SearchMode = strings.ToLower(config.SearchMode)
+	if config.SearchMode != SearchExact {
+		config.SearchMode = SearchFuzzy
+	}
+	// A format or zone that does not parse is reported, and the default
+	// used, rather than failing the run over how dates look.
+	timeFormat, err := certificate.ParseTimeFormat(config.DateFormat, config.Timezone)
+	if err != nil {
+		readErr = errors.Join(readErr, err)
+		config.DateFormat, config.Timezone = certificate.TimeLayoutDefault, DefaultTimezone
+	}
+	config.TimeFormat = timeFormat
+	config.File = v.ConfigFileUsed()
+	config.ProfileNames = profiles
+	if os.Getenv("NO_COLOR") != "" {
+		config.NoColor = true
+	}
+	if config.ScreenReader {
+		config.UseScreenReader()
+	}
+
+	// Lay the configured colours over the preset, fill in the named themes
+	// from the result and switch to the chosen theme. An unknown name, say
+	// of a theme since removed, falls back to the default rather than
+	// failing, and an unknown preset likewise.
+	config.overrides, config.configuredThemes = config.Theme, config.Themes
+	themeName := strings.ToLower(config.ThemeName)
+	if config.UsePreset(v.GetString("theme.preset")) != nil {
+		_ = config.UsePreset(DefaultThemeName)
+	}
+	if theme, ok := config.Themes[themeName]; ok {
+		config.Theme, config.ThemeName = theme, themeName
+	}
 
 	return &config, readErr
 }
+
+// fillFrom returns the theme with every colour it leaves empty taken from
+// base.
+func (t Theme) fillFrom(base Theme) Theme {
+	filled := reflect.ValueOf(&t).Elem()
+	from := reflect.ValueOf(base)
+	for i := range filled.NumField() {
+		if filled.Field(i).String() == "" {
+			filled.Field(i).SetString(from.Field(i).String())
+		}
+	}
+	return t
+}
+
+// ThemeNames lists the themes on offer, the default first and the rest in
+// alphabetical order.
+func (c *Config) ThemeNames() []string {
+	names := []string{DefaultThemeName}
+	for _, name := range slices.Sorted(maps.Keys(c.Themes)) {
+		if name != DefaultThemeName {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SaveValue sets one top-level key of the YAML configuration file at path,
+// creating the file if there is none. The rest of the file, comments and
+// all, is left as it was. An empty path means DefaultPath.
+func SaveValue(path, key, value string) error {
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return err
+		}
+	}
+
+	mode := os.FileMode(0o644)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", path)
+	}
+
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			root.Content[i+1] = valueNode
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, valueNode)
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, out.Bytes(), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// UseScreenReader turns on screen reader mode, and the modes it implies.
+func (c *Config) UseScreenReader() {
+	c.ScreenReader, c.ASCII, c.NoColor, c.NoSplash = true, true, true, true
+}
+
+// orNone is s, or "none" when it is empty.
+func orNone(s string) string {
+	return cmp.Or(s, "none")
+}
@@ -0,0 +1,353 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/kanywst/y509/internal/xdg"
+	"github.com/kanywst/y509/pkg/certificate"
+	"go.yaml.in/yaml/v3"
+)
+
+// FileName is the name of the configuration file in the configuration
+// directory, ~/.config/y509 unless XDG_CONFIG_HOME says otherwise.
+const FileName = "config.yaml"
+
+// LegacyFileName is the name the configuration file had before it moved to
+// the configuration directory, in the home directory or the working one.
+// It is still read when there is no FileName.
+const LegacyFileName = ".y509.yaml"
+
+// Paths are the configuration files LoadConfig looks for, the first found
+// read: FileName in the configuration directory, then LegacyFileName in the
+// home directory and in the working one.
+func Paths() []string {
+	var paths []string
+	if dir, err := xdg.ConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, FileName))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, LegacyFileName))
+	}
+	return append(paths, LegacyFileName)
+}
+
+// Find is the first of Paths there is a file at, or "" for none.
+func Find() string {
+	for _, path := range Paths() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// DefaultPath is where a configuration file is written when none was read:
+// FileName in the configuration directory.
+func DefaultPath() (string, error) {
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no configuration file to write to: %w", err)
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// ShortPath is the configuration file in use, or the one DefaultPath would
+// have a setting saved to, with the home directory written as ~.
+func (c *Config) ShortPath() string {
+	path := c.File
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return "~/.config/y509/" + FileName
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && filepath.IsLocal(rel) {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
+
+// KeyBinding is an action of the TUI, for the keys section, and the keys
+// it is bound to.
+type KeyBinding struct {
+	Action string
+	Keys   []string
+	// Help says what the action does, as the help view does.
+	Help string
+}
+
+// setting is one key of a section and its value.
+type setting struct {
+	Key, Value string
+}
+
+// themeSettings lists the colours of a theme in the order Theme has them,
+// under their keys in the file.
+func themeSettings(t Theme) []setting {
+	value := reflect.ValueOf(t)
+	settings := make([]setting, value.NumField())
+	for i := range value.NumField() {
+		settings[i] = setting{value.Type().Field(i).Tag.Get("mapstructure"), value.Field(i).String()}
+	}
+	return settings
+}
+
+// flowList writes a list of strings as a YAML flow sequence, quoted where
+// YAML needs it: [q, ctrl+c], ['?'].
+func flowList(items []string) (string, error) {
+	data, err := yaml.Marshal(flowSequence(items))
+	return strings.TrimSpace(string(data)), err
+}
+
+// templateText is the configuration file Template writes. The behaviour
+// settings are set to their defaults; the colours and the keys are
+// commented out, as set they would hide the preset and any later change to
+// the defaults.
+const templateText = `# y509 configuration, every setting at its default. y509 reads this file
+# as ~/.config/y509/config.yaml, or under $XDG_CONFIG_HOME/y509 when that is
+# set; Y509_<SETTING> in the environment overrides a setting, as
+# Y509_EXPIRY_WARNING_DAYS=60 does.
+
+# Days before expiry to flag a certificate as "expiring soon". Lower this as
+# CA/Browser Forum maximum lifetimes shrink (200 days in 2026).
+expiry_warning_days: {{.ExpiryWarningDays}}
+
+# Certificate Transparency log list (v3 JSON, as published at
+# https://www.gstatic.com/ct/log_list/v3/log_list.json). When set, embedded
+# SCTs in the Misc tab are verified against it; otherwise they are only decoded.
+ct_log_list: ""
+
+# "fuzzy" matches search queries fzf-style and ranks the results; "exact"
+# keeps only certificates containing the query, in chain order.
+search_mode: {{.SearchMode}}
+
+# Columns of the certificate list, left to right: status, cn, issuer, expiry,
+# key and source (the file each certificate came from).
+columns: {{flow .Columns}}
+
+# Ask each certificate's OCSP responder, or failing that its CRL distribution
+# point, whether it has been revoked. Off by default, as it goes to the
+# network; --check-revocation turns it on for one run.
+check_revocation: false
+
+# Draw without colour, as --no-color or NO_COLOR does, and in plain ASCII,
+# as --ascii does.
+no_color: false
+ascii: false
+
+# Open straight on the certificates, without the splash screen, as
+# --no-splash does.
+no_splash: false
+
+# Suit the TUI to a screen reader, as --screen-reader does: plain ASCII, no
+# colour or splash, and a status bar announcing each change in words.
+screen_reader: false
+
+# How long notifications such as "Exported to leaf.pem" stay on screen.
+toast_timeout: {{.ToastTimeout}}
+
+# How times are written, in the TUI and the output of the commands:
+# "default" (2025-06-01 12:00:00 UTC), "rfc3339", "epoch" (seconds since
+# 1970), or a Go layout such as "02 Jan 2006 15:04". JSON and other output
+# for machines keeps RFC 3339 in UTC.
+date_format: {{.DateFormat}}
+
+# The zone times are written in: "utc", "local", or a name from the IANA
+# database such as "Europe/Paris".
+timezone: {{.Timezone}}
+
+# A PEM file of trust anchors to verify against besides the system store:
+# the default of --roots and verify's --ca-file, and what the TUI validates
+# with.
+roots: ""
+
+# Defaults for command line flags, by name, for each command that has the
+# flag. A flag given on the command line wins.
+# flags:
+#   timeout: 5s
+#   crit: 14d
+
+# Rebind keys, each action to the keys listed in place of its defaults.
+# prev_pane and next_pane are other names for left and right. Uncomment an
+# action to rebind it.
+# keys:
+{{- range .Keys}}
+#   {{printf "%-18s" (print .Action ":")}} {{printf "%-28s" (flow .Keys)}} # {{.Help}}
+{{- end}}
+
+# The colours of the TUI, laid over a preset, one of
+# {{join .Presets ", "}}; --theme picks one for a run.
+# Uncomment only the colours to change; the rest come from the preset. These
+# are the default preset's.
+theme:
+  preset: {{.Preset}}
+{{- range .Colours}}
+  # {{.Key}}: {{printf "%q" .Value}}
+{{- end}}
+
+# Named themes to switch between with :theme, besides the presets. Colours
+# left out come from theme: above, which is always on offer as "default".
+# themes:
+#   latte:
+#     background: "#eff1f5"
+#     text: "#4c4f69"
+#     highlight: "#1e66f5"
+
+# The theme to start in. :theme save writes the one in use here.
+theme_name: {{.ThemeName}}
+
+# Named profiles, each laid over the rest of this file when picked with
+# --profile, Y509_PROFILE, or profile: below. A profile may set anything
+# above; its flags and theme are merged with the file's.
+# profiles:
+#   work:
+#     roots: /etc/pki/work-ca.pem
+#     expiry_warning_days: 60
+#     theme:
+#       preset: solarized-dark
+#     flags:
+#       crit: 14d
+#   homelab:
+#     roots: /srv/homelab/root.pem
+#     flags:
+#       no-system-roots: true
+# profile: work
+`
+
+// Template is a configuration file that sets, or lists commented out, every
+// setting at its default, each explained, with keys the actions of the TUI
+// at their default bindings.
+func Template(keys []KeyBinding) []byte {
+	tmpl := template.Must(template.New("config").Funcs(template.FuncMap{
+		"flow": flowList,
+		"join": strings.Join,
+	}).Parse(templateText))
+
+	var b bytes.Buffer
+	err := tmpl.Execute(&b, map[string]any{
+		"ExpiryWarningDays": DefaultExpiryWarningDays,
+		"SearchMode":        SearchFuzzy,
+		"Columns":           DefaultColumns,
+		"ToastTimeout":      DefaultToastTimeout,
+		"DateFormat":        certificate.TimeLayoutDefault,
+		"Timezone":          DefaultTimezone,
+		"Keys":              keys,
+		"Presets":           PresetNames,
+		"Preset":            DefaultThemeName,
+		"Colours":           themeSettings(newDefaultTheme()),
+		"ThemeName":         DefaultThemeName,
+	})
+	if err != nil {
+		// The template and its data are fixed; only a bug gets here.
+		panic(err)
+	}
+	return b.Bytes()
+}
+
+// YAML writes the configuration in force as a configuration file would set
+// it: the file, its profile and the environment merged over the defaults,
+// the theme's colours laid over its preset, keys the bindings in force. The
+// presets are left out of themes, which holds the ones configured, and the
+// profiles out altogether, the one in use being merged in.
+func (c *Config) YAML(keys []KeyBinding) ([]byte, error) {
+	keyMap := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range keys {
+		keyMap.Content = append(keyMap.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k.Action}, flowSequence(k.Keys))
+	}
+
+	theme := themeNode(c.Themes[DefaultThemeName])
+	theme.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "preset"}, {Kind: yaml.ScalarNode, Value: c.Preset},
+	}, theme.Content...)
+	themes := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range slices.Sorted(maps.Keys(c.configuredThemes)) {
+		name = strings.ToLower(name)
+		themes.Content = append(themes.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, themeNode(c.Themes[name]))
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range []struct {
+		key   string
+		value any
+	}{
+		{"expiry_warning_days", c.ExpiryWarningDays},
+		{"ct_log_list", c.CTLogList},
+		{"search_mode", c.SearchMode},
+		{"columns", flowSequence(c.Columns)},
+		{"check_revocation", c.CheckRevocation},
+		{"no_color", c.NoColor},
+		{"ascii", c.ASCII},
+		{"no_splash", c.NoSplash},
+		{"screen_reader", c.ScreenReader},
+		{"toast_timeout", c.ToastTimeout.String()},
+		{"date_format", c.DateFormat},
+		{"timezone", c.Timezone},
+		{"roots", c.Roots},
+		{"flags", nonNilFlags(c.Flags)},
+		{"keys", keyMap},
+		{"theme", theme},
+		{"themes", themes},
+		{"theme_name", c.ThemeName},
+		{"profile", c.Profile},
+	} {
+		value, ok := s.value.(*yaml.Node)
+		if !ok {
+			value = new(yaml.Node)
+			if err := value.Encode(s.value); err != nil {
+				return nil, err
+			}
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.key}, value)
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// nonNilFlags is flags, or an empty map, which YAML writes as {} rather
+// than null.
+func nonNilFlags(flags map[string]any) map[string]any {
+	if flags == nil {
+		return map[string]any{}
+	}
+	return flags
+}
+
+// themeNode is a theme as a YAML mapping, its colours in the order Theme
+// has them.
+func themeNode(t Theme) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range themeSettings(t) {
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: s.Key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: s.Value, Style: yaml.DoubleQuotedStyle})
+	}
+	return node
+}
+
+// flowSequence is a list of strings as a YAML sequence on one line.
+func flowSequence(items []string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, item := range items {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item})
+	}
+	return node
+}
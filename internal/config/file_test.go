@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeys are bindings as the TUI would give them, with keys YAML has to
+// quote.
+var testKeys = []KeyBinding{
+	{Action: "help", Keys: []string{"?"}, Help: "help"},
+	{Action: "goto_tab", Keys: []string{"1", "2"}, Help: "go to detail tab"},
+	{Action: "quit", Keys: []string{"q", "ctrl+c"}, Help: "quit"},
+}
+
+// loadFrom writes data as the configuration file of a fresh home,
+// ~/.config/y509/config.yaml, and loads it.
+func loadFrom(t *testing.T, data []byte) *Config {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Chdir(dir)
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	return cfg
+}
+
+func TestTemplate(t *testing.T) {
+	template := string(Template(testKeys))
+	for _, want := range []string{"expiry_warning_days: 30\n", "columns: [status, cn, expiry]\n", "#   help:", "['?']", "  # text: \"#cdd6f4\"\n"} {
+		if !strings.Contains(template, want) {
+			t.Errorf("no %q in the template:\n%s", want, template)
+		}
+	}
+
+	// As written, it is the defaults; with the keys and the colours
+	// uncommented, it still is.
+	if cfg := loadFrom(t, []byte(template)); cfg.Theme != newDefaultTheme() || len(cfg.Keys) != 0 {
+		t.Errorf("the template changes the defaults: %+v", cfg)
+	}
+	var lines []string
+	for _, line := range strings.Split(template, "\n") {
+		switch {
+		case line == "# keys:", strings.HasPrefix(line, "#   ") && strings.Contains(line, "["):
+			line = strings.TrimPrefix(line, "# ")
+		case strings.HasPrefix(line, "  # "):
+			line = "  " + strings.TrimPrefix(line, "  # ")
+		}
+		lines = append(lines, line)
+	}
+	cfg := loadFrom(t, []byte(strings.Join(lines, "\n")))
+	if cfg.Theme != newDefaultTheme() {
+		t.Errorf("the uncommented colours are not the default theme: %+v", cfg.Theme)
+	}
+	if !slices.Equal(cfg.Keys["help"], []string{"?"}) || !slices.Equal(cfg.Keys["goto_tab"], []string{"1", "2"}) {
+		t.Errorf("the uncommented keys read back as %v", cfg.Keys)
+	}
+}
+
+func TestConfigYAML(t *testing.T) {
+	cfg := loadFrom(t, []byte("expiry_warning_days: 60\ntheme:\n  preset: dracula\n  error: \"#123456\"\nthemes:\n  Mine:\n    text: \"#000000\"\ntheme_name: mine\n"))
+	data, err := cfg.YAML(testKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"expiry_warning_days: 60\n", "  preset: dracula\n", "  error: \"#123456\"\n", "  mine:\n", "theme_name: mine\n", "  help: ['?']\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("no %q in:\n%s", want, data)
+		}
+	}
+
+	// What it writes reads back as the same configuration.
+	again := loadFrom(t, data)
+	if again.ExpiryWarningDays != 60 || again.Theme != cfg.Theme || again.ThemeName != "mine" || again.Themes["mine"] != cfg.Themes["mine"] {
+		t.Errorf("read back as %+v, want %+v", again, cfg)
+	}
+	if !slices.Equal(again.Keys["quit"], []string{"q", "ctrl+c"}) {
+		t.Errorf("keys read back as %v", again.Keys)
+	}
+}
+
+func TestLoadConfigTimeFormat(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := loadFrom(t, []byte("expiry_warning_days: 30\n"))
+	if got := cfg.TimeFormat.Time(at); got != "2025-06-01 12:00:00 UTC" {
+		t.Errorf("by default a time is written %q", got)
+	}
+
+	cfg = loadFrom(t, []byte("date_format: rfc3339\ntimezone: Asia/Tokyo\n"))
+	if got := cfg.TimeFormat.Time(at); got != "2025-06-01T21:00:00+09:00" {
+		t.Errorf("rfc3339 in Asia/Tokyo writes %q", got)
+	}
+
+	t.Setenv("Y509_DATE_FORMAT", "epoch")
+	if cfg = loadFrom(t, nil); cfg.TimeFormat.Time(at) != "1748779200" {
+		t.Errorf("Y509_DATE_FORMAT=epoch writes %q", cfg.TimeFormat.Time(at))
+	}
+
+	// A zone that does not exist is reported, and UTC used.
+	t.Setenv("Y509_TIMEZONE", "Mars/Olympus_Mons")
+	cfg, err := LoadConfig()
+	if err == nil {
+		t.Error("an unknown time zone was not reported")
+	}
+	if cfg.Timezone != DefaultTimezone || cfg.TimeFormat.Time(at) != "2025-06-01 12:00:00 UTC" {
+		t.Errorf("an unknown zone left %q, writing %q", cfg.Timezone, cfg.TimeFormat.Time(at))
+	}
+}
+
+func TestLoadConfigPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Chdir(t.TempDir())
+
+	// Earlier versions kept the file in the home directory; it is still read.
+	legacy := filepath.Join(home, LegacyFileName)
+	if err := os.WriteFile(legacy, []byte("expiry_warning_days: 45\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := LoadConfig(); err != nil || cfg.File != legacy || cfg.ExpiryWarningDays != 45 {
+		t.Errorf("read %q, %d days (%v), want the legacy file", cfg.File, cfg.ExpiryWarningDays, err)
+	}
+
+	// One under XDG_CONFIG_HOME wins over it.
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	path := filepath.Join(xdg, "y509", FileName)
+	if err := SaveValue("", "expiry_warning_days", "60"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := LoadConfig(); err != nil || cfg.File != path || cfg.ExpiryWarningDays != 60 {
+		t.Errorf("read %q, %d days (%v), want %s", cfg.File, cfg.ExpiryWarningDays, err, path)
+	}
+	if got := (&Config{File: filepath.Join(home, ".config", "y509", FileName)}).ShortPath(); got != filepath.Join("~", ".config", "y509", FileName) {
+		t.Errorf("ShortPath = %q", got)
+	}
+}
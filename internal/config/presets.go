@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// PresetNames are the themes shipped with y509, the default first.
+var PresetNames = []string{DefaultThemeName, "light", "dracula", "solarized-dark", "monochrome"}
+
+// presets holds the shipped themes by name. Each sets every colour, so the
+// colours a configuration gives are all it has to say.
+var presets = map[string]Theme{
+	DefaultThemeName: newDefaultTheme(),
+	// Catppuccin Latte, the light flavour of the default.
+	"light": {
+		Text:           "#4c4f69",
+		Border:         "#bcc0cc",
+		BorderFocus:    "#1e66f5",
+		Background:     "#eff1f5",
+		StatusBar:      "#e6e9ef",
+		StatusBarText:  "#4c4f69",
+		CommandBar:     "#ccd0da",
+		CommandBarText: "#4c4f69",
+		Error:          "#d20f39",
+		Highlight:      "#1e66f5",
+		HighlightText:  "#eff1f5",
+		HighlightDim:   "#ccd0da",
+		StatusValid:    "#40a02b",
+		StatusWarning:  "#df8e1d",
+		StatusExpired:  "#d20f39",
+		Title:          "#04a5e5",
+		SectionTitle:   "#7287fd",
+		DetailKey:      "#7c7f93",
+		ListRowAlt:     "#e6e9ef",
+	},
+	"dracula": {
+		Text:           "#f8f8f2",
+		Border:         "#44475a",
+		BorderFocus:    "#bd93f9",
+		Background:     "#282a36",
+		StatusBar:      "#21222c",
+		StatusBarText:  "#f8f8f2",
+		CommandBar:     "#44475a",
+		CommandBarText: "#f8f8f2",
+		Error:          "#ff5555",
+		Highlight:      "#bd93f9",
+		HighlightText:  "#282a36",
+		HighlightDim:   "#44475a",
+		StatusValid:    "#50fa7b",
+		StatusWarning:  "#f1fa8c",
+		StatusExpired:  "#ff5555",
+		Title:          "#8be9fd",
+		SectionTitle:   "#ff79c6",
+		DetailKey:      "#6272a4",
+		ListRowAlt:     "#21222c",
+	},
+	"solarized-dark": {
+		Text:           "#839496",
+		Border:         "#586e75",
+		BorderFocus:    "#268bd2",
+		Background:     "#002b36",
+		StatusBar:      "#073642",
+		StatusBarText:  "#93a1a1",
+		CommandBar:     "#073642",
+		CommandBarText: "#93a1a1",
+		Error:          "#dc322f",
+		Highlight:      "#268bd2",
+		HighlightText:  "#002b36",
+		HighlightDim:   "#073642",
+		StatusValid:    "#859900",
+		StatusWarning:  "#b58900",
+		StatusExpired:  "#dc322f",
+		Title:          "#2aa198",
+		SectionTitle:   "#6c71c4",
+		DetailKey:      "#586e75",
+		ListRowAlt:     "#073642",
+	},
+	// Greys only, for terminals that do colour but people who would rather
+	// they did not. Status still shows in the icons.
+	"monochrome": {
+		Text:           "#d0d0d0",
+		Border:         "#4e4e4e",
+		BorderFocus:    "#ffffff",
+		Background:     "#121212",
+		StatusBar:      "#1c1c1c",
+		StatusBarText:  "#d0d0d0",
+		CommandBar:     "#303030",
+		CommandBarText: "#d0d0d0",
+		Error:          "#ffffff",
+		Highlight:      "#e4e4e4",
+		HighlightText:  "#121212",
+		HighlightDim:   "#3a3a3a",
+		StatusValid:    "#bcbcbc",
+		StatusWarning:  "#e4e4e4",
+		StatusExpired:  "#ffffff",
+		Title:          "#ffffff",
+		SectionTitle:   "#e4e4e4",
+		DetailKey:      "#8a8a8a",
+		ListRowAlt:     "#1c1c1c",
+	},
+}
+
+// UsePreset lays the colours configured under theme over the named preset
+// and starts in the result, as "default". The presets are on offer to
+// :theme by their own names, as they ship, and the configured themes are
+// filled in again from the new default.
+func (c *Config) UsePreset(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	preset, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("no theme preset %q (one of %s)", name, strings.Join(PresetNames, ", "))
+	}
+	c.Preset = name
+	c.Theme = c.overrides.fillFrom(preset)
+
+	themes := make(map[string]Theme, len(presets)+len(c.configuredThemes))
+	maps.Copy(themes, presets)
+	themes[DefaultThemeName] = c.Theme
+	for name, theme := range c.configuredThemes {
+		themes[strings.ToLower(name)] = theme.fillFrom(c.Theme)
+	}
+	c.Themes = themes
+	c.ThemeName = DefaultThemeName
+	return nil
+}
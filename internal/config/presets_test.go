@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	for _, name := range PresetNames {
+		preset, ok := presets[name]
+		if !ok {
+			t.Fatalf("no preset %q", name)
+		}
+		if filled := preset.fillFrom(Theme{}); filled != preset {
+			t.Errorf("preset %q leaves colours out", name)
+		}
+	}
+	if len(presets) != len(PresetNames) {
+		t.Errorf("%d presets, but %d names", len(presets), len(PresetNames))
+	}
+}
+
+func TestLoadConfigPreset(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Chdir(dir)
+	yaml := "theme:\n  preset: Dracula\n  error: \"#123456\"\nthemes:\n  mine:\n    text: \"#000000\"\n"
+	if err := os.WriteFile(filepath.Join(dir, LegacyFileName), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dracula := presets["dracula"]
+	if cfg.Preset != "dracula" || cfg.Theme.Background != dracula.Background {
+		t.Errorf("preset %q, background %q, want dracula's", cfg.Preset, cfg.Theme.Background)
+	}
+	if cfg.Theme.Error != "#123456" {
+		t.Errorf("error colour %q, want the configured one over the preset", cfg.Theme.Error)
+	}
+	if mine := cfg.Themes["mine"]; mine.Text != "#000000" || mine.Error != "#123456" {
+		t.Errorf("a named theme was not filled from the configured one: %+v", mine)
+	}
+	if cfg.Themes["light"] != presets["light"] {
+		t.Error("the light preset is not on offer as it ships")
+	}
+
+	// --theme picks another preset, the configured colours still on top.
+	if err := cfg.UsePreset("solarized-dark"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme.Background != presets["solarized-dark"].Background || cfg.Theme.Error != "#123456" {
+		t.Errorf("UsePreset did not lay the overrides over solarized-dark: %+v", cfg.Theme)
+	}
+	if cfg.Themes["mine"].Background != presets["solarized-dark"].Background {
+		t.Error("the named themes were not filled from the new preset")
+	}
+	if err := cfg.UsePreset("neon"); err == nil {
+		t.Error("an unknown preset was accepted")
+	}
+
+	t.Setenv("Y509_THEME_PRESET", "no-such-preset")
+	if cfg, _ = LoadConfig(); cfg.Preset != DefaultThemeName || cfg.Theme.Background != newDefaultTheme().Background {
+		t.Errorf("an unknown preset gave %q, want the default", cfg.Preset)
+	}
+}
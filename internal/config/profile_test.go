@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestLoadConfigProfile(t *testing.T) {
+	file := []byte(`expiry_warning_days: 30
+theme:
+  error: "#123456"
+flags:
+  timeout: 5s
+profiles:
+  work:
+    expiry_warning_days: 60
+    roots: /etc/work/ca.pem
+    theme:
+      preset: dracula
+    flags:
+      crit: 14d
+      cmd: [filter expired, select all]
+  homelab: {}
+`)
+	cfg := loadFrom(t, file)
+	if cfg.Profile != "" || cfg.ExpiryWarningDays != 30 || cfg.Roots != "" {
+		t.Errorf("no profile asked for, yet profile %q, %d days, roots %q", cfg.Profile, cfg.ExpiryWarningDays, cfg.Roots)
+	}
+	if !slices.Equal(cfg.ProfileNames, []string{"homelab", "work"}) {
+		t.Errorf("ProfileNames = %v", cfg.ProfileNames)
+	}
+
+	t.Setenv("Y509_PROFILE", "Work")
+	cfg = loadFrom(t, file)
+	if cfg.Profile != "work" || cfg.ExpiryWarningDays != 60 || cfg.Roots != "/etc/work/ca.pem" {
+		t.Errorf("profile %q, %d days, roots %q, want work's", cfg.Profile, cfg.ExpiryWarningDays, cfg.Roots)
+	}
+	// The profile's settings are laid over the file's, not in place of them.
+	if cfg.Theme.Background != presets["dracula"].Background || cfg.Theme.Error != "#123456" {
+		t.Errorf("theme %+v, want dracula under the file's error colour", cfg.Theme)
+	}
+	if cfg.Flags["timeout"] != "5s" || cfg.Flags["crit"] != "14d" || len(cfg.Flags["cmd"].([]any)) != 2 {
+		t.Errorf("flags = %v, want the file's and the profile's", cfg.Flags)
+	}
+
+	// --profile wins over the environment.
+	SetProfile("homelab")
+	t.Cleanup(func() { SetProfile("") })
+	if cfg = loadFrom(t, file); cfg.Profile != "homelab" || cfg.ExpiryWarningDays != 30 {
+		t.Errorf("profile %q, %d days, want homelab's", cfg.Profile, cfg.ExpiryWarningDays)
+	}
+
+	SetProfile("play")
+	cfg, err := LoadConfig()
+	if !errors.Is(err, ErrNoProfile) {
+		t.Errorf("an unknown profile gave %v", err)
+	}
+	if cfg.Profile != "" || cfg.ExpiryWarningDays != 30 {
+		t.Errorf("an unknown profile left profile %q, %d days", cfg.Profile, cfg.ExpiryWarningDays)
+	}
+}
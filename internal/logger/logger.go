@@ -2,9 +2,13 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/kanywst/y509/internal/xdg"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -14,25 +18,60 @@ var (
 	Log = zap.NewNop()
 )
 
-// Init initializes the logger with the specified configuration
-func Init(logFile string, debug bool) error {
-	if logFile == "" {
-		logFile = filepath.Join(os.TempDir(), "y509.log")
+// Levels are the levels Init takes, most verbose first.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// DefaultFile is where the log goes when no file is given: y509.log in the
+// state directory, ~/.local/state/y509 unless XDG_STATE_HOME says otherwise,
+// or in the temporary directory when there is no state directory to be had.
+func DefaultFile() string {
+	dir, err := xdg.StateDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "y509.log")
 	}
+	return filepath.Join(dir, "y509.log")
+}
 
-	config := zap.NewProductionConfig()
-	if debug {
-		config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+// Init initializes the logger to write JSON lines at level and above to
+// logFile, or to DefaultFile when it is empty. Stdout belongs to the TUI and
+// to command output, so the log never goes there; "stderr" is allowed. A
+// file is rotated at DefaultMaxSize, DefaultMaxBackups kept. Each entry
+// carries the process id, as runs side by side share the file.
+func Init(logFile, level string) error {
+	level = strings.ToLower(level)
+	if !slices.Contains(Levels, level) {
+		return fmt.Errorf("unknown log level %q (one of %s)", level, strings.Join(Levels, ", "))
+	}
+	if logFile == "stdout" || logFile == "/dev/stdout" {
+		return fmt.Errorf("the log cannot go to stdout; give a file, or stderr")
+	}
+	if logFile == "" {
+		logFile = DefaultFile()
+		// A home that cannot be written to should not stop y509 starting.
+		if err := os.MkdirAll(filepath.Dir(logFile), 0o700); err != nil {
+			logFile = filepath.Join(os.TempDir(), "y509.log")
+		}
 	}
-	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	config.OutputPaths = []string{logFile}
-	config.ErrorOutputPaths = []string{logFile}
 
-	var err error
-	Log, err = config.Build()
+	lvl, err := zapcore.ParseLevel(level)
 	if err != nil {
 		return err
 	}
+	var out zapcore.WriteSyncer
+	if logFile == "stderr" {
+		out = zapcore.Lock(os.Stderr)
+	} else {
+		file, err := openRotating(logFile, DefaultMaxSize, DefaultMaxBackups)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		out = file
+	}
 
+	encoder := zap.NewProductionEncoderConfig()
+	encoder.EncodeTime = zapcore.ISO8601TimeEncoder
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoder), out, lvl)
+	Log = zap.New(core, zap.ErrorOutput(out), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.Fields(zap.Int("pid", os.Getpid())))
 	return nil
 }
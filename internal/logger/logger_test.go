@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "y509.log")
+	for _, level := range append(Levels, "DEBUG", "Warn") {
+		if err := Init(path, level); err != nil {
+			t.Errorf("Init(%q) = %v", level, err)
+		}
+	}
+
+	// Only what the level lets through is written.
+	if err := Init(path, "warn"); err != nil {
+		t.Fatal(err)
+	}
+	Log.Info("below the level")
+	Log.Warn("at the level")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log := string(data); strings.Contains(log, "below the level") || !strings.Contains(log, "at the level") {
+		t.Errorf("the log at warn has:\n%s", log)
+	}
+
+	for _, level := range []string{"loud", "trace", ""} {
+		err := Init(path, level)
+		if err == nil || !strings.Contains(err.Error(), "debug, info, warn, error") {
+			t.Errorf("Init(%q) = %v, want the levels listed", level, err)
+		}
+	}
+}
+
+func TestInitRefusesStdout(t *testing.T) {
+	for _, file := range []string{"stdout", "/dev/stdout"} {
+		if err := Init(file, "info"); err == nil || !strings.Contains(err.Error(), "stdout") {
+			t.Errorf("Init(%q) = %v, want it refused", file, err)
+		}
+	}
+	if err := Init("stderr", "info"); err != nil {
+		t.Errorf("Init(stderr) = %v", err)
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+	want := filepath.Join(state, "y509", "y509.log")
+	if got := DefaultFile(); got != want {
+		t.Errorf("DefaultFile = %q, want %q", got, want)
+	}
+
+	// With no file given, Init makes the state directory and logs there.
+	if err := Init("", "info"); err != nil {
+		t.Fatal(err)
+	}
+	Log.Info("to the default file")
+	if data, err := os.ReadFile(want); err != nil || !strings.Contains(string(data), "to the default file") {
+		t.Errorf("%s = %q (%v)", want, data, err)
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	if got, want := DefaultFile(), filepath.Join(home, ".local", "state", "y509", "y509.log"); got != want {
+		t.Errorf("DefaultFile without XDG_STATE_HOME = %q, want %q", got, want)
+	}
+}
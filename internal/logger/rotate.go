@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The log file is rotated once a write would take it past DefaultMaxSize,
+// and DefaultMaxBackups older files are kept beside it, y509.log.1 the
+// newest. A session's worth of debug logging fits; a year of watch does not
+// fill the disk.
+const (
+	DefaultMaxSize    = 5 << 20
+	DefaultMaxBackups = 3
+)
+
+// rotatingFile is a log file that, when a write would take it past maxSize,
+// is renamed to path.1 -- path.1 to path.2 and so on, the oldest dropped --
+// and started afresh, as lumberjack does. Only a regular file is rotated;
+// /dev/stderr and named pipes are written to as they are.
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	regular    bool
+}
+
+// openRotating opens path to append to, creating it if need be.
+func openRotating(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the file at path for appending and notes its size.
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	r.file, r.size, r.regular = file, info.Size(), info.Mode().IsRegular()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file past maxSize.
+// An entry bigger than maxSize on its own still goes in, in a file of its
+// own.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.regular && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups up by one, dropping the oldest, moves the file
+// to path.1 and opens a new one.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	for i := r.maxBackups - 1; i > 0; i-- {
+		// A backup missing from the sequence is no reason to stop.
+		_ = os.Rename(backupName(r.path, i), backupName(r.path, i+1))
+	}
+	if r.maxBackups > 0 {
+		if err := os.Rename(r.path, backupName(r.path, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// backupName is the nth backup of path.
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// Sync flushes the file to disk.
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "y509.log")
+	r, err := openRotating(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// one and two fit in ten bytes, three does not and starts a file, four
+	// does not fit with it and starts another, which five fits in.
+	checkLog(t, path, "four\nfive\n", "three\n", "one\ntwo\n")
+
+	// Opened again, the file's size is counted: six does not fit, and the
+	// oldest backup is dropped.
+	r, err = openRotating(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("six\n")); err != nil {
+		t.Fatal(err)
+	}
+	checkLog(t, path, "six\n", "four\nfive\n", "three\n")
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Error("a third backup was kept")
+	}
+}
+
+// checkLog checks the log at path, then each backup in turn, has what is
+// wanted.
+func checkLog(t *testing.T, path string, want ...string) {
+	t.Helper()
+	for i, w := range want {
+		name := path
+		if i > 0 {
+			name = backupName(path, i)
+		}
+		if data, err := os.ReadFile(name); err != nil || string(data) != w {
+			t.Errorf("%s = %q (%v), want %q", filepath.Base(name), data, err, w)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "y509.log")
+	if err := Init(path, "info"); err != nil {
+		t.Fatal(err)
+	}
+	Log.Debug("not logged")
+	Log.Info("command started")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log := string(data); !strings.Contains(log, `"msg":"command started"`) || !strings.Contains(log, `"pid":`) || strings.Contains(log, "not logged") {
+		t.Errorf("the log has:\n%s", log)
+	}
+}
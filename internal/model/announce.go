@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// statusWords says in words what the status icon of a certificate shows
+// by its shape and colour: expired, expiring soon, or valid.
+func statusWords(info *certificate.Info, warnDays int) string {
+	switch info.ValidationStatus {
+	case certificate.StatusInvalidSignature:
+		return "invalid signature"
+	case certificate.StatusConstraintViolation:
+		return "constraint violation"
+	case certificate.StatusMismatchedIssuer:
+		return "issuer mismatch"
+	}
+	cert := info.Certificate
+	days := int(time.Until(cert.NotAfter).Hours() / 24)
+	switch {
+	case certificate.IsExpired(cert):
+		return "expired"
+	case days == 0:
+		return "expires today"
+	case certificate.IsExpiringSoonWithin(cert, warnDays):
+		return fmt.Sprintf("expires in %d days", days)
+	}
+	return fmt.Sprintf("valid for %d days", days)
+}
+
+// announcement is the status bar in screen reader mode: the state of the
+// TUI as plain sentences, the news first. The terminal cursor rests on it,
+// so a screen reader reads it out each time it changes.
+func (m Model) announcement() string {
+	var parts []string
+	if m.commandError != "" {
+		parts = append(parts, "Error: "+m.commandError)
+	}
+	if n := len(m.toasts); n > 0 {
+		parts = append(parts, m.toasts[n-1].text)
+	}
+
+	if idx := m.list.Index(); idx < len(m.certificates) {
+		info := m.certificates[idx]
+		parts = append(parts, fmt.Sprintf("Certificate %d of %d: %s, %s", idx+1, len(m.certificates),
+			orNone(info.Certificate.Subject.CommonName), statusWords(info, m.Config.ExpiryWarningDays)))
+		if m.focus == FocusRight {
+			parts = append(parts, m.tabs[m.activeTab]+" tab")
+		}
+	} else {
+		parts = append(parts, "No certificates")
+	}
+
+	if m.filterActive {
+		parts = append(parts, "Filtered by "+m.filterType)
+	}
+	if m.count > 0 {
+		parts = append(parts, fmt.Sprintf("Count %d", m.count))
+	}
+	if n := len(m.marked); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d marked", n))
+	}
+	if m.busy() {
+		parts = append(parts, "Busy "+m.busyStatus())
+	}
+	parts = append(parts, m.keys.Help.Help().Key+" for help")
+	return strings.Join(parts, ". ")
+}
+
+// renderAnnouncement renders the announcement as the status bar, in the
+// bar's style but without colour doing any of the telling.
+func (m Model) renderAnnouncement() string {
+	return m.Styles.StatusBar.Width(m.width).Render(truncateText(m.announcement(), max(1, m.width-2)))
+}
@@ -0,0 +1,80 @@
+package model
+
+import "strings"
+
+// asciiReplacer turns everything y509 draws outside ASCII into ASCII of the
+// same width, so layouts measured with the originals still line up: emoji
+// take two columns and get two characters, the rest one. The emoji form of
+// ⚠ is listed before the bare one so it is replaced whole.
+var asciiReplacer = strings.NewReplacer(
+	// Emoji, two columns wide.
+	"⚠️", "!!",
+	"✅", "OK",
+	"❌", "XX",
+	"🔐", "  ",
+	"📤", "=>",
+	"⏳", "..",
+
+	// Status markers.
+	"●", "*",
+	"▲", "!",
+	"⚠", "!",
+	"✖", "x",
+	"✗", "x",
+	"✓", "v",
+	"✔", "v",
+	"◆", "#",
+	"◈", "#",
+	"⏚", "=",
+	"≠", "~",
+
+	// Punctuation and arrows.
+	"•", "*",
+	"·", ".",
+	"…", ".",
+	"—", "-",
+	"–", "-",
+	"‹", "<",
+	"›", ">",
+	"←", "<",
+	"→", ">",
+	"►", ">",
+	"↑", "^",
+	"↓", "v",
+	"▼", "v",
+	"⏎", "<",
+
+	// Bars.
+	"█", "#",
+	"░", ".",
+
+	// Box drawing, rounded and thick borders included.
+	"─", "-",
+	"━", "-",
+	"│", "|",
+	"┃", "|",
+	"╭", "+",
+	"╮", "+",
+	"╰", "+",
+	"╯", "+",
+	"┌", "+",
+	"┐", "+",
+	"└", "+",
+	"┘", "+",
+	"┏", "+",
+	"┓", "+",
+	"┗", "+",
+	"┛", "+",
+	"├", "+",
+	"┤", "+",
+	"┬", "+",
+	"┴", "+",
+	"┼", "+",
+)
+
+// toASCII rewrites a rendered screen in plain ASCII. Text from the
+// certificates themselves, such as a subject in another script, is left
+// alone: it is what is being inspected.
+func toASCII(s string) string {
+	return asciiReplacer.Replace(s)
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// batchWidth and batchHeight are the screen RunBatch lays the details out
+// for, as if in a terminal of that size.
+const (
+	batchWidth  = 100
+	batchHeight = 40
+)
+
+// batchListCommands change which certificates the list holds, or which are
+// marked, so that RunBatch prints the list after them.
+var batchListCommands = []string{
+	"search", "filter", "back", "reset", "source", "src", "hide", "unhide", "select", "sel",
+}
+
+// batchRefused are the commands that only change how the TUI looks, or
+// that need someone at the keyboard, and so mean nothing without it.
+var batchRefused = []string{
+	"edit", "help", "h", "theme", "config", "columns", "cols", "wrap",
+}
+
+// SetStartupCommands has the TUI run lines as ':' commands as it opens,
+// in order, stopping at the first that fails.
+func (m *Model) SetStartupCommands(lines []string) {
+	m.startupCommands = lines
+}
+
+// runStartupCommands runs the commands given to SetStartupCommands, the
+// splash skipped so that what they show is on screen. A command that fails,
+// or that opens something over the list, stops the rest.
+func (m Model) runStartupCommands() (Model, tea.Cmd) {
+	lines := m.startupCommands
+	m.startupCommands = nil
+	m.viewMode = ViewNormal
+
+	var cmds []tea.Cmd
+	for _, line := range lines {
+		var cmd tea.Cmd
+		m, cmd = m.executeCommand(line)
+		cmds = append(cmds, cmd)
+		if m.commandError != "" {
+			m.commandError = strings.TrimSpace(line) + ": " + m.commandError
+			break
+		}
+		if m.viewMode != ViewNormal {
+			break
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// RunBatch runs lines as ':' commands against certs without the TUI, in
+// order, and writes to w what each shows: the tab it opens, the list as a
+// filter leaves it, a result or a notification, the value copy would have
+// put on the clipboard. It stops at the first command that fails, or that
+// would overwrite files, and returns its error; quit stops it without one.
+func RunBatch(certs []*certificate.Info, cfg *config.Config, lines []string, w io.Writer) error {
+	model, _ := NewModel(certs, cfg).Update(tea.WindowSizeMsg{Width: batchWidth, Height: batchHeight})
+	m := model.(Model)
+	m.viewMode = ViewNormal
+
+	for _, line := range lines {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), ":"))
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		name := strings.ToLower(fields[0])
+		var err error
+		switch {
+		case name == "quit" || name == "q":
+			return nil
+		case slices.Contains(batchRefused, name):
+			err = errors.New("it only works in the TUI")
+		case name == "copy" || name == "yank":
+			err = m.batchCopy(fields[1:], w)
+		default:
+			m, err = m.batchCommand(line, name, w)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", line, err)
+		}
+	}
+	return nil
+}
+
+// batchCommand runs one command line for RunBatch, from the list as if
+// whatever the last one opened had been closed, and writes out what it left
+// on screen.
+func (m Model) batchCommand(line, name string, w io.Writer) (Model, error) {
+	m.viewMode, m.popupType, m.popupMessage = ViewNormal, PopupNone, ""
+	m.pendingExport, m.commandError, m.toasts = nil, "", nil
+	m, cmd := m.executeCommand(line)
+
+	if m.commandError != "" {
+		return m, errors.New(m.commandError)
+	}
+	if name == "pipe" {
+		msg, ok := runBatchCmd(cmd).(pipedMsg)
+		if !ok || msg.pager {
+			return m, errors.New("a pager needs the TUI")
+		}
+		m = m.handlePiped(msg)
+	}
+
+	switch m.viewMode {
+	case ViewPopup:
+		message := strings.TrimSpace(ansi.Strip(m.popupMessage))
+		if m.popupType == PopupConfirm {
+			first, _, _ := strings.Cut(message, "\n")
+			return m, fmt.Errorf("%s; nothing was written", strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(first, "⚠")), ":"))
+		}
+		if failure, ok := strings.CutPrefix(message, "❌"); ok {
+			return m, errors.New(strings.TrimSpace(failure))
+		}
+		if _, err := fmt.Fprintln(w, message); err != nil {
+			return m, err
+		}
+	case ViewDiff:
+		if err := writeBatchText(w, m.renderDiffContent(batchWidth)); err != nil {
+			return m, err
+		}
+	case ViewPipe:
+		if _, err := io.WriteString(w, m.pipeOutput); err != nil {
+			return m, err
+		}
+		if m.pipeStatus != "" {
+			return m, errors.New(m.pipeStatus)
+		}
+	}
+	for _, t := range m.toasts {
+		if _, err := fmt.Fprintln(w, t.text); err != nil {
+			return m, err
+		}
+	}
+	if _, ok := tabCommands[name]; ok {
+		if err := writeBatchText(w, m.renderTabContent(m.viewport.Width())); err != nil {
+			return m, err
+		}
+	}
+	if slices.Contains(batchListCommands, name) {
+		return m, m.writeBatchList(w)
+	}
+	return m, nil
+}
+
+// runBatchCmd runs cmd, and what that returns if it is a batch of more,
+// until one returns a message other than a toast's timer.
+func runBatchCmd(cmd tea.Cmd) tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			if msg := runBatchCmd(cmd); msg != nil {
+				return msg
+			}
+		}
+		return nil
+	default:
+		return msg
+	}
+}
+
+// batchCopy writes what copy would put on the clipboard.
+func (m Model) batchCopy(args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: copy " + strings.Join(copyFields, "|"))
+	}
+	certs := m.selection()
+	if len(certs) == 0 {
+		return nil
+	}
+	_, value, err := copyValue(certs, strings.ToLower(args[0]))
+	if errors.Is(err, errUnknownCopyField) {
+		return errors.New("usage: copy " + strings.Join(copyFields, "|"))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, strings.TrimSuffix(value, "\n"))
+	return err
+}
+
+// writeBatchText writes rendered text without its styling, or the padding
+// that filled out the pane.
+func writeBatchText(w io.Writer, text string) error {
+	var b strings.Builder
+	lines := strings.Split(strings.TrimRight(ansi.Strip(text), "\n"), "\n")
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeBatchList writes the list as it stands, a certificate a line: '>'
+// at the selected one, its position, a star if it is marked, its name and
+// its expiry.
+func (m Model) writeBatchList(w io.Writer) error {
+	var rows strings.Builder
+	for i, info := range m.certificates {
+		cursor, mark := " ", " "
+		if i == m.list.Index() {
+			cursor = ">"
+		}
+		if slices.Contains(m.marked, info) {
+			mark = "*"
+		}
+		fmt.Fprintf(&rows, "%s%d%s\t%s\t%s\n", cursor, i+1, mark, orNone(info.Certificate.Subject.CommonName),
+			certificate.FormatDate(info.Certificate.NotAfter))
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := io.WriteString(tw, rows.String()); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
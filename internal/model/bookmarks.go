@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// isBookmarkLetter reports whether key names a bookmark: a single letter,
+// as vim's marks are.
+func isBookmarkLetter(key string) bool {
+	return len(key) == 1 && (key[0] >= 'a' && key[0] <= 'z' || key[0] >= 'A' && key[0] <= 'Z')
+}
+
+// The actions a bookmark letter finishes, as pendingKey holds them: the
+// keys that start them can be rebound.
+const (
+	pendingBookmark = "bookmark"
+	pendingJump     = "jump"
+)
+
+// handlePendingKey finishes a two-key sequence: m then a letter sets a
+// bookmark on the selected certificate, ' then a letter jumps to one. Any
+// other second key, Esc included, just cancels.
+func (m Model) handlePendingKey(pending, key string) Model {
+	if !isBookmarkLetter(key) {
+		return m
+	}
+	switch pending {
+	case pendingBookmark:
+		return m.setBookmark(key)
+	case pendingJump:
+		return m.jumpToBookmark(key)
+	}
+	return m
+}
+
+// setBookmark puts bookmark letter on the selected certificate, moving it
+// there if another certificate had it.
+func (m Model) setBookmark(letter string) Model {
+	if len(m.certificates) == 0 {
+		return m
+	}
+	bookmarks := maps.Clone(m.bookmarks)
+	if bookmarks == nil {
+		bookmarks = make(map[string]*certificate.Info)
+	}
+	bookmarks[letter] = m.certificates[m.list.Index()]
+	m.bookmarks = bookmarks
+	m.list.SetDelegate(m.newDelegate())
+	return m
+}
+
+// jumpToBookmark selects the certificate holding bookmark letter.
+func (m Model) jumpToBookmark(letter string) Model {
+	target, ok := m.bookmarks[letter]
+	if !ok {
+		m.commandError = fmt.Sprintf("no bookmark '%s", letter)
+		return m
+	}
+	if slices.ContainsFunc(m.hidden, func(h hiddenCert) bool { return h.info == target }) {
+		m.commandError = fmt.Sprintf("'%s is hidden; :unhide brings it back", letter)
+		return m
+	}
+	return m.selectCertificate(target)
+}
+
+// bookmarkLetters lists the bookmarks on a certificate, in order.
+func bookmarkLetters(bookmarks map[string]*certificate.Info, info *certificate.Info) string {
+	var letters []string
+	for letter, c := range bookmarks {
+		if c == info {
+			letters = append(letters, letter)
+		}
+	}
+	slices.Sort(letters)
+	return strings.Join(letters, "")
+}
+
+// isMarked reports whether a certificate is starred or bookmarked, which is
+// what the "marked" filter keeps.
+func (m Model) isMarked(info *certificate.Info) bool {
+	return slices.Contains(m.marked, info) || bookmarkLetters(m.bookmarks, info) != ""
+}
+
+// handleMarksCommand lists the bookmarks and how many certificates are
+// starred, like vim's :marks.
+func (m Model) handleMarksCommand() Model {
+	if len(m.bookmarks) == 0 && len(m.marked) == 0 {
+		m.commandError = "no marks: star with space, or bookmark with m<letter>"
+		return m
+	}
+	var lines []string
+	for _, letter := range slices.Sorted(maps.Keys(m.bookmarks)) {
+		lines = append(lines, fmt.Sprintf("'%s  %s", letter, columnText("cn", m.bookmarks[letter])))
+	}
+	if len(m.marked) > 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, fmt.Sprintf("%d starred", len(m.marked)))
+	}
+	lines = append(lines, "", ":filter marked shows only these")
+	m.popupMessage = strings.Join(lines, "\n")
+	m.viewMode = ViewPopup
+	m.popupType = PopupAlert
+	return m
+}
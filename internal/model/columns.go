@@ -0,0 +1,169 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// listColumn is a column of the certificate list. Fixed columns have a
+// width; the others share what is left of the pane. When the pane is too
+// narrow for everything, columns are dropped lowest priority first.
+type listColumn struct {
+	name     string
+	title    string
+	width    int // 0 for a column that takes a share of the free space
+	priority int // higher survives longer on a narrow pane
+}
+
+// listColumns are the columns on offer, in the order they are listed.
+var listColumns = []listColumn{
+	{name: "status", title: "", width: 4, priority: 4},
+	{name: "cn", title: "SUBJECT", priority: 5},
+	{name: "issuer", title: "ISSUER", priority: 2},
+	{name: "expiry", title: "EXPIRES", width: 14, priority: 3},
+	{name: "key", title: "KEY", width: 12, priority: 1},
+	{name: "source", title: "FILE", width: 16, priority: 0},
+}
+
+// minFlexWidth is the narrowest a shared-width column is allowed to get
+// before columns start being dropped.
+const minFlexWidth = 10
+
+// columnNames lists the names of every column on offer.
+func columnNames() []string {
+	names := make([]string, len(listColumns))
+	for i, c := range listColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// parseColumns checks a list of column names, as typed or configured,
+// separated by commas or spaces.
+func parseColumns(spec []string) ([]string, error) {
+	var names []string
+	for _, s := range spec {
+		for name := range strings.FieldsFuncSeq(strings.ToLower(s), func(r rune) bool { return r == ',' || r == ' ' }) {
+			if !slices.Contains(columnNames(), name) {
+				return nil, fmt.Errorf("unknown column %q (one of %s)", name, strings.Join(columnNames(), ", "))
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no columns given (one of %s)", strings.Join(columnNames(), ", "))
+	}
+	return names, nil
+}
+
+// columnLayout is a column as laid out in a pane of a given width.
+type columnLayout struct {
+	listColumn
+	width int
+}
+
+// layoutColumns fits the named columns into width. Columns that do not fit
+// are dropped lowest priority first, but the last one standing is always
+// kept, and shared-width columns split the space left by the fixed ones.
+func layoutColumns(names []string, width int) []columnLayout {
+	var cols []listColumn
+	for _, name := range names {
+		if i := slices.IndexFunc(listColumns, func(c listColumn) bool { return c.name == name }); i >= 0 {
+			cols = append(cols, listColumns[i])
+		}
+	}
+
+	needed := func(cols []listColumn) int {
+		total := 0
+		for _, c := range cols {
+			if c.width > 0 {
+				total += c.width
+			} else {
+				total += minFlexWidth
+			}
+		}
+		return total
+	}
+	for len(cols) > 1 && needed(cols) > width {
+		lowest := 0
+		for i, c := range cols {
+			if c.priority < cols[lowest].priority {
+				lowest = i
+			}
+		}
+		cols = slices.Delete(cols, lowest, lowest+1)
+	}
+
+	free, flex := width, 0
+	for _, c := range cols {
+		if c.width > 0 {
+			free -= c.width
+		} else {
+			flex++
+		}
+	}
+
+	layout := make([]columnLayout, len(cols))
+	share, extra := 0, 0
+	if flex > 0 {
+		share, extra = free/flex, free%flex
+	}
+	for i, c := range cols {
+		layout[i] = columnLayout{listColumn: c, width: min(c.width, width)}
+		if c.width == 0 {
+			// The first shared column takes the remainder of the split.
+			layout[i].width = max(1, share+extra)
+			extra = 0
+		}
+	}
+	return layout
+}
+
+// columnText is the plain text of a column for one certificate. Status and
+// expiry are drawn by the delegate itself, in colour.
+func columnText(name string, info *certificate.Info) string {
+	cert := info.Certificate
+	switch name {
+	case "cn":
+		if cert.Subject.CommonName == "" {
+			return "(no CN)"
+		}
+		return cert.Subject.CommonName
+	case "issuer":
+		if cert.Issuer.CommonName == "" {
+			return cert.Issuer.String()
+		}
+		return cert.Issuer.CommonName
+	case "key":
+		keyType, _ := certificate.KeyType(cert)
+		return keyType
+	case "source":
+		return sourceLabel(info.Source)
+	}
+	return ""
+}
+
+// handleColumnsCommand sets the list columns for the session, or without
+// arguments shows which are in use and which are on offer.
+func (m Model) handleColumnsCommand(args []string) Model {
+	if len(args) == 0 {
+		m.popupMessage = fmt.Sprintf("Columns: %s\n\nAvailable: %s\n\nSet them with :columns <name>,<name>...\nor columns: in %s",
+			strings.Join(m.columns, ", "), strings.Join(columnNames(), ", "), m.Config.ShortPath())
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m
+	}
+	columns, err := parseColumns(args)
+	if err != nil {
+		m.commandError = err.Error()
+		return m
+	}
+	m.columns = columns
+	m.list.SetDelegate(m.newDelegate())
+	return m
+}
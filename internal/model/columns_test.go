@@ -0,0 +1,83 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestParseColumns(t *testing.T) {
+	got, err := parseColumns([]string{"status,cn", "KEY", "cn"})
+	if err != nil || !slices.Equal(got, []string{"status", "cn", "key"}) {
+		t.Errorf("parseColumns = %v, %v", got, err)
+	}
+	if _, err := parseColumns([]string{"cn,serial"}); err == nil || !strings.Contains(err.Error(), `"serial"`) {
+		t.Errorf("an unknown column should be rejected, got %v", err)
+	}
+	if _, err := parseColumns(nil); err == nil {
+		t.Error("an empty column list should be rejected")
+	}
+}
+
+func TestLayoutColumns(t *testing.T) {
+	names := func(layout []columnLayout) []string {
+		var out []string
+		for _, c := range layout {
+			out = append(out, c.name)
+		}
+		return out
+	}
+	all := columnNames()
+
+	wide := layoutColumns(all, 120)
+	if !slices.Equal(names(wide), all) {
+		t.Errorf("a wide pane should show every column, got %v", names(wide))
+	}
+	total := 0
+	for _, c := range wide {
+		total += c.width
+	}
+	if total != 120 {
+		t.Errorf("columns fill %d of 120 cells", total)
+	}
+
+	// Dropped lowest priority first: file, then key, then issuer.
+	if got := names(layoutColumns(all, 40)); !slices.Equal(got, []string{"status", "cn", "issuer", "expiry"}) {
+		t.Errorf("a 40-cell pane kept %v", got)
+	}
+	if got := names(layoutColumns(all, 30)); !slices.Equal(got, []string{"status", "cn", "expiry"}) {
+		t.Errorf("a 30-cell pane kept %v", got)
+	}
+	if got := names(layoutColumns(all, 8)); !slices.Equal(got, []string{"cn"}) {
+		t.Errorf("the subject should be the last column standing, got %v", got)
+	}
+}
+
+func TestColumnsCommand(t *testing.T) {
+	certs := createTestCertificates(2)
+	certs[0].Source = "/tmp/bundle.pem"
+	m := *NewModel(certs, loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 160, Height: 40})
+	m.viewMode = ViewNormal
+
+	m = runCommand(t, m, "columns cn,key source")
+	if !slices.Equal(m.columns, []string{"cn", "key", "source"}) {
+		t.Fatalf("columns = %v", m.columns)
+	}
+	view := m.View().Content
+	for _, want := range []string{"KEY", "FILE", "RSA-2048", "bundle.pem"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("list missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "EXPIRES") {
+		t.Error("the expiry column should be gone")
+	}
+
+	m = runCommand(t, m, "columns serial")
+	if !strings.Contains(m.commandError, "unknown column") || len(m.columns) != 3 {
+		t.Errorf("commandError = %q, columns = %v", m.commandError, m.columns)
+	}
+}
@@ -2,19 +2,410 @@
 package model
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/atotto/clipboard"
+	"github.com/kanywst/y509/internal/config"
 	"github.com/kanywst/y509/internal/logger"
 	"github.com/kanywst/y509/pkg/certificate"
+	"go.uber.org/zap"
 )
 
+// commandNames are the commands offered by tab completion. Aliases still
+// run, but listing them would only crowd the menu.
+var commandNames = []string{
+	"overview", "subject", "issuer", "validity", "san", "key", "fingerprint", "extensions", "checks", "text", "raw",
+	"validate", "covers", "match", "search", "filter", "back", "reset", "source", "select", "diff", "copy", "export",
+	"columns", "marks", "hide", "unhide", "edit", "pipe", "wrap", "theme", "config", "help", "quit",
+}
+
+// tabCommands are the commands that show a tab of the details, with their
+// aliases, and the tab each shows.
+var tabCommands = map[string]string{
+	"overview":    "Overview",
+	"o":           "Overview",
+	"subject":     "Subject",
+	"s":           "Subject",
+	"issuer":      "Issuer",
+	"i":           "Issuer",
+	"validity":    "Validity",
+	"v":           "Validity",
+	"san":         "SANs",
+	"sans":        "SANs",
+	"key":         "Key",
+	"pubkey":      "Key",
+	"pk":          "Key",
+	"fingerprint": "Misc",
+	"fp":          "Misc",
+	"serial":      "Misc",
+	"extensions":  "Extensions",
+	"ext":         "Extensions",
+	"checks":      "Validation",
+	"validation":  "Validation",
+	"text":        "Text",
+	"raw":         "Raw",
+	"asn1":        "Raw",
+}
+
+// filterTypes are the arguments the filter command accepts as they are.
+// keysizeFilter and sigalgFilter take a value as well.
+var filterTypes = []string{"expired", "expiring", "valid", "self-signed", "marked", "rsa", "ecdsa", "ed25519"}
+
+// keysizeFilter is "keysize<2048" and the like: a comparison with the key
+// size in bits. sigalgFilter is "sigalg=sha1": part of the signature
+// algorithm's name.
+var (
+	keysizeFilter = regexp.MustCompile(`^keysize(<=|>=|<|>|=)(\d+)$`)
+	sigalgFilter  = regexp.MustCompile(`^sigalg=(.+)$`)
+)
+
+// patternFilters take a pattern after a space: "issuer Let's Encrypt",
+// matched whole with * standing for any run of characters, or
+// "san *.internal.example.com", matched against the SANs as a certificate
+// name is.
+var patternFilters = []string{"issuer", "san"}
+
+// filterForms are the filters offered by the filter popup, completion and
+// help: the plain ones and the start of those taking a value.
+var filterForms = append(slices.Concat(filterTypes, patternFilters), "keysize<", "sigalg=")
+
+// executeCommand runs a line typed at the ':' prompt. Errors are reported in
+// the status bar rather than a popup: a typo should not need dismissing.
+func (m Model) executeCommand(line string) (Model, tea.Cmd) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return m, nil
+	}
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+
+	if tab, ok := tabCommands[name]; ok {
+		return m.showTab(tab), nil
+	}
+
+	switch name {
+	case "validate", "val":
+		m.validateAt = time.Time{}
+		if len(args) > 0 {
+			if !strings.EqualFold(args[0], "at") || len(args) < 2 {
+				m.commandError = "usage: validate [at <date>]"
+				return m, nil
+			}
+			at, err := certificate.ParseVerifyTime(strings.Join(args[1:], " "))
+			if err != nil {
+				m.commandError = err.Error()
+				return m, nil
+			}
+			m.validateAt = at
+		}
+		return m.handleValidateCommand(), nil
+
+	case "covers":
+		if len(args) != 1 {
+			m.commandError = "usage: covers <hostname or IP>"
+			return m, nil
+		}
+		return m.handleCoversCommand(args[0]), nil
+
+	case "match":
+		if rest == "" {
+			m.commandError = "usage: match <keyfile>"
+			return m, nil
+		}
+		return m.handleMatchCommand(rest), nil
+
+	case "search":
+		return m.searchCertificates(rest), nil
+	case "filter":
+		return m.filterCertificates(rest), nil
+	case "back":
+		return m.popFilter(), nil
+	case "reset":
+		return m.resetView(), nil
+	case "source", "src":
+		if len(args) != 1 {
+			m.commandError = "usage: source <n|name|all>"
+			return m, nil
+		}
+		return m.handleSourceCommand(rest).checkChain()
+	case "select", "sel":
+		if len(args) != 1 {
+			m.commandError = "usage: select <n>|all|none"
+			return m, nil
+		}
+		return m.handleSelectCommand(strings.ToLower(args[0])), nil
+	case "diff":
+		return m.handleDiffCommand(args), nil
+	case "marks":
+		return m.handleMarksCommand(), nil
+	case "hide":
+		return m.hideSelected()
+	case "unhide":
+		return m.handleUnhideCommand()
+	case "edit":
+		return m.handleEditCommand()
+	case "pipe":
+		return m.handlePipeCommand(rest)
+	case "columns", "cols":
+		return m.handleColumnsCommand(args), nil
+	case "wrap":
+		if len(args) > 1 {
+			m.commandError = "usage: wrap [on|off]"
+			return m, nil
+		}
+		return m.handleWrapCommand(args)
+	case "theme":
+		return m.handleThemeCommand(args)
+	case "config":
+		if len(args) != 1 || !strings.EqualFold(args[0], "reload") {
+			m.commandError = "usage: config reload"
+			return m, nil
+		}
+		return m.reloadConfig()
+	case "copy", "yank":
+		if len(args) != 1 {
+			m.commandError = "usage: copy " + strings.Join(copyFields, "|")
+			return m, nil
+		}
+		return m.handleCopyCommand(strings.ToLower(args[0]))
+	case "export":
+		if rest == "" {
+			m.commandError = "usage: export [format] <file|dir> | export bundle [format] <file|dir> | export pubkey <file>"
+			return m, nil
+		}
+		// "export bundle <file>" writes the list as it stands, in its
+		// order; a file named bundle is still "export bundle" alone.
+		if file, ok := strings.CutPrefix(rest, "bundle "); ok && strings.TrimSpace(file) != "" {
+			format, target := cutExportFormat(strings.TrimSpace(file))
+			return m.exportCertificates(m.certificates, target, format, false)
+		}
+		if file, ok := strings.CutPrefix(rest, "pubkey "); ok && strings.TrimSpace(file) != "" {
+			return m.exportPublicKey(m.selection(), strings.TrimSpace(file), false)
+		}
+		if format, target := cutExportFormat(rest); format != "" {
+			return m.exportCertificates(m.selection(), target, format, false)
+		}
+		return m.handleExportCommand(rest, false)
+	case "help", "h":
+		return m.openHelp(), nil
+	case "quit", "q":
+		return m, tea.Quit
+	}
+
+	m.commandError = fmt.Sprintf("unknown command: %s", fields[0])
+	return m, nil
+}
+
+// completeCommand returns the candidates for the last word of a command
+// line, and the part of the line before that word. The first word completes
+// to a command; after it, filter types, copy fields, file tabs or paths,
+// depending on the command.
+func (m Model) completeCommand(line string) (prefix string, candidates []string) {
+	split := strings.LastIndex(line, " ") + 1
+	prefix, word := line[:split], line[split:]
+	fields := strings.Fields(prefix)
+	if len(fields) == 0 {
+		return prefix, matchingPrefix(commandNames, strings.ToLower(word))
+	}
+
+	name := strings.ToLower(fields[0])
+	switch name {
+	case "export", "match":
+		// The rest of the line is one path, spaces and all.
+		rest := strings.TrimLeft(line[strings.Index(line, fields[0])+len(fields[0]):], " ")
+		for _, keyword := range []string{"bundle ", "pubkey "} {
+			if file, ok := strings.CutPrefix(rest, keyword); ok && name == "export" {
+				rest = strings.TrimLeft(file, " ")
+				break
+			}
+		}
+		if format, file, ok := strings.Cut(rest, " "); ok && name == "export" && slices.Contains(certificate.ExportFormats, strings.ToLower(format)) {
+			rest = strings.TrimLeft(file, " ")
+		}
+		return line[:len(line)-len(rest)], completePath(rest)
+	}
+	if name == "columns" || name == "cols" {
+		// Every argument is a column, and so is each part of a comma list.
+		split = strings.LastIndexAny(line, " ,") + 1
+		return line[:split], matchingPrefix(columnNames(), strings.ToLower(line[split:]))
+	}
+	if len(fields) > 1 {
+		return prefix, nil
+	}
+
+	var options []string
+	switch name {
+	case "filter":
+		options = filterForms
+	case "copy", "yank":
+		options = copyFields
+	case "validate", "val":
+		options = []string{"at"}
+	case "wrap":
+		options = []string{"on", "off"}
+	case "select", "sel":
+		options = []string{"all", "none"}
+	case "theme":
+		options = append(m.Config.ThemeNames(), "save")
+	case "config":
+		options = []string{"reload"}
+	case "source", "src":
+		options = []string{"all"}
+		for _, source := range m.sources {
+			options = append(options, sourceLabel(source))
+		}
+	}
+	return prefix, matchingPrefix(options, strings.ToLower(word))
+}
+
+// matchingPrefix returns the options that start with prefix.
+func matchingPrefix(options []string, prefix string) []string {
+	var matches []string
+	for _, option := range options {
+		if strings.HasPrefix(option, prefix) {
+			matches = append(matches, option)
+		}
+	}
+	return matches
+}
+
+// completePath lists the files and directories that could complete a path,
+// directories with a trailing slash so that completion can carry on into
+// them. Dot files are only offered once a dot has been typed.
+func completePath(word string) []string {
+	dir, base := filepath.Split(word)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		matches = append(matches, dir+name)
+	}
+	return matches
+}
+
+// cycleCompletion is Tab (step 1) and Shift+Tab (step -1) on the command
+// line. A single candidate is accepted outright. With several, the first
+// press opens the menu and each one after moves through it, writing the
+// candidate into the line as it goes, like vim's wildmenu.
+func (m Model) cycleCompletion(step int) Model {
+	if m.completions == nil {
+		prefix, candidates := m.completeCommand(m.commandInput.Value())
+		switch len(candidates) {
+		case 0:
+			return m
+		case 1:
+			line := prefix + candidates[0]
+			if !strings.HasSuffix(line, string(filepath.Separator)) {
+				line += " "
+			}
+			m.commandInput.SetValue(line)
+			m.commandInput.CursorEnd()
+			return m
+		}
+		m.completions, m.completionPrefix, m.completionIndex = candidates, prefix, -1
+	}
+
+	n := len(m.completions)
+	switch {
+	case m.completionIndex < 0 && step < 0:
+		m.completionIndex = n - 1
+	case m.completionIndex < 0:
+		m.completionIndex = 0
+	default:
+		m.completionIndex = (m.completionIndex + step + n) % n
+	}
+	m.commandInput.SetValue(m.completionPrefix + m.completions[m.completionIndex])
+	m.commandInput.CursorEnd()
+	return m
+}
+
+// clearCompletion closes the completion menu.
+func (m Model) clearCompletion() Model {
+	m.completions = nil
+	m.completionPrefix = ""
+	m.completionIndex = -1
+	return m
+}
+
+// showTab focuses the details pane on the named tab.
+func (m Model) showTab(name string) Model {
+	for i, tab := range m.tabs {
+		if tab == name {
+			m.focus = FocusRight
+			return m.selectTab(i)
+		}
+	}
+	return m
+}
+
+// selectTab switches the details to tab i, wrapping around at either end,
+// scrolled back to the top.
+func (m Model) selectTab(i int) Model {
+	m.activeTab = (i + len(m.tabs)) % len(m.tabs)
+	m.viewport.SetYOffset(0)
+	m.detailRow = 0
+	return m.refreshViewportContent()
+}
+
+// handleWrapCommand switches the details between wrapping long lines and
+// cutting them at the pane's edge; alone, it flips between the two. The
+// choice holds for the rest of the session.
+func (m Model) handleWrapCommand(args []string) (Model, tea.Cmd) {
+	switch {
+	case len(args) == 0:
+		m.noWrap = !m.noWrap
+	case strings.EqualFold(args[0], "on"):
+		m.noWrap = false
+	case strings.EqualFold(args[0], "off"):
+		m.noWrap = true
+	default:
+		m.commandError = "usage: wrap [on|off]"
+		return m, nil
+	}
+	m = m.refreshViewportContent()
+	if m.noWrap {
+		return m.notify("Long lines are cut at the edge")
+	}
+	return m.notify("Long lines wrap")
+}
+
+// verifyOptions are the options every validation in the TUI starts from:
+// the configured expiry window and roots.
+func (m Model) verifyOptions() certificate.VerifyOptions {
+	return certificate.VerifyOptions{ExpiryWarningDays: m.Config.ExpiryWarningDays, ExtraRoots: m.trustRoots}
+}
+
 // handleValidateCommand verifies the chain the selected certificate sits in,
-// against the system trust store. It deliberately shares VerifyChain with the
+// against the system trust store and any configured roots. It deliberately shares VerifyChain with the
 // validate subcommand so that `v` and `y509 validate` can never disagree.
 func (m Model) handleValidateCommand() Model {
 	logger.Log.Debug("validating selected certificate")
@@ -35,7 +426,10 @@ func (m Model) handleValidateCommand() Model {
 		chain = append(chain, c.Certificate)
 	}
 
-	result, err := certificate.VerifyChain(chain, certificate.VerifyOptions{})
+	m.pendingIssuerURLs = nil
+	opts := m.verifyOptions()
+	opts.CurrentTime = m.validateAt
+	result, err := certificate.VerifyChain(chain, opts)
 	if err != nil {
 		m.popupMessage = fmt.Sprintf("❌  Could not verify\n\n%v", err)
 		m.viewMode = ViewPopup
@@ -44,12 +438,19 @@ func (m Model) handleValidateCommand() Model {
 	}
 
 	var sb strings.Builder
+	if !m.validateAt.IsZero() {
+		fmt.Fprintf(&sb, "As of %s\n\n", certificate.FormatTime(m.validateAt))
+	}
 	switch result.Level {
 	case certificate.TrustAnchored:
 		sb.WriteString("✅  Certificate is TRUSTED\n\n")
 		fmt.Fprintf(&sb, "Subject: %s\n", leaf.Subject.CommonName)
 		fmt.Fprintf(&sb, "Issuer:  %s\n", leaf.Issuer.CommonName)
-		fmt.Fprintf(&sb, "Anchor:  %s (system trust store)", result.Anchor)
+		store := "system trust store"
+		if len(m.trustRoots) > 0 {
+			store = "system trust store or configured roots"
+		}
+		fmt.Fprintf(&sb, "Anchor:  %s (%s)", result.Anchor, store)
 
 	case certificate.TrustSelfAnchored:
 		sb.WriteString("⚠️   Certificate is SELF-ANCHORED\n\n")
@@ -63,6 +464,25 @@ func (m Model) handleValidateCommand() Model {
 		fmt.Fprintf(&sb, "Subject: %s\n", leaf.Subject.CommonName)
 		fmt.Fprintf(&sb, "Issuer:  %s\n\n", leaf.Issuer.CommonName)
 		fmt.Fprintf(&sb, "%v", result.Err)
+		if missing := result.MissingIssuer; missing != nil && len(missing.URLs) > 0 {
+			m.pendingIssuerURLs = missing.URLs
+			fmt.Fprintf(&sb, "\n\nIt is published at:\n  %s\n\n", strings.Join(missing.URLs, "\n  "))
+			sb.WriteString("Press f to fetch it via AIA.")
+		}
+	}
+
+	if len(result.Warnings) > 0 {
+		sb.WriteString("\n\nWarnings:")
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(&sb, "\n  • %s", warning)
+		}
+	}
+
+	if paths, err := certificate.EnumeratePaths(chain, opts); err == nil {
+		if formatted := certificate.FormatTrustPaths(paths); formatted != "" {
+			sb.WriteString("\n\n")
+			sb.WriteString(formatted)
+		}
 	}
 
 	m.popupMessage = sb.String()
@@ -71,81 +491,239 @@ func (m Model) handleValidateCommand() Model {
 	return m
 }
 
+// handleCoversCommand answers "is this name covered?" for every loaded
+// certificate, not just the selected one: the certificate that covers the
+// name is often not the one being looked at.
+func (m Model) handleCoversCommand(host string) Model {
+	var covered, missed []string
+	for _, c := range m.allCertificates {
+		name := c.Certificate.Subject.CommonName
+		if name == "" {
+			name = c.Label
+		}
+		switch match := certificate.CoversName(c.Certificate, host); {
+		case match.Covered():
+			covered = append(covered, fmt.Sprintf("✅  %s\n      %s match on %s", name, match.Kind, match.Entry))
+		case !c.Certificate.IsCA:
+			// CAs are not expected to cover anything; listing them as
+			// misses would only bury the answer.
+			missed = append(missed, "❌  "+name)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Coverage of %s\n\n", host)
+	switch {
+	case len(covered)+len(missed) == 0:
+		sb.WriteString("No end-entity certificates are loaded.")
+	case len(covered) == 0:
+		sb.WriteString("No loaded certificate covers this name.\n\n")
+		sb.WriteString(strings.Join(missed, "\n"))
+	default:
+		sb.WriteString(strings.Join(append(covered, missed...), "\n"))
+	}
+
+	m.popupMessage = sb.String()
+	m.viewMode = ViewPopup
+	m.popupType = PopupAlert
+	return m
+}
+
+// handleMatchCommand checks a private key file against the selected
+// certificate. On a mismatch it says which loaded certificate the key does
+// belong to, if any: the usual mistake is picking the wrong line.
+func (m Model) handleMatchCommand(keyFile string) Model {
+	if len(m.certificates) == 0 {
+		return m
+	}
+	key, err := certificate.LoadPrivateKey(keyFile)
+	if err != nil {
+		m.commandError = err.Error()
+		return m
+	}
+
+	selected := m.certificates[m.list.Index()].Certificate
+	kind := certificate.DescribePrivateKey(key)
+	var sb strings.Builder
+	if certificate.KeyMatches(selected, key) {
+		fmt.Fprintf(&sb, "✅  Key matches\n\nThe %s key in %s belongs to\n'%s'.", kind, keyFile, selected.Subject.CommonName)
+	} else {
+		fmt.Fprintf(&sb, "❌  Key does not match\n\nThe %s key in %s does not belong to\n'%s'.", kind, keyFile, selected.Subject.CommonName)
+		for _, c := range m.allCertificates {
+			if certificate.KeyMatches(c.Certificate, key) {
+				fmt.Fprintf(&sb, "\n\nIt matches '%s' instead.", c.Certificate.Subject.CommonName)
+				break
+			}
+		}
+	}
+
+	m.popupMessage = sb.String()
+	m.viewMode = ViewPopup
+	m.popupType = PopupAlert
+	return m
+}
+
+// issuerFetchedMsg carries the result of an AIA fetch started from the
+// validation popup.
+type issuerFetchedMsg struct {
+	ctx   context.Context
+	url   string
+	certs []*x509.Certificate
+	err   error
+}
+
+// fetchIssuerCmd downloads a missing issuer in the background, trying each
+// URL in turn until ctx is cancelled. Network I/O never runs on the update
+// loop.
+func fetchIssuerCmd(ctx context.Context, urls []string) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		for _, url := range urls {
+			var certs []*x509.Certificate
+			if certs, err = certificate.FetchIssuer(ctx, url); err == nil {
+				return issuerFetchedMsg{ctx: ctx, url: url, certs: certs}
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return issuerFetchedMsg{ctx: ctx, err: err}
+	}
+}
+
+// handleIssuerFetched adds a fetched issuer to the loaded certificates and
+// validates the selected certificate again, so the popup shows the outcome.
+// A fetch cancelled with esc is dropped.
+func (m Model) handleIssuerFetched(msg issuerFetchedMsg) Model {
+	if cancelled(msg.ctx) {
+		return m
+	}
+	m.fetchCancel = nil
+	if msg.err != nil {
+		m.popupMessage = fmt.Sprintf("❌  Could not fetch issuer\n\n%v", msg.err)
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m
+	}
+
+	var selected *x509.Certificate
+	var source string
+	if len(m.certificates) > 0 {
+		selected = m.certificates[m.list.Index()].Certificate
+		source = m.certificates[m.list.Index()].Source
+	}
+
+	// The issuer joins the bundle of the certificate it completes.
+	merged := m.allCertificates
+	for _, cert := range msg.certs {
+		if !slices.ContainsFunc(merged, func(c *certificate.Info) bool { return c.Certificate.Equal(cert) }) {
+			merged = append(merged, &certificate.Info{Certificate: cert, Index: len(merged), Source: source})
+		}
+	}
+	m.allCertificates = sortBySource(merged)
+	m = m.resetView()
+
+	for i, c := range m.certificates {
+		if selected != nil && c.Certificate.Equal(selected) {
+			m.list.Select(i)
+			break
+		}
+	}
+	m = m.refreshViewportContent()
+
+	logger.Log.Info("fetched missing issuer", zap.String("url", msg.url), zap.Int("certificates", len(msg.certs)))
+	return m.handleValidateCommand()
+}
+
 // searchCertificates searches certificates based on query
 func (m Model) searchCertificates(query string) Model {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return m.resetView()
 	}
+	// In "/re:^api-\d+\./" the regular expression is between the slashes.
+	if pattern, ok := strings.CutPrefix(query, "/"+regexPrefix); ok && len(pattern) > 0 && strings.HasSuffix(pattern, "/") {
+		query = regexPrefix + strings.TrimSuffix(pattern, "/")
+	}
+	if _, err := searchRegexp(query); err != nil {
+		m.commandError = err.Error()
+		return m
+	}
 
-	m.searchQuery = query
-	m.filterActive = true
-	m.filterType = fmt.Sprintf("search: %s", query)
-
-	return m.applyFilter()
+	return m.pushFilter(fmt.Sprintf("search: %s", query))
 }
 
 // filterCertificates filters certificates based on criteria
 func (m Model) filterCertificates(filterType string) Model {
 	filterType = strings.ToLower(strings.TrimSpace(filterType))
+	if name, pattern, ok := strings.Cut(filterType, " "); ok && slices.Contains(patternFilters, name) {
+		return m.pushFilter(name + " " + strings.TrimSpace(pattern))
+	}
+	if slices.Contains(patternFilters, filterType) {
+		m.commandError = fmt.Sprintf("usage: filter %s <pattern>", filterType)
+		return m
+	}
+
+	// "keysize < 2048" is read as keysize<2048.
+	filterType = strings.Join(strings.Fields(filterType), "")
 	if filterType == "" {
 		return m.resetView()
 	}
 
-	validFilters := []string{"expired", "expiring", "valid", "self-signed"}
-	found := false
-	for _, f := range validFilters {
-		if f == filterType {
-			found = true
-			break
-		}
-	}
+	found := slices.Contains(filterTypes, filterType) ||
+		keysizeFilter.MatchString(filterType) || sigalgFilter.MatchString(filterType)
 
 	if !found {
-		m.popupMessage = fmt.Sprintf("❌ Invalid filter type: %s\n\nValid filters are:\n- expired\n- expiring\n- valid\n- self-signed", filterType)
+		m.popupMessage = fmt.Sprintf("❌ Invalid filter type: %s\n\nValid filters are:\n- %s\n- keysize<n, <=n, >n, >=n or =n\n- sigalg=<name>, e.g. sigalg=sha1\n- issuer <pattern>\n- san <name, *.zone, IP or CIDR>", filterType, strings.Join(filterTypes, "\n- "))
 		m.viewMode = ViewPopup
 		m.popupType = PopupAlert
 		return m
 	}
 
-	m.filterActive = true
-	m.filterType = filterType
+	return m.pushFilter(filterType)
+}
 
+// pushFilter stacks a filter, or a "search: <query>", on those already
+// narrowing the list. A search straight after a search takes its place,
+// as a search typed afresh always has; one already in the stack is not
+// stacked twice.
+func (m Model) pushFilter(filter string) Model {
+	filters := slices.Clip(m.filters)
+	if n := len(filters); n > 0 && strings.HasPrefix(filter, "search: ") && strings.HasPrefix(filters[n-1], "search: ") {
+		filters = filters[:n-1]
+	}
+	if !slices.Contains(filters, filter) {
+		filters = append(filters, filter)
+	}
+	m.filters = filters
 	return m.applyFilter()
 }
 
-// applyFilter applies the active filter/search to the certificate list
+// applyFilter narrows the certificate list to those passing every filter
+// and search in the stack. Searches rank what they match; the list is in
+// the order of the last one.
 func (m Model) applyFilter() Model {
-	var filtered []*certificate.Info
-	query := strings.ToLower(m.searchQuery)
+	m.filterActive = len(m.filters) > 0
+	m.filterType = strings.Join(m.filters, " › ")
+	m.searchQuery = ""
+	for _, filter := range m.filters {
+		if query, ok := strings.CutPrefix(filter, "search: "); ok {
+			m.searchQuery = query
+		}
+	}
 
-	for _, certInfo := range m.allCertificates {
-		match := false
-		if strings.HasPrefix(m.filterType, "search:") {
-			if matchSearch(certInfo.Certificate, query) {
-				match = true
+	var filtered []*certificate.Info
+	scores := make(map[*certificate.Info]int)
+	for _, certInfo := range m.sourceCertificates() {
+		match := true
+		for _, filter := range m.filters {
+			score, ok := m.matchFilter(certInfo, filter)
+			if !ok {
+				match = false
+				break
 			}
-		} else {
-			switch m.filterType {
-			case "expired":
-				if certificate.IsExpired(certInfo.Certificate) {
-					match = true
-				}
-			case "expiring":
-				if !certificate.IsExpired(certInfo.Certificate) && certificate.IsExpiringSoonWithin(certInfo.Certificate, m.Config.ExpiryWarningDays) {
-					match = true
-				}
-			case "valid":
-				if !certificate.IsExpired(certInfo.Certificate) {
-					match = true
-				}
-			case "self-signed":
-				if certInfo.Certificate.Issuer.String() == certInfo.Certificate.Subject.String() {
-					// Verify that the certificate is actually self-signed.
-					if err := certInfo.Certificate.CheckSignature(certInfo.Certificate.SignatureAlgorithm, certInfo.Certificate.RawTBSCertificate, certInfo.Certificate.Signature); err == nil {
-						match = true
-					}
-				}
+			if strings.HasPrefix(filter, "search: ") {
+				scores[certInfo] = score
 			}
 		}
 
@@ -153,8 +731,13 @@ func (m Model) applyFilter() Model {
 			filtered = append(filtered, certInfo)
 		}
 	}
+	// Best match first; ties, and every exact match, keep chain order.
+	slices.SortStableFunc(filtered, func(a, b *certificate.Info) int {
+		return cmp.Compare(scores[b], scores[a])
+	})
 
 	m.certificates = filtered
+	m.list.SetDelegate(m.newDelegate())
 	m.list.SetItems(toListItems(filtered))
 	m.list.Select(0)
 	m.viewMode = ViewNormal
@@ -162,52 +745,133 @@ func (m Model) applyFilter() Model {
 	return m
 }
 
-// matchSearch checks if certificate matches search query
-func matchSearch(cert *x509.Certificate, query string) bool {
-	// Search in Subject fields
-	if strings.Contains(strings.ToLower(cert.Subject.CommonName), query) {
-		return true
+// matchFilter reports whether a certificate passes one filter of the stack,
+// with its score when the filter is a search.
+func (m Model) matchFilter(certInfo *certificate.Info, filter string) (int, bool) {
+	if query, ok := strings.CutPrefix(filter, "search: "); ok {
+		return m.searchScore(certInfo.Certificate, query)
 	}
-	for _, org := range cert.Subject.Organization {
-		if strings.Contains(strings.ToLower(org), query) {
-			return true
-		}
+	cert := certInfo.Certificate
+	switch filter {
+	case "expired":
+		return 0, certificate.IsExpired(cert)
+	case "expiring":
+		return 0, !certificate.IsExpired(cert) && certificate.IsExpiringSoonWithin(cert, m.Config.ExpiryWarningDays)
+	case "valid":
+		return 0, !certificate.IsExpired(cert)
+	case "self-signed":
+		return 0, certificate.IsSelfSigned(cert)
+	case "marked":
+		return 0, m.isMarked(certInfo)
+	case "rsa":
+		return 0, cert.PublicKeyAlgorithm == x509.RSA
+	case "ecdsa":
+		return 0, cert.PublicKeyAlgorithm == x509.ECDSA
+	case "ed25519":
+		return 0, cert.PublicKeyAlgorithm == x509.Ed25519
 	}
-	for _, ou := range cert.Subject.OrganizationalUnit {
-		if strings.Contains(strings.ToLower(ou), query) {
-			return true
-		}
+	if pattern, ok := strings.CutPrefix(filter, "issuer "); ok {
+		return 0, slices.ContainsFunc(append([]string{cert.Issuer.CommonName}, cert.Issuer.Organization...), func(name string) bool {
+			return globMatch(pattern, name)
+		})
+	}
+	if pattern, ok := strings.CutPrefix(filter, "san "); ok {
+		return 0, len(certificate.SANsMatching(cert, pattern)) > 0
+	}
+	if match := sigalgFilter.FindStringSubmatch(filter); match != nil {
+		return 0, strings.Contains(strings.ToLower(cert.SignatureAlgorithm.String()), match[1])
 	}
+	if match := keysizeFilter.FindStringSubmatch(filter); match != nil {
+		return 0, compareKeySize(cert, match[1], match[2])
+	}
+	return 0, false
+}
 
-	// Search in Issuer fields
-	if strings.Contains(strings.ToLower(cert.Issuer.CommonName), query) {
-		return true
+// globMatch reports whether value, ignoring case, is the whole of pattern,
+// in which * stands for any run of characters.
+func globMatch(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
 	}
-	for _, org := range cert.Issuer.Organization {
-		if strings.Contains(strings.ToLower(org), query) {
-			return true
-		}
+	re, err := regexp.Compile("(?i)^" + strings.Join(parts, ".*") + "$")
+	return err == nil && re.MatchString(value)
+}
+
+// compareKeySize applies a keysize filter's comparison to a certificate's
+// key. Ed25519 keys, and others whose size is fixed by the algorithm,
+// have no size to compare and match none.
+func compareKeySize(cert *x509.Certificate, op, value string) bool {
+	_, bits := certificate.KeyType(cert)
+	want, err := strconv.Atoi(value)
+	if bits == 0 || err != nil {
+		return false
 	}
-	for _, ou := range cert.Issuer.OrganizationalUnit {
-		if strings.Contains(strings.ToLower(ou), query) {
-			return true
-		}
+	switch op {
+	case "<":
+		return bits < want
+	case "<=":
+		return bits <= want
+	case ">":
+		return bits > want
+	case ">=":
+		return bits >= want
+	default:
+		return bits == want
 	}
+}
 
-	// Search in DNS Names (SANs)
-	for _, dns := range cert.DNSNames {
-		if strings.Contains(strings.ToLower(dns), query) {
-			return true
+// searchScore matches a certificate against a search query: fuzzily,
+// scoring the best of its fields, or in exact mode by substring. A regular
+// expression matches when it finds itself in any field, and scores nothing.
+func (m Model) searchScore(cert *x509.Certificate, query string) (int, bool) {
+	if re, _ := searchRegexp(query); re != nil {
+		return 0, slices.ContainsFunc(searchFields(cert), re.MatchString)
+	}
+	query = strings.ToLower(query)
+	best, found := 0, false
+	for _, field := range searchFields(cert) {
+		if m.Config.SearchMode == config.SearchExact {
+			if strings.Contains(strings.ToLower(field), query) {
+				return 0, true
+			}
+			continue
+		}
+		if score, _, ok := fuzzyMatch(query, field); ok && (!found || score > best) {
+			best, found = score, true
 		}
 	}
-	return false
+	return best, found
+}
+
+// searchFields are the values search looks at: the subject and issuer
+// names, every SAN, and the serial in decimal and in hex.
+func searchFields(cert *x509.Certificate) []string {
+	fields := []string{cert.Subject.CommonName, cert.Issuer.CommonName}
+	for _, name := range []pkix.Name{cert.Subject, cert.Issuer} {
+		fields = append(fields, name.Organization...)
+		fields = append(fields, name.OrganizationalUnit...)
+	}
+	fields = append(fields, cert.DNSNames...)
+	fields = append(fields, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		fields = append(fields, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		fields = append(fields, uri.String())
+	}
+	if cert.SerialNumber != nil {
+		fields = append(fields, cert.SerialNumber.String(), cert.SerialNumber.Text(16))
+	}
+	return fields
 }
 
 // resetView restores the full list of certificates and clears filters
 func (m Model) resetView() Model {
 	m = m.resetAllFields()
-	m.certificates = m.allCertificates
-	m.list.SetItems(toListItems(m.allCertificates))
+	m.certificates = m.sourceCertificates()
+	m.list.SetDelegate(m.newDelegate())
+	m.list.SetItems(toListItems(m.certificates))
 	m.list.Select(0)
 	m = m.refreshViewportContent()
 	return m
@@ -218,6 +882,7 @@ func (m Model) resetAllFields() Model {
 	m.viewMode = ViewNormal
 	m.detailField = ""
 	m.detailValue = ""
+	m.filters = nil
 	m.searchQuery = ""
 	m.filterActive = false
 	m.filterType = ""
@@ -225,32 +890,103 @@ func (m Model) resetAllFields() Model {
 	return m
 }
 
-// handleYankCommand encodes the selected certificate as PEM and ships it
-// to the system clipboard via OSC52, then opens an alert popup so the
-// user knows the copy succeeded (or why it didn't).
-func (m Model) handleYankCommand() (Model, tea.Cmd) {
-	if len(m.certificates) == 0 {
+// copyFields are what :copy can put on the clipboard, by the name typed.
+var copyFields = []string{"pem", "fingerprint", "serial", "subject", "notbefore", "notafter"}
+
+// handleCopyCommand puts one field of the selected certificate, or of each
+// marked one, on the clipboard, with a toast to say so, or a popup saying
+// why it couldn't. The values are the ones the details pane shows, one line
+// per certificate; PEM blocks are concatenated into a bundle.
+func (m Model) handleCopyCommand(field string) (Model, tea.Cmd) {
+	certs := m.selection()
+	if len(certs) == 0 {
 		return m, nil
 	}
-	cert := m.certificates[m.list.Index()].Certificate
-	pemBytes := pem.EncodeToMemory(&pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: cert.Raw,
-	})
-	if pemBytes == nil {
-		m.popupMessage = "❌ Failed to encode certificate as PEM"
+	label, value, err := copyValue(certs, field)
+	switch {
+	case errors.Is(err, errUnknownCopyField):
+		m.commandError = "usage: copy " + strings.Join(copyFields, "|")
+		return m, nil
+	case err != nil:
+		m.popupMessage = "❌ " + err.Error()
 		m.viewMode = ViewPopup
 		m.popupType = PopupAlert
 		return m, nil
 	}
-	m.popupMessage = fmt.Sprintf("✅ Copied PEM to clipboard\n\nSubject: %s\nBytes:   %d", cert.Subject.CommonName, len(pemBytes))
-	m.viewMode = ViewPopup
-	m.popupType = PopupAlert
-	return m, tea.SetClipboard(string(pemBytes))
+	m, toastCmd := m.notify("Copied %s", label)
+	return m, tea.Batch(copyToClipboard(value), toastCmd)
+}
+
+// errUnknownCopyField is copyValue's error for a field not in copyFields.
+var errUnknownCopyField = errors.New("unknown field to copy")
+
+// copyValue is one field of each of certs, a line each, or their PEM
+// blocks as a bundle, and what to call it.
+func copyValue(certs []*certificate.Info, field string) (label, value string, err error) {
+	var values []string
+	for _, info := range certs {
+		cert := info.Certificate
+		var value string
+		switch field {
+		case "pem":
+			pemBytes := pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: cert.Raw,
+			})
+			if pemBytes == nil {
+				return "", "", errors.New("Failed to encode certificate as PEM")
+			}
+			label, value = "PEM", strings.TrimSuffix(string(pemBytes), "\n")
+		case "fingerprint", "fp":
+			label, value = "SHA-256 fingerprint", certificate.FormatFingerprint(cert)
+		case "serial":
+			label, value = "serial number", cert.SerialNumber.String()
+		case "subject":
+			label, value = "subject", cert.Subject.String()
+		case "notbefore":
+			label, value = "start of validity", certificate.FormatTime(cert.NotBefore)
+		case "notafter":
+			label, value = "end of validity", certificate.FormatTime(cert.NotAfter)
+		default:
+			return "", "", errUnknownCopyField
+		}
+		values = append(values, value)
+	}
+
+	value = strings.Join(values, "\n")
+	if field == "pem" {
+		value += "\n"
+	}
+	if len(certs) > 1 {
+		label = fmt.Sprintf("%s of %d certificates", label, len(certs))
+	}
+	return label, value, nil
+}
+
+// copyToClipboard writes to the system clipboard, falling back to OSC52 when
+// there is none to reach -- no xclip on a bare Linux box, say. Over SSH the
+// system clipboard would be the server's, so OSC52 goes straight to the
+// terminal the user is actually looking at.
+func copyToClipboard(text string) tea.Cmd {
+	if os.Getenv("SSH_TTY") != "" {
+		return tea.SetClipboard(text)
+	}
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err != nil {
+			logger.Log.Debug("system clipboard unavailable, using OSC52", zap.Error(err))
+			return tea.SetClipboard(text)()
+		}
+		return nil
+	}
 }
 
-// handleExportCommand handles the export of the current certificate
-func (m Model) handleExportCommand(filename string) Model {
+// handleExportCommand exports the selected certificate, or the marked ones.
+// Several go into the file as a bundle, in the order they were marked; to a
+// directory, each is written to a file of its own. Success closes the
+// export popup and says so in a toast; a failure is reported in a popup.
+// Files already there are only replaced with overwrite set, or once the
+// user says they may be.
+func (m Model) handleExportCommand(filename string, overwrite bool) (Model, tea.Cmd) {
 	filename = strings.TrimSpace(filename)
 	if filename == "" {
 		// Defensive: the export form's required-validator should prevent
@@ -261,27 +997,123 @@ func (m Model) handleExportCommand(filename string) Model {
 			m.viewMode = ViewNormal
 			m.popupType = PopupNone
 		}
-		return m
+		return m, nil
 	}
 
-	if len(m.certificates) == 0 {
+	return m.exportCertificates(m.selection(), filename, "", overwrite)
+}
+
+// cutExportFormat splits a leading format off an export target, so that
+// "der out/" is DER files in out/. A file named for a format, given alone,
+// is still a file.
+func cutExportFormat(rest string) (format, target string) {
+	word, target, ok := strings.Cut(rest, " ")
+	if ok && strings.TrimSpace(target) != "" && slices.Contains(certificate.ExportFormats, strings.ToLower(word)) {
+		return strings.ToLower(word), strings.TrimSpace(target)
+	}
+	return "", rest
+}
+
+// exportPublicKey writes the public key of the one certificate in certs, as
+// a PEM PUBLIC KEY block, or DER for a .der file. Like exportCertificates,
+// it asks before replacing a file unless overwrite is set.
+func (m Model) exportPublicKey(certs []*certificate.Info, filename string, overwrite bool) (Model, tea.Cmd) {
+	if len(certs) != 1 {
+		m.popupMessage = fmt.Sprintf("❌ export pubkey takes one certificate, not the %d marked", len(certs))
+		if len(certs) == 0 {
+			m.popupMessage = "❌ No certificate selected to export"
+		}
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m, nil
+	}
+	if _, err := os.Stat(filename); err == nil && !overwrite {
+		m.pendingExport = &pendingExport{certs: certs, filename: filename, pubkey: true}
+		m.popupMessage = overwriteQuestion([]string{filename}, 1)
+		m.viewMode = ViewPopup
+		m.popupType = PopupConfirm
+		return m, nil
+	}
+
+	format := "pem"
+	if strings.EqualFold(filepath.Ext(filename), ".der") {
+		format = "der"
+	}
+	data, err := certificate.EncodePublicKey(certs[0].Certificate, format)
+	if err == nil {
+		if dir := filepath.Dir(filename); dir != "." {
+			err = os.MkdirAll(dir, 0o755)
+		}
+	}
+	if err == nil {
+		err = os.WriteFile(filename, data, 0o644)
+	}
+	if err != nil {
+		m.popupMessage = fmt.Sprintf("❌ Export failed: %v", err)
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m, nil
+	}
+
+	m.viewMode = ViewNormal
+	m.popupType = PopupNone
+	return m.notify("Exported the public key of %s to %s", orNone(certs[0].Certificate.Subject.CommonName), filename)
+}
+
+// exportCertificates writes certificates to a file as a bundle, in order,
+// or to a directory a file each, in format: for a file, empty goes by its
+// extension, and for a directory means PEM. Unless overwrite is set, it
+// asks first when that would replace files already there.
+func (m Model) exportCertificates(certs []*certificate.Info, filename, format string, overwrite bool) (Model, tea.Cmd) {
+	if len(certs) == 0 {
 		m.popupMessage = "❌ No certificate selected to export"
 		m.viewMode = ViewPopup
 		m.popupType = PopupAlert
-		return m
+		return m, nil
+	}
+	if existing := exportClobbers(certs, filename, format); len(existing) > 0 && !overwrite {
+		total := 1
+		if isExportDir(filename) {
+			total = len(certs)
+		}
+		m.pendingExport = &pendingExport{certs: certs, filename: filename, format: format}
+		m.popupMessage = overwriteQuestion(existing, total)
+		m.viewMode = ViewPopup
+		m.popupType = PopupConfirm
+		return m, nil
 	}
 
-	cert := m.certificates[m.list.Index()].Certificate
-	// Determine format from filename extension (.pem, .der, .crt, etc.)
-	err := certificate.ExportCertificate(cert, "", filename)
+	if isExportDir(filename) {
+		for i, info := range certs {
+			target := filepath.Join(filename, certificate.FileName(info.Certificate, i+1, cmp.Or(format, "pem")))
+			if err := certificate.ExportCertificate(info.Certificate, format, target); err != nil {
+				m.popupMessage = fmt.Sprintf("❌ Export failed after %d of %d: %v", i, len(certs), err)
+				m.viewMode = ViewPopup
+				m.popupType = PopupAlert
+				return m, nil
+			}
+		}
+		m.viewMode = ViewNormal
+		m.popupType = PopupNone
+		return m.notify("Exported %d certificates to %s, one file each", len(certs), filename)
+	}
 
-	if err != nil {
+	bundle := make([]*x509.Certificate, len(certs))
+	for i, info := range certs {
+		bundle[i] = info.Certificate
+	}
+	// Without a format, it comes from the extension (.pem, .der, .crt, etc.)
+	if err := certificate.ExportChain(bundle, format, filename); err != nil {
 		m.popupMessage = fmt.Sprintf("❌ Export failed: %v", err)
-	} else {
-		m.popupMessage = fmt.Sprintf("✅ Certificate exported successfully!\n\nFile: %s\nSubject: %s", filename, cert.Subject.CommonName)
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m, nil
 	}
 
-	m.viewMode = ViewPopup
-	m.popupType = PopupAlert
-	return m
+	m.viewMode = ViewNormal
+	m.popupType = PopupNone
+	if len(certs) > 1 {
+		return m.notify("Exported %d certificates to %s", len(certs), filename)
+	}
+	return m.notify("Exported to %s", filename)
 }
@@ -12,6 +12,16 @@ const (
 	ViewHelp
 	// ViewPopup is the modal popup overlay
 	ViewPopup
+	// ViewCommand is the normal view with the ':' command line open in place
+	// of the status bar
+	ViewCommand
+	// ViewDiff is the full-screen side-by-side diff of two certificates
+	ViewDiff
+	// ViewSearch is the normal view with the '/' search line open in place
+	// of the status bar, the list narrowing as the query is typed
+	ViewSearch
+	// ViewPipe is the full-screen output of a command run by :pipe
+	ViewPipe
 )
 
 // PopupType defines the type of popup currently displayed
@@ -20,14 +30,14 @@ type PopupType int
 const (
 	// PopupNone indicates no popup is active
 	PopupNone PopupType = iota
-	// PopupSearch is the search input popup
-	PopupSearch
 	// PopupFilter is the filter criteria popup
 	PopupFilter
 	// PopupExport is the certificate export filename popup
 	PopupExport
 	// PopupAlert is a notification popup
 	PopupAlert // For validation results or errors
+	// PopupConfirm asks before an export overwrites files already there
+	PopupConfirm
 )
 
 // SplashDoneMsg indicates splash screen is complete
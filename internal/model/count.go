@@ -0,0 +1,148 @@
+package model
+
+import (
+	"slices"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+)
+
+// maxCount caps a count prefix, so a held-down digit cannot overflow it.
+const maxCount = 9999
+
+// countStart is what the details looked like when a count began. The
+// digits 1-9 also jump to a detail tab, and do so at once; when the digit
+// turns out to start a count, the jump is undone from here.
+type countStart struct {
+	tab       int
+	focus     Focus
+	yOffset   int
+	detailRow int
+}
+
+// isCountDigit reports whether a key continues or starts a count: any
+// digit once one is under way, and any but 0 to begin one.
+func (m Model) isCountDigit(msg tea.KeyPressMsg) bool {
+	s := msg.String()
+	return len(s) == 1 && s[0] >= '0' && s[0] <= '9' && (m.count > 0 || s != "0")
+}
+
+// typeCount adds a digit to the count for the motion that follows, as in
+// vim's 5j or 12G. A first digit still jumps to its detail tab; a second
+// one makes it a count, and the jump is undone.
+func (m Model) typeCount(msg tea.KeyPressMsg) Model {
+	if m.count == 0 {
+		m.countFrom = &countStart{tab: m.activeTab, focus: m.focus, yOffset: m.viewport.YOffset(), detailRow: m.detailRow}
+	} else {
+		m = m.undoCountJump()
+	}
+	m.count = min(m.count*10+int(msg.String()[0]-'0'), maxCount)
+
+	if m.count < 10 && key.Matches(msg, m.keys.GotoTab) {
+		if n := slices.Index(m.keys.GotoTab.Keys(), msg.String()) + 1; n <= len(m.tabs) {
+			m.focus = FocusRight
+			m = m.selectTab(n - 1)
+		}
+	}
+	return m
+}
+
+// undoCountJump puts the details back as they were before the count
+// began, undoing a tab jump made by its first digit.
+func (m Model) undoCountJump() Model {
+	if m.countFrom == nil {
+		return m
+	}
+	from := *m.countFrom
+	m.focus = from.focus
+	if m.activeTab != from.tab {
+		m = m.selectTab(from.tab)
+	}
+	m.viewport.SetYOffset(from.yOffset)
+	m.detailRow = from.detailRow
+	return m
+}
+
+// isCounted reports whether a key is a motion that takes a count.
+func (m Model) isCounted(msg tea.KeyPressMsg) bool {
+	k := m.keys
+	for _, b := range []key.Binding{k.Up, k.Down, k.Goto, k.Bottom, k.Tab, k.PrevTab, k.NextMatch, k.PrevMatch} {
+		if key.Matches(msg, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// takeCount is the count typed before a motion, 1 when there was none,
+// and clears it.
+func (m Model) takeCount() (Model, int) {
+	n := max(1, m.count)
+	m.count, m.countFrom = 0, nil
+	return m, n
+}
+
+// repeat applies a motion count times.
+func repeat(m Model, count int, motion func(Model) Model) Model {
+	for range count {
+		m = motion(m)
+	}
+	return m
+}
+
+// gotoLine is G and gg with a count: the nth certificate of the list, or
+// the nth line of the details when they have the focus.
+func (m Model) gotoLine(n int) Model {
+	if m.focus == FocusRight {
+		m.viewport.SetYOffset(n - 1)
+		m.detailRow = max(0, n-1-m.viewport.YOffset())
+		return m
+	}
+	if len(m.certificates) == 0 {
+		return m
+	}
+	if i := min(n, len(m.certificates)) - 1; i != m.list.Index() {
+		m.list.Select(i)
+		m.viewport.SetYOffset(0)
+		m = m.refreshViewportContent()
+	}
+	return m
+}
+
+// gotoBottom is G alone: the last certificate, or the end of the details.
+func (m Model) gotoBottom() Model {
+	if m.focus == FocusRight {
+		m.viewport.GotoBottom()
+		m.detailRow = max(0, m.viewport.Height()-1)
+		return m
+	}
+	return m.gotoLine(len(m.certificates))
+}
+
+// handleGoto finishes a g sequence: gg goes to the top, or to the line a
+// count names; gt goes to the next detail tab, or the one a count names,
+// and gT back as many tabs as the count.
+func (m Model) handleGoto(second string) Model {
+	given := m.count > 0
+	m, n := m.takeCount()
+	switch second {
+	case "g":
+		if given {
+			return m.gotoLine(n)
+		}
+		return m.gotoLine(1)
+	case "t":
+		m.focus = FocusRight
+		if given {
+			if n > len(m.tabs) {
+				return m
+			}
+			return m.selectTab(n - 1)
+		}
+		return m.selectTab(m.activeTab + 1)
+	case "T":
+		m.focus = FocusRight
+		return m.selectTab(m.activeTab - n%len(m.tabs))
+	}
+	return m
+}
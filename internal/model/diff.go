@@ -0,0 +1,221 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// diffFieldWidth is the width of the field-name column of the diff view.
+const diffFieldWidth = 20
+
+// toggleMark marks or unmarks the selected certificate. Marks pick the pair
+// for a diff without having to count list positions.
+func (m Model) toggleMark() Model {
+	if len(m.certificates) == 0 {
+		return m
+	}
+	selected := m.certificates[m.list.Index()]
+	if i := slices.Index(m.marked, selected); i >= 0 {
+		m.marked = slices.Delete(slices.Clone(m.marked), i, i+1)
+	} else {
+		m.marked = append(slices.Clip(m.marked), selected)
+	}
+	m.list.SetDelegate(m.newDelegate())
+	return m
+}
+
+// selection is what export and copy act on: the marked certificates, in
+// the order they were marked, or else the selected one.
+func (m Model) selection() []*certificate.Info {
+	if len(m.marked) > 0 {
+		return m.marked
+	}
+	if len(m.certificates) == 0 {
+		return nil
+	}
+	return []*certificate.Info{m.certificates[m.list.Index()]}
+}
+
+// handleSelectCommand moves the selection to a list position, counted from
+// 1, or marks every certificate in the list, or none, for the commands that
+// act on the selection when they cannot be pointed at.
+func (m Model) handleSelectCommand(arg string) Model {
+	switch arg {
+	case "all":
+		m.marked = slices.Clone(m.certificates)
+	case "none":
+		m.marked = nil
+	default:
+		info, err := m.certificateAt(arg)
+		if err != nil {
+			m.commandError = err.Error()
+			return m
+		}
+		m.list.Select(slices.Index(m.certificates, info))
+		m.viewport.SetYOffset(0)
+		return m.refreshViewportContent()
+	}
+	m.list.SetDelegate(m.newDelegate())
+	return m
+}
+
+// handleDiffCommand opens the diff view. With two arguments they are list
+// positions, counted from 1 as the list shows them. Without, it diffs the two
+// marked certificates, or the one marked against the selection.
+func (m Model) handleDiffCommand(args []string) Model {
+	var a, b *certificate.Info
+	switch len(args) {
+	case 0:
+		switch len(m.marked) {
+		case 2:
+			a, b = m.marked[0], m.marked[1]
+		case 1:
+			if len(m.certificates) > 0 {
+				a, b = m.marked[0], m.certificates[m.list.Index()]
+			}
+		}
+		if a == nil || a == b {
+			m.commandError = "mark two certificates with space to diff them, or use: diff <n> <m>"
+			return m
+		}
+	case 2:
+		var err error
+		if a, err = m.certificateAt(args[0]); err != nil {
+			m.commandError = err.Error()
+			return m
+		}
+		if b, err = m.certificateAt(args[1]); err != nil {
+			m.commandError = err.Error()
+			return m
+		}
+	default:
+		m.commandError = "usage: diff [<n> <m>]"
+		return m
+	}
+
+	m.diffPair = [2]*certificate.Info{a, b}
+	m.viewMode = ViewDiff
+	m.diffViewport.SetYOffset(0)
+	return m.refreshDiffContent()
+}
+
+// certificateAt resolves a list position typed by the user, counted from 1.
+func (m Model) certificateAt(arg string) (*certificate.Info, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(m.certificates) {
+		return nil, fmt.Errorf("no certificate %q: the list has %d", arg, len(m.certificates))
+	}
+	return m.certificates[n-1], nil
+}
+
+// updateDiffMode handles key events while the diff view is open.
+func (m Model) updateDiffMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "d":
+		m.viewMode = ViewNormal
+		m.diffPair = [2]*certificate.Info{}
+		return m, nil
+	case "up", "k":
+		m.diffViewport.ScrollUp(1)
+	case "down", "j":
+		m.diffViewport.ScrollDown(1)
+	case "pgup", "b":
+		m.diffViewport.PageUp()
+	case "pgdown", " ", "f":
+		m.diffViewport.PageDown()
+	case "g", "home":
+		m.diffViewport.GotoTop()
+	case "G", "end":
+		m.diffViewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// resizeDiffViewport sizes the diff viewport to the screen, less the title,
+// column header and footer rows.
+func (m Model) resizeDiffViewport() Model {
+	const chrome = 4 // title, column header, divider, footer
+	m.diffViewport.SetWidth(max(1, m.width))
+	m.diffViewport.SetHeight(max(1, m.height-chrome))
+	return m.refreshDiffContent()
+}
+
+// refreshDiffContent re-renders the diff into its viewport.
+func (m Model) refreshDiffContent() Model {
+	if m.diffPair[0] == nil || m.diffPair[1] == nil {
+		return m
+	}
+	m.diffViewport.SetContent(m.renderDiffContent(m.diffViewport.Width()))
+	return m
+}
+
+// diffColumns splits the screen into the field column and two value columns,
+// leaving a column of space after the field and another between the values.
+func diffColumns(width int) (field, value int) {
+	field = min(diffFieldWidth, max(8, width/4))
+	value = max(1, (width-field-2)/2)
+	return field, value
+}
+
+// renderDiffContent renders every field as a row of three columns. Changed
+// rows are flagged and in full color; unchanged ones are dimmed, so the eye
+// lands on what the renewal changed.
+func (m Model) renderDiffContent(width int) string {
+	fieldWidth, valueWidth := diffColumns(width)
+	gap := lipgloss.NewStyle().Width(1).Render(" ")
+
+	var rows []string
+	for _, d := range certificate.DiffCertificates(m.diffPair[0].Certificate, m.diffPair[1].Certificate) {
+		fieldStyle, valueStyle, marker := m.Styles.DetailKey, m.Styles.Dimmed, "  "
+		if d.Changed() {
+			fieldStyle, valueStyle, marker = m.Styles.BadgeWarning, m.Styles.DetailValue, "≠ "
+		}
+		cell := func(value string) string {
+			if value == "" {
+				return m.Styles.Dimmed.Width(valueWidth).Render("—")
+			}
+			return valueStyle.Width(valueWidth).Render(value)
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top,
+			fieldStyle.Width(fieldWidth).Render(marker+d.Field), gap,
+			cell(d.A), gap, cell(d.B)))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// renderDiffView renders the full-screen diff: a title, the names of the two
+// certificates over their columns, the scrolling rows, and a footer.
+func (m Model) renderDiffView() string {
+	a, b := m.diffPair[0], m.diffPair[1]
+	if a == nil || b == nil {
+		return ""
+	}
+	fieldWidth, valueWidth := diffColumns(m.width)
+
+	changed := 0
+	diffs := certificate.DiffCertificates(a.Certificate, b.Certificate)
+	for _, d := range diffs {
+		if d.Changed() {
+			changed++
+		}
+	}
+
+	title := m.Styles.HeaderTitle.Render("Diff") +
+		m.Styles.Dimmed.Render(fmt.Sprintf("  %d of %d fields differ", changed, len(diffs)))
+	name := func(info *certificate.Info) string {
+		return m.Styles.SectionTitle.Width(valueWidth).Render(truncateText(info.Certificate.Subject.CommonName, valueWidth))
+	}
+	columns := lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(fieldWidth).Render(""), " ", name(a), " ", name(b))
+	divider := m.Styles.Dimmed.Render(strings.Repeat("─", m.width))
+	footer := m.Styles.StatusBar.Width(m.width).Render(
+		fmt.Sprintf("↑↓ scroll │ esc close │ %3.f%%", m.diffViewport.ScrollPercent()*100))
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, columns, divider, m.diffViewport.View(), footer)
+}
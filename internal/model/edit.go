@@ -0,0 +1,147 @@
+package model
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"go.uber.org/zap"
+)
+
+// editedMsg reports that the editor opened by :edit has exited.
+type editedMsg struct {
+	target   *certificate.Info
+	path     string
+	original []byte
+	err      error
+}
+
+// editorCommand is the user's editor, from $VISUAL or $EDITOR with any
+// arguments they carry ("code -w"), or vi, set to open path.
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// handleEditCommand writes the selected certificate's PEM to a temporary
+// file and hands the terminal to the editor. The TUI is suspended until the
+// editor exits; handleEdited then reads the file back.
+func (m Model) handleEditCommand() (Model, tea.Cmd) {
+	if len(m.certificates) == 0 {
+		return m, nil
+	}
+	target := m.certificates[m.list.Index()]
+	original := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: target.Certificate.Raw})
+
+	file, err := os.CreateTemp("", "y509-*.pem")
+	if err != nil {
+		m.commandError = err.Error()
+		return m, nil
+	}
+	path := file.Name()
+	_, err = file.Write(original)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		m.commandError = err.Error()
+		return m, nil
+	}
+
+	return m, tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editedMsg{target: target, path: path, original: original, err: err}
+	})
+}
+
+// handleEdited reads back what the editor left and puts it in place of the
+// certificate that was edited: one certificate, several, or, if it no
+// longer parses, none, with the file kept so the edit is not lost.
+func (m Model) handleEdited(msg editedMsg) (Model, tea.Cmd) {
+	fail := func(format string, args ...any) (Model, tea.Cmd) {
+		m.popupMessage = fmt.Sprintf(format, args...)
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m, nil
+	}
+	if msg.err != nil {
+		_ = os.Remove(msg.path)
+		return fail("❌  The editor failed\n\n%v\n\nSet $EDITOR to the one to use.", msg.err)
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		return fail("❌  Could not read the edited file\n\n%v", err)
+	}
+	if bytes.Equal(bytes.TrimSpace(data), bytes.TrimSpace(msg.original)) {
+		_ = os.Remove(msg.path)
+		return m.notify("No changes")
+	}
+
+	edited, err := certificate.ParseCertificates(data)
+	if err != nil {
+		return fail("❌  The edited certificate does not parse\n\n%v\n\nThe edit is kept in %s.", err, msg.path)
+	}
+	at := slices.Index(m.allCertificates, msg.target)
+	if at < 0 {
+		return fail("❌  The certificate was hidden while it was edited\n\nThe edit is kept in %s.", msg.path)
+	}
+	_ = os.Remove(msg.path)
+
+	for i, info := range edited {
+		info.Source = msg.target.Source
+		info.Index = msg.target.Index + i
+		// The lines were those of the temporary file, not the source.
+		info.Line = 0
+	}
+	m.allCertificates = slices.Replace(slices.Clone(m.allCertificates), at, at+1, edited...)
+	m = m.replaceReferences(msg.target, edited[0])
+	var group []*certificate.Info
+	for _, c := range m.allCertificates {
+		if c.Source == msg.target.Source {
+			group = append(group, c)
+		}
+	}
+	certificate.ValidateChainLinks(group)
+
+	index := m.list.Index()
+	m = m.refreshWorkingSet()
+	m.list.Select(min(index, max(0, len(m.certificates)-1)))
+	m = m.refreshViewportContent()
+	logger.Log.Info("certificate edited", zap.Int("certificates", len(edited)))
+
+	m, chainCmd := m.checkChain()
+	m, toastCmd := m.notify("Reloaded %d certificate(s) from the editor", len(edited))
+	return m, tea.Batch(chainCmd, toastCmd, m.checkRevocationCmds(), m.spin())
+}
+
+// replaceReferences points the marks and bookmarks on one certificate at
+// another, which has taken its place.
+func (m Model) replaceReferences(old, replacement *certificate.Info) Model {
+	if i := slices.Index(m.marked, old); i >= 0 {
+		m.marked = slices.Clone(m.marked)
+		m.marked[i] = replacement
+	}
+	bookmarks := make(map[string]*certificate.Info, len(m.bookmarks))
+	for letter, info := range m.bookmarks {
+		if info == old {
+			info = replacement
+		}
+		bookmarks[letter] = info
+	}
+	m.bookmarks = bookmarks
+	return m
+}
@@ -1,37 +1,186 @@
 package model
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"charm.land/bubbles/v2/key"
 	"charm.land/huh/v2"
+	"github.com/kanywst/y509/pkg/certificate"
 )
 
-// newExportForm builds a fresh huh form for the export popup. Two fields:
-// a required filename and a format selector. The filename validator
-// rejects blank input so the form cannot complete with an empty value
-// and leave the export popup wedged.
-func newExportForm() *huh.Form {
+// exportChoices holds what the export form has been given so far. The form
+// writes to it as the user types, so its hints and the overwrite question can
+// follow the path being entered. The fields are exported because huh hashes
+// them to notice the change.
+type exportChoices struct {
+	Filename  string
+	Format    string
+	Overwrite bool
+}
+
+// target is the file the export will write: the filename, with the format
+// as its extension when it has none. A directory is left as it is; the
+// export writes a file for each certificate into it.
+func (c *exportChoices) target() string {
+	filename := strings.TrimSpace(c.Filename)
+	// filepath.Ext only inspects the final path component, so paths like
+	// "./out/cert" or "dir.with.dots/cert" still get a suffix.
+	if filename != "" && filepath.Ext(filename) == "" && !isExportDir(filename) {
+		filename += "." + c.Format
+	}
+	return filename
+}
+
+// exists reports whether the target is a file already there.
+func (c *exportChoices) exists() bool {
+	target := c.target()
+	if target == "" {
+		return false
+	}
+	info, err := os.Stat(target)
+	return err == nil && !info.IsDir()
+}
+
+// isExportDir reports whether an export target names a directory: one that
+// exists, or a path ending in a separator.
+func isExportDir(target string) bool {
+	if strings.HasSuffix(target, string(filepath.Separator)) || strings.HasSuffix(target, "/") {
+		return true
+	}
+	info, err := os.Stat(target)
+	return err == nil && info.IsDir()
+}
+
+// newExportForm builds a fresh huh form for the export popup: a filename
+// that Tab completes like a shell path, a format selector, and, only when
+// the file is already there, a question whether to overwrite it. The
+// filename validator rejects blank input and missing directories, so the
+// form cannot complete with a path the export would fail on. With batch
+// set, several certificates are going, and the hint says what a file and
+// a directory will do with them.
+func newExportForm(batch bool) *huh.Form {
+	choices := &exportChoices{Format: "pem"}
+
+	keys := huh.NewDefaultKeyMap()
+	keys.Input.AcceptSuggestion = key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "complete"))
+	keys.Input.Next = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "next"))
+
 	return huh.NewForm(
 		huh.NewGroup(
 			huh.NewInput().
 				Key("filename").
 				Title("Filename").
 				Placeholder("cert").
+				Value(&choices.Filename).
+				SuggestionsFunc(func() []string {
+					return completePath(choices.Filename)
+				}, &choices.Filename).
+				DescriptionFunc(func() string {
+					if choices.exists() {
+						return "⚠ exists; you will be asked before it is overwritten"
+					}
+					if batch {
+						return "A file for a bundle, a directory for one each"
+					}
+					return "Tab completes the path"
+				}, choices).
 				Validate(func(s string) error {
-					if strings.TrimSpace(s) == "" {
+					s = strings.TrimSpace(s)
+					if s == "" {
 						return errors.New("filename is required")
 					}
+					if dir := filepath.Dir(s); dir != "." {
+						if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+							return fmt.Errorf("no directory %s", dir)
+						}
+					}
 					return nil
 				}),
 			huh.NewSelect[string]().
 				Key("format").
 				Title("Format").
+				Value(&choices.Format).
 				Options(
 					huh.NewOption("PEM", "pem"),
 					huh.NewOption("DER", "der"),
 					huh.NewOption("CRT", "crt"),
 				),
 		),
-	).WithShowHelp(false).WithShowErrors(true)
+		huh.NewGroup(
+			huh.NewConfirm().
+				Key("overwrite").
+				TitleFunc(func() string {
+					return choices.target() + " already exists"
+				}, choices).
+				Affirmative("Overwrite").
+				Negative("Cancel").
+				Value(&choices.Overwrite),
+		).WithHideFunc(func() bool { return !choices.exists() }),
+	).WithShowHelp(false).WithShowErrors(true).WithKeyMap(keys)
+}
+
+// exportTarget is the file the completed export form asks to write, and
+// whether it may be written: false when it exists and overwriting it was
+// declined.
+func exportTarget(form *huh.Form) (string, bool) {
+	choices := &exportChoices{Filename: form.GetString("filename"), Format: form.GetString("format")}
+	if choices.exists() && !form.GetBool("overwrite") {
+		return choices.target(), false
+	}
+	return choices.target(), true
+}
+
+// pendingExport is an export held back until it is known whether the files
+// it would replace may be overwritten.
+type pendingExport struct {
+	certs    []*certificate.Info
+	filename string
+	// format is the format asked for, empty to go by the extension.
+	format string
+	// pubkey is set for export pubkey, which writes the public key.
+	pubkey bool
+}
+
+// exportClobbers lists the files already there that exporting certs to
+// filename in format would replace: the file itself, or for a directory the
+// files named for the certificates.
+func exportClobbers(certs []*certificate.Info, filename, format string) []string {
+	targets := []string{filename}
+	if isExportDir(filename) {
+		targets = targets[:0]
+		for i, info := range certs {
+			targets = append(targets, filepath.Join(filename, certificate.FileName(info.Certificate, i+1, cmp.Or(format, "pem"))))
+		}
+	}
+	var existing []string
+	for _, target := range targets {
+		if _, err := os.Stat(target); err == nil {
+			existing = append(existing, target)
+		}
+	}
+	return existing
+}
+
+// overwriteQuestion asks whether the files an export would replace may be
+// overwritten, naming the first few.
+func overwriteQuestion(existing []string, total int) string {
+	if total == 1 {
+		return fmt.Sprintf("⚠  %s already exists\n\nOverwrite it? y/n", existing[0])
+	}
+	const shown = 5
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚠  %d of the %d files already exist:\n\n", len(existing), total)
+	for _, name := range existing[:min(shown, len(existing))] {
+		b.WriteString("  " + filepath.Base(name) + "\n")
+	}
+	if len(existing) > shown {
+		fmt.Fprintf(&b, "  and %d more\n", len(existing)-shown)
+	}
+	b.WriteString("\nOverwrite them? y/n")
+	return b.String()
 }
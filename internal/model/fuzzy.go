@@ -0,0 +1,91 @@
+package model
+
+import "strings"
+
+// Fuzzy match scoring, after fzf's v1 algorithm: every matched character
+// scores, more so at the start of a word or in a run of matches, and
+// the gaps between matches cost. So "api" ranks "api.example.com" above
+// "rapid.example.com", and "exco" ranks "example.com" above "excessive.org".
+const (
+	fuzzyScoreMatch       = 16
+	fuzzyBonusBoundary    = 8
+	fuzzyBonusConsecutive = 4
+	fuzzyPenaltyGapStart  = 3
+	fuzzyPenaltyGapExtend = 1
+)
+
+// fuzzyMatch reports whether the characters of pattern appear in text in
+// order, ignoring case, how well, and at which rune positions. It scores the
+// tightest window holding the match: found greedily forwards, then shrunk
+// from the end backwards.
+func fuzzyMatch(pattern, text string) (score int, positions []int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+
+	end, pi := -1, 0
+	for i, r := range t {
+		if r == p[pi] {
+			pi++
+			if pi == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	start, pi := 0, len(p)-1
+	for i := end; i >= 0; i-- {
+		if t[i] == p[pi] {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	// A run of matches carries the bonus of its first character, so a whole
+	// word typed out beats the same letters picked from several words.
+	pi, chunkBonus, inChunk, inGap := 0, 0, false, false
+	for i := start; i <= end && pi < len(p); i++ {
+		if t[i] != p[pi] {
+			if inGap {
+				score -= fuzzyPenaltyGapExtend
+			} else {
+				score -= fuzzyPenaltyGapStart
+			}
+			inChunk, inGap = false, true
+			continue
+		}
+		bonus := 0
+		if i == 0 || isWordBoundary(t[i-1]) {
+			bonus = fuzzyBonusBoundary
+		}
+		if inChunk {
+			bonus = max(bonus, chunkBonus, fuzzyBonusConsecutive)
+		} else {
+			chunkBonus = bonus
+		}
+		score += fuzzyScoreMatch + bonus
+		positions = append(positions, i)
+		inChunk, inGap = true, false
+		pi++
+	}
+	return score, positions, true
+}
+
+// isWordBoundary reports whether r separates the words of a name, a host
+// name or a serial.
+func isWordBoundary(r rune) bool {
+	switch r {
+	case ' ', '.', '-', '_', '/', ':', '@', '*', '=', ',':
+		return true
+	}
+	return false
+}
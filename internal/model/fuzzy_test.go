@@ -0,0 +1,50 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, text string
+		match         bool
+	}{
+		{"", "anything", true},
+		{"exco", "www.example.com", true},
+		{"EXCO", "www.example.com", true},
+		{"api", "API Gateway", true},
+		{"moc", "example.com", false},
+		{"examplex", "example", false},
+	} {
+		if _, _, ok := fuzzyMatch(tt.pattern, tt.text); ok != tt.match {
+			t.Errorf("fuzzyMatch(%q, %q) matched=%v, want %v", tt.pattern, tt.text, ok, tt.match)
+		}
+	}
+}
+
+func TestFuzzyMatchRanking(t *testing.T) {
+	for _, tt := range []struct{ pattern, better, worse string }{
+		// Word starts beat the middle of a word.
+		{"api", "api.example.com", "rapid.example.com"},
+		{"exco", "example.com", "excessive.org"},
+		// A run of characters beats the same characters spread out.
+		{"corp", "corp.example", "c-o-r-p.example"},
+		// A tight match beats one with the pattern's letters far apart.
+		{"ab", "ab", "a---------b"},
+	} {
+		better, _, ok1 := fuzzyMatch(tt.pattern, tt.better)
+		worse, _, ok2 := fuzzyMatch(tt.pattern, tt.worse)
+		if !ok1 || !ok2 || better <= worse {
+			t.Errorf("%q: %q scored %d, %q scored %d", tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestFuzzyMatchPositions(t *testing.T) {
+	// The tightest window: the "a" next to the "c", not the first one.
+	_, positions, _ := fuzzyMatch("ac", "a-b-ac")
+	if want := []int{4, 5}; !slices.Equal(positions, want) {
+		t.Errorf("positions = %v, want %v", positions, want)
+	}
+}
@@ -0,0 +1,285 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// helpKeyWidth is the width of the key column of the help view.
+const helpKeyWidth = 24
+
+// helpEntry is one line of the help view: keys or a command, and what it does.
+type helpEntry struct {
+	keys string
+	desc string
+}
+
+// helpSection is a titled group of help entries.
+type helpSection struct {
+	title   string
+	entries []helpEntry
+}
+
+// commandHelp describes the commands of the ':' line.
+var commandHelp = []helpEntry{
+	{":validate [at <date>]", "validate the chain, now or as of a date"},
+	{":covers <host>", "which certificates cover a hostname or IP"},
+	{":match <keyfile>", "check a private key belongs to the certificate"},
+	{":search <query>", "search subject, SANs, issuer and serial"},
+	{":search re:<regexp>", "search with a regular expression"},
+	{":filter <type>", "keep " + strings.Join(filterTypes, ", ")},
+	{":filter keysize<n sigalg=<name>", "keep keys by size, or signatures by algorithm"},
+	{":filter issuer <pattern>", "keep those issued by a CA, by CN or organization"},
+	{":filter san <*.zone|ip|cidr>", "keep those with SANs in a zone or network"},
+	{":back", "undo the last search or filter"},
+	{":reset", "clear search and filter"},
+	{":source <n|name|all>", "show one loaded file, or all of them"},
+	{":select <n>|all|none", "select list entry n, or mark every entry or none"},
+	{":diff [<n> <m>]", "diff two list entries, or the marked pair"},
+	{":columns [<name>,...]", "choose the list columns"},
+	{":marks", "list bookmarks and starred certificates"},
+	{":hide :unhide", "drop the selected certificate, bring all back"},
+	{":edit", "open the PEM in $EDITOR, reload it after"},
+	{":pipe [pem] <command>", "send the details, or the PEM, to a command"},
+	{":wrap [on|off]", "wrap long lines of the details, or cut them"},
+	{":theme [<name>|save]", "switch theme, or save the one in use"},
+	{":config reload", "read the configuration file again, as a change to it does"},
+	{":copy <field>", "copy " + strings.Join(copyFields, ", ")},
+	{":export [<format>] <file|dir>", "export the selection: a bundle, or a file each"},
+	{":export bundle <file|dir>", "export the list as shown, in its order"},
+	{":export pubkey <file>", "export the selection's public key (SPKI)"},
+	{":overview :subject :issuer", "jump to a detail tab"},
+	{":validity :san :key :fp", "jump to a detail tab"},
+	{":ext :checks", "jump to a detail tab"},
+	{":text :raw", "openssl-style text, or the ASN.1 tree"},
+	{":help :quit", "this help, quit"},
+}
+
+// bindingEntries turns key bindings into help entries, from the same
+// bindings the keys are matched against.
+func bindingEntries(bindings ...key.Binding) []helpEntry {
+	entries := make([]helpEntry, len(bindings))
+	for i, b := range bindings {
+		entries[i] = helpEntry{keys: b.Help().Key, desc: b.Help().Desc}
+	}
+	return entries
+}
+
+// helpSections lists everything the help view shows. The first section
+// depends on the state help was opened from, so the keys that matter right
+// now come first.
+func (m Model) helpSections() []helpSection {
+	k := m.keys
+	var sections []helpSection
+	if entries := m.contextHelp(); len(entries) > 0 {
+		sections = append(sections, helpSection{"Right now", entries})
+	}
+	return append(sections,
+		helpSection{"Navigation", append(bindingEntries(k.Up, k.Down, k.Left, k.Right, k.Tab, k.PrevTab, k.GotoTab, k.Goto, k.Bottom, k.Zoom, k.Issuer, k.Root, k.Leaf, k.PrevSource, k.NextSource),
+			helpEntry{"5j 12G 3gt", "a count before a move: five down, the 12th, tab 3"})},
+		helpSection{"Search and filter", bindingEntries(k.Search, k.NextMatch, k.PrevMatch, k.Filter, k.Undo, k.Back)},
+		helpSection{"Certificate", bindingEntries(k.Validate, k.Export, k.Yank, k.Diff, k.Hide, k.MoveUp, k.MoveDown)},
+		helpSection{"Marks", bindingEntries(k.Mark, k.Bookmark, k.JumpToBookmark)},
+		helpSection{"Commands", append(bindingEntries(k.Command), commandHelp...)},
+		helpSection{"This help", []helpEntry{
+			{"↑/k ↓/j pgup pgdn", "scroll"},
+			{"/", "search the help"},
+			{"esc q ?", "close"},
+		}},
+		helpSection{"General", bindingEntries(k.Help, k.Quit)},
+	)
+}
+
+// contextHelp picks out the keys that apply to what is on screen: the pane
+// in focus, the full-screen details, an active search or filter, marks, and
+// file tabs.
+func (m Model) contextHelp() []helpEntry {
+	k := m.keys
+	var entries []helpEntry
+	switch {
+	case m.fullscreen:
+		entries = append(entries, helpEntry{keysOf(k.Back, k.Left) + " z", "back to the split view"}, helpEntry{keysOf(k.Up, k.Down), "scroll the details"})
+	case m.focus == FocusRight:
+		entries = append(entries, helpEntry{keysOf(k.Up, k.Down), "scroll the details"}, helpEntry{keysOf(k.Yank), "copy the highlighted line"}, helpEntry{keysOf(k.Search), "find in the details"}, helpEntry{"enter", "on an Issuer line, go to the issuer"}, helpEntry{keysOf(k.Left), "back to the list"})
+	default:
+		entries = append(entries, helpEntry{keysOf(k.Up, k.Down), "move through the certificates"}, helpEntry{keysOf(k.Right, k.Zoom), "read the details"})
+	}
+	if query := m.detailQuery(); query != "" {
+		entries = append(entries, helpEntry{keysOf(k.NextMatch, k.PrevMatch), fmt.Sprintf("next / previous match for %q", query)})
+	}
+	if m.busy() {
+		entries = append(entries, helpEntry{keysOf(k.Back), "cancel " + m.busyStatus()})
+	} else if m.findQuery != "" {
+		entries = append(entries, helpEntry{keysOf(k.Back), fmt.Sprintf("stop finding %q", m.findQuery)})
+	} else if m.filterActive {
+		entries = append(entries, helpEntry{keysOf(k.Back), "clear " + m.filterType})
+	}
+	if n := len(m.filters); n > 1 {
+		entries = append(entries, helpEntry{keysOf(k.Undo) + " :back", "undo " + m.filters[n-1]})
+	}
+	if len(m.marked) == 2 {
+		entries = append(entries, helpEntry{keysOf(k.Diff), "diff the two marked certificates"})
+	}
+	if len(m.marked) > 0 {
+		entries = append(entries, helpEntry{keysOf(k.Export, k.Yank) + " :copy", fmt.Sprintf("act on the %d marked certificates", len(m.marked))})
+	}
+	if len(m.sources) > 0 {
+		entries = append(entries, helpEntry{keysOf(k.PrevSource, k.NextSource), "switch file tab"})
+	}
+	return entries
+}
+
+// keysOf lists the keys of bindings as their help shows them, so help
+// about the moment follows any keys the configuration rebinds.
+func keysOf(bindings ...key.Binding) string {
+	keys := make([]string, len(bindings))
+	for i, b := range bindings {
+		keys[i] = b.Help().Key
+	}
+	return strings.Join(keys, " ")
+}
+
+// openHelp opens the help view at the top, with no search.
+func (m Model) openHelp() Model {
+	m.viewMode = ViewHelp
+	m.helpSearching = false
+	m.helpInput.Reset()
+	m.helpInput.Blur()
+	m = m.resizeHelpViewport()
+	m.helpViewport.GotoTop()
+	return m
+}
+
+// updateHelpMode handles key events in the help view: scrolling, '/' to
+// search it, and esc, q or ? to close it.
+func (m Model) updateHelpMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	if m.helpSearching {
+		switch msg.String() {
+		case "enter":
+			m.helpSearching = false
+			m.helpInput.Blur()
+			return m, nil
+		case "esc":
+			m.helpSearching = false
+			m.helpInput.Reset()
+			m.helpInput.Blur()
+			return m.refreshHelpContent(), nil
+		}
+		var cmd tea.Cmd
+		m.helpInput, cmd = m.helpInput.Update(msg)
+		m = m.refreshHelpContent()
+		m.helpViewport.GotoTop()
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "esc":
+		// The first esc drops a search, the next one closes.
+		if m.helpInput.Value() != "" {
+			m.helpInput.Reset()
+			m = m.refreshHelpContent()
+			m.helpViewport.GotoTop()
+			return m, nil
+		}
+		m.viewMode = ViewNormal
+	case "q", "?":
+		m.viewMode = ViewNormal
+	case "/":
+		m.helpSearching = true
+		m.helpInput.Reset()
+		cmd := m.helpInput.Focus()
+		return m.refreshHelpContent(), cmd
+	case "up", "k":
+		m.helpViewport.ScrollUp(1)
+	case "down", "j":
+		m.helpViewport.ScrollDown(1)
+	case "pgup", "b":
+		m.helpViewport.PageUp()
+	case "pgdown", "space", "f":
+		m.helpViewport.PageDown()
+	case "g", "home":
+		m.helpViewport.GotoTop()
+	case "G", "end":
+		m.helpViewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// resizeHelpViewport sizes the help viewport to the screen, less the title
+// and footer rows.
+func (m Model) resizeHelpViewport() Model {
+	const chrome = 3 // title, divider, footer
+	m.helpViewport.SetWidth(max(1, m.width))
+	m.helpViewport.SetHeight(max(1, m.height-chrome))
+	return m.refreshHelpContent()
+}
+
+// refreshHelpContent re-renders the help into its viewport.
+func (m Model) refreshHelpContent() Model {
+	m.helpViewport.SetContent(m.renderHelpContent(strings.TrimSpace(m.helpInput.Value())))
+	return m
+}
+
+// renderHelpContent renders the help sections. With a query only the entries
+// containing it are kept, or every entry of a section whose title does, and
+// the query is highlighted.
+func (m Model) renderHelpContent(query string) string {
+	lower := strings.ToLower(query)
+	var lines []string
+	for _, section := range m.helpSections() {
+		entries := section.entries
+		if query != "" && !strings.Contains(strings.ToLower(section.title), lower) {
+			entries = nil
+			for _, e := range section.entries {
+				if strings.Contains(strings.ToLower(e.keys+" "+e.desc), lower) {
+					entries = append(entries, e)
+				}
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "  "+m.Styles.SectionTitle.Render(section.title))
+		for _, e := range entries {
+			line := "    " + m.Styles.DetailKey.Bold(true).Width(helpKeyWidth).Render(e.keys) + m.Styles.DetailValue.Render(e.desc)
+			if query != "" {
+				line = highlightRunes(line, searchPositions(query, ansi.Strip(line), true), m.Styles.SearchMatch)
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return m.Styles.Dimmed.Render(fmt.Sprintf("  Nothing in the help matches %q", query))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderHelpView renders the full-screen help: a title, the scrolling
+// sections, and a footer that holds the search line while it is open.
+func (m Model) renderHelpView() string {
+	title := m.Styles.HeaderTitle.Render("🔐 y509 Help")
+	if query := strings.TrimSpace(m.helpInput.Value()); query != "" && !m.helpSearching {
+		title += m.Styles.Dimmed.Render(fmt.Sprintf("  matching %q", query))
+	}
+	divider := m.Styles.Dimmed.Render(strings.Repeat("─", m.width))
+
+	var footer string
+	if m.helpSearching {
+		input := m.helpInput
+		input.SetWidth(max(1, m.width-2))
+		footer = m.Styles.CommandBar.Width(m.width).Render(input.View())
+	} else {
+		footer = m.Styles.StatusBar.Width(m.width).Render(
+			fmt.Sprintf("↑↓ scroll │ / search │ esc close │ %3.f%%", m.helpViewport.ScrollPercent()*100))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, title, divider, m.helpViewport.View(), footer)
+}
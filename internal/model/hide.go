@@ -0,0 +1,88 @@
+package model
+
+import (
+	"slices"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// hiddenCert is a certificate hidden from the working set, and the place
+// in it that it left.
+type hiddenCert struct {
+	info *certificate.Info
+	at   int
+}
+
+// hideSelected drops the selected certificate from the working set, leaving
+// the file it came from alone. It is kept in hidden for :unhide, and stops
+// counting for the chain summary, the tabs and every search and filter.
+func (m Model) hideSelected() (Model, tea.Cmd) {
+	if len(m.certificates) == 0 {
+		return m, nil
+	}
+	if len(m.allCertificates) == 1 {
+		m.commandError = "the last certificate cannot be hidden"
+		return m, nil
+	}
+	index := m.list.Index()
+	selected := m.certificates[index]
+
+	at := slices.Index(m.allCertificates, selected)
+	m.allCertificates = slices.Delete(slices.Clone(m.allCertificates), at, at+1)
+	m.hidden = append(slices.Clip(m.hidden), hiddenCert{info: selected, at: at})
+	if i := slices.Index(m.marked, selected); i >= 0 {
+		m.marked = slices.Delete(slices.Clone(m.marked), i, i+1)
+	}
+	m = m.refreshWorkingSet()
+	m.list.Select(min(index, max(0, len(m.certificates)-1)))
+	m = m.refreshViewportContent()
+
+	m, chainCmd := m.checkChain()
+	m, toastCmd := m.notify("Hid %s; :unhide brings it back", orNone(selected.Certificate.Subject.CommonName))
+	return m, tea.Batch(chainCmd, toastCmd)
+}
+
+// handleUnhideCommand brings every hidden certificate back to the place it
+// left, and keeps the selection where it was.
+func (m Model) handleUnhideCommand() (Model, tea.Cmd) {
+	if len(m.hidden) == 0 {
+		m.commandError = "nothing is hidden"
+		return m, nil
+	}
+	var selected *certificate.Info
+	if len(m.certificates) > 0 {
+		selected = m.certificates[m.list.Index()]
+	}
+
+	// Last hidden, first back: each goes back into the list as it was when
+	// it left.
+	restored := len(m.hidden)
+	all := slices.Clone(m.allCertificates)
+	for _, h := range slices.Backward(m.hidden) {
+		all = slices.Insert(all, min(h.at, len(all)), h.info)
+	}
+	m.allCertificates = all
+	m.hidden = nil
+	m = m.refreshWorkingSet()
+	if i := slices.Index(m.certificates, selected); i >= 0 {
+		m.list.Select(i)
+	}
+	m = m.refreshViewportContent()
+
+	m, chainCmd := m.checkChain()
+	m, toastCmd := m.notify("Restored %d hidden", restored)
+	return m, tea.Batch(chainCmd, toastCmd)
+}
+
+// refreshWorkingSet rebuilds the list after certificates join or leave the
+// working set, keeping the file tab and any search or filter.
+func (m Model) refreshWorkingSet() Model {
+	if m.filterActive {
+		return m.applyFilter()
+	}
+	m.certificates = m.sourceCertificates()
+	m.list.SetDelegate(m.newDelegate())
+	m.list.SetItems(toListItems(m.certificates))
+	return m
+}
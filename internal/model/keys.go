@@ -1,23 +1,65 @@
 package model
 
-import "charm.land/bubbles/v2/key"
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
-// keyMap defines all bindings for the TUI. It implements help.KeyMap so the
-// help view can render the same source-of-truth bindings.
+	"charm.land/bubbles/v2/key"
+	"github.com/kanywst/y509/internal/config"
+)
+
+// keyMap defines all bindings for the TUI. The help view lists the same
+// bindings, so what it says and what the keys do cannot drift apart.
 type keyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	Left     key.Binding
-	Right    key.Binding
-	Tab      key.Binding
+	Up    key.Binding
+	Down  key.Binding
+	Left  key.Binding
+	Right key.Binding
+	Tab   key.Binding
+	// PrevTab cycles the detail tabs backwards, and GotoTab jumps to one of
+	// the first nine by number.
+	PrevTab  key.Binding
+	GotoTab  key.Binding
 	Search   key.Binding
 	Filter   key.Binding
 	Validate key.Binding
 	Export   key.Binding
 	Help     key.Binding
 	Back     key.Binding
-	Yank     key.Binding
-	Quit     key.Binding
+	// Undo pops the filter or search stacked last.
+	Undo key.Binding
+	Yank key.Binding
+	Mark key.Binding
+	Diff key.Binding
+	// Hide drops the selected certificate from the session.
+	Hide key.Binding
+	// MoveUp and MoveDown move the selected certificate along the list.
+	MoveUp   key.Binding
+	MoveDown key.Binding
+	// Bookmark and JumpToBookmark take a letter as a second key.
+	Bookmark       key.Binding
+	JumpToBookmark key.Binding
+	// Issuer selects the certificate that issued the selected one, and Root
+	// and Leaf the ends of its chain.
+	Issuer key.Binding
+	Root   key.Binding
+	Leaf   key.Binding
+	// Goto starts the gg, gt and gT sequences, and Bottom is G. Both, like
+	// the moves up and down, take a count typed before them.
+	Goto   key.Binding
+	Bottom key.Binding
+	// Zoom widens the details pane to the full screen and back.
+	Zoom key.Binding
+	// NextMatch and PrevMatch jump between search matches.
+	NextMatch key.Binding
+	PrevMatch key.Binding
+	// PrevSource and NextSource switch file tab.
+	PrevSource key.Binding
+	NextSource key.Binding
+	Command    key.Binding
+	Quit       key.Binding
 }
 
 func defaultKeyMap() keyMap {
@@ -42,6 +84,14 @@ func defaultKeyMap() keyMap {
 			key.WithKeys("tab"),
 			key.WithHelp("tab", "cycle tabs"),
 		),
+		PrevTab: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "cycle tabs back"),
+		),
+		GotoTab: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "go to detail tab"),
+		),
 		Search: key.NewBinding(
 			key.WithKeys("/"),
 			key.WithHelp("/", "search"),
@@ -66,9 +116,85 @@ func defaultKeyMap() keyMap {
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "clear filter"),
 		),
+		Undo: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "undo the last filter (:back)"),
+		),
 		Yank: key.NewBinding(
 			key.WithKeys("y"),
-			key.WithHelp("y", "copy PEM"),
+			key.WithHelp("y", "copy PEM, or the line in the details"),
+		),
+		Mark: key.NewBinding(
+			key.WithKeys("space"),
+			key.WithHelp("space", "mark"),
+		),
+		Diff: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "diff marked"),
+		),
+		MoveUp: key.NewBinding(
+			key.WithKeys("K", "shift+up"),
+			key.WithHelp("K", "move up the list"),
+		),
+		MoveDown: key.NewBinding(
+			key.WithKeys("J", "shift+down"),
+			key.WithHelp("J", "move down the list"),
+		),
+		Hide: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "hide (:unhide)"),
+		),
+		Bookmark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m<a-z>", "bookmark"),
+		),
+		JumpToBookmark: key.NewBinding(
+			key.WithKeys("'"),
+			key.WithHelp("'<a-z>", "go to bookmark"),
+		),
+		Issuer: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "go to the issuer"),
+		),
+		Root: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "go to the chain's root"),
+		),
+		Leaf: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "go to the chain's leaf"),
+		),
+		Goto: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("gg/gt/gT", "top, next tab, previous tab"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("G"),
+			key.WithHelp("G", "bottom; 12G the 12th"),
+		),
+		Zoom: key.NewBinding(
+			key.WithKeys("z", "enter"),
+			key.WithHelp("z/enter", "fullscreen details"),
+		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "prev match"),
+		),
+		PrevSource: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "prev file"),
+		),
+		NextSource: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next file"),
+		),
+		Command: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "command"),
 		),
 		Quit: key.NewBinding(
 			key.WithKeys("q", "ctrl+c"),
@@ -77,16 +203,78 @@ func defaultKeyMap() keyMap {
 	}
 }
 
-// ShortHelp implements help.KeyMap for the inline status hints.
-func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.Search, k.Help, k.Quit}
+// named maps the action names of the keys section of the configuration to
+// the bindings they set. next_pane and prev_pane are other names for right
+// and left.
+func (k *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up": &k.Up, "down": &k.Down, "left": &k.Left, "right": &k.Right,
+		"prev_pane": &k.Left, "next_pane": &k.Right,
+		"tab": &k.Tab, "prev_tab": &k.PrevTab, "goto_tab": &k.GotoTab,
+		"search": &k.Search, "filter": &k.Filter, "validate": &k.Validate, "export": &k.Export,
+		"help": &k.Help, "back": &k.Back, "undo": &k.Undo, "yank": &k.Yank,
+		"mark": &k.Mark, "diff": &k.Diff, "hide": &k.Hide, "move_up": &k.MoveUp, "move_down": &k.MoveDown,
+		"bookmark": &k.Bookmark, "jump_to_bookmark": &k.JumpToBookmark,
+		"issuer": &k.Issuer, "root": &k.Root, "leaf": &k.Leaf, "goto": &k.Goto, "bottom": &k.Bottom, "zoom": &k.Zoom,
+		"next_match": &k.NextMatch, "prev_match": &k.PrevMatch,
+		"prev_source": &k.PrevSource, "next_source": &k.NextSource,
+		"command": &k.Command, "quit": &k.Quit,
+	}
+}
+
+// keyActions are the actions of the keys section, the other names aside,
+// in the order the help view lists them.
+var keyActions = []string{
+	"up", "down", "left", "right", "tab", "prev_tab", "goto_tab",
+	"search", "filter", "validate", "export", "help", "back", "undo", "yank",
+	"mark", "diff", "hide", "move_up", "move_down", "bookmark", "jump_to_bookmark",
+	"issuer", "root", "leaf", "goto", "bottom", "zoom", "next_match", "prev_match",
+	"prev_source", "next_source", "command", "quit",
 }
 
-// FullHelp implements help.KeyMap for the dedicated help overlay.
-func (k keyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{
-		{k.Up, k.Down, k.Left, k.Right, k.Tab},
-		{k.Search, k.Filter, k.Validate, k.Export, k.Yank, k.Back},
-		{k.Help, k.Quit},
+// KeyBindings lists every action of the keys section of the configuration
+// with the keys it is bound to once bindings have rebound them, as y509
+// config writes them out.
+func KeyBindings(bindings map[string][]string) ([]config.KeyBinding, error) {
+	keys, err := defaultKeyMap().withConfig(bindings)
+	named := keys.named()
+	list := make([]config.KeyBinding, len(keyActions))
+	for i, action := range keyActions {
+		binding := named[action]
+		list[i] = config.KeyBinding{Action: action, Keys: binding.Keys(), Help: binding.Help().Desc}
+	}
+	return list, err
+}
+
+// actionKey folds the ways an action may be written, next_match, nextMatch
+// or next-match, into one. Viper has lower-cased the names already.
+func actionKey(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// withConfig rebinds each action the keys section of the configuration
+// names to the keys listed for it, in place of its defaults; the help view
+// shows them as they are configured. Actions it does not know are
+// reported, and the rest applied all the same.
+func (k keyMap) withConfig(bindings map[string][]string) (keyMap, error) {
+	byKey := make(map[string]*key.Binding)
+	for name, binding := range k.named() {
+		byKey[actionKey(name)] = binding
+	}
+
+	var unknown []string
+	for _, name := range slices.Sorted(maps.Keys(bindings)) {
+		binding, ok := byKey[actionKey(name)]
+		keys := bindings[name]
+		if !ok || len(keys) == 0 {
+			unknown = append(unknown, name)
+			continue
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+	if len(unknown) > 0 {
+		return k, fmt.Errorf("unknown or empty key actions: %s", strings.Join(unknown, ", "))
 	}
+	return k, nil
 }
@@ -2,11 +2,13 @@ package model
 
 import (
 	"io"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/list"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/kanywst/y509/pkg/certificate"
 )
 
@@ -29,8 +31,17 @@ func (c certItem) FilterValue() string {
 // pane is signalled by the surrounding border colour, so the delegate
 // itself doesn't need to know which pane currently has focus.
 type certDelegate struct {
-	styles   Styles
-	warnDays int
+	styles    Styles
+	warnDays  int
+	marked    []*certificate.Info
+	bookmarks map[string]*certificate.Info
+	columns   []string
+	// query is the search in force, highlighted in the subject column.
+	query string
+	exact bool
+	// revocation holds the revocation results so far; nil when revocation
+	// checking is off.
+	revocation map[*certificate.Info]certificate.RevocationResult
 }
 
 func (d certDelegate) Height() int                             { return 1 }
@@ -43,17 +54,6 @@ func (d certDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		return
 	}
 
-	width := m.Width()
-	statusWidth := 4
-	expiresWidth := 14
-	subjectWidth := width - statusWidth - expiresWidth
-	if subjectWidth < 10 {
-		subjectWidth = 10
-	}
-
-	statusIcon, statusStyle := getStatusIconAndStyle(ci.info, d.styles, d.warnDays)
-	expiresStr := renderExpiryWithBar(ci.info, d.styles, d.warnDays)
-
 	var baseStyle lipgloss.Style
 	switch {
 	case index == m.Index():
@@ -64,18 +64,41 @@ func (d certDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		baseStyle = lipgloss.NewStyle()
 	}
 
-	sStyle := statusStyle.Background(baseStyle.GetBackground())
-	sCol := sStyle.Width(statusWidth).Render(" " + statusIcon + " ")
-
-	cn := ci.info.Certificate.Subject.CommonName
-	if cn == "" {
-		cn = "(no CN)"
+	var cells []string
+	for _, col := range layoutColumns(d.columns, m.Width()) {
+		switch col.name {
+		case "status":
+			icon, style := getStatusIconAndStyle(ci.info, d.styles, d.warnDays)
+			if d.revocation == nil {
+				cells = append(cells, style.Background(baseStyle.GetBackground()).Width(col.width).Render(" "+icon+" "))
+				break
+			}
+			rev, revStyle := revocationIcon(ci.info, d.revocation, d.styles)
+			cells = append(cells, lipgloss.NewStyle().Background(baseStyle.GetBackground()).Width(col.width).Render(
+				style.Background(baseStyle.GetBackground()).Render(" "+icon)+revStyle.Background(baseStyle.GetBackground()).Render(rev)))
+		case "expiry":
+			cells = append(cells, baseStyle.Width(col.width).Render(renderExpiryWithBar(ci.info, d.styles, d.warnDays)))
+		default:
+			text := columnText(col.name, ci.info)
+			if col.name == "cn" {
+				if letters := bookmarkLetters(d.bookmarks, ci.info); letters != "" {
+					text = "'" + letters + " " + text
+				}
+				if slices.Contains(d.marked, ci.info) {
+					text = "• " + text
+				}
+			}
+			cell := baseStyle.Width(col.width).Render(truncateText(text, col.width-1))
+			// Highlight the search where it looks: the subject and issuer.
+			if d.query != "" && (col.name == "cn" || col.name == "issuer") {
+				positions := searchPositions(d.query, ansi.Strip(cell), d.exact)
+				cell = highlightRunes(cell, positions, d.styles.SearchMatch)
+			}
+			cells = append(cells, cell)
+		}
 	}
-	cCol := baseStyle.Width(subjectWidth).Render(truncateText(cn, subjectWidth-1))
-
-	eCol := baseStyle.Width(expiresWidth).Render(expiresStr)
 
-	row := lipgloss.JoinHorizontal(lipgloss.Left, sCol, cCol, eCol)
+	row := lipgloss.JoinHorizontal(lipgloss.Left, cells...)
 	_, _ = io.WriteString(w, strings.TrimRight(row, "\n"))
 }
 
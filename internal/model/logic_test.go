@@ -1,13 +1,26 @@
 package model
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/pkg/certificate"
 )
 
 func TestFilterLogic(t *testing.T) {
@@ -55,6 +68,123 @@ func TestFilterLogic(t *testing.T) {
 	})
 }
 
+func TestFilterStack(t *testing.T) {
+	certs := createTestCertificates(4)
+	certs[0].Certificate.NotAfter = time.Now().Add(-time.Hour)
+	certs[0].Certificate.Subject.CommonName = "acme old"
+	certs[1].Certificate.NotAfter = time.Now().Add(-time.Hour)
+	certs[2].Certificate.Subject.CommonName = "acme new"
+
+	m := *NewModel(certs, loadTestConfig(t))
+	m.ready = true
+	m = m.filterCertificates("expired")
+	m = m.searchCertificates("acme")
+	if len(m.certificates) != 1 || m.certificates[0] != certs[0] {
+		t.Fatalf("expired then acme matched %d certificates, want the old acme alone", len(m.certificates))
+	}
+	if m.filterType != "expired › search: acme" {
+		t.Errorf("filterType = %q", m.filterType)
+	}
+
+	// A second search replaces the first rather than narrowing it.
+	m = m.searchCertificates("Certificate B")
+	if len(m.filters) != 2 || len(m.certificates) != 1 || m.certificates[0] != certs[1] {
+		t.Errorf("filters = %q, %d certificates", m.filters, len(m.certificates))
+	}
+
+	m = pump(t, m, keyPress('u'))
+	if len(m.certificates) != 2 || m.filterType != "expired" || m.searchQuery != "" {
+		t.Errorf("u left %d certificates under %q", len(m.certificates), m.filterType)
+	}
+	m = runCommand(t, m, "back")
+	if m.filterActive || len(m.certificates) != 4 {
+		t.Errorf(":back left %d certificates, filterActive=%v", len(m.certificates), m.filterActive)
+	}
+	if m = runCommand(t, m, "back"); m.commandError != "no filter to undo" {
+		t.Errorf("commandError = %q", m.commandError)
+	}
+}
+
+func TestKeyAndSignatureFilters(t *testing.T) {
+	certs := createTestCertificates(2)
+	certs[1].Certificate.SignatureAlgorithm = x509.SHA1WithRSA
+	ec, _ := issueTestCert(t, "ec.example.com", false, nil, nil)
+	certs = append(certs, &certificate.Info{Certificate: ec})
+
+	for _, tc := range []struct {
+		filter string
+		want   int
+	}{
+		{"rsa", 2},
+		{"ecdsa", 1},
+		{"ed25519", 0},
+		{"keysize<2048", 1},
+		{"keysize >= 2048", 2},
+		{"keysize=256", 1},
+		{"sigalg=sha1", 1},
+		{"sigalg=ecdsa", 1},
+	} {
+		m := *NewModel(certs, loadTestConfig(t))
+		if m = m.filterCertificates(tc.filter); m.viewMode == ViewPopup || len(m.certificates) != tc.want {
+			t.Errorf("filter %s kept %d certificates, want %d (popup %q)", tc.filter, len(m.certificates), tc.want, m.popupMessage)
+		}
+	}
+
+	// Stacked, rsa and a small key size leave nothing.
+	m := *NewModel(certs, loadTestConfig(t))
+	if m = m.filterCertificates("rsa").filterCertificates("keysize<2048"); len(m.certificates) != 0 {
+		t.Errorf("rsa then keysize<2048 kept %d certificates", len(m.certificates))
+	}
+	if m = m.filterCertificates("keysize~2048"); m.viewMode != ViewPopup {
+		t.Error("a malformed keysize filter was accepted")
+	}
+}
+
+func TestIssuerFilter(t *testing.T) {
+	caCert, caKey := issueTestCert(t, "Acme Issuing CA 2", true, nil, nil)
+	leaf, _ := issueTestCert(t, "www.example.com", false, caCert, caKey)
+	others := createTestCertificates(1)
+	others[0].Certificate.Issuer.Organization = []string{"Acme Corp"}
+	certs := append(others, &certificate.Info{Certificate: caCert}, &certificate.Info{Certificate: leaf})
+
+	for _, tc := range []struct {
+		filter string
+		want   int
+	}{
+		{"issuer acme issuing ca 2", 2},
+		{"issuer Acme*", 3},
+		{"issuer acme", 0},
+		{"issuer acme corp", 1},
+	} {
+		m := *NewModel(certs, loadTestConfig(t))
+		if m = m.filterCertificates(tc.filter); len(m.certificates) != tc.want {
+			t.Errorf("filter %s kept %d certificates, want %d", tc.filter, len(m.certificates), tc.want)
+		}
+	}
+
+	m := *NewModel(certs, loadTestConfig(t))
+	if m = m.filterCertificates("issuer"); m.commandError != "usage: filter issuer <pattern>" {
+		t.Errorf("commandError = %q", m.commandError)
+	}
+}
+
+func TestSANFilter(t *testing.T) {
+	certs := createTestCertificates(3)
+	certs[0].Certificate.DNSNames = []string{"api.internal.example.com"}
+	certs[1].Certificate.DNSNames = []string{"*.internal.example.com"}
+	certs[2].Certificate.DNSNames = []string{"www.example.com"}
+
+	m := *NewModel(certs, loadTestConfig(t))
+	if m = m.filterCertificates("san *.internal.example.com"); len(m.certificates) != 2 {
+		t.Errorf("san *.internal.example.com kept %d certificates, want 2", len(m.certificates))
+	}
+	// A wildcard SAN covers a name one label under it.
+	m = m.resetView().filterCertificates("san db.internal.example.com")
+	if len(m.certificates) != 1 || m.certificates[0] != certs[1] {
+		t.Errorf("san db.internal.example.com kept %d certificates, want the wildcard", len(m.certificates))
+	}
+}
+
 func TestEmptyFilterKeepsFrame(t *testing.T) {
 	cfg := loadTestConfig(t)
 	m := *NewModel(createTestCertificates(3), cfg)
@@ -112,6 +242,67 @@ func TestSearchLogic(t *testing.T) {
 	})
 }
 
+func TestFuzzySearch(t *testing.T) {
+	certs := createTestCertificates(4)
+	certs[0].Certificate.Subject.CommonName = "rapid.example.com"
+	certs[1].Certificate.DNSNames = []string{"api.example.com"}
+	certs[2].Certificate.Subject.CommonName = "Unrelated"
+	certs[3].Certificate.SerialNumber = big.NewInt(0xdeadbeef)
+
+	t.Run("RanksByScore", func(t *testing.T) {
+		m := *NewModel(certs, loadTestConfig(t))
+		m = m.searchCertificates("api")
+		if len(m.certificates) != 2 || m.certificates[0] != certs[1] || m.certificates[1] != certs[0] {
+			t.Errorf("want the SAN starting with api first, then rapid, got %d results", len(m.certificates))
+		}
+	})
+
+	t.Run("Serial", func(t *testing.T) {
+		m := *NewModel(certs, loadTestConfig(t))
+		m = m.searchCertificates("dbeef")
+		if len(m.certificates) != 1 || m.certificates[0] != certs[3] {
+			t.Errorf("serial search matched %d certificates", len(m.certificates))
+		}
+	})
+
+	t.Run("ExactMode", func(t *testing.T) {
+		cfg := loadTestConfig(t)
+		cfg.SearchMode = config.SearchExact
+		m := *NewModel(certs, cfg)
+		if m = m.searchCertificates("aex"); len(m.certificates) != 0 {
+			t.Errorf("exact mode matched %d certificates fuzzily", len(m.certificates))
+		}
+		if m = m.searchCertificates("api"); len(m.certificates) != 2 {
+			t.Errorf("exact mode matched %d certificates for a substring, want 2", len(m.certificates))
+		}
+	})
+}
+
+func TestRegexSearch(t *testing.T) {
+	certs := createTestCertificates(3)
+	certs[0].Certificate.DNSNames = []string{"api-12.example.com"}
+	certs[1].Certificate.DNSNames = []string{"api-x.example.com"}
+	certs[2].Certificate.Subject.CommonName = "API-7.internal"
+
+	m := *NewModel(certs, loadTestConfig(t))
+	m.viewMode = ViewNormal
+	m = runCommand(t, m, `search /re:^api-\d+\./`)
+	if len(m.certificates) != 2 || m.certificates[0] != certs[0] || m.certificates[1] != certs[2] {
+		t.Errorf("re:^api-\\d+\\. matched %d certificates, want the two numbered ones", len(m.certificates))
+	}
+	if got := searchPositions(m.searchQuery, "DNS: api-12.example.com", false); len(got) != 0 {
+		t.Errorf("an anchored expression highlighted mid-line: %v", got)
+	}
+	if got := searchPositions("re:\\d+", "api-12", false); len(got) != 2 || got[0] != 4 {
+		t.Errorf("searchPositions = %v, want the digits", got)
+	}
+
+	before := len(m.certificates)
+	if m = runCommand(t, m, "search re:(api"); m.commandError == "" || len(m.certificates) != before {
+		t.Errorf("a bad expression was applied: %q, %d certificates", m.commandError, len(m.certificates))
+	}
+}
+
 func TestTabNavigation(t *testing.T) {
 	cfg := loadTestConfig(t)
 	m := *NewModel(createTestCertificates(1), cfg)
@@ -125,6 +316,35 @@ func TestTabNavigation(t *testing.T) {
 	if m.activeTab != 1 {
 		t.Errorf("Expected activeTab to be 1 after Tab, got %d", m.activeTab)
 	}
+
+	// Shift+Tab goes back, wrapping past the first tab.
+	for range 2 {
+		newModel, _ = m.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyTab, Mod: tea.ModShift}))
+		m = newModel.(Model)
+	}
+	if m.tabs[m.activeTab] != "Raw" {
+		t.Errorf("Expected Shift+Tab to wrap to Raw, got %q", m.tabs[m.activeTab])
+	}
+
+	// A number jumps straight to a tab, from the list as well.
+	m.focus = FocusLeft
+	newModel, _ = m.Update(tea.KeyPressMsg(tea.Key{Code: '6', Text: "6"}))
+	m = newModel.(Model)
+	if m.tabs[m.activeTab] != "Key" || m.focus != FocusRight {
+		t.Errorf("Expected 6 to show the Key tab focused, got %q focus=%v", m.tabs[m.activeTab], m.focus)
+	}
+}
+
+func TestOverviewTabSummarizes(t *testing.T) {
+	cfg := loadTestConfig(t)
+	m := *NewModel(createTestCertificates(1), cfg)
+	m.activeTab = 0
+	content := m.renderTabContent(80)
+	for _, want := range []string{"Test Certificate A", "Expires", "SHA256", "passed", "days left"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Overview missing %q:\n%s", want, content)
+		}
+	}
 }
 
 func TestPopupTransitions(t *testing.T) {
@@ -135,8 +355,8 @@ func TestPopupTransitions(t *testing.T) {
 	// Press '/' to search
 	updatedModel, _ := m.Update(tea.KeyPressMsg(tea.Key{Code: '/', Text: "/"}))
 	m = updatedModel.(Model)
-	if m.viewMode != ViewPopup || m.popupType != PopupSearch {
-		t.Errorf("Failed to enter Search popup")
+	if m.viewMode != ViewSearch {
+		t.Errorf("Failed to open the search line")
 	}
 
 	// Press Esc to cancel
@@ -151,11 +371,11 @@ func TestPopupTransitions(t *testing.T) {
 	m = updatedModel.(Model)
 
 	// Type 'test' and press Enter
-	m.textInput.SetValue("test")
+	m = pumpKeys(t, m, []rune("test")...)
 	updatedModel, _ = m.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
 	m = updatedModel.(Model)
 	if m.viewMode != ViewNormal {
-		t.Errorf("Expected return to ViewNormal after popup Enter")
+		t.Errorf("Expected return to ViewNormal after search Enter")
 	}
 	if m.searchQuery != "test" {
 		t.Errorf("Expected searchQuery to be 'test', got '%s'", m.searchQuery)
@@ -216,12 +436,12 @@ func TestExportLogic(t *testing.T) {
 		target := filepath.Join(t.TempDir(), "test_export.pem")
 
 		// Shadow m: a subtest should not mutate the model the others share.
-		m := m.handleExportCommand(target)
-		if m.viewMode != ViewPopup || m.popupType != PopupAlert {
-			t.Errorf("Expected PopupAlert after export")
+		m, cmd := m.handleExportCommand(target, false)
+		if m.viewMode == ViewPopup || cmd == nil {
+			t.Errorf("Expected a toast rather than a popup after export")
 		}
-		if !strings.Contains(m.popupMessage, "successfully") {
-			t.Errorf("Expected success message, got %q", m.popupMessage)
+		if lastToast(m) != "Exported to "+target {
+			t.Errorf("Expected success message, got %q", lastToast(m))
 		}
 		// Size matters here: an empty file is exactly what this PR is cleaning
 		// up, so a zero-byte export must not read as success.
@@ -236,9 +456,377 @@ func TestExportLogic(t *testing.T) {
 
 	t.Run("Export_Empty_Filename", func(t *testing.T) {
 		m = *NewModel(createTestCertificates(1), cfg)
-		m = m.handleExportCommand("")
+		m, _ = m.handleExportCommand("", false)
 		if m.viewMode != ViewSplash {
 			t.Errorf("Expected no change for empty filename, got viewMode=%v", m.viewMode)
 		}
 	})
+
+	t.Run("Export_AsksBeforeOverwriting", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "existing.pem")
+		if err := os.WriteFile(target, []byte("keep me"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		m := *NewModel(createTestCertificates(1), cfg)
+		m.viewMode = ViewNormal
+
+		m = runCommand(t, m, "export "+target)
+		if m.viewMode != ViewPopup || m.popupType != PopupConfirm || !strings.Contains(m.popupMessage, "already exists") {
+			t.Fatalf("no question before overwriting: %v %q", m.popupType, m.popupMessage)
+		}
+		m = pump(t, m, keyPress('n'))
+		if data, _ := os.ReadFile(target); string(data) != "keep me" || m.viewMode != ViewNormal {
+			t.Fatalf("n overwrote the file or left the popup up")
+		}
+		if !strings.Contains(lastToast(m), "left as it was") {
+			t.Errorf("toast = %q", lastToast(m))
+		}
+
+		m = runCommand(t, m, "export "+target)
+		m = pump(t, m, keyPress('y'))
+		if data, _ := os.ReadFile(target); !strings.HasPrefix(string(data), "-----BEGIN CERTIFICATE") {
+			t.Errorf("y did not overwrite the file: %q", data)
+		}
+
+		// A directory export asks about the files it would replace.
+		dir := t.TempDir()
+		m = runCommand(t, m, "export "+dir+"/")
+		m = runCommand(t, m, "export "+dir+"/")
+		if m.popupType != PopupConfirm || !strings.Contains(m.popupMessage, filepath.Base(certificate.FileName(m.certificates[0].Certificate, 1, "pem"))) {
+			t.Errorf("directory export did not ask: %q", m.popupMessage)
+		}
+	})
+
+	t.Run("Export_PublicKey", func(t *testing.T) {
+		m := *NewModel(createTestCertificates(2), cfg)
+		m.viewMode = ViewNormal
+		target := filepath.Join(t.TempDir(), "key.pem")
+
+		m = runCommand(t, m, "export pubkey "+target)
+		data, err := os.ReadFile(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "PUBLIC KEY" || !bytes.Equal(block.Bytes, m.certificates[0].Certificate.RawSubjectPublicKeyInfo) {
+			t.Errorf("not the selected certificate's public key:\n%s", data)
+		}
+		if !strings.Contains(lastToast(m), "public key") {
+			t.Errorf("toast = %q", lastToast(m))
+		}
+
+		// A second time, it asks; y writes the key again, as DER here.
+		der := filepath.Join(filepath.Dir(target), "key.der")
+		if err := os.WriteFile(der, []byte("keep me"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		m = runCommand(t, m, "export pubkey "+der)
+		if m.popupType != PopupConfirm {
+			t.Fatalf("no question before overwriting: %q", m.popupMessage)
+		}
+		m = pump(t, m, keyPress('y'))
+		if data, _ := os.ReadFile(der); !bytes.Equal(data, m.certificates[0].Certificate.RawSubjectPublicKeyInfo) {
+			t.Errorf("y did not write the DER key: %q", data)
+		}
+
+		// One key at a time: with two marked, there is no telling which.
+		m.marked = m.certificates
+		m = runCommand(t, m, "export pubkey "+target)
+		if m.popupType != PopupAlert || !strings.Contains(m.popupMessage, "one certificate") {
+			t.Errorf("two marked: %q", m.popupMessage)
+		}
+	})
+}
+
+// issueTestCert mints a certificate signed by parent, or self-signed when
+// parent is nil.
+func issueTestCert(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.DNSNames = []string{cn}
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent, parentKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// TestFetchedIssuerCompletesChain checks the AIA offer end to end, minus the
+// network: validating a chain with a missing intermediate offers the fetch,
+// and the fetched certificate is merged in and the chain validated again.
+func TestFetchedIssuerCompletesChain(t *testing.T) {
+	root, rootKey := issueTestCert(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issueTestCert(t, "Issuing CA", true, root, rootKey)
+	leaf, _ := issueTestCert(t, "leaf.example", false, intermediate, intermediateKey)
+	leaf.IssuingCertificateURL = []string{"http://ca.example/issuing.der"}
+
+	certs := []*certificate.Info{{Certificate: leaf}, {Certificate: root, Index: 1}}
+	m := *NewModel(certs, loadTestConfig(t))
+	m.SetDimensions(120, 40)
+	m.viewMode = ViewNormal
+	m.ready = true
+
+	m = m.handleValidateCommand()
+	if !strings.Contains(m.popupMessage, "Press f to fetch") {
+		t.Fatalf("validation did not offer the fetch:\n%s", m.popupMessage)
+	}
+
+	next, cmd := m.Update(keyPress('f'))
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("f did not start a fetch")
+	}
+
+	m = pump(t, m, issuerFetchedMsg{url: leaf.IssuingCertificateURL[0], certs: []*x509.Certificate{intermediate}})
+	if len(m.allCertificates) != 3 {
+		t.Fatalf("got %d certificates after the fetch, want 3", len(m.allCertificates))
+	}
+	if !m.certificates[m.list.Index()].Certificate.Equal(leaf) {
+		t.Error("the selection moved off the leaf")
+	}
+	if !strings.Contains(m.popupMessage, "SELF-ANCHORED") {
+		t.Errorf("the chain should now link up to its root:\n%s", m.popupMessage)
+	}
+}
+
+// TestValidateTrustsConfiguredRoots checks that the roots of the
+// configuration, or its profile, anchor a chain the system store does not.
+func TestValidateTrustsConfiguredRoots(t *testing.T) {
+	root, rootKey := issueTestCert(t, "Private Root", true, nil, nil)
+	leaf, _ := issueTestCert(t, "leaf.internal", false, root, rootKey)
+	path := filepath.Join(t.TempDir(), "roots.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := loadTestConfig(t)
+	cfg.Roots = path
+	m := *NewModel([]*certificate.Info{{Certificate: leaf}}, cfg)
+	m.SetDimensions(120, 40)
+	m.viewMode = ViewNormal
+	m.ready = true
+
+	m = m.handleValidateCommand()
+	if !strings.Contains(m.popupMessage, "TRUSTED") {
+		t.Errorf("the configured root did not anchor the chain:\n%s", m.popupMessage)
+	}
+}
+
+// TestRevocationIcons checks the list's revocation indicator: absent with
+// checking off, pending until a result arrives, then the result, which also
+// replaces the offline Revocation check.
+func TestRevocationIcons(t *testing.T) {
+	root, rootKey := issueTestCert(t, "Root", true, nil, nil)
+	leaf, _ := issueTestCert(t, "leaf.example", false, root, rootKey)
+	certs := []*certificate.Info{{Certificate: leaf}, {Certificate: root, Index: 1}}
+
+	cfg := loadTestConfig(t)
+	m := *NewModel(certs, cfg)
+	if m.newDelegate().revocation != nil {
+		t.Error("revocation indicator shown with checking off")
+	}
+
+	cfg.CheckRevocation = true
+	m = *NewModel(certs, cfg)
+	m.SetDimensions(120, 40)
+	m.viewMode = ViewNormal
+	m.ready = true
+	results := m.newDelegate().revocation
+	if icon, _ := revocationIcon(certs[0], results, m.Styles); icon != "…" {
+		t.Errorf("leaf before its check = %q, want …", icon)
+	}
+	if icon, _ := revocationIcon(certs[1], results, m.Styles); icon != " " {
+		t.Errorf("self-signed root = %q, want no indicator", icon)
+	}
+
+	m = pump(t, m, revocationCheckedMsg{info: certs[0], result: certificate.RevocationResult{
+		Status: certificate.RevocationRevoked, Source: "CRL http://crl.example/ca.crl", RevokedAt: time.Now(), Reason: 1,
+	}})
+	if icon, _ := revocationIcon(certs[0], m.newDelegate().revocation, m.Styles); icon != "✖" {
+		t.Errorf("revoked leaf = %q, want ✖", icon)
+	}
+	for _, check := range m.checksFor(certs[0]) {
+		if check.Name == "Revocation" && (check.Status != certificate.CheckFail || !strings.Contains(check.Detail, "keyCompromise")) {
+			t.Errorf("Revocation check = %v %q, want a failure naming the reason", check.Status, check.Detail)
+		}
+	}
+}
+
+// TestNetworkWorkShowsProgressAndCancels checks that background network work
+// is announced in the status bar and that esc cancels it: the checks still
+// out are settled as unknown, the fetch is abandoned, and results that come
+// back afterwards are dropped.
+func TestNetworkWorkShowsProgressAndCancels(t *testing.T) {
+	root, rootKey := issueTestCert(t, "Root", true, nil, nil)
+	leaf, _ := issueTestCert(t, "leaf.example", false, root, rootKey)
+	certs := []*certificate.Info{{Certificate: leaf}, {Certificate: root, Index: 1}}
+	esc := tea.KeyPressMsg{Code: tea.KeyEscape}
+
+	cfg := loadTestConfig(t)
+	cfg.CheckRevocation = true
+	m := *NewModel(certs, cfg)
+	m.SetDimensions(160, 40)
+	m.viewMode = ViewNormal
+	m.ready = true
+
+	status := ansi.Strip(m.renderStatusBar())
+	if !strings.Contains(status, "checking revocation 0/1") || !strings.Contains(status, "cancel") {
+		t.Errorf("status bar does not show the check in flight:\n%s", status)
+	}
+
+	ctx := m.netCtx
+	next, _ := m.Update(esc)
+	m = next.(Model)
+	if ctx.Err() == nil {
+		t.Error("esc did not cancel the checks")
+	}
+	if m.busy() {
+		t.Errorf("still busy after esc: %s", m.busyStatus())
+	}
+	if got := m.revocation[certs[0]]; got.Status != certificate.RevocationUnknown || got.Err != errCancelled {
+		t.Errorf("cancelled check = %v %v, want unknown, cancelled", got.Status, got.Err)
+	}
+	if _, cmd := m.handleSpinnerTick(spinner.TickMsg{ID: m.spinner.ID()}); cmd != nil {
+		t.Error("the spinner kept turning with nothing in flight")
+	}
+
+	m = pump(t, m, revocationCheckedMsg{ctx: ctx, info: certs[0], result: certificate.RevocationResult{Status: certificate.RevocationGood}})
+	if got := m.revocation[certs[0]]; got.Err != errCancelled {
+		t.Errorf("a late result replaced the cancelled one: %v", got.Status)
+	}
+
+	// A fetch from the validation popup shows in the status bar, and esc
+	// on its popup abandons it.
+	m.pendingIssuerURLs = []string{"http://ca.example/issuing.der"}
+	m.viewMode = ViewPopup
+	m.popupType = PopupAlert
+	next, cmd := m.Update(keyPress('f'))
+	m = next.(Model)
+	if cmd == nil || !strings.Contains(m.busyStatus(), "fetching issuer") {
+		t.Fatalf("f did not start a fetch: %q", m.busyStatus())
+	}
+	next, _ = m.Update(esc)
+	m = next.(Model)
+	if m.busy() || m.viewMode != ViewNormal {
+		t.Errorf("esc left the fetch running (%q) or the popup open", m.busyStatus())
+	}
+
+	late, cancel := context.WithCancel(context.Background())
+	cancel()
+	m = pump(t, m, issuerFetchedMsg{ctx: late, err: context.Canceled})
+	if m.viewMode != ViewNormal {
+		t.Errorf("a cancelled fetch reported back:\n%s", m.popupMessage)
+	}
+}
+
+// TestJumpToIssuer checks that i goes to the certificate that signed the
+// selected one, passing over another CA of the same name, and says so when
+// there is nowhere to go.
+func TestJumpToIssuer(t *testing.T) {
+	root, rootKey := issueTestCert(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issueTestCert(t, "Issuing CA", true, root, rootKey)
+	decoy, _ := issueTestCert(t, "Issuing CA", true, root, rootKey)
+	leaf, _ := issueTestCert(t, "leaf.example", false, intermediate, intermediateKey)
+	certs := []*certificate.Info{{Certificate: leaf}, {Certificate: decoy, Index: 1}, {Certificate: intermediate, Index: 2}, {Certificate: root, Index: 3}}
+
+	m := pump(t, *NewModel(certs, loadTestConfig(t)), tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	selected := func(m Model) *x509.Certificate { return m.certificates[m.list.Index()].Certificate }
+
+	m = m.selectCertificate(certs[0])
+	m = pump(t, m, keyPress('i'))
+	if selected(m) != intermediate {
+		t.Fatalf("i from the leaf selected %s", selected(m).Subject.CommonName)
+	}
+	m = pump(t, m, keyPress('i'))
+	if selected(m) != root {
+		t.Fatalf("i from the intermediate selected %s", selected(m).Subject.CommonName)
+	}
+	m = pump(t, m, keyPress('i'))
+	if selected(m) != root || !strings.Contains(m.commandError, "self-signed") {
+		t.Errorf("i from the root: commandError %q", m.commandError)
+	}
+
+	// Enter on the Issuer line of the details goes there too.
+	m = m.selectCertificate(certs[0])
+	m.focus = FocusRight
+	for range 10 {
+		if m.onIssuerLine() {
+			break
+		}
+		m = m.moveDetailCursor(1)
+	}
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if selected(m) != intermediate || m.fullscreen {
+		t.Errorf("enter on the Issuer line selected %s (fullscreen %v)", selected(m).Subject.CommonName, m.fullscreen)
+	}
+
+	m = pump(t, *NewModel(certs[:3], loadTestConfig(t)), tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	m = pump(t, m.selectCertificate(certs[2]), keyPress('i'))
+	if m.commandError != "issuer Root is not present" {
+		t.Errorf("commandError = %q", m.commandError)
+	}
+}
+
+// TestJumpToRootAndLeaf checks R and L go to the ends of the selected
+// certificate's chain, and stay off a chain loaded alongside it.
+func TestJumpToRootAndLeaf(t *testing.T) {
+	root, rootKey := issueTestCert(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issueTestCert(t, "Issuing CA", true, root, rootKey)
+	leaf, _ := issueTestCert(t, "leaf.example", false, intermediate, intermediateKey)
+	other, otherKey := issueTestCert(t, "Other Root", true, nil, nil)
+	otherLeaf, _ := issueTestCert(t, "other.example", false, other, otherKey)
+	certs := []*certificate.Info{{Certificate: otherLeaf}, {Certificate: other, Index: 1}, {Certificate: root, Index: 2}, {Certificate: intermediate, Index: 3}, {Certificate: leaf, Index: 4}}
+
+	m := pump(t, *NewModel(certs, loadTestConfig(t)), tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	selected := func(m Model) *x509.Certificate { return m.certificates[m.list.Index()].Certificate }
+
+	m = pump(t, m.selectCertificate(certs[3]), keyPress('R'))
+	if selected(m) != root || m.commandError != "" {
+		t.Fatalf("R selected %s (%q)", selected(m).Subject.CommonName, m.commandError)
+	}
+	m = pump(t, m, keyPress('L'))
+	if selected(m) != leaf {
+		t.Fatalf("L selected %s", selected(m).Subject.CommonName)
+	}
+
+	// Without its root, R goes as high as the chain does.
+	m = pump(t, *NewModel(certs[3:], loadTestConfig(t)), tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	m = pump(t, m.selectCertificate(certs[4]), keyPress('R'))
+	if selected(m) != intermediate || !strings.Contains(m.commandError, "root Root is not present") {
+		t.Errorf("R without the root selected %s (%q)", selected(m).Subject.CommonName, m.commandError)
+	}
 }
@@ -1,18 +1,22 @@
 package model
 
 import (
+	"context"
 	"crypto/x509"
+	"maps"
 	"time"
 
-	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/list"
+	"charm.land/bubbles/v2/spinner"
 	"charm.land/bubbles/v2/textinput"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/huh/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/internal/logger"
 	"github.com/kanywst/y509/pkg/certificate"
+	"go.uber.org/zap"
 )
 
 type (
@@ -37,6 +41,7 @@ type Styles struct {
 	CommandBar    lipgloss.Style
 	CommandError  lipgloss.Style
 	Highlight     lipgloss.Style
+	SearchMatch   lipgloss.Style
 	HighlightDim  lipgloss.Style
 	StatusValid   lipgloss.Style
 	StatusWarning lipgloss.Style
@@ -76,6 +81,7 @@ func NewStyles(theme *config.Theme) Styles {
 		CommandBar:    lipgloss.NewStyle().Background(lipgloss.Color(theme.CommandBar)).Foreground(lipgloss.Color(theme.CommandBarText)),
 		CommandError:  lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Error)).Bold(true),
 		Highlight:     lipgloss.NewStyle().Background(lipgloss.Color(theme.Highlight)).Foreground(lipgloss.Color(theme.HighlightText)).Bold(true),
+		SearchMatch:   lipgloss.NewStyle().Background(lipgloss.Color(theme.StatusWarning)).Foreground(lipgloss.Color(theme.HighlightText)).Bold(true),
 		HighlightDim:  lipgloss.NewStyle().Background(lipgloss.Color(theme.HighlightDim)).Foreground(lipgloss.Color(theme.Text)),
 		StatusValid:   lipgloss.NewStyle().Foreground(lipgloss.Color(theme.StatusValid)),
 		StatusWarning: lipgloss.NewStyle().Foreground(lipgloss.Color(theme.StatusWarning)),
@@ -105,10 +111,37 @@ func NewStyles(theme *config.Theme) Styles {
 	}
 }
 
+// stylesFor builds the styles for a configuration: its theme, drawn without
+// colour when NoColor is set.
+func stylesFor(cfg *config.Config) Styles {
+	styles := NewStyles(&cfg.Theme)
+	if cfg.NoColor {
+		styles = styles.withoutColor()
+	}
+	return styles
+}
+
+// withoutColor marks with attributes what the styles otherwise mark with
+// colour alone. Once colours are dropped, the selected row, the matches of a
+// search and the focused pane would look like everything else.
+func (s Styles) withoutColor() Styles {
+	s.Highlight = s.Highlight.Reverse(true)
+	s.StatusBarKey = s.StatusBarKey.Reverse(true)
+	s.SearchMatch = s.SearchMatch.Reverse(true).Underline(true)
+	s.HighlightDim = s.HighlightDim.Underline(true)
+	s.TabActive = s.TabActive.Underline(true)
+	s.PaneFocus = s.PaneFocus.Border(lipgloss.ThickBorder(), true)
+	return s
+}
+
 // Model represents the application state
 type Model struct {
 	certificates    []*certificate.Info // Filtered list of certificates
 	allCertificates []*certificate.Info // Original unfiltered list
+	sources         []string            // Files loaded, when there is more than one
+	columns         []string            // Columns of the list, left to right
+	fullscreen      bool                // Details pane widened over the list
+	activeSource    int                 // File tab: 0 is "All", i is sources[i-1]
 	width           int                 // Window width
 	height          int                 // Window height
 	ready           bool                // Whether dimensions are initialized
@@ -127,22 +160,137 @@ type Model struct {
 	list     list.Model
 	viewport viewport.Model
 
+	// Marked certificates, in the order they were marked, and the pair the
+	// diff view is showing.
+	marked []*certificate.Info
+	// Certificates hidden from the working set with x, in the order they
+	// were hidden, for :unhide to bring back.
+	hidden []hiddenCert
+	// Bookmarks by letter, set with m and jumped to with '. pendingKey is
+	// what the first key of such a sequence, or of gg and gt, started while
+	// the second is awaited. count is a count typed before a motion, as in 5j, and
+	// countFrom the details as they were when it began.
+	bookmarks    map[string]*certificate.Info
+	pendingKey   string
+	count        int
+	countFrom    *countStart
+	diffPair     [2]*certificate.Info
+	diffViewport viewport.Model
+
+	// The last command run by :pipe, what it wrote, and how it exited when
+	// that was not cleanly.
+	pipeCommand  string
+	pipeOutput   string
+	pipeStatus   string
+	pipeViewport viewport.Model
+
 	// Popup state
 	popupType    PopupType
 	popupMessage string
 	textInput    textinput.Model
 	exportForm   *huh.Form
+	// pendingExport is the export the confirm popup is asking about.
+	pendingExport *pendingExport
 
-	// Key bindings and help
+	// Command line state. commandError is the last command's failure, shown
+	// in place of the status bar until the next key press.
+	commandInput textinput.Model
+	commandError string
+	// Tab completion: the candidates for the word under completion, the
+	// line before that word, and the candidate shown (-1 for none yet).
+	completions      []string
+	completionPrefix string
+	completionIndex  int
+
+	// Search line state. preSearchFilters are the filters in force when the
+	// line opened, put back on Esc. matchLines are the lines of the details
+	// holding the query, and matchIndex the one n last jumped to. With
+	// findingDetails set, the line finds findQuery within the details
+	// rather than searching the list.
+	searchInput      textinput.Model
+	preSearchFilters []string
+	matchLines       []int
+	matchIndex       int
+	findingDetails   bool
+	findQuery        string
+
+	// detailRow is the row of the details cursor within the viewport.
+	detailRow int
+	// noWrap cuts long lines of the details at the pane's edge rather than
+	// wrapping them, after :wrap off.
+	noWrap bool
+
+	// Key bindings
 	keys keyMap
-	help help.Model
 
-	// Internal state for logic
+	// Help view state. helpSearching is true while the '/' line inside help
+	// is open; its query stays in force after enter, until esc.
+	helpViewport  viewport.Model
+	helpInput     textinput.Model
+	helpSearching bool
+
+	// themeBeforePreview is the theme in force when the command line began
+	// previewing themes, put back if the line is abandoned.
+	themeBeforePreview string
+
+	// Internal state for logic. filters is the stack of filters and
+	// "search: <query>" entries narrowing the list, oldest first; u pops
+	// the last. filterType names them all, and searchQuery is the last
+	// search among them.
 	detailField  string
 	detailValue  string
+	filters      []string
 	searchQuery  string
 	filterActive bool
 	filterType   string
+
+	// Known CT logs, for verifying embedded SCTs. Nil when none configured.
+	ctLogs certificate.CTLogList
+	// trustRoots are the configured roots, trusted besides the system
+	// store when validating. Nil when none configured.
+	trustRoots []*x509.Certificate
+	// configStamp is the configuration file as last read, to tell when it
+	// changes; loadConfig reads it again, nil for config.LoadConfig.
+	configStamp configStamp
+	loadConfig  func() (*config.Config, error)
+
+	// validateAt is the time the last validation was run at; zero means now.
+	validateAt time.Time
+
+	// AIA URLs of an issuer the last validation found missing, offered for
+	// fetching from the validation popup.
+	pendingIssuerURLs []string
+
+	// The background chain check behind the status bar summary. chainGen
+	// counts the checks started, so only the latest one's result is kept.
+	chainGen     int
+	chainChecked bool
+	chainLevel   certificate.TrustLevel
+	chainErr     error
+
+	// expiredSeen is how many certificates had expired at the last refresh.
+	expiredSeen int
+
+	// Revocation results by certificate, as the background checks come in.
+	revocation map[*certificate.Info]certificate.RevocationResult
+
+	// Network work (AIA fetches, revocation checks) runs in the background
+	// under netCtx, and esc cancels it through netCancel. fetchCancel is set
+	// while an AIA fetch is out, so closing its popup can cancel just that.
+	// The spinner turns in the status bar while anything is in flight.
+	netCtx      context.Context
+	netCancel   context.CancelFunc
+	fetchCancel context.CancelFunc
+	spinner     spinner.Model
+
+	// Notifications on screen, oldest first. toastSeq numbers them, so each
+	// timeout takes down its own.
+	toasts   []toast
+	toastSeq int
+
+	// startupCommands are ':' commands to run once the window size is
+	// known, as the TUI opens.
+	startupCommands []string
 }
 
 // SetDimensions sets the width and height of the model (for testing only)
@@ -182,63 +330,89 @@ func NewModel(certs []*certificate.Info, cfg *config.Config) *Model {
 	if cfg.ExpiryWarningDays <= 0 {
 		cfg.ExpiryWarningDays = config.DefaultExpiryWarningDays
 	}
-
-	// Sort and validate the certificate chain
-	var sortedCerts []*certificate.Info
-	if len(certs) > 0 {
-		rawCerts := make([]*x509.Certificate, len(certs))
-		for i, c := range certs {
-			rawCerts[i] = c.Certificate
+	if cfg.ToastTimeout <= 0 {
+		cfg.ToastTimeout = config.DefaultToastTimeout
+	}
+	// ...and the theme they set is the one :theme default goes back to.
+	if _, ok := cfg.Themes[config.DefaultThemeName]; !ok {
+		cfg.Themes = maps.Clone(cfg.Themes)
+		if cfg.Themes == nil {
+			cfg.Themes = make(map[string]config.Theme)
 		}
-		// Sort the raw certificates
-		sortedRawCerts, _ := certificate.SortChain(rawCerts)
-
-		// Map raw certificates to their Info wrappers for efficient lookup.
-		// Use fingerprint as key, and a slice of wrappers to handle potential duplicates
-		// in the input (preserving their distinct metadata like original index).
-		certMap := make(map[string][]*certificate.Info)
-		for _, c := range certs {
-			fingerprint := certificate.FormatFingerprint(c.Certificate)
-			certMap[fingerprint] = append(certMap[fingerprint], c)
+		cfg.Themes[config.DefaultThemeName] = cfg.Theme
+		if cfg.ThemeName == "" {
+			cfg.ThemeName = config.DefaultThemeName
 		}
+	}
 
-		// Build sorted list of Info
-		sortedCerts = make([]*certificate.Info, len(sortedRawCerts))
-		for i, rawCert := range sortedRawCerts {
-			fingerprint := certificate.FormatFingerprint(rawCert)
-			if infos, ok := certMap[fingerprint]; ok && len(infos) > 0 {
-				// Take the first available wrapper for this fingerprint
-				sortedCerts[i] = infos[0]
-				// Remove it from the map slice so duplicates use the next available wrapper
-				certMap[fingerprint] = infos[1:]
-			} else {
-				// Safeguard: Create a new wrapper if not found in map (should not happen if SortChain only reorders)
-				sortedCerts[i] = &certificate.Info{
-					Certificate: rawCert,
-				}
-			}
-		}
-		certificate.ValidateChainLinks(sortedCerts)
+	sortedCerts := sortBySource(certs)
+	sources := sourceNames(sortedCerts)
+	if len(sources) < 2 {
+		sources = nil
 	}
 
-	tabs := []string{"Subject", "Issuer", "Validity", "SANs", "Misc"}
+	tabs := []string{"Overview", "Subject", "Issuer", "Validity", "SANs", "Key", "Misc", "Extensions", "Validation", "Text", "Raw"}
 
 	ti := textinput.New()
-	tiStyles := textinput.DefaultDarkStyles()
-	tiStyles.Cursor.Color = lipgloss.Color(cfg.Theme.Highlight)
+	tiStyles := newInputStyles(&cfg.Theme)
 	ti.SetStyles(tiStyles)
 	ti.Focus()
 
-	helpModel := help.New()
-	helpModel.Styles = help.DefaultDarkStyles()
+	ci := textinput.New()
+	ci.Prompt = ":"
+	ci.SetStyles(tiStyles)
+
+	si := textinput.New()
+	si.Prompt = "/"
+	si.SetStyles(tiStyles)
+
+	// Help wraps rather than cutting descriptions off on a narrow terminal.
+	hv := viewport.New()
+	hv.SoftWrap = true
+	hi := textinput.New()
+	hi.Prompt = "/"
+	hi.SetStyles(tiStyles)
 
 	vp := viewport.New()
 	vp.MouseWheelEnabled = false
 	vp.SoftWrap = true
 
-	styles := NewStyles(&cfg.Theme)
+	styles := stylesFor(cfg)
 
-	delegate := certDelegate{styles: styles, warnDays: cfg.ExpiryWarningDays}
+	// A missing or broken log list is not worth refusing to start over: SCTs
+	// are still decoded, just not verified.
+	var ctLogs certificate.CTLogList
+	if cfg.CTLogList != "" {
+		var err error
+		if ctLogs, err = certificate.LoadCTLogList(cfg.CTLogList); err != nil {
+			logger.Log.Warn("failed to load CT log list", zap.String("path", cfg.CTLogList), zap.Error(err))
+		}
+	}
+	// Nor are roots that cannot be read: validation falls back to the
+	// system store alone.
+	var trustRoots []*x509.Certificate
+	if cfg.Roots != "" {
+		roots, err := certificate.LoadCertificates(cfg.Roots)
+		if err != nil {
+			logger.Log.Warn("failed to load trust roots", zap.String("path", cfg.Roots), zap.Error(err))
+		}
+		for _, root := range roots {
+			trustRoots = append(trustRoots, root.Certificate)
+		}
+	}
+
+	columns, err := parseColumns(cfg.Columns)
+	if err != nil {
+		logger.Log.Warn("invalid list columns, using the default", zap.Strings("columns", cfg.Columns), zap.Error(err))
+		columns = config.DefaultColumns
+	}
+
+	keys, err := defaultKeyMap().withConfig(cfg.Keys)
+	if err != nil {
+		logger.Log.Warn("invalid key bindings, keeping the defaults for them", zap.Error(err))
+	}
+
+	delegate := certDelegate{styles: styles, warnDays: cfg.ExpiryWarningDays, columns: columns}
 	listModel := list.New(toListItems(sortedCerts), delegate, 0, 0)
 	listModel.SetShowTitle(false)
 	listModel.SetShowStatusBar(false)
@@ -247,21 +421,42 @@ func NewModel(certs []*certificate.Info, cfg *config.Config) *Model {
 	listModel.SetShowPagination(false)
 	listModel.SetFilteringEnabled(false)
 
+	netCtx, netCancel := context.WithCancel(context.Background())
+
+	viewMode := ViewSplash
+	if cfg.NoSplash {
+		viewMode = ViewNormal
+	}
+
 	return &Model{
 		certificates:    sortedCerts,
 		allCertificates: sortedCerts,
+		sources:         sources,
 		ready:           false,
-		viewMode:        ViewSplash,
+		viewMode:        viewMode,
 		focus:           FocusLeft,
 		tabs:            tabs,
 		activeTab:       0,
 		list:            listModel,
 		viewport:        vp,
+		diffViewport:    viewport.New(),
+		pipeViewport:    viewport.New(),
 		Config:          cfg,
 		Styles:          styles,
 		textInput:       ti,
-		keys:            defaultKeyMap(),
-		help:            helpModel,
+		commandInput:    ci,
+		searchInput:     si,
+		columns:         columns,
+		keys:            keys,
+		helpViewport:    hv,
+		helpInput:       hi,
+		ctLogs:          ctLogs,
+		trustRoots:      trustRoots,
+		configStamp:     currentConfigStamp(),
+		expiredSeen:     countExpired(sortedCerts),
+		netCtx:          netCtx,
+		netCancel:       netCancel,
+		spinner:         newSpinner(cfg),
 		// Logic fields
 		detailField:  "",
 		detailValue:  "",
@@ -273,8 +468,78 @@ func NewModel(certs []*certificate.Info, cfg *config.Config) *Model {
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	// Wait a bit for the splash screen to be visible
-	return tea.Tick(time.Millisecond*500, func(_ time.Time) tea.Msg {
-		return SplashDoneMsg{}
-	})
+	// Wait a bit for the splash screen to be visible, checking the chain
+	// for the status bar meanwhile, and start the clock that keeps expiry
+	// current and the look out for changes to the configuration file.
+	// Without the splash there is nothing to wait for.
+	var splash tea.Cmd
+	if m.viewMode == ViewSplash {
+		splash = tea.Tick(time.Millisecond*500, func(_ time.Time) tea.Msg {
+			return SplashDoneMsg{}
+		})
+	}
+	if len(m.allCertificates) == 0 {
+		return tea.Batch(splash, configPollTick())
+	}
+	return tea.Batch(splash, refreshTick(), configPollTick(), checkChainCmd(m.chainGen, m.chainGroups(), m.verifyOptions()), m.checkRevocationCmds(), m.spin())
+}
+
+// newDelegate builds the list delegate from the current styles and marks.
+func (m Model) newDelegate() certDelegate {
+	return certDelegate{
+		styles:    m.Styles,
+		warnDays:  m.Config.ExpiryWarningDays,
+		marked:    m.marked,
+		bookmarks: m.bookmarks,
+		columns:   m.columns,
+		query:     m.searchQuery,
+		exact:     m.exactSearch(),
+		// revocation stays nil unless checking is enabled, which hides the
+		// indicator altogether.
+		revocation: m.revocationResults(),
+	}
+}
+
+// sortInfos orders certificates leaf first and links each to its issuer. The
+// Info wrappers are reused rather than rebuilt so per-file metadata survives
+// the sort.
+func sortInfos(certs []*certificate.Info) []*certificate.Info {
+	if len(certs) == 0 {
+		return nil
+	}
+
+	rawCerts := make([]*x509.Certificate, len(certs))
+	for i, c := range certs {
+		rawCerts[i] = c.Certificate
+	}
+	// Sort the raw certificates
+	sortedRawCerts, _ := certificate.SortChain(rawCerts)
+
+	// Map raw certificates to their Info wrappers for efficient lookup.
+	// Use fingerprint as key, and a slice of wrappers to handle potential duplicates
+	// in the input (preserving their distinct metadata like original index).
+	certMap := make(map[string][]*certificate.Info)
+	for _, c := range certs {
+		fingerprint := certificate.FormatFingerprint(c.Certificate)
+		certMap[fingerprint] = append(certMap[fingerprint], c)
+	}
+
+	// Build sorted list of Info
+	sortedCerts := make([]*certificate.Info, len(sortedRawCerts))
+	for i, rawCert := range sortedRawCerts {
+		fingerprint := certificate.FormatFingerprint(rawCert)
+		if infos, ok := certMap[fingerprint]; ok && len(infos) > 0 {
+			// Take the first available wrapper for this fingerprint
+			sortedCerts[i] = infos[0]
+			// Remove it from the map slice so duplicates use the next available wrapper
+			certMap[fingerprint] = infos[1:]
+		} else {
+			// Safeguard: Create a new wrapper if not found in map (should not happen if SortChain only reorders)
+			sortedCerts[i] = &certificate.Info{
+				Certificate: rawCert,
+			}
+		}
+	}
+	certificate.ValidateChainLinks(sortedCerts)
+	return sortedCerts
 }
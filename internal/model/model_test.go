@@ -117,15 +117,12 @@ func TestUpdate(t *testing.T) {
 		t.Errorf("Expected cursor to be 1, got %d", m.list.Index())
 	}
 
-	// Test entering Popup mode
+	// Test opening the search line
 	m.viewMode = ViewNormal
 	updatedModel, _ = m.Update(tea.KeyPressMsg(tea.Key{Code: '/', Text: "/"}))
 	m = updatedModel.(Model)
-	if m.viewMode != ViewPopup {
-		t.Errorf("Expected view mode to be ViewPopup after '/' key, got %v", m.viewMode)
-	}
-	if m.popupType != PopupSearch {
-		t.Errorf("Expected popup type to be PopupSearch, got %v", m.popupType)
+	if m.viewMode != ViewSearch {
+		t.Errorf("Expected view mode to be ViewSearch after '/' key, got %v", m.viewMode)
 	}
 
 	m = *NewModel(createTestCertificates(3), cfg)
@@ -0,0 +1,183 @@
+package model
+
+import (
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// updateMouseClick handles a left click. In the normal view it focuses the
+// pane clicked and selects the list row, file tab or detail tab under the
+// pointer; in a popup it presses the hint's buttons, and a click outside an
+// alert dismisses it. The hit tests follow the layout the renderers draw.
+func (m Model) updateMouseClick(msg tea.MouseClickMsg) (tea.Model, tea.Cmd) {
+	if msg.Button != tea.MouseLeft {
+		return m, nil
+	}
+	switch m.viewMode {
+	case ViewSplash:
+		m.viewMode = ViewNormal
+		return m, nil
+	case ViewNormal:
+		return m.clickNormalView(msg.X, msg.Y)
+	case ViewPopup:
+		return m.clickPopup(msg.X, msg.Y)
+	}
+	return m, nil
+}
+
+// clickNormalView handles a click on the list or details pane.
+func (m Model) clickNormalView(x, y int) (tea.Model, tea.Cmd) {
+	paneTop := HeaderHeight
+	if len(m.certificates) == 0 || y <= paneTop || y >= m.height-statusBarHeight {
+		return m, nil
+	}
+	row := y - paneTop - 1 // first row inside the top border
+
+	left, right := m.paneWidths()
+	if x >= left {
+		m.focus = FocusRight
+		if row < 2 { // the tab strip: label and underline
+			if tab := m.tabAt(x-left-1, right); tab >= 0 && tab != m.activeTab {
+				m.activeTab = tab
+				m.viewport.SetYOffset(0)
+				m = m.refreshViewportContent()
+			}
+		}
+		return m, nil
+	}
+
+	m.focus = FocusLeft
+	if len(m.sources) > 0 {
+		if row == 0 {
+			if tab := m.sourceTabAt(x-1, left-PaneSideBorderWidth); tab >= 0 && tab != m.activeSource {
+				return m.selectSource(tab).checkChain()
+			}
+			return m, nil
+		}
+		row -= sourceBarHeight
+	}
+	row -= ListHeaderHeight
+	if row < 0 || row >= m.list.Paginator.ItemsOnPage(len(m.list.VisibleItems())) {
+		return m, nil
+	}
+	if index := m.list.Paginator.Page*m.list.Paginator.PerPage + row; index != m.list.Index() {
+		m.list.Select(index)
+		m.viewport.SetYOffset(0)
+		m = m.refreshViewportContent()
+	}
+	return m, nil
+}
+
+// tabAt is the detail tab at column x of the tab strip in a pane width
+// wide, or -1. In the compact form the arrows step to the neighbouring tab.
+func (m Model) tabAt(x, width int) int {
+	if len(m.tabs) == 0 || x < 0 {
+		return -1
+	}
+	activeIdx := m.activeTab
+	if activeIdx < 0 || activeIdx >= len(m.tabs) {
+		activeIdx = 0
+	}
+
+	total := 0
+	for _, t := range m.tabs {
+		total += lipgloss.Width(t) + 4
+	}
+	if budget := width - 2; budget > 0 && total <= budget {
+		start := 0
+		for i, t := range m.tabs {
+			end := start + lipgloss.Width(t) + 4
+			if x < end {
+				return i
+			}
+			start = end
+		}
+		return -1
+	}
+
+	// "‹ Active ›  i/n"
+	arrowWidth := lipgloss.Width("‹ ")
+	next := arrowWidth + lipgloss.Width(m.tabs[activeIdx])
+	switch {
+	case x < arrowWidth:
+		return (activeIdx + len(m.tabs) - 1) % len(m.tabs)
+	case x >= next && x < next+arrowWidth:
+		return (activeIdx + 1) % len(m.tabs)
+	}
+	return -1
+}
+
+// sourceTabAt is the file tab at column x of the file tab row, width wide,
+// or -1, laid out as renderSourceTabs draws it.
+func (m Model) sourceTabAt(x, width int) int {
+	if x < 0 {
+		return -1
+	}
+	labels := m.sourceTabLabels()
+	widths := make([]int, len(labels))
+	total := 0
+	for i, label := range labels {
+		widths[i] = lipgloss.Width(label) + 2
+		total += widths[i]
+	}
+	if total <= width {
+		start := 0
+		for i, w := range widths {
+			if x < start+w {
+				return i
+			}
+			start += w
+		}
+		return -1
+	}
+
+	arrowWidth := lipgloss.Width("‹ ")
+	next := arrowWidth + lipgloss.Width(truncateText(labels[m.activeSource], max(1, width-12)))
+	switch {
+	case x < arrowWidth:
+		return (m.activeSource + len(labels) - 1) % len(labels)
+	case x >= next && x < next+arrowWidth:
+		return (m.activeSource + 1) % len(labels)
+	}
+	return -1
+}
+
+// clickPopup handles a click while a popup is open. The hint line holds
+// the buttons: dismiss on an alert, confirm and cancel on the others. They
+// act as the keys they name would.
+func (m Model) clickPopup(x, y int) (tea.Model, tea.Cmd) {
+	box := m.popupBox()
+	boxWidth, boxHeight := lipgloss.Width(box), lipgloss.Height(box)
+	boxLeft, boxTop := (m.width-boxWidth)/2, (m.height-boxHeight)/2
+	enter := tea.KeyPressMsg{Code: tea.KeyEnter}
+	esc := tea.KeyPressMsg{Code: tea.KeyEscape}
+
+	outside := x < boxLeft || x >= boxLeft+boxWidth || y < boxTop || y >= boxTop+boxHeight
+	if outside {
+		if m.popupType == PopupAlert {
+			return m.updatePopupMode(esc)
+		}
+		return m, nil
+	}
+	// Bottom border and padding lie below the hint.
+	if y != boxTop+boxHeight-3 {
+		return m, nil
+	}
+	if m.popupType == PopupAlert {
+		return m.updatePopupMode(enter)
+	}
+
+	// The hint is centred in the box, inside its border and padding.
+	const frame = 3
+	innerWidth := boxWidth - 2*frame
+	confirm := lipgloss.Width(popupConfirmHint)
+	hintWidth := confirm + lipgloss.Width(popupHintSep) + lipgloss.Width(popupCancelHint)
+	hintLeft := boxLeft + frame + (innerWidth-hintWidth)/2
+	switch {
+	case x >= hintLeft && x < hintLeft+confirm:
+		return m.updatePopupMode(enter)
+	case x >= hintLeft+hintWidth-lipgloss.Width(popupCancelHint) && x < hintLeft+hintWidth:
+		return m.updatePopupMode(esc)
+	}
+	return m, nil
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// selectCertificate moves the cursor to target. One hidden by a search,
+// filter or file tab is brought back by clearing them.
+func (m Model) selectCertificate(target *certificate.Info) Model {
+	if !slices.Contains(m.certificates, target) {
+		m.activeSource = 0
+		m = m.resetView()
+	}
+	if i := slices.Index(m.certificates, target); i >= 0 {
+		m.list.Select(i)
+		m.viewport.SetYOffset(0)
+		m = m.refreshViewportContent()
+	}
+	return m
+}
+
+// onIssuerLine reports whether the details cursor is on a line about the
+// issuer: the Issuer row of a tab, or any line of the Issuer tab.
+func (m Model) onIssuerLine() bool {
+	if m.focus != FocusRight {
+		return false
+	}
+	if m.tabs[m.activeTab] == "Issuer" {
+		return true
+	}
+	lines := strings.Split(m.viewport.GetContent(), "\n")
+	cursor := m.detailCursor()
+	return cursor < len(lines) && strings.HasPrefix(strings.TrimSpace(ansi.Strip(lines[cursor])), "Issuer")
+}
+
+// jumpToIssuer selects the loaded certificate that issued the selected one:
+// the one whose subject, key identifier and key match its issuer and
+// signature, not merely one with the issuer's name.
+func (m Model) jumpToIssuer() Model {
+	if len(m.certificates) == 0 {
+		return m
+	}
+	selected := m.certificates[m.list.Index()]
+	if certificate.IsSelfSigned(selected.Certificate) {
+		m.commandError = fmt.Sprintf("%s is self-signed: it is its own issuer", orNone(selected.Certificate.Subject.CommonName))
+		return m
+	}
+
+	if issuer := m.issuerOf(selected); issuer != nil {
+		return m.selectCertificate(issuer)
+	}
+
+	m.commandError = fmt.Sprintf("issuer %s is not present", orNone(selected.Certificate.Issuer.CommonName))
+	if slices.ContainsFunc(m.hidden, func(h hiddenCert) bool {
+		return certificate.IssuerOf(selected.Certificate, []*x509.Certificate{h.info.Certificate}) != nil
+	}) {
+		m.commandError = fmt.Sprintf("issuer %s is hidden; :unhide brings it back", orNone(selected.Certificate.Issuer.CommonName))
+	}
+	return m
+}
+
+// pool is the certificates of the working set, for the chain functions of
+// the certificate package.
+func (m Model) pool() []*x509.Certificate {
+	pool := make([]*x509.Certificate, len(m.allCertificates))
+	for i, info := range m.allCertificates {
+		pool[i] = info.Certificate
+	}
+	return pool
+}
+
+// issuerOf is the certificate in the working set that issued info, or nil.
+func (m Model) issuerOf(info *certificate.Info) *certificate.Info {
+	pool := m.pool()
+	if issuer := certificate.IssuerOf(info.Certificate, pool); issuer != nil {
+		return m.allCertificates[slices.Index(pool, issuer)]
+	}
+	return nil
+}
+
+// chainTop follows the selected certificate's issuers up as far as the
+// working set goes: to the root, or to the last certificate whose issuer is
+// not loaded. A loop of cross-signatures stops where it comes round again.
+func (m Model) chainTop(info *certificate.Info) *certificate.Info {
+	seen := map[*certificate.Info]bool{info: true}
+	for {
+		issuer := m.issuerOf(info)
+		if issuer == nil || seen[issuer] {
+			return info
+		}
+		seen[issuer] = true
+		info = issuer
+	}
+}
+
+// jumpToRoot selects the root of the selected certificate's chain. When the
+// chain stops short of a self-signed root, it goes as far as it can and
+// says so.
+func (m Model) jumpToRoot() Model {
+	if len(m.certificates) == 0 {
+		return m
+	}
+	top := m.chainTop(m.certificates[m.list.Index()])
+	m = m.selectCertificate(top)
+	if !certificate.IsSelfSigned(top.Certificate) {
+		m.commandError = fmt.Sprintf("root %s is not present; the chain goes no higher", orNone(top.Certificate.Issuer.CommonName))
+	}
+	return m
+}
+
+// jumpToLeaf selects the first leaf, in list order, of the selected
+// certificate's chain: the first certificate under the same top that issued
+// none of the others.
+func (m Model) jumpToLeaf() Model {
+	if len(m.certificates) == 0 {
+		return m
+	}
+	top := m.chainTop(m.certificates[m.list.Index()])
+	issuing := make(map[*certificate.Info]bool)
+	for _, info := range m.allCertificates {
+		if issuer := m.issuerOf(info); issuer != nil {
+			issuing[issuer] = true
+		}
+	}
+	for _, info := range m.allCertificates {
+		if !issuing[info] && m.chainTop(info) == top {
+			return m.selectCertificate(info)
+		}
+	}
+	m.commandError = fmt.Sprintf("%s has no leaf loaded below it", orNone(top.Certificate.Subject.CommonName))
+	return m
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"charm.land/bubbles/v2/spinner"
+	tea "charm.land/bubbletea/v2"
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// errCancelled is recorded for the revocation checks esc cut short.
+var errCancelled = errors.New("cancelled")
+
+// newSpinner is the status bar's activity spinner: braille dots, or a
+// plain turning line in ASCII mode.
+func newSpinner(cfg *config.Config) spinner.Model {
+	frames := spinner.MiniDot
+	if cfg.ASCII {
+		frames = spinner.Line
+	}
+	return spinner.New(spinner.WithSpinner(frames))
+}
+
+// pendingRevocations counts the revocation checks still out, of total
+// started. Self-signed certificates are not checked and count for neither.
+func (m Model) pendingRevocations() (pending, total int) {
+	if !m.Config.CheckRevocation {
+		return 0, 0
+	}
+	for _, info := range m.allCertificates {
+		if certificate.IsSelfSigned(info.Certificate) {
+			continue
+		}
+		total++
+		if _, ok := m.revocation[info]; !ok {
+			pending++
+		}
+	}
+	return pending, total
+}
+
+// busy reports whether any network work is in flight.
+func (m Model) busy() bool {
+	pending, _ := m.pendingRevocations()
+	return m.fetchCancel != nil || pending > 0
+}
+
+// busyStatus says what is in flight, for the status bar.
+func (m Model) busyStatus() string {
+	var parts []string
+	if m.fetchCancel != nil {
+		parts = append(parts, "fetching issuer")
+	}
+	if pending, total := m.pendingRevocations(); pending > 0 {
+		parts = append(parts, fmt.Sprintf("checking revocation %d/%d", total-pending, total))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// spin starts the spinner when there is work in flight. It stops by itself
+// once there is none: handleSpinnerTick lets the ticks lapse. In screen
+// reader mode it never starts, as each turn would be read out.
+func (m Model) spin() tea.Cmd {
+	if !m.busy() || m.Config.ScreenReader {
+		return nil
+	}
+	return m.spinner.Tick
+}
+
+// handleSpinnerTick turns the spinner while work is in flight.
+func (m Model) handleSpinnerTick(msg spinner.TickMsg) (Model, tea.Cmd) {
+	if !m.busy() {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+// cancelled reports whether a result was started under a context that esc
+// has since cancelled. Such results are dropped: cancelNetwork has already
+// settled what they were for.
+func cancelled(ctx context.Context) bool {
+	return ctx != nil && ctx.Err() != nil
+}
+
+// cancelFetch abandons the AIA fetch in flight.
+func (m Model) cancelFetch() Model {
+	if m.fetchCancel != nil {
+		m.fetchCancel()
+		m.fetchCancel = nil
+	}
+	return m
+}
+
+// cancelNetwork cancels the network work in flight and starts a fresh
+// context for what comes next. The fetch is abandoned, and the revocation
+// checks that had not answered are recorded as unknown, so their icons
+// settle at once rather than when the requests give up.
+func (m Model) cancelNetwork() Model {
+	m = m.cancelFetch()
+	m.netCancel()
+	m.netCtx, m.netCancel = context.WithCancel(context.Background())
+
+	if pending, _ := m.pendingRevocations(); pending == 0 {
+		return m
+	}
+	revocation := maps.Clone(m.revocation)
+	if revocation == nil {
+		revocation = make(map[*certificate.Info]certificate.RevocationResult)
+	}
+	for _, info := range m.allCertificates {
+		if _, ok := revocation[info]; !ok && !certificate.IsSelfSigned(info.Certificate) {
+			revocation[info] = certificate.RevocationResult{Status: certificate.RevocationUnknown, Err: errCancelled}
+		}
+	}
+	m.revocation = revocation
+	m.list.SetDelegate(m.newDelegate())
+	return m.refreshViewportContent()
+}
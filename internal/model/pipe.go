@@ -0,0 +1,185 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// pipeTimeout bounds a command run by :pipe, whose output is waited for
+// with the TUI still up.
+const pipeTimeout = 30 * time.Second
+
+// pagers are the commands :pipe hands the terminal to rather than
+// capturing, along with whatever $PAGER names.
+var pagers = []string{"less", "more", "most", "bat"}
+
+// pipedMsg carries what a command run by :pipe wrote, once it has exited.
+// Pagers write to the terminal, and report only how they exited.
+type pipedMsg struct {
+	command string
+	output  []byte
+	err     error
+	pager   bool
+}
+
+// isPager reports whether a command line starts with a pager, which needs
+// the terminal to itself.
+func isPager(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	name := filepath.Base(fields[0])
+	if pager := strings.Fields(os.Getenv("PAGER")); len(pager) > 0 && name == filepath.Base(pager[0]) {
+		return true
+	}
+	return slices.Contains(pagers, name)
+}
+
+// pipeInput is what :pipe sends: the active tab as plain text, or with pem,
+// the PEM of the selection.
+func (m Model) pipeInput(pemOnly bool) []byte {
+	if pemOnly {
+		var buf bytes.Buffer
+		for _, info := range m.selection() {
+			_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: info.Certificate.Raw})
+		}
+		return buf.Bytes()
+	}
+	return []byte(ansi.Strip(m.renderTabContent(m.viewport.Width())) + "\n")
+}
+
+// handlePipeCommand runs a shell command with the details, or the PEM, on
+// its standard input. A pager gets the terminal, the TUI suspended until
+// it exits; anything else runs in the background, and what it writes is
+// shown full screen.
+func (m Model) handlePipeCommand(rest string) (Model, tea.Cmd) {
+	pemOnly := false
+	if command, ok := strings.CutPrefix(rest, "pem "); ok {
+		pemOnly, rest = true, strings.TrimSpace(command)
+	}
+	if rest == "" || len(m.certificates) == 0 {
+		m.commandError = "usage: pipe [pem] <command>"
+		return m, nil
+	}
+	input, command := m.pipeInput(pemOnly), rest
+
+	if isPager(command) {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdin = bytes.NewReader(input)
+		return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+			return pipedMsg{command: command, err: err, pager: true}
+		})
+	}
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), pipeTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stdin = bytes.NewReader(input)
+		output, err := cmd.CombinedOutput()
+		if ctx.Err() != nil {
+			err = fmt.Errorf("gave up after %s", pipeTimeout)
+		}
+		return pipedMsg{command: command, output: output, err: err}
+	}
+}
+
+// handlePiped shows what a piped command wrote. A command that exits with
+// a status still has its output shown, as grep finding nothing does; one
+// that could not be run at all is an alert.
+func (m Model) handlePiped(msg pipedMsg) Model {
+	var exitErr *exec.ExitError
+	if msg.err != nil && !errors.As(msg.err, &exitErr) {
+		m.popupMessage = fmt.Sprintf("❌  Could not run %s\n\n%v", msg.command, msg.err)
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m
+	}
+	if msg.pager {
+		return m
+	}
+	m.pipeCommand, m.pipeOutput, m.pipeStatus = msg.command, string(msg.output), ""
+	if exitErr != nil {
+		m.pipeStatus = exitErr.Error()
+	}
+	m.viewMode = ViewPipe
+	m.pipeViewport.SetYOffset(0)
+	return m.refreshPipeContent()
+}
+
+// updatePipeMode handles key events while the output of :pipe is shown.
+func (m Model) updatePipeMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.viewMode = ViewNormal
+		m.pipeOutput = ""
+		return m, nil
+	case "up", "k":
+		m.pipeViewport.ScrollUp(1)
+	case "down", "j":
+		m.pipeViewport.ScrollDown(1)
+	case "pgup", "b":
+		m.pipeViewport.PageUp()
+	case "pgdown", " ", "f":
+		m.pipeViewport.PageDown()
+	case "g", "home":
+		m.pipeViewport.GotoTop()
+	case "G", "end":
+		m.pipeViewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// resizePipeViewport sizes the output viewport to the screen, less the
+// title, divider and footer rows.
+func (m Model) resizePipeViewport() Model {
+	const chrome = 3 // title, divider, footer
+	m.pipeViewport.SetWidth(max(1, m.width))
+	m.pipeViewport.SetHeight(max(1, m.height-chrome))
+	return m.refreshPipeContent()
+}
+
+// refreshPipeContent puts the output into its viewport, cut to the width:
+// control sequences and tabs a command wrote would otherwise upset the
+// layout.
+func (m Model) refreshPipeContent() Model {
+	output := strings.TrimRight(ansi.Strip(strings.ReplaceAll(m.pipeOutput, "\t", "    ")), "\n")
+	if output == "" {
+		m.pipeViewport.SetContent(m.Styles.Dimmed.Render("(no output)"))
+		return m
+	}
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, m.pipeViewport.Width(), "…")
+	}
+	m.pipeViewport.SetContent(strings.Join(lines, "\n"))
+	return m
+}
+
+// renderPipeView renders the output of :pipe full screen: the command as a
+// title, with its exit status when it failed, the scrolling output, and a
+// footer.
+func (m Model) renderPipeView() string {
+	title := m.Styles.HeaderTitle.Render("Pipe") + m.Styles.Dimmed.Render("  | "+truncateText(m.pipeCommand, max(1, m.width-20)))
+	if m.pipeStatus != "" {
+		title += m.Styles.BadgeWarning.Render("  " + m.pipeStatus)
+	}
+	divider := m.Styles.Dimmed.Render(strings.Repeat("─", m.width))
+	footer := m.Styles.StatusBar.Width(m.width).Render(
+		fmt.Sprintf("↑↓ scroll │ esc close │ %3.f%%", m.pipeViewport.ScrollPercent()*100))
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, divider, m.pipeViewport.View(), footer)
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// refreshInterval is how often the display catches up with the clock.
+// Expiry is shown in days, so once a minute is plenty.
+const refreshInterval = time.Minute
+
+// refreshMsg is the periodic tick that keeps expiry current while y509 is
+// left open.
+type refreshMsg time.Time
+
+// refreshTick schedules the next refresh.
+func refreshTick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg {
+		return refreshMsg(t)
+	})
+}
+
+// handleRefresh re-renders the details, so "days left" counts down and a
+// certificate that expires on screen turns EXPIRED. The list and the status
+// bar work from the clock each time they are drawn and need nothing. When a
+// certificate has expired since the last tick the chain is checked again,
+// since it no longer verifies.
+func (m Model) handleRefresh() (Model, tea.Cmd) {
+	matchIndex := m.matchIndex
+	m = m.refreshViewportContent()
+	m.matchIndex = min(matchIndex, len(m.matchLines)-1)
+
+	expired := countExpired(m.allCertificates)
+	if expired == m.expiredSeen {
+		return m, refreshTick()
+	}
+	m.expiredSeen = expired
+	m, cmd := m.checkChain()
+	return m, tea.Batch(cmd, refreshTick())
+}
+
+// countExpired counts the certificates that have expired by now.
+func countExpired(certs []*certificate.Info) int {
+	n := 0
+	for _, c := range certs {
+		if certificate.IsExpired(c.Certificate) {
+			n++
+		}
+	}
+	return n
+}
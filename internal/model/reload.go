@@ -0,0 +1,118 @@
+package model
+
+import (
+	"os"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kanywst/y509/internal/config"
+	"github.com/kanywst/y509/internal/logger"
+	"github.com/kanywst/y509/pkg/certificate"
+	"go.uber.org/zap"
+)
+
+// configPollInterval is how often the configuration file is looked at for
+// changes while the TUI runs. A stat every couple of seconds costs nothing,
+// and unlike a watch on the file it survives editors that write a new file
+// and rename it over the old one.
+const configPollInterval = 2 * time.Second
+
+// configStamp tells one version of the configuration file from another:
+// which file would be read, and when it was last written.
+type configStamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// currentConfigStamp stamps the file config.LoadConfig would read now; the
+// zero stamp when there is none.
+func currentConfigStamp() configStamp {
+	path := config.Find()
+	if path == "" {
+		return configStamp{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return configStamp{path: path}
+	}
+	return configStamp{path: path, modTime: info.ModTime(), size: info.Size()}
+}
+
+// configPolledMsg is the stamp of the configuration file at a tick.
+type configPolledMsg configStamp
+
+// configPollTick schedules the next look at the configuration file.
+func configPollTick() tea.Cmd {
+	return tea.Tick(configPollInterval, func(time.Time) tea.Msg {
+		return configPolledMsg(currentConfigStamp())
+	})
+}
+
+// SetConfigLoader has the TUI reload its configuration with load, when the
+// file changes or on :config reload, rather than with config.LoadConfig
+// alone: the command line can then lay its flags over the file again.
+func (m *Model) SetConfigLoader(load func() (*config.Config, error)) {
+	m.loadConfig = load
+}
+
+// handleConfigPolled reloads the configuration when its file has been
+// written, created or removed since the last tick.
+func (m Model) handleConfigPolled(msg configPolledMsg) (Model, tea.Cmd) {
+	if configStamp(msg) == m.configStamp {
+		return m, configPollTick()
+	}
+	m.configStamp = configStamp(msg)
+	m, cmd := m.reloadConfig()
+	return m, tea.Batch(cmd, configPollTick())
+}
+
+// reloadConfig reads the configuration again and applies what can change
+// while the TUI runs: the theme and its colours, the key bindings, the list
+// columns, the expiry warning, how times are written and how search
+// matches. A file that no longer loads is reported and the configuration in
+// force kept. Colour, ASCII, screen reader, revocation, the CT log list and
+// the roots are left as the TUI started with them.
+func (m Model) reloadConfig() (Model, tea.Cmd) {
+	load := m.loadConfig
+	if load == nil {
+		load = config.LoadConfig
+	}
+	cfg, err := load()
+	if err != nil {
+		logger.Log.Warn("failed to reload the configuration", zap.Error(err))
+		return m.notify("Configuration not reloaded: %v", err)
+	}
+
+	old := m.Config
+	cfg.NoColor, cfg.ASCII, cfg.ScreenReader, cfg.NoSplash = old.NoColor, old.ASCII, old.ScreenReader, old.NoSplash
+	cfg.CheckRevocation, cfg.CTLogList, cfg.Roots = old.CheckRevocation, old.CTLogList, old.Roots
+
+	keys, keysErr := defaultKeyMap().withConfig(cfg.Keys)
+	if keysErr != nil {
+		logger.Log.Warn("invalid key bindings, keeping the defaults for them", zap.Error(keysErr))
+	}
+	if columns, err := parseColumns(cfg.Columns); err == nil {
+		m.columns = columns
+	} else {
+		logger.Log.Warn("invalid list columns, keeping those in use", zap.Strings("columns", cfg.Columns), zap.Error(err))
+	}
+
+	// The theme in use stays, with its colours as the file now has them,
+	// unless the file no longer has it.
+	theme := old.ThemeName
+	if _, ok := cfg.Themes[theme]; !ok {
+		theme = cfg.ThemeName
+	}
+	m.Config, m.keys = cfg, keys
+	certificate.SetTimeFormat(cfg.TimeFormat)
+	m = m.applyTheme(theme)
+
+	switch {
+	case keysErr != nil:
+		return m.notify("Configuration reloaded; %v", keysErr)
+	case cfg.File == "":
+		return m.notify("No configuration file; back to the defaults")
+	}
+	return m.notify("Configuration reloaded from %s", cfg.ShortPath())
+}
@@ -0,0 +1,34 @@
+package model
+
+import "slices"
+
+// moveSelected moves the selected certificate one place up (step -1) or
+// down (step 1) the list, to put a chain into the order it should be
+// shipped in before :export bundle writes it. The two certificates trade
+// places in the working set, so the order holds across file tabs and
+// filters. Search results are listed best match first and cannot be
+// reordered.
+func (m Model) moveSelected(step int) Model {
+	if len(m.certificates) < 2 {
+		return m
+	}
+	if m.searchQuery != "" {
+		m.commandError = "search results are in match order; clear the search to reorder"
+		return m
+	}
+	i := m.list.Index()
+	j := i + step
+	if j < 0 || j >= len(m.certificates) {
+		return m
+	}
+
+	a, b := m.certificates[i], m.certificates[j]
+	all := slices.Clone(m.allCertificates)
+	ai, bi := slices.Index(all, a), slices.Index(all, b)
+	all[ai], all[bi] = b, a
+	m.allCertificates = all
+
+	m = m.refreshWorkingSet()
+	m.list.Select(j)
+	return m.refreshViewportContent()
+}
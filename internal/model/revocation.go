@@ -0,0 +1,111 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// revocationCheckedMsg carries the result of one certificate's revocation
+// check.
+type revocationCheckedMsg struct {
+	ctx    context.Context
+	info   *certificate.Info
+	result certificate.RevocationResult
+}
+
+// checkRevocationCmds starts a revocation check for every loaded certificate
+// that has an issuer to ask, when checking is enabled. Each runs on its own,
+// so a slow responder only holds up its own icon, and esc cancels them all.
+func (m Model) checkRevocationCmds() tea.Cmd {
+	if !m.Config.CheckRevocation {
+		return nil
+	}
+	pool := m.pool()
+	ctx := m.netCtx
+	var cmds []tea.Cmd
+	for _, info := range m.allCertificates {
+		if certificate.IsSelfSigned(info.Certificate) {
+			continue
+		}
+		issuer := certificate.IssuerOf(info.Certificate, pool)
+		cmds = append(cmds, func() tea.Msg {
+			return revocationCheckedMsg{ctx: ctx, info: info, result: certificate.CheckRevocation(ctx, info.Certificate, issuer)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleRevocationChecked records a revocation result and redraws the list.
+// The last result to come in brings a toast totting them up.
+func (m Model) handleRevocationChecked(msg revocationCheckedMsg) (Model, tea.Cmd) {
+	if cancelled(msg.ctx) {
+		return m, nil
+	}
+	revocation := maps.Clone(m.revocation)
+	if revocation == nil {
+		revocation = make(map[*certificate.Info]certificate.RevocationResult)
+	}
+	revocation[msg.info] = msg.result
+	m.revocation = revocation
+	m.list.SetDelegate(m.newDelegate())
+	m = m.refreshViewportContent()
+
+	if pending, _ := m.pendingRevocations(); pending > 0 {
+		return m, nil
+	}
+	return m.notify("Revocation: %s", m.revocationSummary())
+}
+
+// revocationSummary counts the revocation results by status, as in
+// "2 good, 1 revoked".
+func (m Model) revocationSummary() string {
+	counts := make(map[certificate.RevocationStatus]int)
+	for _, result := range m.revocation {
+		counts[result.Status]++
+	}
+	var parts []string
+	for _, status := range []certificate.RevocationStatus{certificate.RevocationGood, certificate.RevocationRevoked, certificate.RevocationUnknown} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// revocationResults is what the list delegate is given: nil when checking
+// is off, otherwise the results so far, never nil.
+func (m Model) revocationResults() map[*certificate.Info]certificate.RevocationResult {
+	if !m.Config.CheckRevocation {
+		return nil
+	}
+	if m.revocation == nil {
+		return map[*certificate.Info]certificate.RevocationResult{}
+	}
+	return m.revocation
+}
+
+// revocationIcon is the list's revocation indicator: ✓ good, ✖ revoked,
+// ? unknown, and … while the check is running. Self-signed certificates
+// are not checked and get none.
+func revocationIcon(info *certificate.Info, results map[*certificate.Info]certificate.RevocationResult, styles Styles) (string, lipgloss.Style) {
+	if certificate.IsSelfSigned(info.Certificate) {
+		return " ", styles.Dimmed
+	}
+	result, ok := results[info]
+	switch {
+	case !ok:
+		return "…", styles.Dimmed
+	case result.Status == certificate.RevocationGood:
+		return "✓", styles.StatusValid
+	case result.Status == certificate.RevocationRevoked:
+		return "✖", styles.StatusExpired
+	default:
+		return "?", styles.StatusWarning
+	}
+}
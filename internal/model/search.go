@@ -0,0 +1,285 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kanywst/y509/internal/config"
+)
+
+// openSearch opens the '/' line. The list narrows as the query is typed;
+// Esc puts back whatever search or filter was in force before. With the
+// details in focus, the line finds within them instead.
+func (m Model) openSearch() (Model, tea.Cmd) {
+	m.viewMode = ViewSearch
+	m.preSearchFilters = m.filters
+	m.findingDetails = m.focus == FocusRight || m.fullscreen
+	if !m.findingDetails {
+		m.findQuery = ""
+	}
+	m.searchInput.Reset()
+	return m, m.searchInput.Focus()
+}
+
+// updateSearchMode handles key events while the '/' line is open.
+func (m Model) updateSearchMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	if m.findingDetails {
+		return m.updateFindMode(msg)
+	}
+	switch msg.String() {
+	case "enter":
+		m.viewMode = ViewNormal
+		m.searchInput.Blur()
+		return m, nil
+	case "esc":
+		return m.cancelSearch(), nil
+	case "backspace":
+		// Backspacing past the prompt cancels, as on the command line.
+		if m.searchInput.Value() == "" {
+			return m.cancelSearch(), nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if query := strings.TrimSpace(m.searchInput.Value()); query != "" {
+		m = m.searchCertificates(query)
+	} else {
+		m = m.restoreFilters(m.preSearchFilters)
+	}
+	m.viewMode = ViewSearch
+	return m, cmd
+}
+
+// cancelSearch closes the '/' line and restores the list it started from.
+func (m Model) cancelSearch() Model {
+	m.searchInput.Reset()
+	m.searchInput.Blur()
+	m = m.restoreFilters(m.preSearchFilters)
+	m.viewMode = ViewNormal
+	return m
+}
+
+// updateFindMode handles key events while the '/' line finds within the
+// details. The details scroll to the first match as the query is typed;
+// enter keeps the query for n and N, and esc drops it.
+func (m Model) updateFindMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.viewMode = ViewNormal
+		m.searchInput.Blur()
+		if m.findQuery != "" && len(m.matchLines) == 0 {
+			m.commandError = fmt.Sprintf("%q is not in the details", m.findQuery)
+		}
+		return m, nil
+	case "esc":
+		return m.clearFind(), nil
+	case "backspace":
+		if m.searchInput.Value() == "" {
+			return m.clearFind(), nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	m.findQuery = strings.TrimSpace(m.searchInput.Value())
+	m = m.refreshViewportContent()
+	if len(m.matchLines) > 0 {
+		m.matchIndex = 0
+		m.viewport.SetYOffset(m.matchLines[0])
+		m.detailRow = 0
+	}
+	return m, cmd
+}
+
+// clearFind closes the '/' line, if it is open, and drops the query found
+// in the details.
+func (m Model) clearFind() Model {
+	m.searchInput.Reset()
+	m.searchInput.Blur()
+	m.findQuery = ""
+	m.findingDetails = false
+	m.viewMode = ViewNormal
+	return m.refreshViewportContent()
+}
+
+// restoreFilters puts back a stack of filters as it was recorded: empty
+// for none, or filter names and "search: <query>" entries.
+func (m Model) restoreFilters(filters []string) Model {
+	if len(filters) == 0 {
+		return m.resetView()
+	}
+	m.filters = filters
+	return m.applyFilter()
+}
+
+// popFilter is :back and u: it undoes the filter or search stacked last,
+// leaving the list as the ones before it had it.
+func (m Model) popFilter() Model {
+	if len(m.filters) == 0 {
+		m.commandError = "no filter to undo"
+		return m
+	}
+	return m.restoreFilters(m.filters[:len(m.filters)-1])
+}
+
+// regexPrefix marks a search query as an RE2 regular expression, as in
+// "re:^api-\d+\.". It is matched against each field, ignoring case.
+const regexPrefix = "re:"
+
+// searchRegexp compiles a query given as a regular expression. Any other
+// query gives nil, and no error.
+func searchRegexp(query string) (*regexp.Regexp, error) {
+	pattern, ok := strings.CutPrefix(query, regexPrefix)
+	if !ok {
+		return nil, nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	return regexp.MustCompile("(?i)" + pattern), nil
+}
+
+// searchPositions lists the rune positions of text that the search query
+// matches: the fuzzy match, or with exact set every occurrence of the query,
+// or for a regular expression every run it matches.
+func searchPositions(query, text string, exact bool) []int {
+	if query == "" {
+		return nil
+	}
+	re, err := searchRegexp(query)
+	if err != nil {
+		return nil
+	}
+	if re != nil {
+		var positions []int
+		for _, match := range re.FindAllStringIndex(text, -1) {
+			start := utf8.RuneCountInString(text[:match[0]])
+			for i := range utf8.RuneCountInString(text[match[0]:match[1]]) {
+				positions = append(positions, start+i)
+			}
+		}
+		return positions
+	}
+	if !exact {
+		_, positions, _ := fuzzyMatch(query, text)
+		return positions
+	}
+
+	t, q := []rune(strings.ToLower(text)), []rune(strings.ToLower(query))
+	var positions []int
+	for i := 0; i+len(q) <= len(t); {
+		if !slices.Equal(t[i:i+len(q)], q) {
+			i++
+			continue
+		}
+		for j := range q {
+			positions = append(positions, i+j)
+		}
+		i += len(q)
+	}
+	return positions
+}
+
+// highlightRunes restyles the runes of s at the given positions, counted in
+// s with its escape sequences stripped. The styling around them is kept.
+func highlightRunes(s string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return s
+	}
+	var ranges []lipgloss.Range
+	col := 0
+	for i, r := range []rune(ansi.Strip(s)) {
+		width := ansi.StringWidth(string(r))
+		if slices.Contains(positions, i) {
+			if n := len(ranges); n > 0 && ranges[n-1].End == col {
+				ranges[n-1].End += width
+			} else {
+				ranges = append(ranges, lipgloss.NewRange(col, col+width, style))
+			}
+		}
+		col += width
+	}
+	return lipgloss.StyleRanges(s, ranges...)
+}
+
+// highlightContent marks every occurrence of the query found in the
+// details, or else of the search query, in rendered detail content and
+// returns the lines they fall on, for n and N. The details are matched as
+// typed even in fuzzy mode: letters picked out here and there across a page
+// of details would only be noise.
+func (m Model) highlightContent(content string) (string, []int) {
+	query := m.detailQuery()
+	if query == "" {
+		return content, nil
+	}
+	lines := strings.Split(content, "\n")
+	var matched []int
+	for i, line := range lines {
+		if positions := searchPositions(query, ansi.Strip(line), true); len(positions) > 0 {
+			lines[i] = highlightRunes(line, positions, m.Styles.SearchMatch)
+			matched = append(matched, i)
+		}
+	}
+	return strings.Join(lines, "\n"), matched
+}
+
+// detailQuery is the query highlighted in the details: the one found with
+// '/' in them, or else the list's search.
+func (m Model) detailQuery() string {
+	if m.findQuery != "" {
+		return m.findQuery
+	}
+	return m.searchQuery
+}
+
+// exactSearch reports whether search is configured to match as typed.
+func (m Model) exactSearch() bool {
+	return m.Config.SearchMode == config.SearchExact
+}
+
+// jumpToMatch is n (step 1) and N (step -1): it scrolls the details to the
+// next or previous line holding the search query and, past the last one,
+// moves on to the next certificate in the list, as vim's n moves on through
+// a file. A query found in the details wraps round within them instead.
+func (m Model) jumpToMatch(step int) Model {
+	if m.detailQuery() == "" || len(m.certificates) == 0 {
+		return m
+	}
+
+	next := m.matchIndex + step
+	if m.findQuery != "" {
+		if len(m.matchLines) == 0 {
+			m.commandError = fmt.Sprintf("%q is not in the details", m.findQuery)
+			return m
+		}
+		next = (next + len(m.matchLines)) % len(m.matchLines)
+	}
+	if next >= 0 && next < len(m.matchLines) {
+		m.matchIndex = next
+		m.viewport.SetYOffset(m.matchLines[next])
+		m.detailRow = 0
+		return m
+	}
+
+	index := (m.list.Index() + step + len(m.certificates)) % len(m.certificates)
+	m.list.Select(index)
+	m.viewport.SetYOffset(0)
+	m = m.refreshViewportContent()
+	if len(m.matchLines) == 0 {
+		return m
+	}
+	m.matchIndex = 0
+	if step < 0 {
+		m.matchIndex = len(m.matchLines) - 1
+	}
+	m.viewport.SetYOffset(m.matchLines[m.matchIndex])
+	m.detailRow = 0
+	return m
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// sourceBarHeight is the height of the file tabs above the list, shown only
+// when more than one file is loaded.
+const sourceBarHeight = 1
+
+// sourceNames lists the distinct sources of the certificates, in the order
+// they were loaded.
+func sourceNames(certs []*certificate.Info) []string {
+	var names []string
+	for _, c := range certs {
+		if !slices.Contains(names, c.Source) {
+			names = append(names, c.Source)
+		}
+	}
+	return names
+}
+
+// sortBySource puts each source's certificates in chain order, keeping the
+// sources apart. Sorting two bundles together would interleave their chains.
+func sortBySource(certs []*certificate.Info) []*certificate.Info {
+	names := sourceNames(certs)
+	if len(names) < 2 {
+		return sortInfos(certs)
+	}
+	var sorted []*certificate.Info
+	for _, name := range names {
+		var group []*certificate.Info
+		for _, c := range certs {
+			if c.Source == name {
+				group = append(group, c)
+			}
+		}
+		sorted = append(sorted, sortInfos(group)...)
+	}
+	return sorted
+}
+
+// sourceLabel is how a source is named on its tab: the file name, or the
+// address of a server.
+func sourceLabel(source string) string {
+	if source == "" {
+		return "stdin"
+	}
+	return filepath.Base(source)
+}
+
+// origin says where in its input a certificate was read, as
+// "name:line, certificate n", a form editors open at the line.
+func origin(name string, info *certificate.Info) string {
+	if info.Line > 0 {
+		name = fmt.Sprintf("%s:%d", name, info.Line)
+	}
+	return fmt.Sprintf("%s, certificate %d", name, info.Index+1)
+}
+
+// sourceCertificates is what the active file tab shows before any search or
+// filter: every certificate on the "All" tab, else those from one source.
+func (m Model) sourceCertificates() []*certificate.Info {
+	if m.activeSource == 0 || m.activeSource > len(m.sources) {
+		return m.allCertificates
+	}
+	source := m.sources[m.activeSource-1]
+	var certs []*certificate.Info
+	for _, c := range m.allCertificates {
+		if c.Source == source {
+			certs = append(certs, c)
+		}
+	}
+	return certs
+}
+
+// selectSource switches file tab, index 0 being "All". An active search or
+// filter carries over to the new tab.
+func (m Model) selectSource(index int) Model {
+	if len(m.sources) == 0 {
+		return m
+	}
+	m.activeSource = (index + len(m.sources) + 1) % (len(m.sources) + 1)
+	if m.filterActive {
+		return m.applyFilter()
+	}
+	certs := m.sourceCertificates()
+	m.certificates = certs
+	m.list.SetItems(toListItems(certs))
+	m.list.Select(0)
+	m.viewport.SetYOffset(0)
+	return m.refreshViewportContent()
+}
+
+// handleSourceCommand switches file tab by number (1 is the first file),
+// by file name, or to "all".
+func (m Model) handleSourceCommand(arg string) Model {
+	if len(m.sources) == 0 {
+		m.commandError = "only one source is loaded"
+		return m
+	}
+	if strings.EqualFold(arg, "all") || arg == "0" {
+		return m.selectSource(0)
+	}
+	if n, err := strconv.Atoi(arg); err == nil && n >= 1 && n <= len(m.sources) {
+		return m.selectSource(n)
+	}
+	for i, source := range m.sources {
+		if arg == source || arg == sourceLabel(source) {
+			return m.selectSource(i + 1)
+		}
+	}
+	m.commandError = fmt.Sprintf("no source %q", arg)
+	return m
+}
+
+// sourceTabLabels labels the file tabs, "All" first, each with its count.
+func (m Model) sourceTabLabels() []string {
+	labels := []string{fmt.Sprintf("All %d", len(m.allCertificates))}
+	for _, source := range m.sources {
+		count := 0
+		for _, c := range m.allCertificates {
+			if c.Source == source {
+				count++
+			}
+		}
+		labels = append(labels, fmt.Sprintf("%s %d", sourceLabel(source), count))
+	}
+	return labels
+}
+
+// renderSourceTabs renders the file tabs above the list: "All" and then one
+// per source, each with its certificate count. When they do not fit the pane
+// they collapse to the active tab alone, as the detail tabs do.
+func (m Model) renderSourceTabs(width int) string {
+	labels := m.sourceTabLabels()
+	rendered := make([]string, len(labels))
+	for i, label := range labels {
+		if i == m.activeSource {
+			rendered[i] = m.Styles.TabActive.Padding(0, 1).Render(label)
+		} else {
+			rendered[i] = m.Styles.Tab.Padding(0, 1).Render(label)
+		}
+	}
+	full := lipgloss.JoinHorizontal(lipgloss.Top, rendered...)
+	if lipgloss.Width(full) <= width {
+		return full
+	}
+
+	active := labels[m.activeSource]
+	return m.Styles.Dimmed.Render("‹ ") +
+		m.Styles.Title.Bold(true).Render(truncateText(active, max(1, width-12))) +
+		m.Styles.Dimmed.Render(fmt.Sprintf(" ›  %d/%d", m.activeSource+1, len(labels)))
+}
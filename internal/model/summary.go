@@ -0,0 +1,116 @@
+package model
+
+import (
+	"crypto/x509"
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/kanywst/y509/pkg/certificate"
+)
+
+// chainCheckedMsg carries the result of the background chain check behind
+// the status bar summary. gen ties it to the check that was asked for, so a
+// result that arrives after the tab has changed again is dropped.
+type chainCheckedMsg struct {
+	gen   int
+	level certificate.TrustLevel
+	err   error
+}
+
+// checkChain starts verifying the chains on the active file tab in the
+// background. The system trust store is consulted, which can take a moment,
+// so it never runs on the update loop.
+func (m Model) checkChain() (Model, tea.Cmd) {
+	m.chainGen++
+	m.chainChecked = false
+	if len(m.allCertificates) == 0 {
+		return m, nil
+	}
+	return m, checkChainCmd(m.chainGen, m.chainGroups(), m.verifyOptions())
+}
+
+// chainGroups splits the active tab into the chains to verify: one per
+// source, each leaf first, as sortBySource leaves them. The "All" tab holds
+// every source, and two bundles are never one chain.
+func (m Model) chainGroups() [][]*x509.Certificate {
+	certs := m.sourceCertificates()
+	var groups [][]*x509.Certificate
+	for _, source := range sourceNames(certs) {
+		var chain []*x509.Certificate
+		for _, c := range certs {
+			if c.Source == source {
+				chain = append(chain, c.Certificate)
+			}
+		}
+		groups = append(groups, chain)
+	}
+	return groups
+}
+
+// checkChainCmd verifies each chain and reports the worst of them.
+func checkChainCmd(gen int, groups [][]*x509.Certificate, opts certificate.VerifyOptions) tea.Cmd {
+	return func() tea.Msg {
+		worst := certificate.TrustAnchored
+		for _, chain := range groups {
+			result, err := certificate.VerifyChain(chain, opts)
+			if err != nil {
+				return chainCheckedMsg{gen: gen, err: err}
+			}
+			worst = min(worst, result.Level)
+		}
+		return chainCheckedMsg{gen: gen, level: worst}
+	}
+}
+
+// handleChainChecked records the outcome of the latest chain check.
+func (m Model) handleChainChecked(msg chainCheckedMsg) Model {
+	if msg.gen != m.chainGen {
+		return m
+	}
+	m.chainChecked = true
+	m.chainLevel = msg.level
+	m.chainErr = msg.err
+	return m
+}
+
+// summaryParts renders the validation summary for the status bar, most
+// important first: how many certificates are listed, how many of those have
+// expired or are about to, and how the chain verified. Counts that are zero
+// are left out.
+func (m Model) summaryParts() []string {
+	expired, expiring := 0, 0
+	for _, c := range m.certificates {
+		switch {
+		case certificate.IsExpired(c.Certificate):
+			expired++
+		case certificate.IsExpiringSoonWithin(c.Certificate, m.Config.ExpiryWarningDays):
+			expiring++
+		}
+	}
+
+	bar := m.Styles.StatusBar.Padding(0)
+	var parts []string
+	if expired > 0 {
+		parts = append(parts, bar.Foreground(m.Styles.StatusExpired.GetForeground()).Render(fmt.Sprintf("%d expired", expired)))
+	}
+	if expiring > 0 {
+		parts = append(parts, bar.Foreground(m.Styles.StatusWarning.GetForeground()).Render(fmt.Sprintf("%d expiring", expiring)))
+	}
+	if len(m.allCertificates) == 0 {
+		return parts
+	}
+
+	switch {
+	case !m.chainChecked:
+		parts = append(parts, bar.Faint(true).Render("chain: checking…"))
+	case m.chainErr != nil:
+		parts = append(parts, bar.Foreground(m.Styles.StatusExpired.GetForeground()).Render("chain: ERROR"))
+	case m.chainLevel == certificate.TrustAnchored:
+		parts = append(parts, bar.Foreground(m.Styles.StatusValid.GetForeground()).Render("chain: OK"))
+	case m.chainLevel == certificate.TrustSelfAnchored:
+		parts = append(parts, bar.Foreground(m.Styles.StatusWarning.GetForeground()).Render("chain: SELF-ANCHORED"))
+	default:
+		parts = append(parts, bar.Foreground(m.Styles.StatusExpired.GetForeground()).Render("chain: BROKEN"))
+	}
+	return parts
+}
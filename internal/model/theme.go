@@ -0,0 +1,106 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/kanywst/y509/internal/config"
+)
+
+// newInputStyles styles the text inputs to match a theme.
+func newInputStyles(theme *config.Theme) textinput.Styles {
+	styles := textinput.DefaultDarkStyles()
+	styles.Cursor.Color = lipgloss.Color(theme.Highlight)
+	return styles
+}
+
+// applyTheme switches to a named theme and redraws everything in it. The
+// configuration is copied rather than changed in place, as other holders of
+// it expect it to stay put.
+func (m Model) applyTheme(name string) Model {
+	theme, ok := m.Config.Themes[name]
+	if !ok {
+		return m
+	}
+	cfg := *m.Config
+	cfg.Theme, cfg.ThemeName = theme, name
+	m.Config = &cfg
+	m.Styles = stylesFor(&cfg)
+	inputStyles := newInputStyles(&cfg.Theme)
+	m.textInput.SetStyles(inputStyles)
+	m.commandInput.SetStyles(inputStyles)
+	m.searchInput.SetStyles(inputStyles)
+	m.helpInput.SetStyles(inputStyles)
+	m.list.SetDelegate(m.newDelegate())
+	m = m.refreshViewportContent()
+	return m.refreshDiffContent()
+}
+
+// handleThemeCommand switches theme for the session, writes the one in use
+// to the configuration file with "save", or without arguments lists them.
+func (m Model) handleThemeCommand(args []string) (Model, tea.Cmd) {
+	if len(args) == 0 {
+		var lines []string
+		for _, name := range m.Config.ThemeNames() {
+			marker := "  "
+			if name == m.Config.ThemeName {
+				marker = "• "
+			}
+			lines = append(lines, marker+name)
+		}
+		m.popupMessage = fmt.Sprintf("Themes:\n\n%s\n\nSwitch with :theme <name>, keep it with :theme save.\nMore go under themes: in %s",
+			strings.Join(lines, "\n"), m.Config.ShortPath())
+		m.viewMode = ViewPopup
+		m.popupType = PopupAlert
+		return m, nil
+	}
+
+	name := strings.ToLower(args[0])
+	if name == "save" {
+		if err := config.SaveValue(m.Config.File, "theme_name", m.Config.ThemeName); err != nil {
+			m.commandError = err.Error()
+			return m, nil
+		}
+		// The file now says what is on screen; no need to reload it.
+		m.configStamp = currentConfigStamp()
+		return m.notify("Theme %q saved to %s", m.Config.ThemeName, m.Config.ShortPath())
+	}
+	if _, ok := m.Config.Themes[name]; !ok {
+		m.commandError = fmt.Sprintf("no theme %q (one of %s)", name, strings.Join(m.Config.ThemeNames(), ", "))
+		return m, nil
+	}
+	return m.applyTheme(name), nil
+}
+
+// previewTheme shows the theme named on the command line while it is typed
+// or stepped through with tab, so themes can be compared before one is
+// picked. Once the line no longer names one, the theme from before is back.
+func (m Model) previewTheme() Model {
+	if m.themeBeforePreview == "" {
+		m.themeBeforePreview = m.Config.ThemeName
+	}
+	target := m.themeBeforePreview
+	if fields := strings.Fields(m.commandInput.Value()); len(fields) == 2 && strings.EqualFold(fields[0], "theme") {
+		name := strings.ToLower(fields[1])
+		if _, ok := m.Config.Themes[name]; ok {
+			target = name
+		}
+	}
+	if target != m.Config.ThemeName {
+		m = m.applyTheme(target)
+	}
+	return m
+}
+
+// endThemePreview puts back the theme from before the command line opened,
+// when the line is abandoned.
+func (m Model) endThemePreview() Model {
+	if m.themeBeforePreview != "" && m.themeBeforePreview != m.Config.ThemeName {
+		m = m.applyTheme(m.themeBeforePreview)
+	}
+	m.themeBeforePreview = ""
+	return m
+}
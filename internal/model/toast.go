@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxToasts is how many notifications are shown at once; the oldest give
+// way to new ones.
+const maxToasts = 3
+
+// toast is a transient notification: a confirmation that needs no answer,
+// shown over the bottom right of the panes until it times out.
+type toast struct {
+	id   int
+	text string
+}
+
+// toastExpiredMsg retires the toast with that id.
+type toastExpiredMsg int
+
+// notify shows a toast, and returns the command that takes it down again
+// after the configured timeout.
+func (m Model) notify(format string, args ...any) (Model, tea.Cmd) {
+	m.toastSeq++
+	id := m.toastSeq
+	toasts := append(slices.Clone(m.toasts), toast{id: id, text: fmt.Sprintf(format, args...)})
+	m.toasts = toasts[max(0, len(toasts)-maxToasts):]
+	return m, tea.Tick(m.Config.ToastTimeout, func(time.Time) tea.Msg {
+		return toastExpiredMsg(id)
+	})
+}
+
+// handleToastExpired takes down a toast whose time is up.
+func (m Model) handleToastExpired(msg toastExpiredMsg) Model {
+	m.toasts = slices.DeleteFunc(slices.Clone(m.toasts), func(t toast) bool { return t.id == int(msg) })
+	return m
+}
+
+// overlayToasts draws the toasts over the last rows of the panes, against
+// the right border, the newest lowest. Lines too narrow to hold one are
+// left alone. In screen reader mode they are read out from the status bar
+// instead.
+func (m Model) overlayToasts(content string) string {
+	if len(m.toasts) == 0 || m.Config.ScreenReader {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	for i, t := range m.toasts {
+		row := len(lines) - 1 - len(m.toasts) + i // above the bottom border
+		if row < 1 {
+			continue
+		}
+		text := m.Styles.StatusBarKey.Render(truncateText(t.text, max(1, m.width/2)))
+		width := lipgloss.Width(text)
+		line := lines[row]
+		if lipgloss.Width(line) < width+2 {
+			continue
+		}
+		right := ansi.TruncateLeft(line, lipgloss.Width(line)-1, "") // the border
+		lines[row] = ansi.Truncate(line, lipgloss.Width(line)-width-1, "") + text + right
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,9 +1,14 @@
 package model
 
 import (
-	"path/filepath"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/spinner"
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/huh/v2"
@@ -20,12 +25,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ready = true
 		m = m.resizeComponents()
 		m = m.refreshViewportContent()
+		m = m.resizeDiffViewport()
+		m = m.resizePipeViewport()
+		m = m.resizeHelpViewport()
 		logger.Log.Debug("window size updated",
 			zap.Int("width", m.width),
 			zap.Int("height", m.height))
 		if m.exportFormOpen() {
 			return m.updateExportForm(msg)
 		}
+		if len(m.startupCommands) > 0 {
+			return m.runStartupCommands()
+		}
 		return m, nil
 
 	case tea.MouseWheelMsg:
@@ -40,6 +51,38 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case tea.MouseClickMsg:
+		return m.updateMouseClick(msg)
+
+	case issuerFetchedMsg:
+		// The fetched issuer may answer what could not be checked before.
+		m, cmd := m.handleIssuerFetched(msg).checkChain()
+		return m, tea.Batch(cmd, m.checkRevocationCmds(), m.spin())
+
+	case chainCheckedMsg:
+		return m.handleChainChecked(msg), nil
+
+	case refreshMsg:
+		return m.handleRefresh()
+
+	case configPolledMsg:
+		return m.handleConfigPolled(msg)
+
+	case revocationCheckedMsg:
+		return m.handleRevocationChecked(msg)
+
+	case pipedMsg:
+		return m.handlePiped(msg), nil
+
+	case editedMsg:
+		return m.handleEdited(msg)
+
+	case toastExpiredMsg:
+		return m.handleToastExpired(msg), nil
+
+	case spinner.TickMsg:
+		return m.handleSpinnerTick(msg)
+
 	case SplashDoneMsg:
 		// The splash is also dismissed by any key press, and the timer message
 		// is still in flight when that happens. Only let it retire the splash,
@@ -65,14 +108,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch m.viewMode {
 		case ViewNormal:
-			if key.Matches(msg, m.keys.Quit) {
-				return m, tea.Quit
-			}
 			return m.updateNormalMode(msg)
 		case ViewHelp:
 			return m.updateHelpMode(msg)
 		case ViewPopup:
 			return m.updatePopupMode(msg)
+		case ViewCommand:
+			return m.updateCommandMode(msg)
+		case ViewSearch:
+			return m.updateSearchMode(msg)
+		case ViewDiff:
+			return m.updateDiffMode(msg)
+		case ViewPipe:
+			return m.updatePipeMode(msg)
 		default:
 			m.viewMode = ViewNormal
 			return m, nil
@@ -122,21 +170,75 @@ func (m Model) updateExportForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
-	filename := m.exportForm.GetString("filename")
-	format := m.exportForm.GetString("format")
-	// filepath.Ext only inspects the final path component, so paths like
-	// "./out/cert" or "dir.with.dots/cert" still get a suffix.
-	if filename != "" && filepath.Ext(filename) == "" {
-		filename = filename + "." + format
-	}
+	filename, ok := exportTarget(m.exportForm)
 	m.exportForm = nil
-	m = m.handleExportCommand(filename)
-	return m, cmd
+	if !ok {
+		m.viewMode = ViewNormal
+		m.popupType = PopupNone
+		m, toastCmd := m.notify("Export cancelled: %s was left as it was", filename)
+		return m, tea.Batch(cmd, toastCmd)
+	}
+	// The form asked before replacing a file; a directory's files it
+	// cannot know, so those are still asked about.
+	m, exportCmd := m.handleExportCommand(filename, !isExportDir(filename))
+	return m, tea.Batch(cmd, exportCmd)
 }
 
 // updateNormalMode handles key events in normal (two-pane) mode
 func (m Model) updateNormalMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	// A command error stays up until the user does something else.
+	m.commandError = ""
+
+	if m.pendingKey != "" {
+		pending := m.pendingKey
+		m.pendingKey = ""
+		if pending == "g" {
+			return m.handleGoto(msg.String()), nil
+		}
+		return m.handlePendingKey(pending, msg.String()), nil
+	}
+
+	// q quits, unless it finished the g, m or ' above.
+	if key.Matches(msg, m.keys.Quit) {
+		return m, tea.Quit
+	}
+
+	// Digits make a count for the motion after them. Any other key drops
+	// it, keeping a tab jump its first digit made.
+	if m.isCountDigit(msg) {
+		return m.typeCount(msg), nil
+	}
+	if m.count > 0 && m.isCounted(msg) {
+		m = m.undoCountJump()
+	} else {
+		m.count, m.countFrom = 0, nil
+	}
+
+	// Fullscreen details give way to the split on the keys that lead back
+	// to the list, and on zoom again. Enter only ever zooms in: it also
+	// follows an issuer line.
+	zoomOut := key.Matches(msg, m.keys.Zoom) && msg.String() != "enter"
+	if m.fullscreen && (key.Matches(msg, m.keys.Back) || key.Matches(msg, m.keys.Left) || zoomOut) {
+		return m.toggleFullscreen(), nil
+	}
+
+	// Enter on a line about the issuer goes to it, rather than zooming.
+	if msg.String() == "enter" && m.onIssuerLine() {
+		return m.jumpToIssuer(), nil
+	}
+
 	switch {
+	case key.Matches(msg, m.keys.Issuer):
+		return m.jumpToIssuer(), nil
+	case key.Matches(msg, m.keys.Root):
+		return m.jumpToRoot(), nil
+	case key.Matches(msg, m.keys.Leaf):
+		return m.jumpToLeaf(), nil
+	case key.Matches(msg, m.keys.Zoom):
+		if !m.fullscreen {
+			return m.toggleFullscreen(), nil
+		}
+		return m, nil
 	case key.Matches(msg, m.keys.Left):
 		m.focus = FocusLeft
 		return m, nil
@@ -144,50 +246,112 @@ func (m Model) updateNormalMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 		m.focus = FocusRight
 		return m, nil
 	case key.Matches(msg, m.keys.Tab):
+		m, n := m.takeCount()
 		if m.focus == FocusRight {
-			m.activeTab = (m.activeTab + 1) % len(m.tabs)
-			m.viewport.SetYOffset(0)
-			m = m.refreshViewportContent()
+			return m.selectTab(m.activeTab + n), nil
 		}
 		return m, nil
-	case key.Matches(msg, m.keys.Up):
-		m = m.moveCursorUp()
+	case key.Matches(msg, m.keys.PrevTab):
+		m, n := m.takeCount()
+		if m.focus == FocusRight {
+			return m.selectTab(m.activeTab - n%len(m.tabs)), nil
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.GotoTab):
+		if n := slices.Index(m.keys.GotoTab.Keys(), msg.String()) + 1; n <= len(m.tabs) {
+			m.focus = FocusRight
+			return m.selectTab(n - 1), nil
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.MoveUp):
+		if m.focus == FocusLeft {
+			return m.moveSelected(-1), nil
+		}
+		return m, nil
+	case key.Matches(msg, m.keys.MoveDown):
+		if m.focus == FocusLeft {
+			return m.moveSelected(1), nil
+		}
 		return m, nil
+	case key.Matches(msg, m.keys.Up):
+		m, n := m.takeCount()
+		return repeat(m, n, Model.moveCursorUp), nil
 	case key.Matches(msg, m.keys.Down):
-		m = m.moveCursorDown()
+		m, n := m.takeCount()
+		return repeat(m, n, Model.moveCursorDown), nil
+	case key.Matches(msg, m.keys.Goto):
+		m.pendingKey = "g"
 		return m, nil
+	case key.Matches(msg, m.keys.Bottom):
+		if m.count > 0 {
+			m, n := m.takeCount()
+			return m.gotoLine(n), nil
+		}
+		return m.gotoBottom(), nil
 	case key.Matches(msg, m.keys.Back):
+		// Work in flight is the first thing esc gives up.
+		if m.busy() {
+			return m.cancelNetwork(), nil
+		}
+		if m.findQuery != "" {
+			return m.clearFind(), nil
+		}
 		if m.filterActive {
 			m = m.resetView()
 		}
 		return m, nil
+	case key.Matches(msg, m.keys.Undo):
+		return m.popFilter(), nil
 	case key.Matches(msg, m.keys.Help):
-		m.viewMode = ViewHelp
-		return m, nil
+		return m.openHelp(), nil
 	case key.Matches(msg, m.keys.Search):
-		m.viewMode = ViewPopup
-		m.popupType = PopupSearch
-		m.textInput.Placeholder = "Search query..."
-		m.textInput.Focus()
-		return m, textinput.Blink
+		return m.openSearch()
+	case key.Matches(msg, m.keys.NextMatch):
+		m, n := m.takeCount()
+		return repeat(m, n, func(m Model) Model { return m.jumpToMatch(1) }), nil
+	case key.Matches(msg, m.keys.PrevMatch):
+		m, n := m.takeCount()
+		return repeat(m, n, func(m Model) Model { return m.jumpToMatch(-1) }), nil
 	case key.Matches(msg, m.keys.Filter):
 		m.viewMode = ViewPopup
 		m.popupType = PopupFilter
-		m.textInput.Placeholder = "Filter (expired, expiring, valid, self-signed)"
+		m.textInput.Placeholder = "Filter (" + strings.Join(filterForms, ", ") + "…)"
 		m.textInput.Focus()
 		return m, textinput.Blink
 	case key.Matches(msg, m.keys.Validate):
+		m.validateAt = time.Time{}
 		m = m.handleValidateCommand()
 		return m, nil
+	case key.Matches(msg, m.keys.Command):
+		m.viewMode = ViewCommand
+		m.commandInput.Reset()
+		return m, m.commandInput.Focus()
 	case key.Matches(msg, m.keys.Export):
 		m.viewMode = ViewPopup
 		m.popupType = PopupExport
-		m.exportForm = newExportForm()
+		m.exportForm = newExportForm(len(m.marked) > 0)
 		return m, m.exportForm.Init()
 	case key.Matches(msg, m.keys.Yank):
-		var cmd tea.Cmd
-		m, cmd = m.handleYankCommand()
-		return m, cmd
+		if m.focus == FocusRight {
+			return m.yankDetailLine()
+		}
+		return m.handleCopyCommand("pem")
+	case key.Matches(msg, m.keys.PrevSource):
+		return m.selectSource(m.activeSource - 1).checkChain()
+	case key.Matches(msg, m.keys.NextSource):
+		return m.selectSource(m.activeSource + 1).checkChain()
+	case key.Matches(msg, m.keys.Mark):
+		return m.toggleMark(), nil
+	case key.Matches(msg, m.keys.Bookmark):
+		m.pendingKey = pendingBookmark
+		return m, nil
+	case key.Matches(msg, m.keys.JumpToBookmark):
+		m.pendingKey = pendingJump
+		return m, nil
+	case key.Matches(msg, m.keys.Hide):
+		return m.hideSelected()
+	case key.Matches(msg, m.keys.Diff):
+		return m.handleDiffCommand(nil), nil
 	}
 
 	return m, nil
@@ -203,7 +367,7 @@ func (m Model) moveCursorUp() Model {
 			m = m.refreshViewportContent()
 		}
 	} else {
-		m.viewport.ScrollUp(1)
+		m = m.moveDetailCursor(-1)
 	}
 	return m
 }
@@ -218,11 +382,34 @@ func (m Model) moveCursorDown() Model {
 			m = m.refreshViewportContent()
 		}
 	} else {
-		m.viewport.ScrollDown(1)
+		m = m.moveDetailCursor(1)
 	}
 	return m
 }
 
+// toggleFullscreen widens the details pane over the list, or restores the
+// split. The details keep the focus while full screen, so the arrow keys
+// scroll them.
+func (m Model) toggleFullscreen() Model {
+	m.fullscreen = !m.fullscreen
+	if m.fullscreen {
+		m.focus = FocusRight
+	} else {
+		m.focus = FocusLeft
+	}
+	m = m.resizeComponents()
+	return m.refreshViewportContent()
+}
+
+// paneWidths splits the screen between the list and the details.
+func (m Model) paneWidths() (left, right int) {
+	if m.fullscreen {
+		return 0, m.width
+	}
+	left = m.width * 2 / 5
+	return left, m.width - left
+}
+
 // resizeComponents recomputes child component sizes from the current
 // terminal dimensions. Both panes derive their geometry from the same
 // constants used by the renderers, keeping Update and View in agreement.
@@ -231,15 +418,17 @@ func (m Model) resizeComponents() Model {
 		return m
 	}
 
-	leftPaneWidth := m.width * 2 / 5
-	rightPaneWidth := m.width - leftPaneWidth
+	leftPaneWidth, rightPaneWidth := m.paneWidths()
 	paneHeight := m.height - HeaderHeight - statusBarHeight
 
 	// List sits inside the left pane, below the SUBJECT/EXPIRES header,
 	// inside one visible left border column and the rounded top + bottom
 	// border rows.
-	listInnerWidth := leftPaneWidth - PaneSideBorderWidth
+	listInnerWidth := max(1, leftPaneWidth-PaneSideBorderWidth)
 	listInnerHeight := paneHeight - PaneBorderHeight - ListHeaderHeight
+	if len(m.sources) > 0 {
+		listInnerHeight -= sourceBarHeight
+	}
 	if listInnerHeight < 1 {
 		listInnerHeight = 1
 	}
@@ -271,14 +460,48 @@ func (m Model) refreshViewportContent() Model {
 	if m.viewport.Width() <= 0 || m.list.Index() >= len(m.certificates) {
 		return m
 	}
-	m.viewport.SetContent(m.renderTabContent(m.viewport.Width()))
+	content, matchLines := m.highlightContent(m.renderTabContent(m.viewport.Width()))
+	m.viewport.SetContent(content)
+	m.matchLines, m.matchIndex = matchLines, -1
 	return m
 }
 
-// updateHelpMode handles key events in help mode
-func (m Model) updateHelpMode(_ tea.KeyPressMsg) (tea.Model, tea.Cmd) {
-	m.viewMode = ViewNormal
-	return m, nil
+// updateCommandMode handles key events while the ':' command line is open.
+func (m Model) updateCommandMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "tab":
+		return m.cycleCompletion(1).previewTheme(), nil
+	case "shift+tab":
+		return m.cycleCompletion(-1).previewTheme(), nil
+	}
+	// Any other key settles on the candidate shown and closes the menu.
+	m = m.clearCompletion()
+
+	switch msg.String() {
+	case "enter":
+		line := m.commandInput.Value()
+		m.viewMode = ViewNormal
+		m.commandInput.Reset()
+		m.commandInput.Blur()
+		m.themeBeforePreview = ""
+		return m.executeCommand(line)
+	case "esc":
+		m.viewMode = ViewNormal
+		m.commandInput.Reset()
+		m.commandInput.Blur()
+		return m.endThemePreview(), nil
+	case "backspace":
+		// Backspacing past the prompt closes the line, as in vim.
+		if m.commandInput.Value() == "" {
+			m.viewMode = ViewNormal
+			m.commandInput.Blur()
+			return m.endThemePreview(), nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.commandInput, cmd = m.commandInput.Update(msg)
+	return m.previewTheme(), cmd
 }
 
 // updatePopupMode handles key events in popup mode
@@ -287,14 +510,46 @@ func (m Model) updatePopupMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 
 	// Handle Alert Popup (no input, just dismiss)
 	if m.popupType == PopupAlert {
+		if keyStr == "f" && len(m.pendingIssuerURLs) > 0 {
+			urls := m.pendingIssuerURLs
+			m.pendingIssuerURLs = nil
+			m.popupMessage = fmt.Sprintf("⏳  Fetching issuer...\n\n%s\n\nesc cancels; enter carries on in the background", urls[0])
+			var ctx context.Context
+			ctx, m.fetchCancel = context.WithCancel(m.netCtx)
+			return m, tea.Batch(fetchIssuerCmd(ctx, urls), m.spinner.Tick)
+		}
+		if keyStr == "esc" && m.fetchCancel != nil {
+			m = m.cancelFetch()
+		}
 		if keyStr == "enter" || keyStr == "esc" || keyStr == "q" {
 			m.viewMode = ViewNormal
 			m.popupType = PopupNone
+			m.pendingIssuerURLs = nil
 			return m, nil
 		}
 		return m, nil
 	}
 
+	// An export waiting to know whether it may overwrite: y or enter go
+	// ahead, n or esc leave the files as they were.
+	if m.popupType == PopupConfirm && m.pendingExport != nil {
+		pending := *m.pendingExport
+		switch keyStr {
+		case "y", "Y", "enter":
+			m.pendingExport = nil
+			if pending.pubkey {
+				return m.exportPublicKey(pending.certs, pending.filename, true)
+			}
+			return m.exportCertificates(pending.certs, pending.filename, pending.format, true)
+		case "n", "N", "esc", "q":
+			m.pendingExport = nil
+			m.viewMode = ViewNormal
+			m.popupType = PopupNone
+			return m.notify("Export cancelled: %s was left as it was", pending.filename)
+		}
+		return m, nil
+	}
+
 	// Export popup is driven by huh; delegate the message and bail out.
 	if m.popupType == PopupExport && m.exportForm != nil {
 		if keyStr == "esc" {
@@ -306,7 +561,7 @@ func (m Model) updatePopupMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 		return m.updateExportForm(msg)
 	}
 
-	// Handle Input Popups (Search/Filter)
+	// Handle the filter input popup
 	switch keyStr {
 	case "enter":
 		value := m.textInput.Value()
@@ -320,10 +575,7 @@ func (m Model) updatePopupMode(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 		m.popupType = PopupNone
 		m.textInput.Reset()
 
-		switch submitted {
-		case PopupSearch:
-			m = m.searchCertificates(value)
-		case PopupFilter:
+		if submitted == PopupFilter {
 			m = m.filterCertificates(value)
 		}
 		return m, nil
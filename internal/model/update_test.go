@@ -3,14 +3,21 @@ package model
 import (
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
 	"math/big"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kanywst/y509/internal/config"
 	"github.com/kanywst/y509/pkg/certificate"
 )
 
@@ -186,11 +193,11 @@ func TestExportFormCompletesThroughUpdate(t *testing.T) {
 	if m.exportForm != nil {
 		t.Fatalf("export form never completed (huh state = %v)", m.exportForm.State)
 	}
-	if m.popupType != PopupAlert {
-		t.Errorf("expected an alert popup after export, got popupType=%v", m.popupType)
+	if m.viewMode != ViewNormal {
+		t.Errorf("expected the popup closed after export, got viewMode=%v", m.viewMode)
 	}
-	if !strings.Contains(m.popupMessage, "successfully") {
-		t.Errorf("expected a success message, got %q", m.popupMessage)
+	if !strings.Contains(lastToast(m), "Exported to "+target+".pem") {
+		t.Errorf("expected a success toast, got %q", lastToast(m))
 	}
 	// The form's default format is PEM, so the extension is appended for us.
 	if _, err := os.Stat(target + ".pem"); err != nil {
@@ -198,6 +205,48 @@ func TestExportFormCompletesThroughUpdate(t *testing.T) {
 	}
 }
 
+// TestExportFormCompletesPathsAndAsksBeforeOverwriting covers the export
+// form's Tab completion of the filename and its question when the file is
+// already there.
+func TestExportFormCompletesPathsAndAsksBeforeOverwriting(t *testing.T) {
+	cfg := loadTestConfig(t)
+	m := *NewModel(createTestCertificates(1), cfg)
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.pem")
+	if err := os.WriteFile(existing, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m = pump(t, m, keyPress('e'))
+	m = pump(t, m, tea.PasteMsg{Content: filepath.Join(dir, "exi")})
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyTab}))
+	if !strings.Contains(ansi.Strip(m.View().Content), "exists") {
+		t.Fatalf("completing to an existing file gave no warning:\n%s", ansi.Strip(m.View().Content))
+	}
+
+	// Filename, format, then the overwrite question, which defaults to no.
+	for range 3 {
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	}
+	if m.exportForm != nil || !strings.Contains(lastToast(m), "Export cancelled") || !strings.Contains(lastToast(m), existing) {
+		t.Fatalf("declining to overwrite did not cancel: form=%v toast=%q", m.exportForm != nil, lastToast(m))
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "keep me" {
+		t.Error("the existing file was overwritten")
+	}
+
+	// A missing directory is caught before the export is tried.
+	m = pump(t, m, keyPress('e'))
+	m = pump(t, m, tea.PasteMsg{Content: filepath.Join(dir, "missing", "out")})
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if !m.exportFormOpen() || !strings.Contains(ansi.Strip(m.View().Content), "no directory") {
+		t.Errorf("a missing directory was not reported:\n%s", ansi.Strip(m.View().Content))
+	}
+}
+
 func TestHelpModeQClosesWithoutQuitting(t *testing.T) {
 	cfg := loadTestConfig(t)
 	m := *NewModel([]*certificate.Info{createDummyCert(1)}, cfg)
@@ -237,6 +286,22 @@ func TestCtrlCQuitsFromSplash(t *testing.T) {
 	}
 }
 
+// TestNoSplashStartsOnCertificates checks no_splash opens on the list, so
+// the first key acts on it rather than only dismissing the splash.
+func TestNoSplashStartsOnCertificates(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.NoSplash = true
+	m := *NewModel(createTestCertificates(2), cfg)
+	if m.viewMode != ViewNormal {
+		t.Fatalf("expected to start on ViewNormal, got %v", m.viewMode)
+	}
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = pump(t, m, keyPress('j'))
+	if m.list.Index() != 1 {
+		t.Errorf("the first key did not move the cursor: index %d", m.list.Index())
+	}
+}
+
 // TestInvalidFilterShowsAlert checks that submitting an unknown filter type
 // surfaces the error. filterCertificates raises a PopupAlert; the enter handler
 // used to clear popupType right after calling it, leaving ViewPopup with no
@@ -286,21 +351,21 @@ func TestLateSplashDoneDoesNotClosePopup(t *testing.T) {
 		t.Fatalf("expected to start on the splash, got %v", m.viewMode)
 	}
 
-	// A key dismisses the splash, and the user immediately opens search.
+	// A key dismisses the splash, and the user immediately opens filter.
 	m = pump(t, m, keyPress('x'))
-	m = pump(t, m, keyPress('/'))
+	m = pump(t, m, keyPress('f'))
 	m = pump(t, m, keyPress('a'))
 
-	if m.viewMode != ViewPopup || m.popupType != PopupSearch {
-		t.Fatalf("expected the search popup to be open, got viewMode=%v popupType=%v",
+	if m.viewMode != ViewPopup || m.popupType != PopupFilter {
+		t.Fatalf("expected the filter popup to be open, got viewMode=%v popupType=%v",
 			m.viewMode, m.popupType)
 	}
 
 	// Now the splash timer finally fires.
 	m = pump(t, m, SplashDoneMsg{})
 
-	if m.viewMode != ViewPopup || m.popupType != PopupSearch {
-		t.Errorf("a late SplashDoneMsg closed the search popup: viewMode=%v popupType=%v",
+	if m.viewMode != ViewPopup || m.popupType != PopupFilter {
+		t.Errorf("a late SplashDoneMsg closed the filter popup: viewMode=%v popupType=%v",
 			m.viewMode, m.popupType)
 	}
 	if got := m.textInput.Value(); got != "a" {
@@ -343,3 +408,1151 @@ func (m Model) updateExportFormModel(t *testing.T, msg tea.Msg) Model {
 	settle(cmd)
 	return out
 }
+
+// runCommand opens the command line, types line and submits it.
+func runCommand(t *testing.T, m Model, line string) Model {
+	t.Helper()
+	m = pump(t, m, keyPress(':'))
+	if m.viewMode != ViewCommand {
+		t.Fatalf("':' did not open the command line, viewMode=%v", m.viewMode)
+	}
+	m = pumpKeys(t, m, []rune(line)...)
+	next, _ := m.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	return next.(Model)
+}
+
+func TestCommandMode(t *testing.T) {
+	cfg := loadTestConfig(t)
+	m := *NewModel(createTestCertificates(3), cfg)
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	t.Run("ValidateAt", func(t *testing.T) {
+		m := runCommand(t, m, "validate at 2000-01-01")
+		if m.viewMode != ViewPopup || m.popupType != PopupAlert {
+			t.Fatalf("expected the validation popup, got viewMode=%v popupType=%v", m.viewMode, m.popupType)
+		}
+		if !strings.Contains(m.popupMessage, "As of 2000-01-01") {
+			t.Errorf("popup does not say when it validated at:\n%s", m.popupMessage)
+		}
+		// The test certificates were issued today, so in 2000 they are not
+		// yet valid.
+		if !strings.Contains(m.popupMessage, "INVALID") {
+			t.Errorf("a certificate from the future should not validate:\n%s", m.popupMessage)
+		}
+	})
+
+	t.Run("BadDate", func(t *testing.T) {
+		m := runCommand(t, m, "validate at someday")
+		if m.viewMode != ViewNormal || !strings.Contains(m.commandError, "invalid time") {
+			t.Errorf("expected an error in the status bar, got viewMode=%v commandError=%q", m.viewMode, m.commandError)
+		}
+		if !strings.Contains(m.View().Content, "invalid time") {
+			t.Error("the command error is not rendered")
+		}
+		// The next key clears it.
+		m = pump(t, m, keyPress('j'))
+		if m.commandError != "" {
+			t.Errorf("commandError survived a key press: %q", m.commandError)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		m := runCommand(t, m, "frobnicate")
+		if !strings.Contains(m.commandError, "unknown command") {
+			t.Errorf("commandError = %q", m.commandError)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		m := runCommand(t, m, "filter valid")
+		if !m.filterActive || m.filterType != "valid" {
+			t.Errorf("filter not applied: active=%v type=%q", m.filterActive, m.filterType)
+		}
+	})
+
+	t.Run("Covers", func(t *testing.T) {
+		m := runCommand(t, m, "covers www.example.com")
+		if m.viewMode != ViewPopup || !strings.Contains(m.popupMessage, "No loaded certificate covers") {
+			t.Errorf("expected a coverage popup with no match, got:\n%s", m.popupMessage)
+		}
+		if !strings.Contains(m.popupMessage, "Test Certificate A") {
+			t.Errorf("coverage popup does not list the misses:\n%s", m.popupMessage)
+		}
+
+		m = runCommand(t, pump(t, m, keyPress('q')), "covers")
+		if !strings.Contains(m.commandError, "usage: covers") {
+			t.Errorf("commandError = %q", m.commandError)
+		}
+	})
+
+	t.Run("MatchMissingKey", func(t *testing.T) {
+		m := runCommand(t, m, "match /nonexistent/key.pem")
+		if m.viewMode != ViewNormal || !strings.Contains(m.commandError, "failed to read private key") {
+			t.Errorf("expected a read error in the status bar, got viewMode=%v commandError=%q", m.viewMode, m.commandError)
+		}
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		m := runCommand(t, m, "copy fingerprint")
+		if m.viewMode != ViewNormal || lastToast(m) != "Copied SHA-256 fingerprint" {
+			t.Errorf("expected a copy confirmation, got viewMode=%v toast=%q", m.viewMode, lastToast(m))
+		}
+
+		m = runCommand(t, m, "copy key")
+		if !strings.Contains(m.commandError, "usage: copy pem|fingerprint|serial|subject") {
+			t.Errorf("commandError = %q", m.commandError)
+		}
+	})
+
+	t.Run("YankField", func(t *testing.T) {
+		m := runCommand(t, m, "yank notafter")
+		want := m.certificates[m.list.Index()].Certificate.NotAfter.UTC().Format(time.RFC3339)
+		if lastToast(m) != "Copied end of validity" {
+			t.Errorf("expected a copy confirmation for %s, got %q", want, lastToast(m))
+		}
+	})
+
+	t.Run("YankLine", func(t *testing.T) {
+		m := m.showTab("Subject")
+		if !strings.Contains(ansi.Strip(m.renderRightPane(72, 30)), "CN") {
+			t.Fatal("Subject tab not shown")
+		}
+		// The details are short, so j moves the cursor rather than scrolling.
+		next, _ := m.Update(keyPress('j'))
+		m = next.(Model)
+		next, cmd := m.Update(keyPress('y'))
+		m = next.(Model)
+		if cmd == nil || !strings.Contains(lastToast(m), "Organization") || !strings.Contains(lastToast(m), "Test Org") {
+			t.Errorf("expected the Organization line copied, got %q", lastToast(m))
+		}
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		m := runCommand(t, m, "diff 1 2")
+		if m.viewMode != ViewDiff {
+			t.Fatalf("expected the diff view, got viewMode=%v commandError=%q", m.viewMode, m.commandError)
+		}
+		view := m.View().Content
+		for _, want := range []string{"fields differ", "≠ Subject", "Fingerprint"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("diff view missing %q:\n%s", want, view)
+			}
+		}
+
+		// A field name filling its column is still kept off its value.
+		chain, err := certificate.GenerateChain("ecdsa-p256", certificate.GenerateOptions{Subject: pkix.Name{CommonName: "Diff CA"}, PathLen: -1}, nil,
+			certificate.GenerateOptions{Subject: pkix.Name{CommonName: "diff.example.com"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.diffPair = [2]*certificate.Info{{Certificate: chain[0].Certificate}, {Certificate: chain[1].Certificate}}
+		field := "Extended Key Usage"
+		row, found := "", false
+		for line := range strings.Lines(ansi.Strip(m.renderDiffContent(120))) {
+			if _, rest, ok := strings.Cut(line, field); ok {
+				row, found = rest, true
+				break
+			}
+		}
+		if !found || !strings.HasPrefix(row, " ") {
+			t.Errorf("%q runs into its value: %q", field, row)
+		}
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+		if m.viewMode != ViewNormal {
+			t.Errorf("esc did not close the diff, viewMode=%v", m.viewMode)
+		}
+
+		m = runCommand(t, m, "diff 1 9")
+		if !strings.Contains(m.commandError, `no certificate "9"`) {
+			t.Errorf("commandError = %q", m.commandError)
+		}
+	})
+
+	t.Run("DiffMarked", func(t *testing.T) {
+		m := pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeySpace}))
+		m = pump(t, m, keyPress('j'))
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeySpace}))
+		if len(m.marked) != 2 {
+			t.Fatalf("marked %d certificates, want 2", len(m.marked))
+		}
+		m = pump(t, m, keyPress('d'))
+		if m.viewMode != ViewDiff || m.diffPair[0] != m.marked[0] || m.diffPair[1] != m.marked[1] {
+			t.Errorf("d did not diff the marked pair, viewMode=%v", m.viewMode)
+		}
+	})
+
+	t.Run("BatchExportAndCopy", func(t *testing.T) {
+		m := pump(t, m, keyPress('j'))
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeySpace}))
+		m = pump(t, m, keyPress('k'))
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeySpace}))
+		if !strings.Contains(ansi.Strip(m.renderStatusBar()), "2 marked") {
+			t.Errorf("status bar does not count the marks:\n%s", ansi.Strip(m.renderStatusBar()))
+		}
+
+		// A file gets a bundle, in the order the certificates were marked.
+		bundle := filepath.Join(t.TempDir(), "bundle.pem")
+		m = runCommand(t, m, "export "+bundle)
+		if lastToast(m) != "Exported 2 certificates to "+bundle {
+			t.Errorf("toast = %q, commandError = %q", lastToast(m), m.commandError)
+		}
+		certs, err := certificate.LoadCertificates(bundle)
+		if err != nil || len(certs) != 2 || !certs[0].Certificate.Equal(m.marked[0].Certificate) {
+			t.Errorf("bundle holds %d certificates (%v), want the two marked in order", len(certs), err)
+		}
+
+		// A directory gets a file each.
+		dir := t.TempDir()
+		m = runCommand(t, m, "export "+dir)
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 2 || entries[0].Name() != "01-Test_Certificate_B.pem" || entries[1].Name() != "02-Test_Certificate_A.pem" {
+			t.Errorf("directory export wrote %v", entries)
+		}
+
+		m = runCommand(t, m, "copy fp")
+		if lastToast(m) != "Copied SHA-256 fingerprint of 2 certificates" {
+			t.Errorf("toast = %q", lastToast(m))
+		}
+	})
+
+	t.Run("HideAndUnhide", func(t *testing.T) {
+		m := pump(t, m, keyPress('j'))
+		hidden := m.certificates[1]
+		m = pump(t, m, keyPress('x'))
+		if len(m.certificates) != 2 || slices.Contains(m.allCertificates, hidden) || m.list.Index() != 1 {
+			t.Fatalf("x left %d certificates, cursor %d", len(m.certificates), m.list.Index())
+		}
+		if !strings.Contains(lastToast(m), "Hid "+hidden.Certificate.Subject.CommonName) || !strings.Contains(ansi.Strip(m.renderStatusBar()), "1 hidden") {
+			t.Errorf("hiding was not reported: toast %q", lastToast(m))
+		}
+
+		// Hidden certificates are out of searches too.
+		m = runCommand(t, m, "search "+hidden.Certificate.Subject.CommonName)
+		if slices.Contains(m.certificates, hidden) {
+			t.Error("search found a hidden certificate")
+		}
+
+		m = runCommand(t, m, "reset")
+		m = runCommand(t, m, "unhide")
+		if len(m.allCertificates) != 3 || m.certificates[1] != hidden || len(m.hidden) != 0 {
+			t.Errorf("unhide did not restore the certificate in its place")
+		}
+		m = runCommand(t, m, "unhide")
+		if m.commandError != "nothing is hidden" {
+			t.Errorf("commandError = %q", m.commandError)
+		}
+	})
+
+	t.Run("Edit", func(t *testing.T) {
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "code -w")
+		if args := editorCommand("x.pem").Args; !slices.Equal(args, []string{"code", "-w", "x.pem"}) {
+			t.Errorf("editor command = %v", args)
+		}
+
+		// The editor is not run here: the test stands in for it, writing
+		// the file and sending what its exit would.
+		m := pump(t, m, keyPress('j'))
+		target := m.certificates[1]
+		original := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: target.Certificate.Raw})
+		edit := func(m Model, content []byte) Model {
+			path := filepath.Join(t.TempDir(), "edit.pem")
+			if err := os.WriteFile(path, content, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			return pump(t, m, editedMsg{target: target, path: path, original: original})
+		}
+
+		m = edit(m, original)
+		if lastToast(m) != "No changes" {
+			t.Errorf("unchanged edit: toast %q", lastToast(m))
+		}
+		if broken := edit(m, []byte("not a certificate")); broken.viewMode != ViewPopup || !strings.Contains(broken.popupMessage, "does not parse") {
+			t.Errorf("an unparsable edit was not reported: %q", broken.popupMessage)
+		}
+
+		// The certificate is replaced in place by what the file now holds.
+		replacement := m.certificates[0].Certificate.Raw
+		m = edit(m, append(slices.Clone(original), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: replacement})...))
+		if len(m.allCertificates) != 4 || slices.Contains(m.allCertificates, target) || m.list.Index() != 1 {
+			t.Fatalf("edit left %d certificates, cursor %d", len(m.allCertificates), m.list.Index())
+		}
+		if !m.certificates[1].Certificate.Equal(target.Certificate) || m.certificates[1].Source != target.Source {
+			t.Error("the edited certificate is not in its place")
+		}
+		if lastToast(m) != "Reloaded 2 certificate(s) from the editor" {
+			t.Errorf("toast = %q", lastToast(m))
+		}
+	})
+
+	t.Run("Pipe", func(t *testing.T) {
+		// The command runs in the background; wait for it here rather than
+		// in pump, which gives up on slow commands.
+		pipe := func(m Model, line string) Model {
+			m, cmd := m.executeCommand(line)
+			if cmd == nil {
+				t.Fatalf("%s ran nothing: %s", line, m.commandError)
+			}
+			return pump(t, m, cmd())
+		}
+
+		m := pipe(m, "pipe pem grep -c BEGIN")
+		if m.viewMode != ViewPipe || strings.TrimSpace(m.pipeOutput) != "1" || m.pipeStatus != "" {
+			t.Fatalf("pipe pem: view %v, output %q, status %q", m.viewMode, m.pipeOutput, m.pipeStatus)
+		}
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+		if m.viewMode != ViewNormal {
+			t.Errorf("esc left view mode %v", m.viewMode)
+		}
+
+		m = pipe(m, "pipe grep -i 'no such line'")
+		if m.viewMode != ViewPipe || m.pipeStatus != "exit status 1" || !strings.Contains(ansi.Strip(m.renderPipeView()), "(no output)") {
+			t.Errorf("a failing command: status %q\n%s", m.pipeStatus, ansi.Strip(m.renderPipeView()))
+		}
+
+		if !isPager("/usr/bin/less -R") || isPager("grep less") {
+			t.Error("pagers are not told apart by the command name")
+		}
+	})
+
+	t.Run("ReorderAndExportBundle", func(t *testing.T) {
+		names := func(m Model) string {
+			var cns []string
+			for _, c := range m.certificates {
+				cns = append(cns, strings.TrimPrefix(c.Certificate.Subject.CommonName, "Test Certificate "))
+			}
+			return strings.Join(cns, "")
+		}
+		before := names(m)
+		m := pumpKeys(t, m, 'J', 'J', 'J')
+		if got, want := names(m), before[1:2]+before[2:3]+before[0:1]; got != want || m.list.Index() != 2 {
+			t.Fatalf("order after J J J = %s (cursor %d), want %s with the cursor following", got, m.list.Index(), want)
+		}
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyUp, Mod: tea.ModShift}))
+		if got, want := names(m), before[1:2]+before[0:1]+before[2:3]; got != want || m.list.Index() != 1 {
+			t.Fatalf("order after shift+up = %s, want %s", got, want)
+		}
+
+		bundle := filepath.Join(t.TempDir(), "chain.pem")
+		m = runCommand(t, m, "export bundle "+bundle)
+		certs, err := certificate.LoadCertificates(bundle)
+		if err != nil || len(certs) != 3 {
+			t.Fatalf("bundle holds %d certificates: %v", len(certs), err)
+		}
+		for i, c := range certs {
+			if !c.Certificate.Equal(m.certificates[i].Certificate) {
+				t.Errorf("bundle entry %d is out of the displayed order", i)
+			}
+		}
+
+		m = runCommand(t, m, "search Certificate")
+		m = pumpKeys(t, m, 'K')
+		if !strings.Contains(m.commandError, "match order") {
+			t.Errorf("reordering search results: commandError = %q", m.commandError)
+		}
+	})
+
+	t.Run("Tab", func(t *testing.T) {
+		m := runCommand(t, m, "san")
+		if m.tabs[m.activeTab] != "SANs" || m.focus != FocusRight {
+			t.Errorf("expected the SANs tab focused, got %q focus=%v", m.tabs[m.activeTab], m.focus)
+		}
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		tab := tea.KeyPressMsg(tea.Key{Code: tea.KeyTab})
+		typeAndTab := func(line string) Model {
+			m := pump(t, m, keyPress(':'))
+			m = pumpKeys(t, m, []rune(line)...)
+			return pump(t, m, tab)
+		}
+
+		if got := typeAndTab("rese").commandInput.Value(); got != "reset " {
+			t.Errorf("a unique command should complete outright, got %q", got)
+		}
+
+		m := typeAndTab("filter ex")
+		if !slices.Equal(m.completions, []string{"expired", "expiring"}) || m.commandInput.Value() != "filter expired" {
+			t.Fatalf("completions=%v line=%q", m.completions, m.commandInput.Value())
+		}
+		if !strings.Contains(m.View().Content, "expiring") {
+			t.Errorf("completion menu not shown:\n%s", m.View().Content)
+		}
+		m = pump(t, m, tab)
+		if m.commandInput.Value() != "filter expiring" {
+			t.Errorf("tab should move to the next candidate, got %q", m.commandInput.Value())
+		}
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyTab, Mod: tea.ModShift}))
+		if m.commandInput.Value() != "filter expired" {
+			t.Errorf("shift+tab should move back, got %q", m.commandInput.Value())
+		}
+		next, _ := m.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+		if m = next.(Model); m.filterType != "expired" || m.completions != nil {
+			t.Errorf("enter should run the candidate shown, filterType=%q", m.filterType)
+		}
+
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "leaf.pem"), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Mkdir(filepath.Join(dir, "keys"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if got := typeAndTab("export " + dir + "/le").commandInput.Value(); got != "export "+dir+"/leaf.pem " {
+			t.Errorf("path completion got %q", got)
+		}
+		if got := typeAndTab("match " + dir + "/k").commandInput.Value(); got != "match "+dir+"/keys/" {
+			t.Errorf("a directory should complete with a slash, got %q", got)
+		}
+	})
+
+	t.Run("EscCancels", func(t *testing.T) {
+		m := pump(t, m, keyPress(':'))
+		m = pumpKeys(t, m, 'q')
+		m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+		if m.viewMode != ViewNormal {
+			t.Errorf("esc did not close the command line, viewMode=%v", m.viewMode)
+		}
+	})
+}
+
+func TestSourceTabs(t *testing.T) {
+	certs := createTestCertificates(3)
+	certs[0].Source = "/tmp/old-bundle.pem"
+	certs[1].Source = "/tmp/new-bundle.pem"
+	certs[2].Source = "/tmp/new-bundle.pem"
+	m := *NewModel(certs, loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	if len(m.sources) != 2 || len(m.certificates) != 3 {
+		t.Fatalf("sources=%v certificates=%d, want 2 sources on an All tab of 3", m.sources, len(m.certificates))
+	}
+	if view := m.View().Content; !strings.Contains(view, "All 3") || !strings.Contains(view, "new-bundle.pem 2") {
+		t.Errorf("file tabs missing from the list pane:\n%s", view)
+	}
+
+	m = pump(t, m, keyPress(']'))
+	if m.activeSource != 1 || len(m.certificates) != 1 || m.certificates[0] != certs[0] {
+		t.Errorf("] did not show only old-bundle.pem, activeSource=%d certificates=%d", m.activeSource, len(m.certificates))
+	}
+	m = pump(t, m, keyPress('['))
+	m = pump(t, m, keyPress('['))
+	if m.activeSource != 2 {
+		t.Errorf("[ from All should wrap to the last file, activeSource=%d", m.activeSource)
+	}
+
+	m = runCommand(t, m, "source new-bundle.pem")
+	if m.activeSource != 2 || len(m.certificates) != 2 {
+		t.Errorf(":source by name, activeSource=%d certificates=%d", m.activeSource, len(m.certificates))
+	}
+	m = runCommand(t, m, "search Certificate A")
+	if len(m.certificates) != 0 {
+		t.Errorf("search leaked across files: %d matches on new-bundle.pem", len(m.certificates))
+	}
+	m = runCommand(t, m, "source all")
+	if m.activeSource != 0 || len(m.certificates) != 1 {
+		t.Errorf("search should carry over to All, activeSource=%d certificates=%d", m.activeSource, len(m.certificates))
+	}
+	m = runCommand(t, m, "source 7")
+	if !strings.Contains(m.commandError, `no source "7"`) {
+		t.Errorf("commandError = %q", m.commandError)
+	}
+}
+
+func TestSingleSourceHasNoTabs(t *testing.T) {
+	m := *NewModel(createTestCertificates(2), loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	if m.sources != nil {
+		t.Errorf("sources = %v, want none for a single input", m.sources)
+	}
+	m = runCommand(t, m, "source 1")
+	if m.commandError != "only one source is loaded" {
+		t.Errorf("commandError = %q", m.commandError)
+	}
+}
+
+func TestLiveSearch(t *testing.T) {
+	certs := createTestCertificates(3)
+	certs[0].Certificate.Subject.CommonName = "alpha.example.com"
+	certs[1].Certificate.Subject.CommonName = "beta.example.com"
+	certs[2].Certificate.Subject.CommonName = "gamma.internal"
+	m := *NewModel(certs, loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	m = pump(t, m, keyPress('/'))
+	m = pumpKeys(t, m, 'b', 'e')
+	if m.viewMode != ViewSearch || len(m.certificates) != 1 || m.certificates[0] != certs[1] {
+		t.Fatalf("the list should narrow while typing: viewMode=%v certificates=%d", m.viewMode, len(m.certificates))
+	}
+	if !strings.Contains(ansi.Strip(m.View().Content), "/be") {
+		t.Errorf("search line not shown:\n%s", m.View().Content)
+	}
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if m.viewMode != ViewNormal || len(m.certificates) != 3 || m.filterActive {
+		t.Errorf("esc should restore the full list, viewMode=%v certificates=%d", m.viewMode, len(m.certificates))
+	}
+
+	m = pump(t, m, keyPress('/'))
+	m = pumpKeys(t, m, []rune("example")...)
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if m.viewMode != ViewNormal || len(m.certificates) != 2 || m.searchQuery != "example" {
+		t.Fatalf("enter should keep the search, viewMode=%v certificates=%d", m.viewMode, len(m.certificates))
+	}
+	if len(m.matchLines) == 0 {
+		t.Fatal("the Subject tab should have a line holding the query")
+	}
+
+	first := m.list.Index()
+	for range m.matchLines {
+		m = pump(t, m, keyPress('n'))
+	}
+	if m.list.Index() != first {
+		t.Errorf("n should stay on the certificate while it has matches left")
+	}
+	m = pump(t, m, keyPress('n'))
+	if m.list.Index() == first || m.matchIndex != 0 {
+		t.Errorf("n past the last match should move to the next certificate, index=%d matchIndex=%d", m.list.Index(), m.matchIndex)
+	}
+	m = pump(t, m, keyPress('N'))
+	if m.list.Index() != first || m.matchIndex != len(m.matchLines)-1 {
+		t.Errorf("N should go back to the last match of the previous certificate, index=%d matchIndex=%d", m.list.Index(), m.matchIndex)
+	}
+}
+
+// TestFindInDetails checks '/' with the details focused: it finds within
+// them, on the Raw tab as on any other, leaves the list alone, wraps n round
+// the matches, and lets go on esc.
+func TestFindInDetails(t *testing.T) {
+	m := pump(t, *NewModel(createTestCertificates(3), loadTestConfig(t)), tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	m = runCommand(t, m, "raw")
+	if m.focus != FocusRight {
+		t.Fatal(":raw did not focus the details")
+	}
+
+	m = pump(t, m, keyPress('/'))
+	m = pumpKeys(t, m, []rune("sequence")...)
+	if !m.findingDetails || len(m.certificates) != 3 || len(m.matchLines) < 2 {
+		t.Fatalf("finding in the details: %d certificates, %d lines", len(m.certificates), len(m.matchLines))
+	}
+	if m.viewport.YOffset() != min(m.matchLines[0], max(0, m.viewport.TotalLineCount()-m.viewport.Height())) {
+		t.Errorf("the details did not scroll to the first match")
+	}
+	if !strings.Contains(ansi.Strip(m.renderStatusBar()), fmt.Sprintf("%d lines", len(m.matchLines))) {
+		t.Errorf("status bar: %s", ansi.Strip(m.renderStatusBar()))
+	}
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+
+	selected := m.list.Index()
+	for range m.matchLines {
+		m = pump(t, m, keyPress('n'))
+	}
+	if m.list.Index() != selected || m.matchIndex != 0 {
+		t.Errorf("n should wrap round within the details, at match %d of certificate %d", m.matchIndex, m.list.Index())
+	}
+
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if m.findQuery != "" || len(m.matchLines) != 0 {
+		t.Errorf("esc left the find %q in force", m.findQuery)
+	}
+}
+
+func TestHighlightRunesKeepsText(t *testing.T) {
+	styles := NewStyles(&loadTestConfig(t).Theme)
+	in := styles.DetailValue.Render("www.example.com")
+	out := highlightRunes(in, searchPositions("example", "www.example.com", true), styles.SearchMatch)
+	if out == in || ansi.Strip(out) != "www.example.com" {
+		t.Errorf("highlightRunes(%q) = %q", in, out)
+	}
+}
+
+func TestFullscreenDetails(t *testing.T) {
+	m := *NewModel(createTestCertificates(2), loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	m.viewMode = ViewNormal
+	splitWidth := m.viewport.Width()
+
+	m = pump(t, m, keyPress('z'))
+	if !m.fullscreen || m.focus != FocusRight || m.viewport.Width() <= splitWidth {
+		t.Fatalf("z should widen the details: fullscreen=%v focus=%v width=%d", m.fullscreen, m.focus, m.viewport.Width())
+	}
+	view := m.View().Content
+	if strings.Contains(view, "SUBJECT") {
+		t.Error("the list should be hidden while the details are full screen")
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > 100 {
+			t.Fatalf("line %d cells wide overflows the screen", w)
+		}
+	}
+
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if m.fullscreen || m.viewport.Width() != splitWidth || m.focus != FocusLeft {
+		t.Errorf("esc should restore the split: fullscreen=%v width=%d", m.fullscreen, m.viewport.Width())
+	}
+
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if !m.fullscreen {
+		t.Error("enter on the list should open the details full screen")
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	m := *NewModel(createTestCertificates(4), loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	m = pumpKeys(t, m, 'j', 'j', 'm', 'a')
+	target := m.certificates[2]
+	if m.bookmarks["a"] != target {
+		t.Fatalf("ma did not bookmark the selected certificate: %v", m.bookmarks)
+	}
+	if !strings.Contains(m.View().Content, "'a ") {
+		t.Error("the bookmark letter is not shown in the list")
+	}
+
+	// The jump brings the certificate back even from behind a search.
+	m = runCommand(t, m, "search no-such-certificate")
+	m = pumpKeys(t, m, '\'', 'a')
+	if m.filterActive || m.certificates[m.list.Index()] != target {
+		t.Errorf("'a did not select the bookmarked certificate, filterActive=%v", m.filterActive)
+	}
+	m = pumpKeys(t, m, '\'', 'b')
+	if m.commandError != "no bookmark 'b" {
+		t.Errorf("commandError = %q", m.commandError)
+	}
+
+	m = pumpKeys(t, m, 'k', 'k')
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeySpace}))
+	m = runCommand(t, m, "filter marked")
+	if len(m.certificates) != 2 || !slices.Contains(m.certificates, target) {
+		t.Errorf("filter marked kept %d certificates, want the starred and the bookmarked one", len(m.certificates))
+	}
+
+	m = runCommand(t, m, "marks")
+	if m.popupType != PopupAlert || !strings.Contains(m.popupMessage, "'a  ") || !strings.Contains(m.popupMessage, "1 starred") {
+		t.Errorf(":marks popup = %q", m.popupMessage)
+	}
+}
+
+// TestPendingKeysTakeQ checks that q finishing a g, m or ' is the letter it
+// waits for rather than the quit key.
+func TestPendingKeysTakeQ(t *testing.T) {
+	m := *NewModel(createTestCertificates(3), loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	press := func(r rune) {
+		t.Helper()
+		next, cmd := m.Update(keyPress(r))
+		m = next.(Model)
+		if cmd != nil {
+			if _, ok := cmd().(tea.QuitMsg); ok {
+				t.Fatalf("%q quit", r)
+			}
+		}
+	}
+
+	press('j')
+	press('m')
+	press('q')
+	target := m.certificates[1]
+	if m.bookmarks["q"] != target {
+		t.Fatalf("mq did not bookmark the selected certificate: %v", m.bookmarks)
+	}
+
+	press('k')
+	press('\'')
+	press('q')
+	if m.certificates[m.list.Index()] != target {
+		t.Error("'q did not select the bookmarked certificate")
+	}
+
+	press('g')
+	press('q')
+
+	if _, cmd := m.Update(keyPress('q')); cmd == nil {
+		t.Error("q on its own did not quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("q on its own did not quit")
+	}
+}
+
+func TestRefreshCountsDownExpiry(t *testing.T) {
+	certs := createTestCertificates(1)
+	certs[0].Certificate.NotAfter = time.Now().Add(200 * time.Millisecond)
+	m := *NewModel(certs, loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	m = m.showTab("Validity")
+	if strings.Contains(m.viewport.View(), "EXPIRED") {
+		t.Fatal("certificate shown as expired before its time")
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	gen := m.chainGen
+	next, cmd := m.Update(refreshMsg(time.Now()))
+	m = next.(Model)
+	if !strings.Contains(m.viewport.View(), "EXPIRED") {
+		t.Errorf("certificate did not flip to EXPIRED on refresh:\n%s", m.viewport.View())
+	}
+	if m.chainGen == gen || cmd == nil {
+		t.Error("an expiry on screen did not check the chain again")
+	}
+
+	gen = m.chainGen
+	next, _ = m.Update(refreshMsg(time.Now()))
+	if next.(Model).chainGen != gen {
+		t.Error("a refresh with nothing newly expired checked the chain again")
+	}
+}
+
+func TestHelpViewScrollsAndSearches(t *testing.T) {
+	m := *NewModel(createTestCertificates(2), loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 80, Height: 16})
+	m.viewMode = ViewNormal
+
+	m = pump(t, m, keyPress('?'))
+	if m.viewMode != ViewHelp {
+		t.Fatalf("? opened %v, want the help view", m.viewMode)
+	}
+	if !strings.Contains(m.View().Content, "move through the certificates") {
+		t.Error("help opened from the list does not lead with the list's keys")
+	}
+
+	// Too long for 16 rows, so it scrolls rather than being cut off.
+	m = pump(t, m, keyPress('G'))
+	if m.helpViewport.YOffset() == 0 || !strings.Contains(m.View().Content, "quit") {
+		t.Errorf("G did not scroll to the end of the help:\n%s", m.View().Content)
+	}
+
+	m = pump(t, m, keyPress('/'))
+	for _, r := range "bookmark" {
+		m = pump(t, m, keyPress(r))
+	}
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	view := ansi.Strip(m.View().Content)
+	if !strings.Contains(view, "go to bookmark") || strings.Contains(view, "diff marked") {
+		t.Errorf("help search for bookmark:\n%s", view)
+	}
+
+	// The first esc drops the search, the second closes help.
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if m.viewMode != ViewHelp || !strings.Contains(m.View().Content, "Navigation") {
+		t.Error("esc did not clear the help search")
+	}
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if m.viewMode != ViewNormal {
+		t.Errorf("second esc left %v, want the normal view", m.viewMode)
+	}
+
+	// Opened from the details pane, help leads with the details' keys.
+	m.focus = FocusRight
+	m = pump(t, m, keyPress('?'))
+	if !strings.Contains(m.View().Content, "scroll the details") {
+		t.Error("help opened from the details does not lead with their keys")
+	}
+}
+
+func TestThemeSwitching(t *testing.T) {
+	cfg := loadTestConfig(t)
+	light := cfg.Theme
+	light.Highlight = "#1e66f5"
+	cfg.Themes = map[string]config.Theme{config.DefaultThemeName: cfg.Theme, "light": light}
+	cfg.File = filepath.Join(t.TempDir(), ".y509.yaml")
+	if err := os.WriteFile(cfg.File, []byte("# mine\nexpiry_warning_days: 60\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m := *NewModel(createTestCertificates(2), cfg)
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	// Stepping through the candidates previews each one...
+	m = pump(t, m, keyPress(':'))
+	m = pumpKeys(t, m, []rune("theme li")...)
+	if m.Config.ThemeName != config.DefaultThemeName {
+		t.Errorf("half a theme name switched to %q", m.Config.ThemeName)
+	}
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyTab}))
+	if m.Config.ThemeName != "light" || m.Config.Theme.Highlight != "#1e66f5" {
+		t.Errorf("tab to light did not preview it, theme=%q", m.Config.ThemeName)
+	}
+	// ...and abandoning the line puts the old one back.
+	m = pump(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if m.Config.ThemeName != config.DefaultThemeName {
+		t.Errorf("esc left theme %q, want the default back", m.Config.ThemeName)
+	}
+
+	m = runCommand(t, m, "theme light")
+	if m.Config.ThemeName != "light" || cfg.ThemeName == "light" {
+		t.Errorf("theme=%q, and the shared config should not change (%q)", m.Config.ThemeName, cfg.ThemeName)
+	}
+	m = runCommand(t, m, "theme nope")
+	if !strings.Contains(m.commandError, `no theme "nope"`) {
+		t.Errorf("unknown theme: commandError=%q", m.commandError)
+	}
+
+	m.commandError = ""
+	m = runCommand(t, m, "theme save")
+	if !strings.Contains(lastToast(m), `Theme "light" saved`) {
+		t.Errorf("no toast for the save, got %q", lastToast(m))
+	}
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved := string(data); !strings.Contains(saved, "theme_name: light") || !strings.Contains(saved, "# mine") || !strings.Contains(saved, "expiry_warning_days: 60") {
+		t.Errorf("saved config lost something or missed the theme:\n%s", saved)
+	}
+}
+
+// lastToast is the text of the newest notification, or "" when there is
+// none.
+// TestConfigReload edits the configuration file under a running TUI: the
+// next look at it applies the new colours and keys, a file that no longer
+// parses is reported and changes nothing, and :config reload reads it at once.
+func TestConfigReload(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	path, err := config.DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writes := 0
+	write := func(data string) {
+		t.Helper()
+		writes++
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// A write within the same tick of the clock still has to show.
+		later := time.Now().Add(time.Duration(writes) * time.Second)
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("theme:\n  highlight: \"#111111\"\n")
+
+	m := *NewModel(createTestCertificates(2), loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+	m.Config.NoColor = true // as --no-color would have it
+
+	// Nothing changed: nothing reloaded.
+	m = pump(t, m, configPolledMsg(currentConfigStamp()))
+	if len(m.toasts) != 0 {
+		t.Errorf("an unchanged file was reloaded: %q", lastToast(m))
+	}
+
+	write("theme:\n  highlight: \"#222222\"\nkeys:\n  quit: [x]\ncolumns: [cn]\n")
+	m = pump(t, m, configPolledMsg(currentConfigStamp()))
+	if m.Config.Theme.Highlight != "#222222" {
+		t.Errorf("highlight %q after the edit, want #222222", m.Config.Theme.Highlight)
+	}
+	if !slices.Equal(m.keys.Quit.Keys(), []string{"x"}) || !slices.Equal(m.columns, []string{"cn"}) {
+		t.Errorf("keys %v, columns %v after the edit", m.keys.Quit.Keys(), m.columns)
+	}
+	if !m.Config.NoColor {
+		t.Error("the reload undid --no-color")
+	}
+	if !strings.Contains(lastToast(m), "Configuration reloaded") {
+		t.Errorf("no toast for the reload, got %q", lastToast(m))
+	}
+
+	write("theme: [not, a, mapping\n")
+	m = pump(t, m, configPolledMsg(currentConfigStamp()))
+	if m.Config.Theme.Highlight != "#222222" || !strings.Contains(lastToast(m), "not reloaded") {
+		t.Errorf("a broken file: highlight %q, toast %q", m.Config.Theme.Highlight, lastToast(m))
+	}
+
+	write("theme:\n  highlight: \"#333333\"\n")
+	m = runCommand(t, m, "config reload")
+	if m.Config.Theme.Highlight != "#333333" {
+		t.Errorf(":config reload left highlight %q", m.Config.Theme.Highlight)
+	}
+	if m = runCommand(t, m, "config"); m.commandError != "usage: config reload" {
+		t.Errorf(":config alone: commandError=%q", m.commandError)
+	}
+}
+
+func lastToast(m Model) string {
+	if len(m.toasts) == 0 {
+		return ""
+	}
+	return m.toasts[len(m.toasts)-1].text
+}
+
+// clickOn clicks the first place the text appears on screen, so the test
+// checks the hit testing against what is actually drawn.
+func clickOn(t *testing.T, m Model, text string) Model {
+	t.Helper()
+	for y, line := range strings.Split(ansi.Strip(m.View().Content), "\n") {
+		if i := strings.Index(line, text); i >= 0 {
+			next, _ := m.Update(tea.MouseClickMsg{X: ansi.StringWidth(line[:i]), Y: y, Button: tea.MouseLeft})
+			return next.(Model)
+		}
+	}
+	t.Fatalf("%q is not on screen", text)
+	return m
+}
+
+func TestMouseClicks(t *testing.T) {
+	certs := createTestCertificates(3)
+	certs[0].Source = "/tmp/a.pem"
+	certs[1].Source = "/tmp/b.pem"
+	certs[2].Source = "/tmp/b.pem"
+	m := *NewModel(certs, loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 200, Height: 30})
+	m.viewMode = ViewNormal
+
+	m = clickOn(t, m, "Test Certificate B")
+	if got := m.certificates[m.list.Index()].Certificate.Subject.CommonName; got != "Test Certificate B" {
+		t.Errorf("clicking a row selected %q", got)
+	}
+
+	m = clickOn(t, m, "Issuer")
+	if m.focus != FocusRight || m.tabs[m.activeTab] != "Issuer" {
+		t.Errorf("clicking a detail tab: focus=%v tab=%q", m.focus, m.tabs[m.activeTab])
+	}
+	// On a narrower screen the tabs collapse, and the arrows step through them.
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = clickOn(t, m, "›  3/11")
+	if m.tabs[m.activeTab] != "Validity" {
+		t.Errorf("clicking › moved to %q, want Validity", m.tabs[m.activeTab])
+	}
+
+	m = clickOn(t, m, "SUBJECT")
+	if m.focus != FocusLeft {
+		t.Error("clicking the list did not focus it")
+	}
+
+	m = clickOn(t, m, "b.pem")
+	if m.activeSource != 2 || len(m.certificates) != 2 {
+		t.Errorf("clicking a file tab: source=%d, %d listed", m.activeSource, len(m.certificates))
+	}
+
+	// Popups: the hint's buttons act as their keys, and a click outside an
+	// alert dismisses it.
+	m = pump(t, m, keyPress('f'))
+	m = clickOn(t, m, "Esc cancel")
+	if m.viewMode != ViewNormal {
+		t.Error("clicking cancel did not close the filter popup")
+	}
+	m = pump(t, m, keyPress('f'))
+	m = pumpKeys(t, m, []rune("expiring")...)
+	m = clickOn(t, m, "Enter")
+	if !m.filterActive || m.filterType != "expiring" {
+		t.Errorf("clicking confirm did not apply the filter: active=%v type=%q", m.filterActive, m.filterType)
+	}
+
+	m = runCommand(t, m, "theme")
+	next, _ := m.Update(tea.MouseClickMsg{X: 0, Y: 0, Button: tea.MouseLeft})
+	if m = next.(Model); m.viewMode != ViewNormal {
+		t.Error("clicking outside an alert did not dismiss it")
+	}
+}
+
+func TestConfiguredKeys(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.Keys = map[string][]string{
+		"down":     {"t"},
+		"gototab":  {"&", "é", "\""},
+		"NextPane": {"r"},
+		"bogus":    {"z"},
+		"bookmark": {"b"},
+		"zoom":     {"Z"},
+	}
+	m := *NewModel(createTestCertificates(3), cfg)
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	if m = pump(t, m, keyPress('j')); m.list.Index() != 0 {
+		t.Error("j still moves down after down was rebound")
+	}
+	if m = pump(t, m, keyPress('t')); m.list.Index() != 1 {
+		t.Errorf("t did not move down: index %d", m.list.Index())
+	}
+	if m = pump(t, m, keyPress('é')); m.activeTab != 1 || m.focus != FocusRight {
+		t.Errorf("é did not open the second tab: tab %d", m.activeTab)
+	}
+	m.focus = FocusLeft
+	if m = pump(t, m, keyPress('r')); m.focus != FocusRight {
+		t.Error("next_pane did not focus the details")
+	}
+	if m = pumpKeys(t, m, 'b', 'a'); m.bookmarks["a"] != m.certificates[m.list.Index()] {
+		t.Errorf("b a did not set a bookmark: %v", m.bookmarks)
+	}
+	if m = pump(t, m, keyPress('Z')); !m.fullscreen {
+		t.Error("Z did not widen the details")
+	}
+	if m = pump(t, m, keyPress('Z')); m.fullscreen {
+		t.Error("Z again did not leave fullscreen")
+	}
+
+	if _, err := defaultKeyMap().withConfig(cfg.Keys); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("withConfig error = %v, want bogus reported", err)
+	}
+
+	// KeyBindings writes out every action once, rebound or not.
+	bindings, _ := KeyBindings(cfg.Keys)
+	defaults := defaultKeyMap()
+	named := defaults.named()
+	if len(bindings) != len(named)-2 { // less prev_pane and next_pane
+		t.Errorf("KeyBindings lists %d actions, want %d", len(bindings), len(named)-2)
+	}
+	for _, b := range bindings {
+		if b.Action == "down" && !slices.Equal(b.Keys, []string{"t"}) {
+			t.Errorf("down is bound to %v, want [t]", b.Keys)
+		}
+		if b.Action == "quit" && !slices.Equal(b.Keys, []string{"q", "ctrl+c"}) {
+			t.Errorf("quit is bound to %v, want its defaults", b.Keys)
+		}
+	}
+	m = m.openHelp()
+	content := ansi.Strip(m.helpViewport.GetContent())
+	if !slices.ContainsFunc(strings.Split(content, "\n"), func(line string) bool {
+		return slices.Equal(strings.Fields(line), []string{"t", "down"})
+	}) {
+		t.Errorf("help does not show down on t:\n%s", content)
+	}
+}
+
+func TestCountPrefixes(t *testing.T) {
+	m := *NewModel(createTestCertificates(10), loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	type want struct{ index, tab int }
+	steps := []struct {
+		keys  string
+		want  want
+		focus Focus
+	}{
+		{"3j", want{3, 0}, FocusLeft},
+		{"2k", want{1, 0}, FocusLeft},
+		{"12G", want{9, 0}, FocusLeft},
+		{"gg", want{0, 0}, FocusLeft},
+		{"4G", want{3, 0}, FocusLeft},
+		{"G", want{9, 0}, FocusLeft},
+		{"3gt", want{9, 2}, FocusRight},
+		{"2gT", want{9, 0}, FocusRight},
+		{"h", want{9, 0}, FocusLeft},
+		// A digit alone still jumps to its tab.
+		{"4", want{9, 3}, FocusRight},
+	}
+	for _, step := range steps {
+		m = pumpKeys(t, m, []rune(step.keys)...)
+		if got := (want{m.list.Index(), m.activeTab}); got != step.want || m.focus != step.focus {
+			t.Errorf("after %s: index %d tab %d focus %v, want %+v focus %v", step.keys, got.index, got.tab, m.focus, step.want, step.focus)
+		}
+		if m.count != 0 && step.keys != "4" {
+			t.Errorf("after %s: count %d left pending", step.keys, m.count)
+		}
+	}
+
+	m = pumpKeys(t, m, 'h', '1', '2')
+	if !strings.Contains(ansi.Strip(m.renderStatusBar()), " 12 ") {
+		t.Error("the count being typed is not shown")
+	}
+}
+
+func TestSelectCommand(t *testing.T) {
+	cfg := loadTestConfig(t)
+	m := *NewModel(createTestCertificates(3), cfg)
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.viewMode = ViewNormal
+
+	m = runCommand(t, m, "select 3")
+	if m.list.Index() != 2 {
+		t.Errorf("select 3 left the selection at %d", m.list.Index()+1)
+	}
+	m = runCommand(t, m, "select all")
+	if len(m.selection()) != 3 {
+		t.Errorf("select all marked %d of 3", len(m.marked))
+	}
+	m = runCommand(t, m, "select none")
+	if len(m.marked) != 0 {
+		t.Errorf("select none left %d marked", len(m.marked))
+	}
+	m = runCommand(t, m, "select 4")
+	if m.commandError == "" {
+		t.Error("select 4 of 3 was not refused")
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	cfg := loadTestConfig(t)
+	certs := createTestCertificates(3)
+	dir := t.TempDir()
+
+	var out strings.Builder
+	err := RunBatch(certs, cfg, []string{
+		"search Certificate B",
+		"back",
+		"select all",
+		"export der " + dir + "/",
+		"select none",
+		"select 2",
+		"copy serial",
+		"validity",
+	}, &out)
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+	for _, want := range []string{
+		">1   Test Certificate B",
+		"1*  Test Certificate A",
+		"Exported 3 certificates to " + dir + "/",
+		">2   Test Certificate B",
+		"\n2\n",
+		"Not After",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	for i, info := range certs {
+		name := filepath.Join(dir, certificate.FileName(info.Certificate, i+1, "der"))
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("export did not write %s: %v", name, err)
+		}
+	}
+
+	// Overwriting is never done without asking, and there is no one to ask.
+	err = RunBatch(certs, cfg, []string{"select all", "export der " + dir + "/"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "nothing was written") {
+		t.Errorf("an export over existing files gave %v", err)
+	}
+	err = RunBatch(certs, cfg, []string{"filter nonsense", "copy serial"}, io.Discard)
+	if err == nil || !strings.HasPrefix(err.Error(), "filter nonsense: ") {
+		t.Errorf("a failing command gave %v", err)
+	}
+	if err := RunBatch(certs, cfg, []string{"theme"}, io.Discard); err == nil {
+		t.Error("theme was run without a TUI")
+	}
+}
+
+func TestStartupCommands(t *testing.T) {
+	cfg := loadTestConfig(t)
+	model := NewModel(createTestCertificates(3), cfg)
+	model.SetStartupCommands([]string{"select 2", "validity", "select 9", "select 3"})
+	m := pump(t, *model, tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	if m.viewMode != ViewNormal {
+		t.Errorf("the splash is still up, viewMode=%v", m.viewMode)
+	}
+	if m.list.Index() != 1 || m.tabs[m.activeTab] != "Validity" {
+		t.Errorf("the commands left certificate %d on %s", m.list.Index()+1, m.tabs[m.activeTab])
+	}
+	if !strings.HasPrefix(m.commandError, "select 9: ") {
+		t.Errorf("the failed command was not named: %q", m.commandError)
+	}
+}
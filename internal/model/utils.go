@@ -1,7 +1,11 @@
 // Package model provides the core TUI application logic and view.
 package model
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
 
 // getMinimumSize returns the minimum required width and height for the TUI
 func getMinimumSize() (int, int) {
@@ -24,3 +28,13 @@ func truncateText(text string, width int) string {
 	}
 	return string(r[:width-3]) + "..."
 }
+
+// truncateLines cuts every line of text to width cells, with an ellipsis
+// where one is cut. Styling is kept, and counted as taking no room.
+func truncateLines(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "…")
+	}
+	return strings.Join(lines, "\n")
+}
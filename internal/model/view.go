@@ -1,21 +1,34 @@
 package model
 
 import (
+	"crypto/x509"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"charm.land/lipgloss/v2/table"
+	"github.com/kanywst/y509/internal/logger"
 	"github.com/kanywst/y509/pkg/certificate"
+	"go.uber.org/zap"
 )
 
 // View renders the model
 func (m Model) View() tea.View {
-	v := tea.NewView(m.viewContent())
+	content := m.viewContent()
+	if m.Config.ASCII {
+		content = toASCII(content)
+	}
+	v := tea.NewView(content)
 	v.AltScreen = true
 	v.MouseMode = tea.MouseModeCellMotion
+	// A screen reader follows the terminal cursor, so it is left on the
+	// announcement in the status bar.
+	if m.Config.ScreenReader && m.ready && m.viewMode == ViewNormal {
+		v.Cursor = tea.NewCursor(0, max(0, m.height-1))
+	}
 	return v
 }
 
@@ -36,7 +49,13 @@ func (m Model) viewContent() string {
 		return m.renderHelpView()
 	case ViewPopup:
 		return m.renderPopup()
+	case ViewDiff:
+		return m.renderDiffView()
+	case ViewPipe:
+		return m.renderPipeView()
 	default:
+		// ViewCommand and ViewSearch are the normal view with the command or
+		// search line standing in for the status bar.
 		return m.renderNormalView()
 	}
 }
@@ -53,6 +72,15 @@ func (m Model) renderNormalView() string {
 
 	panes := m.renderTwoPanes(panesHeight)
 	mainContent := lipgloss.NewStyle().Height(panesHeight).Render(panes)
+	mainContent = m.overlayToasts(mainContent)
+
+	// The completion menu takes the line above the command bar, over the
+	// bottom of the panes, as vim's wildmenu takes the status line.
+	if menu := m.renderCompletionMenu(); menu != "" {
+		lines := strings.Split(mainContent, "\n")
+		lines[len(lines)-1] = menu
+		mainContent = strings.Join(lines, "\n")
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, header, mainContent, statusBar)
 }
@@ -72,6 +100,9 @@ func (m Model) renderEmptyState() string {
 			term, matched = "search", m.searchQuery
 		}
 		msg = fmt.Sprintf("Nothing matches %q\n\nPress Esc to clear the %s", matched, term)
+		if len(m.filters) > 1 {
+			msg = fmt.Sprintf("Nothing matches %s\n\nPress u to undo %s, or Esc to clear them all", m.filterType, m.filters[len(m.filters)-1])
+		}
 	}
 
 	body := lipgloss.NewStyle().
@@ -120,8 +151,10 @@ func (m Model) renderHeader() string {
 // passed in from renderNormalView so the header and status bar don't get
 // rendered again just to measure them.
 func (m Model) renderTwoPanes(paneHeight int) string {
-	leftPaneWidth := m.width * 2 / 5
-	rightPaneWidth := m.width - leftPaneWidth
+	leftPaneWidth, rightPaneWidth := m.paneWidths()
+	if m.fullscreen {
+		return m.renderRightPane(rightPaneWidth, paneHeight)
+	}
 
 	leftPane := m.renderLeftPane(leftPaneWidth, paneHeight)
 	rightPane := m.renderRightPane(rightPaneWidth, paneHeight)
@@ -140,20 +173,17 @@ func (m Model) renderLeftPane(width, height int) string {
 	paneStyle = paneStyle.BorderRight(false).Width(width).Height(height)
 
 	innerWidth := width - PaneSideBorderWidth
-	statusWidth := 4
-	expiresWidth := 14
-	subjectWidth := innerWidth - statusWidth - expiresWidth
-	if subjectWidth < 10 {
-		subjectWidth = 10
+	var titles []string
+	for _, col := range layoutColumns(m.columns, innerWidth) {
+		titles = append(titles, m.Styles.Dimmed.Bold(true).Width(col.width).Render(truncateText(col.title, col.width-1)))
 	}
+	header := lipgloss.JoinHorizontal(lipgloss.Left, titles...)
 
-	header := lipgloss.JoinHorizontal(lipgloss.Left,
-		m.Styles.Dimmed.Bold(true).Width(statusWidth).Render("  "),
-		m.Styles.Dimmed.Bold(true).Width(subjectWidth).Render("SUBJECT"),
-		m.Styles.Dimmed.Bold(true).Width(expiresWidth).Render("EXPIRES"),
-	)
-
-	body := lipgloss.JoinVertical(lipgloss.Left, header, m.list.View())
+	rows := []string{header, m.list.View()}
+	if len(m.sources) > 0 {
+		rows = append([]string{m.renderSourceTabs(innerWidth)}, rows...)
+	}
+	body := lipgloss.JoinVertical(lipgloss.Left, rows...)
 	return paneStyle.Render(body)
 }
 
@@ -216,9 +246,13 @@ func (m Model) renderRightPane(width, height int) string {
 	const horizontalPadding = 2
 	const verticalPadding = 1
 
+	content := m.viewport.View()
+	if m.focus == FocusRight {
+		content = m.highlightDetailCursor(content)
+	}
 	paddedContent := lipgloss.NewStyle().
 		Padding(verticalPadding, horizontalPadding).
-		Render(m.viewport.View())
+		Render(content)
 	// Inner width is the pane width minus its two border columns; the footer
 	// then loses the same horizontal padding as the content above it.
 	footer := m.renderScrollFooter(width - PaneBorderWidth - 2*horizontalPadding)
@@ -232,7 +266,10 @@ func (m Model) renderRightPane(width, height int) string {
 	// The left pane draws no right border, so the right pane's left edge is
 	// the shared divider. Use T-junctions where it meets the top and bottom
 	// rules instead of a second rounded corner butting against the left one.
-	return paneStyle.Border(seamBorder).Width(width).Height(height).Render(paneContent)
+	if !m.fullscreen {
+		paneStyle = paneStyle.Border(seamBorder)
+	}
+	return paneStyle.Width(width).Height(height).Render(paneContent)
 }
 
 // seamBorder is a rounded border whose left corners are T-junctions, used by
@@ -366,12 +403,16 @@ func (m Model) renderTabContent(width int) string {
 	}
 
 	// kv renders an aligned key/value row. Long values wrap inside the value
-	// column instead of spilling back to the left margin. An empty key gives
-	// a blank column so continuation/section lines still align with values.
+	// column instead of spilling back to the left margin, or after :wrap off
+	// are cut at its edge. An empty key gives a blank column so
+	// continuation/section lines still align with values.
 	kv := func(key, value string) {
 		if value == "" {
 			return
 		}
+		if m.noWrap {
+			value = truncateLines(value, valueWidth)
+		}
 		keyCell := m.Styles.DetailKey.Width(keyWidth).Render(key)
 		valueCell := m.Styles.DetailValue.Width(valueWidth).Render(value)
 		row := lipgloss.JoinHorizontal(lipgloss.Top, keyCell, valueCell)
@@ -393,7 +434,34 @@ func (m Model) renderTabContent(width int) string {
 		}
 	}
 
+	// With several files loaded, every tab starts with where the certificate
+	// came from, so the one to fix is known.
+	if len(m.sources) > 0 {
+		name := cert.Source
+		if name == "" {
+			name = sourceLabel(name)
+		}
+		b.WriteString(m.Styles.Dimmed.Width(width).Render("From "+origin(name, cert)) + "\n\n")
+	}
+
+	// A certificate that failed the chain check says why at the top of every
+	// tab, not only in the :validate report.
+	if chainFailed(cert) {
+		b.WriteString(m.Styles.BadgeExpired.Width(width).Render("⚠ "+cert.ValidationError.Error()) + "\n\n")
+	}
+
 	switch m.tabs[m.activeTab] {
+	case "Overview":
+		kv("Subject", orNone(cert.Certificate.Subject.CommonName))
+		kv("Issuer", orNone(cert.Certificate.Issuer.CommonName))
+		kv("Expires", certificate.FormatDate(cert.Certificate.NotAfter))
+		kv("Key", columnText("key", cert))
+		kv("SANs", summarizeSANs(cert.Certificate))
+		kv("SHA256", groupHex(certificate.FormatFingerprint(cert.Certificate)))
+		kv("Checks", summarizeChecks(m.checksFor(cert)))
+		b.WriteString("\n")
+		b.WriteString(m.renderValidityBadge(cert.Certificate) + "\n")
+
 	case "Subject":
 		kv("CN", cert.Certificate.Subject.CommonName)
 		kv("Organization", strings.Join(cert.Certificate.Subject.Organization, ", "))
@@ -406,25 +474,15 @@ func (m Model) renderTabContent(width int) string {
 		kv("Organization", strings.Join(cert.Certificate.Issuer.Organization, ", "))
 		kv("Country", strings.Join(cert.Certificate.Issuer.Country, ", "))
 	case "Validity":
-		notBefore := cert.Certificate.NotBefore.Format("2006-01-02 15:04:05 MST")
-		notAfter := cert.Certificate.NotAfter.Format("2006-01-02 15:04:05 MST")
+		notBefore := certificate.FormatTime(cert.Certificate.NotBefore)
+		notAfter := certificate.FormatTime(cert.Certificate.NotAfter)
 		kv("Not Before", notBefore)
 		kv("Not After", notAfter)
 		kv("Lifetime", fmt.Sprintf("%d days total", certificate.ValidityPeriodDays(cert.Certificate)))
 
 		// Validity status badge
 		b.WriteString("\n")
-		d := time.Until(cert.Certificate.NotAfter)
-		if d < 0 {
-			b.WriteString(m.Styles.BadgeExpired.Render("  ✖ EXPIRED") + "\n")
-		} else {
-			days := int(d.Hours() / 24)
-			if days <= m.Config.ExpiryWarningDays {
-				b.WriteString(m.Styles.BadgeWarning.Render(fmt.Sprintf("  ▲ %d days left", days)) + "\n")
-			} else {
-				b.WriteString(m.Styles.BadgeValid.Render(fmt.Sprintf("  ● Valid · %d days left", days)) + "\n")
-			}
-		}
+		b.WriteString(m.renderValidityBadge(cert.Certificate) + "\n")
 
 		// Flag subscriber certs that exceed the CA/Browser Forum max lifetime.
 		if certificate.ExceedsCABMaxLifetime(cert.Certificate) {
@@ -472,21 +530,251 @@ func (m Model) renderTabContent(width int) string {
 		if !hasSANs {
 			b.WriteString(m.Styles.Dimmed.Render("  No SANs present"))
 		}
+	case "Key":
+		b.WriteString(m.Styles.SectionTitle.Render("Public Key") + "\n")
+		kvLines(certificate.FormatPublicKey(cert.Certificate))
+
+		b.WriteString("\n")
+		b.WriteString(m.Styles.SectionTitle.Render("Key Usage") + "\n")
+		kv("Usage", orNone(certificate.FormatKeyUsage(cert.Certificate)))
+		kv("Extended", orNone(certificate.FormatExtKeyUsage(cert.Certificate)))
+		for _, finding := range m.usageFindingsFor(cert) {
+			kv("⚠", finding.Detail)
+		}
+
 	case "Misc":
 		kv("Serial", cert.Certificate.SerialNumber.String())
 		kv("SHA256", groupHex(certificate.FormatFingerprint(cert.Certificate)))
 		kv("Sig Algo", cert.Certificate.SignatureAlgorithm.String())
-		b.WriteString("\n")
-		b.WriteString(m.Styles.SectionTitle.Render("Public Key") + "\n")
-		kvLines(certificate.FormatPublicKey(cert.Certificate))
+
+		if policies, err := certificate.ParsePolicies(cert.Certificate); err != nil {
+			logger.Log.Debug("failed to decode certificate policies", zap.Error(err))
+		} else if len(policies) > 0 {
+			b.WriteString("\n")
+			b.WriteString(m.Styles.SectionTitle.Render("Policies") + "\n")
+			if level := certificate.ValidationLevel(cert.Certificate); level != "" {
+				kv("Validation", level)
+			}
+			for _, policy := range policies {
+				kv("Policy", certificate.FormatPolicy(policy))
+				for _, cps := range policy.CPS {
+					kv("CPS", cps)
+				}
+				for _, notice := range policy.UserNotices {
+					kv("Notice", notice)
+				}
+			}
+		}
+
+		if checks := m.sctChecksFor(cert); len(checks) > 0 {
+			b.WriteString("\n")
+			b.WriteString(m.Styles.SectionTitle.Render("Certificate Transparency") + "\n")
+			for i, check := range checks {
+				kv(fmt.Sprintf("SCT %d", i+1), certificate.FormatSCT(check))
+			}
+		}
 
 		// Chain position visualization
 		b.WriteString("\n")
 		b.WriteString(m.Styles.SectionTitle.Render("Chain Position") + "\n")
 		b.WriteString(m.renderChainPosition(cert))
+
+	case "Extensions":
+		extensions := certificate.DecodeExtensions(cert.Certificate)
+		if len(extensions) == 0 {
+			b.WriteString(m.Styles.Dimmed.Render("  No extensions present"))
+		}
+		for i, ext := range extensions {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			name := ext.Name
+			if name == "" {
+				name = "Unknown extension"
+			}
+			header := m.Styles.SectionTitle.Render(name) + m.Styles.Dimmed.Render(" "+ext.OID)
+			if ext.Critical {
+				header += m.Styles.BadgeWarning.Render(" critical")
+			}
+			b.WriteString(header + "\n")
+			kvLines(strings.Join(ext.Values, "\n"))
+		}
+
+	case "Validation":
+		for _, check := range m.checksFor(cert) {
+			kv(m.renderCheckStatus(check.Status)+" "+check.Name, check.Detail)
+		}
+
+	case "Text":
+		b.WriteString(certificate.FormatText(cert.Certificate))
+
+	case "Raw":
+		// The tree comes first: it is where a malformed certificate shows how
+		// far it parsed. Offsets in both panes are the same, so an element can
+		// be found in the dump below.
+		b.WriteString(m.Styles.SectionTitle.Render("ASN.1") + "\n")
+		nodes, err := certificate.ParseASN1(cert.Certificate.Raw)
+		if tree := certificate.FormatASN1(nodes); tree != "" {
+			b.WriteString(tree + "\n")
+		}
+		if err != nil {
+			b.WriteString(m.Styles.BadgeExpired.Render("parse error: "+err.Error()) + "\n")
+		}
+
+		b.WriteString("\n" + m.Styles.SectionTitle.Render(fmt.Sprintf("DER (%d bytes)", len(cert.Certificate.Raw))) + "\n")
+		b.WriteString(certificate.HexDump(cert.Certificate.Raw, hexDumpWidth(width)) + "\n")
+	}
+
+	content := b.String()
+	if m.noWrap {
+		content = truncateLines(content, width)
+	}
+	return lipgloss.NewStyle().Width(width).Render(content)
+}
+
+// renderValidityBadge is the one-line verdict on a certificate's dates:
+// expired, expiring within the warning window, or valid, with the days left.
+func (m Model) renderValidityBadge(cert *x509.Certificate) string {
+	d := time.Until(cert.NotAfter)
+	if d < 0 {
+		return m.Styles.BadgeExpired.Render("  ✖ EXPIRED")
+	}
+	days := int(d.Hours() / 24)
+	if days <= m.Config.ExpiryWarningDays {
+		return m.Styles.BadgeWarning.Render(fmt.Sprintf("  ▲ %d days left", days))
+	}
+	return m.Styles.BadgeValid.Render(fmt.Sprintf("  ● Valid · %d days left", days))
+}
+
+// summarizeSANs names the first few SANs and counts the rest, for the
+// Overview tab; the SANs tab lists them all.
+func summarizeSANs(cert *x509.Certificate) string {
+	var names []string
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	const shown = 3
+	if len(names) <= shown {
+		return orNone(strings.Join(names, ", "))
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:shown], ", "), len(names)-shown)
+}
+
+// summarizeChecks counts the validation checks by outcome, for the Overview
+// tab; the Validation tab has the details.
+func summarizeChecks(checks []certificate.Check) string {
+	var passed, warned, failed int
+	for _, check := range checks {
+		switch check.Status {
+		case certificate.CheckPass:
+			passed++
+		case certificate.CheckWarn:
+			warned++
+		case certificate.CheckFail:
+			failed++
+		}
+	}
+	parts := []string{fmt.Sprintf("%d passed", passed)}
+	if warned > 0 {
+		parts = append(parts, fmt.Sprintf("%d warned", warned))
+	}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", failed))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hexDumpWidth picks how many bytes a hex dump row can show without wrapping:
+// each byte takes four cells, plus the offset and the ASCII bars.
+func hexDumpWidth(width int) int {
+	switch {
+	case width >= 13+4*16:
+		return 16
+	case width >= 13+4*8:
+		return 8
+	default:
+		return 4
+	}
+}
+
+// checksFor runs the per-certificate validation checks, looking for the
+// issuer among everything loaded.
+func (m Model) checksFor(current *certificate.Info) []certificate.Check {
+	certs := make([]*x509.Certificate, 0, len(m.allCertificates))
+	for _, c := range m.allCertificates {
+		certs = append(certs, c.Certificate)
+	}
+	checks := certificate.CheckCertificate(current.Certificate, certs, m.verifyOptions())
+	// A revocation check that has come back replaces the offline one, which
+	// only says where revocation is published.
+	if result, ok := m.revocation[current]; ok {
+		for i, check := range checks {
+			if check.Name == "Revocation" {
+				checks[i] = result.Check()
+			}
+		}
+	}
+	return checks
+}
+
+// renderCheckStatus picks the icon for a check, in the same colors as the
+// validity badges.
+func (m Model) renderCheckStatus(status certificate.CheckStatus) string {
+	switch status {
+	case certificate.CheckPass:
+		return m.Styles.BadgeValid.Render("✔")
+	case certificate.CheckWarn:
+		return m.Styles.BadgeWarning.Render("▲")
+	case certificate.CheckFail:
+		return m.Styles.BadgeExpired.Render("✖")
+	default:
+		return m.Styles.Dimmed.Render("–")
+	}
+}
+
+// usageFindingsFor runs the key usage analysis up the chain from the given
+// certificate, through everything loaded, and keeps the findings about it.
+func (m Model) usageFindingsFor(current *certificate.Info) []certificate.UsageFinding {
+	chain := []*x509.Certificate{current.Certificate}
+	for _, c := range m.allCertificates {
+		if c != current {
+			chain = append(chain, c.Certificate)
+		}
 	}
 
-	return lipgloss.NewStyle().Width(width).Render(b.String())
+	var own []certificate.UsageFinding
+	for _, finding := range certificate.AnalyzeUsage(chain) {
+		if finding.Certificate == current.Certificate {
+			own = append(own, finding)
+		}
+	}
+	return own
+}
+
+// sctChecksFor decodes the certificate's embedded SCTs and verifies them
+// against the configured log list, looking for the issuer among everything
+// loaded.
+func (m Model) sctChecksFor(current *certificate.Info) []certificate.SCTCheck {
+	certs := make([]*x509.Certificate, 0, len(m.allCertificates))
+	for _, c := range m.allCertificates {
+		certs = append(certs, c.Certificate)
+	}
+	checks, err := certificate.CheckSCTs(current.Certificate, certs, m.ctLogs)
+	if err != nil {
+		logger.Log.Debug("failed to decode SCTs", zap.Error(err))
+	}
+	return checks
+}
+
+// orNone stands in for an absent value, so a row that is empty on purpose is
+// not silently dropped by kv.
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
 }
 
 // renderChainPosition shows the certificate chain as a table, marking the
@@ -503,7 +791,7 @@ func (m Model) renderChainPosition(current *certificate.Info) string {
 		}
 		role := "Intermediate"
 		switch {
-		case cert.Certificate.Issuer.String() == cert.Certificate.Subject.String():
+		case certificate.IsSelfSigned(cert.Certificate):
 			role = "Root"
 		case i == 0:
 			role = "Leaf"
@@ -545,6 +833,17 @@ func (m Model) renderChainPosition(current *certificate.Info) string {
 	return t.Render()
 }
 
+// chainFailed reports whether the chain check found a certificate at fault:
+// a signature its issuer's key does not verify, or an issuer that was not
+// allowed to sign it.
+func chainFailed(info *certificate.Info) bool {
+	switch info.ValidationStatus {
+	case certificate.StatusInvalidSignature, certificate.StatusConstraintViolation:
+		return info.ValidationError != nil
+	}
+	return false
+}
+
 func getStatusIconAndStyle(certInfo *certificate.Info, styles Styles, warnDays int) (string, lipgloss.Style) {
 	if certInfo == nil {
 		return "", lipgloss.NewStyle()
@@ -554,7 +853,9 @@ func getStatusIconAndStyle(certInfo *certificate.Info, styles Styles, warnDays i
 		return "▲", styles.StatusWarning
 	case certificate.StatusExpired:
 		return "✖", styles.StatusExpired
-	case certificate.StatusMismatchedIssuer, certificate.StatusInvalidSignature:
+	case certificate.StatusInvalidSignature, certificate.StatusConstraintViolation:
+		return "⚠", styles.StatusExpired
+	case certificate.StatusMismatchedIssuer:
 		return "◆", styles.StatusExpired
 	default:
 		// The status may not have been computed (StatusUnknown/StatusGood),
@@ -573,15 +874,117 @@ func getStatusIconAndStyle(certInfo *certificate.Info, styles Styles, warnDays i
 	}
 }
 
+// renderCompletionMenu renders the command line's completion candidates on
+// one line, the one shown highlighted. When they do not all fit, the line
+// scrolls to keep the highlighted one in view, with ‹ and › marking that
+// there are more.
+func (m Model) renderCompletionMenu() string {
+	if m.viewMode != ViewCommand || len(m.completions) == 0 {
+		return ""
+	}
+	items := make([]string, len(m.completions))
+	for i, c := range m.completions {
+		// Paths show only their last element, as in vim.
+		label := filepath.Base(strings.TrimSuffix(c, string(filepath.Separator)))
+		if strings.HasSuffix(c, string(filepath.Separator)) {
+			label += string(filepath.Separator)
+		}
+		if i == m.completionIndex {
+			items[i] = m.Styles.TabActive.Padding(0, 1).Render(label)
+		} else {
+			items[i] = m.Styles.CommandBar.Padding(0, 1).Render(label)
+		}
+	}
+
+	// Start from the first item, or far enough along that the highlighted
+	// one fits, leaving room for the markers.
+	first := 0
+	fits := func(from, through int) bool {
+		width := 4
+		for _, item := range items[from : through+1] {
+			width += lipgloss.Width(item)
+		}
+		return width <= m.width
+	}
+	for m.completionIndex > first && !fits(first, m.completionIndex) {
+		first++
+	}
+	last := first
+	for last+1 < len(items) && fits(first, last+1) {
+		last++
+	}
+
+	left, right := "  ", "  "
+	if first > 0 {
+		left = "‹ "
+	}
+	if last < len(items)-1 {
+		right = " ›"
+	}
+	row := m.Styles.CommandBar.Render(left) + strings.Join(items[first:last+1], "") + m.Styles.CommandBar.Render(right)
+	return m.Styles.CommandBar.Width(m.width).Render(row)
+}
+
 func (m Model) renderStatusBar() string {
-	// Left section: cert count and filter
+	// The command line and its errors take the whole bar while they are up.
+	if m.viewMode == ViewCommand {
+		input := m.commandInput
+		input.SetWidth(max(1, m.width-2))
+		return m.Styles.CommandBar.Width(m.width).Render(input.View())
+	}
+	if m.viewMode == ViewSearch {
+		input := m.searchInput
+		count := m.Styles.Dimmed.Render(fmt.Sprintf(" %d/%d ", len(m.certificates), len(m.sourceCertificates())))
+		if m.findingDetails {
+			count = m.Styles.Dimmed.Render(fmt.Sprintf(" %d lines ", len(m.matchLines)))
+		}
+		input.SetWidth(max(1, m.width-2-lipgloss.Width(count)))
+		return m.Styles.CommandBar.Width(m.width).Render(
+			lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().Width(m.width-lipgloss.Width(count)).Render(input.View()), count))
+	}
+	if m.Config.ScreenReader {
+		return m.renderAnnouncement()
+	}
+	if m.commandError != "" {
+		return m.Styles.CommandBar.Width(m.width).Render(
+			m.Styles.CommandError.Render(truncateText("✖ "+m.commandError, m.width)))
+	}
+
+	// Left section: cert count, filter, and the validation summary
 	leftParts := []string{
 		m.Styles.StatusBarKey.Render(fmt.Sprintf(" %d certs ", len(m.certificates))),
 	}
 	if m.filterActive {
 		leftParts = append(leftParts, m.Styles.StatusBar.Foreground(lipgloss.Color(m.Config.Theme.StatusWarning)).Render(" ⏚ "+m.filterType+" "))
 	}
+	// A count being typed, as vim shows it, so 12 is not mistaken for 2.
+	if m.count > 0 {
+		leftParts = append(leftParts, m.Styles.StatusBar.Bold(true).Render(fmt.Sprintf(" %d ", m.count)))
+	}
+	if n := len(m.marked); n > 0 {
+		leftParts = append(leftParts, m.Styles.StatusBar.Render(fmt.Sprintf(" • %d marked ", n)))
+	}
+	if n := len(m.hidden); n > 0 {
+		leftParts = append(leftParts, m.Styles.StatusBar.Render(fmt.Sprintf(" %d hidden ", n)))
+	}
+	if m.busy() {
+		leftParts = append(leftParts, m.Styles.StatusBar.Render(" "+m.spinner.View()+" "+m.busyStatus()+" "))
+	}
 	left := lipgloss.JoinHorizontal(lipgloss.Left, leftParts...)
+	// With several files loaded, where the selected certificate came from.
+	var where string
+	if idx := m.list.Index(); len(m.sources) > 0 && idx < len(m.certificates) {
+		cert := m.certificates[idx]
+		where = m.Styles.StatusBar.Render(" " + truncateText(origin(sourceLabel(cert.Source), cert), 40) + " ")
+	}
+	summary := m.summaryParts()
+	dot := m.Styles.StatusBar.Padding(0).Render(" • ")
+	renderSummary := func() string {
+		if len(summary) == 0 {
+			return ""
+		}
+		return m.Styles.StatusBar.Padding(0).Render(" ") + strings.Join(summary, dot) + m.Styles.StatusBar.Padding(0).Render(" ")
+	}
 
 	// Right section: keybinding hints. "? help" is always shown (it reveals
 	// the rest); the others are dropped from the end when the bar is too
@@ -590,11 +993,12 @@ func (m Model) renderStatusBar() string {
 		{"↑↓", "nav"},
 		{"←→", "pane"},
 		{"tab", "tabs"},
-		{"/", "search"},
-		{"f", "filter"},
-		{"v", "validate"},
-		{"e", "export"},
-		{"y", "copy"},
+		{m.keys.Search.Help().Key, "search"},
+		{m.keys.Filter.Help().Key, "filter"},
+		{m.keys.Validate.Help().Key, "validate"},
+		{m.keys.Export.Help().Key, "export"},
+		{m.keys.Yank.Help().Key, "copy"},
+		{m.keys.Command.Help().Key, "command"},
 	}
 	render := func(key, desc string) string {
 		return m.Styles.StatusBar.Bold(true).Render(key) + m.Styles.StatusBar.Render(" "+desc)
@@ -602,26 +1006,39 @@ func (m Model) renderStatusBar() string {
 	sep := m.Styles.StatusBar.Foreground(lipgloss.Color(m.Config.Theme.Border)).Render(" │ ")
 
 	// quit and help are the priority hints; the rest fill whatever space is
-	// left. Optional hints are dropped first, then the priority ones, so the
-	// bar fits on one line at any width.
+	// left. Optional hints are dropped first, then the summary from its
+	// least important end, then where the certificate came from, then the
+	// priority hints, so the bar fits on one line at any width.
+	if m.busy() {
+		hints = append([]struct{ key, desc string }{{"esc", "cancel"}}, hints...)
+	}
 	core := make([]string, 0, len(hints))
 	for _, h := range hints {
 		core = append(core, render(h.key, h.desc))
 	}
-	tail := []string{render("q", "quit"), render("?", "help")}
+	tail := []string{render(m.keys.Quit.Help().Key, "quit"), render(m.keys.Help.Help().Key, "help")}
 
-	leftWidth := lipgloss.Width(left)
 	join := func() string {
 		return strings.Join(append(append([]string{}, core...), tail...), sep)
 	}
-	fits := func() bool { return leftWidth+lipgloss.Width(join()) <= m.width }
+	fits := func() bool {
+		return lipgloss.Width(left)+lipgloss.Width(where)+lipgloss.Width(renderSummary())+lipgloss.Width(join()) <= m.width
+	}
 
 	for len(core) > 0 && !fits() {
 		core = core[:len(core)-1]
 	}
+	for len(summary) > 0 && !fits() {
+		summary = summary[:len(summary)-1]
+	}
+	if !fits() {
+		where = ""
+	}
 	for len(tail) > 0 && !fits() {
 		tail = tail[:len(tail)-1]
 	}
+	left += where + renderSummary()
+	leftWidth := lipgloss.Width(left)
 	right := join()
 
 	// Fill the middle with status bar background. Use an unpadded style so
@@ -634,27 +1051,6 @@ func (m Model) renderStatusBar() string {
 	return left + middle + right
 }
 
-func (m Model) renderHelpView() string {
-	var content strings.Builder
-
-	title := m.Styles.HeaderTitle.Render("🔐 y509 Help")
-	content.WriteString(title + "\n\n")
-
-	help := m.help
-	help.ShowAll = true
-	content.WriteString(help.View(m.keys))
-
-	pane := m.Styles.PopupBorder.
-		Width(56).
-		Render(content.String())
-
-	return lipgloss.NewStyle().
-		Width(m.width).
-		Height(m.height).
-		Align(lipgloss.Center, lipgloss.Center).
-		Render(pane)
-}
-
 // renderMinimumSizeWarning renders a warning message when the terminal is too small
 func (m Model) renderMinimumSizeWarning(minWidth, minHeight int) string {
 	icon := "⚠"
@@ -662,8 +1058,30 @@ func (m Model) renderMinimumSizeWarning(minWidth, minHeight int) string {
 	return m.Styles.Warning.Width(m.width).Height(m.height).Align(lipgloss.Center, lipgloss.Center).Render(msg)
 }
 
-// renderPopup renders the modal popup box
+// Popup hints. The confirm and cancel halves of the input popups' hint are
+// also buttons for the mouse.
+const (
+	popupDismissHint = "Press Enter or Esc to dismiss"
+	popupConfirmHint = "Enter ⏎ confirm"
+	popupHintSep     = "  ·  "
+	popupCancelHint  = "Esc cancel"
+)
+
+// renderPopup renders the modal popup box, centred on the screen.
 func (m Model) renderPopup() string {
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.popupBox())
+}
+
+// popupWidth is the width of the popup box, border included.
+func (m Model) popupWidth() int {
+	if m.width < 64 {
+		return m.width - 4
+	}
+	return 60
+}
+
+// popupBox renders the popup box itself, before it is placed on screen.
+func (m Model) popupBox() string {
 	var content string
 	var title string
 	var icon string
@@ -673,15 +1091,19 @@ func (m Model) renderPopup() string {
 		title = "Result"
 		icon = "◈"
 		content = m.popupMessage
+	case m.popupType == PopupConfirm:
+		title = "Overwrite?"
+		icon = "⚠"
+		content = m.popupMessage
 	case m.popupType == PopupExport && m.exportForm != nil:
 		title = "Export"
+		if n := len(m.marked); n > 0 {
+			title = fmt.Sprintf("Export %d marked", n)
+		}
 		icon = "📤"
 		content = m.exportForm.View()
 	default:
 		switch m.popupType {
-		case PopupSearch:
-			title = "Search"
-			icon = "🔍"
 		case PopupFilter:
 			title = "Filter"
 			icon = "⏚"
@@ -692,10 +1114,7 @@ func (m Model) renderPopup() string {
 		content = m.textInput.View()
 	}
 
-	popupWidth := 60
-	if m.width < 64 {
-		popupWidth = m.width - 4
-	}
+	popupWidth := m.popupWidth()
 	innerWidth := popupWidth - 6
 
 	titleRendered := m.Styles.PopupTitle.Render(icon + "  " + title)
@@ -703,12 +1122,12 @@ func (m Model) renderPopup() string {
 
 	var hint string
 	if m.popupType == PopupAlert {
-		hint = m.Styles.PopupHint.Render("Press Enter or Esc to dismiss")
+		hint = m.Styles.PopupHint.Render(popupDismissHint)
 	} else {
-		hint = m.Styles.PopupHint.Render("Enter ⏎ confirm  ·  Esc cancel")
+		hint = m.Styles.PopupHint.Render(popupConfirmHint + popupHintSep + popupCancelHint)
 	}
 
-	box := m.Styles.PopupBorder.
+	return m.Styles.PopupBorder.
 		Width(popupWidth).
 		Render(
 			lipgloss.JoinVertical(lipgloss.Left,
@@ -720,6 +1139,4 @@ func (m Model) renderPopup() string {
 				lipgloss.NewStyle().Width(innerWidth).Align(lipgloss.Center).Render(hint),
 			),
 		)
-
-	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
 }
@@ -2,11 +2,15 @@ package model
 
 import (
 	"crypto/x509"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
+	"unicode"
 
+	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/kanywst/y509/internal/config"
 	"github.com/kanywst/y509/pkg/certificate"
 )
@@ -55,6 +59,34 @@ func TestStatusIconReflectsExpiringSoon(t *testing.T) {
 	}
 }
 
+// TestChainFailureShownInline checks a certificate the chain check failed
+// is flagged in the list and says why at the top of its details.
+func TestChainFailureShownInline(t *testing.T) {
+	root, rootKey := issueTestCert(t, "Root", true, nil, nil)
+	notCA, notCAKey := issueTestCert(t, "Not a CA", false, root, rootKey)
+	leaf, _ := issueTestCert(t, "leaf.example", false, notCA, notCAKey)
+	certs := []*certificate.Info{{Certificate: leaf}, {Certificate: notCA, Index: 1}, {Certificate: root, Index: 2}}
+
+	mp := NewModel(certs, loadTestConfig(t))
+	mp.width, mp.height, mp.ready = 120, 40, true
+	m := mp.resizeComponents()
+	m = m.selectCertificate(certs[0])
+	if certs[0].ValidationStatus != certificate.StatusConstraintViolation {
+		t.Fatalf("status = %v, want a constraint violation", certs[0].ValidationStatus)
+	}
+
+	if icon, _ := getStatusIconAndStyle(certs[0], m.Styles, 30); icon != "⚠" {
+		t.Errorf("list icon = %q, want ⚠", icon)
+	}
+	details := ansi.Strip(m.renderTabContent(100))
+	if want := "⚠ " + strings.Fields(certs[0].ValidationError.Error())[0]; !strings.HasPrefix(strings.TrimSpace(details), want) {
+		t.Errorf("details do not open with the error:\n%s", details)
+	}
+	if details := ansi.Strip(m.selectCertificate(certs[2]).renderTabContent(100)); strings.Contains(details, "⚠") {
+		t.Errorf("a sound certificate carries a warning:\n%s", details)
+	}
+}
+
 func TestRenderHeader(t *testing.T) {
 	cfg, _ := config.LoadConfig()
 	m := NewModel(createTestCertificates(1), cfg)
@@ -96,9 +128,95 @@ func TestRenderRightPane(t *testing.T) {
 	m := mp.resizeComponents().refreshViewportContent()
 
 	pane := m.renderRightPane(40, 20)
-	// Default tab is Subject
-	if !strings.Contains(pane, "Subject") || !strings.Contains(pane, "CN") {
-		t.Errorf("Right pane missing Subject details")
+	// Default tab is the Overview
+	if !strings.Contains(pane, "Subject") || !strings.Contains(pane, "Expires") {
+		t.Errorf("Right pane missing the overview")
+	}
+}
+
+func TestValidationTabListsChecks(t *testing.T) {
+	cfg, _ := config.LoadConfig()
+	mp := NewModel(createTestCertificates(1), cfg)
+	mp.width, mp.height, mp.ready = 120, 40, true
+	m := mp.resizeComponents().showTab("Validation")
+
+	out := m.renderTabContent(80)
+	for _, want := range []string{"Dates", "Signature", "Trust", "Revocation", "✔"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Validation tab missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExtensionsTabListsEveryExtension(t *testing.T) {
+	cfg, _ := config.LoadConfig()
+	mp := NewModel(createTestCertificates(1), cfg)
+	mp.width, mp.height, mp.ready = 120, 40, true
+	m := mp.resizeComponents().showTab("Extensions")
+
+	out := m.renderTabContent(80)
+	for _, want := range []string{"Key Usage", "2.5.29.15", "critical", "digitalSignature"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Extensions tab missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRawTabShowsTreeAndDump(t *testing.T) {
+	cfg, _ := config.LoadConfig()
+	mp := NewModel(createTestCertificates(1), cfg)
+	mp.width, mp.height, mp.ready = 120, 40, true
+	m, _ := mp.resizeComponents().executeCommand("raw")
+
+	out := m.renderTabContent(100)
+	for _, want := range []string{"ASN.1", "0:d=0", "SEQUENCE", "DER (", "00000000  30 82"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Raw tab missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestWrapToggle checks :wrap off cuts long detail lines at the pane's edge
+// rather than wrapping them, and :wrap on puts the wrapping back.
+func TestWrapToggle(t *testing.T) {
+	certs := createTestCertificates(1)
+	certs[0].Certificate.Subject.OrganizationalUnit = []string{strings.Repeat("a very long organizational unit ", 8)}
+	mp := NewModel(certs, loadTestConfig(t))
+	mp.width, mp.height, mp.ready = 120, 40, true
+	m, _ := mp.resizeComponents().executeCommand("subject")
+
+	wrapped := strings.Count(m.renderTabContent(60), "\n")
+	m, _ = m.executeCommand("wrap off")
+	cut := m.renderTabContent(60)
+	if !m.noWrap || strings.Count(cut, "\n") >= wrapped || !strings.Contains(cut, "…") {
+		t.Errorf("wrap off: %d lines against %d wrapped:\n%s", strings.Count(cut, "\n"), wrapped, cut)
+	}
+	for _, line := range strings.Split(cut, "\n") {
+		if lipgloss.Width(line) > 60 {
+			t.Errorf("line wider than the pane: %q", line)
+		}
+	}
+
+	m, _ = m.executeCommand("wrap")
+	if m.noWrap || strings.Count(m.renderTabContent(60), "\n") != wrapped {
+		t.Error(":wrap alone did not switch wrapping back on")
+	}
+	if m, _ = m.executeCommand("wrap sideways"); m.commandError != "usage: wrap [on|off]" {
+		t.Errorf("commandError = %q", m.commandError)
+	}
+}
+
+func TestTextTabUsesOpenSSLLayout(t *testing.T) {
+	cfg, _ := config.LoadConfig()
+	mp := NewModel(createTestCertificates(1), cfg)
+	mp.width, mp.height, mp.ready = 120, 40, true
+	m, _ := mp.resizeComponents().executeCommand("text")
+
+	out := m.renderTabContent(100)
+	for _, want := range []string{"Certificate:", "Serial Number:", "Subject Public Key Info:", "Signature Value:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Text tab missing %q:\n%s", want, out)
+		}
 	}
 }
 
@@ -109,12 +227,12 @@ func TestRenderPopup(t *testing.T) {
 	m.height = 24
 	m.ready = true
 
-	// Test Search Popup
+	// Test Filter Popup
 	m.viewMode = ViewPopup
-	m.popupType = PopupSearch
+	m.popupType = PopupFilter
 	view := m.View().Content
-	if !strings.Contains(view, "Search") {
-		t.Errorf("Search popup title missing")
+	if !strings.Contains(view, "Filter") {
+		t.Errorf("Filter popup title missing")
 	}
 
 	// Test Alert Popup
@@ -197,3 +315,179 @@ func TestStatusBarShowsQuitAndHelpWhenRoom(t *testing.T) {
 		}
 	}
 }
+
+func TestStatusBarSummary(t *testing.T) {
+	certs := createTestCertificates(3)
+	certs[0].Source = "/tmp/a.pem"
+	certs[1].Source = "/tmp/b.pem"
+	certs[2].Source = "/tmp/b.pem"
+	m := *NewModel(certs, loadTestConfig(t))
+	m.width = 140
+	m.viewMode = ViewNormal
+
+	// The test certificates expire within a day.
+	if bar := m.renderStatusBar(); !strings.Contains(bar, "3 expiring") || !strings.Contains(bar, "chain: checking") {
+		t.Errorf("status bar before the chain check = %q", bar)
+	}
+
+	m = pump(t, m, keyPress(']'))
+	if !m.chainChecked {
+		t.Fatal("switching tab did not check the chain again")
+	}
+	bar := m.renderStatusBar()
+	if !strings.Contains(bar, "1 expiring") || strings.Contains(bar, "checking") {
+		t.Errorf("status bar after the chain check = %q", bar)
+	}
+
+	// A result for a check that has since been superseded is dropped.
+	m = m.handleChainChecked(chainCheckedMsg{gen: m.chainGen - 1, level: certificate.TrustAnchored})
+	if strings.Contains(m.renderStatusBar(), "chain: OK") {
+		t.Error("a stale chain result replaced the latest one")
+	}
+
+	// On a narrow bar the summary gives way before quit and help.
+	m.width = 50
+	if bar := m.renderStatusBar(); !strings.Contains(bar, "quit") || !strings.Contains(bar, "help") {
+		t.Errorf("width 50: summary crowded out quit/help: %q", bar)
+	}
+}
+
+func TestASCIIAndNoColorModes(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.ASCII = true
+	cfg.NoColor = true
+	updated, _ := NewModel(createTestCertificates(3), cfg).Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m := updated.(Model)
+	m.viewMode = ViewNormal
+
+	for _, mode := range []ViewMode{ViewNormal, ViewHelp} {
+		m.viewMode = mode
+		screen := ansi.Strip(m.View().Content)
+		for _, r := range screen {
+			if r > unicode.MaxASCII {
+				t.Errorf("mode %d: %q drawn in ASCII mode", mode, r)
+				break
+			}
+		}
+	}
+
+	// Without colour the selection has to show some other way.
+	if !m.Styles.Highlight.GetReverse() || !m.Styles.SearchMatch.GetReverse() {
+		t.Error("no-color styles do not reverse the selection and matches")
+	}
+
+	// Replacements keep the width the layout was measured with.
+	for _, s := range []string{"⚠️ ✅ ❌ 🔐 📤 ⏳", "● ▲ ✖ ◆ … › │ ╭─╮"} {
+		if got, want := ansi.StringWidth(toASCII(s)), ansi.StringWidth(s); got != want {
+			t.Errorf("toASCII(%q) is %d columns wide, want %d", s, got, want)
+		}
+	}
+}
+
+// TestScreenReaderMode checks the status bar becomes a plain announcement
+// of the selection and of each change, with the cursor left on it, and
+// that toasts are read from it rather than drawn over the panes.
+func TestScreenReaderMode(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.UseScreenReader()
+	if !cfg.ASCII || !cfg.NoColor || !cfg.NoSplash {
+		t.Fatal("screen reader mode does not imply ascii, no_color and no_splash")
+	}
+	m := *NewModel(createTestCertificates(3), cfg)
+	if m.viewMode != ViewNormal {
+		t.Fatalf("view mode = %d, want the certificates without a splash", m.viewMode)
+	}
+	m = pump(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	bar := ansi.Strip(m.renderStatusBar())
+	if want := "Certificate 1 of 3: Test Certificate A, expires today"; !strings.Contains(bar, want) {
+		t.Errorf("status bar = %q, want it to say %q", bar, want)
+	}
+	v := m.View()
+	if v.Cursor == nil || v.Cursor.Y != 39 {
+		t.Errorf("cursor = %+v, want it on the status bar", v.Cursor)
+	}
+
+	m = pumpKeys(t, m, 'j')
+	if bar := ansi.Strip(m.renderStatusBar()); !strings.Contains(bar, "Certificate 2 of 3") {
+		t.Errorf("after j the status bar = %q, want the second certificate announced", bar)
+	}
+
+	m, _ = m.notify("Copied PEM")
+	if bar := ansi.Strip(m.renderStatusBar()); !strings.Contains(bar, "Copied PEM. Certificate 2 of 3") {
+		t.Errorf("status bar = %q, want the toast announced first", bar)
+	}
+	if strings.Contains(m.overlayToasts(strings.Repeat(strings.Repeat(" ", 80)+"\n", 5)), "Copied") {
+		t.Error("toast drawn over the panes in screen reader mode")
+	}
+
+	m.commandError = "no filter to undo"
+	if bar := ansi.Strip(m.renderStatusBar()); !strings.HasPrefix(strings.TrimSpace(bar), "Error: no filter to undo.") {
+		t.Errorf("status bar = %q, want the error announced first", bar)
+	}
+}
+
+// TestToastsShowAndExpire checks notifications: drawn over the bottom of the
+// panes, newest lowest, no more than maxToasts at once, and each taken down
+// by its own timeout.
+func TestToastsShowAndExpire(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.ToastTimeout = 10 * time.Millisecond
+	m := *NewModel(createTestCertificates(2), cfg)
+	m.SetDimensions(120, 30)
+	m.viewMode = ViewNormal
+	m.ready = true
+
+	var first tea.Cmd
+	m, first = m.notify("Exported to %s", "./leaf.pem")
+	m, _ = m.notify("Copied serial number")
+	lines := strings.Split(ansi.Strip(m.View().Content), "\n")
+	bottom := len(lines) - statusBarHeight - 1 // the panes' bottom border
+	if !strings.Contains(lines[bottom-1], "Copied serial number") || !strings.Contains(lines[bottom-2], "Exported to ./leaf.pem") {
+		t.Errorf("toasts not drawn above the bottom border, newest lowest:\n%s", strings.Join(lines[bottom-3:], "\n"))
+	}
+	if !strings.HasSuffix(lines[bottom-1], "│") {
+		t.Errorf("a toast covered the pane border: %q", lines[bottom-1])
+	}
+
+	m = pump(t, m, first())
+	if len(m.toasts) != 1 || lastToast(m) != "Copied serial number" {
+		t.Errorf("the first timeout took down the wrong toast: %v", m.toasts)
+	}
+
+	for i := range maxToasts + 2 {
+		m, _ = m.notify("toast %d", i)
+	}
+	if len(m.toasts) != maxToasts || lastToast(m) != fmt.Sprintf("toast %d", maxToasts+1) {
+		t.Errorf("got %d toasts, newest %q", len(m.toasts), lastToast(m))
+	}
+}
+
+func TestSourceShownPerCertificate(t *testing.T) {
+	certs := createTestCertificates(3)
+	for i, c := range certs {
+		c.Source, c.Index, c.Line = "/tmp/a.pem", i, 1+i*20
+	}
+	certs[2].Source, certs[2].Index, certs[2].Line = "/tmp/b.pem", 0, 4
+	m := *NewModel(certs, loadTestConfig(t))
+	m = pump(t, m, tea.WindowSizeMsg{Width: 160, Height: 40})
+	m.viewMode = ViewNormal
+
+	selected := m.certificates[m.list.Index()]
+	want := origin(sourceLabel(selected.Source), selected)
+	if bar := ansi.Strip(m.renderStatusBar()); !strings.Contains(bar, want) {
+		t.Errorf("status bar %q does not say %q", bar, want)
+	}
+	if details := ansi.Strip(m.renderTabContent(80)); !strings.Contains(details, "From "+origin(selected.Source, selected)) {
+		t.Errorf("details do not start with the certificate's origin:\n%s", details)
+	}
+	if got := origin("b.pem", certs[2]); got != "b.pem:4, certificate 1" {
+		t.Errorf("origin = %q", got)
+	}
+
+	// With one file, there is nothing to tell apart.
+	m = *NewModel(certs[:2], loadTestConfig(t))
+	if details := ansi.Strip(m.renderTabContent(80)); strings.Contains(details, "From ") {
+		t.Error("a single file's certificates name it")
+	}
+}
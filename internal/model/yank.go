@@ -0,0 +1,71 @@
+package model
+
+import (
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The details pane has a line cursor while it has the focus, for y to copy
+// from. detailRow is its row within the viewport: scrolling carries it
+// along, and it only moves within the view once the view can scroll no
+// further, so j and k still scroll the details as they always have.
+
+// detailCursor is the line of the details the cursor is on.
+func (m Model) detailCursor() int {
+	total := m.viewport.TotalLineCount()
+	if total == 0 {
+		return 0
+	}
+	row := min(m.detailRow, max(0, m.viewport.Height()-1))
+	return min(m.viewport.YOffset()+row, total-1)
+}
+
+// moveDetailCursor moves the details cursor down (step 1) or up (step -1)
+// one line, scrolling while the view can scroll.
+func (m Model) moveDetailCursor(step int) Model {
+	row := min(m.detailRow, max(0, m.viewport.Height()-1))
+	switch {
+	case step > 0 && !m.viewport.AtBottom():
+		m.viewport.ScrollDown(1)
+	case step > 0:
+		if m.detailCursor() < m.viewport.TotalLineCount()-1 {
+			row++
+		}
+	case row > 0:
+		row--
+	default:
+		m.viewport.ScrollUp(1)
+	}
+	m.detailRow = row
+	return m
+}
+
+// highlightDetailCursor marks the cursor's row in the rendered viewport.
+// The row is drawn plain, so the highlight spans it evenly.
+func (m Model) highlightDetailCursor(view string) string {
+	lines := strings.Split(view, "\n")
+	row := m.detailCursor() - m.viewport.YOffset()
+	if row < 0 || row >= len(lines) {
+		return view
+	}
+	lines[row] = m.Styles.HighlightDim.Width(m.viewport.Width()).Render(ansi.Strip(lines[row]))
+	return strings.Join(lines, "\n")
+}
+
+// yankDetailLine copies the line under the details cursor.
+func (m Model) yankDetailLine() (Model, tea.Cmd) {
+	lines := strings.Split(m.viewport.GetContent(), "\n")
+	cursor := m.detailCursor()
+	if cursor >= len(lines) {
+		return m, nil
+	}
+	line := strings.TrimSpace(ansi.Strip(lines[cursor]))
+	if line == "" {
+		m.commandError = "nothing to copy on this line"
+		return m, nil
+	}
+	m, toastCmd := m.notify("Copied %q", truncateText(line, 40))
+	return m, tea.Batch(copyToClipboard(line), toastCmd)
+}
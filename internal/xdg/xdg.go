@@ -0,0 +1,36 @@
+// Package xdg finds y509's directories as the XDG Base Directory
+// Specification places them, on every platform alike.
+package xdg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigDir is where y509's configuration goes: y509 in XDG_CONFIG_HOME,
+// ~/.config/y509 when that is not set.
+func ConfigDir() (string, error) {
+	return dir("XDG_CONFIG_HOME", ".config")
+}
+
+// StateDir is where y509 keeps what it writes for itself and that is worth
+// keeping between runs, the log among it: y509 in XDG_STATE_HOME,
+// ~/.local/state/y509 when that is not set.
+func StateDir() (string, error) {
+	return dir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// dir is y509 in the directory the environment variable names, or in
+// fallback under the home directory. The specification has a relative path
+// in the variable ignored, as not set.
+func dir(variable, fallback string) (string, error) {
+	if base := os.Getenv(variable); filepath.IsAbs(base) {
+		return filepath.Join(base, "y509"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("neither %s nor a home directory: %w", variable, err)
+	}
+	return filepath.Join(home, fallback, "y509"), nil
+}
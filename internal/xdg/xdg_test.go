@@ -0,0 +1,30 @@
+package xdg
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "relative/state")
+
+	if got, err := ConfigDir(); err != nil || got != filepath.Join(home, ".config", "y509") {
+		t.Errorf("ConfigDir = %q, %v without XDG_CONFIG_HOME", got, err)
+	}
+	if got, err := StateDir(); err != nil || got != filepath.Join(home, ".local", "state", "y509") {
+		t.Errorf("StateDir = %q, %v with a relative XDG_STATE_HOME", got, err)
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("XDG_STATE_HOME", xdg)
+	if got, _ := ConfigDir(); got != filepath.Join(xdg, "y509") {
+		t.Errorf("ConfigDir = %q, not under XDG_CONFIG_HOME", got)
+	}
+	if got, _ := StateDir(); got != filepath.Join(xdg, "y509") {
+		t.Errorf("StateDir = %q, not under XDG_STATE_HOME", got)
+	}
+}
@@ -0,0 +1,135 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MissingIssuerError means the chain stops at a certificate whose issuer was
+// not supplied. Saying so is far more useful than the verifier's "certificate
+// signed by unknown authority", which reads the same whether the issuer is
+// absent or present and untrusted.
+type MissingIssuerError struct {
+	// Certificate is the last certificate the chain reached.
+	Certificate *x509.Certificate
+	// Issuer is the common name of the certificate that is missing.
+	Issuer string
+	// URLs are the AIA CA-Issuers URLs the missing certificate can be fetched
+	// from, if the certificate carries any.
+	URLs []string
+}
+
+// Error implements error.
+func (e *MissingIssuerError) Error() string {
+	return fmt.Sprintf("issuer '%s' not present in input", e.Issuer)
+}
+
+// missingIssuer reports the issuer the path up from the leaf runs out at,
+// when that is why the verifier failed with err: it found no authority, and
+// no certificate of the input nor any of roots is the issuer's. It is nil
+// when err is anything else -- an expired leaf, a bad signature -- or the
+// path ends at a self-signed certificate.
+func missingIssuer(certs []*x509.Certificate, err error, roots *x509.CertPool) *MissingIssuerError {
+	if len(certs) == 0 || certs[0] == nil {
+		return nil
+	}
+	var unknown x509.UnknownAuthorityError
+	if !errors.As(err, &unknown) {
+		return nil
+	}
+	path := issuerPath(certs[0], certs)
+	top := path[len(path)-1]
+	if IsSelfSigned(top) {
+		return nil
+	}
+	for _, cert := range certs {
+		if cert != nil && cert.Subject.String() == top.Issuer.String() {
+			// Present, just not a signer that verifies. That is a signature
+			// problem, and the verifier's own error says so.
+			return nil
+		}
+	}
+	if roots != nil {
+		// Subjects leaves out the roots of a platform verifier, as on macOS
+		// and Windows; there a root the store has may still be called
+		// missing.
+		for _, subject := range roots.Subjects() { //nolint:staticcheck // see above
+			if bytes.Equal(subject, top.RawIssuer) {
+				return nil
+			}
+		}
+	}
+	return &MissingIssuerError{
+		Certificate: top,
+		Issuer:      nameOrUnknown(top.Issuer.CommonName),
+		URLs:        top.IssuingCertificateURL,
+	}
+}
+
+// maxAIAResponseSize bounds what FetchIssuer will read. An issuer certificate
+// is a few kilobytes; anything near this is not one.
+const maxAIAResponseSize = 1 << 20
+
+// FetchIssuer downloads the certificate at an AIA CA-Issuers URL. CAs publish
+// it as DER, occasionally as PEM, and sometimes as a certs-only PKCS#7 bundle
+// (.p7c); all three are accepted.
+func FetchIssuer(ctx context.Context, url string) ([]*x509.Certificate, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		// ldap:// URLs turn up in enterprise PKIs; there is nothing to do with
+		// them here.
+		return nil, fmt.Errorf("unsupported AIA URL %q: only http and https are fetched", url)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultConnectTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AIA URL %q: %w", url, err)
+	}
+
+	logger.Info("fetching issuer", zap.String("url", url))
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logRequest("AIA request", start, err, zap.String("url", url))
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	logRequest("AIA request", start, nil, zap.String("url", url), zap.Int("status", resp.StatusCode))
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Warn("failed to close AIA response", zap.Error(closeErr))
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAIAResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+
+	if certs, err := parsePKCS7Certificates(data); err == nil {
+		return certs, nil
+	}
+	infos, err := ParseCertificates(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s did not return a certificate: %w", url, err)
+	}
+	certs := make([]*x509.Certificate, len(infos))
+	for i, info := range infos {
+		certs[i] = info.Certificate
+	}
+	return certs, nil
+}
@@ -0,0 +1,149 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// certsOnlyPKCS7 wraps certificates in a degenerate SignedData, the way CAs
+// publish .p7c files.
+func certsOnlyPKCS7(t *testing.T, certs ...*x509.Certificate) []byte {
+	t.Helper()
+
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+	signedData := struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      struct{ ContentType asn1.ObjectIdentifier }
+		Certificates     asn1.RawValue `asn1:"tag:0"`
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+	}
+	signedData.ContentInfo.ContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+
+	inner, err := asn1.Marshal(signedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestFetchIssuer_Formats(t *testing.T) {
+	issuer, _ := issue(t, "Issuing CA", true, nil, nil)
+
+	bodies := map[string][]byte{
+		"/ca.der": issuer.Raw,
+		"/ca.pem": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuer.Raw}),
+		"/ca.p7c": certsOnlyPKCS7(t, issuer),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	for path := range bodies {
+		t.Run(path, func(t *testing.T) {
+			certs, err := FetchIssuer(context.Background(), server.URL+path)
+			if err != nil {
+				t.Fatalf("FetchIssuer: %v", err)
+			}
+			if len(certs) != 1 || !certs[0].Equal(issuer) {
+				t.Errorf("got %d certificates, want the issuer", len(certs))
+			}
+		})
+	}
+
+	if _, err := FetchIssuer(context.Background(), server.URL+"/missing"); err == nil {
+		t.Error("FetchIssuer succeeded on a 404")
+	}
+	if _, err := FetchIssuer(context.Background(), "ldap://ldap.example/cn=CA"); err == nil {
+		t.Error("FetchIssuer accepted an ldap URL")
+	}
+}
+
+// TestVerifyChain_NamesMissingIssuer checks that a chain stopping short of an
+// intermediate names it and passes on where it can be fetched from.
+func TestVerifyChain_NamesMissingIssuer(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Issuing CA", true, root, rootKey)
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+	leaf.IssuingCertificateURL = []string{"http://ca.example/issuing.der"}
+
+	result, err := VerifyChain([]*x509.Certificate{leaf, root}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyChain returned an error: %v", err)
+	}
+
+	var missing *MissingIssuerError
+	if !errors.As(result.Err, &missing) || result.MissingIssuer == nil {
+		t.Fatalf("Err = %v, want a MissingIssuerError", result.Err)
+	}
+	if missing.Issuer != "Issuing CA" {
+		t.Errorf("Issuer = %q, want the intermediate", missing.Issuer)
+	}
+	text := FormatVerifyResult(result)
+	for _, want := range []string{"issuer 'Issuing CA' not present in input", "http://ca.example/issuing.der", "--fetch-missing"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("formatted result does not mention %q:\n%s", want, text)
+		}
+	}
+}
+
+// TestVerifyChain_SuppliedRootIsNotMissing checks that a chain whose root
+// came in through ExtraRoots is not said to be missing it when it fails for
+// another reason: here the leaf has expired, and that is what is reported.
+func TestVerifyChain_SuppliedRootIsNotMissing(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Issuing CA", true, root, rootKey)
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+
+	result, err := VerifyChain([]*x509.Certificate{leaf, intermediate}, VerifyOptions{
+		ExtraRoots:      []*x509.Certificate{root},
+		SkipSystemRoots: true,
+		CurrentTime:     leaf.NotAfter.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("VerifyChain returned an error: %v", err)
+	}
+	if result.Level != TrustBroken {
+		t.Fatalf("Level = %v, want broken", result.Level)
+	}
+	var missing *MissingIssuerError
+	if errors.As(result.Err, &missing) || result.MissingIssuer != nil {
+		t.Errorf("the root was supplied, yet it is reported missing: %v", result.Err)
+	}
+	var invalid x509.CertificateInvalidError
+	if !errors.As(result.Err, &invalid) || invalid.Reason != x509.Expired {
+		t.Errorf("Err = %v, want the verifier's expiry error", result.Err)
+	}
+	if result.Expired != leaf {
+		t.Errorf("Expired = %v, want the leaf", result.Expired)
+	}
+}
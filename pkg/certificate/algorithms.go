@@ -0,0 +1,225 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// AlgorithmRule is one line of an algorithm policy: certificates whose key or
+// signature algorithm matches it are flagged.
+type AlgorithmRule struct {
+	// Match is a glob against the key type ("RSA-2048", "ECDSA-P256",
+	// "Ed25519") or the signature algorithm ("SHA1-RSA", "ECDSA-SHA256"),
+	// e.g. "RSA-*" or "*SHA1*".
+	Match string `yaml:"match"`
+	// MinBits, when set, only flags keys smaller than this many bits.
+	MinBits int `yaml:"min_bits"`
+	// After, when set, only flags certificates still valid after this date:
+	// "no RSA-2048 after 2030" rather than "no RSA-2048".
+	After string `yaml:"after"`
+	// Reason is shown with each finding.
+	Reason string `yaml:"reason"`
+
+	after time.Time
+}
+
+// AlgorithmPolicy is the set of rules a bundle is checked against.
+type AlgorithmPolicy struct {
+	Rules []AlgorithmRule `yaml:"rules"`
+}
+
+// DefaultAlgorithmPolicy flags what is already broken or has a published
+// end date: MD2, MD5 and SHA-1 signatures, RSA keys under 2048 bits, and
+// RSA-2048 past NIST SP 800-131A's 2030 cut-off for 112-bit security.
+func DefaultAlgorithmPolicy() *AlgorithmPolicy {
+	policy := &AlgorithmPolicy{Rules: []AlgorithmRule{
+		{Match: "*MD5*", Reason: "MD5 signatures are broken"},
+		{Match: "*MD2*", Reason: "MD2 signatures are broken"},
+		{Match: "*SHA1*", Reason: "SHA-1 signatures are rejected by modern clients"},
+		{Match: "RSA-*", MinBits: 2048, Reason: "RSA keys under 2048 bits are too weak"},
+		{Match: "RSA-2048", After: "2030-12-31", Reason: "NIST SP 800-131A disallows 112-bit security after 2030"},
+	}}
+	// The defaults are known good.
+	_ = policy.compile()
+	return policy
+}
+
+// ParseAlgorithmPolicy decodes a YAML policy:
+//
+//	rules:
+//	  - match: "RSA-2048"
+//	    after: 2030-01-01
+//	    reason: migrate to RSA-3072 or ECDSA
+//	  - match: "*SHA1*"
+func ParseAlgorithmPolicy(data []byte) (*AlgorithmPolicy, error) {
+	var policy AlgorithmPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("malformed algorithm policy: %w", err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// LoadAlgorithmPolicy reads a YAML policy from a file.
+func LoadAlgorithmPolicy(filename string) (*AlgorithmPolicy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read algorithm policy: %w", err)
+	}
+	return ParseAlgorithmPolicy(data)
+}
+
+// compile checks every rule and parses its date, so a typo is reported when
+// the policy is loaded rather than silently never matching.
+func (p *AlgorithmPolicy) compile() error {
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Match == "" {
+			return fmt.Errorf("algorithm policy rule %d has no match pattern", i+1)
+		}
+		if _, err := path.Match(rule.Match, ""); err != nil {
+			return fmt.Errorf("algorithm policy rule %d: bad pattern %q: %w", i+1, rule.Match, err)
+		}
+		if rule.After != "" {
+			after, err := ParseVerifyTime(rule.After)
+			if err != nil {
+				return fmt.Errorf("algorithm policy rule %d: %w", i+1, err)
+			}
+			rule.after = after
+		}
+	}
+	return nil
+}
+
+// KeyType names a certificate's public key the way policies match it:
+// "RSA-2048", "ECDSA-P256", "Ed25519". The second result is the key size in
+// bits, zero where size is not a meaningful knob.
+func KeyType(cert *x509.Certificate) (string, int) {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		bits := pub.N.BitLen()
+		return fmt.Sprintf("RSA-%d", bits), bits
+	case *ecdsa.PublicKey:
+		name := strings.ReplaceAll(pub.Curve.Params().Name, "-", "")
+		return "ECDSA-" + name, pub.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 0
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0
+	}
+}
+
+// AlgorithmFinding is a certificate that breaks a policy rule.
+type AlgorithmFinding struct {
+	Certificate *x509.Certificate
+	// Algorithm is what matched: the key type or the signature algorithm.
+	Algorithm string
+	Rule      AlgorithmRule
+}
+
+// AlgorithmReport summarizes the algorithms in a bundle.
+type AlgorithmReport struct {
+	// Signatures and Keys count certificates by signature algorithm and by
+	// key type.
+	Signatures map[string]int
+	Keys       map[string]int
+	Findings   []AlgorithmFinding
+}
+
+// ReportAlgorithms tallies the signature algorithms and key types across the
+// certificates and checks each against the policy. A nil policy means
+// DefaultAlgorithmPolicy.
+func ReportAlgorithms(certs []*x509.Certificate, policy *AlgorithmPolicy) *AlgorithmReport {
+	if policy == nil {
+		policy = DefaultAlgorithmPolicy()
+	}
+	report := &AlgorithmReport{Signatures: map[string]int{}, Keys: map[string]int{}}
+
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		signature := cert.SignatureAlgorithm.String()
+		key, bits := KeyType(cert)
+		report.Signatures[signature]++
+		report.Keys[key]++
+
+		for _, rule := range policy.Rules {
+			var matched string
+			switch {
+			case globMatch(rule.Match, key) && (rule.MinBits == 0 || (bits > 0 && bits < rule.MinBits)):
+				matched = key
+			case rule.MinBits == 0 && globMatch(rule.Match, signature) && !IsSelfSigned(cert):
+				// A root's self-signature is never checked, so its hash
+				// does not matter.
+				matched = signature
+			default:
+				continue
+			}
+			if !rule.after.IsZero() && !cert.NotAfter.After(rule.after) {
+				continue
+			}
+			report.Findings = append(report.Findings, AlgorithmFinding{Certificate: cert, Algorithm: matched, Rule: rule})
+		}
+	}
+	return report
+}
+
+func globMatch(pattern, name string) bool {
+	ok, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(name))
+	return ok
+}
+
+// FormatAlgorithmReport renders the report for the terminal: the tallies,
+// most common first, then the policy findings.
+func FormatAlgorithmReport(report *AlgorithmReport) string {
+	var sb strings.Builder
+
+	tally := func(title string, counts map[string]int) {
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		slices.SortFunc(names, func(a, b string) int {
+			if counts[a] != counts[b] {
+				return counts[b] - counts[a]
+			}
+			return strings.Compare(a, b)
+		})
+		fmt.Fprintf(&sb, "%s:\n", title)
+		for _, name := range names {
+			fmt.Fprintf(&sb, "  %-16s %d\n", name, counts[name])
+		}
+	}
+	tally("Signature algorithms", report.Signatures)
+	sb.WriteString("\n")
+	tally("Key types", report.Keys)
+	sb.WriteString("\n")
+
+	if len(report.Findings) == 0 {
+		sb.WriteString("✅ Nothing outside the policy.")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "⚠️  %d finding(s) outside the policy:\n", len(report.Findings))
+	for _, finding := range report.Findings {
+		fmt.Fprintf(&sb, "  • %s: %s (expires %s)", displayName(finding.Certificate), finding.Algorithm,
+			FormatDate(finding.Certificate.NotAfter))
+		if finding.Rule.Reason != "" {
+			fmt.Fprintf(&sb, "\n    %s", finding.Rule.Reason)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
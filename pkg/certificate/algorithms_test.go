@@ -0,0 +1,69 @@
+package certificate
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportAlgorithms(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	longLived := generateCertificate(&x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: "rsa.example"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Date(2031, 6, 1, 0, 0, 0, 0, time.UTC),
+	}, root, &rsaKey.PublicKey, rootKey)
+	leaf, _ := issue(t, "ec.example", false, root, rootKey)
+
+	certs := []*x509.Certificate{longLived, leaf, root}
+	report := ReportAlgorithms(certs, nil)
+	if report.Keys["ECDSA-P256"] != 2 || report.Keys["RSA-2048"] != 1 {
+		t.Errorf("Keys = %v", report.Keys)
+	}
+	if report.Signatures["ECDSA-SHA256"] != 3 {
+		t.Errorf("Signatures = %v", report.Signatures)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Certificate != longLived || report.Findings[0].Algorithm != "RSA-2048" {
+		t.Fatalf("Findings = %+v, want the RSA-2048 certificate valid past 2030", report.Findings)
+	}
+	if got := FormatAlgorithmReport(report); !strings.Contains(got, "rsa.example: RSA-2048") || !strings.Contains(got, "NIST") {
+		t.Errorf("FormatAlgorithmReport =\n%s", got)
+	}
+
+	policy, err := ParseAlgorithmPolicy([]byte(`
+rules:
+  - match: "ecdsa-*"
+    min_bits: 384
+    reason: P-384 or better
+`))
+	if err != nil {
+		t.Fatalf("ParseAlgorithmPolicy: %v", err)
+	}
+	report = ReportAlgorithms(certs, policy)
+	if len(report.Findings) != 2 {
+		t.Errorf("got %d findings, want the two P-256 certificates: %+v", len(report.Findings), report.Findings)
+	}
+}
+
+func TestParseAlgorithmPolicy_Errors(t *testing.T) {
+	for _, doc := range []string{
+		"rules:\n  - reason: no pattern\n",
+		"rules:\n  - match: \"[\"\n",
+		"rules:\n  - match: RSA-2048\n    after: someday\n",
+		"rules: [",
+	} {
+		if _, err := ParseAlgorithmPolicy([]byte(doc)); err == nil {
+			t.Errorf("ParseAlgorithmPolicy(%q) succeeded", doc)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package certificate
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxASN1Depth bounds how deep ParseASN1 descends. A certificate is a dozen
+// levels deep at most; anything past this is malformed or hostile.
+const maxASN1Depth = 32
+
+// ASN1Node is one element of a DER encoding, as openssl asn1parse shows it.
+type ASN1Node struct {
+	// Offset is where the element starts in the encoding.
+	Offset int
+	// Depth is how many constructed elements enclose it.
+	Depth int
+	// HeaderLength and Length are the sizes of its tag-and-length header and
+	// of its contents.
+	HeaderLength int
+	Length       int
+	// Class and Tag identify the element's type; Constructed is set when its
+	// contents are further elements.
+	Class       int
+	Tag         int
+	Constructed bool
+	// Encapsulated is set on an OCTET STRING or BIT STRING whose contents
+	// are themselves DER, as an extension value is. Its children follow it.
+	Encapsulated bool
+	// Value is the decoded contents of a primitive element, when there is
+	// something readable to show.
+	Value string
+}
+
+// ParseASN1 walks a DER encoding and returns every element in document order,
+// parents before their children. Constructed elements are always descended
+// into; an OCTET STRING or BIT STRING is descended into when its contents
+// parse cleanly as DER.
+//
+// A parse error part-way through still returns the elements read so far, so
+// a malformed certificate shows how far it got.
+func ParseASN1(der []byte) ([]ASN1Node, error) {
+	var nodes []ASN1Node
+	err := parseASN1(der, 0, 0, &nodes)
+	return nodes, err
+}
+
+func parseASN1(data []byte, offset, depth int, nodes *[]ASN1Node) error {
+	if depth > maxASN1Depth {
+		return fmt.Errorf("ASN.1 nested deeper than %d levels at offset %d", maxASN1Depth, offset)
+	}
+	for len(data) > 0 {
+		var raw asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &raw)
+		if err != nil {
+			return fmt.Errorf("offset %d: %w", offset, err)
+		}
+		header := len(raw.FullBytes) - len(raw.Bytes)
+		node := ASN1Node{
+			Offset:       offset,
+			Depth:        depth,
+			HeaderLength: header,
+			Length:       len(raw.Bytes),
+			Class:        raw.Class,
+			Tag:          raw.Tag,
+			Constructed:  raw.IsCompound,
+		}
+
+		switch {
+		case raw.IsCompound:
+			*nodes = append(*nodes, node)
+			if err := parseASN1(raw.Bytes, offset+header, depth+1, nodes); err != nil {
+				return err
+			}
+		case encapsulatesDER(raw):
+			node.Encapsulated = true
+			contents, skip := raw.Bytes, 0
+			if raw.Tag == asn1.TagBitString {
+				contents, skip = raw.Bytes[1:], 1 // the unused-bits byte
+			}
+			*nodes = append(*nodes, node)
+			if err := parseASN1(contents, offset+header+skip, depth+1, nodes); err != nil {
+				return err
+			}
+		default:
+			node.Value = asn1Value(raw)
+			*nodes = append(*nodes, node)
+		}
+
+		offset += len(raw.FullBytes)
+		data = rest
+	}
+	return nil
+}
+
+// encapsulatesDER reports whether a primitive string's contents are a
+// complete DER encoding, which is how extension values and public keys are
+// wrapped. Only a SEQUENCE is taken as a sign: a short string of bytes can
+// happen to look like some other element.
+func encapsulatesDER(raw asn1.RawValue) bool {
+	if raw.Class != asn1.ClassUniversal {
+		return false
+	}
+	contents := raw.Bytes
+	switch raw.Tag {
+	case asn1.TagOctetString:
+	case asn1.TagBitString:
+		if len(contents) == 0 || contents[0] != 0 {
+			return false
+		}
+		contents = contents[1:]
+	default:
+		return false
+	}
+	if len(contents) < 2 || contents[0] != 0x30 {
+		return false
+	}
+	for len(contents) > 0 {
+		var inner asn1.RawValue
+		rest, err := asn1.Unmarshal(contents, &inner)
+		if err != nil {
+			return false
+		}
+		contents = rest
+	}
+	return true
+}
+
+// asn1TagNames are the universal tags a certificate uses.
+var asn1TagNames = map[int]string{
+	asn1.TagBoolean:         "BOOLEAN",
+	asn1.TagInteger:         "INTEGER",
+	asn1.TagBitString:       "BIT STRING",
+	asn1.TagOctetString:     "OCTET STRING",
+	asn1.TagNull:            "NULL",
+	asn1.TagOID:             "OBJECT",
+	asn1.TagEnum:            "ENUMERATED",
+	asn1.TagUTF8String:      "UTF8STRING",
+	asn1.TagSequence:        "SEQUENCE",
+	asn1.TagSet:             "SET",
+	asn1.TagNumericString:   "NUMERICSTRING",
+	asn1.TagPrintableString: "PRINTABLESTRING",
+	asn1.TagT61String:       "T61STRING",
+	asn1.TagIA5String:       "IA5STRING",
+	asn1.TagUTCTime:         "UTCTIME",
+	asn1.TagGeneralizedTime: "GENERALIZEDTIME",
+	asn1.TagGeneralString:   "GENERALSTRING",
+	asn1.TagBMPString:       "BMPSTRING",
+}
+
+// TagName names the element's type the way openssl does: SEQUENCE, INTEGER,
+// cont [ 3 ] for a context-specific tag.
+func (n ASN1Node) TagName() string {
+	switch n.Class {
+	case asn1.ClassUniversal:
+		if name, ok := asn1TagNames[n.Tag]; ok {
+			return name
+		}
+		return fmt.Sprintf("univ [ %d ]", n.Tag)
+	case asn1.ClassContextSpecific:
+		return fmt.Sprintf("cont [ %d ]", n.Tag)
+	case asn1.ClassApplication:
+		return fmt.Sprintf("appl [ %d ]", n.Tag)
+	default:
+		return fmt.Sprintf("priv [ %d ]", n.Tag)
+	}
+}
+
+// asn1Value decodes a primitive element for display. It returns "" for
+// anything with no readable form.
+func asn1Value(raw asn1.RawValue) string {
+	if raw.Class != asn1.ClassUniversal {
+		// An implicitly tagged string is most often a SAN or URL; show it if
+		// it reads as text.
+		if isPrintableText(raw.Bytes) {
+			return string(raw.Bytes)
+		}
+		return ""
+	}
+
+	switch raw.Tag {
+	case asn1.TagBoolean:
+		if len(raw.Bytes) == 1 && raw.Bytes[0] != 0 {
+			return "TRUE"
+		}
+		return "FALSE"
+	case asn1.TagInteger, asn1.TagEnum:
+		n := new(big.Int).SetBytes(raw.Bytes)
+		if len(raw.Bytes) > 0 && raw.Bytes[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(raw.Bytes))))
+		}
+		if n.BitLen() > 64 {
+			return hexBytes(raw.Bytes)
+		}
+		return n.String()
+	case asn1.TagOID:
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(raw.FullBytes, &oid); err != nil {
+			return ""
+		}
+		if name := OIDName(oid.String()); name != "" {
+			return fmt.Sprintf("%s (%s)", name, oid)
+		}
+		return oid.String()
+	case asn1.TagUTCTime, asn1.TagGeneralizedTime:
+		var t time.Time
+		if _, err := asn1.Unmarshal(raw.FullBytes, &t); err != nil {
+			return string(raw.Bytes)
+		}
+		return FormatTime(t)
+	case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String,
+		asn1.TagNumericString, asn1.TagT61String, asn1.TagGeneralString:
+		return string(raw.Bytes)
+	case asn1.TagBitString:
+		if len(raw.Bytes) == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d bits", 8*(len(raw.Bytes)-1)-int(raw.Bytes[0]))
+	case asn1.TagOctetString:
+		return fmt.Sprintf("%d bytes", len(raw.Bytes))
+	}
+	return ""
+}
+
+// isPrintableText reports whether data is non-empty printable UTF-8.
+func isPrintableText(data []byte) bool {
+	if len(data) == 0 || !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// oidNames covers the object identifiers inside a certificate that are not
+// extensions: name attributes, signature algorithms and key types.
+var oidNames = map[string]string{
+	"2.5.4.3":               "commonName",
+	"2.5.4.5":               "serialNumber",
+	"2.5.4.6":               "countryName",
+	"2.5.4.7":               "localityName",
+	"2.5.4.8":               "stateOrProvinceName",
+	"2.5.4.9":               "streetAddress",
+	"2.5.4.10":              "organizationName",
+	"2.5.4.11":              "organizationalUnitName",
+	"2.5.4.17":              "postalCode",
+	"1.2.840.113549.1.9.1":  "emailAddress",
+	"1.2.840.113549.1.1.1":  "rsaEncryption",
+	"1.2.840.113549.1.1.5":  "sha1WithRSAEncryption",
+	"1.2.840.113549.1.1.10": "rsassaPss",
+	"1.2.840.113549.1.1.11": "sha256WithRSAEncryption",
+	"1.2.840.113549.1.1.12": "sha384WithRSAEncryption",
+	"1.2.840.113549.1.1.13": "sha512WithRSAEncryption",
+	"1.2.840.10045.2.1":     "id-ecPublicKey",
+	"1.2.840.10045.3.1.7":   "prime256v1",
+	"1.3.132.0.34":          "secp384r1",
+	"1.3.132.0.35":          "secp521r1",
+	"1.2.840.10045.4.1":     "ecdsa-with-SHA1",
+	"1.2.840.10045.4.3.2":   "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":   "ecdsa-with-SHA384",
+	"1.2.840.10045.4.3.4":   "ecdsa-with-SHA512",
+	"1.3.101.112":           "ED25519",
+	"1.3.6.1.5.5.7.3.1":     "serverAuth",
+	"1.3.6.1.5.5.7.3.2":     "clientAuth",
+	"1.3.6.1.5.5.7.48.1":    "OCSP",
+	"1.3.6.1.5.5.7.48.2":    "caIssuers",
+}
+
+// OIDName gives the usual name of an object identifier in dotted form: an
+// extension, a name attribute, an algorithm. It returns "" for one y509 does
+// not know.
+func OIDName(oid string) string {
+	if name, ok := extensionNames[oid]; ok {
+		return name
+	}
+	if name, ok := oidNames[oid]; ok {
+		return name
+	}
+	if policy, ok := knownPolicies[oid]; ok {
+		return policy.name
+	}
+	return ""
+}
+
+// FormatASN1 renders the elements in the layout of openssl asn1parse, with
+// each element indented by its depth:
+//
+//	0:d=0  hl=4 l=1011 cons: SEQUENCE
+//	4:d=1  hl=4 l= 731 cons:   SEQUENCE
+func FormatASN1(nodes []ASN1Node) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		kind := "prim"
+		if n.Constructed {
+			kind = "cons"
+		}
+		fmt.Fprintf(&sb, "%5d:d=%-2d hl=%d l=%4d %s: %s%s",
+			n.Offset, n.Depth, n.HeaderLength, n.Length, kind, strings.Repeat("  ", n.Depth), n.TagName())
+		if n.Encapsulated {
+			sb.WriteString(" (encapsulates)")
+		}
+		if n.Value != "" {
+			sb.WriteString("  :" + n.Value)
+		}
+		sb.WriteByte('\n')
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// HexDump renders data as offset, hex and ASCII columns, perLine bytes to a
+// row, in the layout of hexdump -C.
+func HexDump(data []byte, perLine int) string {
+	if perLine <= 0 {
+		perLine = 16
+	}
+	var sb strings.Builder
+	for start := 0; start < len(data); start += perLine {
+		row := data[start:min(start+perLine, len(data))]
+		fmt.Fprintf(&sb, "%08x  ", start)
+		for i := range perLine {
+			if i < len(row) {
+				fmt.Fprintf(&sb, "%02x ", row[i])
+			} else {
+				sb.WriteString("   ")
+			}
+		}
+		sb.WriteString(" |")
+		for _, b := range row {
+			if b >= 0x20 && b < 0x7f {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,85 @@
+package certificate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseASN1(t *testing.T) {
+	root, _ := issue(t, "Root", true, nil, nil)
+
+	nodes, err := ParseASN1(root.Raw)
+	if err != nil {
+		t.Fatalf("ParseASN1: %v", err)
+	}
+	if len(nodes) == 0 {
+		t.Fatal("no elements")
+	}
+
+	top := nodes[0]
+	if top.Offset != 0 || top.Depth != 0 || top.TagName() != "SEQUENCE" || !top.Constructed {
+		t.Errorf("first element = %+v, want the outer SEQUENCE", top)
+	}
+	if top.HeaderLength+top.Length != len(root.Raw) {
+		t.Errorf("outer SEQUENCE covers %d bytes, the certificate is %d", top.HeaderLength+top.Length, len(root.Raw))
+	}
+
+	// Every element must sit where its offset says.
+	for _, n := range nodes {
+		if n.Offset+n.HeaderLength+n.Length > len(root.Raw) {
+			t.Fatalf("element at %d runs past the end", n.Offset)
+		}
+	}
+
+	var values []string
+	encapsulated := false
+	for _, n := range nodes {
+		values = append(values, n.Value)
+		encapsulated = encapsulated || n.Encapsulated
+	}
+	joined := strings.Join(values, "\n")
+	for _, want := range []string{"commonName (2.5.4.3)", "Root", "Basic Constraints (2.5.29.19)", "TRUE"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("decoded values missing %q", want)
+		}
+	}
+	if !encapsulated {
+		t.Error("no extension value was descended into")
+	}
+
+	tree := FormatASN1(nodes)
+	if !strings.HasPrefix(tree, "    0:d=0  hl=") || !strings.Contains(tree, "cons: SEQUENCE") {
+		t.Errorf("FormatASN1 not in asn1parse layout:\n%s", tree)
+	}
+}
+
+func TestParseASN1_Truncated(t *testing.T) {
+	root, _ := issue(t, "Root", true, nil, nil)
+
+	nodes, err := ParseASN1(root.Raw[:len(root.Raw)/2])
+	if err == nil {
+		t.Fatal("expected an error for truncated DER")
+	}
+	if len(nodes) != 0 {
+		t.Errorf("outer SEQUENCE cannot be read, yet got %d elements", len(nodes))
+	}
+
+	// A SEQUENCE whose second INTEGER claims more bytes than it has: the
+	// elements before it still come back.
+	nodes, err = ParseASN1([]byte{0x30, 0x06, 0x02, 0x01, 0x05, 0x02, 0x05, 0x00})
+	if err == nil {
+		t.Fatal("expected an error for a truncated INTEGER")
+	}
+	if len(nodes) != 2 || nodes[1].Value != "5" {
+		t.Errorf("elements before the error = %+v, want the SEQUENCE and INTEGER 5", nodes)
+	}
+}
+
+func TestHexDump(t *testing.T) {
+	got := HexDump([]byte("0\x82ABCDEFGHIJKLMNOPQR"), 16)
+	want := "00000000  30 82 41 42 43 44 45 46 47 48 49 4a 4b 4c 4d 4e  |0.ABCDEFGHIJKLMN|\n" +
+		"00000010  4f 50 51 52                                      |OPQR|"
+	if got != want {
+		t.Errorf("HexDump =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxBuildSteps bounds the search BuildChain does through a pool. A pile of
+// cross-signs fans out combinatorially, and a real chain is a handful of
+// certificates deep.
+const maxBuildSteps = 1024
+
+// BuildChains assembles a chain for every leaf in an unordered pool of
+// certificates -- a directory's worth of PEM files concatenated, say, or a
+// bundle whose sender never heard of leaf-first order.
+//
+// A leaf is any certificate in the pool that issued none of the others. Each
+// chain is built by BuildChain, and the chains come back in the order their
+// leaves appear in the pool. Duplicates and nil entries are ignored.
+func BuildChains(pool []*x509.Certificate, at time.Time) [][]*x509.Certificate {
+	certs := uniqueCerts(pool)
+
+	var chains [][]*x509.Certificate
+	for _, cert := range certs {
+		if slices.ContainsFunc(certs, func(other *x509.Certificate) bool {
+			return other != cert && !IsSelfSigned(other) && issues(cert, other)
+		}) {
+			continue
+		}
+		chains = append(chains, BuildChain(cert, certs, at))
+	}
+	return chains
+}
+
+// BuildChain builds the best chain up from leaf through pool, leaf first.
+//
+// An issuer is accepted only when its subject matches the child's issuer name,
+// its subject key identifier matches the child's authority key identifier
+// (when both are present), and its key verifies the child's signature. Of the
+// paths those rules allow, the best is the one whose certificates are all within
+// their validity period at the given time (zero means now), then one that
+// ends at a self-signed root, then the longest. The first of those criteria
+// is what keeps a cross-sign to an expired root -- DST Root CA X3, say --
+// from winning over the current self-signed root just for being longer.
+func BuildChain(leaf *x509.Certificate, pool []*x509.Certificate, at time.Time) []*x509.Certificate {
+	if leaf == nil {
+		return nil
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	certs := uniqueCerts(pool)
+
+	best := []*x509.Certificate{leaf}
+	steps := 0
+	var walk func(path []*x509.Certificate)
+	walk = func(path []*x509.Certificate) {
+		if steps++; steps > maxBuildSteps {
+			return
+		}
+		if betterChain(path, best, at) {
+			best = slices.Clone(path)
+		}
+		top := path[len(path)-1]
+		if IsSelfSigned(top) {
+			return
+		}
+		for _, candidate := range certs {
+			if slices.ContainsFunc(path, candidate.Equal) || !issues(candidate, top) {
+				continue
+			}
+			walk(append(slices.Clip(path), candidate))
+		}
+	}
+	walk([]*x509.Certificate{leaf})
+
+	return best
+}
+
+// AssembleChain puts certs in the order of the one chain they make, leaf
+// first, as a bundle file holds them. It is an error for them to make more
+// than one chain, or to hold a certificate the chain built up from the leaf
+// leaves out: a stray from another bundle, or an issuer whose signature does
+// not check out. Duplicates are dropped. The chain need not reach a root.
+func AssembleChain(certs []*x509.Certificate) ([]*x509.Certificate, error) {
+	unique := uniqueCerts(certs)
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("no certificates to bundle")
+	}
+
+	chains := BuildChains(unique, time.Time{})
+	switch len(chains) {
+	case 0:
+		return nil, fmt.Errorf("no leaf: every certificate issued another")
+	case 1:
+	default:
+		leaves := make([]string, len(chains))
+		for i, chain := range chains {
+			leaves[i] = "'" + displayName(chain[0]) + "'"
+		}
+		return nil, fmt.Errorf("the certificates make %d chains, from %s; a bundle holds one", len(chains), strings.Join(leaves, ", "))
+	}
+
+	chain := chains[0]
+	for _, cert := range unique {
+		if !slices.ContainsFunc(chain, cert.Equal) {
+			return nil, fmt.Errorf("'%s' is not part of the chain %s", displayName(cert), FormatChainNames(chain))
+		}
+	}
+	return chain, nil
+}
+
+// issues reports whether parent issued child, by name, key identifier and
+// signature. Key identifiers are only compared when both sides carry one; a
+// certificate without them is still linked by its signature.
+func issues(parent, child *x509.Certificate) bool {
+	if !bytes.Equal(parent.RawSubject, child.RawIssuer) {
+		return false
+	}
+	if len(child.AuthorityKeyId) > 0 && len(parent.SubjectKeyId) > 0 &&
+		!bytes.Equal(child.AuthorityKeyId, parent.SubjectKeyId) {
+		return false
+	}
+	return signedBy(child, parent)
+}
+
+// betterChain reports whether a ranks above b by BuildChain's criteria.
+func betterChain(a, b []*x509.Certificate, at time.Time) bool {
+	if aValid, bValid := withinValidity(a, at), withinValidity(b, at); aValid != bValid {
+		return aValid
+	}
+	if aComplete, bComplete := IsSelfSigned(a[len(a)-1]), IsSelfSigned(b[len(b)-1]); aComplete != bComplete {
+		return aComplete
+	}
+	return len(a) > len(b)
+}
+
+// withinValidity reports whether every certificate in chain is valid at at.
+func withinValidity(chain []*x509.Certificate, at time.Time) bool {
+	for _, cert := range chain {
+		if at.Before(cert.NotBefore) || at.After(cert.NotAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueCerts drops nil entries and repeated certificates, keeping the first
+// occurrence of each.
+func uniqueCerts(certs []*x509.Certificate) []*x509.Certificate {
+	unique := make([]*x509.Certificate, 0, len(certs))
+	seen := make(map[string]bool, len(certs))
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		fingerprint := FormatFingerprint(cert)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		unique = append(unique, cert)
+	}
+	return unique
+}
+
+// FormatChainNames renders a chain on one line, leaf first, by common name.
+func FormatChainNames(chain []*x509.Certificate) string {
+	names := make([]string, len(chain))
+	for i, cert := range chain {
+		names[i] = displayName(cert)
+	}
+	return strings.Join(names, " → ")
+}
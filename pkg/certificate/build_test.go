@@ -0,0 +1,131 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildChains_UnorderedPool(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Intermediate", true, root, rootKey)
+	first, _ := issue(t, "first.example", false, intermediate, intermediateKey)
+	second, _ := issue(t, "second.example", false, intermediate, intermediateKey)
+
+	pool := []*x509.Certificate{root, second, intermediate, root, first}
+	chains := BuildChains(pool, time.Time{})
+	if len(chains) != 2 {
+		t.Fatalf("got %d chains, want one per leaf", len(chains))
+	}
+
+	want := [][]*x509.Certificate{
+		{second, intermediate, root},
+		{first, intermediate, root},
+	}
+	for i, chain := range chains {
+		if !sameOrder(chain, want[i]) {
+			t.Errorf("chain %d = %s, want %s", i, FormatChainNames(chain), FormatChainNames(want[i]))
+		}
+	}
+}
+
+// TestBuildChain_PrefersValidOverLonger checks that the cross-sign to an
+// expired root is not taken just because it makes the chain longer.
+func TestBuildChain_PrefersValidOverLonger(t *testing.T) {
+	oldRoot, oldRootKey := expiredRoot(t, "DST Root CA X3")
+	newRoot, newRootKey := issue(t, "ISRG Root X1", true, nil, nil)
+	crossed := crossSign(t, newRoot, newRootKey, oldRoot, oldRootKey)
+	intermediate, intermediateKey := issue(t, "R3", true, newRoot, newRootKey)
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+
+	chain := BuildChain(leaf, []*x509.Certificate{oldRoot, crossed, leaf, intermediate, newRoot}, time.Time{})
+	want := []*x509.Certificate{leaf, intermediate, newRoot}
+	if !sameOrder(chain, want) {
+		t.Errorf("chain = %s, want %s", FormatChainNames(chain), FormatChainNames(want))
+	}
+
+	// Without the self-signed root, the valid path stops at the cross-sign
+	// rather than running on to the expired root.
+	chain = BuildChain(leaf, []*x509.Certificate{oldRoot, crossed, intermediate}, time.Time{})
+	want = []*x509.Certificate{leaf, intermediate, crossed}
+	if !sameOrder(chain, want) {
+		t.Errorf("without the self-signed root, chain = %s, want %s", FormatChainNames(chain), FormatChainNames(want))
+	}
+}
+
+func TestAssembleChain(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Intermediate", true, root, rootKey)
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+	other, _ := issue(t, "other.example", false, intermediate, intermediateKey)
+	stray, strayKey := issue(t, "Other Root", true, nil, nil)
+	strayLeaf, _ := issue(t, "stray.example", false, stray, strayKey)
+
+	chain, err := AssembleChain([]*x509.Certificate{root, leaf, intermediate, leaf})
+	if err != nil || !sameOrder(chain, []*x509.Certificate{leaf, intermediate, root}) {
+		t.Errorf("AssembleChain = %s, %v; want leaf.example → Intermediate → Root", FormatChainNames(chain), err)
+	}
+	// No root is fine: most full-chain files leave it out.
+	if chain, err := AssembleChain([]*x509.Certificate{intermediate, leaf}); err != nil || len(chain) != 2 {
+		t.Errorf("without the root, AssembleChain = %s, %v", FormatChainNames(chain), err)
+	}
+
+	for name, certs := range map[string][]*x509.Certificate{
+		"two leaves":     {leaf, other, intermediate},
+		"a stray root":   {leaf, intermediate, stray},
+		"another chain":  {leaf, intermediate, strayLeaf},
+		"no certificate": nil,
+	} {
+		if chain, err := AssembleChain(certs); err == nil {
+			t.Errorf("%s: AssembleChain = %s, want an error", name, FormatChainNames(chain))
+		}
+	}
+}
+
+func TestBuildChain_StopsAtMissingIssuer(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Intermediate", true, root, rootKey)
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+	unrelated, _ := issue(t, "Other Root", true, nil, nil)
+
+	chain := BuildChain(leaf, []*x509.Certificate{unrelated, intermediate}, time.Time{})
+	if !sameOrder(chain, []*x509.Certificate{leaf, intermediate}) {
+		t.Errorf("chain = %s, want leaf.example → Intermediate", FormatChainNames(chain))
+	}
+}
+
+func TestExportChain_RoundTrip(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+
+	for _, format := range []string{"pem", "p7b"} {
+		target := filepath.Join(t.TempDir(), "chain."+format)
+		if err := ExportChain([]*x509.Certificate{leaf, root}, format, target); err != nil {
+			t.Fatalf("ExportChain(%s): %v", format, err)
+		}
+		loaded, err := LoadCertificates(target)
+		if err != nil {
+			t.Fatalf("LoadCertificates(%s): %v", format, err)
+		}
+		if len(loaded) != 2 || !loaded[0].Certificate.Equal(leaf) || !loaded[1].Certificate.Equal(root) {
+			t.Errorf("%s: got %d certificates back, want leaf then root", format, len(loaded))
+		}
+	}
+
+	// Most readers of a DER file see only its first certificate, so a chain
+	// is refused rather than cut short without a word.
+	target := filepath.Join(t.TempDir(), "chain.der")
+	if err := ExportChain([]*x509.Certificate{leaf, root}, "der", target); err == nil || !strings.Contains(err.Error(), "one certificate") {
+		t.Errorf("ExportChain(der) of a chain = %v, want an error", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("a refused DER chain left a file behind")
+	}
+	if data, err := EncodeChain([]*x509.Certificate{leaf}, "der"); err != nil || !bytes.Equal(data, leaf.Raw) {
+		t.Errorf("EncodeChain(der) of one certificate = %v", err)
+	}
+}
@@ -2,6 +2,7 @@
 package certificate
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
@@ -11,12 +12,12 @@ import (
 	"encoding/asn1"
 	"encoding/pem"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"time"
+	"unicode"
 
 	"go.uber.org/zap"
 )
@@ -47,7 +48,20 @@ func SetLogger(l *zap.Logger) {
 	if l == nil {
 		l = zap.NewNop()
 	}
-	logger.l.Store(l)
+	// Skip safeLogger's own frame, so that the caller logged is the code
+	// that logged.
+	logger.l.Store(l.WithOptions(zap.AddCallerSkip(1)))
+}
+
+// logRequest records a network call once it is over: what it was, how long
+// it took, and how it ended.
+func logRequest(msg string, start time.Time, err error, fields ...zap.Field) {
+	fields = append(fields, zap.Duration("elapsed", time.Since(start)))
+	if err != nil {
+		logger.Warn(msg+" failed", append(fields, zap.Error(err))...)
+		return
+	}
+	logger.Info(msg, fields...)
 }
 
 // ValidationStatus represents the validation status of a single certificate in the chain.
@@ -70,6 +84,9 @@ const (
 	StatusMismatchedIssuer
 	// StatusInvalidSignature represents a failed signature verification
 	StatusInvalidSignature
+	// StatusConstraintViolation represents a certificate issued in breach of
+	// its issuer's basic constraints: by a non-CA, or beyond a path length
+	StatusConstraintViolation
 )
 
 // Info holds certificate data and metadata
@@ -79,39 +96,18 @@ type Info struct {
 	Label            string
 	ValidationStatus ValidationStatus
 	ValidationError  error
+	// Source is the file or server address the certificate was loaded from,
+	// empty for stdin.
+	Source string
+	// Line is the line of the input its PEM block begins on, counting from
+	// 1, or 0 when it was not read from PEM.
+	Line int
 }
 
-// LoadCertificates loads certificates from a file or stdin
+// LoadCertificates loads certificates from a file or stdin, detecting
+// their format.
 func LoadCertificates(filename string) ([]*Info, error) {
-	var input io.Reader
-	if filename == "" {
-		input = os.Stdin
-	} else {
-		file, err := os.Open(filename)
-		if err != nil {
-			logger.Error("Failed to open file", zap.Error(err))
-			return nil, fmt.Errorf("failed to read input: %w", err)
-		}
-		defer func() {
-			if closeErr := file.Close(); closeErr != nil {
-				logger.Error("Failed to close input file", zap.String("filename", filename), zap.Error(closeErr))
-			}
-		}()
-		input = file
-	}
-
-	data, err := io.ReadAll(input)
-	if err != nil {
-		logger.Error("Failed to read input", zap.Error(err))
-		return nil, fmt.Errorf("failed to read input: %w", err)
-	}
-
-	if len(data) == 0 {
-		logger.Error("Empty input")
-		return nil, fmt.Errorf("empty input")
-	}
-
-	return ParseCertificates(data)
+	return LoadCertificatesWith(filename, LoadOptions{})
 }
 
 // SortChain sorts certificates into valid chains [Leaf, Intermediate, Root]
@@ -209,9 +205,12 @@ func SortChain(certs []*x509.Certificate) ([]*x509.Certificate, error) {
 func ValidateChainLinks(certs []*Info) {
 	// Create a map of subjects for quick parent lookup
 	subjectMap := make(map[string]*x509.Certificate)
-	for _, c := range certs {
+	rawCerts := make([]*x509.Certificate, len(certs))
+	for i, c := range certs {
 		subjectMap[c.Certificate.Subject.String()] = c.Certificate
+		rawCerts[i] = c.Certificate
 	}
+	violations := constraintViolationsIn(rawCerts)
 
 	for _, certInfo := range certs {
 		cert := certInfo.Certificate
@@ -249,7 +248,19 @@ func ValidateChainLinks(certs []*Info) {
 		if !found {
 			// Parent is not in the provided list, it's an orphan.
 			certInfo.ValidationStatus = StatusMismatchedIssuer
-			certInfo.ValidationError = fmt.Errorf("issuer ('%s') not found in provided certificates", cert.Issuer.CommonName)
+			certInfo.ValidationError = &MissingIssuerError{
+				Certificate: cert,
+				Issuer:      nameOrUnknown(cert.Issuer.CommonName),
+				URLs:        cert.IssuingCertificateURL,
+			}
+			continue
+		}
+
+		// A parent that was never allowed to issue this certificate is the
+		// real problem; CheckSignatureFrom would only call it a bad signature.
+		if violation, ok := violations[FormatFingerprint(cert)]; ok {
+			certInfo.ValidationStatus = StatusConstraintViolation
+			certInfo.ValidationError = violation
 			continue
 		}
 
@@ -261,12 +272,39 @@ func ValidateChainLinks(certs []*Info) {
 	}
 }
 
+// FileName suggests a file name for the nth of a set of certificates being
+// written one per file, counting from 1: "02-Issuing_CA.pem". The name comes
+// from the common name, or the serial when there is none, with anything
+// awkward in a file name replaced by an underscore.
+func FileName(cert *x509.Certificate, n int, format string) string {
+	name := SafeFileName(displayName(cert))
+	if name == "" {
+		name = "certificate"
+	}
+	return fmt.Sprintf("%02d-%s.%s", n, name, format)
+}
+
+// SafeFileName makes s fit to go in a file name: anything but ASCII
+// letters, digits, '-', '.' and '_' becomes an underscore, and dots and
+// underscores at either end go. What is left may be empty.
+func SafeFileName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x80 && (r == '-' || r == '.' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(s))
+	return strings.Trim(name, "._")
+}
+
 // ExportCertificate exports a certificate to a file
 func ExportCertificate(cert *x509.Certificate, format string, filename string) error {
-	if cert == nil || len(cert.Raw) == 0 {
-		return fmt.Errorf("certificate has no raw data to export")
-	}
+	return ExportChain([]*x509.Certificate{cert}, format, filename)
+}
 
+// ExportChain exports certificates to a single file, in order: a PEM bundle
+// or a certs-only PKCS#7 bundle. DER takes only one certificate.
+func ExportChain(certs []*x509.Certificate, format string, filename string) error {
 	// Determine format from argument or extension
 	f := strings.ToLower(format)
 	if f == "" {
@@ -278,17 +316,9 @@ func ExportCertificate(cert *x509.Certificate, format string, filename string) e
 
 	// Build the file contents before touching the filesystem so an
 	// unsupported format doesn't leave an empty file behind.
-	var data []byte
-	switch f {
-	case "pem", "crt", "cert":
-		data = pem.EncodeToMemory(&pem.Block{
-			Type:  "CERTIFICATE",
-			Bytes: cert.Raw,
-		})
-	case "der":
-		data = cert.Raw
-	default:
-		return fmt.Errorf("unsupported format: %s (supported: pem, der, crt, cert)", f)
+	data, err := EncodeChain(certs, f)
+	if err != nil {
+		return err
 	}
 
 	// Create directory if it doesn't exist
@@ -316,12 +346,60 @@ func ExportCertificate(cert *x509.Certificate, format string, filename string) e
 	return nil
 }
 
-// ParseCertificates extracts certificates from a PEM bundle or from raw DER.
+// ExportFormats are the formats EncodeChain takes, pkcs7 aside: crt and cert
+// are PEM, and p7b and p7c PKCS#7.
+var ExportFormats = []string{"pem", "der", "crt", "cert", "p7b", "p7c"}
+
+// EncodeChain encodes certificates, in order, in one of the export formats:
+// pem (or crt, cert), der, or pkcs7 (or p7b, p7c). der takes a single
+// certificate.
+func EncodeChain(certs []*x509.Certificate, format string) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates to export")
+	}
+	for _, cert := range certs {
+		if cert == nil || len(cert.Raw) == 0 {
+			return nil, fmt.Errorf("certificate has no raw data to export")
+		}
+	}
+
+	var data []byte
+	switch f := strings.ToLower(format); f {
+	case "pem", "crt", "cert":
+		for _, cert := range certs {
+			data = append(data, pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: cert.Raw,
+			})...)
+		}
+	case "der":
+		// Concatenated DER parses here, but openssl and most other readers
+		// see only the first certificate of it.
+		if len(certs) > 1 {
+			return nil, fmt.Errorf("a DER file holds one certificate, not %d: use pem or pkcs7 for a chain", len(certs))
+		}
+		data = certs[0].Raw
+	case "pkcs7", "p7b", "p7c":
+		return EncodePKCS7(certs)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s (supported: %s)", f, strings.Join(ExportFormats, ", "))
+	}
+	return data, nil
+}
+
+// ParseCertificates extracts certificates from a PEM bundle, from raw DER,
+// from a certs-only PKCS#7 bundle in either, or from a PKCS#12 file with no
+// password.
+func ParseCertificates(data []byte) ([]*Info, error) {
+	return ParseCertificatesWith(data, LoadOptions{})
+}
+
+// detectCertificates is ParseCertificates without PKCS#12.
 //
 // PEM is tried first. If the input holds no PEM armour at all it is treated as
 // DER, which is what Windows and most CAs hand out as .der / .cer, and what
 // y509's own export writes when asked for DER.
-func ParseCertificates(data []byte) ([]*Info, error) {
+func detectCertificates(data []byte) ([]*Info, error) {
 	certs, sawPEM, err := parsePEMCertificates(data)
 	if err != nil {
 		return nil, err
@@ -354,17 +432,31 @@ func parsePEMCertificates(data []byte) (certs []*Info, sawPEM bool, err error) {
 		}
 		sawPEM = true
 
-		if block.Type == "CERTIFICATE" {
+		var parsed []*x509.Certificate
+		switch block.Type {
+		case "CERTIFICATE":
 			crt, err := x509.ParseCertificate(block.Bytes)
 			if err != nil {
 				logger.Error("Failed to parse certificate", zap.Error(err))
 				return nil, sawPEM, fmt.Errorf("failed to parse certificate %d: %w", index, err)
 			}
+			parsed = []*x509.Certificate{crt}
+		case "PKCS7":
+			parsed, err = parsePKCS7Certificates(block.Bytes)
+			if err != nil {
+				return nil, sawPEM, fmt.Errorf("failed to parse PKCS#7 block: %w", err)
+			}
+		}
 
+		// The block is the last BEGIN line before where Decode stopped.
+		end := len(data) - len(remaining)
+		begin := bytes.LastIndex(data[:end], []byte("-----BEGIN"))
+		for _, crt := range parsed {
 			certs = append(certs, &Info{
 				Certificate: crt,
 				Index:       index,
 				Label:       generateCertificateLabel(crt, index),
+				Line:        bytes.Count(data[:max(0, begin)], []byte("\n")) + 1,
 			})
 			// Count certificates, not PEM blocks: a bundle may also carry a
 			// private key, DH parameters, or a CRL, and those must not consume
@@ -383,6 +475,12 @@ func parsePEMCertificates(data []byte) (certs []*Info, sawPEM bool, err error) {
 // is usually shipped.
 func parseDERCertificates(data []byte) ([]*Info, error) {
 	parsed, err := x509.ParseCertificates(data)
+	if err != nil && isPKCSContainer(data) {
+		// A .p7b or .p7c: take the certificates out of it.
+		if bundled, perr := parsePKCS7Certificates(data); perr == nil {
+			parsed, err = bundled, nil
+		}
+	}
 	if err != nil {
 		// Failing to parse is the ordinary outcome for anything that is not a
 		// certificate, so log it at debug rather than spamming the log on every
@@ -394,8 +492,8 @@ func parseDERCertificates(data []byte) ([]*Info, error) {
 			// A DER SEQUENCE whose first element is an OID or INTEGER is a PKCS
 			// container, not a certificate. Testing the first byte alone would
 			// misfire on any text starting with '0' (0x30).
-			return nil, fmt.Errorf("input is a DER structure but not a certificate "+
-				"(PKCS#7 and PKCS#12 bundles are not supported): %w", err)
+			return nil, fmt.Errorf("input is a DER structure but not a certificate, "+
+				"PKCS#7 bundle or PKCS#12 file: %w", err)
 		case len(data) > 0 && data[0] == derSequenceTag:
 			// Begins like DER but does not form a complete SEQUENCE: a
 			// truncated or corrupt certificate rather than a container.
@@ -412,6 +510,11 @@ func parseDERCertificates(data []byte) ([]*Info, error) {
 		return nil, fmt.Errorf("no certificates found in input")
 	}
 
+	return newInfos(parsed), nil
+}
+
+// newInfos numbers certificates that did not come from PEM.
+func newInfos(parsed []*x509.Certificate) []*Info {
 	certs := make([]*Info, len(parsed))
 	for i, crt := range parsed {
 		certs[i] = &Info{
@@ -420,7 +523,7 @@ func parseDERCertificates(data []byte) ([]*Info, error) {
 			Label:       generateCertificateLabel(crt, i),
 		}
 	}
-	return certs, nil
+	return certs
 }
 
 // derSequenceTag is the ASN.1 universal SEQUENCE tag, the first byte of any
@@ -469,6 +572,24 @@ func generateCertificateLabel(cert *x509.Certificate, index int) string {
 	return fmt.Sprintf("%d. %s", index+1, cn)
 }
 
+// IsSelfSigned reports whether the certificate issued itself: its issuer DN is
+// byte-for-byte its subject DN, and its signature verifies against its own
+// key. A matching common name proves nothing -- "R3" or "Root CA" is shared by
+// any number of unrelated certificates -- and even a matching DN is only a
+// claim until the signature checks out.
+//
+// The self-signature is checked with CheckSignature, not CheckSignatureFrom.
+// The latter also enforces the CA basic constraint, which would reject a
+// self-signed *leaf* -- a dev server certificate is exactly that, and it still
+// needs to anchor its own one-cert chain so the result is self-anchored rather
+// than broken.
+func IsSelfSigned(cert *x509.Certificate) bool {
+	if cert == nil || !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
 // IsExpired checks if certificate is expired
 func IsExpired(cert *x509.Certificate) bool {
 	return cert.NotAfter.Before(time.Now())
@@ -574,8 +695,8 @@ func FormatIssuer(cert *x509.Certificate) string {
 func FormatValidity(cert *x509.Certificate) string {
 	var details strings.Builder
 
-	details.WriteString(fmt.Sprintf("Not Before: %s\n", cert.NotBefore.Format("2006-01-02 15:04:05 MST")))
-	details.WriteString(fmt.Sprintf("Not After:  %s\n", cert.NotAfter.Format("2006-01-02 15:04:05 MST")))
+	details.WriteString(fmt.Sprintf("Not Before: %s\n", FormatTime(cert.NotBefore)))
+	details.WriteString(fmt.Sprintf("Not After:  %s\n", FormatTime(cert.NotAfter)))
 
 	// Total validity period, plus a flag for subscriber certs that exceed the
 	// CA/Browser Forum maximum lifetime (CA certs are exempt).
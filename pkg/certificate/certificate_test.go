@@ -338,6 +338,49 @@ func TestIsExpired(t *testing.T) {
 	}
 }
 
+func TestIsSelfSigned(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	selfSignedLeaf, _ := issue(t, "dev.local", false, nil, nil)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Shares its issuer's common name but not its full DN: the kind of
+	// certificate a CommonName comparison calls self-signed.
+	namesake := generateCertificate(&x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: "Root", Organization: []string{"Elsewhere"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, root, &key.PublicKey, rootKey)
+	// Claims to be its own issuer, but another key signed it.
+	forged := generateCertificate(&x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: "Root"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, root, &key.PublicKey, rootKey)
+
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		want bool
+	}{
+		{"self-signed root", root, true},
+		{"self-signed leaf", selfSignedLeaf, true},
+		{"same common name, different issuer", namesake, false},
+		{"matching DN, foreign signature", forged, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSelfSigned(tt.cert); got != tt.want {
+				t.Errorf("IsSelfSigned = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsExpiringSoon(t *testing.T) {
 	now := time.Now()
 
@@ -716,6 +759,25 @@ func TestExportCertificateUnsupportedLeavesNoFile(t *testing.T) {
 	}
 }
 
+func TestFileName(t *testing.T) {
+	cert := createTestCert()
+	if got := FileName(cert, 1, "pem"); got != "01-test.example.com.pem" {
+		t.Errorf("FileName = %q", got)
+	}
+	cert.Subject.CommonName = "Issuing CA / R3"
+	if got := FileName(cert, 12, "der"); got != "12-Issuing_CA___R3.der" {
+		t.Errorf("FileName = %q", got)
+	}
+	cert.Subject.CommonName = ""
+	if got := FileName(cert, 3, "pem"); got != "03-serial_12345.pem" {
+		t.Errorf("FileName without a common name = %q", got)
+	}
+	cert.Subject.CommonName = "../.."
+	if got := FileName(cert, 4, "pem"); got != "04-certificate.pem" {
+		t.Errorf("FileName of a path = %q", got)
+	}
+}
+
 func TestIsExpiringSoonWithin(t *testing.T) {
 	now := time.Now()
 
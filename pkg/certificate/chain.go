@@ -142,7 +142,7 @@ func AnalyzeChain(certs []*x509.Certificate) *ChainReport {
 		// of a chain. On its own it is the leaf -- a self-signed server
 		// certificate the client has to receive to connect -- and flagging it
 		// would be a false positive.
-		if cert.Issuer.String() == cert.Subject.String() && distinct > 1 {
+		if IsSelfSigned(cert) && distinct > 1 {
 			report.Findings = append(report.Findings, ChainFinding{
 				Problem: ProblemRedundantRoot,
 				Subject: displayName(cert),
@@ -232,7 +232,7 @@ func chainTerminus(sorted []*x509.Certificate) *x509.Certificate {
 		}
 		visited[fingerprint] = true
 
-		if current.Issuer.String() == current.Subject.String() {
+		if IsSelfSigned(current) {
 			// Self-signed: the chain ends here, and it is already reported as a
 			// redundant root.
 			return nil
@@ -0,0 +1,261 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckStatus is the outcome of one validation check.
+type CheckStatus int
+
+const (
+	// CheckSkipped means the check was not run, or does not apply.
+	CheckSkipped CheckStatus = iota
+	// CheckPass means the certificate passed.
+	CheckPass
+	// CheckWarn means the certificate passed, but something deserves a look.
+	CheckWarn
+	// CheckFail means a client would reject the certificate over this.
+	CheckFail
+)
+
+// String returns a short label for the status.
+func (s CheckStatus) String() string {
+	switch s {
+	case CheckPass:
+		return "pass"
+	case CheckWarn:
+		return "warn"
+	case CheckFail:
+		return "fail"
+	default:
+		return "skipped"
+	}
+}
+
+// Check is one line of a per-certificate validation report.
+type Check struct {
+	// Name says what was checked, e.g. "Signature".
+	Name string
+	// Status is the outcome.
+	Status CheckStatus
+	// Detail explains the outcome in a sentence.
+	Detail string
+}
+
+// CheckCertificate runs every check y509 knows about against one
+// certificate, looking for its issuer among certs, and reports each one.
+//
+// VerifyChain answers a single question about the chain as a whole and stops
+// at the first failure. This answers it certificate by certificate, and keeps
+// going: an expired intermediate with a good signature reads differently from
+// one whose signature does not verify at all.
+func CheckCertificate(cert *x509.Certificate, certs []*x509.Certificate, opts VerifyOptions) []Check {
+	if cert == nil {
+		return nil
+	}
+
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	days := opts.ExpiryWarningDays
+	if days <= 0 {
+		days = defaultExpiryWarningDays
+	}
+
+	path := issuerPath(cert, certs)
+	var issuer *x509.Certificate
+	if len(path) > 1 {
+		issuer = path[1]
+	}
+
+	checks := []Check{
+		checkDates(cert, now, days),
+		checkLifetime(cert),
+		checkSignature(cert, issuer),
+		checkSignatureAlgorithm(cert),
+		checkConstraints(cert, path),
+		checkUsage(cert, certs),
+	}
+	if opts.DNSName != "" && !cert.IsCA {
+		checks = append(checks, checkHostname(cert, opts.DNSName))
+	}
+	checks = append(checks, checkTrust(cert, certs, opts), checkRevocation(cert))
+	return checks
+}
+
+func checkDates(cert *x509.Certificate, now time.Time, days int) Check {
+	check := Check{Name: "Dates"}
+	switch {
+	case now.Before(cert.NotBefore):
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("not valid until %s", FormatTime(cert.NotBefore))
+	case now.After(cert.NotAfter):
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("expired on %s", FormatTime(cert.NotAfter))
+	default:
+		left := int(cert.NotAfter.Sub(now).Hours() / 24)
+		check.Status = CheckPass
+		if cert.NotAfter.Before(now.AddDate(0, 0, days)) {
+			check.Status = CheckWarn
+		}
+		check.Detail = fmt.Sprintf("valid until %s (%d days left)", FormatDate(cert.NotAfter), left)
+	}
+	return check
+}
+
+func checkLifetime(cert *x509.Certificate) Check {
+	check := Check{Name: "Lifetime", Status: CheckPass}
+	total := ValidityPeriodDays(cert)
+	switch {
+	case cert.IsCA:
+		check.Status = CheckSkipped
+		check.Detail = fmt.Sprintf("%d days; no limit applies to a CA", total)
+	case ExceedsCABMaxLifetime(cert):
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("%d days, longer than the CA/Browser Forum maximum of %d", total, CABMaxSubscriberValidityDays)
+	default:
+		check.Detail = fmt.Sprintf("%d days", total)
+	}
+	return check
+}
+
+// checkSignature checks the signature against the issuer issuerPath found,
+// which is the loaded certificate whose key verifies it if there is one.
+func checkSignature(cert, issuer *x509.Certificate) Check {
+	check := Check{Name: "Signature"}
+	switch {
+	case IsSelfSigned(cert):
+		check.Status = CheckPass
+		check.Detail = "self-signed; the signature verifies with its own key"
+	case issuer == nil:
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("issuer %q is not loaded, so the signature cannot be checked",
+			nameOrUnknown(cert.Issuer.CommonName))
+	case signedBy(cert, issuer):
+		check.Status = CheckPass
+		check.Detail = fmt.Sprintf("verifies with the key of %q", displayName(issuer))
+	default:
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("does not verify with the key of %q", displayName(issuer))
+	}
+	return check
+}
+
+func checkSignatureAlgorithm(cert *x509.Certificate) Check {
+	check := Check{Name: "Algorithm", Status: CheckPass, Detail: cert.SignatureAlgorithm.String()}
+	switch {
+	case IsSelfSigned(cert):
+		// A root is trusted by presence; its self-signature is never checked.
+	case cert.SignatureAlgorithm == x509.MD5WithRSA || cert.SignatureAlgorithm == x509.MD2WithRSA:
+		check.Status = CheckFail
+		check.Detail += "; MD2 and MD5 signatures are broken"
+	case isSHA1(cert.SignatureAlgorithm):
+		check.Status = CheckWarn
+		check.Detail += "; SHA-1 signatures are rejected by modern clients"
+	}
+	return check
+}
+
+func checkConstraints(cert *x509.Certificate, path []*x509.Certificate) Check {
+	check := Check{Name: "Constraints", Status: CheckPass, Detail: "issued within its issuers' constraints"}
+	if len(path) < 2 {
+		check.Status = CheckSkipped
+		check.Detail = "no issuer to check against"
+	}
+	for _, violation := range CheckBasicConstraints(path) {
+		if violation.Certificate == cert {
+			check.Status = CheckFail
+			check.Detail = violation.Detail
+			break
+		}
+	}
+	return check
+}
+
+func checkUsage(cert *x509.Certificate, certs []*x509.Certificate) Check {
+	chain := []*x509.Certificate{cert}
+	for _, c := range certs {
+		if c != nil && !c.Equal(cert) {
+			chain = append(chain, c)
+		}
+	}
+
+	var details []string
+	for _, finding := range AnalyzeUsage(chain) {
+		if finding.Certificate == cert {
+			details = append(details, finding.Detail)
+		}
+	}
+	if len(details) > 0 {
+		return Check{Name: "Key usage", Status: CheckWarn, Detail: strings.Join(details, "; ")}
+	}
+	return Check{Name: "Key usage", Status: CheckPass, Detail: "consistent with the certificate's role"}
+}
+
+func checkHostname(cert *x509.Certificate, host string) Check {
+	if err := cert.VerifyHostname(host); err != nil {
+		return Check{Name: "Hostname", Status: CheckFail, Detail: err.Error()}
+	}
+	return Check{Name: "Hostname", Status: CheckPass, Detail: fmt.Sprintf("covers %s", host)}
+}
+
+// checkTrust verifies the chain from this certificate up, so an intermediate
+// gets its own answer rather than the leaf's.
+func checkTrust(cert *x509.Certificate, certs []*x509.Certificate, opts VerifyOptions) Check {
+	check := Check{Name: "Trust"}
+	chain := []*x509.Certificate{cert}
+	for _, c := range certs {
+		if c != nil && !c.Equal(cert) {
+			chain = append(chain, c)
+		}
+	}
+	// The hostname belongs to the leaf and is checked on its own line.
+	opts.DNSName = ""
+	result, err := verifyChain(chain, opts)
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		return check
+	}
+	switch result.Level {
+	case TrustAnchored:
+		check.Status = CheckPass
+		check.Detail = fmt.Sprintf("anchored at %s in the system trust store", result.Anchor)
+	case TrustSelfAnchored:
+		check.Status = CheckWarn
+		check.Detail = fmt.Sprintf("links up to %s, which is not in the system trust store", result.Anchor)
+	default:
+		check.Status = CheckFail
+		check.Detail = "the chain does not verify"
+		if result.Err != nil {
+			check.Detail = result.Err.Error()
+		}
+	}
+	return check
+}
+
+// checkRevocation reports where revocation status is published. y509 does
+// not query OCSP responders or download CRLs while rendering a view.
+func checkRevocation(cert *x509.Certificate) Check {
+	check := Check{Name: "Revocation", Status: CheckSkipped}
+	var sources []string
+	if len(cert.OCSPServer) > 0 {
+		sources = append(sources, "OCSP "+strings.Join(cert.OCSPServer, ", "))
+	}
+	if len(cert.CRLDistributionPoints) > 0 {
+		sources = append(sources, "CRL "+strings.Join(cert.CRLDistributionPoints, ", "))
+	}
+	switch {
+	case len(sources) > 0:
+		check.Detail = "not checked; published via " + strings.Join(sources, "; ")
+	case IsSelfSigned(cert):
+		check.Detail = "not applicable to a self-signed certificate"
+	default:
+		check.Detail = "no OCSP responder or CRL distribution point is published"
+	}
+	return check
+}
@@ -0,0 +1,63 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+// checkNamed finds a check by name, failing the test if it is missing.
+func checkNamed(t *testing.T, checks []Check, name string) Check {
+	t.Helper()
+	for _, check := range checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("no %q check in %+v", name, checks)
+	return Check{}
+}
+
+func TestCheckCertificate_SoundChain(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "good.example", false, root, rootKey)
+	certs := []*x509.Certificate{leaf, root}
+
+	checks := CheckCertificate(leaf, certs, VerifyOptions{DNSName: "good.example", ExpiryWarningDays: -1})
+	for name, want := range map[string]CheckStatus{
+		"Signature":   CheckPass,
+		"Constraints": CheckPass,
+		"Hostname":    CheckPass,
+		"Trust":       CheckWarn, // self-anchored: the test root is not in the store
+		"Revocation":  CheckSkipped,
+	} {
+		if got := checkNamed(t, checks, name); got.Status != want {
+			t.Errorf("%s = %v (%s), want %v", name, got.Status, got.Detail, want)
+		}
+	}
+
+	rootChecks := CheckCertificate(root, certs, VerifyOptions{})
+	if got := checkNamed(t, rootChecks, "Signature"); got.Status != CheckPass {
+		t.Errorf("root Signature = %v (%s)", got.Status, got.Detail)
+	}
+	if got := checkNamed(t, rootChecks, "Constraints"); got.Status != CheckSkipped {
+		t.Errorf("root Constraints = %v, want skipped", got.Status)
+	}
+}
+
+func TestCheckCertificate_Failures(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	notCA, notCAKey := issue(t, "not-a-ca.example", false, root, rootKey)
+	leaf, _ := issue(t, "under.example", false, notCA, notCAKey)
+
+	checks := CheckCertificate(leaf, []*x509.Certificate{leaf, notCA, root}, VerifyOptions{DNSName: "other.example"})
+	for _, name := range []string{"Constraints", "Hostname", "Trust"} {
+		if got := checkNamed(t, checks, name); got.Status != CheckFail {
+			t.Errorf("%s = %v (%s), want fail", name, got.Status, got.Detail)
+		}
+	}
+
+	orphan := CheckCertificate(leaf, []*x509.Certificate{leaf}, VerifyOptions{})
+	if got := checkNamed(t, orphan, "Signature"); got.Status != CheckFail {
+		t.Errorf("Signature without the issuer = %v, want fail", got.Status)
+	}
+}
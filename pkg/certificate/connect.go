@@ -76,11 +76,19 @@ func (r *ConnectResult) TLSVersionName() string {
 // verify is precisely what the user is trying to look at, so rejecting it at
 // the transport would defeat the purpose. Verification is a separate step, via
 // VerifyChain.
-func FetchChain(ctx context.Context, addr string, opts ConnectOptions) (*ConnectResult, error) {
+func FetchChain(ctx context.Context, addr string, opts ConnectOptions) (result *ConnectResult, err error) {
 	address, host, err := normalizeAddress(addr)
 	if err != nil {
 		return nil, err
 	}
+	start := time.Now()
+	defer func() {
+		fields := []zap.Field{zap.String("address", address)}
+		if result != nil {
+			fields = append(fields, zap.String("version", result.TLSVersionName()), zap.Int("certificates", len(result.Certificates)))
+		}
+		logRequest("TLS connection", start, err, fields...)
+	}()
 
 	// Reject an unknown protocol before dialling. Otherwise a typo in
 	// --starttls surfaces as whatever the connection happens to do first, which
@@ -171,6 +179,7 @@ func FetchChain(ctx context.Context, addr string, opts ConnectOptions) (*Connect
 			Certificate: cert,
 			Index:       i,
 			Label:       generateCertificateLabel(cert, i),
+			Source:      address,
 		}
 	}
 
@@ -0,0 +1,155 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// ConstraintError is a chain that breaks an issuer's basic constraints: a
+// certificate signed by something that is not a CA, or a CA issued deeper in
+// the chain than an ancestor's path length allows.
+//
+// Go's verifier reports both, but in words that are easy to misread as a bad
+// signature ("parent certificate cannot sign this kind of certificate"). The
+// signature is fine; the issuer was never allowed to make it.
+type ConstraintError struct {
+	// Certificate is the certificate whose issuance breaks the constraint.
+	Certificate *x509.Certificate
+	// Issuer is the certificate whose constraint was broken.
+	Issuer *x509.Certificate
+	// Detail explains the violation in a sentence.
+	Detail string
+}
+
+// Error implements error.
+func (e *ConstraintError) Error() string { return e.Detail }
+
+// CheckBasicConstraints walks a leaf-first path -- each certificate issued by
+// the next -- and reports every basic-constraints violation along it. It
+// returns nil when the path is sound.
+func CheckBasicConstraints(path []*x509.Certificate) []*ConstraintError {
+	var violations []*ConstraintError
+
+	for i := 1; i < len(path); i++ {
+		issuer, child := path[i], path[i-1]
+		if issuer == nil || child == nil {
+			continue
+		}
+
+		if !issuer.IsCA {
+			violations = append(violations, &ConstraintError{
+				Certificate: child,
+				Issuer:      issuer,
+				Detail: fmt.Sprintf("%q was signed by %q, which is not a CA "+
+					"(basicConstraints cA is false or absent)",
+					displayName(child), displayName(issuer)),
+			})
+			// The constraint that matters here is already broken; counting
+			// intermediates beneath a non-CA would only pile on.
+			continue
+		}
+
+		if !hasPathLenConstraint(issuer) {
+			continue
+		}
+		// Everything strictly between the leaf and this issuer is an
+		// intermediate it has to account for.
+		below := i - 1
+		if below <= issuer.MaxPathLen {
+			continue
+		}
+		// The first intermediate past the allowance, counting down from the
+		// constrained CA, is the one that should never have been issued.
+		offender := path[i-1-issuer.MaxPathLen]
+		violations = append(violations, &ConstraintError{
+			Certificate: offender,
+			Issuer:      issuer,
+			Detail: fmt.Sprintf("%q has pathLenConstraint %d, but %d intermediate CA(s) "+
+				"follow it; %q exceeds the allowed depth",
+				displayName(issuer), issuer.MaxPathLen, below, displayName(offender)),
+		})
+	}
+
+	return violations
+}
+
+// hasPathLenConstraint reports whether the certificate carries a path length
+// limit. A parsed certificate without one has MaxPathLen -1; a hand-built
+// template spells "no limit" as zero without MaxPathLenZero, so both have to
+// be read as unconstrained.
+func hasPathLenConstraint(cert *x509.Certificate) bool {
+	return cert.MaxPathLen > 0 || (cert.MaxPathLen == 0 && cert.MaxPathLenZero)
+}
+
+// issuerPath walks up from start through certs, following issuer names and
+// signatures, and returns the leaf-first path it finds. It stops at a
+// self-signed certificate, a missing issuer, or a loop.
+func issuerPath(start *x509.Certificate, certs []*x509.Certificate) []*x509.Certificate {
+	if start == nil {
+		return nil
+	}
+
+	bySubject := make(map[string][]*x509.Certificate, len(certs))
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		subject := cert.Subject.String()
+		bySubject[subject] = append(bySubject[subject], cert)
+	}
+
+	path := []*x509.Certificate{start}
+	visited := map[string]bool{FormatFingerprint(start): true}
+	current := start
+	for !IsSelfSigned(current) {
+		parent := signingIssuer(current, bySubject[current.Issuer.String()])
+		if parent == nil || visited[FormatFingerprint(parent)] {
+			break
+		}
+		visited[FormatFingerprint(parent)] = true
+		path = append(path, parent)
+		current = parent
+	}
+	return path
+}
+
+// signingIssuer is findIssuer without the CA check: it prefers the candidate
+// whose key verifies the child's signature, whether or not that candidate was
+// allowed to sign. CheckSignatureFrom would refuse a non-CA parent outright,
+// which hides exactly the violation CheckBasicConstraints is looking for.
+func signingIssuer(child *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	if len(candidates) == 0 {
+		return nil
+	}
+	for _, candidate := range candidates {
+		if signedBy(child, candidate) {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// signedBy reports whether parent's key verifies child's signature, ignoring
+// whether parent is permitted to issue certificates.
+func signedBy(child, parent *x509.Certificate) bool {
+	return parent.CheckSignature(child.SignatureAlgorithm, child.RawTBSCertificate, child.Signature) == nil
+}
+
+// constraintViolationsIn checks the path up from every certificate in certs
+// and indexes the violations by the fingerprint of the certificate at fault,
+// so a per-certificate view can say which one is wrong.
+func constraintViolationsIn(certs []*x509.Certificate) map[string]*ConstraintError {
+	found := make(map[string]*ConstraintError)
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		for _, violation := range CheckBasicConstraints(issuerPath(cert, certs)) {
+			fingerprint := FormatFingerprint(violation.Certificate)
+			if _, seen := found[fingerprint]; !seen {
+				found[fingerprint] = violation
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,92 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+// pathLen limits a CA to n intermediates under it, or none for n negative.
+func pathLen(n int) func(*x509.Certificate) {
+	return func(c *x509.Certificate) {
+		c.MaxPathLen, c.MaxPathLenZero = n, n == 0
+	}
+}
+
+func TestCheckBasicConstraints_SoundPath(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil, pathLen(1))
+	intermediate, intermediateKey := issue(t, "Intermediate", true, root, rootKey, pathLen(0))
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+
+	if got := CheckBasicConstraints([]*x509.Certificate{leaf, intermediate, root}); got != nil {
+		t.Errorf("sound path reported violations: %v", got)
+	}
+}
+
+// TestVerifyChain_LeafSignedByNonCA checks that a leaf issued by another leaf
+// is reported as a constraint violation, not as a bad signature.
+func TestVerifyChain_LeafSignedByNonCA(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	server, serverKey := issue(t, "server.example", false, root, rootKey)
+	impostor, _ := issue(t, "impostor.example", false, server, serverKey)
+
+	result, err := VerifyChain([]*x509.Certificate{impostor, server, root}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyChain returned an error: %v", err)
+	}
+	if result.Level != TrustBroken {
+		t.Fatalf("Level = %v, want %v", result.Level, TrustBroken)
+	}
+	if len(result.Violations) != 1 {
+		t.Fatalf("got %d violations, want 1: %v", len(result.Violations), result.Violations)
+	}
+	if !strings.Contains(result.Err.Error(), "not a CA") {
+		t.Errorf("Err = %q, want it to name the non-CA issuer", result.Err)
+	}
+	if !strings.Contains(FormatVerifyResult(result), "Basic constraints violated") {
+		t.Errorf("formatted result does not explain the violation:\n%s", FormatVerifyResult(result))
+	}
+}
+
+// TestVerifyChain_PathLenExceeded covers a root limited to issuing end-entity
+// certificates directly, with an intermediate underneath it anyway.
+func TestVerifyChain_PathLenExceeded(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil, pathLen(0))
+	intermediate, intermediateKey := issue(t, "Intermediate", true, root, rootKey, pathLen(-1))
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+
+	result, err := VerifyChain([]*x509.Certificate{leaf, intermediate, root}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyChain returned an error: %v", err)
+	}
+	if result.Level != TrustBroken {
+		t.Fatalf("Level = %v, want %v", result.Level, TrustBroken)
+	}
+	if len(result.Violations) != 1 {
+		t.Fatalf("got %d violations, want 1", len(result.Violations))
+	}
+	violation := result.Violations[0]
+	if !violation.Certificate.Equal(intermediate) || !violation.Issuer.Equal(root) {
+		t.Errorf("violation blames %q under %q, want the intermediate under the root",
+			violation.Certificate.Subject.CommonName, violation.Issuer.Subject.CommonName)
+	}
+	if !strings.Contains(violation.Detail, "pathLenConstraint 0") {
+		t.Errorf("Detail = %q, want it to quote the limit", violation.Detail)
+	}
+}
+
+func TestValidateChainLinks_ConstraintViolation(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	server, serverKey := issue(t, "server.example", false, root, rootKey)
+	impostor, _ := issue(t, "impostor.example", false, server, serverKey)
+
+	infos := []*Info{{Certificate: impostor}, {Certificate: server}, {Certificate: root}}
+	ValidateChainLinks(infos)
+
+	if infos[0].ValidationStatus != StatusConstraintViolation {
+		t.Errorf("impostor status = %v, want StatusConstraintViolation", infos[0].ValidationStatus)
+	}
+	if infos[1].ValidationStatus != StatusGood {
+		t.Errorf("server status = %v, want StatusGood (%v)", infos[1].ValidationStatus, infos[1].ValidationError)
+	}
+}
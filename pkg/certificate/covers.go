@@ -0,0 +1,118 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"net"
+	"strings"
+)
+
+// NameMatch says how a certificate covers a hostname, if it does.
+type NameMatch struct {
+	// Kind is "exact", "wildcard", "ip" or "common name", or empty when the
+	// name is not covered.
+	Kind string
+	// Entry is the SAN (or, for a legacy certificate, the CN) that matched.
+	Entry string
+}
+
+// Covered reports whether the name is covered.
+func (n NameMatch) Covered() bool { return n.Kind != "" }
+
+// CoversName reports whether the certificate would be accepted for host, and
+// which of its names matched.
+//
+// The rules are the ones crypto/x509 applies: names compare case-insensitively
+// and ignore a trailing dot, a wildcard stands for exactly one leftmost label,
+// and an IP address only ever matches an IP SAN. The common name is consulted
+// only when the certificate has no SANs at all, which Go itself no longer
+// accepts; it is reported so a legacy certificate can be told apart from one
+// that simply lacks the name.
+func CoversName(c *x509.Certificate, host string) NameMatch {
+	if c == nil {
+		return NameMatch{}
+	}
+
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		for _, candidate := range c.IPAddresses {
+			if candidate.Equal(ip) {
+				return NameMatch{Kind: "ip", Entry: candidate.String()}
+			}
+		}
+		return NameMatch{}
+	}
+
+	host = strings.ToLower(host)
+	for _, name := range c.DNSNames {
+		pattern := strings.ToLower(strings.TrimSuffix(name, "."))
+		if pattern == host {
+			return NameMatch{Kind: "exact", Entry: name}
+		}
+	}
+	for _, name := range c.DNSNames {
+		if matchesWildcard(strings.ToLower(strings.TrimSuffix(name, ".")), host) {
+			return NameMatch{Kind: "wildcard", Entry: name}
+		}
+	}
+
+	if len(c.DNSNames) == 0 && len(c.IPAddresses) == 0 && len(c.EmailAddresses) == 0 && len(c.URIs) == 0 {
+		cn := strings.ToLower(strings.TrimSuffix(c.Subject.CommonName, "."))
+		if cn != "" && (cn == host || matchesWildcard(cn, host)) {
+			return NameMatch{Kind: "common name", Entry: c.Subject.CommonName}
+		}
+	}
+	return NameMatch{}
+}
+
+// matchesWildcard reports whether a "*.example.com" pattern covers host. The
+// wildcard has to be the whole leftmost label and covers exactly one label,
+// so it matches "www.example.com" but neither "example.com" nor
+// "a.b.example.com".
+func matchesWildcard(pattern, host string) bool {
+	suffix, ok := strings.CutPrefix(pattern, "*.")
+	if !ok || suffix == "" {
+		return false
+	}
+	label, rest, ok := strings.Cut(host, ".")
+	return ok && label != "" && rest == suffix
+}
+
+// SANsMatching lists the DNS and IP SANs of the certificate that pattern
+// picks out, to audit which certificates cover a zone. The pattern is a
+// name, matched exactly or by a wildcard SAN covering it; a wildcard, which
+// takes in the names one label below it and the same wildcard; an IP
+// address; or a CIDR block, which takes in every IP SAN inside it. The
+// common name is not looked at.
+func SANsMatching(c *x509.Certificate, pattern string) []string {
+	if c == nil {
+		return nil
+	}
+	pattern = strings.TrimSuffix(strings.TrimSpace(pattern), ".")
+
+	var matched []string
+	if _, block, err := net.ParseCIDR(pattern); err == nil {
+		for _, ip := range c.IPAddresses {
+			if block.Contains(ip) {
+				matched = append(matched, ip.String())
+			}
+		}
+		return matched
+	}
+	if ip := net.ParseIP(strings.Trim(pattern, "[]")); ip != nil {
+		for _, candidate := range c.IPAddresses {
+			if candidate.Equal(ip) {
+				matched = append(matched, candidate.String())
+			}
+		}
+		return matched
+	}
+
+	pattern = strings.ToLower(pattern)
+	for _, name := range c.DNSNames {
+		san := strings.ToLower(strings.TrimSuffix(name, "."))
+		if san == pattern || matchesWildcard(pattern, san) || matchesWildcard(san, pattern) {
+			matched = append(matched, name)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,92 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCoversName(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"example.com", "*.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("192.0.2.1")},
+	}
+	cert := generateCertificate(template, template, &key.PublicKey, key)
+
+	tests := []struct {
+		host string
+		kind string
+	}{
+		{"example.com", "exact"},
+		{"EXAMPLE.com.", "exact"},
+		{"www.example.com", "wildcard"},
+		{"a.b.example.com", ""},
+		{"example.org", ""},
+		{"192.0.2.1", "ip"},
+		{"[192.0.2.1]", "ip"},
+		{"192.0.2.2", ""},
+	}
+	for _, tt := range tests {
+		if got := CoversName(cert, tt.host); got.Kind != tt.kind {
+			t.Errorf("CoversName(%q) = %+v, want kind %q", tt.host, got, tt.kind)
+		}
+	}
+
+	legacy := generateCertificate(&x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: "legacy.example"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, template, &key.PublicKey, key)
+	if got := CoversName(legacy, "legacy.example"); got.Kind != "common name" {
+		t.Errorf("legacy CN match = %+v", got)
+	}
+}
+
+func TestSANsMatching(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: "internal.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"api.internal.example.com", "*.db.internal.example.com", "a.b.internal.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("10.1.2.3")},
+	}
+	cert := generateCertificate(template, template, &key.PublicKey, key)
+
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{"*.internal.example.com", 1},
+		{"API.internal.example.com.", 1},
+		{"pg.db.internal.example.com", 1},
+		{"*.db.internal.example.com", 1},
+		{"internal.example.com", 0},
+		{"10.1.2.3", 1},
+		{"10.0.0.0/8", 1},
+		{"192.168.0.0/16", 0},
+	}
+	for _, tt := range tests {
+		if got := SANsMatching(cert, tt.pattern); len(got) != tt.want {
+			t.Errorf("SANsMatching(%q) = %q, want %d", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package certificate
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TLSAUsages, TLSASelectors and TLSAMatchingTypes name the values of the
+// three TLSA fields, indexed by value, with the mnemonics RFC 7218 gives
+// them.
+var (
+	TLSAUsages        = []string{"PKIX-TA", "PKIX-EE", "DANE-TA", "DANE-EE"}
+	TLSASelectors     = []string{"Cert", "SPKI"}
+	TLSAMatchingTypes = []string{"Full", "SHA2-256", "SHA2-512"}
+)
+
+// TLSAProtocols are the transports a TLSA owner name can be for.
+var TLSAProtocols = []string{"tcp", "udp", "sctp"}
+
+// TLSA is the data of a DANE TLSA record (RFC 6698): how the certificate is
+// to be used, which part of it is matched, how, and what it is matched
+// against.
+type TLSA struct {
+	Usage        uint8
+	Selector     uint8
+	MatchingType uint8
+	Data         []byte
+}
+
+// NewTLSA builds the TLSA record that pins cert: selector 0 takes the whole
+// certificate and 1 its SubjectPublicKeyInfo, which a renewal with the same
+// key leaves alone; matching type 0 carries that as it is, 1 its SHA-256 and
+// 2 its SHA-512.
+func NewTLSA(cert *x509.Certificate, usage, selector, matchingType uint8) (*TLSA, error) {
+	if int(usage) >= len(TLSAUsages) {
+		return nil, fmt.Errorf("unknown TLSA usage %d (0 to %d)", usage, len(TLSAUsages)-1)
+	}
+
+	var data []byte
+	switch selector {
+	case 0:
+		data = cert.Raw
+	case 1:
+		data = cert.RawSubjectPublicKeyInfo
+	default:
+		return nil, fmt.Errorf("unknown TLSA selector %d (0 or 1)", selector)
+	}
+
+	switch matchingType {
+	case 0:
+		data = slices.Clone(data)
+	case 1:
+		sum := sha256.Sum256(data)
+		data = sum[:]
+	case 2:
+		sum := sha512.Sum512(data)
+		data = sum[:]
+	default:
+		return nil, fmt.Errorf("unknown TLSA matching type %d (0, 1 or 2)", matchingType)
+	}
+	return &TLSA{Usage: usage, Selector: selector, MatchingType: matchingType, Data: data}, nil
+}
+
+// String renders the record data as a zone file holds it: "3 1 1 <hex>".
+func (t *TLSA) String() string {
+	return fmt.Sprintf("%d %d %d %s", t.Usage, t.Selector, t.MatchingType, hex.EncodeToString(t.Data))
+}
+
+// TrustAnchor reports whether the usage pins a CA rather than the server's
+// own certificate.
+func (t *TLSA) TrustAnchor() bool {
+	return t.Usage == 0 || t.Usage == 2
+}
+
+// ParseTLSAField reads a TLSA field given as its number or its mnemonic,
+// in any case, from names.
+func ParseTLSAField(s string, names []string) (uint8, error) {
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil && int(n) < len(names) {
+		return uint8(n), nil
+	}
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return uint8(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not one of 0-%d or %s", s, len(names)-1, strings.Join(names, ", "))
+}
+
+// TLSAOwner is the name a TLSA record for host's port goes under:
+// "_443._tcp.mail.example.com.".
+func TLSAOwner(host string, port int, proto string) (string, error) {
+	proto = strings.ToLower(proto)
+	if !slices.Contains(TLSAProtocols, proto) {
+		return "", fmt.Errorf("unknown protocol %q (one of %s)", proto, strings.Join(TLSAProtocols, ", "))
+	}
+	if port < 1 || port > 65535 {
+		return "", fmt.Errorf("port %d out of range", port)
+	}
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("no host to name the record for")
+	}
+	return fmt.Sprintf("_%d._%s.%s.", port, proto, host), nil
+}
@@ -0,0 +1,74 @@
+package certificate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewTLSA(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+
+	record, err := NewTLSA(leaf, 3, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spki := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	if got, want := record.String(), "3 1 1 "+hex.EncodeToString(spki[:]); got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	if record.TrustAnchor() {
+		t.Error("DANE-EE was taken for a trust anchor")
+	}
+
+	full, err := NewTLSA(root, 2, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(full.Data) != string(root.Raw) || !full.TrustAnchor() {
+		t.Error("2 0 0 did not carry the whole certificate")
+	}
+	if sha512, _ := NewTLSA(leaf, 3, 0, 2); len(sha512.Data) != 64 {
+		t.Errorf("SHA2-512 data is %d bytes, want 64", len(sha512.Data))
+	}
+
+	for _, bad := range [][3]uint8{{4, 1, 1}, {3, 2, 1}, {3, 1, 3}} {
+		if _, err := NewTLSA(leaf, bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("%v was accepted", bad)
+		}
+	}
+}
+
+func TestParseTLSAField(t *testing.T) {
+	for input, want := range map[string]uint8{"3": 3, "dane-ee": 3, "PKIX-TA": 0} {
+		got, err := ParseTLSAField(input, TLSAUsages)
+		if err != nil || got != want {
+			t.Errorf("ParseTLSAField(%q) = %d, %v, want %d", input, got, err, want)
+		}
+	}
+	if got, _ := ParseTLSAField("spki", TLSASelectors); got != 1 {
+		t.Errorf("spki = %d, want 1", got)
+	}
+	for _, bad := range []string{"4", "-1", "EE"} {
+		if _, err := ParseTLSAField(bad, TLSAUsages); err == nil {
+			t.Errorf("%q was accepted", bad)
+		}
+	}
+}
+
+func TestTLSAOwner(t *testing.T) {
+	got, err := TLSAOwner("mail.example.com.", 25, "TCP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "_25._tcp.mail.example.com."; got != want {
+		t.Errorf("TLSAOwner = %q, want %q", got, want)
+	}
+	if _, err := TLSAOwner("example.com", 443, "quic"); err == nil {
+		t.Error("an unknown protocol was accepted")
+	}
+	if _, err := TLSAOwner("example.com", 0, "tcp"); err == nil {
+		t.Error("port 0 was accepted")
+	}
+}
@@ -0,0 +1,48 @@
+package certificate
+
+import "testing"
+
+func TestDiffCertificates(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	old, _ := issue(t, "old.example", false, root, rootKey)
+	renewed, _ := issue(t, "new.example", false, root, rootKey)
+
+	byField := make(map[string]FieldDiff)
+	for _, d := range DiffCertificates(old, renewed) {
+		byField[d.Field] = d
+	}
+
+	for field, changed := range map[string]bool{
+		"Subject":                  true,
+		"Issuer":                   false,
+		"SANs":                     true,
+		"Key SHA-256":              true,
+		"Signature":                false,
+		"Key Usage":                false,
+		"Authority Key Identifier": false,
+		"Fingerprint":              true,
+	} {
+		d, ok := byField[field]
+		if !ok {
+			t.Errorf("no %s row", field)
+			continue
+		}
+		if d.Changed() != changed {
+			t.Errorf("%s: changed = %v, want %v (%q vs %q)", field, d.Changed(), changed, d.A, d.B)
+		}
+	}
+	if d := byField["SANs"]; d.A != "DNS: old.example" || d.B != "DNS: new.example" {
+		t.Errorf("SANs = %q vs %q", d.A, d.B)
+	}
+
+	// An extension only one side carries shows as missing on the other.
+	for _, d := range DiffCertificates(root, old) {
+		if d.Field == "Extended Key Usage" {
+			if d.A != "" || d.B != "serverAuth" {
+				t.Errorf("Extended Key Usage = %q vs %q, want missing vs serverAuth", d.A, d.B)
+			}
+			return
+		}
+	}
+	t.Error("no Extended Key Usage row when one certificate carries it")
+}
@@ -0,0 +1,222 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// extensionNames are the extensions a TLS certificate actually carries, by
+// dotted OID.
+var extensionNames = map[string]string{
+	"2.5.29.14":               "Subject Key Identifier",
+	"2.5.29.15":               "Key Usage",
+	"2.5.29.17":               "Subject Alternative Name",
+	"2.5.29.18":               "Issuer Alternative Name",
+	"2.5.29.19":               "Basic Constraints",
+	"2.5.29.30":               "Name Constraints",
+	"2.5.29.31":               "CRL Distribution Points",
+	"2.5.29.32":               "Certificate Policies",
+	"2.5.29.33":               "Policy Mappings",
+	"2.5.29.35":               "Authority Key Identifier",
+	"2.5.29.36":               "Policy Constraints",
+	"2.5.29.37":               "Extended Key Usage",
+	"2.5.29.54":               "Inhibit anyPolicy",
+	"1.3.6.1.5.5.7.1.1":       "Authority Information Access",
+	"1.3.6.1.5.5.7.1.11":      "Subject Information Access",
+	"1.3.6.1.5.5.7.1.24":      "TLS Feature",
+	"1.3.6.1.4.1.11129.2.4.2": "Embedded SCTs",
+	"1.3.6.1.4.1.11129.2.4.3": "CT Precertificate Poison",
+	"1.3.6.1.5.5.7.48.1.5":    "OCSP No Check",
+}
+
+// Extension is one X.509 extension, decoded for display.
+type Extension struct {
+	// OID is the extension identifier in dotted form.
+	OID string
+	// Name is the extension's usual name, empty when it is not one y509
+	// knows.
+	Name string
+	// Critical is the extension's criticality flag: a client that does not
+	// understand a critical extension must reject the certificate.
+	Critical bool
+	// Values is the decoded value, one line each, as "Label: value" where a
+	// label helps. An extension y509 cannot decode is shown as hex.
+	Values []string
+}
+
+// DecodeExtensions lists every extension in the certificate, in the order it
+// carries them, with a decoded value where the extension is known and a hex
+// dump where it is not.
+func DecodeExtensions(cert *x509.Certificate) []Extension {
+	extensions := make([]Extension, 0, len(cert.Extensions))
+	for _, ext := range cert.Extensions {
+		oid := ext.Id.String()
+		values, err := decodeExtension(cert, oid, ext.Value)
+		values = slices.DeleteFunc(values, func(v string) bool { return v == "" })
+		if err != nil || len(values) == 0 {
+			// A malformed value is still worth seeing; the bytes are the
+			// evidence.
+			values = []string{"Hex: " + hexBytes(ext.Value)}
+		}
+		extensions = append(extensions, Extension{
+			OID:      oid,
+			Name:     extensionNames[oid],
+			Critical: ext.Critical,
+			Values:   values,
+		})
+	}
+	return extensions
+}
+
+// decodeExtension decodes one extension value. Most of the work has already
+// been done by the x509 package, so the parsed fields are read back rather
+// than the DER parsed a second time.
+func decodeExtension(cert *x509.Certificate, oid string, value []byte) ([]string, error) {
+	switch oid {
+	case "2.5.29.14":
+		return []string{"Key ID: " + hexBytes(cert.SubjectKeyId)}, nil
+	case "2.5.29.35":
+		return []string{"Key ID: " + hexBytes(cert.AuthorityKeyId)}, nil
+	case "2.5.29.15":
+		return []string{FormatKeyUsage(cert)}, nil
+	case "2.5.29.37":
+		return []string{FormatExtKeyUsage(cert)}, nil
+	case "2.5.29.19":
+		if !cert.IsCA {
+			return []string{"CA: false"}, nil
+		}
+		if hasPathLenConstraint(cert) {
+			return []string{fmt.Sprintf("CA: true, path length: %d", cert.MaxPathLen)}, nil
+		}
+		return []string{"CA: true"}, nil
+	case "2.5.29.17":
+		return alternativeNames(cert), nil
+	case "2.5.29.30":
+		return nameConstraints(cert), nil
+	case "2.5.29.31":
+		values := make([]string, len(cert.CRLDistributionPoints))
+		for i, url := range cert.CRLDistributionPoints {
+			values[i] = "URI: " + url
+		}
+		return values, nil
+	case "1.3.6.1.5.5.7.1.1":
+		var values []string
+		for _, url := range cert.OCSPServer {
+			values = append(values, "OCSP: "+url)
+		}
+		for _, url := range cert.IssuingCertificateURL {
+			values = append(values, "CA Issuers: "+url)
+		}
+		return values, nil
+	case "2.5.29.32":
+		policies, err := ParsePolicies(cert)
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		for _, policy := range policies {
+			values = append(values, "Policy: "+FormatPolicy(policy))
+			for _, cps := range policy.CPS {
+				values = append(values, "CPS: "+cps)
+			}
+			for _, notice := range policy.UserNotices {
+				values = append(values, "Notice: "+notice)
+			}
+		}
+		return values, nil
+	case "1.3.6.1.4.1.11129.2.4.2":
+		scts, err := ParseSCTs(cert)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, len(scts))
+		for i, sct := range scts {
+			values[i] = fmt.Sprintf("SCT %d: log %s, %s", i+1,
+				hex.EncodeToString(sct.LogID[:4]), FormatTime(sct.Timestamp))
+		}
+		return values, nil
+	case "1.3.6.1.4.1.11129.2.4.3":
+		return []string{"precertificate, not for use in TLS"}, nil
+	case "1.3.6.1.5.5.7.48.1.5":
+		return []string{"OCSP responses signed by this key are not checked for revocation"}, nil
+	case "1.3.6.1.5.5.7.1.24":
+		return tlsFeatures(value)
+	}
+	return nil, nil
+}
+
+// alternativeNames lists the subject alternative names by type.
+func alternativeNames(cert *x509.Certificate) []string {
+	var values []string
+	for _, name := range cert.DNSNames {
+		values = append(values, "DNS: "+name)
+	}
+	for _, ip := range cert.IPAddresses {
+		values = append(values, "IP: "+ip.String())
+	}
+	for _, email := range cert.EmailAddresses {
+		values = append(values, "Email: "+email)
+	}
+	for _, uri := range cert.URIs {
+		values = append(values, "URI: "+uri.String())
+	}
+	return values
+}
+
+// nameConstraints lists the permitted and excluded subtrees.
+func nameConstraints(cert *x509.Certificate) []string {
+	var values []string
+	add := func(label string, names []string) {
+		for _, name := range names {
+			values = append(values, label+": "+name)
+		}
+	}
+	add("Permitted DNS", cert.PermittedDNSDomains)
+	add("Excluded DNS", cert.ExcludedDNSDomains)
+	for _, ip := range cert.PermittedIPRanges {
+		values = append(values, "Permitted IP: "+ip.String())
+	}
+	for _, ip := range cert.ExcludedIPRanges {
+		values = append(values, "Excluded IP: "+ip.String())
+	}
+	add("Permitted Email", cert.PermittedEmailAddresses)
+	add("Excluded Email", cert.ExcludedEmailAddresses)
+	add("Permitted URI", cert.PermittedURIDomains)
+	add("Excluded URI", cert.ExcludedURIDomains)
+	return values
+}
+
+// tlsFeatures decodes the TLS feature extension (RFC 7633), whose only use
+// in practice is OCSP must-staple.
+func tlsFeatures(value []byte) ([]string, error) {
+	var features []int
+	if rest, err := asn1.Unmarshal(value, &features); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("malformed TLS feature extension")
+	}
+	values := make([]string, len(features))
+	for i, feature := range features {
+		switch feature {
+		case 5:
+			values[i] = "status_request (OCSP must-staple)"
+		case 17:
+			values[i] = "status_request_v2"
+		default:
+			values[i] = fmt.Sprintf("extension %d", feature)
+		}
+	}
+	return values, nil
+}
+
+// hexBytes renders bytes as colon-separated upper-case hex, the way key
+// identifiers are usually written.
+func hexBytes(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
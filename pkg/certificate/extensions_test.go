@@ -0,0 +1,89 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDecodeExtensions(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustStaple, err := asn1.Marshal([]int{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(t),
+		Subject:               pkix.Name{CommonName: "leaf.example"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"leaf.example"},
+		CRLDistributionPoints: []string{"http://crl.example/root.crl"},
+		OCSPServer:            []string{"http://ocsp.example"},
+		IssuingCertificateURL: []string{"http://ca.example/root.cer"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}, Value: mustStaple},
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Critical: true, Value: []byte{0xde, 0xad}},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, root, &key.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byOID := make(map[string]Extension)
+	for _, ext := range DecodeExtensions(cert) {
+		byOID[ext.OID] = ext
+	}
+	if len(byOID) != len(cert.Extensions) {
+		t.Errorf("decoded %d extensions, the certificate carries %d", len(byOID), len(cert.Extensions))
+	}
+
+	tests := []struct {
+		oid, name, value string
+	}{
+		{"2.5.29.15", "Key Usage", "digitalSignature"},
+		{"2.5.29.19", "Basic Constraints", "CA: false"},
+		{"2.5.29.17", "Subject Alternative Name", "DNS: leaf.example"},
+		{"2.5.29.31", "CRL Distribution Points", "URI: http://crl.example/root.crl"},
+		{"1.3.6.1.5.5.7.1.1", "Authority Information Access", "OCSP: http://ocsp.example"},
+		{"1.3.6.1.5.5.7.1.1", "Authority Information Access", "CA Issuers: http://ca.example/root.cer"},
+		{"2.5.29.35", "Authority Key Identifier", "Key ID: " + hexBytes(root.SubjectKeyId)},
+		{"1.3.6.1.5.5.7.1.24", "TLS Feature", "status_request (OCSP must-staple)"},
+		{"1.2.3.4", "", "Hex: DE:AD"},
+	}
+	for _, tt := range tests {
+		ext, ok := byOID[tt.oid]
+		if !ok {
+			t.Errorf("%s not decoded", tt.oid)
+			continue
+		}
+		if ext.Name != tt.name {
+			t.Errorf("%s: Name = %q, want %q", tt.oid, ext.Name, tt.name)
+		}
+		if !slices.Contains(ext.Values, tt.value) {
+			t.Errorf("%s: Values = %q, want it to contain %q", tt.oid, ext.Values, tt.value)
+		}
+	}
+	if !byOID["1.2.3.4"].Critical {
+		t.Error("the unknown extension should be marked critical")
+	}
+}
@@ -0,0 +1,53 @@
+package certificate
+
+import (
+	"crypto/md5"  //nolint:gosec // fingerprints only, as older tooling prints them
+	"crypto/sha1" //nolint:gosec // fingerprints only, as older tooling prints them
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// FingerprintAlgorithms are the digests Fingerprint takes.
+var FingerprintAlgorithms = []string{"sha256", "sha1", "md5", "spki-sha256"}
+
+// Fingerprint digests a certificate: sha256, sha1 or md5 over the whole
+// DER, as browsers and openssl x509 -fingerprint show it, or spki-sha256
+// over the SubjectPublicKeyInfo alone, the hash that key pinning uses. It
+// survives a renewal with the same key, where the others change.
+func Fingerprint(cert *x509.Certificate, algo string) ([]byte, error) {
+	switch strings.ToLower(algo) {
+	case "sha256":
+		sum := sha256.Sum256(cert.Raw)
+		return sum[:], nil
+	case "sha1":
+		sum := sha1.Sum(cert.Raw) //nolint:gosec // a fingerprint, not a signature
+		return sum[:], nil
+	case "md5":
+		sum := md5.Sum(cert.Raw) //nolint:gosec // a fingerprint, not a signature
+		return sum[:], nil
+	case "spki-sha256":
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		return sum[:], nil
+	}
+	return nil, fmt.Errorf("unknown fingerprint algorithm %q (one of %s)", algo, strings.Join(FingerprintAlgorithms, ", "))
+}
+
+// ColonHex writes a digest as upper-case hex pairs between colons, as
+// openssl does: "AB:CD:EF".
+func ColonHex(digest []byte) string {
+	pairs := make([]string, len(digest))
+	for i, b := range digest {
+		pairs[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(pairs, ":")
+}
+
+// SPKIPin is the base64 SHA-256 of a certificate's SubjectPublicKeyInfo,
+// the value HPKP's pin-sha256 and curl's --pinnedpubkey sha256// take.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
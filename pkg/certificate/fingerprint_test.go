@@ -0,0 +1,49 @@
+package certificate
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+
+	sha256, err := Fingerprint(leaf, "SHA256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(sha256); got != FormatFingerprint(leaf) {
+		t.Errorf("sha256 = %s, want %s", got, FormatFingerprint(leaf))
+	}
+
+	for algo, size := range map[string]int{"sha1": 20, "md5": 16, "spki-sha256": 32} {
+		digest, err := Fingerprint(leaf, algo)
+		if err != nil {
+			t.Fatalf("%s: %v", algo, err)
+		}
+		if len(digest) != size {
+			t.Errorf("%s is %d bytes, want %d", algo, len(digest), size)
+		}
+	}
+
+	// The SPKI hash is of the key alone, not the whole certificate.
+	spki, _ := Fingerprint(leaf, "spki-sha256")
+	if string(spki) == string(sha256) {
+		t.Error("spki-sha256 hashed the whole certificate")
+	}
+
+	if _, err := Fingerprint(leaf, "sha512"); err == nil {
+		t.Error("an unknown algorithm was accepted")
+	}
+
+	pin, err := base64.StdEncoding.DecodeString(SPKIPin(leaf))
+	if err != nil || string(pin) != string(spki) {
+		t.Errorf("SPKIPin = %q, not the base64 of the SPKI hash", SPKIPin(leaf))
+	}
+
+	if got, want := ColonHex([]byte{0xab, 0x01, 0xff}), "AB:01:FF"; got != want {
+		t.Errorf("ColonHex = %q, want %q", got, want)
+	}
+}
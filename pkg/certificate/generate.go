@@ -0,0 +1,331 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeyTypes are the kinds of key GenerateKey makes, the first the default.
+var KeyTypes = []string{"ecdsa-p256", "ecdsa-p384", "ed25519", "rsa-2048", "rsa-3072", "rsa-4096"}
+
+// GenerateKey makes a new private key of one of the KeyTypes.
+func GenerateKey(keyType string) (crypto.Signer, error) {
+	switch strings.ToLower(keyType) {
+	case "ecdsa-p256", "ec", "ecdsa":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ecdsa-p384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case "rsa-2048", "rsa":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "rsa-3072":
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case "rsa-4096":
+		return rsa.GenerateKey(rand.Reader, 4096)
+	default:
+		return nil, fmt.Errorf("unknown key type %q (one of %s)", keyType, strings.Join(KeyTypes, ", "))
+	}
+}
+
+// EncodePrivateKey writes key as an unencrypted PKCS#8 PEM block, which
+// OpenSSL and every TLS server read.
+func EncodePrivateKey(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// SANs are subject alternative names, as a request or certificate carries
+// them.
+type SANs struct {
+	DNSNames       []string
+	IPAddresses    []net.IP
+	EmailAddresses []string
+	URIs           []*url.URL
+}
+
+// ParseSANs reads subject alternative names written "dns:api.example.com",
+// "ip:10.0.0.1", "email:ops@example.com" or "uri:spiffe://example.org/api".
+// A name without a prefix is taken for an IP address if it parses as one,
+// a URI if it has a scheme and "://", an email address if it has an @, and a
+// DNS name otherwise. Any other prefix is an error, a typo like "dsn:" being
+// no part of a DNS name.
+func ParseSANs(names []string) (SANs, error) {
+	var sans SANs
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		kind, value, found := strings.Cut(name, ":")
+		if !found || !isSANKind(kind) {
+			prefix := kind
+			kind, value = guessSANKind(name), name
+			if found && kind != "ip" && kind != "uri" {
+				return SANs{}, fmt.Errorf("unknown kind %q in SAN %q (one of dns, ip, email, uri)", prefix, name)
+			}
+		}
+		switch strings.ToLower(kind) {
+		case "dns":
+			sans.DNSNames = append(sans.DNSNames, value)
+		case "ip":
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return SANs{}, fmt.Errorf("SAN %q: not an IP address", name)
+			}
+			sans.IPAddresses = append(sans.IPAddresses, ip)
+		case "email":
+			sans.EmailAddresses = append(sans.EmailAddresses, value)
+		case "uri":
+			uri, err := url.Parse(value)
+			if err != nil || uri.Scheme == "" {
+				return SANs{}, fmt.Errorf("SAN %q: not an absolute URI", name)
+			}
+			sans.URIs = append(sans.URIs, uri)
+		}
+	}
+	return sans, nil
+}
+
+// isSANKind reports whether kind is one of ParseSANs' prefixes.
+func isSANKind(kind string) bool {
+	switch strings.ToLower(kind) {
+	case "dns", "ip", "email", "uri":
+		return true
+	}
+	return false
+}
+
+// guessSANKind is the kind of a name given without a prefix.
+func guessSANKind(name string) string {
+	switch {
+	case net.ParseIP(name) != nil:
+		return "ip"
+	case isURI(name):
+		return "uri"
+	case strings.Contains(name, "@"):
+		return "email"
+	default:
+		return "dns"
+	}
+}
+
+// isURI reports whether name is an absolute URI with an authority, as
+// spiffe://example.org/api is. "foo:bar" parses as a URI too, but is far more
+// likely a mistyped prefix.
+func isURI(name string) bool {
+	uri, err := url.Parse(name)
+	return err == nil && uri.Scheme != "" && strings.Contains(name, "://")
+}
+
+// Empty reports whether there are no names at all.
+func (s SANs) Empty() bool {
+	return len(s.DNSNames)+len(s.IPAddresses)+len(s.EmailAddresses)+len(s.URIs) == 0
+}
+
+// CreateCSR makes a PKCS#10 certificate signing request for subject and
+// sans, signed with key, and returns it as PEM.
+func CreateCSR(key crypto.Signer, subject pkix.Name, sans SANs) ([]byte, error) {
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        subject,
+		DNSNames:       sans.DNSNames,
+		IPAddresses:    sans.IPAddresses,
+		EmailAddresses: sans.EmailAddresses,
+		URIs:           sans.URIs,
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// GenerateOptions are the fields of a certificate to make. Those left at
+// their zero value get what a TLS server certificate, or a CA with IsCA,
+// would usually have.
+type GenerateOptions struct {
+	Subject pkix.Name
+	SANs    SANs
+	// SerialNumber is the serial; nil draws a random 128-bit one.
+	SerialNumber *big.Int
+	// NotBefore is the start of the validity period; zero is now.
+	NotBefore time.Time
+	// Days is the length of the validity period; zero is a year.
+	Days int
+	// IsCA makes a CA certificate, with PathLen as its path length
+	// constraint; a negative PathLen leaves it unconstrained.
+	IsCA    bool
+	PathLen int
+	// KeyUsage zero is keyCertSign and cRLSign for a CA, and
+	// digitalSignature, with keyEncipherment for an RSA key, otherwise.
+	KeyUsage x509.KeyUsage
+	// ExtKeyUsage nil is none for a CA, and serverAuth otherwise.
+	ExtKeyUsage []x509.ExtKeyUsage
+}
+
+// GenerateSelfSignedCert makes a certificate for key signed with key itself.
+func GenerateSelfSignedCert(key crypto.Signer, opts GenerateOptions) (*x509.Certificate, error) {
+	return IssueCertificate(key, opts, nil, nil)
+}
+
+// IssueCertificate makes a certificate for key issued by issuer and signed
+// with issuerKey, or self-signed when issuer is nil.
+func IssueCertificate(key crypto.Signer, opts GenerateOptions, issuer *x509.Certificate, issuerKey crypto.Signer) (*x509.Certificate, error) {
+	serial := opts.SerialNumber
+	if serial == nil {
+		var err error
+		if serial, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+			return nil, fmt.Errorf("failed to draw a serial number: %w", err)
+		}
+	}
+	notBefore := opts.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+	days := opts.Days
+	if days == 0 {
+		days = 365
+	}
+	if days < 0 {
+		return nil, fmt.Errorf("a certificate cannot be valid for %d days", days)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               opts.Subject,
+		NotBefore:             notBefore.UTC().Truncate(time.Second),
+		NotAfter:              notBefore.UTC().Truncate(time.Second).AddDate(0, 0, days),
+		DNSNames:              opts.SANs.DNSNames,
+		IPAddresses:           opts.SANs.IPAddresses,
+		EmailAddresses:        opts.SANs.EmailAddresses,
+		URIs:                  opts.SANs.URIs,
+		BasicConstraintsValid: true,
+		IsCA:                  opts.IsCA,
+		KeyUsage:              opts.KeyUsage,
+		ExtKeyUsage:           opts.ExtKeyUsage,
+	}
+	if opts.IsCA && opts.PathLen >= 0 {
+		template.MaxPathLen = opts.PathLen
+		template.MaxPathLenZero = opts.PathLen == 0
+	} else {
+		template.MaxPathLen = -1
+	}
+	if template.KeyUsage == 0 {
+		switch {
+		case opts.IsCA:
+			template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		default:
+			template.KeyUsage = x509.KeyUsageDigitalSignature
+			if _, ok := key.Public().(*rsa.PublicKey); ok {
+				template.KeyUsage |= x509.KeyUsageKeyEncipherment
+			}
+		}
+	}
+	if template.ExtKeyUsage == nil && !opts.IsCA {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	if issuer == nil {
+		issuer, issuerKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+// GeneratedCert is a certificate GenerateChain made, with its key.
+type GeneratedCert struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+// GenerateChain makes a test PKI: a self-signed root CA, the intermediate
+// CAs, each issued by the one before it, and a leaf issued by the last, all
+// with new keys of keyType. The root and intermediates are made CAs whatever
+// their options say. The chain comes back leaf first, root last.
+func GenerateChain(keyType string, root GenerateOptions, intermediates []GenerateOptions, leaf GenerateOptions) ([]GeneratedCert, error) {
+	var chain []GeneratedCert
+	var issuer *GeneratedCert
+	specs := append(append([]GenerateOptions{root}, intermediates...), leaf)
+	for i, opts := range specs {
+		if i < len(specs)-1 {
+			opts.IsCA = true
+		}
+		key, err := GenerateKey(keyType)
+		if err != nil {
+			return nil, err
+		}
+		var cert *x509.Certificate
+		if issuer == nil {
+			cert, err = GenerateSelfSignedCert(key, opts)
+		} else {
+			cert, err = IssueCertificate(key, opts, issuer.Certificate, issuer.Key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", nameOrUnknown(opts.Subject.CommonName), err)
+		}
+		chain = append([]GeneratedCert{{Certificate: cert, Key: key}}, chain...)
+		issuer = &chain[0]
+	}
+	return chain, nil
+}
+
+// LoadCertificateRequest reads a PKCS#10 certificate signing request from a
+// file. See ParseCertificateRequest.
+func LoadCertificateRequest(filename string) (*x509.CertificateRequest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate request: %w", err)
+	}
+	return ParseCertificateRequest(data)
+}
+
+// ParseCertificateRequest decodes a certificate signing request, PEM
+// ("CERTIFICATE REQUEST", or "NEW CERTIFICATE REQUEST" as older tools
+// write it) or DER, and checks its signature.
+func ParseCertificateRequest(data []byte) (*x509.CertificateRequest, error) {
+	der := data
+	sawPEM := false
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		sawPEM = true
+		if block.Type == "CERTIFICATE REQUEST" || block.Type == "NEW CERTIFICATE REQUEST" {
+			der, sawPEM = block.Bytes, false
+			break
+		}
+	}
+	if sawPEM {
+		return nil, fmt.Errorf("no certificate request found in PEM data")
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate request: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("certificate request signature does not verify: %w", err)
+	}
+	return csr, nil
+}
@@ -0,0 +1,214 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	for _, keyType := range []string{"ecdsa-p256", "ecdsa-p384", "ed25519", "rsa-2048"} {
+		key, err := GenerateKey(keyType)
+		if err != nil {
+			t.Fatalf("GenerateKey(%s): %v", keyType, err)
+		}
+		encoded, err := EncodePrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParsePrivateKey(encoded)
+		if err != nil {
+			t.Fatalf("%s key does not read back: %v", keyType, err)
+		}
+		if DescribePrivateKey(parsed) != DescribePrivateKey(key) {
+			t.Errorf("%s key read back as %s", keyType, DescribePrivateKey(parsed))
+		}
+	}
+	if _, err := GenerateKey("dsa"); err == nil {
+		t.Error("GenerateKey made a DSA key")
+	}
+}
+
+func TestParseSANs(t *testing.T) {
+	sans, err := ParseSANs([]string{"dns:api.example.com", "ip:10.0.0.1", "www.example.com", "::1",
+		"ops@example.com", "URI:spiffe://example.org/api", "email:admin@example.com", "https://example.com/id", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sans.DNSNames) != 2 || sans.DNSNames[1] != "www.example.com" {
+		t.Errorf("DNS names = %q", sans.DNSNames)
+	}
+	if len(sans.IPAddresses) != 2 || sans.IPAddresses[1].String() != "::1" {
+		t.Errorf("IP addresses = %v", sans.IPAddresses)
+	}
+	if len(sans.EmailAddresses) != 2 || len(sans.URIs) != 2 || sans.URIs[0].Host != "example.org" {
+		t.Errorf("emails = %q, URIs = %v", sans.EmailAddresses, sans.URIs)
+	}
+
+	for _, bad := range []string{"ip:not-an-ip", "uri:no-scheme", "foo:bar", "dsn:x", "dsn:example.com"} {
+		if _, err := ParseSANs([]string{bad}); err == nil {
+			t.Errorf("ParseSANs(%q) succeeded", bad)
+		}
+	}
+	if sans, _ := ParseSANs(nil); !sans.Empty() {
+		t.Error("no names are not Empty")
+	}
+}
+
+func TestCreateCSR(t *testing.T) {
+	key, err := GenerateKey("ecdsa-p256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sans, _ := ParseSANs([]string{"api.example.com", "10.0.0.1"})
+	data, err := CreateCSR(key, pkix.Name{CommonName: "api.example.com", Organization: []string{"Example, Inc"}}, sans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		t.Fatalf("CreateCSR wrote %q", data)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Errorf("request signature: %v", err)
+	}
+	if csr.Subject.Organization[0] != "Example, Inc" || len(csr.DNSNames) != 1 || len(csr.IPAddresses) != 1 {
+		t.Errorf("request subject %s, DNS %q, IP %v", csr.Subject, csr.DNSNames, csr.IPAddresses)
+	}
+}
+
+func TestGenerateSelfSignedCert(t *testing.T) {
+	key, err := GenerateKey("rsa-2048")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sans, _ := ParseSANs([]string{"foo.local", "10.0.0.5"})
+	cert, err := GenerateSelfSignedCert(key, GenerateOptions{Subject: pkix.Name{CommonName: "foo.local"}, SANs: sans, Days: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSelfSigned(cert) || !KeyMatches(cert, key) {
+		t.Error("certificate is not self-signed with the key")
+	}
+	if days := cert.NotAfter.Sub(cert.NotBefore).Hours() / 24; days != 90 {
+		t.Errorf("valid for %v days, want 90", days)
+	}
+	if cert.IsCA || cert.KeyUsage != x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment ||
+		len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("server certificate has CA %v, key usage %s, extended key usage %s",
+			cert.IsCA, FormatKeyUsage(cert), FormatExtKeyUsage(cert))
+	}
+	if err := cert.VerifyHostname("10.0.0.5"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIssueCertificate(t *testing.T) {
+	caKey, _ := GenerateKey("ecdsa-p256")
+	ca, err := GenerateSelfSignedCert(caKey, GenerateOptions{Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true, PathLen: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ca.IsCA || ca.MaxPathLen != 0 || !ca.MaxPathLenZero || ca.KeyUsage&x509.KeyUsageCertSign == 0 || len(ca.ExtKeyUsage) != 0 {
+		t.Errorf("CA has path length %d, key usage %s, extended key usage %s", ca.MaxPathLen, FormatKeyUsage(ca), FormatExtKeyUsage(ca))
+	}
+
+	key, _ := GenerateKey("ed25519")
+	leaf, err := IssueCertificate(key, GenerateOptions{
+		Subject:      pkix.Name{CommonName: "client"},
+		SerialNumber: big.NewInt(7),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("leaf is not signed by the CA: %v", err)
+	}
+	if leaf.SerialNumber.Int64() != 7 || leaf.ExtKeyUsage[0] != x509.ExtKeyUsageClientAuth {
+		t.Errorf("leaf serial %s, extended key usage %s", leaf.SerialNumber, FormatExtKeyUsage(leaf))
+	}
+
+	if _, err := GenerateSelfSignedCert(key, GenerateOptions{Days: -1}); err == nil {
+		t.Error("made a certificate valid for -1 days")
+	}
+}
+
+func TestGenerateChain(t *testing.T) {
+	leafOpts := GenerateOptions{Subject: pkix.Name{CommonName: "leaf.example.com"}, SANs: SANs{DNSNames: []string{"leaf.example.com"}}}
+	chain, err := GenerateChain("ecdsa-p256",
+		GenerateOptions{Subject: pkix.Name{CommonName: "Root"}, PathLen: -1},
+		[]GenerateOptions{{Subject: pkix.Name{CommonName: "Intermediate"}, PathLen: 0}},
+		leafOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 3 {
+		t.Fatalf("got %d certificates, want 3", len(chain))
+	}
+	certs := make([]*x509.Certificate, len(chain))
+	for i, generated := range chain {
+		certs[i] = generated.Certificate
+		if !KeyMatches(generated.Certificate, generated.Key) {
+			t.Errorf("the key of '%s' does not match it", generated.Certificate.Subject.CommonName)
+		}
+	}
+	if got := FormatChainNames(certs); got != "leaf.example.com → Intermediate → Root" {
+		t.Errorf("chain = %s", got)
+	}
+	if !certs[1].IsCA || !certs[2].IsCA || certs[0].IsCA {
+		t.Error("only the root and the intermediate should be CAs")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certs[2])
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(certs[1])
+	if _, err := certs[0].Verify(x509.VerifyOptions{DNSName: "leaf.example.com", Roots: roots, Intermediates: intermediates}); err != nil {
+		t.Errorf("chain does not verify: %v", err)
+	}
+
+	if _, err := GenerateChain("dsa", GenerateOptions{}, nil, leafOpts); err == nil {
+		t.Error("GenerateChain made a chain of DSA keys")
+	}
+}
+
+func TestParseCertificateRequest(t *testing.T) {
+	key, _ := GenerateKey("ecdsa-p256")
+	data, err := CreateCSR(key, pkix.Name{CommonName: "api.example.com"}, SANs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCertificateRequest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SamePublicKey(key.Public(), csr.PublicKey) {
+		t.Error("the request does not hold the key it was made for")
+	}
+	other, _ := GenerateKey("ecdsa-p256")
+	if SamePublicKey(other.Public(), csr.PublicKey) || SamePublicKey(key.Public(), nil) {
+		t.Error("SamePublicKey matched different keys")
+	}
+
+	block, _ := pem.Decode(data)
+	if _, err := ParseCertificateRequest(block.Bytes); err != nil {
+		t.Errorf("DER request: %v", err)
+	}
+	tampered := append([]byte(nil), block.Bytes...)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := ParseCertificateRequest(tampered); err == nil {
+		t.Error("a request with a broken signature parsed")
+	}
+	if _, err := ParseCertificateRequest(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0}})); err == nil ||
+		!strings.Contains(err.Error(), "no certificate request") {
+		t.Errorf("PEM without a request: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a certificate's public key as a JSON Web Key (RFC 7517), with the
+// certificate, and the chain above it, carried in x5c, and its SHA-256
+// thumbprint in x5t#S256, as JOSE libraries take them to validate a JWT.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	// X5c are the certificates, the key's own first, as base64 DER: the
+	// standard encoding, not the URL one the rest of the key uses.
+	X5c     []string `json:"x5c"`
+	X5tS256 string   `json:"x5t#S256"`
+}
+
+// NewJWK makes the JWK of the first certificate of chain, carrying the whole
+// chain in x5c. Its kid is the key's RFC 7638 thumbprint, so the same key
+// gets the same kid whichever certificate it comes in.
+func NewJWK(chain []*x509.Certificate) (*JWK, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificate to make a JWK of")
+	}
+	cert := chain[0]
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var jwk JWK
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty, jwk.N, jwk.E = "RSA", b64(key.N.Bytes()), b64(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		point, err := key.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		// The uncompressed point is 0x04, then X and Y at the curve's size.
+		size := (len(point) - 1) / 2
+		jwk.Kty, jwk.Crv = "EC", key.Curve.Params().Name
+		jwk.X, jwk.Y = b64(point[1:1+size]), b64(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", b64(key)
+	default:
+		return nil, fmt.Errorf("a %s key has no JWK form", cert.PublicKeyAlgorithm)
+	}
+
+	for _, c := range chain {
+		jwk.X5c = append(jwk.X5c, base64.StdEncoding.EncodeToString(c.Raw))
+	}
+	sum := sha256.Sum256(cert.Raw)
+	jwk.X5tS256 = b64(sum[:])
+
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = thumbprint
+	return &jwk, nil
+}
+
+// Thumbprint is the RFC 7638 thumbprint of the key: the SHA-256, base64url,
+// of its required members alone, in order and without spaces.
+func (j *JWK) Thumbprint() (string, error) {
+	var members any
+	switch j.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return "", fmt.Errorf("unknown key type %q", j.Kty)
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
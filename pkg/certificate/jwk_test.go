@@ -0,0 +1,75 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewJWK(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+
+	jwk, err := NewJWK([]*x509.Certificate{leaf, root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwk.Kty != "EC" || jwk.Crv != "P-256" {
+		t.Errorf("kty %q, crv %q, want EC P-256", jwk.Kty, jwk.Crv)
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	point, _ := leaf.PublicKey.(*ecdsa.PublicKey).Bytes()
+	if string(x) != string(point[1:33]) {
+		t.Errorf("x = %q is not the key's X coordinate", jwk.X)
+	}
+
+	if len(jwk.X5c) != 2 {
+		t.Fatalf("x5c has %d certificates, want 2", len(jwk.X5c))
+	}
+	if der, _ := base64.StdEncoding.DecodeString(jwk.X5c[0]); string(der) != string(leaf.Raw) {
+		t.Error("x5c does not start with the certificate")
+	}
+	sum := sha256.Sum256(leaf.Raw)
+	if jwk.X5tS256 != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Errorf("x5t#S256 = %q, not the certificate's thumbprint", jwk.X5tS256)
+	}
+
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []string{`"kid":"` + jwk.Kid + `"`, `"x5t#S256":`, `"y":`} {
+		if !strings.Contains(string(data), member) {
+			t.Errorf("no %s in %s", member, data)
+		}
+	}
+	if strings.Contains(string(data), `"n":`) {
+		t.Errorf("an EC key has RSA members: %s", data)
+	}
+
+	if _, err := NewJWK(nil); err == nil {
+		t.Error("an empty chain was accepted")
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// The example of RFC 7638, section 3.1.
+	jwk := &JWK{
+		Kty: "RSA",
+		E:   "AQAB",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn" +
+			"64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91" +
+			"CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	got, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Thumbprint = %q, want %q", got, want)
+	}
+}
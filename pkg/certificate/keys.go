@@ -0,0 +1,258 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrEncryptedKey is returned for a passphrase-protected private key.
+var ErrEncryptedKey = errors.New("private key is encrypted; decrypt it first (e.g. openssl pkey -in key.pem)")
+
+// LoadPrivateKey reads a private key from a file. See ParsePrivateKey.
+func LoadPrivateKey(filename string) (crypto.Signer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	return ParsePrivateKey(data)
+}
+
+// ParsePrivateKey decodes an RSA, ECDSA or Ed25519 private key in any of the
+// encodings OpenSSL writes: PKCS#8, PKCS#1 ("RSA PRIVATE KEY") and SEC 1 ("EC
+// PRIVATE KEY"), as PEM or raw DER. In a PEM file holding other blocks too --
+// a certificate and its key together -- the first key block is used.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, der, err := privateKeyDER(data)
+	if err != nil {
+		return nil, err
+	}
+	if block != nil && isEncryptedBlock(block) {
+		return nil, ErrEncryptedKey
+	}
+	key, _, err := parsePrivateKeyDER(der)
+	return key, err
+}
+
+// privateKeyDER finds the first private key block of PEM data, or takes
+// data for DER when it is not PEM at all.
+func privateKeyDER(data []byte) (*pem.Block, []byte, error) {
+	sawPEM := false
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		sawPEM = true
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			return block, block.Bytes, nil
+		}
+	}
+	if sawPEM {
+		return nil, nil, fmt.Errorf("no private key found in PEM data")
+	}
+	return nil, data, nil
+}
+
+// isEncryptedBlock reports whether a key block is passphrase-protected, as
+// PKCS#8 or in the legacy PEM encryption of the DEK-Info header.
+func isEncryptedBlock(block *pem.Block) bool {
+	return block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] == "4,ENCRYPTED"
+}
+
+// parsePrivateKeyDER decodes an unencrypted key, and names the encoding it
+// was in.
+func parsePrivateKeyDER(der []byte) (crypto.Signer, string, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, err := asSigner(key)
+		return signer, "PKCS#8", err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, "PKCS#1", nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, "SEC 1", nil
+	}
+	return nil, "", fmt.Errorf("unrecognized private key format (want PKCS#8, PKCS#1 or SEC 1)")
+}
+
+// asSigner narrows what ParsePKCS8PrivateKey returns to the key types the
+// rest of the package understands.
+func asSigner(key any) (crypto.Signer, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// KeyMatches reports whether the private key belongs to the certificate: that
+// is, whether its public half is the certificate's public key.
+func KeyMatches(cert *x509.Certificate, key crypto.Signer) bool {
+	if cert == nil || key == nil {
+		return false
+	}
+	return SamePublicKey(key.Public(), cert.PublicKey)
+}
+
+// SamePublicKey reports whether two public keys, of a certificate, a
+// request or a private key, are the same key.
+func SamePublicKey(a, b crypto.PublicKey) bool {
+	pub, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && b != nil && pub.Equal(b)
+}
+
+// PublicKeyFormats are the formats EncodePublicKey takes.
+var PublicKeyFormats = []string{"pem", "der"}
+
+// EncodePublicKey encodes the subjectPublicKeyInfo of cert, the form key
+// pinning and JWT verification want the public key in: a PEM "PUBLIC KEY"
+// block, as openssl x509 -pubkey prints it, or the bare DER.
+func EncodePublicKey(cert *x509.Certificate, format string) ([]byte, error) {
+	if cert == nil || len(cert.RawSubjectPublicKeyInfo) == 0 {
+		return nil, fmt.Errorf("certificate has no public key to export")
+	}
+	switch f := strings.ToLower(format); f {
+	case "pem", "":
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: cert.RawSubjectPublicKeyInfo}), nil
+	case "der":
+		return cert.RawSubjectPublicKeyInfo, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key format: %s (supported: %s)", f, strings.Join(PublicKeyFormats, ", "))
+	}
+}
+
+// DescribePrivateKey names the key's algorithm and size, e.g. "ECDSA P-256".
+func DescribePrivateKey(key crypto.Signer) string {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return fmt.Sprintf("RSA %d bits", k.N.BitLen())
+	case *ecdsa.PrivateKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PrivateKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
+
+// KeyInfo describes a private key as it is stored.
+type KeyInfo struct {
+	// Key is the key, or nil when it is encrypted.
+	Key crypto.Signer
+	// Algorithm is RSA, ECDSA or Ed25519; it is empty for an encrypted
+	// PKCS#8 key, whose type is encrypted with it.
+	Algorithm string
+	// Bits is the size of an RSA key, or of the curve of an ECDSA key.
+	Bits int
+	// Curve names the curve of an ECDSA key, as P-256.
+	Curve string
+	// Format is the encoding: PKCS#8, PKCS#1 or SEC 1, then PEM or DER.
+	Format string
+	// Encrypted is whether the key is passphrase-protected, and Encryption
+	// the cipher it is encrypted with, when that can be told.
+	Encrypted  bool
+	Encryption string
+	// SPKISHA256 is the SHA-256 digest of the public key's
+	// SubjectPublicKeyInfo, as the spki-sha256 fingerprint of a certificate
+	// for the key; nil when the key is encrypted.
+	SPKISHA256 []byte
+}
+
+// InspectPrivateKey describes a private key in any encoding ParsePrivateKey
+// reads. An encrypted key is described as far as it can be without the
+// passphrase, rather than failing with ErrEncryptedKey.
+func InspectPrivateKey(data []byte) (*KeyInfo, error) {
+	block, der, err := privateKeyDER(data)
+	if err != nil {
+		return nil, err
+	}
+	info := &KeyInfo{}
+	container := "DER"
+	if block != nil {
+		container = "PEM"
+	}
+
+	if block != nil && isEncryptedBlock(block) {
+		info.Encrypted = true
+		switch block.Type {
+		case "ENCRYPTED PRIVATE KEY":
+			info.Format = "PKCS#8"
+			info.Encryption = pkcs8Encryption(block.Bytes)
+		case "RSA PRIVATE KEY":
+			info.Algorithm, info.Format = "RSA", "PKCS#1"
+		case "EC PRIVATE KEY":
+			info.Algorithm, info.Format = "ECDSA", "SEC 1"
+		}
+		if dekInfo := block.Headers["DEK-Info"]; dekInfo != "" {
+			info.Encryption, _, _ = strings.Cut(dekInfo, ",")
+		}
+		info.Format = strings.TrimSpace(info.Format + " " + container)
+		return info, nil
+	}
+
+	key, format, err := parsePrivateKeyDER(der)
+	if err != nil {
+		return nil, err
+	}
+	info.Key = key
+	info.Format = format + " " + container
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		info.Algorithm, info.Bits = "RSA", k.N.BitLen()
+	case *ecdsa.PrivateKey:
+		info.Algorithm, info.Bits, info.Curve = "ECDSA", k.Curve.Params().BitSize, k.Curve.Params().Name
+	case ed25519.PrivateKey:
+		info.Algorithm, info.Bits = "Ed25519", 256
+	}
+	spki, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	sum := sha256.Sum256(spki)
+	info.SPKISHA256 = sum[:]
+	return info, nil
+}
+
+// pbeCiphers names the ciphers PBES2 encrypts PKCS#8 keys with.
+var pbeCiphers = map[string]string{
+	"2.16.840.1.101.3.4.1.2":  "AES-128-CBC",
+	"2.16.840.1.101.3.4.1.22": "AES-192-CBC",
+	"2.16.840.1.101.3.4.1.42": "AES-256-CBC",
+	"1.2.840.113549.3.7":      "DES-EDE3-CBC",
+}
+
+// pkcs8Encryption names the encryption of an encrypted PKCS#8 key: the
+// PBES2 cipher, or the OID of an older scheme.
+func pkcs8Encryption(der []byte) string {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return ""
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return info.Algorithm.Algorithm.String()
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return "PBES2"
+	}
+	if name, ok := pbeCiphers[params.Encryption.Algorithm.String()]; ok {
+		return "PBES2 " + name
+	}
+	return "PBES2 " + params.Encryption.Algorithm.String()
+}
@@ -0,0 +1,170 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePrivateKeyAndMatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8 := func(key any) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		key  crypto.Signer
+	}{
+		{"PKCS#1", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), rsaKey},
+		{"PKCS#8 RSA", pkcs8(rsaKey), rsaKey},
+		{"SEC 1", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}), ecKey},
+		{"SEC 1 DER", sec1, ecKey},
+		{"PKCS#8 Ed25519", pkcs8(edKey), edKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePrivateKey(tt.data)
+			if err != nil {
+				t.Fatalf("ParsePrivateKey: %v", err)
+			}
+
+			template := &x509.Certificate{
+				SerialNumber: randomSerial(t),
+				Subject:      pkix.Name{CommonName: "match.example"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(time.Hour),
+			}
+			cert := generateCertificate(template, template, tt.key.Public(), tt.key)
+			if !KeyMatches(cert, key) {
+				t.Error("key does not match its own certificate")
+			}
+
+			other, _ := issue(t, "other.example", false, nil, nil)
+			if KeyMatches(other, key) {
+				t.Error("key matches an unrelated certificate")
+			}
+		})
+	}
+}
+
+func TestParsePrivateKey_Errors(t *testing.T) {
+	encrypted := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte{0}})
+	if _, err := ParsePrivateKey(encrypted); !errors.Is(err, ErrEncryptedKey) {
+		t.Errorf("encrypted key: err = %v", err)
+	}
+
+	cert, _ := issue(t, "cert.example", false, nil, nil)
+	certOnly := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if _, err := ParsePrivateKey(certOnly); err == nil {
+		t.Error("a certificate was accepted as a private key")
+	}
+}
+
+func TestInspectPrivateKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := InspectPrivateKey(sec1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Algorithm != "ECDSA" || info.Curve != "P-384" || info.Bits != 384 || info.Format != "SEC 1 DER" || info.Encrypted {
+		t.Errorf("SEC 1 key described as %+v", info)
+	}
+	cert, err := GenerateSelfSignedCert(ecKey, GenerateOptions{Subject: pkix.Name{CommonName: "spki"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spki, _ := Fingerprint(cert, "spki-sha256"); !bytes.Equal(info.SPKISHA256, spki) {
+		t.Error("SPKISHA256 is not the spki-sha256 fingerprint of a certificate for the key")
+	}
+
+	// The parameters of an encrypted PKCS#8 key as openssl pkcs8 -topk8
+	// writes them: PBES2 with PBKDF2 and AES-256-CBC.
+	shrouded, err := shroudKey(ecKey, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err = InspectPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: shrouded}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Encrypted || info.Key != nil || info.Algorithm != "" || info.Encryption != "PBES2 AES-256-CBC" || info.Format != "PKCS#8 PEM" {
+		t.Errorf("encrypted PKCS#8 key described as %+v", info)
+	}
+
+	legacy := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte{0},
+		Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-128-CBC,00112233445566778899AABBCCDDEEFF"}})
+	info, err = InspectPrivateKey(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Encrypted || info.Algorithm != "RSA" || info.Encryption != "AES-128-CBC" || info.Format != "PKCS#1 PEM" {
+		t.Errorf("legacy encrypted key described as %+v", info)
+	}
+}
+
+func TestEncodePublicKey(t *testing.T) {
+	cert, key := issue(t, "pinned.example.com", false, nil, nil)
+
+	data, err := EncodePublicKey(cert, "pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, rest := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" || len(rest) != 0 {
+		t.Fatalf("not one PUBLIC KEY block:\n%s", data)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SamePublicKey(pub, key.Public()) {
+		t.Error("the exported public key is not the certificate's")
+	}
+
+	der, err := EncodePublicKey(cert, "DER")
+	if err != nil || !bytes.Equal(der, cert.RawSubjectPublicKeyInfo) {
+		t.Errorf("der = %x, %v", der, err)
+	}
+	if _, err := EncodePublicKey(cert, "p7b"); err == nil {
+		t.Error("p7b should not be a public key format")
+	}
+}
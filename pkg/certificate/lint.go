@@ -0,0 +1,203 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"embed"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// profileFS holds the built-in lint profiles. They are data, not code, so
+// that tracking a change in the Baseline Requirements is an edit to a YAML
+// file.
+//
+//go:embed profiles/*.yaml
+var profileFS embed.FS
+
+// LintProfile is a set of requirements for end-entity certificates. Every
+// field is optional; a zero value checks nothing.
+type LintProfile struct {
+	// Name identifies the profile, e.g. "cabf-br".
+	Name string `yaml:"name"`
+	// Description says where the requirements come from.
+	Description string `yaml:"description"`
+
+	MaxValidityDays       int      `yaml:"max_validity_days"`
+	RequireSAN            bool     `yaml:"require_san"`
+	RequireCNInSAN        bool     `yaml:"require_cn_in_san"`
+	RequiredEKUs          []string `yaml:"required_ekus"`
+	ForbiddenEKUs         []string `yaml:"forbidden_ekus"`
+	AllowedKeyTypes       []string `yaml:"allowed_key_types"`
+	MinRSABits            int      `yaml:"min_rsa_bits"`
+	RSAModulusMultipleOf8 bool     `yaml:"rsa_modulus_multiple_of_8"`
+	AllowedCurves         []string `yaml:"allowed_curves"`
+	ForbidSHA1            bool     `yaml:"forbid_sha1"`
+	MinSerialBits         int      `yaml:"min_serial_bits"`
+	RequireAIA            bool     `yaml:"require_aia"`
+	ForbidCA              bool     `yaml:"forbid_ca"`
+}
+
+// LintProfiles lists the names of the built-in profiles.
+func LintProfiles() []string {
+	entries, err := profileFS.ReadDir("profiles")
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	return names
+}
+
+// LoadLintProfile returns the built-in profile of that name or, failing that,
+// reads the argument as the path of a profile file.
+func LoadLintProfile(nameOrPath string) (*LintProfile, error) {
+	data, err := profileFS.ReadFile("profiles/" + nameOrPath + ".yaml")
+	if err != nil {
+		if data, err = os.ReadFile(nameOrPath); err != nil {
+			return nil, fmt.Errorf("unknown lint profile %q (built in: %s)", nameOrPath, strings.Join(LintProfiles(), ", "))
+		}
+	}
+	return ParseLintProfile(data)
+}
+
+// ParseLintProfile decodes a YAML profile.
+func ParseLintProfile(data []byte) (*LintProfile, error) {
+	var profile LintProfile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	// A misspelt requirement would otherwise be silently not checked.
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&profile); err != nil {
+		return nil, fmt.Errorf("malformed lint profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// LintFinding is a requirement a certificate does not meet.
+type LintFinding struct {
+	// Rule is the profile field that was broken, e.g. "max_validity_days".
+	Rule string
+	// Detail explains the finding in a sentence.
+	Detail string
+}
+
+// Lint checks an end-entity certificate against a profile.
+func Lint(cert *x509.Certificate, profile *LintProfile) []LintFinding {
+	var findings []LintFinding
+	add := func(rule, format string, args ...any) {
+		findings = append(findings, LintFinding{Rule: rule, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if profile.ForbidCA && cert.IsCA {
+		add("forbid_ca", "is a CA certificate")
+	}
+
+	if limit := profile.MaxValidityDays; limit > 0 {
+		if days := ValidityPeriodDays(cert); days > limit {
+			add("max_validity_days", "is valid for %d days; the maximum is %d", days, limit)
+		}
+	}
+
+	hasSAN := len(cert.DNSNames)+len(cert.IPAddresses)+len(cert.EmailAddresses)+len(cert.URIs) > 0
+	if profile.RequireSAN && !hasSAN {
+		add("require_san", "has no subjectAltName")
+	}
+	if cn := cert.Subject.CommonName; profile.RequireCNInSAN && cn != "" && hasSAN && !cnInSAN(cert, cn) {
+		add("require_cn_in_san", "common name %q is not one of its SANs", cn)
+	}
+
+	ekus := extKeyUsageNames(cert)
+	for _, required := range profile.RequiredEKUs {
+		if !slices.Contains(ekus, required) {
+			add("required_ekus", "lacks the %s extended key usage", required)
+		}
+	}
+	for _, forbidden := range profile.ForbiddenEKUs {
+		if slices.Contains(ekus, forbidden) {
+			add("forbidden_ekus", "asserts the %s extended key usage", forbidden)
+		}
+	}
+
+	lintKey(cert, profile, add)
+
+	if profile.ForbidSHA1 && isSHA1(cert.SignatureAlgorithm) {
+		add("forbid_sha1", "is signed with %s", cert.SignatureAlgorithm)
+	}
+
+	if limit := profile.MinSerialBits; limit > 0 && cert.SerialNumber != nil && cert.SerialNumber.BitLen() < limit {
+		add("min_serial_bits", "serial number has %d bits; at least %d bits of randomness are required",
+			cert.SerialNumber.BitLen(), limit)
+	}
+
+	if profile.RequireAIA && len(cert.IssuingCertificateURL) == 0 {
+		add("require_aia", "has no authorityInformationAccess caIssuers URL")
+	}
+
+	return findings
+}
+
+// lintKey applies the key type, size and curve requirements.
+func lintKey(cert *x509.Certificate, profile *LintProfile, add func(rule, format string, args ...any)) {
+	var keyType string
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		keyType = "RSA"
+		bits := pub.N.BitLen()
+		if profile.MinRSABits > 0 && bits < profile.MinRSABits {
+			add("min_rsa_bits", "has a %d-bit RSA key; the minimum is %d", bits, profile.MinRSABits)
+		}
+		if profile.RSAModulusMultipleOf8 && bits%8 != 0 {
+			add("rsa_modulus_multiple_of_8", "has a %d-bit RSA modulus, which is not a multiple of 8", bits)
+		}
+	case *ecdsa.PublicKey:
+		keyType = "ECDSA"
+		curve := pub.Curve.Params().Name
+		if len(profile.AllowedCurves) > 0 && !slices.Contains(profile.AllowedCurves, curve) {
+			add("allowed_curves", "uses curve %s; allowed are %s", curve, strings.Join(profile.AllowedCurves, ", "))
+		}
+	case ed25519.PublicKey:
+		keyType = "Ed25519"
+	default:
+		keyType = cert.PublicKeyAlgorithm.String()
+	}
+	if len(profile.AllowedKeyTypes) > 0 && !slices.Contains(profile.AllowedKeyTypes, keyType) {
+		add("allowed_key_types", "has a %s key; allowed are %s", keyType, strings.Join(profile.AllowedKeyTypes, ", "))
+	}
+}
+
+// cnInSAN reports whether the common name repeats one of the DNS or IP SANs.
+func cnInSAN(cert *x509.Certificate, cn string) bool {
+	for _, name := range cert.DNSNames {
+		if strings.EqualFold(name, cn) {
+			return true
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		if ip.String() == cn {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatLintFindings renders the findings for one certificate.
+func FormatLintFindings(cert *x509.Certificate, findings []LintFinding) string {
+	if len(findings) == 0 {
+		return fmt.Sprintf("✅ %s", displayName(cert))
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "❌ %s", displayName(cert))
+	for _, finding := range findings {
+		fmt.Fprintf(&sb, "\n  • %s [%s]", finding.Detail, finding.Rule)
+	}
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+)
+
+// lintRules collects the rule names of the findings.
+func lintRules(findings []LintFinding) []string {
+	rules := make([]string, len(findings))
+	for i, finding := range findings {
+		rules[i] = finding.Rule
+	}
+	return rules
+}
+
+func TestBuiltinLintProfilesLoad(t *testing.T) {
+	names := LintProfiles()
+	for _, want := range []string{"cabf-br", "mozilla"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("LintProfiles() = %v, missing %s", names, want)
+		}
+	}
+	for _, name := range names {
+		profile, err := LoadLintProfile(name)
+		if err != nil {
+			t.Errorf("LoadLintProfile(%q): %v", name, err)
+			continue
+		}
+		if profile.Name != name || profile.MaxValidityDays == 0 {
+			t.Errorf("profile %q loaded as %+v", name, profile)
+		}
+	}
+	if _, err := LoadLintProfile("no-such-profile"); err == nil {
+		t.Error("an unknown profile loaded")
+	}
+}
+
+func TestLint(t *testing.T) {
+	profile, err := LoadLintProfile("mozilla")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, rootKey := issue(t, "Root", true, nil, nil)
+
+	leaf, _ := issue(t, "good.example", false, root, rootKey)
+	if got := lintRules(Lint(leaf, profile)); !slices.Equal(got, []string{"require_aia"}) {
+		t.Errorf("conforming leaf without AIA: findings %v", got)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := generateCertificate(&x509.Certificate{
+		SerialNumber:          big.NewInt(42),
+		Subject:               pkix.Name{CommonName: "bad.example"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(2, 0, 0),
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageCodeSigning},
+		IssuingCertificateURL: []string{"http://ca.example/root.crt"},
+	}, root, &key.PublicKey, rootKey)
+
+	got := lintRules(Lint(bad, profile))
+	for _, want := range []string{"max_validity_days", "require_san", "required_ekus", "forbidden_ekus", "allowed_curves", "min_serial_bits"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("findings %v lack %s", got, want)
+		}
+	}
+	if slices.Contains(got, "require_aia") {
+		t.Errorf("AIA was present, findings %v", got)
+	}
+}
+
+func TestParseLintProfile_RejectsUnknownFields(t *testing.T) {
+	if _, err := ParseLintProfile([]byte("max_validty_days: 90\n")); err == nil {
+		t.Error("a misspelt requirement was accepted")
+	}
+	profile, err := ParseLintProfile([]byte("name: internal\nmax_validity_days: 90\n"))
+	if err != nil || profile.MaxValidityDays != 90 {
+		t.Errorf("ParseLintProfile = %+v, %v", profile, err)
+	}
+}
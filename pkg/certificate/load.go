@@ -0,0 +1,186 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// InputFormats are the formats LoadOptions.Format takes. auto detects the
+// format, as LoadCertificates does.
+var InputFormats = []string{"auto", "pem", "der", "p7b", "p12"}
+
+// LoadOptions say how to read certificates.
+type LoadOptions struct {
+	// Format forces the input to be read as one of InputFormats rather
+	// than detected. Empty means auto.
+	Format string
+	// Password opens a PKCS#12 file. Empty tries the file as unprotected.
+	Password string
+}
+
+// LoadCertificatesWith loads certificates from a file, or stdin when
+// filename is empty, as opts say.
+func LoadCertificatesWith(filename string, opts LoadOptions) ([]*Info, error) {
+	var input io.Reader
+	if filename == "" {
+		input = os.Stdin
+	} else {
+		file, err := os.Open(filename)
+		if err != nil {
+			logger.Error("Failed to open file", zap.Error(err))
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil {
+				logger.Error("Failed to close input file", zap.String("filename", filename), zap.Error(closeErr))
+			}
+		}()
+		input = file
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		logger.Error("Failed to read input", zap.Error(err))
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if len(data) == 0 {
+		logger.Error("Empty input")
+		return nil, fmt.Errorf("empty input")
+	}
+
+	certs, err := ParseCertificatesWith(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range certs {
+		c.Source = filename
+	}
+	return certs, nil
+}
+
+// ParseCertificatesWith extracts certificates from data as opts say.
+//
+// auto tells the formats apart as well as their encodings allow. A forced
+// format is for input that defeats that: base64 with no PEM armour, as a
+// secret store or an API hands it out, is decoded for any format, so a
+// bare blob on stdin reads as what it is named.
+func ParseCertificatesWith(data []byte, opts LoadOptions) ([]*Info, error) {
+	format := strings.ToLower(opts.Format)
+	if format == "" || format == "auto" {
+		if isPFX(data) {
+			return parsePKCS12Infos(data, opts.Password)
+		}
+		certs, err := detectCertificates(data)
+		if err != nil {
+			if _, ok := decodeBase64(data); ok {
+				return nil, fmt.Errorf("%w; it is base64, so name what it encodes with --in-format", err)
+			}
+		}
+		return certs, err
+	}
+
+	switch format {
+	case "pem":
+		certs, sawPEM, err := parsePEMCertificates(data)
+		switch {
+		case err != nil:
+			return nil, err
+		case len(certs) > 0:
+			return certs, nil
+		case sawPEM:
+			return nil, fmt.Errorf("no certificates found in input: the PEM data contains no CERTIFICATE blocks")
+		}
+		// A PEM body without its BEGIN and END lines.
+		der, ok := decodeBase64(data)
+		if !ok {
+			return nil, fmt.Errorf("input is not PEM: it has no BEGIN line, and is not base64")
+		}
+		return parseDERInfos(der)
+	case "der":
+		if der, ok := decodeBase64(data); ok {
+			data = der
+		}
+		return parseDERInfos(data)
+	case "p7b", "p7c", "pkcs7":
+		var parsed []*x509.Certificate
+		for rest := data; ; {
+			block, remaining := pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type == "PKCS7" {
+				certs, err := parsePKCS7Certificates(block.Bytes)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse PKCS#7 block: %w", err)
+				}
+				parsed = append(parsed, certs...)
+			}
+			rest = remaining
+		}
+		if len(parsed) == 0 {
+			if der, ok := decodeBase64(data); ok {
+				data = der
+			}
+			var err error
+			if parsed, err = parsePKCS7Certificates(data); err != nil {
+				return nil, err
+			}
+		}
+		return newInfos(parsed), nil
+	case "p12", "pfx", "pkcs12":
+		if der, ok := decodeBase64(data); ok {
+			data = der
+		}
+		return parsePKCS12Infos(data, opts.Password)
+	default:
+		return nil, fmt.Errorf("unknown input format %q (one of %s)", opts.Format, strings.Join(InputFormats, ", "))
+	}
+}
+
+// parseDERInfos reads data as DER certificates, one or several
+// concatenated, and nothing else.
+func parseDERInfos(data []byte) ([]*Info, error) {
+	parsed, err := x509.ParseCertificates(data)
+	if err != nil {
+		return nil, fmt.Errorf("input is not DER certificates: %w", err)
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no certificates found in input")
+	}
+	return newInfos(parsed), nil
+}
+
+func parsePKCS12Infos(data []byte, password string) ([]*Info, error) {
+	parsed, err := parsePKCS12Certificates(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return newInfos(parsed), nil
+}
+
+// decodeBase64 decodes data when it is nothing but base64, padded or not,
+// and spread over lines or not, of DER: a word that happens to be base64
+// is not taken for it.
+func decodeBase64(data []byte) ([]byte, bool) {
+	text := strings.Join(strings.Fields(string(data)), "")
+	if text == "" || strings.ContainsFunc(text, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("+/=-_", r))
+	}) {
+		return nil, false
+	}
+	text = strings.TrimRight(text, "=")
+	for _, enc := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+		if der, err := enc.DecodeString(text); err == nil && len(der) > 0 && der[0] == derSequenceTag {
+			return der, true
+		}
+	}
+	return nil, false
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"math/big"
@@ -57,7 +58,10 @@ func TestLoadCertificates_WithPrivateKey(t *testing.T) {
 	}
 
 	if len(certs) != 1 {
-		t.Errorf("Expected 1 certificate, got %d", len(certs))
+		t.Fatalf("Expected 1 certificate, got %d", len(certs))
+	}
+	if certs[0].Source != tmpfile.Name() {
+		t.Errorf("Source = %q, want the file it came from", certs[0].Source)
 	}
 }
 
@@ -115,6 +119,20 @@ func TestParseCertificates_NumbersCertificatesNotBlocks(t *testing.T) {
 			t.Errorf("certificate %d: Label = %q, want it to start with %q", i, info.Label, wantPrefix)
 		}
 	}
+
+	// Each certificate knows the line its block begins on, the other
+	// blocks counted in.
+	var beginLines []int
+	for i, line := range strings.Split(string(bundle), "\n") {
+		if line == "-----BEGIN CERTIFICATE-----" {
+			beginLines = append(beginLines, i+1)
+		}
+	}
+	for i, info := range certs {
+		if info.Line != beginLines[i] {
+			t.Errorf("certificate %d: Line = %d, want %d", i, info.Line, beginLines[i])
+		}
+	}
 }
 
 // TestParseCertificates_DER covers raw DER input. y509's own export form offers
@@ -223,7 +241,7 @@ func TestParseCertificates_Errors(t *testing.T) {
 				}
 				return der
 			}(),
-			want: "PKCS#7 and PKCS#12",
+			want: "not a certificate, PKCS#7 bundle or PKCS#12 file",
 		},
 		{
 			name: "text that merely starts with 0x30",
@@ -290,9 +308,91 @@ func TestParseCertificates_Errors(t *testing.T) {
 				t.Errorf("error = %q, want it to mention %q", err, tt.want)
 			}
 			// Only a genuine complete SEQUENCE may be called a PKCS container.
-			if tt.want != "PKCS#7 and PKCS#12" && strings.Contains(err.Error(), "PKCS") {
+			if tt.want != "not a certificate, PKCS#7 bundle or PKCS#12 file" && strings.Contains(err.Error(), "PKCS") {
 				t.Errorf("error = %q wrongly claims a PKCS container", err)
 			}
 		})
 	}
 }
+
+func TestParseCertificates_PKCS7(t *testing.T) {
+	ca, caKey := issue(t, "Test CA", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, ca, caKey)
+
+	der, err := EncodeChain([]*x509.Certificate{leaf, ca}, "p7b")
+	if err != nil {
+		t.Fatalf("EncodeChain: %v", err)
+	}
+	inputs := map[string][]byte{
+		"DER": der,
+		"PEM": pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der}),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			certs, err := ParseCertificates(input)
+			if err != nil {
+				t.Fatalf("ParseCertificates: %v", err)
+			}
+			if len(certs) != 2 || !certs[0].Certificate.Equal(leaf) || !certs[1].Certificate.Equal(ca) {
+				t.Fatalf("got %d certificates, want the leaf then the CA", len(certs))
+			}
+			if certs[1].Index != 1 {
+				t.Errorf("second certificate has index %d", certs[1].Index)
+			}
+		})
+	}
+}
+
+func TestParseCertificatesWith(t *testing.T) {
+	ca, caKey := issue(t, "Test CA", true, nil, nil)
+	leaf, leafKey := issue(t, "leaf.example", false, ca, caKey)
+	p7b, err := EncodePKCS7([]*x509.Certificate{leaf, ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p12, err := EncodePKCS12(leafKey, []*x509.Certificate{leaf, ca}, "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw})
+	b64 := func(der []byte) []byte { return []byte(base64.StdEncoding.EncodeToString(der) + "\n") }
+
+	tests := []struct {
+		name   string
+		input  []byte
+		format string
+		want   int
+	}{
+		{"auto PEM", leafPEM, "auto", 1},
+		{"auto PKCS#12", p12, "", 2},
+		{"forced PEM", leafPEM, "pem", 1},
+		{"PEM body without its armour", b64(leaf.Raw), "pem", 1},
+		{"DER", leaf.Raw, "der", 1},
+		{"base64 DER", b64(leaf.Raw), "der", 1},
+		{"PKCS#7 as PEM", pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: p7b}), "p7b", 2},
+		{"base64 PKCS#7", b64(p7b), "p7b", 2},
+		{"base64 PKCS#12", b64(p12), "p12", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs, err := ParseCertificatesWith(tt.input, LoadOptions{Format: tt.format, Password: "pw"})
+			if err != nil {
+				t.Fatalf("ParseCertificatesWith: %v", err)
+			}
+			if len(certs) != tt.want || !certs[0].Certificate.Equal(leaf) {
+				t.Errorf("got %d certificates, want %d starting with the leaf", len(certs), tt.want)
+			}
+		})
+	}
+
+	// A forced format is not second-guessed: PEM is not DER.
+	if _, err := ParseCertificatesWith(leafPEM, LoadOptions{Format: "der"}); err == nil {
+		t.Error("PEM read as forced DER")
+	}
+	if _, err := ParseCertificatesWith(leafPEM, LoadOptions{Format: "xml"}); err == nil || !strings.Contains(err.Error(), "unknown input format") {
+		t.Errorf("unknown format: error = %v", err)
+	}
+	if _, err := ParseCertificatesWith(b64(leaf.Raw), LoadOptions{}); err == nil || !strings.Contains(err.Error(), "--in-format") {
+		t.Errorf("auto on base64: error = %v, want a pointer to --in-format", err)
+	}
+}
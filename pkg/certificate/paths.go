@@ -0,0 +1,213 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxTrustPaths caps how many paths EnumeratePaths returns. Real bundles have
+// one or two; a pile of cross-signs can fan out combinatorially.
+const maxTrustPaths = 16
+
+// TrustPath is one way up from the leaf to a root, with its own verdict.
+type TrustPath struct {
+	// Certificates is the path, leaf first. For a trusted path the last
+	// element is the trust anchor, which may have come from the trust store
+	// rather than the input.
+	Certificates []*x509.Certificate
+	// Level is how far this particular path verified.
+	Level TrustLevel
+	// Err explains why the path is not trusted. It is nil for TrustAnchored.
+	Err error
+}
+
+// EnumeratePaths lists every path from the leaf (certs[0]) to a root, each
+// with its own verdict, rather than the single best one VerifyChain settles
+// on.
+//
+// This is what a cross-signed chain needs. A bundle carrying both the ISRG
+// Root X1 self-signed certificate and its cross-sign from DST Root CA X3 can
+// be followed two ways, and clients disagree about which one they take:
+// a current trust store reaches ISRG Root X1, an old one follows the
+// cross-sign to a root that expired in 2021. Showing both explains why the
+// same chain works in one place and not another.
+//
+// Paths are built from the input certificates by issuer name and signature.
+// Each is then checked against the trust anchors, and a path that stops
+// short of the input's end but reaches a trust store root is completed with
+// it. Trusted paths come first, then self-anchored, then broken ones.
+func EnumeratePaths(certs []*x509.Certificate, opts VerifyOptions) ([]TrustPath, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("empty certificate chain")
+	}
+	leaf := certs[0]
+	if leaf == nil {
+		return nil, fmt.Errorf("leaf certificate is nil")
+	}
+
+	roots, err := trustAnchors(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []*x509.Certificate
+	for _, cert := range certs {
+		if cert != nil {
+			inputs = append(inputs, cert)
+		}
+	}
+
+	// What the verifier can reach with everything on offer. Go enumerates
+	// every chain it finds, so these are all the trusted paths.
+	intermediates := x509.NewCertPool()
+	for _, cert := range inputs[1:] {
+		intermediates.AddCert(cert)
+	}
+	anchored, trustErr := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       opts.DNSName,
+		CurrentTime:   opts.CurrentTime,
+	})
+
+	var paths []TrustPath
+	for _, chain := range anchored {
+		paths = append(paths, TrustPath{Certificates: chain, Level: TrustAnchored})
+	}
+
+	for _, path := range inputPaths(leaf, inputs) {
+		if len(paths) >= maxTrustPaths {
+			break
+		}
+		if slices.ContainsFunc(anchored, func(chain []*x509.Certificate) bool {
+			return hasPathPrefix(chain, path)
+		}) {
+			// Already listed, either exactly or completed by a store root.
+			continue
+		}
+		paths = append(paths, judgePath(path, opts, trustErr))
+	}
+
+	slices.SortStableFunc(paths, func(a, b TrustPath) int { return int(b.Level) - int(a.Level) })
+	return paths, nil
+}
+
+// inputPaths follows every issuer the input offers, depth first, and returns
+// each leaf-first path that ends at a self-signed certificate or at one whose
+// issuer is not in the input.
+func inputPaths(leaf *x509.Certificate, certs []*x509.Certificate) [][]*x509.Certificate {
+	var paths [][]*x509.Certificate
+
+	var walk func(path []*x509.Certificate)
+	walk = func(path []*x509.Certificate) {
+		if len(paths) >= maxTrustPaths {
+			return
+		}
+		top := path[len(path)-1]
+		extended := false
+		if !IsSelfSigned(top) {
+			for _, candidate := range certs {
+				if slices.ContainsFunc(path, candidate.Equal) {
+					continue
+				}
+				if candidate.Subject.String() != top.Issuer.String() || !signedBy(top, candidate) {
+					continue
+				}
+				extended = true
+				walk(append(slices.Clip(path), candidate))
+			}
+		}
+		if !extended {
+			paths = append(paths, path)
+		}
+	}
+	walk([]*x509.Certificate{leaf})
+
+	return paths
+}
+
+// judgePath gives a path that the trust store did not accept its verdict:
+// self-anchored if it links up to its own self-signed top, broken otherwise.
+// trustErr is the trust store's reason for rejecting the chain as a whole.
+func judgePath(path []*x509.Certificate, opts VerifyOptions, trustErr error) TrustPath {
+	result := TrustPath{Certificates: path, Level: TrustBroken, Err: trustErr}
+
+	top := path[len(path)-1]
+	if !IsSelfSigned(top) {
+		result.Err = &MissingIssuerError{
+			Certificate: top,
+			Issuer:      nameOrUnknown(top.Issuer.CommonName),
+			URLs:        top.IssuingCertificateURL,
+		}
+		return result
+	}
+
+	// Verify against this path's own root, offering only this path's
+	// intermediates, so the verifier cannot wander onto another path.
+	roots := x509.NewCertPool()
+	roots.AddCert(top)
+	intermediates := x509.NewCertPool()
+	if len(path) > 2 {
+		for _, cert := range path[1 : len(path)-1] {
+			intermediates.AddCert(cert)
+		}
+	}
+	if _, err := path[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       opts.DNSName,
+		CurrentTime:   opts.CurrentTime,
+	}); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Level = TrustSelfAnchored
+	return result
+}
+
+// hasPathPrefix reports whether chain begins with every certificate of prefix.
+func hasPathPrefix(chain, prefix []*x509.Certificate) bool {
+	if len(prefix) > len(chain) {
+		return false
+	}
+	for i, cert := range prefix {
+		if !chain[i].Equal(cert) {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatTrustPaths renders the paths for the terminal. It returns an empty
+// string when there is at most one, since VerifyChain's verdict already says
+// everything about a chain that can only be read one way.
+func FormatTrustPaths(paths []TrustPath) string {
+	if len(paths) < 2 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Trust paths (%d):\n", len(paths))
+	for i, path := range paths {
+		fmt.Fprintf(&sb, "  %d. %s %s: %s\n", i+1, trustPathIcon(path.Level), path.Level, FormatChainNames(path.Certificates))
+		if path.Err != nil {
+			fmt.Fprintf(&sb, "       %v\n", path.Err)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// trustPathIcon matches the icons FormatVerifyResult leads with.
+func trustPathIcon(level TrustLevel) string {
+	switch level {
+	case TrustAnchored:
+		return "✅"
+	case TrustSelfAnchored:
+		return "⚠️ "
+	default:
+		return "❌"
+	}
+}
@@ -0,0 +1,114 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+)
+
+// crossSign reissues a CA certificate's subject and key under another issuer,
+// the way ISRG Root X1 was cross-signed by DST Root CA X3.
+func crossSign(t *testing.T, cert *x509.Certificate, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(t),
+		Subject:               cert.Subject,
+		NotBefore:             cert.NotBefore,
+		NotAfter:              cert.NotAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crossed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crossed
+}
+
+// expiredRoot mints a self-signed CA that expired a year ago.
+func expiredRoot(t *testing.T, cn string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          randomSerial(t),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().AddDate(-10, 0, 0),
+		NotAfter:              time.Now().AddDate(-1, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// TestEnumeratePaths_CrossSigned reproduces the Let's Encrypt layout: the
+// bundle reaches the current root directly, and also through a cross-sign to
+// an expired one. Both paths are shown, each with its own verdict.
+func TestEnumeratePaths_CrossSigned(t *testing.T) {
+	oldRoot, oldRootKey := expiredRoot(t, "DST Root CA X3")
+	newRoot, newRootKey := issue(t, "ISRG Root X1", true, nil, nil)
+	crossed := crossSign(t, newRoot, newRootKey, oldRoot, oldRootKey)
+	intermediate, intermediateKey := issue(t, "R3", true, newRoot, newRootKey)
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+
+	certs := []*x509.Certificate{leaf, intermediate, crossed, oldRoot}
+	opts := VerifyOptions{ExtraRoots: []*x509.Certificate{newRoot}, SkipSystemRoots: true}
+
+	paths, err := EnumeratePaths(certs, opts)
+	if err != nil {
+		t.Fatalf("EnumeratePaths: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("got %d paths, want 2:\n%s", len(paths), FormatTrustPaths(paths))
+	}
+
+	trusted, expired := paths[0], paths[1]
+	if trusted.Level != TrustAnchored || !trusted.Certificates[len(trusted.Certificates)-1].Equal(newRoot) {
+		t.Errorf("first path should be trusted and end at ISRG Root X1:\n%s", FormatTrustPaths(paths))
+	}
+	if expired.Level != TrustBroken || !expired.Certificates[len(expired.Certificates)-1].Equal(oldRoot) {
+		t.Errorf("second path should be broken and end at DST Root CA X3:\n%s", FormatTrustPaths(paths))
+	}
+	if expired.Err == nil || !strings.Contains(expired.Err.Error(), "expired") {
+		t.Errorf("the cross-signed path should blame the expired root, got %v", expired.Err)
+	}
+}
+
+func TestEnumeratePaths_SinglePathIsNotFormatted(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+
+	paths, err := EnumeratePaths([]*x509.Certificate{leaf, root}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("EnumeratePaths: %v", err)
+	}
+	if len(paths) != 1 || paths[0].Level != TrustSelfAnchored {
+		t.Fatalf("want one self-anchored path, got %+v", paths)
+	}
+	if got := FormatTrustPaths(paths); got != "" {
+		t.Errorf("FormatTrustPaths = %q, want empty for a single path", got)
+	}
+}
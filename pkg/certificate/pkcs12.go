@@ -0,0 +1,607 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des" //nolint:gosec // to read files other tools encrypted with 3DES
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // the local key ID, as OpenSSL derives it
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+
+	"go.uber.org/zap"
+	legacypkcs12 "golang.org/x/crypto/pkcs12" //nolint:staticcheck // deprecated, but the only RC2 there is
+)
+
+// The object identifiers of a PKCS#12 file, from RFC 7292, RFC 8018 and RFC
+// 2985.
+var (
+	oidCertBag         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidShroudedKeyBag  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidX509Certificate = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidLocalKeyID      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidFriendlyName    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidPBES2           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256  = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC       = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	asn1Null           = asn1.RawValue{Tag: asn1.TagNull}
+)
+
+// The parameters of the encryption and MAC: OpenSSL's iteration count and
+// salt length, and the RFC 7292 ID for deriving a MAC key.
+const (
+	pkcs12Iterations = 2048
+	pkcs12SaltLength = 16
+	pkcs12MACKeyID   = 3
+)
+
+// contentInfo is a PKCS#7 ContentInfo holding data: an OCTET STRING under
+// an explicit [0].
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,tag:0"`
+}
+
+// safeBag is a PKCS#12 SafeBag. Value is the bag's encoding already under
+// its explicit [0]: encoding/asn1 does not add the tag to a RawValue given
+// as FullBytes.
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue
+	Attributes []pkcs12Attribute `asn1:"set,optional,omitempty"`
+}
+
+type pkcs12Attribute struct {
+	ID     asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"explicit,tag:0"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivation pkix.AlgorithmIdentifier
+	Encryption    pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params leaves out the key length and PRF when they are the
+// defaults, as RFC 8018 allows; a missing PRF is HMAC-SHA1.
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type macData struct {
+	Mac struct {
+		Algorithm pkix.AlgorithmIdentifier
+		Digest    []byte
+	}
+	Salt       []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+// pfx is a PKCS#12 file. The MAC is optional: a file can go without one,
+// though nothing y509 writes does.
+type pfx struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+// EncodePKCS12 writes a PKCS#12 (.p12, .pfx) file holding key and the
+// certificates, the first of which must be the key's. The key is encrypted
+// with PBES2 (PBKDF2 with HMAC-SHA256, and AES-256-CBC) and the whole file
+// authenticated with HMAC-SHA256, as OpenSSL 3 writes them by default; the
+// certificates are not encrypted, which every reader accepts.
+func EncodePKCS12(key crypto.Signer, certs []*x509.Certificate, password string) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates to export")
+	}
+	if !KeyMatches(certs[0], key) {
+		return nil, fmt.Errorf("private key does not match certificate '%s'", certs[0].Subject.CommonName)
+	}
+
+	keyID := sha1.Sum(certs[0].Raw) //nolint:gosec // an identifier, not a digest anything relies on
+	leafAttributes, err := pkcs12Attributes(keyID[:], displayName(certs[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	var certBags []safeBag
+	for i, cert := range certs {
+		value, err := asn1.Marshal(certBag{ID: oidX509Certificate, Data: cert.Raw})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode certificate %d: %w", i, err)
+		}
+		bag := safeBag{ID: oidCertBag, Value: explicitTag0(value)}
+		if i == 0 {
+			bag.Attributes = leafAttributes
+		}
+		certBags = append(certBags, bag)
+	}
+
+	shrouded, err := shroudKey(key, password)
+	if err != nil {
+		return nil, err
+	}
+	keyBags := []safeBag{{ID: oidShroudedKeyBag, Value: explicitTag0(shrouded), Attributes: leafAttributes}}
+
+	var authSafe []contentInfo
+	for _, bags := range [][]safeBag{certBags, keyBags} {
+		contents, err := asn1.Marshal(bags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode PKCS#12 bags: %w", err)
+		}
+		authSafe = append(authSafe, contentInfo{ContentType: oidData, Content: contents})
+	}
+	authSafeBytes, err := asn1.Marshal(authSafe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#12 contents: %w", err)
+	}
+
+	out := pfx{Version: 3, AuthSafe: contentInfo{ContentType: oidData, Content: authSafeBytes}}
+	out.MacData.Salt = make([]byte, pkcs12SaltLength)
+	if _, err := rand.Read(out.MacData.Salt); err != nil {
+		return nil, err
+	}
+	out.MacData.Iterations = pkcs12Iterations
+	out.MacData.Mac.Algorithm = pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1Null}
+	macKey := pkcs12KDF(crypto.SHA256, append(bmpString(password), 0, 0), out.MacData.Salt, pkcs12MACKeyID, pkcs12Iterations, sha256.Size)
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(authSafeBytes)
+	out.MacData.Mac.Digest = mac.Sum(nil)
+
+	return asn1.Marshal(out)
+}
+
+// explicitTag0 puts DER under an explicit [0].
+func explicitTag0(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+// pkcs12Attributes are the bag attributes tying the leaf certificate to its
+// key: a local key ID, and a friendly name that importers show.
+func pkcs12Attributes(keyID []byte, name string) ([]pkcs12Attribute, error) {
+	id, err := asn1.Marshal(keyID)
+	if err != nil {
+		return nil, err
+	}
+	bmp, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagBMPString, Bytes: bmpString(name)})
+	if err != nil {
+		return nil, err
+	}
+	set := func(value []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value}
+	}
+	return []pkcs12Attribute{
+		{ID: oidFriendlyName, Values: set(bmp)},
+		{ID: oidLocalKeyID, Values: set(id)},
+	}, nil
+}
+
+// shroudKey encrypts key as a PKCS#8 EncryptedPrivateKeyInfo with PBES2.
+func shroudKey(key crypto.Signer, password string) ([]byte, error) {
+	plain, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	salt := make([]byte, pkcs12SaltLength)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	aesKey, err := pbkdf2.Key(sha256.New, password, salt, pkcs12Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(plain)%aes.BlockSize
+	for range padding {
+		plain = append(plain, byte(padding))
+	}
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: pkcs12Iterations,
+		KeyLength:  32,
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1Null},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivBytes, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivation: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		Encryption:    pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivBytes}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+}
+
+// bmpString is s as a BMPString holds it: big-endian UTF-16. A password
+// also takes a zero character on the end before going into pkcs12KDF.
+func bmpString(s string) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u>>8), byte(u))
+	}
+	return out
+}
+
+// pkcs12KDF derives size bytes from a password as RFC 7292 appendix B.2
+// does, with h: SHA-256 for what y509 writes, SHA-1 in older files. id says
+// what the bytes are for: 1 for a key, 2 for an IV, 3 for a MAC key.
+func pkcs12KDF(h crypto.Hash, password, salt []byte, id byte, iterations, size int) []byte {
+	v := h.New().BlockSize()
+
+	fill := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		out := make([]byte, v*((len(b)+v-1)/v))
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+	i := append(fill(salt), fill(password)...)
+
+	var out []byte
+	for len(out) < size {
+		hash := h.New()
+		hash.Write(d)
+		hash.Write(i)
+		a := hash.Sum(nil)
+		for range iterations - 1 {
+			hash.Reset()
+			hash.Write(a)
+			a = hash.Sum(nil)
+		}
+		out = append(out, a...)
+
+		// Each v-byte block of I becomes I + B + 1, B being A repeated.
+		b := fill(a)
+		for j := 0; j < len(i); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				carry += int(i[j+k]) + int(b[k])
+				i[j+k] = byte(carry)
+				carry >>= 8
+			}
+		}
+	}
+	return out[:size]
+}
+
+// The object identifiers only reading a PKCS#12 file needs: the ciphers and
+// digests of files other tools wrote.
+var (
+	oidEncryptedData       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+	oidPBEWithSHA3DES      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHA128RC2    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 5}
+	oidPBEWithSHA40RC2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+	oidHMACWithSHA1        = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA384      = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512      = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC           = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC           = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidDESEDE3CBC          = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidSHA1                = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA384              = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512              = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	errPKCS12RC2Encryption = errors.New("PKCS#12 contents are encrypted with RC2")
+)
+
+// ErrPKCS12Password is returned when a PKCS#12 file does not open with the
+// password given: its MAC does not verify, or its contents do not decrypt.
+var ErrPKCS12Password = errors.New("wrong password for the PKCS#12 file")
+
+// pbeParams are the parameters of the PKCS#12 password-based ciphers.
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo struct {
+		ContentType      asn1.ObjectIdentifier
+		Algorithm        pkix.AlgorithmIdentifier
+		EncryptedContent []byte `asn1:"tag:0,optional"`
+	}
+}
+
+// isPFX reports whether data is shaped as a PKCS#12 file, so it can be
+// told from the PKCS#7 bundle that also opens as a DER SEQUENCE.
+func isPFX(data []byte) bool {
+	var p pfx
+	rest, err := asn1.Unmarshal(data, &p)
+	return err == nil && len(rest) == 0 && p.Version == 3
+}
+
+// parsePKCS12Certificates takes the certificates out of a PKCS#12 (.p12,
+// .pfx) file, in the order it holds them, leaving the keys alone. The MAC is
+// checked, and encrypted contents decrypted, with password: PBES2 as OpenSSL
+// 3 and y509 write it, or the 3DES of older files. RC2, which OpenSSL's
+// -legacy and older Windows exports encrypt certificates with, is left to
+// golang.org/x/crypto/pkcs12.
+func parsePKCS12Certificates(data []byte, password string) ([]*x509.Certificate, error) {
+	certs, err := decodePKCS12Certificates(data, password)
+	if errors.Is(err, errPKCS12RC2Encryption) {
+		return legacyPKCS12Certificates(data, password)
+	}
+	return certs, err
+}
+
+func decodePKCS12Certificates(data []byte, password string) ([]*x509.Certificate, error) {
+	var p pfx
+	rest, err := asn1.Unmarshal(data, &p)
+	if err != nil || len(rest) > 0 {
+		logger.Debug("input did not parse as PKCS#12", zap.Error(err))
+		return nil, fmt.Errorf("input is not a PKCS#12 file")
+	}
+	if p.Version != 3 {
+		return nil, fmt.Errorf("PKCS#12 version %d is not supported", p.Version)
+	}
+	if !p.AuthSafe.ContentType.Equal(oidData) {
+		return nil, fmt.Errorf("PKCS#12 file protected with a public key, not a password, is not supported")
+	}
+
+	bmpPassword, err := pkcs12MACPassword(p, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var authSafe []struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"explicit,tag:0"`
+	}
+	if _, err := asn1.Unmarshal(p.AuthSafe.Content, &authSafe); err != nil {
+		return nil, fmt.Errorf("malformed PKCS#12 contents: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	for _, info := range authSafe {
+		var contents []byte
+		switch {
+		case info.ContentType.Equal(oidData):
+			if _, err := asn1.Unmarshal(info.Content.Bytes, &contents); err != nil {
+				return nil, fmt.Errorf("malformed PKCS#12 contents: %w", err)
+			}
+		case info.ContentType.Equal(oidEncryptedData):
+			var encrypted encryptedData
+			if _, err := asn1.Unmarshal(info.Content.Bytes, &encrypted); err != nil {
+				return nil, fmt.Errorf("malformed PKCS#12 encrypted contents: %w", err)
+			}
+			eci := encrypted.EncryptedContentInfo
+			if contents, err = pbeDecrypt(eci.Algorithm, eci.EncryptedContent, password, bmpPassword); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("PKCS#12 content type %s is not supported", info.ContentType)
+		}
+
+		var bags []safeBag
+		if _, err := asn1.Unmarshal(contents, &bags); err != nil {
+			if info.ContentType.Equal(oidEncryptedData) && len(p.MacData.Mac.Digest) == 0 {
+				// With no MAC to check the password against, a wrong one
+				// shows only here, as contents that decrypt to nonsense.
+				return nil, ErrPKCS12Password
+			}
+			return nil, fmt.Errorf("malformed PKCS#12 bags: %w", err)
+		}
+		for _, bag := range bags {
+			if !bag.ID.Equal(oidCertBag) {
+				continue
+			}
+			var cb certBag
+			if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil {
+				return nil, fmt.Errorf("malformed PKCS#12 certificate bag: %w", err)
+			}
+			if !cb.ID.Equal(oidX509Certificate) {
+				continue
+			}
+			cert, err := x509.ParseCertificate(cb.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate %d of the PKCS#12 file: %w", len(certs), err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("PKCS#12 file contains no certificates")
+	}
+	return certs, nil
+}
+
+// pkcs12MACPassword checks the file's MAC with password and returns the
+// password as the legacy ciphers take it, a BMPString. An empty password is
+// tried both as an empty BMPString with its zero character and with
+// nothing at all, since writers disagree on which it is.
+func pkcs12MACPassword(p pfx, password string) ([]byte, error) {
+	candidates := [][]byte{append(bmpString(password), 0, 0)}
+	if password == "" {
+		candidates = append(candidates, nil)
+	}
+	if len(p.MacData.Mac.Digest) == 0 {
+		return candidates[0], nil
+	}
+
+	var h crypto.Hash
+	switch alg := p.MacData.Mac.Algorithm.Algorithm; {
+	case alg.Equal(oidSHA1):
+		h = crypto.SHA1
+	case alg.Equal(oidSHA256):
+		h = crypto.SHA256
+	case alg.Equal(oidSHA384):
+		h = crypto.SHA384
+	case alg.Equal(oidSHA512):
+		h = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("PKCS#12 MAC algorithm %s is not supported", alg)
+	}
+	for _, candidate := range candidates {
+		key := pkcs12KDF(h, candidate, p.MacData.Salt, pkcs12MACKeyID, p.MacData.Iterations, h.Size())
+		mac := hmac.New(h.New, key)
+		mac.Write(p.AuthSafe.Content)
+		if hmac.Equal(mac.Sum(nil), p.MacData.Mac.Digest) {
+			return candidate, nil
+		}
+	}
+	return nil, ErrPKCS12Password
+}
+
+// pbeDecrypt decrypts PKCS#12 contents. PBES2 takes the password as it is,
+// the legacy ciphers as the BMPString the MAC was checked with.
+func pbeDecrypt(alg pkix.AlgorithmIdentifier, encrypted []byte, password string, bmpPassword []byte) ([]byte, error) {
+	var block cipher.Block
+	var iv []byte
+	switch {
+	case alg.Algorithm.Equal(oidPBES2):
+		var params pbes2Params
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("malformed PBES2 parameters: %w", err)
+		}
+		if !params.KeyDerivation.Algorithm.Equal(oidPBKDF2) {
+			return nil, fmt.Errorf("PBES2 key derivation %s is not supported", params.KeyDerivation.Algorithm)
+		}
+		var kdf pbkdf2Params
+		if _, err := asn1.Unmarshal(params.KeyDerivation.Parameters.FullBytes, &kdf); err != nil {
+			return nil, fmt.Errorf("malformed PBKDF2 parameters: %w", err)
+		}
+		prf := sha1.New
+		switch p := kdf.PRF.Algorithm; {
+		case len(p) == 0, p.Equal(oidHMACWithSHA1):
+		case p.Equal(oidHMACWithSHA256):
+			prf = sha256.New
+		case p.Equal(oidHMACWithSHA384):
+			prf = sha512.New384
+		case p.Equal(oidHMACWithSHA512):
+			prf = sha512.New
+		default:
+			return nil, fmt.Errorf("PBKDF2 PRF %s is not supported", p)
+		}
+
+		var keyLen int
+		newCipher := aes.NewCipher
+		switch e := params.Encryption.Algorithm; {
+		case e.Equal(oidAES128CBC):
+			keyLen = 16
+		case e.Equal(oidAES192CBC):
+			keyLen = 24
+		case e.Equal(oidAES256CBC):
+			keyLen = 32
+		case e.Equal(oidDESEDE3CBC):
+			keyLen, newCipher = 24, des.NewTripleDESCipher
+		default:
+			return nil, fmt.Errorf("PBES2 cipher %s is not supported", e)
+		}
+		if _, err := asn1.Unmarshal(params.Encryption.Parameters.FullBytes, &iv); err != nil {
+			return nil, fmt.Errorf("malformed PBES2 IV: %w", err)
+		}
+		key, err := pbkdf2.Key(prf, password, kdf.Salt, kdf.Iterations, keyLen)
+		if err != nil {
+			return nil, err
+		}
+		if block, err = newCipher(key); err != nil {
+			return nil, err
+		}
+	case alg.Algorithm.Equal(oidPBEWithSHA3DES):
+		var params pbeParams
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("malformed PBE parameters: %w", err)
+		}
+		key := pkcs12KDF(crypto.SHA1, bmpPassword, params.Salt, 1, params.Iterations, 24)
+		iv = pkcs12KDF(crypto.SHA1, bmpPassword, params.Salt, 2, params.Iterations, des.BlockSize)
+		var err error
+		if block, err = des.NewTripleDESCipher(key); err != nil {
+			return nil, err
+		}
+	case alg.Algorithm.Equal(oidPBEWithSHA40RC2), alg.Algorithm.Equal(oidPBEWithSHA128RC2):
+		return nil, errPKCS12RC2Encryption
+	default:
+		return nil, fmt.Errorf("PKCS#12 encryption %s is not supported", alg.Algorithm)
+	}
+
+	if len(iv) != block.BlockSize() || len(encrypted) == 0 || len(encrypted)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("malformed PKCS#12 encrypted contents")
+	}
+	plain := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, encrypted)
+	// Padding that is not PKCS#7's is what a wrong password decrypts to.
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > block.BlockSize() ||
+		!bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrPKCS12Password
+	}
+	return plain[:len(plain)-padding], nil
+}
+
+// legacyPKCS12Certificates reads a file whose certificates are encrypted
+// with RC2, a cipher the standard library does not have.
+func legacyPKCS12Certificates(data []byte, password string) ([]*x509.Certificate, error) {
+	blocks, err := legacypkcs12.ToPEM(data, password)
+	if errors.Is(err, legacypkcs12.ErrIncorrectPassword) {
+		return nil, ErrPKCS12Password
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy PKCS#12 file: %w", err)
+	}
+	var certs []*x509.Certificate
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %d of the PKCS#12 file: %w", len(certs), err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("PKCS#12 file contains no certificates")
+	}
+	return certs, nil
+}
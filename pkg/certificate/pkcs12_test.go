@@ -0,0 +1,164 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPKCS12KDF(t *testing.T) {
+	// From openssl kdf -keylen 32 -kdfopt digest:SHA256 -kdfopt pass:smeg
+	// -kdfopt hexsalt:0A58CF64530D823F -kdfopt iter:1000 -kdfopt id:3 PKCS12KDF
+	salt, _ := hex.DecodeString("0a58cf64530d823f")
+	want := "10be804cfe52cd6c548910e4db674b5cfefc7f2d3ad2720283faad1b3ac80b78"
+	if got := hex.EncodeToString(pkcs12KDF(crypto.SHA256, []byte("smeg"), salt, pkcs12MACKeyID, 1000, 32)); got != want {
+		t.Errorf("pkcs12KDF = %s, want %s", got, want)
+	}
+}
+
+func TestEncodePKCS12(t *testing.T) {
+	ca, caKey := issue(t, "Test CA", true, nil, nil)
+	leaf, leafKey := issue(t, "leaf.example", false, ca, caKey)
+
+	if _, err := EncodePKCS12(caKey, []*x509.Certificate{leaf, ca}, "pw"); err == nil {
+		t.Error("EncodePKCS12 took a key that is not the first certificate's")
+	}
+
+	der, err := EncodePKCS12(leafKey, []*x509.Certificate{leaf, ca}, "pw")
+	if err != nil {
+		t.Fatalf("EncodePKCS12: %v", err)
+	}
+
+	var out pfx
+	if _, err := asn1.Unmarshal(der, &out); err != nil {
+		t.Fatalf("PFX does not parse: %v", err)
+	}
+	if out.Version != 3 {
+		t.Errorf("version = %d, want 3", out.Version)
+	}
+
+	macKey := pkcs12KDF(crypto.SHA256, append(bmpString("pw"), 0, 0), out.MacData.Salt, pkcs12MACKeyID, out.MacData.Iterations, 32)
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(out.AuthSafe.Content)
+	if !hmac.Equal(mac.Sum(nil), out.MacData.Mac.Digest) {
+		t.Error("MAC does not verify with the password")
+	}
+
+	var authSafe []contentInfo
+	if _, err := asn1.Unmarshal(out.AuthSafe.Content, &authSafe); err != nil || len(authSafe) != 2 {
+		t.Fatalf("authenticated safe: %d contents, %v", len(authSafe), err)
+	}
+
+	var certBags []safeBag
+	if _, err := asn1.Unmarshal(authSafe[0].Content, &certBags); err != nil {
+		t.Fatal(err)
+	}
+	var certs [][]byte
+	for _, bag := range certBags {
+		var cb certBag
+		if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, cb.Data)
+	}
+	if len(certs) != 2 || !bytes.Equal(certs[0], leaf.Raw) || !bytes.Equal(certs[1], ca.Raw) {
+		t.Errorf("certificate bags do not hold the leaf then the CA")
+	}
+
+	var keyBags []safeBag
+	if _, err := asn1.Unmarshal(authSafe[1].Content, &keyBags); err != nil || len(keyBags) != 1 {
+		t.Fatalf("key bags: %d, %v", len(keyBags), err)
+	}
+	var shrouded encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(keyBags[0].Value.Bytes, &shrouded); err != nil {
+		t.Fatal(err)
+	}
+	var params pbes2Params
+	var kdf pbkdf2Params
+	var iv []byte
+	if _, err := asn1.Unmarshal(shrouded.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(params.KeyDerivation.Parameters.FullBytes, &kdf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(params.Encryption.Parameters.FullBytes, &iv); err != nil {
+		t.Fatal(err)
+	}
+	aesKey, err := pbkdf2.Key(sha256.New, "pw", kdf.Salt, kdf.Iterations, kdf.KeyLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make([]byte, len(shrouded.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, shrouded.EncryptedData)
+	plain = plain[:len(plain)-int(plain[len(plain)-1])]
+	key, err := x509.ParsePKCS8PrivateKey(plain)
+	if err != nil {
+		t.Fatalf("decrypted key does not parse: %v", err)
+	}
+	if !leafKey.Equal(key) {
+		t.Error("decrypted key is not the leaf's")
+	}
+}
+
+func TestParsePKCS12Certificates(t *testing.T) {
+	ca, caKey := issue(t, "Test CA", true, nil, nil)
+	leaf, leafKey := issue(t, "leaf.example", false, ca, caKey)
+	der, err := EncodePKCS12(leafKey, []*x509.Certificate{leaf, ca}, "pw")
+	if err != nil {
+		t.Fatalf("EncodePKCS12: %v", err)
+	}
+
+	certs, err := parsePKCS12Certificates(der, "pw")
+	if err != nil {
+		t.Fatalf("parsePKCS12Certificates: %v", err)
+	}
+	if len(certs) != 2 || !certs[0].Equal(leaf) || !certs[1].Equal(ca) {
+		t.Errorf("got %d certificates, want the leaf then the CA", len(certs))
+	}
+	if _, err := parsePKCS12Certificates(der, "wrong"); !errors.Is(err, ErrPKCS12Password) {
+		t.Errorf("wrong password: error = %v, want ErrPKCS12Password", err)
+	}
+
+	// Written by openssl pkcs12 -export, with and without -legacy, from the
+	// leaf and intermediate of a test PKI, with the password s3cret: the
+	// certificates encrypted with PBES2 and AES-256, and with RC2.
+	for _, name := range []string{"openssl3", "legacy"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "pkcs12-"+name+".p12"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := parsePKCS12Certificates(data, ""); !errors.Is(err, ErrPKCS12Password) {
+				t.Errorf("no password: error = %v, want ErrPKCS12Password", err)
+			}
+			certs, err := parsePKCS12Certificates(data, "s3cret")
+			if err != nil {
+				t.Fatalf("parsePKCS12Certificates: %v", err)
+			}
+			var names []string
+			for _, cert := range certs {
+				names = append(names, cert.Subject.CommonName)
+			}
+			if want := "leaf.example.com, y509 Test Intermediate CA"; strings.Join(names, ", ") != want {
+				t.Errorf("certificates = %s, want %s", strings.Join(names, ", "), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+// oidSignedData identifies a PKCS#7 / CMS SignedData content.
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// parsePKCS7Certificates extracts the certificates from a certs-only PKCS#7
+// bundle -- the "degenerate" SignedData that .p7b and .p7c files carry, with
+// no signers and no content. Nothing is verified: there is nothing to verify.
+func parsePKCS7Certificates(data []byte) ([]*x509.Certificate, error) {
+	var contentInfo struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"explicit,tag:0"`
+	}
+	rest, err := asn1.Unmarshal(data, &contentInfo)
+	if err != nil {
+		return nil, fmt.Errorf("not a PKCS#7 structure: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("not a PKCS#7 structure: trailing data")
+	}
+	if !contentInfo.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("PKCS#7 content type %s is not SignedData", contentInfo.ContentType)
+	}
+
+	var signedData asn1.RawValue
+	if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &signedData); err != nil {
+		return nil, fmt.Errorf("malformed PKCS#7 SignedData: %w", err)
+	}
+
+	// SignedData is version, digestAlgorithms, encapContentInfo, then the
+	// optional [0] IMPLICIT certificates. Walk the elements rather than
+	// unmarshalling into a struct: encoding/asn1 matches a RawValue field
+	// against any tag, so an optional [0] cannot be expressed that way.
+	fields := signedData.Bytes
+	for len(fields) > 0 {
+		var field asn1.RawValue
+		fields, err = asn1.Unmarshal(fields, &field)
+		if err != nil {
+			return nil, fmt.Errorf("malformed PKCS#7 SignedData: %w", err)
+		}
+		if field.Class == asn1.ClassContextSpecific && field.Tag == 0 {
+			certs, err := x509.ParseCertificates(field.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("malformed certificate in PKCS#7 bundle: %w", err)
+			}
+			if len(certs) == 0 {
+				break
+			}
+			return certs, nil
+		}
+	}
+	return nil, fmt.Errorf("PKCS#7 bundle contains no certificates")
+}
+
+// oidData identifies plain PKCS#7 data, the (empty) content of a certs-only
+// bundle.
+var oidData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+
+// EncodePKCS7 wraps certificates, in order, in a certs-only PKCS#7 bundle as
+// DER: the degenerate SignedData of .p7b files, which parsePKCS7Certificates
+// reads back.
+func EncodePKCS7(certs []*x509.Certificate) ([]byte, error) {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	signedData := struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      struct{ ContentType asn1.ObjectIdentifier }
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw},
+		SignerInfos:      emptySet,
+	}
+	signedData.ContentInfo.ContentType = oidData
+
+	inner, err := asn1.Marshal(signedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#7 SignedData: %w", err)
+	}
+	return asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{oidSignedData, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner}})
+}
@@ -0,0 +1,191 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+)
+
+var (
+	// oidCertificatePolicies is the certificatePolicies extension (RFC 5280,
+	// section 4.2.1.4).
+	oidCertificatePolicies = asn1.ObjectIdentifier{2, 5, 29, 32}
+	// oidQualifierCPS and oidQualifierUserNotice are the two policy
+	// qualifiers RFC 5280 defines.
+	oidQualifierCPS        = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 1}
+	oidQualifierUserNotice = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 2}
+)
+
+// knownPolicy names a policy OID and, for the CA/Browser Forum reserved OIDs
+// and the CA-specific EV OIDs browsers recognize, the validation level it
+// asserts.
+type knownPolicy struct {
+	name  string
+	level string
+}
+
+// knownPolicies covers the OIDs a user actually meets on a TLS certificate:
+// the CA/Browser Forum's reserved policy identifiers, and the EV OIDs the
+// large CAs registered before 2.23.140.1.1 existed and still assert alongside
+// it.
+var knownPolicies = map[string]knownPolicy{
+	"2.5.29.32.0":      {"anyPolicy", ""},
+	"2.23.140.1.1":     {"CA/B Forum Extended Validation", "EV"},
+	"2.23.140.1.2.1":   {"CA/B Forum Domain Validated", "DV"},
+	"2.23.140.1.2.2":   {"CA/B Forum Organization Validated", "OV"},
+	"2.23.140.1.2.3":   {"CA/B Forum Individual Validated", "IV"},
+	"2.23.140.1.31":    {"CA/B Forum Onion EV", "EV"},
+	"2.23.140.1.3":     {"CA/B Forum EV Code Signing", "EV"},
+	"2.23.140.1.4.1":   {"CA/B Forum Code Signing", ""},
+	"2.23.140.1.5.1.1": {"CA/B Forum S/MIME Mailbox (legacy)", ""},
+
+	"1.3.6.1.4.1.44947.1.1.1":       {"ISRG Domain Validated", "DV"},
+	"2.16.840.1.114412.2.1":         {"DigiCert EV", "EV"},
+	"2.16.840.1.114412.1.1":         {"DigiCert OV", "OV"},
+	"1.3.6.1.4.1.6449.1.2.1.5.1":    {"Sectigo EV", "EV"},
+	"1.3.6.1.4.1.4146.1.1":          {"GlobalSign EV", "EV"},
+	"2.16.840.1.114028.10.1.2":      {"Entrust EV", "EV"},
+	"2.16.840.1.114413.1.7.23.3":    {"GoDaddy EV", "EV"},
+	"2.16.840.1.114414.1.7.23.3":    {"Starfield EV", "EV"},
+	"2.16.756.1.89.1.2.1.1":         {"SwissSign EV", "EV"},
+	"1.3.6.1.4.1.34697.2.1":         {"AffirmTrust EV", "EV"},
+	"1.3.6.1.4.1.14370.1.6":         {"GeoTrust EV (legacy)", "EV"},
+	"2.16.840.1.113733.1.7.23.6":    {"VeriSign EV (legacy)", "EV"},
+	"1.3.6.1.4.1.782.1.2.1.8.1":     {"Network Solutions EV", "EV"},
+	"1.3.6.1.4.1.8024.0.2.100.1.2":  {"QuoVadis EV", "EV"},
+	"2.16.528.1.1003.1.2.7":         {"PKIoverheid EV", "EV"},
+	"1.2.616.1.113527.2.5.1.1":      {"Certum EV", "EV"},
+	"1.3.6.1.4.1.23223.1.1.1":       {"StartCom EV (legacy)", "EV"},
+	"2.16.840.1.114171.500.9":       {"Wells Fargo EV", "EV"},
+	"1.3.6.1.4.1.7879.13.24.1":      {"T-TeleSec EV", "EV"},
+	"1.3.6.1.4.1.13177.10.1.3.10":   {"Firmaprofesional EV", "EV"},
+	"1.3.6.1.4.1.40869.1.1.22.3":    {"TWCA EV", "EV"},
+	"2.16.840.1.114404.1.1.2.4.1":   {"Trustwave EV", "EV"},
+	"1.3.6.1.4.1.17326.10.14.2.1.2": {"Camerfirma EV", "EV"},
+}
+
+// PolicyInfo is one entry of the certificatePolicies extension.
+type PolicyInfo struct {
+	// OID is the policy identifier in dotted form.
+	OID string
+	// Name is a friendly name for well-known policies, or empty.
+	Name string
+	// Level is "DV", "OV", "IV" or "EV" when the policy asserts a CA/Browser
+	// Forum validation level, or empty.
+	Level string
+	// CPS are the Certification Practice Statement URIs qualifying the policy.
+	CPS []string
+	// UserNotices are the explicit texts of any user notice qualifiers.
+	UserNotices []string
+}
+
+// ParsePolicies decodes the certificatePolicies extension, qualifiers
+// included. It returns nil and no error when the extension is absent.
+//
+// crypto/x509 exposes the policy OIDs but drops their qualifiers, and the CPS
+// link is what a reader actually follows to find out what a policy means.
+func ParsePolicies(cert *x509.Certificate) ([]PolicyInfo, error) {
+	var value []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidCertificatePolicies) {
+			value = ext.Value
+			break
+		}
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	type policyQualifier struct {
+		ID        asn1.ObjectIdentifier
+		Qualifier asn1.RawValue
+	}
+	type policyInformation struct {
+		ID         asn1.ObjectIdentifier
+		Qualifiers []policyQualifier `asn1:"optional"`
+	}
+	var raw []policyInformation
+	if rest, err := asn1.Unmarshal(value, &raw); err != nil {
+		return nil, fmt.Errorf("malformed certificate policies: %w", err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("malformed certificate policies: trailing data")
+	}
+
+	policies := make([]PolicyInfo, 0, len(raw))
+	for _, p := range raw {
+		info := PolicyInfo{OID: p.ID.String()}
+		if known, ok := knownPolicies[info.OID]; ok {
+			info.Name, info.Level = known.name, known.level
+		}
+		for _, q := range p.Qualifiers {
+			switch {
+			case q.ID.Equal(oidQualifierCPS):
+				// IA5String, but be lenient about the string type: the
+				// value is only displayed.
+				info.CPS = append(info.CPS, string(q.Qualifier.Bytes))
+			case q.ID.Equal(oidQualifierUserNotice):
+				if text := userNoticeText(q.Qualifier.FullBytes); text != "" {
+					info.UserNotices = append(info.UserNotices, text)
+				}
+			}
+		}
+		policies = append(policies, info)
+	}
+	return policies, nil
+}
+
+// userNoticeText pulls the explicitText out of a UserNotice. The optional
+// noticeRef, a pointer into a document nobody has, is skipped.
+func userNoticeText(der []byte) string {
+	var notice asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &notice); err != nil {
+		return ""
+	}
+	for rest := notice.Bytes; len(rest) > 0; {
+		var field asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &field); err != nil {
+			return ""
+		}
+		// explicitText is a DisplayText: any of the string types. noticeRef
+		// is the one SEQUENCE.
+		if field.Tag != asn1.TagSequence {
+			return string(field.Bytes)
+		}
+	}
+	return ""
+}
+
+// ValidationLevel reports the strongest CA/Browser Forum validation level the
+// certificate's policies assert -- "EV", "OV", "IV" or "DV" -- or an empty
+// string when none is asserted.
+func ValidationLevel(cert *x509.Certificate) string {
+	policies, err := ParsePolicies(cert)
+	if err != nil {
+		return ""
+	}
+	rank := map[string]int{"DV": 1, "IV": 2, "OV": 3, "EV": 4}
+	best := ""
+	for _, p := range policies {
+		if rank[p.Level] > rank[best] {
+			best = p.Level
+		}
+	}
+	return best
+}
+
+// FormatPolicy describes a policy in a line: its friendly name where known,
+// and its OID.
+func FormatPolicy(p PolicyInfo) string {
+	var sb strings.Builder
+	if p.Name != "" {
+		fmt.Fprintf(&sb, "%s (%s)", p.Name, p.OID)
+	} else {
+		sb.WriteString(p.OID)
+	}
+	if p.Level != "" {
+		fmt.Fprintf(&sb, " [%s]", p.Level)
+	}
+	return sb.String()
+}
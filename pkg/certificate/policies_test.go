@@ -0,0 +1,104 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+	"time"
+)
+
+// withPolicies mints a self-signed certificate carrying the given DER-encoded
+// certificatePolicies extension value.
+func withPolicies(t *testing.T, value []byte) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    randomSerial(t),
+		Subject:         pkix.Name{CommonName: "policy.example"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: oidCertificatePolicies, Value: value}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestParsePolicies(t *testing.T) {
+	type qualifier struct {
+		ID        asn1.ObjectIdentifier
+		Qualifier any
+	}
+	type userNotice struct {
+		ExplicitText string `asn1:"utf8"`
+	}
+	type policy struct {
+		ID         asn1.ObjectIdentifier
+		Qualifiers []qualifier `asn1:"optional"`
+	}
+
+	cps, err := asn1.MarshalWithParams("https://cps.example/", "ia5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := asn1.Marshal([]policy{
+		{ID: asn1.ObjectIdentifier{2, 23, 140, 1, 1}},
+		{
+			ID: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1},
+			Qualifiers: []qualifier{
+				{ID: oidQualifierCPS, Qualifier: asn1.RawValue{FullBytes: cps}},
+				{ID: oidQualifierUserNotice, Qualifier: userNotice{ExplicitText: "Relying parties beware"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := withPolicies(t, value)
+	policies, err := ParsePolicies(cert)
+	if err != nil {
+		t.Fatalf("ParsePolicies: %v", err)
+	}
+	if len(policies) != 2 {
+		t.Fatalf("got %d policies, want 2", len(policies))
+	}
+
+	if ev := policies[0]; ev.Level != "EV" || ev.Name == "" {
+		t.Errorf("2.23.140.1.1 not recognized as EV: %+v", ev)
+	}
+	private := policies[1]
+	if private.OID != "1.3.6.1.4.1.99999.1" || private.Name != "" {
+		t.Errorf("unknown policy = %+v", private)
+	}
+	if len(private.CPS) != 1 || private.CPS[0] != "https://cps.example/" {
+		t.Errorf("CPS = %v", private.CPS)
+	}
+	if len(private.UserNotices) != 1 || private.UserNotices[0] != "Relying parties beware" {
+		t.Errorf("UserNotices = %v", private.UserNotices)
+	}
+	if got := ValidationLevel(cert); got != "EV" {
+		t.Errorf("ValidationLevel = %q, want EV", got)
+	}
+}
+
+func TestValidationLevel_None(t *testing.T) {
+	leaf, _ := issue(t, "plain.example", false, nil, nil)
+	if got := ValidationLevel(leaf); got != "" {
+		t.Errorf("ValidationLevel = %q for a certificate without policies", got)
+	}
+}
@@ -0,0 +1,369 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ocsp"
+)
+
+// RevocationStatus is what a CA says about a certificate it issued.
+type RevocationStatus int
+
+const (
+	// RevocationUnknown means no answer could be had: nothing is published,
+	// the responder or CRL could not be reached, or the responder does not
+	// know the certificate.
+	RevocationUnknown RevocationStatus = iota
+	// RevocationGood means the CA vouches that the certificate is not revoked.
+	RevocationGood
+	// RevocationRevoked means the CA has revoked the certificate.
+	RevocationRevoked
+)
+
+// String returns the status in lower case.
+func (s RevocationStatus) String() string {
+	switch s {
+	case RevocationGood:
+		return "good"
+	case RevocationRevoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// RevocationResult is the outcome of a revocation check.
+type RevocationResult struct {
+	Status RevocationStatus
+	// Source is where the answer came from: "OCSP" or "CRL", with its URL.
+	Source string
+	// RevokedAt and Reason are set for a revoked certificate. Reason is an
+	// RFC 5280 CRLReason code.
+	RevokedAt time.Time
+	Reason    int
+	// Err explains an unknown status.
+	Err error
+}
+
+// maxRevocationResponseSize bounds what is read from a responder or CRL
+// distribution point. OCSP responses are small; CRLs of large CAs are not,
+// but ones past this are not worth waiting for in an interactive view.
+const maxRevocationResponseSize = 16 << 20
+
+// revocationClockSkew is how far the clocks of y509 and a CA may disagree
+// before an answer is taken to be from the future or out of date.
+const revocationClockSkew = 5 * time.Minute
+
+// staleAnswer says why an OCSP response or CRL good from thisUpdate to
+// nextUpdate is no answer now, or returns nil when it is current. A zero
+// nextUpdate means the CA does not say, and never goes out of date.
+func staleAnswer(thisUpdate, nextUpdate time.Time) error {
+	now := time.Now()
+	switch {
+	case thisUpdate.After(now.Add(revocationClockSkew)):
+		return fmt.Errorf("the answer is dated %s, in the future", FormatTime(thisUpdate))
+	case !nextUpdate.IsZero() && now.After(nextUpdate.Add(revocationClockSkew)):
+		return fmt.Errorf("the answer went out of date on %s", FormatTime(nextUpdate))
+	}
+	return nil
+}
+
+// CheckRevocation asks the CA whether cert has been revoked: its OCSP
+// responders first, then its CRL distribution points. issuer is needed for
+// both, to build the OCSP request and to check the signature on the answer.
+func CheckRevocation(ctx context.Context, cert, issuer *x509.Certificate) RevocationResult {
+	if len(cert.OCSPServer) == 0 && len(cert.CRLDistributionPoints) == 0 {
+		return RevocationResult{Err: errors.New("no OCSP responder or CRL distribution point is published")}
+	}
+	if issuer == nil {
+		return RevocationResult{Err: fmt.Errorf("issuer '%s' not present in input", nameOrUnknown(cert.Issuer.CommonName))}
+	}
+
+	var errs []error
+	for _, url := range cert.OCSPServer {
+		result, err := checkOCSP(ctx, url, cert, issuer)
+		if err == nil && result.Status != RevocationUnknown {
+			return result
+		}
+		if err == nil && result.Err != nil {
+			err = fmt.Errorf("%s: %w", result.Source, result.Err)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, url := range cert.CRLDistributionPoints {
+		result, err := checkCRL(ctx, url, cert, issuer)
+		if err == nil && result.Status != RevocationUnknown {
+			return result
+		}
+		if err == nil {
+			err = fmt.Errorf("%s: %w", result.Source, result.Err)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return RevocationResult{Source: "OCSP", Err: errors.New("the responder does not know this certificate")}
+	}
+	return RevocationResult{Err: errors.Join(errs...)}
+}
+
+// checkOCSP asks one OCSP responder about cert.
+func checkOCSP(ctx context.Context, url string, cert, issuer *x509.Certificate) (RevocationResult, error) {
+	result, err := QueryOCSP(ctx, url, cert, issuer)
+	return result.RevocationResult, err
+}
+
+// OCSPResult is one OCSP responder's answer about a certificate, with what
+// the answer says of its own freshness.
+type OCSPResult struct {
+	RevocationResult
+	// Responder is the URL that was asked.
+	Responder string
+	// ProducedAt is when the responder signed the answer; ThisUpdate and
+	// NextUpdate bound the time it is good for. NextUpdate is zero when
+	// the responder does not say.
+	ProducedAt time.Time
+	ThisUpdate time.Time
+	NextUpdate time.Time
+	// Elapsed is how long the responder took to answer.
+	Elapsed time.Duration
+}
+
+// QueryOCSP asks the OCSP responder at url about cert, and checks that the
+// answer is signed by issuer or by a responder it delegated to. A good
+// answer out of date, or dated in the future, is unknown, its Err saying so;
+// a revocation does not lapse, and stands however old the answer.
+func QueryOCSP(ctx context.Context, url string, cert, issuer *x509.Certificate) (OCSPResult, error) {
+	// The request identifies cert by SHA-1 hashes, the default: responders
+	// following RFC 5019, as those of most public CAs do, know no other.
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return OCSPResult{}, fmt.Errorf("failed to build OCSP request: %w", err)
+	}
+	start := time.Now()
+	body, err := fetchRevocation(ctx, http.MethodPost, url, request)
+	elapsed := time.Since(start)
+	if err != nil {
+		return OCSPResult{Responder: url, Elapsed: elapsed}, err
+	}
+	response, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return OCSPResult{Responder: url, Elapsed: elapsed}, fmt.Errorf("bad OCSP response from %s: %w", url, err)
+	}
+
+	result := OCSPResult{
+		RevocationResult: RevocationResult{Source: "OCSP " + url},
+		Responder:        url,
+		ProducedAt:       response.ProducedAt,
+		ThisUpdate:       response.ThisUpdate,
+		NextUpdate:       response.NextUpdate,
+		Elapsed:          elapsed,
+	}
+	switch response.Status {
+	case ocsp.Good:
+		result.Status = RevocationGood
+		if err := staleAnswer(response.ThisUpdate, response.NextUpdate); err != nil {
+			result.Status, result.Err = RevocationUnknown, err
+		}
+	case ocsp.Revoked:
+		result.Status = RevocationRevoked
+		result.RevokedAt = response.RevokedAt
+		result.Reason = response.RevocationReason
+	default:
+		result.Err = errors.New("the responder does not know this certificate")
+	}
+	return result, nil
+}
+
+// checkCRL downloads one CRL and looks cert up in it.
+func checkCRL(ctx context.Context, url string, cert, issuer *x509.Certificate) (RevocationResult, error) {
+	crl, err := FetchCRL(ctx, url)
+	if err != nil {
+		return RevocationResult{}, err
+	}
+	result, err := LookupCRL(crl, cert, issuer)
+	if err != nil {
+		return RevocationResult{}, fmt.Errorf("CRL from %s: %w", url, err)
+	}
+	result.Source = "CRL " + url
+	return result, nil
+}
+
+// FetchCRL downloads the CRL at an http or https URL.
+func FetchCRL(ctx context.Context, url string) (*x509.RevocationList, error) {
+	body, err := fetchRevocation(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := ParseCRL(body)
+	if err != nil {
+		return nil, fmt.Errorf("bad CRL from %s: %w", url, err)
+	}
+	return crl, nil
+}
+
+// LoadCRL reads a CRL from a file. See ParseCRL.
+func LoadCRL(filename string) (*x509.RevocationList, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CRL: %w", err)
+	}
+	return ParseCRL(data)
+}
+
+// ParseCRL decodes a certificate revocation list, PEM ("X509 CRL") or DER.
+func ParseCRL(data []byte) (*x509.RevocationList, error) {
+	der := data
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "X509 CRL" {
+			return nil, fmt.Errorf("PEM block is %q, not an X509 CRL", block.Type)
+		}
+		der = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CRL: %w", err)
+	}
+	return crl, nil
+}
+
+// LookupCRL looks cert up in crl. The CRL must be issued under the name
+// cert's issuer has and, when issuer is given, be signed by it; otherwise it
+// says nothing about cert and an error says why. A CRL out of date, or dated
+// in the future, cannot say cert is not revoked: the status is unknown, Err
+// saying so, unless the CRL lists it.
+func LookupCRL(crl *x509.RevocationList, cert, issuer *x509.Certificate) (RevocationResult, error) {
+	if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+		return RevocationResult{}, fmt.Errorf("CRL is issued by '%s', not by the issuer of '%s', '%s'",
+			nameOrUnknown(crl.Issuer.CommonName), nameOrUnknown(cert.Subject.CommonName), nameOrUnknown(cert.Issuer.CommonName))
+	}
+	if issuer != nil {
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			return RevocationResult{}, fmt.Errorf("CRL is not signed by the issuer: %w", err)
+		}
+	}
+
+	result := RevocationResult{Status: RevocationGood, Source: "CRL"}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			result.Status = RevocationRevoked
+			result.RevokedAt = entry.RevocationTime
+			result.Reason = entry.ReasonCode
+			return result, nil
+		}
+	}
+	if err := staleAnswer(crl.ThisUpdate, crl.NextUpdate); err != nil {
+		return RevocationResult{Source: "CRL", Err: err}, nil
+	}
+	return result, nil
+}
+
+// fetchRevocation makes one request to a responder or distribution point.
+func fetchRevocation(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("unsupported URL %q: only http and https are fetched", url)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultConnectTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", url, err)
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/ocsp-request")
+	}
+
+	logger.Info("checking revocation", zap.String("url", url))
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		logRequest("revocation request", start, err, zap.String("method", method), zap.String("url", url))
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	logRequest("revocation request", start, nil, zap.String("method", method), zap.String("url", url), zap.Int("status", resp.StatusCode))
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Warn("failed to close revocation response", zap.Error(closeErr))
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// IssuerOf picks the certificate in pool that issued cert, or nil when it
+// is not there. A self-signed certificate has no issuer to ask.
+func IssuerOf(cert *x509.Certificate, pool []*x509.Certificate) *x509.Certificate {
+	if IsSelfSigned(cert) {
+		return nil
+	}
+	for _, candidate := range pool {
+		if !candidate.Equal(cert) && issues(candidate, cert) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// revocationReasons names the RFC 5280 CRLReason codes.
+var revocationReasons = map[int]string{
+	0:  "unspecified",
+	1:  "keyCompromise",
+	2:  "cACompromise",
+	3:  "affiliationChanged",
+	4:  "superseded",
+	5:  "cessationOfOperation",
+	6:  "certificateHold",
+	8:  "removeFromCRL",
+	9:  "privilegeWithdrawn",
+	10: "aACompromise",
+}
+
+// RevocationReasonName names an RFC 5280 CRLReason code, as keyCompromise.
+func RevocationReasonName(code int) string {
+	if reason, ok := revocationReasons[code]; ok {
+		return reason
+	}
+	return fmt.Sprintf("reason %d", code)
+}
+
+// Check reports the result as the Revocation check, in place of the one
+// CheckCertificate makes without going to the network.
+func (r RevocationResult) Check() Check {
+	check := Check{Name: "Revocation"}
+	switch r.Status {
+	case RevocationGood:
+		check.Status = CheckPass
+		check.Detail = "not revoked, per " + r.Source
+	case RevocationRevoked:
+		check.Status = CheckFail
+		check.Detail = fmt.Sprintf("revoked on %s (%s), per %s", FormatDate(r.RevokedAt), RevocationReasonName(r.Reason), r.Source)
+	default:
+		check.Status = CheckWarn
+		check.Detail = "status unknown"
+		if r.Err != nil {
+			check.Detail += ": " + r.Err.Error()
+		}
+	}
+	return check
+}
@@ -0,0 +1,293 @@
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// issueWithRevocation issues a leaf that publishes the given OCSP responder
+// and CRL distribution point.
+func issueWithRevocation(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, ocspURL, crlURL string) *x509.Certificate {
+	t.Helper()
+
+	leaf, _ := issue(t, "leaf.example.com", false, ca, caKey, func(c *x509.Certificate) {
+		if ocspURL != "" {
+			c.OCSPServer = []string{ocspURL}
+		}
+		if crlURL != "" {
+			c.CRLDistributionPoints = []string{crlURL}
+		}
+	})
+	return leaf
+}
+
+func TestCheckRevocation_OCSP(t *testing.T) {
+	ca, caKey := issue(t, "Issuing CA", true, nil, nil)
+
+	for _, tc := range []struct {
+		status int
+		want   RevocationStatus
+	}{
+		{ocsp.Good, RevocationGood},
+		{ocsp.Revoked, RevocationRevoked},
+		{ocsp.Unknown, RevocationUnknown},
+	} {
+		var leaf *x509.Certificate
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if _, err := ocsp.ParseRequest(body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+				Status:           tc.status,
+				SerialNumber:     leaf.SerialNumber,
+				ThisUpdate:       time.Now().Add(-time.Minute),
+				NextUpdate:       time.Now().Add(time.Hour),
+				RevokedAt:        time.Now().Add(-time.Minute),
+				RevocationReason: ocsp.KeyCompromise,
+			}, caKey)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write(resp)
+		}))
+		leaf = issueWithRevocation(t, ca, caKey, server.URL, "")
+
+		result := CheckRevocation(context.Background(), leaf, ca)
+		server.Close()
+		if result.Status != tc.want {
+			t.Errorf("OCSP status %d: got %s (%v), want %s", tc.status, result.Status, result.Err, tc.want)
+		}
+		if tc.want == RevocationRevoked && result.Reason != ocsp.KeyCompromise {
+			t.Errorf("revocation reason = %d, want keyCompromise", result.Reason)
+		}
+	}
+}
+
+func TestCheckRevocation_CRL(t *testing.T) {
+	ca, caKey := issue(t, "Issuing CA", true, nil, nil)
+
+	var revoked *big.Int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: time.Now().Add(-time.Minute),
+			NextUpdate: time.Now().Add(time.Hour),
+			RevokedCertificateEntries: []x509.RevocationListEntry{
+				{SerialNumber: revoked, RevocationTime: time.Now().Add(-time.Minute)},
+			},
+		}, ca, caKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(crl)
+	}))
+	defer server.Close()
+
+	leaf := issueWithRevocation(t, ca, caKey, "", server.URL)
+	other := issueWithRevocation(t, ca, caKey, "", server.URL)
+	revoked = leaf.SerialNumber
+
+	if result := CheckRevocation(context.Background(), leaf, ca); result.Status != RevocationRevoked {
+		t.Errorf("revoked leaf: got %s (%v)", result.Status, result.Err)
+	}
+	if result := CheckRevocation(context.Background(), other, ca); result.Status != RevocationGood {
+		t.Errorf("leaf not on the CRL: got %s (%v)", result.Status, result.Err)
+	}
+
+	// A CRL signed by someone else says nothing about the leaf.
+	impostor, _ := issue(t, "Issuing CA", true, nil, nil)
+	if result := CheckRevocation(context.Background(), other, impostor); result.Status != RevocationUnknown || result.Err == nil {
+		t.Errorf("CRL from the wrong issuer: got %s (%v), want unknown", result.Status, result.Err)
+	}
+}
+
+func TestCheckRevocation_NothingPublished(t *testing.T) {
+	ca, caKey := issue(t, "Issuing CA", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example.com", false, ca, caKey)
+
+	if result := CheckRevocation(context.Background(), leaf, ca); result.Status != RevocationUnknown || result.Err == nil {
+		t.Errorf("got %s (%v), want unknown with a reason", result.Status, result.Err)
+	}
+	if IssuerOf(leaf, []*x509.Certificate{leaf, ca}) != ca {
+		t.Error("IssuerOf did not find the CA")
+	}
+	if IssuerOf(ca, []*x509.Certificate{leaf, ca}) != nil {
+		t.Error("IssuerOf found an issuer for a self-signed root")
+	}
+}
+
+func TestQueryOCSP(t *testing.T) {
+	ca, caKey := issue(t, "Issuing CA", true, nil, nil)
+	thisUpdate := time.Now().Add(-time.Minute).Truncate(time.Second)
+	nextUpdate := thisUpdate.Add(7 * 24 * time.Hour)
+
+	var leaf *x509.Certificate
+	var hash crypto.Hash
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if req, err := ocsp.ParseRequest(body); err == nil {
+			hash = req.HashAlgorithm
+		}
+		resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: leaf.SerialNumber,
+			ThisUpdate:   thisUpdate,
+			NextUpdate:   nextUpdate,
+		}, caKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(resp)
+	}))
+	defer server.Close()
+	leaf = issueWithRevocation(t, ca, caKey, server.URL, "")
+
+	result, err := QueryOCSP(context.Background(), server.URL, leaf, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != RevocationGood || result.Responder != server.URL {
+		t.Errorf("got %s from %s", result.Status, result.Responder)
+	}
+	if !result.ThisUpdate.Equal(thisUpdate) || !result.NextUpdate.Equal(nextUpdate) || result.ProducedAt.IsZero() {
+		t.Errorf("this update %s, next update %s, produced at %s", result.ThisUpdate, result.NextUpdate, result.ProducedAt)
+	}
+	if result.Elapsed <= 0 {
+		t.Error("Elapsed not measured")
+	}
+	// RFC 5019 responders know SHA-1 CertIDs only.
+	if hash != crypto.SHA1 {
+		t.Errorf("the request hashed with %v, want SHA-1", hash)
+	}
+
+	if _, err := QueryOCSP(context.Background(), "ldap://ocsp.example.com", leaf, ca); err == nil {
+		t.Error("QueryOCSP asked an ldap responder")
+	}
+	if RevocationReasonName(ocsp.KeyCompromise) != "keyCompromise" || RevocationReasonName(42) != "reason 42" {
+		t.Error("RevocationReasonName")
+	}
+}
+
+// TestQueryOCSP_Stale checks that a good answer out of date, or dated in the
+// future, is no answer, while a revocation stands however old.
+func TestQueryOCSP_Stale(t *testing.T) {
+	ca, caKey := issue(t, "Issuing CA", true, nil, nil)
+	now := time.Now()
+
+	for _, tc := range []struct {
+		name                   string
+		status                 int
+		thisUpdate, nextUpdate time.Time
+		want                   RevocationStatus
+		wantErr                string
+	}{
+		{"expired", ocsp.Good, now.Add(-48 * time.Hour), now.Add(-24 * time.Hour), RevocationUnknown, "out of date"},
+		{"future", ocsp.Good, now.Add(time.Hour), now.Add(48 * time.Hour), RevocationUnknown, "in the future"},
+		{"expired revocation", ocsp.Revoked, now.Add(-48 * time.Hour), now.Add(-24 * time.Hour), RevocationRevoked, ""},
+	} {
+		var leaf *x509.Certificate
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			resp, err := ocsp.CreateResponse(ca, ca, ocsp.Response{
+				Status:       tc.status,
+				SerialNumber: leaf.SerialNumber,
+				ThisUpdate:   tc.thisUpdate,
+				NextUpdate:   tc.nextUpdate,
+				RevokedAt:    now.Add(-72 * time.Hour),
+			}, caKey)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write(resp)
+		}))
+		leaf = issueWithRevocation(t, ca, caKey, server.URL, "")
+
+		result, err := QueryOCSP(context.Background(), server.URL, leaf, ca)
+		// With no CRL to fall back on, CheckRevocation gives the reason the
+		// answer was refused.
+		checked := CheckRevocation(context.Background(), leaf, ca)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Status != tc.want || checked.Status != tc.want {
+			t.Errorf("%s: got %s and %s from CheckRevocation, want %s", tc.name, result.Status, checked.Status, tc.want)
+		}
+		if tc.wantErr != "" && (result.Err == nil || !strings.Contains(result.Err.Error(), tc.wantErr)) {
+			t.Errorf("%s: Err = %v, want it to say %q", tc.name, result.Err, tc.wantErr)
+		}
+		if tc.wantErr != "" && (checked.Err == nil || !strings.Contains(checked.Err.Error(), tc.wantErr)) {
+			t.Errorf("%s: CheckRevocation Err = %v, want it to say %q", tc.name, checked.Err, tc.wantErr)
+		}
+	}
+}
+
+func TestParseCRLAndLookup(t *testing.T) {
+	ca, caKey := issue(t, "Issuing CA", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example.com", false, ca, caKey)
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crl, err := ParseCRL(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := LookupCRL(crl, leaf, ca); err != nil || result.Status != RevocationGood {
+		t.Errorf("leaf not on the CRL: got %s (%v)", result.Status, err)
+	}
+	if result, err := LookupCRL(crl, leaf, nil); err != nil || result.Status != RevocationGood {
+		t.Errorf("without the issuer: got %s (%v)", result.Status, err)
+	}
+
+	// The CRL of another CA says nothing about the leaf.
+	other, otherKey := issue(t, "Other CA", true, nil, nil)
+	stranger, _ := issue(t, "stranger.example.com", false, other, otherKey)
+	if _, err := LookupCRL(crl, stranger, nil); err == nil || !strings.Contains(err.Error(), "Other CA") {
+		t.Errorf("CRL of another issuer: %v", err)
+	}
+
+	// An out of date CRL cannot say the leaf is not revoked.
+	der, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(2),
+		ThisUpdate: time.Now().Add(-48 * time.Hour),
+		NextUpdate: time.Now().Add(-24 * time.Hour),
+	}, ca, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crl, err = ParseCRL(der); err != nil {
+		t.Fatal(err)
+	}
+	result, err := LookupCRL(crl, leaf, ca)
+	if err != nil || result.Status != RevocationUnknown || result.Err == nil || !strings.Contains(result.Err.Error(), "out of date") {
+		t.Errorf("expired CRL: got %s (%v, %v), want unknown", result.Status, result.Err, err)
+	}
+
+	if _, err := ParseCRL(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})); err == nil {
+		t.Error("ParseCRL read a certificate")
+	}
+}
@@ -0,0 +1,365 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// oidSCTList is the embedded SignedCertificateTimestampList extension (RFC
+// 6962, section 3.3).
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// SCT is one Signed Certificate Timestamp: a CT log's promise that it has
+// logged the certificate.
+type SCT struct {
+	// Version is the SCT version; only v1 (0) exists.
+	Version uint8
+	// LogID is the SHA-256 hash of the log's public key.
+	LogID [32]byte
+	// Timestamp is when the log accepted the precertificate.
+	Timestamp time.Time
+	// Extensions are the SCT's own extensions, empty in practice.
+	Extensions []byte
+	// HashAlgorithm and SignatureAlgorithm are the TLS identifiers of the
+	// signature: 4 is SHA-256, 1 is RSA and 3 is ECDSA.
+	HashAlgorithm      uint8
+	SignatureAlgorithm uint8
+	// Signature is the log's signature over the timestamped entry.
+	Signature []byte
+}
+
+// ParseSCTs decodes the SCT list embedded in a certificate. It returns nil
+// and no error when the certificate carries none.
+func ParseSCTs(cert *x509.Certificate) ([]SCT, error) {
+	var value []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidSCTList) {
+			value = ext.Value
+			break
+		}
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	// The extension value is an OCTET STRING wrapping a TLS-encoded list, not
+	// more DER.
+	var list []byte
+	if _, err := asn1.Unmarshal(value, &list); err != nil {
+		return nil, fmt.Errorf("malformed SCT list extension: %w", err)
+	}
+	entries, rest, err := readOpaque16(list)
+	if err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("malformed SCT list")
+	}
+
+	var scts []SCT
+	for len(entries) > 0 {
+		var raw []byte
+		if raw, entries, err = readOpaque16(entries); err != nil {
+			return nil, fmt.Errorf("malformed SCT list")
+		}
+		sct, err := parseSCT(raw)
+		if err != nil {
+			return nil, err
+		}
+		scts = append(scts, sct)
+	}
+	return scts, nil
+}
+
+// parseSCT decodes a single serialized v1 SCT.
+func parseSCT(raw []byte) (SCT, error) {
+	var sct SCT
+	if len(raw) < 1+32+8 {
+		return sct, fmt.Errorf("SCT too short")
+	}
+	sct.Version = raw[0]
+	if sct.Version != 0 {
+		return sct, fmt.Errorf("unsupported SCT version %d", sct.Version)
+	}
+	copy(sct.LogID[:], raw[1:33])
+	sct.Timestamp = time.UnixMilli(int64(binary.BigEndian.Uint64(raw[33:41]))).UTC()
+
+	rest := raw[41:]
+	var err error
+	if sct.Extensions, rest, err = readOpaque16(rest); err != nil {
+		return sct, fmt.Errorf("malformed SCT extensions")
+	}
+	if len(rest) < 2 {
+		return sct, fmt.Errorf("malformed SCT signature")
+	}
+	sct.HashAlgorithm, sct.SignatureAlgorithm = rest[0], rest[1]
+	if sct.Signature, rest, err = readOpaque16(rest[2:]); err != nil || len(rest) > 0 {
+		return sct, fmt.Errorf("malformed SCT signature")
+	}
+	return sct, nil
+}
+
+// readOpaque16 splits a TLS opaque<0..2^16-1> off the front of data.
+func readOpaque16(data []byte) ([]byte, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, fmt.Errorf("truncated length")
+	}
+	n := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+n {
+		return nil, nil, fmt.Errorf("truncated value")
+	}
+	return data[2 : 2+n], data[2+n:], nil
+}
+
+// CTLog is a Certificate Transparency log from a log list.
+type CTLog struct {
+	// Description is the log's human-readable name, e.g. "Google 'Argon2026h1'".
+	Description string
+	// Operator runs the log.
+	Operator string
+	// Key is the log's public key.
+	Key crypto.PublicKey
+}
+
+// CTLogList indexes known logs by log ID.
+type CTLogList map[[32]byte]*CTLog
+
+// ParseCTLogList reads a log list in the v3 JSON schema Google and Apple
+// publish (https://www.gstatic.com/ct/log_list/v3/log_list.json). Logs that
+// have been retired are kept: an SCT from one is still a valid signature, and
+// whether it still counts towards a policy is the browser's call.
+func ParseCTLogList(data []byte) (CTLogList, error) {
+	type logEntry struct {
+		Description string `json:"description"`
+		Key         string `json:"key"`
+	}
+	var doc struct {
+		Operators []struct {
+			Name      string     `json:"name"`
+			Logs      []logEntry `json:"logs"`
+			TiledLogs []logEntry `json:"tiled_logs"`
+		} `json:"operators"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("malformed CT log list: %w", err)
+	}
+
+	logs := make(CTLogList)
+	for _, operator := range doc.Operators {
+		for _, entry := range append(operator.Logs, operator.TiledLogs...) {
+			der, err := base64.StdEncoding.DecodeString(entry.Key)
+			if err != nil {
+				return nil, fmt.Errorf("CT log %q: malformed key: %w", entry.Description, err)
+			}
+			key, err := x509.ParsePKIXPublicKey(der)
+			if err != nil {
+				return nil, fmt.Errorf("CT log %q: %w", entry.Description, err)
+			}
+			// The log ID is defined as the hash of the key, so derive it
+			// rather than trusting the list's copy.
+			logs[sha256.Sum256(der)] = &CTLog{
+				Description: entry.Description,
+				Operator:    operator.Name,
+				Key:         key,
+			}
+		}
+	}
+	return logs, nil
+}
+
+// LoadCTLogList reads a log list file.
+func LoadCTLogList(path string) (CTLogList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CT log list: %w", err)
+	}
+	return ParseCTLogList(data)
+}
+
+// SCTCheck is the outcome of checking one SCT against the log list.
+type SCTCheck struct {
+	SCT SCT
+	// Log is the log that issued the SCT, or nil when it is not in the list.
+	Log *CTLog
+	// Err is why the signature did not verify. It is nil both when the SCT
+	// verified and when it could not be checked; Verified tells them apart.
+	Err error
+	// Verified is true when the log's signature checked out.
+	Verified bool
+}
+
+// CheckSCTs decodes the certificate's embedded SCTs and verifies each against
+// the log list. certs are searched for the certificate's issuer, whose key is
+// part of what the log signed. logs may be nil, in which case the SCTs are
+// only decoded.
+func CheckSCTs(cert *x509.Certificate, certs []*x509.Certificate, logs CTLogList) ([]SCTCheck, error) {
+	scts, err := ParseSCTs(cert)
+	if err != nil || len(scts) == 0 {
+		return nil, err
+	}
+
+	var issuer *x509.Certificate
+	if path := issuerPath(cert, certs); len(path) > 1 {
+		issuer = path[1]
+	}
+	checks := make([]SCTCheck, len(scts))
+	for i, sct := range scts {
+		checks[i] = SCTCheck{SCT: sct, Log: logs[sct.LogID]}
+		if checks[i].Log == nil {
+			continue
+		}
+		if issuer == nil {
+			checks[i].Err = fmt.Errorf("issuer not present in input")
+			continue
+		}
+		if err := VerifySCT(sct, cert, issuer, checks[i].Log); err != nil {
+			checks[i].Err = err
+			continue
+		}
+		checks[i].Verified = true
+	}
+	return checks, nil
+}
+
+// VerifySCT checks an embedded SCT's signature. An embedded SCT was issued
+// for the precertificate, so the signed data is rebuilt from the final
+// certificate with its SCT list removed, bound to the issuer's key.
+func VerifySCT(sct SCT, cert, issuer *x509.Certificate, log *CTLog) error {
+	tbs, err := tbsWithoutSCTs(cert.RawTBSCertificate)
+	if err != nil {
+		return err
+	}
+	if len(tbs) >= 1<<24 {
+		return fmt.Errorf("certificate too large for a CT entry")
+	}
+
+	// digitally-signed struct from RFC 6962, section 3.2, for a precert_entry.
+	var signed []byte
+	signed = append(signed, sct.Version, 0) // certificate_timestamp
+	signed = binary.BigEndian.AppendUint64(signed, uint64(sct.Timestamp.UnixMilli()))
+	signed = append(signed, 0, 1) // precert_entry
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	signed = append(signed, issuerKeyHash[:]...)
+	signed = append(signed, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+	signed = append(signed, tbs...)
+	signed = binary.BigEndian.AppendUint16(signed, uint16(len(sct.Extensions)))
+	signed = append(signed, sct.Extensions...)
+
+	if sct.HashAlgorithm != 4 {
+		return fmt.Errorf("unsupported SCT hash algorithm %d", sct.HashAlgorithm)
+	}
+	digest := sha256.Sum256(signed)
+
+	switch key := log.Key.(type) {
+	case *ecdsa.PublicKey:
+		if sct.SignatureAlgorithm != 3 || !ecdsa.VerifyASN1(key, digest[:], sct.Signature) {
+			return errors.New("SCT signature does not verify")
+		}
+	case *rsa.PublicKey:
+		if sct.SignatureAlgorithm != 1 || rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sct.Signature) != nil {
+			return errors.New("SCT signature does not verify")
+		}
+	default:
+		return fmt.Errorf("unsupported CT log key type %T", log.Key)
+	}
+	return nil
+}
+
+// tbsWithoutSCTs re-encodes a TBSCertificate with the SCT list extension
+// dropped, which is what the precertificate looked like once its poison
+// extension was removed.
+func tbsWithoutSCTs(rawTBS []byte) ([]byte, error) {
+	var tbs asn1.RawValue
+	if _, err := asn1.Unmarshal(rawTBS, &tbs); err != nil {
+		return nil, fmt.Errorf("malformed TBSCertificate: %w", err)
+	}
+
+	var fields []byte
+	for rest := tbs.Bytes; len(rest) > 0; {
+		var field asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &field); err != nil {
+			return nil, fmt.Errorf("malformed TBSCertificate: %w", err)
+		}
+		if field.Class != asn1.ClassContextSpecific || field.Tag != 3 {
+			fields = append(fields, field.FullBytes...)
+			continue
+		}
+
+		// [3] EXPLICIT Extensions: a SEQUENCE of Extension.
+		var extensions asn1.RawValue
+		if _, err := asn1.Unmarshal(field.Bytes, &extensions); err != nil {
+			return nil, fmt.Errorf("malformed extensions: %w", err)
+		}
+		var kept []byte
+		for extRest := extensions.Bytes; len(extRest) > 0; {
+			var ext asn1.RawValue
+			if extRest, err = asn1.Unmarshal(extRest, &ext); err != nil {
+				return nil, fmt.Errorf("malformed extension: %w", err)
+			}
+			var id asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(ext.Bytes, &id); err != nil {
+				return nil, fmt.Errorf("malformed extension: %w", err)
+			}
+			if !id.Equal(oidSCTList) {
+				kept = append(kept, ext.FullBytes...)
+			}
+		}
+		sequence, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: kept})
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 3, IsCompound: true, Bytes: sequence})
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, wrapped...)
+	}
+
+	return asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: fields})
+}
+
+// FormatSCT describes one check in a line: which log, when, and whether the
+// signature verified.
+func FormatSCT(check SCTCheck) string {
+	name := "unknown log " + hex.EncodeToString(check.SCT.LogID[:4])
+	if check.Log != nil {
+		name = check.Log.Description
+	}
+
+	status := "not verified"
+	switch {
+	case check.Verified:
+		status = "✓ verified"
+	case check.Err != nil:
+		status = "✗ " + check.Err.Error()
+	case check.Log == nil:
+		status = "not in log list"
+	}
+	return fmt.Sprintf("%s, %s (%s)", name, FormatTime(check.SCT.Timestamp), status)
+}
+
+// FormatSCTChecks renders the checks for the terminal. It returns an empty
+// string when there are none, so a caller can print it unconditionally.
+func FormatSCTChecks(checks []SCTCheck) string {
+	if len(checks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Certificate Transparency (%d SCTs):\n", len(checks))
+	for _, check := range checks {
+		fmt.Fprintf(&sb, "  • %s\n", FormatSCT(check))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
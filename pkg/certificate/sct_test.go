@@ -0,0 +1,158 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logListFor builds a one-log v3 log list around the given key.
+func logListFor(t *testing.T, key *ecdsa.PrivateKey) CTLogList {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := fmt.Sprintf(`{"operators":[{"name":"Test","logs":[{"description":"Test 'Log2026'","key":%q}]}]}`,
+		base64.StdEncoding.EncodeToString(der))
+	logs, err := ParseCTLogList([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseCTLogList: %v", err)
+	}
+	return logs
+}
+
+// issueWithSCT mints a leaf carrying one embedded SCT from logKey, signed the
+// way a log signs a precertificate: over the TBS without the SCT list.
+func issueWithSCT(t *testing.T, issuer *x509.Certificate, issuerKey, logKey *ecdsa.PrivateKey, when time.Time) *x509.Certificate {
+	t.Helper()
+
+	// Stand-in for the precertificate: the leaf without the SCTs, whose
+	// template and key the leaf proper is signed again from.
+	var template *x509.Certificate
+	precert, key := issue(t, "ct.example", false, issuer, issuerKey, func(c *x509.Certificate) { template = c })
+	tbs := precert.RawTBSCertificate
+
+	logDER, err := x509.MarshalPKIXPublicKey(&logKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(logDER)
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+
+	signed := []byte{0, 0}
+	signed = binary.BigEndian.AppendUint64(signed, uint64(when.UnixMilli()))
+	signed = append(signed, 0, 1)
+	signed = append(signed, issuerKeyHash[:]...)
+	signed = append(signed, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+	signed = append(signed, tbs...)
+	signed = append(signed, 0, 0)
+	digest := sha256.Sum256(signed)
+	signature, err := ecdsa.SignASN1(rand.Reader, logKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sct := []byte{0}
+	sct = append(sct, logID[:]...)
+	sct = binary.BigEndian.AppendUint64(sct, uint64(when.UnixMilli()))
+	sct = append(sct, 0, 0, 4, 3)
+	sct = binary.BigEndian.AppendUint16(sct, uint16(len(signature)))
+	sct = append(sct, signature...)
+
+	entry := binary.BigEndian.AppendUint16(nil, uint16(len(sct)))
+	entry = append(entry, sct...)
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(entry)))
+	list = append(list, entry...)
+	value, err := asn1.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.ExtraExtensions = []pkix.Extension{{Id: oidSCTList, Value: value}}
+	return sign(t, template, key, issuer, issuerKey)
+}
+
+func TestCheckSCTs_Verifies(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	leaf := issueWithSCT(t, root, rootKey, logKey, when)
+
+	checks, err := CheckSCTs(leaf, []*x509.Certificate{leaf, root}, logListFor(t, logKey))
+	if err != nil {
+		t.Fatalf("CheckSCTs: %v", err)
+	}
+	if len(checks) != 1 {
+		t.Fatalf("got %d SCTs, want 1", len(checks))
+	}
+	check := checks[0]
+	if !check.Verified || check.Err != nil {
+		t.Errorf("SCT did not verify: %v", check.Err)
+	}
+	if !check.SCT.Timestamp.Equal(when) {
+		t.Errorf("Timestamp = %v, want %v", check.SCT.Timestamp, when)
+	}
+	if got := FormatSCT(check); !strings.Contains(got, "Test 'Log2026'") || !strings.Contains(got, "verified") {
+		t.Errorf("FormatSCT = %q", got)
+	}
+}
+
+func TestCheckSCTs_WrongIssuerFails(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := issueWithSCT(t, root, rootKey, logKey, time.Now())
+
+	// The issuer key hash is part of the signed entry, so checking against
+	// a different issuer has to fail.
+	impostor, _ := issue(t, "Root", true, nil, nil)
+	checks, err := CheckSCTs(leaf, []*x509.Certificate{leaf, impostor}, logListFor(t, logKey))
+	if err != nil {
+		t.Fatalf("CheckSCTs: %v", err)
+	}
+	if len(checks) != 1 || checks[0].Verified || checks[0].Err == nil {
+		t.Errorf("SCT verified against the wrong issuer: %+v", checks)
+	}
+}
+
+func TestCheckSCTs_UnknownLogIsDecodedOnly(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	logKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := issueWithSCT(t, root, rootKey, logKey, time.Now())
+
+	checks, err := CheckSCTs(leaf, []*x509.Certificate{leaf, root}, nil)
+	if err != nil {
+		t.Fatalf("CheckSCTs: %v", err)
+	}
+	if len(checks) != 1 || checks[0].Verified || checks[0].Err != nil || checks[0].Log != nil {
+		t.Errorf("want one undecided SCT, got %+v", checks)
+	}
+	if got := FormatSCTChecks(checks); !strings.Contains(got, "not in log list") {
+		t.Errorf("FormatSCTChecks = %q", got)
+	}
+
+	plain, _ := issue(t, "plain.example", false, root, rootKey)
+	if checks, err := CheckSCTs(plain, nil, nil); checks != nil || err != nil {
+		t.Errorf("certificate without SCTs: got %v, %v", checks, err)
+	}
+}
@@ -0,0 +1,167 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// VerifyStep is one of the checks VerifySteps makes, and how it came out.
+type VerifyStep struct {
+	// Name says what was checked: validity, signatures, constraints, trust,
+	// hostname or usage.
+	Name string
+	// Passed is whether the check passed.
+	Passed bool
+	// Detail says what was found, as a sentence or a few joined with "; ".
+	Detail string
+}
+
+// VerifySteps verifies a leaf-first chain as a TLS client would, one step at
+// a time, so that a failure says which step failed rather than only the
+// first error the verifier ran into. The hostname step is made only when
+// opts.DNSName is set, and the usage step only when usages are given; every
+// other step is always made, so a chain with several faults reports them
+// all.
+//
+// The signature and constraint steps follow the path up from the leaf
+// through the chain and opts.ExtraRoots; the trust step asks Go's verifier
+// for a path to a trust anchor, as VerifyChain does.
+func VerifySteps(certs []*x509.Certificate, opts VerifyOptions, usages []x509.ExtKeyUsage) ([]VerifyStep, error) {
+	if len(certs) == 0 || certs[0] == nil {
+		return nil, fmt.Errorf("empty certificate chain")
+	}
+	leaf := certs[0]
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	path := issuerPath(leaf, append(slices.Clone(certs), opts.ExtraRoots...))
+
+	steps := []VerifyStep{
+		validityStep(certs, now),
+		signatureStep(path),
+		constraintStep(path),
+	}
+
+	trust, err := trustStep(certs, opts)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, trust)
+
+	if opts.DNSName != "" {
+		step := VerifyStep{Name: "hostname", Passed: true, Detail: fmt.Sprintf("'%s' is valid for %s", displayName(leaf), opts.DNSName)}
+		if err := leaf.VerifyHostname(opts.DNSName); err != nil {
+			step.Passed, step.Detail = false, err.Error()
+		}
+		steps = append(steps, step)
+	}
+	if len(usages) > 0 {
+		steps = append(steps, usageStep(path, usages))
+	}
+	return steps, nil
+}
+
+// validityStep checks every certificate in the chain is within its validity
+// period at now.
+func validityStep(certs []*x509.Certificate, now time.Time) VerifyStep {
+	var faults []string
+	for _, cert := range certs {
+		switch {
+		case now.After(cert.NotAfter):
+			faults = append(faults, fmt.Sprintf("'%s' expired on %s", displayName(cert), FormatDate(cert.NotAfter)))
+		case now.Before(cert.NotBefore):
+			faults = append(faults, fmt.Sprintf("'%s' is not valid until %s", displayName(cert), FormatDate(cert.NotBefore)))
+		}
+	}
+	if len(faults) > 0 {
+		return VerifyStep{Name: "validity", Detail: strings.Join(faults, "; ")}
+	}
+	return VerifyStep{Name: "validity", Passed: true, Detail: fmt.Sprintf("%d certificate(s) within their validity period", len(certs))}
+}
+
+// signatureStep checks each certificate on the path is signed by the next.
+func signatureStep(path []*x509.Certificate) VerifyStep {
+	var faults []string
+	for i := 0; i+1 < len(path); i++ {
+		if !signedBy(path[i], path[i+1]) {
+			faults = append(faults, fmt.Sprintf("the signature on '%s' does not verify with the key of '%s'", displayName(path[i]), displayName(path[i+1])))
+		}
+	}
+	if len(faults) > 0 {
+		return VerifyStep{Name: "signatures", Detail: strings.Join(faults, "; ")}
+	}
+	if len(path) == 1 && !IsSelfSigned(path[0]) {
+		return VerifyStep{Name: "signatures", Passed: true,
+			Detail: fmt.Sprintf("no issuer of '%s' in the chain to check its signature with", displayName(path[0]))}
+	}
+	return VerifyStep{Name: "signatures", Passed: true, Detail: FormatChainNames(path)}
+}
+
+// constraintStep checks basic constraints and path lengths along the path.
+func constraintStep(path []*x509.Certificate) VerifyStep {
+	violations := CheckBasicConstraints(path)
+	if len(violations) == 0 {
+		return VerifyStep{Name: "constraints", Passed: true, Detail: "every issuer is a CA within its path length"}
+	}
+	faults := make([]string, len(violations))
+	for i, violation := range violations {
+		faults[i] = violation.Error()
+	}
+	return VerifyStep{Name: "constraints", Detail: strings.Join(faults, "; ")}
+}
+
+// trustStep asks Go's verifier for a path from the leaf to a trust anchor,
+// for any usage and any name: those are steps of their own.
+func trustStep(certs []*x509.Certificate, opts VerifyOptions) (VerifyStep, error) {
+	roots, err := trustAnchors(opts)
+	if err != nil {
+		return VerifyStep{}, err
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		if cert != nil {
+			intermediates.AddCert(cert)
+		}
+	}
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   opts.CurrentTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		if missing := missingIssuer(certs, err, roots); missing != nil {
+			err = missing
+		}
+		return VerifyStep{Name: "trust", Detail: err.Error()}, nil
+	}
+	return VerifyStep{Name: "trust", Passed: true, Detail: fmt.Sprintf("chains to trust anchor '%s'", anchorName(chains))}, nil
+}
+
+// usageStep checks every certificate on the path allows each usage: one
+// with extended key usages must list it, or anyExtendedKeyUsage, as Go's
+// verifier and browsers require of intermediates too.
+func usageStep(path []*x509.Certificate, usages []x509.ExtKeyUsage) VerifyStep {
+	var faults, names []string
+	for _, usage := range usages {
+		names = append(names, ExtKeyUsageName(usage))
+		for _, cert := range path {
+			if len(cert.ExtKeyUsage) == 0 && len(cert.UnknownExtKeyUsage) == 0 {
+				continue
+			}
+			if slices.Contains(cert.ExtKeyUsage, usage) || slices.Contains(cert.ExtKeyUsage, x509.ExtKeyUsageAny) {
+				continue
+			}
+			faults = append(faults, fmt.Sprintf("'%s' does not allow %s (it allows %s)",
+				displayName(cert), ExtKeyUsageName(usage), FormatExtKeyUsage(cert)))
+		}
+	}
+	if len(faults) > 0 {
+		return VerifyStep{Name: "usage", Detail: strings.Join(faults, "; ")}
+	}
+	return VerifyStep{Name: "usage", Passed: true, Detail: strings.Join(names, ", ") + " allowed along the chain"}
+}
@@ -0,0 +1,82 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stepsByName indexes VerifySteps' result by step name.
+func stepsByName(t *testing.T, steps []VerifyStep) map[string]VerifyStep {
+	t.Helper()
+	byName := make(map[string]VerifyStep, len(steps))
+	for _, step := range steps {
+		byName[step.Name] = step
+	}
+	return byName
+}
+
+func TestVerifySteps(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Intermediate", true, root, rootKey)
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+	opts := VerifyOptions{ExtraRoots: []*x509.Certificate{root}, SkipSystemRoots: true, DNSName: "leaf.example"}
+
+	steps, err := VerifySteps([]*x509.Certificate{leaf, intermediate}, opts, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+		if !step.Passed {
+			t.Errorf("step %s failed: %s", step.Name, step.Detail)
+		}
+	}
+	if got := strings.Join(names, " "); got != "validity signatures constraints trust hostname usage" {
+		t.Errorf("steps = %s", got)
+	}
+	if detail := stepsByName(t, steps)["signatures"].Detail; detail != "leaf.example → Intermediate → Root" {
+		t.Errorf("signatures detail = %q", detail)
+	}
+
+	// Every fault is reported, each against its own step.
+	opts.DNSName = "other.example"
+	opts.CurrentTime = time.Now().Add(48 * time.Hour)
+	steps, err = VerifySteps([]*x509.Certificate{leaf, intermediate}, opts, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := stepsByName(t, steps)
+	for _, name := range []string{"validity", "trust", "hostname", "usage"} {
+		if byName[name].Passed {
+			t.Errorf("step %s passed, want it failed", name)
+		}
+	}
+	if !byName["signatures"].Passed || !byName["constraints"].Passed {
+		t.Errorf("signatures or constraints failed on a sound chain: %+v", steps)
+	}
+	if !strings.Contains(byName["usage"].Detail, "'leaf.example' does not allow clientAuth") {
+		t.Errorf("usage detail = %q", byName["usage"].Detail)
+	}
+}
+
+func TestVerifySteps_BadSignature(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+	// Another root under the same name, whose key did not sign the leaf.
+	impostor, _ := issue(t, "Root", true, nil, nil)
+
+	steps, err := VerifySteps([]*x509.Certificate{leaf, impostor}, VerifyOptions{ExtraRoots: []*x509.Certificate{impostor}, SkipSystemRoots: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := stepsByName(t, steps)
+	if byName["signatures"].Passed || byName["trust"].Passed {
+		t.Errorf("signatures or trust passed with the wrong issuer key: %+v", steps)
+	}
+	if _, ok := byName["hostname"]; ok {
+		t.Error("hostname step made without a hostname")
+	}
+}
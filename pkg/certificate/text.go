@@ -0,0 +1,567 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// FormatText renders a certificate in the layout of openssl x509 -text, as
+// OpenSSL 3 prints it, so the output can be diffed against existing tooling
+// or pasted into a ticket where that layout is what readers expect.
+//
+// Names follow OpenSSL's default oneline form, and extensions carry
+// OpenSSL's names and value formats. An extension neither knows is printed
+// the way OpenSSL prints one it cannot parse: its raw bytes.
+func FormatText(cert *x509.Certificate) string {
+	var sb strings.Builder
+	line := func(indent int, format string, args ...any) {
+		sb.WriteString(strings.Repeat(" ", indent))
+		fmt.Fprintf(&sb, format, args...)
+		sb.WriteByte('\n')
+	}
+
+	line(0, "Certificate:")
+	line(4, "Data:")
+	line(8, "Version: %d (0x%x)", cert.Version, cert.Version-1)
+	if serial := cert.SerialNumber; serial != nil && serial.Sign() >= 0 && serial.IsInt64() {
+		line(8, "Serial Number: %d (0x%x)", serial, serial)
+	} else {
+		line(8, "Serial Number:%s", negativeSerialSuffix(serial))
+		line(12, "%s", textHex(serialBytes(serial)))
+	}
+	sigAlg, signature := outerSignature(cert)
+	line(8, "Signature Algorithm: %s", sigAlg)
+	line(8, "Issuer: %s", textName(cert.RawIssuer))
+	line(8, "Validity")
+	line(12, "Not Before: %s", cert.NotBefore.UTC().Format(textTime))
+	line(12, "Not After : %s", cert.NotAfter.UTC().Format(textTime))
+	line(8, "Subject: %s", textName(cert.RawSubject))
+	line(8, "Subject Public Key Info:")
+	sb.WriteString(textPublicKey(cert))
+
+	if len(cert.Extensions) > 0 {
+		line(8, "X509v3 extensions:")
+		for _, ext := range cert.Extensions {
+			critical := ""
+			if ext.Critical {
+				critical = "critical"
+			}
+			// OpenSSL leaves the space after the colon even when nothing
+			// follows it; keeping it is what lets the output diff clean.
+			line(12, "%s: %s", textExtensionName(ext.Id.String()), critical)
+			for _, value := range textExtensionValue(cert, ext) {
+				line(16, "%s", value)
+			}
+		}
+	}
+
+	line(4, "Signature Algorithm: %s", sigAlg)
+	line(4, "Signature Value:")
+	sb.WriteString(textHexBlock(signature, 18, 8))
+	return sb.String()
+}
+
+// textTime is the date layout OpenSSL prints: asctime order, padded day.
+const textTime = "Jan _2 15:04:05 2006 GMT"
+
+// negativeSerialSuffix marks a negative serial number the way OpenSSL does.
+func negativeSerialSuffix(serial *big.Int) string {
+	if serial != nil && serial.Sign() < 0 {
+		return " (Negative)"
+	}
+	return ""
+}
+
+// serialBytes is the magnitude of the serial number, with a leading zero
+// byte where the top bit is set, as DER encodes it.
+func serialBytes(serial *big.Int) []byte {
+	if serial == nil {
+		return nil
+	}
+	b := new(big.Int).Abs(serial).Bytes()
+	if len(b) == 0 {
+		return []byte{0}
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+// outerSignature names the certificate's signature algorithm and returns the
+// signature bytes. The name comes from the encoded OID rather than
+// cert.SignatureAlgorithm, so an algorithm Go does not support still shows.
+func outerSignature(cert *x509.Certificate) (string, []byte) {
+	var outer struct {
+		TBS       asn1.RawValue
+		Algorithm pkix.AlgorithmIdentifier
+		Signature asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(cert.Raw, &outer); err != nil {
+		return cert.SignatureAlgorithm.String(), cert.Signature
+	}
+	return textOIDName(outer.Algorithm.Algorithm.String()), outer.Signature.Bytes
+}
+
+// textOIDName is OpenSSL's name for an algorithm OID, or the dotted OID.
+func textOIDName(oid string) string {
+	if name, ok := oidNames[oid]; ok {
+		return name
+	}
+	return oid
+}
+
+// textNameAttributes are OpenSSL's short names for the attributes a
+// distinguished name carries.
+var textNameAttributes = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.4":                    "SN",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.9":                    "street",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"2.5.4.12":                   "title",
+	"2.5.4.15":                   "businessCategory",
+	"2.5.4.17":                   "postalCode",
+	"2.5.4.42":                   "GN",
+	"2.5.4.97":                   "organizationIdentifier",
+	"0.9.2342.19200300.100.1.1":  "UID",
+	"0.9.2342.19200300.100.1.25": "DC",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+	"1.3.6.1.4.1.311.60.2.1.1":   "jurisdictionL",
+	"1.3.6.1.4.1.311.60.2.1.2":   "jurisdictionST",
+	"1.3.6.1.4.1.311.60.2.1.3":   "jurisdictionC",
+}
+
+// textName renders an encoded distinguished name in OpenSSL's oneline form:
+// "C = US, O = Example, CN = example.com", in the order it is encoded, with
+// " + " between the attributes of a multi-valued RDN.
+func textName(raw []byte) string {
+	var rdns pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(raw, &rdns); err != nil || len(rest) > 0 {
+		return "<malformed name>"
+	}
+	parts := make([]string, 0, len(rdns))
+	for _, rdn := range rdns {
+		attributes := make([]string, len(rdn))
+		for i, atv := range rdn {
+			oid := atv.Type.String()
+			name, ok := textNameAttributes[oid]
+			if !ok {
+				name = oid
+			}
+			attributes[i] = name + " = " + textNameValue(fmt.Sprint(atv.Value))
+		}
+		parts = append(parts, strings.Join(attributes, " + "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// textNameValue quotes a value the way OpenSSL's oneline form does when it
+// holds a character that would otherwise read as a separator.
+func textNameValue(value string) string {
+	if value == "" || (!strings.ContainsAny(value, `,+<>;"\#=`) &&
+		value[0] != ' ' && value[len(value)-1] != ' ') {
+		return value
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return `"` + escaped + `"`
+}
+
+// textPublicKey renders the Subject Public Key Info section.
+func textPublicKey(cert *x509.Certificate) string {
+	var sb strings.Builder
+	line := func(indent int, format string, args ...any) {
+		sb.WriteString(strings.Repeat(" ", indent))
+		fmt.Fprintf(&sb, format, args...)
+		sb.WriteByte('\n')
+	}
+
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	algorithm := cert.PublicKeyAlgorithm.String()
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err == nil {
+		algorithm = textOIDName(spki.Algorithm.Algorithm.String())
+	}
+	line(12, "Public Key Algorithm: %s", algorithm)
+
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		line(16, "Public-Key: (%d bit)", pub.N.BitLen())
+		line(16, "Modulus:")
+		modulus := pub.N.Bytes()
+		if len(modulus) > 0 && modulus[0]&0x80 != 0 {
+			modulus = append([]byte{0}, modulus...)
+		}
+		sb.WriteString(textHexBlock(modulus, 15, 20))
+		line(16, "Exponent: %d (0x%x)", pub.E, pub.E)
+	case *ecdsa.PublicKey:
+		line(16, "Public-Key: (%d bit)", pub.Curve.Params().BitSize)
+		line(16, "pub:")
+		sb.WriteString(textHexBlock(spki.PublicKey.Bytes, 15, 20))
+		var curve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(spki.Algorithm.Parameters.FullBytes, &curve); err == nil {
+			line(16, "ASN1 OID: %s", textOIDName(curve.String()))
+		}
+		line(16, "NIST CURVE: %s", pub.Curve.Params().Name)
+	case ed25519.PublicKey:
+		line(16, "ED25519 Public-Key:")
+		line(16, "pub:")
+		sb.WriteString(textHexBlock(pub, 15, 20))
+	default:
+		// OpenSSL dumps a key type it does not know the same way.
+		line(16, "Unable to load Public Key")
+	}
+	return sb.String()
+}
+
+// textExtensionNames are OpenSSL's names for the extensions it decodes.
+var textExtensionNames = map[string]string{
+	"2.5.29.14":               "X509v3 Subject Key Identifier",
+	"2.5.29.15":               "X509v3 Key Usage",
+	"2.5.29.17":               "X509v3 Subject Alternative Name",
+	"2.5.29.18":               "X509v3 Issuer Alternative Name",
+	"2.5.29.19":               "X509v3 Basic Constraints",
+	"2.5.29.30":               "X509v3 Name Constraints",
+	"2.5.29.31":               "X509v3 CRL Distribution Points",
+	"2.5.29.32":               "X509v3 Certificate Policies",
+	"2.5.29.33":               "X509v3 Policy Mappings",
+	"2.5.29.35":               "X509v3 Authority Key Identifier",
+	"2.5.29.36":               "X509v3 Policy Constraints",
+	"2.5.29.37":               "X509v3 Extended Key Usage",
+	"2.5.29.54":               "X509v3 Inhibit Any Policy",
+	"1.3.6.1.5.5.7.1.1":       "Authority Information Access",
+	"1.3.6.1.5.5.7.1.11":      "Subject Information Access",
+	"1.3.6.1.5.5.7.1.24":      "TLS Feature",
+	"1.3.6.1.4.1.11129.2.4.2": "CT Precertificate SCTs",
+	"1.3.6.1.4.1.11129.2.4.3": "CT Precertificate Poison",
+	"1.3.6.1.5.5.7.48.1.5":    "OCSP No Check",
+}
+
+func textExtensionName(oid string) string {
+	if name, ok := textExtensionNames[oid]; ok {
+		return name
+	}
+	return oid
+}
+
+// textKeyUsageNames are OpenSSL's names for the key usage bits, in bit order.
+var textKeyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "Digital Signature"},
+	{x509.KeyUsageContentCommitment, "Non Repudiation"},
+	{x509.KeyUsageKeyEncipherment, "Key Encipherment"},
+	{x509.KeyUsageDataEncipherment, "Data Encipherment"},
+	{x509.KeyUsageKeyAgreement, "Key Agreement"},
+	{x509.KeyUsageCertSign, "Certificate Sign"},
+	{x509.KeyUsageCRLSign, "CRL Sign"},
+	{x509.KeyUsageEncipherOnly, "Encipher Only"},
+	{x509.KeyUsageDecipherOnly, "Decipher Only"},
+}
+
+// textExtKeyUsageNames are OpenSSL's long names for the extended key usages.
+var textExtKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "Any Extended Key Usage",
+	x509.ExtKeyUsageServerAuth:                     "TLS Web Server Authentication",
+	x509.ExtKeyUsageClientAuth:                     "TLS Web Client Authentication",
+	x509.ExtKeyUsageCodeSigning:                    "Code Signing",
+	x509.ExtKeyUsageEmailProtection:                "E-mail Protection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "IPSec End System",
+	x509.ExtKeyUsageIPSECTunnel:                    "IPSec Tunnel",
+	x509.ExtKeyUsageIPSECUser:                      "IPSec User",
+	x509.ExtKeyUsageTimeStamping:                   "Time Stamping",
+	x509.ExtKeyUsageOCSPSigning:                    "OCSP Signing",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "Microsoft Server Gated Crypto",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "Netscape Server Gated Crypto",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "Microsoft Commercial Code Signing",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "1.3.6.1.4.1.311.61.1.1",
+}
+
+// textExtensionValue renders an extension's value as OpenSSL does, one
+// element per line. A value it cannot decode is printed as OpenSSL prints
+// one: the raw bytes, non-printable ones as dots.
+func textExtensionValue(cert *x509.Certificate, ext pkix.Extension) []string {
+	switch ext.Id.String() {
+	case "2.5.29.14":
+		return []string{hexBytes(cert.SubjectKeyId)}
+	case "2.5.29.35":
+		return []string{hexBytes(cert.AuthorityKeyId)}
+	case "2.5.29.15":
+		var names []string
+		for _, ku := range textKeyUsageNames {
+			if cert.KeyUsage&ku.usage != 0 {
+				names = append(names, ku.name)
+			}
+		}
+		return []string{strings.Join(names, ", ")}
+	case "2.5.29.37":
+		var names []string
+		for _, usage := range cert.ExtKeyUsage {
+			names = append(names, textExtKeyUsageNames[usage])
+		}
+		for _, oid := range cert.UnknownExtKeyUsage {
+			names = append(names, oid.String())
+		}
+		return []string{strings.Join(names, ", ")}
+	case "2.5.29.19":
+		if !cert.IsCA {
+			return []string{"CA:FALSE"}
+		}
+		if hasPathLenConstraint(cert) {
+			return []string{fmt.Sprintf("CA:TRUE, pathlen:%d", cert.MaxPathLen)}
+		}
+		return []string{"CA:TRUE"}
+	case "2.5.29.17", "2.5.29.18":
+		if names, ok := textGeneralNames(ext.Value); ok {
+			return []string{strings.Join(names, ", ")}
+		}
+	case "2.5.29.31":
+		var values []string
+		for _, url := range cert.CRLDistributionPoints {
+			values = append(values, "Full Name:", "  URI:"+url)
+		}
+		return values
+	case "1.3.6.1.5.5.7.1.1":
+		if values, ok := textAccessDescriptions(ext.Value); ok {
+			return values
+		}
+	case "2.5.29.32":
+		policies, err := ParsePolicies(cert)
+		if err != nil {
+			break
+		}
+		var values []string
+		for _, policy := range policies {
+			values = append(values, "Policy: "+policy.OID)
+			for _, cps := range policy.CPS {
+				values = append(values, "  CPS: "+cps)
+			}
+			for _, notice := range policy.UserNotices {
+				values = append(values, "  User Notice:", "    Explicit Text: "+notice)
+			}
+		}
+		return values
+	case "1.3.6.1.4.1.11129.2.4.2":
+		scts, err := ParseSCTs(cert)
+		if err != nil {
+			break
+		}
+		var values []string
+		for _, sct := range scts {
+			values = append(values, textSCT(sct)...)
+		}
+		return values
+	case "1.3.6.1.4.1.11129.2.4.3":
+		return []string{"NULL"}
+	case "1.3.6.1.5.5.7.1.24":
+		if features, err := tlsFeatures(ext.Value); err == nil {
+			for i, feature := range features {
+				features[i], _, _ = strings.Cut(feature, " (")
+			}
+			return []string{strings.Join(features, ", ")}
+		}
+	}
+	return []string{textRawString(ext.Value)}
+}
+
+// textGeneralNames renders a GeneralNames sequence the way OpenSSL does, in
+// encoded order: "DNS:example.com, IP Address:192.0.2.1".
+func textGeneralNames(value []byte) ([]string, bool) {
+	var raw []asn1.RawValue
+	if rest, err := asn1.Unmarshal(value, &raw); err != nil || len(rest) > 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(raw))
+	for _, name := range raw {
+		names = append(names, textGeneralName(name))
+	}
+	return names, true
+}
+
+// textGeneralName renders one GeneralName (RFC 5280, section 4.2.1.6).
+func textGeneralName(name asn1.RawValue) string {
+	if name.Class != asn1.ClassContextSpecific {
+		return "<unsupported>"
+	}
+	switch name.Tag {
+	case 0:
+		return "othername:<unsupported>"
+	case 1:
+		return "email:" + string(name.Bytes)
+	case 2:
+		return "DNS:" + string(name.Bytes)
+	case 4:
+		return "DirName:" + textDirName(name.Bytes)
+	case 6:
+		return "URI:" + string(name.Bytes)
+	case 7:
+		return "IP Address:" + textIP(name.Bytes)
+	case 8:
+		var oid asn1.ObjectIdentifier
+		full := append([]byte{asn1.TagOID, byte(len(name.Bytes))}, name.Bytes...)
+		if _, err := asn1.Unmarshal(full, &oid); err == nil {
+			return "Registered ID:" + oid.String()
+		}
+	}
+	return "<unsupported>"
+}
+
+// textDirName renders a directoryName, whose contents are a Name.
+func textDirName(contents []byte) string {
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(contents, &raw); err != nil {
+		return "<malformed name>"
+	}
+	return textName(raw.FullBytes)
+}
+
+// textIP renders an IP address as OpenSSL does: IPv6 in full, upper-case,
+// without zero compression.
+func textIP(ip []byte) string {
+	switch len(ip) {
+	case net.IPv4len:
+		return net.IP(ip).String()
+	case net.IPv6len:
+		groups := make([]string, 8)
+		for i := range groups {
+			groups[i] = fmt.Sprintf("%X", int(ip[2*i])<<8|int(ip[2*i+1]))
+		}
+		return strings.Join(groups, ":")
+	default:
+		return "<invalid>"
+	}
+}
+
+// textAccessDescriptions renders Authority Information Access in encoded
+// order: "OCSP - URI:http://ocsp.example".
+func textAccessDescriptions(value []byte) ([]string, bool) {
+	var descriptions []struct {
+		Method   asn1.ObjectIdentifier
+		Location asn1.RawValue
+	}
+	if rest, err := asn1.Unmarshal(value, &descriptions); err != nil || len(rest) > 0 {
+		return nil, false
+	}
+	values := make([]string, len(descriptions))
+	for i, d := range descriptions {
+		method := d.Method.String()
+		switch method {
+		case "1.3.6.1.5.5.7.48.1":
+			method = "OCSP"
+		case "1.3.6.1.5.5.7.48.2":
+			method = "CA Issuers"
+		}
+		values[i] = method + " - " + textGeneralName(d.Location)
+	}
+	return values, true
+}
+
+// textSCT renders one SCT in OpenSSL's layout, relative to the extension
+// value's indent.
+func textSCT(sct SCT) []string {
+	const continuation = "                "
+	values := []string{
+		"Signed Certificate Timestamp:",
+		fmt.Sprintf("    Version   : v%d (0x%x)", sct.Version+1, sct.Version),
+	}
+	logID := textHexLines(sct.LogID[:], 16)
+	values = append(values, "    Log ID    : "+logID[0])
+	for _, l := range logID[1:] {
+		values = append(values, continuation+l)
+	}
+	values = append(values, "    Timestamp : "+sct.Timestamp.UTC().Format("Jan _2 15:04:05.000 2006 GMT"))
+	if len(sct.Extensions) == 0 {
+		values = append(values, "    Extensions: none")
+	} else {
+		values = append(values, "    Extensions: "+textHexLines(sct.Extensions, 16)[0])
+	}
+	values = append(values, "    Signature : "+textSCTSignatureAlgorithm(sct))
+	for _, l := range textHexLines(sct.Signature, 16) {
+		values = append(values, continuation+l)
+	}
+	return values
+}
+
+// textSCTSignatureAlgorithm names the TLS signature scheme of an SCT.
+func textSCTSignatureAlgorithm(sct SCT) string {
+	hash := map[uint8]string{2: "SHA1", 4: "SHA256", 5: "SHA384", 6: "SHA512"}[sct.HashAlgorithm]
+	switch sct.SignatureAlgorithm {
+	case 1:
+		return strings.ToLower(hash) + "WithRSAEncryption"
+	case 3:
+		return "ecdsa-with-" + hash
+	}
+	return "UNKNOWN"
+}
+
+// textRawString prints bytes as OpenSSL's ASN1_STRING_print does: printable
+// characters as themselves, everything else as a dot.
+func textRawString(data []byte) string {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		if b >= 0x20 && b < 0x7f {
+			out[i] = b
+		} else {
+			out[i] = '.'
+		}
+	}
+	return string(out)
+}
+
+// textHex renders bytes as lower-case colon-separated hex on one line.
+func textHex(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// textHexLines splits bytes into rows of perLine, upper-case and
+// colon-separated, each row but the last ending in a colon.
+func textHexLines(data []byte, perLine int) []string {
+	var rows []string
+	for start := 0; start < len(data); start += perLine {
+		end := min(start+perLine, len(data))
+		row := hexBytes(data[start:end])
+		if end < len(data) {
+			row += ":"
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		rows = []string{""}
+	}
+	return rows
+}
+
+// textHexBlock renders bytes as OpenSSL prints keys and signatures: lower-case
+// hex, perLine bytes to a row, each row but the last ending in a colon.
+func textHexBlock(data []byte, perLine, indent int) string {
+	var sb strings.Builder
+	for start := 0; start < len(data); start += perLine {
+		end := min(start+perLine, len(data))
+		sb.WriteString(strings.Repeat(" ", indent))
+		sb.WriteString(textHex(data[start:end]))
+		if end < len(data) {
+			sb.WriteByte(':')
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
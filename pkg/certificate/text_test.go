@@ -0,0 +1,73 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The golden files were written by `openssl x509 -noout -text` (OpenSSL
+// 3.0) from the certificates beside them.
+func TestFormatText_MatchesOpenSSL(t *testing.T) {
+	for _, name := range []string{"rsa", "ecdsa", "ed25519"} {
+		t.Run(name, func(t *testing.T) {
+			certs, err := LoadCertificates(filepath.Join("testdata", "text-"+name+".pem"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", "text-"+name+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := FormatText(certs[0].Certificate); got != string(want) {
+				t.Errorf("FormatText differs from openssl:\n--- got\n%s\n--- want\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestFormatText_Fallbacks(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: new(big.Int).Lsh(big.NewInt(1), 70),
+		Subject:      pkix.Name{CommonName: "leaf.example", Organization: []string{"Example, Inc."}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Critical: true, Value: []byte("hi\x00")},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, root, &key.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := FormatText(cert)
+	for _, want := range []string{
+		"Serial Number:\n            40:00:00:00:00:00:00:00:00\n",
+		`Subject: O = "Example, Inc.", CN = leaf.example`,
+		"            1.2.3.4: critical\n                hi.\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q:\n%s", want, text)
+		}
+	}
+}
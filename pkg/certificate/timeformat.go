@@ -0,0 +1,125 @@
+package certificate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Time layouts TimeFormat takes by name, besides a Go layout.
+const (
+	// TimeLayoutDefault is 2025-06-01 12:00:00 UTC.
+	TimeLayoutDefault = "default"
+	// TimeLayoutRFC3339 is 2025-06-01T12:00:00Z.
+	TimeLayoutRFC3339 = "rfc3339"
+	// TimeLayoutEpoch is the seconds since 1970, 1748779200.
+	TimeLayoutEpoch = "epoch"
+)
+
+// defaultLayout is what TimeLayoutDefault stands for.
+const defaultLayout = "2006-01-02 15:04:05 MST"
+
+// TimeFormat is how times are written for people to read: a layout and
+// the zone to write them in. Its zero value is TimeLayoutDefault in UTC.
+// Output for machines, JSON and the like, keeps RFC 3339 in UTC whatever
+// the format.
+type TimeFormat struct {
+	// Layout is a Go time layout, or one of the TimeLayout names.
+	Layout string
+	// Location is the zone; nil for UTC.
+	Location *time.Location
+}
+
+// ParseTimeFormat makes a TimeFormat of a layout, a TimeLayout name or a
+// Go layout such as "02 Jan 2006 15:04", and a zone: "utc", "local" or an
+// IANA name such as "Europe/Paris". Empty strings mean the defaults.
+func ParseTimeFormat(layout, zone string) (TimeFormat, error) {
+	var f TimeFormat
+	switch name := strings.ToLower(strings.TrimSpace(layout)); name {
+	case "", TimeLayoutDefault:
+		f.Layout = TimeLayoutDefault
+	case TimeLayoutRFC3339, TimeLayoutEpoch:
+		f.Layout = name
+	default:
+		// A layout with no element of the reference time in it would
+		// write every time alike.
+		if time.Unix(0, 0).UTC().Format(layout) == layout {
+			return TimeFormat{}, fmt.Errorf("date format %q has no part of the reference time, Mon Jan 2 15:04:05 MST 2006", layout)
+		}
+		f.Layout = layout
+	}
+
+	switch name := strings.TrimSpace(zone); strings.ToLower(name) {
+	case "", "utc":
+	case "local":
+		f.Location = time.Local
+	default:
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return TimeFormat{}, fmt.Errorf("no time zone %q: %w", zone, err)
+		}
+		f.Location = loc
+	}
+	return f, nil
+}
+
+// In is t in the format's zone.
+func (f TimeFormat) In(t time.Time) time.Time {
+	if f.Location == nil {
+		return t.UTC()
+	}
+	return t.In(f.Location)
+}
+
+// Time writes t, to the second.
+func (f TimeFormat) Time(t time.Time) string {
+	switch f.Layout {
+	case "", TimeLayoutDefault:
+		return f.In(t).Format(defaultLayout)
+	case TimeLayoutRFC3339:
+		return f.In(t).Format(time.RFC3339)
+	case TimeLayoutEpoch:
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return f.In(t).Format(f.Layout)
+}
+
+// Date writes the day of t, for columns and sentences with no room for
+// the time: 2025-06-01 in the format's zone, or the seconds since 1970
+// for TimeLayoutEpoch, which has no days.
+func (f TimeFormat) Date(t time.Time) string {
+	if f.Layout == TimeLayoutEpoch {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return f.In(t).Format(time.DateOnly)
+}
+
+// timeFormat is the format FormatTime and FormatDate write in, set by
+// SetTimeFormat.
+var timeFormat atomic.Pointer[TimeFormat]
+
+// SetTimeFormat has the package, and FormatTime and FormatDate, write
+// times in f.
+func SetTimeFormat(f TimeFormat) {
+	timeFormat.Store(&f)
+}
+
+// CurrentTimeFormat is the format SetTimeFormat set, or the zero one.
+func CurrentTimeFormat() TimeFormat {
+	if f := timeFormat.Load(); f != nil {
+		return *f
+	}
+	return TimeFormat{}
+}
+
+// FormatTime writes t in the format SetTimeFormat set.
+func FormatTime(t time.Time) string {
+	return CurrentTimeFormat().Time(t)
+}
+
+// FormatDate writes the day of t in the format SetTimeFormat set.
+func FormatDate(t time.Time) string {
+	return CurrentTimeFormat().Date(t)
+}
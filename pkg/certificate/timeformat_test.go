@@ -0,0 +1,60 @@
+package certificate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFormat(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		layout, zone string
+		time, date   string
+	}{
+		{"", "", "2025-06-01 12:00:00 UTC", "2025-06-01"},
+		{"RFC3339", "utc", "2025-06-01T12:00:00Z", "2025-06-01"},
+		{"epoch", "", "1748779200", "1748779200"},
+		{"02 Jan 2006 15:04", "", "01 Jun 2025 12:00", "2025-06-01"},
+		{"default", "Asia/Tokyo", "2025-06-01 21:00:00 JST", "2025-06-01"},
+		{"rfc3339", "America/New_York", "2025-06-01T08:00:00-04:00", "2025-06-01"},
+		{"epoch", "Asia/Tokyo", "1748779200", "1748779200"},
+	} {
+		f, err := ParseTimeFormat(tc.layout, tc.zone)
+		if err != nil {
+			t.Errorf("ParseTimeFormat(%q, %q): %v", tc.layout, tc.zone, err)
+			continue
+		}
+		if got := f.Time(at); got != tc.time {
+			t.Errorf("%q in %q: Time = %q, want %q", tc.layout, tc.zone, got, tc.time)
+		}
+		if got := f.Date(at); got != tc.date {
+			t.Errorf("%q in %q: Date = %q, want %q", tc.layout, tc.zone, got, tc.date)
+		}
+	}
+
+	if got := (TimeFormat{}).Time(at); got != "2025-06-01 12:00:00 UTC" {
+		t.Errorf("the zero format writes %q", got)
+	}
+	if _, err := ParseTimeFormat("yesterday", ""); err == nil {
+		t.Error("a layout with no time in it was accepted")
+	}
+	if _, err := ParseTimeFormat("", "Mars/Olympus_Mons"); err == nil {
+		t.Error("an unknown zone was accepted")
+	}
+}
+
+func TestSetTimeFormat(t *testing.T) {
+	t.Cleanup(func() { SetTimeFormat(TimeFormat{}) })
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	SetTimeFormat(TimeFormat{Layout: TimeLayoutEpoch})
+	if got := FormatTime(at); got != "1748779200" {
+		t.Errorf("FormatTime = %q after SetTimeFormat", got)
+	}
+	a, _ := issue(t, "a.example", true, nil, nil)
+	for _, d := range DiffCertificates(a, a) {
+		if d.Field == "Not After" && d.A != FormatTime(a.NotAfter) {
+			t.Errorf("diff writes Not After as %q", d.A)
+		}
+	}
+}
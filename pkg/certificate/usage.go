@@ -0,0 +1,228 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// UsageFinding is a key usage problem on one certificate of a chain.
+type UsageFinding struct {
+	// Certificate is the certificate concerned.
+	Certificate *x509.Certificate
+	// Subject is its common name.
+	Subject string
+	// Detail explains the finding in a sentence.
+	Detail string
+}
+
+// AnalyzeUsage checks that the key usages along a chain agree with the job
+// each certificate does. certs is the bundle leaf first; the path is followed
+// up from the leaf by issuer name and signature, as the verifier would.
+//
+// It looks for three things: a serving certificate that cannot sign a TLS
+// handshake or is not valid for serverAuth, a CA that is not allowed to sign
+// certificates, and an extended key usage that an issuer does not itself
+// hold. The last is what Go and most browsers enforce as EKU nesting, and it
+// is the one that surprises people: an intermediate restricted to clientAuth
+// silently cannot issue a working server certificate.
+func AnalyzeUsage(certs []*x509.Certificate) []UsageFinding {
+	if len(certs) == 0 || certs[0] == nil {
+		return nil
+	}
+	path := issuerPath(certs[0], certs)
+
+	var findings []UsageFinding
+	add := func(cert *x509.Certificate, format string, args ...any) {
+		findings = append(findings, UsageFinding{
+			Certificate: cert,
+			Subject:     displayName(cert),
+			Detail:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, cert := range path {
+		if cert.IsCA {
+			// An empty KeyUsage means the extension is absent, which RFC 5280
+			// reads as "no restriction".
+			if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+				add(cert, "CA certificate lacks keyCertSign, so it may not issue certificates")
+			}
+			continue
+		}
+		if i != 0 {
+			// A non-CA above the leaf is a basic-constraints problem, and
+			// CheckBasicConstraints reports it.
+			continue
+		}
+
+		if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+			add(cert, "lacks digitalSignature; TLS 1.3 and ECDHE handshakes need it to sign")
+		}
+		if len(cert.ExtKeyUsage) > 0 && !hasExtKeyUsage(cert, x509.ExtKeyUsageServerAuth) {
+			add(cert, "extended key usage (%s) does not include serverAuth, so TLS clients "+
+				"will reject it as a server certificate", strings.Join(extKeyUsageNames(cert), ", "))
+		}
+	}
+
+	// EKU nesting: every usage a certificate claims has to be held by each CA
+	// above it. A CA with no EKU extension, or with anyExtendedKeyUsage, passes
+	// everything through.
+	for i := 0; i < len(path)-1; i++ {
+		child := path[i]
+		for _, issuer := range path[i+1:] {
+			if len(issuer.ExtKeyUsage) == 0 || hasExtKeyUsage(issuer, x509.ExtKeyUsageAny) {
+				continue
+			}
+			for _, usage := range child.ExtKeyUsage {
+				if usage == x509.ExtKeyUsageAny || slices.Contains(issuer.ExtKeyUsage, usage) {
+					continue
+				}
+				add(child, "extended key usage %s is not permitted by issuer %q (%s)",
+					ExtKeyUsageName(usage), displayName(issuer), strings.Join(extKeyUsageNames(issuer), ", "))
+			}
+		}
+	}
+
+	return findings
+}
+
+// hasExtKeyUsage reports whether the certificate lists the given usage.
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	return slices.Contains(cert.ExtKeyUsage, usage)
+}
+
+// keyUsageNames are the RFC 5280 names, in bit order.
+var keyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "digitalSignature"},
+	{x509.KeyUsageContentCommitment, "contentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "keyAgreement"},
+	{x509.KeyUsageCertSign, "keyCertSign"},
+	{x509.KeyUsageCRLSign, "cRLSign"},
+	{x509.KeyUsageEncipherOnly, "encipherOnly"},
+	{x509.KeyUsageDecipherOnly, "decipherOnly"},
+}
+
+// FormatKeyUsage lists the key usage bits, or an empty string when the
+// extension is absent.
+func FormatKeyUsage(cert *x509.Certificate) string {
+	var names []string
+	for _, ku := range keyUsageNames {
+		if cert.KeyUsage&ku.usage != 0 {
+			names = append(names, ku.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseKeyUsage reads a key usage bit by its RFC 5280 name, in any case:
+// "keycertsign" is keyCertSign.
+func ParseKeyUsage(name string) (x509.KeyUsage, error) {
+	names := make([]string, len(keyUsageNames))
+	for i, ku := range keyUsageNames {
+		names[i] = ku.name
+		if strings.EqualFold(strings.TrimSpace(name), ku.name) {
+			return ku.usage, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key usage %q (one of %s)", name, strings.Join(names, ", "))
+}
+
+// FormatExtKeyUsage lists the extended key usages, or an empty string when
+// the extension is absent.
+func FormatExtKeyUsage(cert *x509.Certificate) string {
+	return strings.Join(extKeyUsageNames(cert), ", ")
+}
+
+func extKeyUsageNames(cert *x509.Certificate) []string {
+	names := make([]string, 0, len(cert.ExtKeyUsage)+len(cert.UnknownExtKeyUsage))
+	for _, usage := range cert.ExtKeyUsage {
+		names = append(names, ExtKeyUsageName(usage))
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		names = append(names, oid.String())
+	}
+	return names
+}
+
+// ExtKeyUsageName returns the conventional name of an extended key usage.
+func ExtKeyUsageName(usage x509.ExtKeyUsage) string {
+	switch usage {
+	case x509.ExtKeyUsageAny:
+		return "any"
+	case x509.ExtKeyUsageServerAuth:
+		return "serverAuth"
+	case x509.ExtKeyUsageClientAuth:
+		return "clientAuth"
+	case x509.ExtKeyUsageCodeSigning:
+		return "codeSigning"
+	case x509.ExtKeyUsageEmailProtection:
+		return "emailProtection"
+	case x509.ExtKeyUsageIPSECEndSystem:
+		return "ipsecEndSystem"
+	case x509.ExtKeyUsageIPSECTunnel:
+		return "ipsecTunnel"
+	case x509.ExtKeyUsageIPSECUser:
+		return "ipsecUser"
+	case x509.ExtKeyUsageTimeStamping:
+		return "timeStamping"
+	case x509.ExtKeyUsageOCSPSigning:
+		return "OCSPSigning"
+	case x509.ExtKeyUsageMicrosoftServerGatedCrypto:
+		return "msSGC"
+	case x509.ExtKeyUsageNetscapeServerGatedCrypto:
+		return "nsSGC"
+	case x509.ExtKeyUsageMicrosoftCommercialCodeSigning:
+		return "msCodeCom"
+	case x509.ExtKeyUsageMicrosoftKernelCodeSigning:
+		return "msKernelCode"
+	default:
+		return fmt.Sprintf("unknown (%d)", usage)
+	}
+}
+
+// commonExtKeyUsages are the extended key usages ParseExtKeyUsage knows by
+// name.
+var commonExtKeyUsages = []x509.ExtKeyUsage{
+	x509.ExtKeyUsageServerAuth,
+	x509.ExtKeyUsageClientAuth,
+	x509.ExtKeyUsageCodeSigning,
+	x509.ExtKeyUsageEmailProtection,
+	x509.ExtKeyUsageTimeStamping,
+	x509.ExtKeyUsageOCSPSigning,
+	x509.ExtKeyUsageAny,
+}
+
+// ParseExtKeyUsage reads an extended key usage by the name ExtKeyUsageName
+// gives it, in any case: "serverauth" is serverAuth.
+func ParseExtKeyUsage(name string) (x509.ExtKeyUsage, error) {
+	names := make([]string, len(commonExtKeyUsages))
+	for i, usage := range commonExtKeyUsages {
+		names[i] = ExtKeyUsageName(usage)
+		if strings.EqualFold(strings.TrimSpace(name), names[i]) {
+			return usage, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key usage %q (one of %s)", name, strings.Join(names, ", "))
+}
+
+// FormatUsageFindings renders the findings for the terminal. It returns an
+// empty string when there are none, so a caller can print it unconditionally.
+func FormatUsageFindings(findings []UsageFinding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Key usage:\n")
+	for _, finding := range findings {
+		fmt.Fprintf(&sb, "  • %s: %s\n", finding.Subject, finding.Detail)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,101 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+// usages sets a certificate's key usage and extended key usages.
+func usages(ku x509.KeyUsage, eku ...x509.ExtKeyUsage) func(*x509.Certificate) {
+	return func(c *x509.Certificate) {
+		c.KeyUsage, c.ExtKeyUsage = ku, eku
+	}
+}
+
+func TestAnalyzeUsage_Clean(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+
+	if findings := AnalyzeUsage([]*x509.Certificate{leaf, root}); len(findings) != 0 {
+		t.Errorf("clean chain produced findings: %+v", findings)
+	}
+}
+
+func TestAnalyzeUsage_Problems(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Client Issuing CA", true, root, rootKey,
+		usages(x509.KeyUsageCRLSign|x509.KeyUsageCertSign, x509.ExtKeyUsageClientAuth))
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey,
+		usages(x509.KeyUsageKeyEncipherment, x509.ExtKeyUsageServerAuth))
+
+	findings := AnalyzeUsage([]*x509.Certificate{leaf, intermediate, root})
+	text := FormatUsageFindings(findings)
+
+	for _, want := range []string{"lacks digitalSignature", "serverAuth is not permitted by issuer"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("findings do not mention %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestAnalyzeUsage_CAWithoutCertSign(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	intermediate, intermediateKey := issue(t, "Odd CA", true, root, rootKey, usages(x509.KeyUsageCRLSign))
+	leaf, _ := issue(t, "leaf.example", false, intermediate, intermediateKey)
+
+	findings := AnalyzeUsage([]*x509.Certificate{leaf, intermediate, root})
+	if len(findings) != 1 || !strings.Contains(findings[0].Detail, "keyCertSign") {
+		t.Errorf("want one keyCertSign finding, got %+v", findings)
+	}
+	if findings[0].Subject != "Odd CA" {
+		t.Errorf("finding blames %q, want the intermediate", findings[0].Subject)
+	}
+}
+
+func TestFormatKeyUsage(t *testing.T) {
+	cert := &x509.Certificate{
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if got := FormatKeyUsage(cert); got != "digitalSignature, keyEncipherment" {
+		t.Errorf("FormatKeyUsage = %q", got)
+	}
+	if got := FormatExtKeyUsage(cert); got != "serverAuth, clientAuth" {
+		t.Errorf("FormatExtKeyUsage = %q", got)
+	}
+	if got := FormatUsageFindings(nil); got != "" {
+		t.Errorf("FormatUsageFindings(nil) = %q, want empty", got)
+	}
+}
+
+func TestParseExtKeyUsage(t *testing.T) {
+	for name, want := range map[string]x509.ExtKeyUsage{
+		"serverAuth":   x509.ExtKeyUsageServerAuth,
+		"CLIENTAUTH":   x509.ExtKeyUsageClientAuth,
+		" ocspsigning": x509.ExtKeyUsageOCSPSigning,
+		"any":          x509.ExtKeyUsageAny,
+	} {
+		if got, err := ParseExtKeyUsage(name); err != nil || got != want {
+			t.Errorf("ParseExtKeyUsage(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseExtKeyUsage("msSGC"); err == nil {
+		t.Error("ParseExtKeyUsage took a usage outside the common ones")
+	}
+}
+
+func TestParseKeyUsage(t *testing.T) {
+	for name, want := range map[string]x509.KeyUsage{
+		"digitalSignature": x509.KeyUsageDigitalSignature,
+		"KEYCERTSIGN":      x509.KeyUsageCertSign,
+		"cRLSign":          x509.KeyUsageCRLSign,
+	} {
+		if got, err := ParseKeyUsage(name); err != nil || got != want {
+			t.Errorf("ParseKeyUsage(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseKeyUsage("signing"); err == nil {
+		t.Error("ParseKeyUsage took an unknown name")
+	}
+}
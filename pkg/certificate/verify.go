@@ -2,6 +2,7 @@ package certificate
 
 import (
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -58,6 +59,33 @@ type VerifyOptions struct {
 	DNSName string
 	// CurrentTime overrides the verification time. The zero value means now.
 	CurrentTime time.Time
+	// ExpiryWarningDays is how close to expiry a certificate has to be to draw
+	// a warning. Non-positive values fall back to the default window.
+	ExpiryWarningDays int
+}
+
+// verifyTimeLayouts are the forms ParseVerifyTime accepts, most specific
+// first.
+var verifyTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseVerifyTime reads a point in time to verify at, for questions like "will
+// this chain still verify after the old root expires?". A bare date means
+// midnight UTC; a time without a zone is UTC too, so the answer does not
+// depend on where it is asked.
+func ParseVerifyTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range verifyTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use YYYY-MM-DD, YYYY-MM-DD HH:MM, or RFC 3339", value)
 }
 
 // VerifyResult reports the outcome of verifying a chain.
@@ -71,6 +99,25 @@ type VerifyResult struct {
 	// set for every level below TrustAnchored, including TrustSelfAnchored,
 	// where it explains why the chain is not publicly trusted.
 	Err error
+	// Violations are the basic-constraints failures along the chain: a
+	// certificate issued by a non-CA, or a CA beyond an ancestor's path
+	// length. When present they replace Err's generic wording, and the chain is
+	// broken no matter what the verifier said.
+	Violations []*ConstraintError
+	// MissingIssuer is set when the chain is broken because the path up from
+	// the leaf runs out: the next issuer was never supplied. It carries the
+	// AIA URLs that would supply it.
+	MissingIssuer *MissingIssuerError
+	// Expired and NotYetValid name the certificate on the path that is outside
+	// its validity period at the verification time, when the chain is broken.
+	// At most one is set; a script can tell "renew it" from "the clock or the
+	// issuance is wrong" without parsing Err.
+	Expired     *x509.Certificate
+	NotYetValid *x509.Certificate
+	// Warnings are problems that do not fail verification but will, or that a
+	// stricter client might already refuse: a certificate close to expiry, a
+	// SHA-1 signature, an over-long lifetime, a missing key identifier.
+	Warnings []string
 }
 
 // VerifyChain verifies a chain against real trust anchors.
@@ -81,6 +128,15 @@ type VerifyResult struct {
 // promoted to anchors, which tells "internally consistent but not trusted"
 // apart from "broken".
 func VerifyChain(certs []*x509.Certificate, opts VerifyOptions) (*VerifyResult, error) {
+	result, err := verifyChain(certs, opts)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = chainWarnings(certs, opts)
+	return result, nil
+}
+
+func verifyChain(certs []*x509.Certificate, opts VerifyOptions) (*VerifyResult, error) {
 	if len(certs) == 0 {
 		return nil, fmt.Errorf("empty certificate chain")
 	}
@@ -121,7 +177,7 @@ func VerifyChain(certs []*x509.Certificate, opts VerifyOptions) (*VerifyResult,
 	// anchors to find out whether the bundle at least hangs together.
 	selfAnchors := selfSignedFrom(certs)
 	if selfAnchors == nil {
-		return &VerifyResult{Level: TrustBroken, Err: trustErr}, nil
+		return brokenResult(certs, trustErr, roots, opts), nil
 	}
 
 	verifyOpts.Roots = selfAnchors
@@ -131,12 +187,69 @@ func VerifyChain(certs []*x509.Certificate, opts VerifyOptions) (*VerifyResult,
 		// still failed means a structural fault -- expiry, a bad signature, a
 		// name constraint -- and selfErr names it. trustErr would only say
 		// "unknown authority", which is not why this is broken.
-		return &VerifyResult{Level: TrustBroken, Err: selfErr}, nil
+		return brokenResult(certs, selfErr, roots, opts), nil
 	}
 
 	return &VerifyResult{Level: TrustSelfAnchored, Anchor: anchorName(chains), Err: trustErr}, nil
 }
 
+// brokenResult builds the result for a chain that did not verify. If the path
+// up from the leaf breaks a basic constraint, that is the real reason, and it
+// is reported in place of the verifier's error: Go words a non-CA issuer as
+// "parent certificate cannot sign this kind of certificate", which reads like
+// a bad signature, and a path length violation as an opaque count. roots are
+// the trust anchors the chain was verified against.
+func brokenResult(certs []*x509.Certificate, err error, roots *x509.CertPool, opts VerifyOptions) *VerifyResult {
+	result := &VerifyResult{Level: TrustBroken, Err: err}
+	markValidityFailure(result, certs, err, opts)
+
+	violations := CheckBasicConstraints(issuerPath(certs[0], certs))
+	if len(violations) == 0 {
+		// An issuer that was never sent is the other common cause, and the
+		// verifier words it as "unknown authority" -- the same thing it says
+		// about a root that is present but untrusted.
+		if missing := missingIssuer(certs, err, roots); missing != nil {
+			result.MissingIssuer = missing
+			result.Err = missing
+		}
+		return result
+	}
+	result.Violations = violations
+	errs := make([]error, len(violations))
+	for i, violation := range violations {
+		errs[i] = violation
+	}
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// markValidityFailure records which certificate, if any, is outside its
+// validity period. The verifier names the leaf when that is the one; an
+// expired intermediate instead surfaces as a chain that cannot be built, so
+// the path is checked date by date as well.
+func markValidityFailure(result *VerifyResult, certs []*x509.Certificate, err error, opts VerifyOptions) {
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	candidates := issuerPath(certs[0], certs)
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) && invalid.Reason == x509.Expired && invalid.Cert != nil {
+		candidates = append([]*x509.Certificate{invalid.Cert}, candidates...)
+	}
+	for _, cert := range candidates {
+		switch {
+		case now.Before(cert.NotBefore):
+			result.NotYetValid = cert
+			return
+		case now.After(cert.NotAfter):
+			result.Expired = cert
+			return
+		}
+	}
+}
+
 // trustAnchors builds the root pool: the system trust store unless it was
 // skipped, plus any roots the caller supplied.
 func trustAnchors(opts VerifyOptions) (*x509.CertPool, error) {
@@ -177,18 +290,7 @@ func selfSignedFrom(certs []*x509.Certificate) *x509.CertPool {
 	found := false
 
 	for _, cert := range certs {
-		if cert == nil {
-			continue
-		}
-		if cert.Issuer.String() != cert.Subject.String() {
-			continue
-		}
-		// Verify the self-signature with CheckSignature, not CheckSignatureFrom.
-		// The latter also enforces the CA basic constraint, which would reject a
-		// self-signed *leaf* -- a dev server certificate is exactly that, and it
-		// still needs to anchor its own one-cert chain so the result is
-		// self-anchored rather than broken.
-		if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		if cert == nil || !IsSelfSigned(cert) {
 			continue
 		}
 		pool.AddCert(cert)
@@ -220,6 +322,21 @@ func FormatVerifyResult(result *VerifyResult) string {
 		return "❌ Certificate chain could not be verified."
 	}
 
+	verdict := formatVerdict(result)
+	if len(result.Warnings) == 0 {
+		return verdict
+	}
+	var sb strings.Builder
+	sb.WriteString(verdict)
+	sb.WriteString("\n\nWarnings:")
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(&sb, "\n  • %s", warning)
+	}
+	return sb.String()
+}
+
+// formatVerdict renders the trust level and its explanation.
+func formatVerdict(result *VerifyResult) string {
 	switch result.Level {
 	case TrustAnchored:
 		if result.Anchor != "" {
@@ -244,9 +361,24 @@ func FormatVerifyResult(result *VerifyResult) string {
 	default:
 		var sb strings.Builder
 		sb.WriteString("❌ Certificate chain is broken.\n")
+		if len(result.Violations) > 0 {
+			sb.WriteString("\nBasic constraints violated:")
+			for _, violation := range result.Violations {
+				fmt.Fprintf(&sb, "\n  • %s", violation.Detail)
+			}
+			return sb.String()
+		}
 		if result.Err != nil {
 			fmt.Fprintf(&sb, "\n%v", result.Err)
 		}
+		if missing := result.MissingIssuer; missing != nil {
+			for _, url := range missing.URLs {
+				fmt.Fprintf(&sb, "\n  fetch from: %s", url)
+			}
+			if len(missing.URLs) > 0 {
+				sb.WriteString("\n\nPass --fetch-missing to retrieve it over AIA and verify again.")
+			}
+		}
 		return sb.String()
 	}
 }
@@ -25,8 +25,9 @@ func randomSerial(t *testing.T) *big.Int {
 }
 
 // issue mints a certificate signed by parent, or a self-signed one when parent
-// is nil.
-func issue(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+// is nil. edits, if any, change the template before it is signed.
+func issue(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+	edits ...func(*x509.Certificate)) (*x509.Certificate, *ecdsa.PrivateKey) {
 	t.Helper()
 
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -49,12 +50,21 @@ func issue(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentK
 		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
 		template.DNSNames = []string{cn}
 	}
+	for _, edit := range edits {
+		edit(template)
+	}
+	return sign(t, template, key, parent, parentKey), key
+}
+
+// sign signs template for key by parent, or by key itself when parent is
+// nil.
+func sign(t *testing.T, template *x509.Certificate, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	t.Helper()
 
 	signer, signerKey := template, key
 	if parent != nil {
 		signer, signerKey = parent, parentKey
 	}
-
 	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
 	if err != nil {
 		t.Fatal(err)
@@ -63,7 +73,7 @@ func issue(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentK
 	if err != nil {
 		t.Fatal(err)
 	}
-	return cert, key
+	return cert
 }
 
 // TestVerifyChain_SelfSignedIsNotTrusted is the regression that matters: a lone
@@ -181,6 +191,12 @@ func TestVerifyChain_ExpiredIsBroken(t *testing.T) {
 	if result.Level != TrustBroken {
 		t.Errorf("Level = %v, want %v (an expired leaf is not a valid chain)", result.Level, TrustBroken)
 	}
+	if result.Expired != expired {
+		t.Errorf("Expired = %v, want the leaf", result.Expired)
+	}
+	if result.NotYetValid != nil {
+		t.Errorf("NotYetValid = %v, want nil", result.NotYetValid)
+	}
 }
 
 // TestVerifyChain_HostnameMismatch checks the --host check.
@@ -315,3 +331,61 @@ func TestVerifyChain_BrokenReportsStructuralError(t *testing.T) {
 		t.Errorf("Err = %v, want the expiry reason, not the trust-store error", result.Err)
 	}
 }
+
+func TestParseVerifyTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-03-01 12:30", time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)},
+		{"2026-03-01T12:30:00+09:00", time.Date(2026, 3, 1, 3, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseVerifyTime(tt.in)
+		if err != nil {
+			t.Errorf("ParseVerifyTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseVerifyTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	if _, err := ParseVerifyTime("next tuesday"); err == nil {
+		t.Error("ParseVerifyTime accepted nonsense")
+	}
+}
+
+// TestVerifyChain_AtFutureTime checks the --at use case: a chain that is fine
+// today is broken once its root has expired.
+func TestVerifyChain_AtFutureTime(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "leaf.example", false, root, rootKey)
+	opts := VerifyOptions{ExtraRoots: []*x509.Certificate{root}, SkipSystemRoots: true}
+
+	result, err := VerifyChain([]*x509.Certificate{leaf, root}, opts)
+	if err != nil || result.Level != TrustAnchored {
+		t.Fatalf("chain should verify now: %v %v", result, err)
+	}
+
+	opts.CurrentTime = root.NotAfter.Add(time.Hour)
+	result, err = VerifyChain([]*x509.Certificate{leaf, root}, opts)
+	if err != nil {
+		t.Fatalf("VerifyChain returned an error: %v", err)
+	}
+	if result.Level != TrustBroken {
+		t.Errorf("Level = %v after the root expires, want %v", result.Level, TrustBroken)
+	}
+	if result.Expired == nil {
+		t.Error("Expired is nil, want the first expired certificate on the path")
+	}
+
+	opts.CurrentTime = root.NotBefore.Add(-time.Hour)
+	result, err = VerifyChain([]*x509.Certificate{leaf, root}, opts)
+	if err != nil {
+		t.Fatalf("VerifyChain returned an error: %v", err)
+	}
+	if result.NotYetValid == nil || result.Expired != nil {
+		t.Errorf("before issuance: NotYetValid = %v, Expired = %v", result.NotYetValid, result.Expired)
+	}
+}
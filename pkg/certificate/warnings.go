@@ -0,0 +1,67 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// chainWarnings looks along the path up from the leaf for problems that do
+// not fail verification. The verifier stops at the first error and says
+// nothing about what merely looks wrong; these are what a reviewer would flag.
+func chainWarnings(certs []*x509.Certificate, opts VerifyOptions) []string {
+	if len(certs) == 0 || certs[0] == nil {
+		return nil
+	}
+
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	days := opts.ExpiryWarningDays
+	if days <= 0 {
+		days = defaultExpiryWarningDays
+	}
+
+	var warnings []string
+	for i, cert := range issuerPath(certs[0], certs) {
+		name := displayName(cert)
+		selfSigned := IsSelfSigned(cert)
+
+		// Expired is an error, not a warning; only the run-up counts here.
+		if now.Before(cert.NotAfter) && cert.NotAfter.Before(now.AddDate(0, 0, days)) {
+			left := int(cert.NotAfter.Sub(now).Hours() / 24)
+			warnings = append(warnings, fmt.Sprintf("'%s' expires in %d days (%s)",
+				name, left, FormatDate(cert.NotAfter)))
+		}
+
+		// A root's self-signature is never checked -- it is trusted by
+		// presence -- so SHA-1 there is harmless.
+		if !selfSigned && isSHA1(cert.SignatureAlgorithm) {
+			warnings = append(warnings, fmt.Sprintf("'%s' is signed with %s; SHA-1 signatures are rejected by modern clients",
+				name, cert.SignatureAlgorithm))
+		}
+
+		if i == 0 && ExceedsCABMaxLifetime(cert) {
+			warnings = append(warnings, fmt.Sprintf("'%s' is valid for %d days, longer than the CA/Browser Forum maximum of %d",
+				name, ValidityPeriodDays(cert), CABMaxSubscriberValidityDays))
+		}
+
+		if cert.IsCA && len(cert.SubjectKeyId) == 0 {
+			warnings = append(warnings, fmt.Sprintf("'%s' is a CA without a subject key identifier", name))
+		}
+		if !selfSigned && len(cert.AuthorityKeyId) == 0 {
+			warnings = append(warnings, fmt.Sprintf("'%s' has no authority key identifier", name))
+		}
+	}
+	return warnings
+}
+
+// isSHA1 reports whether the signature algorithm hashes with SHA-1.
+func isSHA1(algorithm x509.SignatureAlgorithm) bool {
+	switch algorithm {
+	case x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+		return true
+	}
+	return false
+}
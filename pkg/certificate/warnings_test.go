@@ -0,0 +1,81 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyChain_Warnings(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Issued by a copy of the root without its key identifier, so the leaf
+	// gets no authority key identifier.
+	anonymous := *root
+	anonymous.SubjectKeyId = nil
+	leaf := generateCertificate(&x509.Certificate{
+		SerialNumber: randomSerial(t),
+		Subject:      pkix.Name{CommonName: "warn.example"},
+		NotBefore:    time.Now().AddDate(-2, 0, 0),
+		NotAfter:     time.Now().AddDate(0, 0, 5),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"warn.example"},
+	}, &anonymous, &key.PublicKey, rootKey)
+
+	result, err := VerifyChain([]*x509.Certificate{leaf, root}, VerifyOptions{ExpiryWarningDays: 10})
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+
+	for _, want := range []string{
+		"'warn.example' expires in",
+		"'warn.example' is valid for",
+		"'warn.example' has no authority key identifier",
+	} {
+		if !containsPrefix(result.Warnings, want) {
+			t.Errorf("no warning starting %q in %q", want, result.Warnings)
+		}
+	}
+	if containsPrefix(result.Warnings, "'Root' has no authority key identifier") {
+		t.Error("a self-signed root was flagged for lacking an authority key identifier")
+	}
+
+	formatted := FormatVerifyResult(result)
+	if !strings.Contains(formatted, "Warnings:") || !strings.Contains(formatted, "• 'warn.example' expires in") {
+		t.Errorf("FormatVerifyResult does not list the warnings:\n%s", formatted)
+	}
+}
+
+func TestVerifyChain_ExpiryWarningWindow(t *testing.T) {
+	root, rootKey := issue(t, "Root", true, nil, nil)
+	leaf, _ := issue(t, "soon.example", false, root, rootKey)
+
+	// Both expire within a day, well inside the default 30-day window that
+	// a zero ExpiryWarningDays falls back to.
+	result, err := VerifyChain([]*x509.Certificate{leaf, root}, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if !containsPrefix(result.Warnings, "'soon.example' expires in 0 days") {
+		t.Errorf("Warnings = %q", result.Warnings)
+	}
+}
+
+func containsPrefix(list []string, prefix string) bool {
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}